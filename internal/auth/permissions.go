@@ -26,39 +26,48 @@ const (
 )
 
 var apiPermissionMap = map[string]StaffPermission{
-	"/api/merchant/orders":           PermOrders,
-	"/api/merchant/reservations":     PermOrders,
-	"/api/merchant/drivers":          PermDriverDashboard,
-	"/api/merchant/pos":              PermOrders,
-	"/api/merchant/customer-display": PermCustomerDisplay,
-	"/api/merchant/menu":             PermMenu,
-	"/api/merchant/menu/stock":       PermMenuStock,
-	"/api/merchant/menu/builder":     PermMenuBuilder,
-	"/api/merchant/menu/bulk":        PermMenu,
-	"/api/merchant/deleted-items":    PermMenu,
-	"/api/merchant/stock-photos":     PermMenu,
-	"/api/merchant/categories":       PermCategories,
-	"/api/merchant/addon-categories": PermAddonCategories,
-	"/api/merchant/addon-items":      PermAddonItems,
-	"/api/merchant/bulk/addon-items": PermAddonItems,
-	"/api/merchant/bulk/menu":        PermMenu,
-	"/api/merchant/menu-books":       PermMenuBooks,
-	"/api/merchant/special-prices":   PermSpecialPrices,
-	"/api/merchant/order-vouchers":   PermOrderVouchers,
-	"/api/merchant/feedback":         PermCustomerFeedback,
-	"/api/merchant/analytics":        PermRevenue,
-	"/api/merchant/reports":          PermReports,
-	"/api/merchant/revenue":          PermRevenue,
-	"/api/merchant/branches":         PermMerchantSettings,
-	"/api/merchant/setup-progress":   PermMerchantSettings,
-	"/api/merchant/main":             PermMerchantSettings,
-	"/api/merchant/vouchers":         PermSubscription,
-	"PUT /api/merchant/profile":      PermMerchantSettings,
-	"/api/merchant/opening-hours":    PermMerchantSettings,
-	"/api/merchant/special-hours":    PermMerchantSettings,
-	"/api/merchant/mode-schedules":   PermMerchantSettings,
-	"/api/merchant/toggle-open":      PermStoreToggleOpen,
-	"/api/merchant/subscription":     PermSubscription,
+	"/api/merchant/orders":            PermOrders,
+	"/api/merchant/reservations":      PermOrders,
+	"/api/merchant/drivers":           PermDriverDashboard,
+	"/api/merchant/driver/":           PermDriverDashboard,
+	"/api/merchant/dispatch-settings": PermMerchantSettings,
+	"/api/merchant/courier-settings":  PermMerchantSettings,
+	"/api/merchant/delivery-batches":  PermOrders,
+	"/api/merchant/pos":               PermOrders,
+	"/api/merchant/customer-display":  PermCustomerDisplay,
+	"/api/merchant/menu":              PermMenu,
+	"/api/merchant/menu/stock":        PermMenuStock,
+	"/api/merchant/menu/builder":      PermMenuBuilder,
+	"/api/merchant/menu/bulk":         PermMenu,
+	"/api/merchant/deleted-items":     PermMenu,
+	"/api/merchant/stock-photos":      PermMenu,
+	"/api/merchant/categories":        PermCategories,
+	"/api/merchant/addon-categories":  PermAddonCategories,
+	"/api/merchant/addon-items":       PermAddonItems,
+	"/api/merchant/bulk/addon-items":  PermAddonItems,
+	"/api/merchant/bulk/menu":         PermMenu,
+	"/api/merchant/menu-books":        PermMenuBooks,
+	"/api/merchant/menu-drafts":       PermMenuBuilder,
+	"/api/merchant/menu-versions":     PermMenuBuilder,
+	"/api/merchant/translations":      PermMenu,
+	"/api/merchant/ingredients":       PermMenuStock,
+	"/api/merchant/special-prices":    PermSpecialPrices,
+	"/api/merchant/upsell-rules":      PermSpecialPrices,
+	"/api/merchant/order-vouchers":    PermOrderVouchers,
+	"/api/merchant/feedback":          PermCustomerFeedback,
+	"/api/merchant/analytics":         PermRevenue,
+	"/api/merchant/reports":           PermReports,
+	"/api/merchant/revenue":           PermRevenue,
+	"/api/merchant/branches":          PermMerchantSettings,
+	"/api/merchant/setup-progress":    PermMerchantSettings,
+	"/api/merchant/main":              PermMerchantSettings,
+	"/api/merchant/vouchers":          PermSubscription,
+	"PUT /api/merchant/profile":       PermMerchantSettings,
+	"/api/merchant/opening-hours":     PermMerchantSettings,
+	"/api/merchant/special-hours":     PermMerchantSettings,
+	"/api/merchant/mode-schedules":    PermMerchantSettings,
+	"/api/merchant/toggle-open":       PermStoreToggleOpen,
+	"/api/merchant/subscription":      PermSubscription,
 }
 
 func AllStaffPermissions() []string {
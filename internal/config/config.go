@@ -26,6 +26,16 @@ type Config struct {
 	WSGroupOrderPollInterval time.Duration
 	NextApiBaseURL           string
 
+	GeocoderProvider    string
+	GeocoderBaseURL     string
+	GeocoderFixturePath string
+	GeocodeCacheTTL     time.Duration
+
+	CourierProviders       []string
+	CourierWebhookSecret   string
+	CourierCallbackBaseURL string
+	CourierMockStep        time.Duration
+
 	ObjectStoreEndpoint        string
 	ObjectStoreRegion          string
 	ObjectStoreAccessKeyID     string
@@ -55,6 +65,16 @@ func Load() Config {
 		WSGroupOrderPollInterval: getEnvDuration("WS_GROUP_ORDER_POLL_INTERVAL", 5*time.Second),
 		NextApiBaseURL:           getEnvFirst([]string{"NEXT_API_BASE_URL", "NEXT_APP_BASE_URL", "NEXT_BASE_URL"}, "http://localhost:3000"),
 
+		GeocoderProvider:    getEnv("GEOCODER_PROVIDER", "nominatim"),
+		GeocoderBaseURL:     getEnv("GEOCODER_BASE_URL", "https://nominatim.openstreetmap.org"),
+		GeocoderFixturePath: getEnv("GEOCODER_FIXTURE_PATH", ""),
+		GeocodeCacheTTL:     getEnvDuration("GEOCODE_CACHE_TTL", 30*24*time.Hour),
+
+		CourierProviders:       splitCSV(getEnv("COURIER_PROVIDERS", "mock")),
+		CourierWebhookSecret:   getEnv("COURIER_WEBHOOK_SECRET", "dev-insecure-courier-secret"),
+		CourierCallbackBaseURL: getEnv("COURIER_CALLBACK_BASE_URL", ""),
+		CourierMockStep:        getEnvDuration("COURIER_MOCK_STEP", 2*time.Minute),
+
 		// Object store (Cloudflare R2 / S3-compatible)
 		ObjectStoreEndpoint:        getEnvFirst([]string{"OBJECT_STORE_ENDPOINT", "R2_S3_ENDPOINT"}, ""),
 		ObjectStoreRegion:          getEnvFirst([]string{"OBJECT_STORE_REGION", "R2_REGION"}, "auto"),
@@ -0,0 +1,152 @@
+package courier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const ProviderMock = "mock"
+
+// Courier statuses as reported by providers. Every provider maps its own
+// vocabulary onto these; all but CANCELLED match the order delivery status.
+const (
+	StatusAssigned  = "ASSIGNED"
+	StatusPickedUp  = "PICKED_UP"
+	StatusArrived   = "ARRIVED"
+	StatusDelivered = "DELIVERED"
+	StatusFailed    = "FAILED"
+	StatusCancelled = "CANCELLED"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown courier provider")
+	ErrInvalidSignature = errors.New("invalid courier webhook signature")
+	ErrInvalidPayload   = errors.New("invalid courier webhook payload")
+	ErrBookingNotFound  = errors.New("courier booking not found")
+	// ErrStatusUnsupported is returned by providers that only report progress
+	// through webhooks and cannot be polled.
+	ErrStatusUnsupported = errors.New("courier status polling not supported")
+)
+
+type Location struct {
+	Lat     float64
+	Lng     float64
+	Address string
+}
+
+type QuoteRequest struct {
+	Pickup     Location
+	Dropoff    Location
+	DistanceKm float64
+}
+
+type Quote struct {
+	Fee            float64
+	PickupMinutes  int
+	DropoffMinutes int
+}
+
+type BookingRequest struct {
+	// Reference is the order number, shown to the courier.
+	Reference     string
+	Pickup        Location
+	Dropoff       Location
+	DistanceKm    float64
+	PickupName    string
+	PickupPhone   string
+	CustomerName  string
+	CustomerPhone string
+	Notes         string
+	// CallbackURL receives status webhooks when the provider supports
+	// per-booking callbacks.
+	CallbackURL string
+}
+
+type Booking struct {
+	ExternalID  string
+	Status      string
+	TrackingURL string
+	Fee         float64
+}
+
+// StatusUpdate is a provider status change, either parsed from a webhook or
+// returned by a status poll.
+type StatusUpdate struct {
+	ExternalID   string
+	Status       string
+	Reason       string
+	CourierName  string
+	CourierPhone string
+	Latitude     *float64
+	Longitude    *float64
+	OccurredAt   time.Time
+}
+
+type Provider interface {
+	Name() string
+	Quote(ctx context.Context, req QuoteRequest) (Quote, error)
+	Book(ctx context.Context, req BookingRequest) (Booking, error)
+	Cancel(ctx context.Context, externalID string) error
+	Status(ctx context.Context, externalID string) (StatusUpdate, error)
+	// ParseWebhook verifies and decodes a status callback.
+	ParseWebhook(header http.Header, body []byte) (StatusUpdate, error)
+}
+
+type Config struct {
+	Providers     []string
+	WebhookSecret string
+	// MockStep is how long the mock courier spends in each status.
+	MockStep time.Duration
+}
+
+// Registry holds the providers enabled for this deployment.
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry builds the providers listed in cfg.Providers.
+func NewRegistry(cfg Config) (*Registry, error) {
+	reg := &Registry{providers: map[string]Provider{}}
+	for _, name := range cfg.Providers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case ProviderMock:
+			reg.providers[name] = NewMock(cfg.WebhookSecret, cfg.MockStep)
+		default:
+			return nil, fmt.Errorf("%w %q", ErrUnknownProvider, name)
+		}
+	}
+	return reg, nil
+}
+
+// Register adds or replaces a provider.
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.providers[strings.ToLower(strings.TrimSpace(name))]
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	if r == nil {
+		return []string{}
+	}
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
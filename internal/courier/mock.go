@@ -0,0 +1,229 @@
+package courier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	MockSignatureHeader = "X-Courier-Signature"
+	// MockFailMarker in the booking notes makes the mock courier fail the
+	// drop-off instead of delivering, to exercise the failure path.
+	MockFailMarker = "mock-fail"
+
+	defaultMockStep   = 2 * time.Minute
+	mockBaseFee       = 5
+	mockPerKmFee      = 1.2
+	mockSpeedKmh      = 25
+	mockCourierName   = "Mock Courier"
+	mockCourierPhone  = "+10000000000"
+	mockFailSuffix    = "-f"
+	mockExternalIDPre = "mock_"
+)
+
+// Mock is a courier that needs no network. A booking moves through
+// ASSIGNED, PICKED_UP and ARRIVED, spending MockStep in each, and is then
+// DELIVERED (or FAILED when the notes carry MockFailMarker). The booking time
+// is encoded in the external ID, so progress survives a restart; only
+// cancellations are kept in memory.
+type Mock struct {
+	secret []byte
+	step   time.Duration
+	now    func() time.Time
+	seq    atomic.Int64
+
+	mu        sync.Mutex
+	cancelled map[string]time.Time
+}
+
+func NewMock(webhookSecret string, step time.Duration) *Mock {
+	if step <= 0 {
+		step = defaultMockStep
+	}
+	return &Mock{
+		secret:    []byte(webhookSecret),
+		step:      step,
+		now:       time.Now,
+		cancelled: map[string]time.Time{},
+	}
+}
+
+func (m *Mock) Name() string {
+	return ProviderMock
+}
+
+func (m *Mock) Quote(_ context.Context, req QuoteRequest) (Quote, error) {
+	fee := math.Round((mockBaseFee+mockPerKmFee*req.DistanceKm)*100) / 100
+	travel := int(math.Ceil(req.DistanceKm / mockSpeedKmh * 60))
+	pickup := int(math.Ceil(m.step.Minutes()))
+	return Quote{Fee: fee, PickupMinutes: pickup, DropoffMinutes: pickup + travel}, nil
+}
+
+func (m *Mock) Book(ctx context.Context, req BookingRequest) (Booking, error) {
+	quote, _ := m.Quote(ctx, QuoteRequest{Pickup: req.Pickup, Dropoff: req.Dropoff, DistanceKm: req.DistanceKm})
+	id := fmt.Sprintf("%s%d_%d", mockExternalIDPre, m.now().UnixMilli(), m.seq.Add(1))
+	if strings.Contains(strings.ToLower(req.Notes), MockFailMarker) {
+		id += mockFailSuffix
+	}
+	return Booking{
+		ExternalID:  id,
+		Status:      StatusAssigned,
+		TrackingURL: "https://courier.invalid/track/" + id,
+		Fee:         quote.Fee,
+	}, nil
+}
+
+func (m *Mock) Cancel(_ context.Context, externalID string) error {
+	if _, _, ok := parseMockExternalID(externalID); !ok {
+		return ErrBookingNotFound
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.cancelled[externalID]; !ok {
+		m.cancelled[externalID] = m.now()
+	}
+	return nil
+}
+
+func (m *Mock) Status(_ context.Context, externalID string) (StatusUpdate, error) {
+	bookedAt, fail, ok := parseMockExternalID(externalID)
+	if !ok {
+		return StatusUpdate{}, ErrBookingNotFound
+	}
+
+	m.mu.Lock()
+	cancelledAt, cancelled := m.cancelled[externalID]
+	m.mu.Unlock()
+	if cancelled {
+		return StatusUpdate{ExternalID: externalID, Status: StatusCancelled, Reason: "Cancelled by merchant", OccurredAt: cancelledAt}, nil
+	}
+
+	update := StatusUpdate{ExternalID: externalID, CourierName: mockCourierName, CourierPhone: mockCourierPhone}
+	steps := int(m.now().Sub(bookedAt) / m.step)
+	switch {
+	case steps < 1:
+		update.Status = StatusAssigned
+	case steps < 2:
+		update.Status = StatusPickedUp
+	case steps < 3:
+		update.Status = StatusArrived
+	case fail:
+		update.Status = StatusFailed
+		update.Reason = "Customer unavailable"
+	default:
+		update.Status = StatusDelivered
+	}
+	if steps > 3 {
+		steps = 3
+	}
+	update.OccurredAt = bookedAt.Add(time.Duration(steps) * m.step)
+	return update, nil
+}
+
+type mockWebhookPayload struct {
+	ExternalID   string    `json:"externalId"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+	CourierName  string    `json:"courierName,omitempty"`
+	CourierPhone string    `json:"courierPhone,omitempty"`
+	Latitude     *float64  `json:"latitude,omitempty"`
+	Longitude    *float64  `json:"longitude,omitempty"`
+	OccurredAt   time.Time `json:"occurredAt"`
+}
+
+// ParseWebhook expects a JSON body signed with HMAC-SHA256 of the webhook
+// secret, sent hex-encoded in X-Courier-Signature as "sha256=<hex>".
+func (m *Mock) ParseWebhook(header http.Header, body []byte) (StatusUpdate, error) {
+	if !m.validSignature(header.Get(MockSignatureHeader), body) {
+		return StatusUpdate{}, ErrInvalidSignature
+	}
+	var payload mockWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return StatusUpdate{}, ErrInvalidPayload
+	}
+	status := strings.ToUpper(strings.TrimSpace(payload.Status))
+	switch status {
+	case StatusAssigned, StatusPickedUp, StatusArrived, StatusDelivered, StatusFailed, StatusCancelled:
+	default:
+		return StatusUpdate{}, ErrInvalidPayload
+	}
+	if strings.TrimSpace(payload.ExternalID) == "" {
+		return StatusUpdate{}, ErrInvalidPayload
+	}
+	occurredAt := payload.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = m.now()
+	}
+	return StatusUpdate{
+		ExternalID:   payload.ExternalID,
+		Status:       status,
+		Reason:       payload.Reason,
+		CourierName:  payload.CourierName,
+		CourierPhone: payload.CourierPhone,
+		Latitude:     payload.Latitude,
+		Longitude:    payload.Longitude,
+		OccurredAt:   occurredAt,
+	}, nil
+}
+
+// SignedWebhook encodes update the way ParseWebhook expects it, for tests and
+// staging scripts that drive the webhook endpoint.
+func (m *Mock) SignedWebhook(update StatusUpdate) ([]byte, string, error) {
+	body, err := json.Marshal(mockWebhookPayload{
+		ExternalID:   update.ExternalID,
+		Status:       update.Status,
+		Reason:       update.Reason,
+		CourierName:  update.CourierName,
+		CourierPhone: update.CourierPhone,
+		Latitude:     update.Latitude,
+		Longitude:    update.Longitude,
+		OccurredAt:   update.OccurredAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, "sha256=" + m.sign(body), nil
+}
+
+func (m *Mock) sign(body []byte) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Mock) validSignature(signature string, body []byte) bool {
+	if len(m.secret) == 0 {
+		return false
+	}
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	expected := m.sign(body)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func parseMockExternalID(id string) (time.Time, bool, bool) {
+	rest, ok := strings.CutPrefix(id, mockExternalIDPre)
+	if !ok {
+		return time.Time{}, false, false
+	}
+	rest, fail := strings.CutSuffix(rest, mockFailSuffix)
+	msPart, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return time.Time{}, false, false
+	}
+	ms, err := strconv.ParseInt(msPart, 10, 64)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return time.UnixMilli(ms), fail, true
+}
@@ -0,0 +1,84 @@
+package courier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMockProgression(t *testing.T) {
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+	mock := NewMock("secret", time.Minute)
+	mock.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	booking, err := mock.Book(ctx, BookingRequest{Reference: "ORD-1", DistanceKm: 5})
+	if err != nil || booking.Status != StatusAssigned || booking.Fee != 11 {
+		t.Fatalf("unexpected booking %+v (%v)", booking, err)
+	}
+	failing, _ := mock.Book(ctx, BookingRequest{Reference: "ORD-2", Notes: "please " + MockFailMarker})
+
+	tests := []struct {
+		elapsed time.Duration
+		want    string
+		failing string
+	}{
+		{0, StatusAssigned, StatusAssigned},
+		{90 * time.Second, StatusPickedUp, StatusPickedUp},
+		{150 * time.Second, StatusArrived, StatusArrived},
+		{10 * time.Minute, StatusDelivered, StatusFailed},
+	}
+	for _, tt := range tests {
+		now = start.Add(tt.elapsed)
+		got, err := mock.Status(ctx, booking.ExternalID)
+		if err != nil || got.Status != tt.want {
+			t.Fatalf("after %s: expected %s, got %+v (%v)", tt.elapsed, tt.want, got, err)
+		}
+		got, _ = mock.Status(ctx, failing.ExternalID)
+		if got.Status != tt.failing {
+			t.Fatalf("after %s: expected failing booking %s, got %s", tt.elapsed, tt.failing, got.Status)
+		}
+	}
+	if got, _ := mock.Status(ctx, booking.ExternalID); !got.OccurredAt.Equal(start.Add(3 * time.Minute)) {
+		t.Fatalf("expected delivery to be stamped at the third step, got %s", got.OccurredAt)
+	}
+
+	if err := mock.Cancel(ctx, failing.ExternalID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got, _ := mock.Status(ctx, failing.ExternalID); got.Status != StatusCancelled {
+		t.Fatalf("expected cancelled booking, got %s", got.Status)
+	}
+	if _, err := mock.Status(ctx, "other_123"); !errors.Is(err, ErrBookingNotFound) {
+		t.Fatalf("expected unknown booking error, got %v", err)
+	}
+}
+
+func TestMockWebhookSignature(t *testing.T) {
+	mock := NewMock("secret", time.Minute)
+	body, signature, err := mock.SignedWebhook(StatusUpdate{ExternalID: "mock_1_1", Status: StatusPickedUp})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set(MockSignatureHeader, signature)
+	update, err := mock.ParseWebhook(header, body)
+	if err != nil || update.Status != StatusPickedUp || update.ExternalID != "mock_1_1" {
+		t.Fatalf("unexpected update %+v (%v)", update, err)
+	}
+
+	header.Set(MockSignatureHeader, "sha256=00")
+	if _, err := mock.ParseWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+
+	unsigned := NewMock("", time.Minute)
+	header.Set(MockSignatureHeader, signature)
+	if _, err := unsigned.ParseWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected webhooks to be rejected without a secret, got %v", err)
+	}
+}
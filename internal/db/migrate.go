@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the pg_advisory_lock key used so that only one replica
+// applies migrations at a time.
+const migrationLockKey int64 = 7261530041
+
+// migrationLockPollInterval is how often a replica retries the migration lock.
+// Replicas poll pg_try_advisory_lock instead of blocking in pg_advisory_lock so
+// that a waiting replica never holds an open statement snapshot, which
+// create index concurrently on the lock holder would otherwise wait on.
+const migrationLockPollInterval = time.Second
+
+// noTransactionDirective marks a migration whose statements must run outside a
+// transaction, such as create index concurrently on core tables that are
+// written while the service starts.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// Migrate applies the schema additions owned by this service. The core schema
+// is managed by genfity-order-main; files under migrations/ only add tables,
+// columns and indexes the Go service needs. Each file runs once inside its own
+// transaction and is recorded in go_schema_migrations. Files starting with
+// noTransactionDirective run statement by statement outside a transaction and
+// are recorded once every statement has succeeded, so they must be safe to
+// re-run after a partial failure.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if err := acquireMigrationLock(ctx, conn); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), `select pg_advisory_unlock($1)`, migrationLockKey)
+	}()
+
+	if _, err := conn.Exec(ctx, `
+		create table if not exists go_schema_migrations (
+			name text primary key,
+			applied_at timestamptz not null default now()
+		)
+	`); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, path := range names {
+		name := strings.TrimPrefix(path, "migrations/")
+
+		var applied bool
+		if err := conn.QueryRow(ctx, `select exists(select 1 from go_schema_migrations where name = $1)`, name).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		body, err := migrationFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(string(body), noTransactionDirective) {
+			if err := runWithoutTransaction(ctx, conn, string(body)); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			if _, err := conn.Exec(ctx, `insert into go_schema_migrations (name) values ($1)`, name); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			continue
+		}
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, string(body)); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+		if _, err := tx.Exec(ctx, `insert into go_schema_migrations (name) values ($1)`, name); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func acquireMigrationLock(ctx context.Context, conn *pgxpool.Conn) error {
+	for {
+		var locked bool
+		if err := conn.QueryRow(ctx, `select pg_try_advisory_lock($1)`, migrationLockKey).Scan(&locked); err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+// runWithoutTransaction executes each statement of body on its own, because
+// several statements sent together run in one implicit transaction. Statements
+// are split on semicolons at the end of a line, so these files must not use
+// function bodies or other multi-line literals containing such semicolons.
+func runWithoutTransaction(ctx context.Context, conn *pgxpool.Conn, body string) error {
+	for _, stmt := range splitStatements(body) {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitStatements(body string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
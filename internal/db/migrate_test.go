@@ -0,0 +1,42 @@
+package db
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	body := "-- migrate:no-transaction\n" +
+		"-- comment\n" +
+		"drop index concurrently if exists a_idx;\n" +
+		"create index concurrently if not exists a_idx\n" +
+		"\ton a (b, c desc);\n" +
+		"\n" +
+		"select 1"
+	want := []string{
+		"drop index concurrently if exists a_idx;",
+		"create index concurrently if not exists a_idx\n\ton a (b, c desc);",
+		"select 1",
+	}
+	if got := splitStatements(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements: expected %q, got %q", want, got)
+	}
+}
+
+func TestConcurrentIndexesRunWithoutTransaction(t *testing.T) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range names {
+		body, err := migrationFiles.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(body), "concurrently") && !strings.HasPrefix(string(body), noTransactionDirective) {
+			t.Errorf("%s builds indexes concurrently but is not marked %q", path, noTransactionDirective)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+-- migrate:no-transaction
+-- Keyset pagination for merchant order lists, POS history and balance transactions.
+-- orders and balance_transactions are written continuously, so the indexes are
+-- built concurrently instead of holding a write lock for the whole build. A
+-- failed concurrent build leaves an invalid index behind, which "if not exists"
+-- would then skip, so any leftover from an earlier attempt is dropped first.
+drop index concurrently if exists orders_merchant_placed_at_id_idx;
+create index concurrently if not exists orders_merchant_placed_at_id_idx
+	on orders (merchant_id, placed_at desc, id desc);
+
+drop index concurrently if exists balance_transactions_balance_created_at_id_idx;
+create index concurrently if not exists balance_transactions_balance_created_at_id_idx
+	on balance_transactions (balance_id, created_at desc, id desc);
@@ -0,0 +1,35 @@
+-- Driver app: delivery progress states, proof of delivery and an audit trail.
+-- The "DeliveryStatus" enum and orders belong to the core schema, so the
+-- finer-grained progress (PICKED_UP, ARRIVED, FAILED) lives in a side table
+-- while orders.delivery_status stays ASSIGNED until the order is DELIVERED.
+create table if not exists order_delivery_progress (
+	order_id bigint primary key references orders(id) on delete cascade,
+	merchant_id bigint not null,
+	status text not null,
+	picked_up_at timestamp(3),
+	arrived_at timestamp(3),
+	failed_at timestamp(3),
+	failure_reason text,
+	proof_url text,
+	proof_meta jsonb,
+	updated_at timestamp(3) not null default now()
+);
+
+create table if not exists order_delivery_events (
+	id bigserial primary key,
+	order_id bigint not null references orders(id) on delete cascade,
+	merchant_id bigint not null,
+	driver_user_id bigint,
+	delivery_status text not null,
+	reason text,
+	latitude numeric(10, 7),
+	longitude numeric(10, 7),
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists order_delivery_events_order_idx
+	on order_delivery_events (order_id, created_at);
+
+create index if not exists orders_driver_delivery_idx
+	on orders (delivery_driver_user_id, merchant_id)
+	where delivery_driver_user_id is not null;
@@ -0,0 +1,36 @@
+-- Driver availability and automatic dispatch offers.
+create table if not exists driver_availability (
+	merchant_id bigint not null,
+	user_id bigint not null,
+	status text not null default 'OFFLINE',
+	latitude numeric(10, 7),
+	longitude numeric(10, 7),
+	location_updated_at timestamp(3),
+	last_offered_at timestamp(3),
+	updated_at timestamp(3) not null default now(),
+	primary key (merchant_id, user_id)
+);
+
+create table if not exists order_dispatch_attempts (
+	id bigserial primary key,
+	order_id bigint not null references orders(id) on delete cascade,
+	merchant_id bigint not null,
+	driver_user_id bigint not null,
+	strategy text not null,
+	distance_km numeric(10, 3),
+	status text not null default 'OFFERED',
+	reason text,
+	offered_at timestamp(3) not null default now(),
+	expires_at timestamp(3) not null,
+	responded_at timestamp(3)
+);
+
+create index if not exists order_dispatch_attempts_order_idx
+	on order_dispatch_attempts (order_id, offered_at);
+
+create index if not exists order_dispatch_attempts_driver_idx
+	on order_dispatch_attempts (driver_user_id, status);
+
+create index if not exists order_dispatch_attempts_open_idx
+	on order_dispatch_attempts (expires_at)
+	where status = 'OFFERED';
@@ -0,0 +1,29 @@
+-- Multi-drop delivery batches: one driver, several orders, a planned route.
+create table if not exists delivery_batches (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	driver_user_id bigint,
+	status text not null default 'PLANNED',
+	origin_latitude numeric(10, 7) not null,
+	origin_longitude numeric(10, 7) not null,
+	total_distance_km numeric(10, 3) not null default 0,
+	created_by_user_id bigint,
+	assigned_at timestamp(3),
+	completed_at timestamp(3),
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists delivery_batches_merchant_idx
+	on delivery_batches (merchant_id, status, created_at desc);
+
+create table if not exists delivery_batch_stops (
+	batch_id bigint not null references delivery_batches(id) on delete cascade,
+	order_id bigint not null references orders(id) on delete cascade,
+	position integer not null,
+	cumulative_distance_km numeric(10, 3) not null,
+	primary key (batch_id, order_id)
+);
+
+create unique index if not exists delivery_batch_stops_order_idx
+	on delivery_batch_stops (order_id);
@@ -0,0 +1,11 @@
+-- Shared geocoding cache so results survive deploys and are reused by every replica.
+create table if not exists geocode_cache (
+	cache_key text primary key,
+	provider text not null,
+	payload jsonb not null,
+	expires_at timestamp(3) not null,
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists geocode_cache_expires_idx
+	on geocode_cache (expires_at);
@@ -0,0 +1,30 @@
+-- Third-party courier bookings. An order has at most one active booking; the
+-- delivery itself is still tracked on orders.delivery_status and
+-- order_delivery_progress.
+create table if not exists courier_deliveries (
+	id bigserial primary key,
+	order_id bigint not null references orders(id) on delete cascade,
+	merchant_id bigint not null,
+	provider text not null,
+	external_id text not null,
+	status text not null default 'ASSIGNED',
+	fee_amount numeric(10, 2),
+	tracking_url text,
+	courier_name text,
+	courier_phone text,
+	booked_by_user_id bigint,
+	last_status_at timestamp(3),
+	cancelled_at timestamp(3),
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create unique index if not exists courier_deliveries_external_idx
+	on courier_deliveries (provider, external_id);
+
+create unique index if not exists courier_deliveries_active_order_idx
+	on courier_deliveries (order_id)
+	where status in ('ASSIGNED', 'PICKED_UP', 'ARRIVED');
+
+create index if not exists courier_deliveries_merchant_idx
+	on courier_deliveries (merchant_id, created_at desc);
@@ -0,0 +1,18 @@
+-- Curbside TAKEAWAY pickups: the customer's vehicle, their "I've arrived"
+-- check-in and the handover, with the wait in between kept for reporting.
+create table if not exists order_curbside_pickups (
+	order_id bigint primary key references orders(id) on delete cascade,
+	merchant_id bigint not null,
+	vehicle_description text not null,
+	parking_spot text,
+	arrival_note text,
+	arrived_at timestamp(3),
+	handed_over_at timestamp(3),
+	handed_over_by_user_id bigint,
+	wait_seconds integer,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists order_curbside_pickups_merchant_idx
+	on order_curbside_pickups (merchant_id, handed_over_at);
@@ -0,0 +1,44 @@
+-- Published menu snapshots. Every publish or rollback appends a version; live
+-- edits made outside drafts are captured as a LIVE version before the next
+-- publish so they can be rolled back to as well.
+create table if not exists menu_versions (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	version_number integer not null,
+	source text not null,
+	snapshot jsonb not null,
+	note text,
+	draft_id bigint,
+	rolled_back_from_version_id bigint,
+	created_by_user_id bigint,
+	created_at timestamp(3) not null default now()
+);
+
+create unique index if not exists menu_versions_merchant_number_idx
+	on menu_versions (merchant_id, version_number);
+
+-- Staged catalog edits. The snapshot is a full catalog document that is only
+-- applied to the live tables when the draft is published.
+create table if not exists menu_drafts (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	name text not null,
+	snapshot jsonb not null,
+	base_version_id bigint,
+	status text not null default 'DRAFT',
+	scheduled_publish_at timestamp(3),
+	published_at timestamp(3),
+	published_version_id bigint,
+	last_error text,
+	created_by_user_id bigint,
+	updated_by_user_id bigint,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists menu_drafts_merchant_idx
+	on menu_drafts (merchant_id, updated_at desc);
+
+create index if not exists menu_drafts_scheduled_idx
+	on menu_drafts (scheduled_publish_at)
+	where status = 'SCHEDULED';
@@ -0,0 +1,57 @@
+-- Priced variants (sizes, temperatures) of a menu item. Each variant carries
+-- its own price, SKU and stock; when a menu has active variants, order items
+-- must pick one.
+create table if not exists menu_variants (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	menu_id bigint not null,
+	name text not null,
+	sku text,
+	price numeric(10, 2) not null,
+	display_order integer not null default 0,
+	is_active boolean not null default true,
+	track_stock boolean not null default false,
+	stock_qty integer,
+	daily_stock_template integer,
+	auto_reset_stock boolean not null default false,
+	last_stock_reset_at timestamp(3),
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now(),
+	deleted_at timestamp(3)
+);
+
+create index if not exists menu_variants_menu_idx
+	on menu_variants (menu_id, display_order)
+	where deleted_at is null;
+
+create unique index if not exists menu_variants_merchant_sku_idx
+	on menu_variants (merchant_id, lower(sku))
+	where deleted_at is null and sku is not null;
+
+-- Variant promo prices and the variant picked by an order item live in side
+-- tables: special_price_items and order_items belong to the core schema.
+-- Special price items keep applying to the menu's base price only.
+create table if not exists special_price_variant_items (
+	id bigserial primary key,
+	special_price_id bigint not null references special_prices(id) on delete cascade,
+	menu_id bigint not null,
+	variant_id bigint not null,
+	promo_price numeric(10, 2) not null,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists special_price_variant_items_variant_idx
+	on special_price_variant_items (variant_id);
+
+create index if not exists special_price_variant_items_price_idx
+	on special_price_variant_items (special_price_id);
+
+create table if not exists order_item_variants (
+	order_item_id bigint primary key references order_items(id) on delete cascade,
+	variant_id bigint not null,
+	variant_name text not null
+);
+
+create index if not exists order_item_variants_variant_idx
+	on order_item_variants (variant_id);
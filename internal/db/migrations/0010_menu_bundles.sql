@@ -0,0 +1,62 @@
+-- Bundle (combo) menus: a regular menu row priced as a whole, made of slots
+-- the customer fills by picking among eligible menus or variants. The bundle
+-- flag and revenue allocation sit beside menus, which is a core table.
+create table if not exists menu_bundle_settings (
+	menu_id bigint primary key references menus(id) on delete cascade,
+	merchant_id bigint not null,
+	is_bundle boolean not null default false,
+	revenue_allocation text not null default 'LIST_PRICE',
+	updated_at timestamp(3) not null default now()
+);
+
+create table if not exists menu_bundle_slots (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	bundle_menu_id bigint not null,
+	name text not null,
+	min_selection integer not null default 1,
+	max_selection integer not null default 1,
+	display_order integer not null default 0,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists menu_bundle_slots_bundle_idx
+	on menu_bundle_slots (bundle_menu_id, display_order);
+
+-- An option without a variant lets the customer pick any active variant of
+-- the menu; with one it is fixed to that variant.
+create table if not exists menu_bundle_slot_options (
+	id bigserial primary key,
+	slot_id bigint not null references menu_bundle_slots (id) on delete cascade,
+	menu_id bigint not null,
+	variant_id bigint,
+	upcharge numeric(10, 2) not null default 0,
+	display_order integer not null default 0
+);
+
+create index if not exists menu_bundle_slot_options_slot_idx
+	on menu_bundle_slot_options (slot_id, display_order);
+
+-- Components chosen for a bundle order item, per bundle unit. Names and
+-- prices are snapshots so tickets and analytics survive menu edits.
+create table if not exists order_item_components (
+	id bigserial primary key,
+	order_item_id bigint not null,
+	slot_id bigint,
+	slot_name text not null,
+	menu_id bigint not null,
+	menu_name text not null,
+	variant_id bigint,
+	variant_name text,
+	quantity integer not null default 1,
+	upcharge numeric(10, 2) not null default 0,
+	list_price numeric(10, 2) not null default 0,
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists order_item_components_item_idx
+	on order_item_components (order_item_id);
+
+create index if not exists order_item_components_menu_idx
+	on order_item_components (menu_id);
@@ -0,0 +1,32 @@
+-- Allergen, dietary and nutrition attributes for menus and addon items.
+-- Allergens and dietary tags hold codes from the fixed taxonomy in
+-- handlers/menu_dietary.go. menus and addon_items are core tables, so the
+-- attributes live in one side table per owner.
+create table if not exists menu_dietary_attributes (
+	menu_id bigint primary key references menus(id) on delete cascade,
+	merchant_id bigint not null,
+	allergens text[] not null default '{}',
+	dietary_tags text[] not null default '{}',
+	spicy_level smallint,
+	calories integer,
+	nutrition jsonb,
+	updated_at timestamp(3) not null default now(),
+	updated_by_user_id bigint
+);
+
+create table if not exists addon_item_dietary_attributes (
+	addon_item_id bigint primary key references addon_items(id) on delete cascade,
+	merchant_id bigint not null,
+	allergens text[] not null default '{}',
+	dietary_tags text[] not null default '{}',
+	spicy_level smallint,
+	calories integer,
+	nutrition jsonb,
+	updated_at timestamp(3) not null default now(),
+	updated_by_user_id bigint
+);
+
+create index if not exists menu_dietary_attributes_allergens_idx
+	on menu_dietary_attributes using gin (allergens);
+create index if not exists menu_dietary_attributes_tags_idx
+	on menu_dietary_attributes using gin (dietary_tags);
@@ -0,0 +1,20 @@
+-- Translated text for menus, categories, addons and merchant content. The
+-- source text stays on the entity itself and is the last fallback.
+create table if not exists content_translations (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	entity_type text not null,
+	entity_id bigint not null,
+	field text not null,
+	locale text not null,
+	value text not null,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now(),
+	updated_by_user_id bigint
+);
+
+create unique index if not exists content_translations_key_idx
+	on content_translations (merchant_id, entity_type, entity_id, field, locale);
+
+create index if not exists content_translations_locale_idx
+	on content_translations (merchant_id, locale);
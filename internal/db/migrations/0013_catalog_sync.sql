@@ -0,0 +1,38 @@
+-- Catalog sync from a main merchant to its branches. A link ties a main
+-- entity to its copy in a branch so later syncs update the copy. The synced
+-- price and availability record what the last sync wrote; when the branch
+-- value differs from them the branch has overridden it and syncs keep it.
+create table if not exists catalog_sync_links (
+	id bigserial primary key,
+	main_merchant_id bigint not null,
+	branch_merchant_id bigint not null,
+	entity_type text not null,
+	source_id bigint not null,
+	target_id bigint not null,
+	synced_price numeric(10, 2),
+	synced_is_active boolean,
+	last_synced_at timestamp(3) not null default now(),
+	created_at timestamp(3) not null default now()
+);
+
+create unique index if not exists catalog_sync_links_source_idx
+	on catalog_sync_links (branch_merchant_id, entity_type, source_id);
+
+create index if not exists catalog_sync_links_main_idx
+	on catalog_sync_links (main_merchant_id, branch_merchant_id);
+
+create table if not exists catalog_sync_runs (
+	id bigserial primary key,
+	main_merchant_id bigint not null,
+	branch_merchant_id bigint not null,
+	status text not null,
+	selection jsonb not null,
+	summary jsonb not null,
+	actions jsonb not null,
+	error_message text,
+	created_by_user_id bigint,
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists catalog_sync_runs_main_idx
+	on catalog_sync_runs (main_merchant_id, created_at desc);
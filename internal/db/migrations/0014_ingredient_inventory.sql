@@ -0,0 +1,80 @@
+-- Ingredient inventory. Recipes tie menus, variants and addon items to
+-- ingredient quantities; accepted orders deplete them and refunds restore
+-- them. Every change to on_hand is recorded in ingredient_movements, and the
+-- ORDER and REFUND movements of an order net to what it currently consumes.
+create table if not exists ingredients (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	name text not null,
+	unit text not null,
+	on_hand numeric(14, 3) not null default 0,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now(),
+	created_by_user_id bigint,
+	updated_by_user_id bigint,
+	deleted_at timestamp(3),
+	deleted_by_user_id bigint
+);
+
+create unique index if not exists ingredients_merchant_name_idx
+	on ingredients (merchant_id, lower(name)) where deleted_at is null;
+
+create table if not exists recipe_lines (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	ingredient_id bigint not null references ingredients (id) on delete cascade,
+	menu_id bigint,
+	variant_id bigint,
+	addon_item_id bigint,
+	quantity numeric(12, 3) not null,
+	is_optional boolean not null default false,
+	created_at timestamp(3) not null default now(),
+	check (num_nonnulls(menu_id, variant_id, addon_item_id) = 1)
+);
+
+create index if not exists recipe_lines_menu_idx on recipe_lines (menu_id) where menu_id is not null;
+create index if not exists recipe_lines_variant_idx on recipe_lines (variant_id) where variant_id is not null;
+create index if not exists recipe_lines_addon_item_idx on recipe_lines (addon_item_id) where addon_item_id is not null;
+create index if not exists recipe_lines_ingredient_idx on recipe_lines (ingredient_id);
+
+create table if not exists ingredient_movements (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	ingredient_id bigint not null references ingredients (id) on delete cascade,
+	movement_type text not null,
+	quantity numeric(14, 3) not null,
+	balance_after numeric(14, 3) not null,
+	reason text,
+	order_id bigint,
+	created_by_user_id bigint,
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists ingredient_movements_ingredient_idx
+	on ingredient_movements (ingredient_id, created_at desc);
+create index if not exists ingredient_movements_merchant_idx
+	on ingredient_movements (merchant_id, created_at desc);
+create index if not exists ingredient_movements_order_idx
+	on ingredient_movements (order_id) where order_id is not null;
+
+-- Orders whose ingredients have been depleted. orders is a core table.
+create table if not exists order_ingredient_depletions (
+	order_id bigint primary key references orders(id) on delete cascade,
+	depleted_at timestamp(3) not null default now()
+);
+
+-- Menus and addon items switched off because a required ingredient ran out,
+-- so they are switched back on when the ingredient is restocked. Variants
+-- are this service's own table and carry the flag themselves.
+create table if not exists ingredient_disabled_items (
+	item_type text not null,
+	item_id bigint not null,
+	merchant_id bigint not null,
+	created_at timestamp(3) not null default now(),
+	primary key (item_type, item_id)
+);
+
+create index if not exists ingredient_disabled_items_merchant_idx
+	on ingredient_disabled_items (merchant_id, item_type);
+
+alter table menu_variants add column if not exists disabled_by_ingredients boolean not null default false;
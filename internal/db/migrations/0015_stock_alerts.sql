@@ -0,0 +1,28 @@
+-- Low-stock and sold-out alerts. Menus and addon items keep their own
+-- low_stock_threshold; merchants.default_low_stock_threshold covers the rest
+-- (variants included). stock_alert_states remembers the last level seen per
+-- item so an event is emitted only when an item crosses into a worse level,
+-- and the last level notified so the worker can apply the cool-down, which
+-- is kept per merchant in stock_alert_settings (60 minutes when unset).
+create table if not exists stock_alert_settings (
+	merchant_id bigint primary key references merchants(id) on delete cascade,
+	cooldown_minutes integer not null default 60,
+	updated_at timestamp(3) not null default now()
+);
+
+create table if not exists stock_alert_states (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	item_type text not null,
+	item_id bigint not null,
+	level text not null,
+	notified_level text,
+	notified_at timestamp(3),
+	updated_at timestamp(3) not null default now()
+);
+
+create unique index if not exists stock_alert_states_item_idx
+	on stock_alert_states (item_type, item_id);
+
+create index if not exists stock_alert_states_merchant_idx
+	on stock_alert_states (merchant_id, level);
@@ -0,0 +1,24 @@
+-- Scheduled daily stock reset. A merchant with a stock_reset_schedules
+-- reset_time ("HH:MM", merchant-local) has its auto-reset menus, variants and
+-- addon items restored to their daily template once per local day. The
+-- last_reset_on claim keeps replicas from running the same day twice. Every
+-- reset, scheduled or manual, is logged with before/after quantities.
+create table if not exists stock_reset_schedules (
+	merchant_id bigint primary key references merchants(id) on delete cascade,
+	reset_time text,
+	last_reset_on date,
+	updated_at timestamp(3) not null default now()
+);
+
+create table if not exists stock_reset_runs (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	trigger text not null,
+	item_count integer not null,
+	items jsonb not null,
+	created_by_user_id bigint,
+	created_at timestamp(3) not null default now()
+);
+
+create index if not exists stock_reset_runs_merchant_idx
+	on stock_reset_runs (merchant_id, id desc);
@@ -0,0 +1,74 @@
+-- Per-merchant version of everything the public menu endpoints return. It is
+-- bumped by triggers rather than by the handlers because menu, category,
+-- addon, special-price and stock writes are spread across many code paths
+-- (and the Next.js app writes the same tables). Each bump notifies
+-- menu_cache_updates with "<merchantId>:<version>" so every replica can drop
+-- its cached responses for that merchant.
+create table if not exists merchant_menu_versions (
+	merchant_id bigint primary key,
+	version bigint not null default 1,
+	updated_at timestamp(3) not null default now()
+);
+
+create or replace function bump_menu_cache_version() returns trigger
+language plpgsql as $$
+declare
+	rec record;
+	target bigint;
+	next_version bigint;
+begin
+	if tg_op = 'DELETE' then
+		rec := old;
+	else
+		rec := new;
+	end if;
+
+	case tg_table_name
+		when 'merchants' then
+			target := rec.id;
+		when 'menu_category_items', 'menu_addon_categories' then
+			select merchant_id into target from menus where id = rec.menu_id;
+		when 'addon_items' then
+			select merchant_id into target from addon_categories where id = rec.addon_category_id;
+		when 'special_price_items', 'special_price_variant_items' then
+			select merchant_id into target from special_prices where id = rec.special_price_id;
+		when 'menu_bundle_slot_options' then
+			select merchant_id into target from menu_bundle_slots where id = rec.slot_id;
+		else
+			target := rec.merchant_id;
+	end case;
+
+	if target is null then
+		return null;
+	end if;
+
+	insert into merchant_menu_versions (merchant_id, version, updated_at)
+	values (target, 1, now())
+	on conflict (merchant_id) do update
+	set version = merchant_menu_versions.version + 1, updated_at = now()
+	returning version into next_version;
+
+	perform pg_notify('menu_cache_updates', target::text || ':' || next_version::text);
+	return null;
+end;
+$$;
+
+do $$
+declare
+	t text;
+begin
+	foreach t in array array[
+		'merchants', 'merchant_opening_hours', 'merchant_payment_settings', 'merchant_payment_accounts',
+		'menus', 'menu_categories', 'menu_category_items', 'menu_addon_categories',
+		'addon_categories', 'addon_items', 'special_prices', 'special_price_items',
+		'special_price_variant_items', 'menu_variants', 'menu_bundle_settings', 'menu_bundle_slots',
+		'menu_bundle_slot_options', 'menu_dietary_attributes', 'addon_item_dietary_attributes',
+		'content_translations'
+	] loop
+		execute format('drop trigger if exists menu_cache_version_bump on %I', t);
+		execute format(
+			'create trigger menu_cache_version_bump after insert or update or delete on %I '
+			'for each row execute function bump_menu_cache_version()', t);
+	end loop;
+end;
+$$;
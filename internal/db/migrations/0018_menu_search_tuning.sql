@@ -0,0 +1,45 @@
+-- Merchant search tuning. menu_search_keywords holds extra names staff or
+-- customers use for a menu, beside the core menus table. Each menu_search_synonyms row is a group of
+-- equivalent terms ("es teh", "iced tea"); a query containing one term also
+-- searches for the others. Queries that match nothing are counted per day in
+-- menu_search_misses for the merchant analytics.
+create table if not exists menu_search_keywords (
+	menu_id bigint primary key references menus(id) on delete cascade,
+	merchant_id bigint not null,
+	keywords text[] not null default '{}',
+	updated_at timestamp(3) not null default now(),
+	updated_by_user_id bigint
+);
+
+create table if not exists menu_search_synonyms (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	terms text[] not null,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now()
+);
+
+create index if not exists menu_search_synonyms_merchant_idx
+	on menu_search_synonyms (merchant_id);
+
+create table if not exists menu_search_misses (
+	merchant_id bigint not null,
+	query text not null,
+	day date not null,
+	count integer not null default 1,
+	last_searched_at timestamp(3) not null default now(),
+	primary key (merchant_id, query, day)
+);
+
+create index if not exists menu_search_misses_merchant_day_idx
+	on menu_search_misses (merchant_id, day);
+
+-- Keyword and synonym changes invalidate cached search indexes like any
+-- other menu write.
+drop trigger if exists menu_cache_version_bump on menu_search_keywords;
+create trigger menu_cache_version_bump after insert or update or delete on menu_search_keywords
+	for each row execute function bump_menu_cache_version();
+
+drop trigger if exists menu_cache_version_bump on menu_search_synonyms;
+create trigger menu_cache_version_bump after insert or update or delete on menu_search_synonyms
+	for each row execute function bump_menu_cache_version();
@@ -0,0 +1,41 @@
+-- Precomputed menu recommendations. A sweep rebuilds, per merchant, the
+-- item-to-item association scores (confidence and lift of "ordered together")
+-- and the order counts per merchant-local day of week and hour from the last
+-- 90 days of orders. merchant_recommendation_runs.computed_at is claimed
+-- before each rebuild so replicas do not repeat it; it sits beside merchants
+-- so the claim never locks the core merchants row. Merchants can pin menus to
+-- the top of the list or exclude them.
+create table if not exists merchant_recommendation_runs (
+	merchant_id bigint primary key references merchants(id) on delete cascade,
+	computed_at timestamp(3) not null
+);
+
+create table if not exists menu_association_scores (
+	merchant_id bigint not null,
+	menu_id bigint not null,
+	related_menu_id bigint not null,
+	support_count integer not null,
+	confidence double precision not null,
+	lift double precision not null,
+	computed_at timestamp(3) not null default now(),
+	primary key (merchant_id, menu_id, related_menu_id)
+);
+
+create table if not exists menu_popularity_slots (
+	merchant_id bigint not null,
+	menu_id bigint not null,
+	day_of_week smallint not null,
+	hour smallint not null,
+	order_count integer not null,
+	primary key (merchant_id, menu_id, day_of_week, hour)
+);
+
+create table if not exists menu_recommendation_rules (
+	merchant_id bigint not null,
+	menu_id bigint not null,
+	rule text not null,
+	position integer not null default 0,
+	created_by_user_id bigint,
+	created_at timestamp(3) not null default now(),
+	primary key (merchant_id, menu_id)
+);
@@ -0,0 +1,45 @@
+-- Merchant upsell and cross-sell rules. A rule fires when the cart holds one
+-- of its trigger menus or a menu from a trigger category, and/or reaches
+-- min_subtotal, and then offers its offer menus, optionally discounted.
+-- Order items bought from an offer are linked to their rule in
+-- order_item_upsells so acceptances and revenue can be reported; impressions
+-- are counted per rule and day.
+create table if not exists upsell_rules (
+	id bigserial primary key,
+	merchant_id bigint not null,
+	name text not null,
+	message text,
+	is_active boolean not null default true,
+	trigger_menu_ids bigint[] not null default '{}',
+	trigger_category_ids bigint[] not null default '{}',
+	min_subtotal numeric(10, 2),
+	offer_menu_ids bigint[] not null,
+	discount_type text,
+	discount_value numeric(10, 2),
+	max_quantity integer not null default 1,
+	priority integer not null default 0,
+	created_by_user_id bigint,
+	updated_by_user_id bigint,
+	created_at timestamp(3) not null default now(),
+	updated_at timestamp(3) not null default now(),
+	deleted_at timestamp(3)
+);
+
+create index if not exists upsell_rules_merchant_idx
+	on upsell_rules (merchant_id) where deleted_at is null;
+
+create table if not exists upsell_rule_impressions (
+	rule_id bigint not null,
+	merchant_id bigint not null,
+	day date not null,
+	count integer not null default 0,
+	primary key (rule_id, day)
+);
+
+create table if not exists order_item_upsells (
+	order_item_id bigint primary key references order_items(id) on delete cascade,
+	upsell_rule_id bigint not null
+);
+
+create index if not exists order_item_upsells_rule_idx
+	on order_item_upsells (upsell_rule_id);
@@ -0,0 +1,10 @@
+-- Per-client fixed-window counters for the public geocode endpoints, shared
+-- by every replica so the limit holds behind a load balancer.
+create table if not exists geocode_rate_limits (
+	client_key text primary key,
+	window_started_at timestamp(3) not null,
+	request_count integer not null
+);
+
+create index if not exists geocode_rate_limits_window_idx
+	on geocode_rate_limits (window_started_at);
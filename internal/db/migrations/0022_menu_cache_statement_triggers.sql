@@ -0,0 +1,163 @@
+-- The row-level triggers from 0017 bumped merchant_menu_versions once per
+-- changed row, so a bulk write (catalog apply, branch sync, stock reset)
+-- upserted the same version row hundreds of times and held its lock until
+-- commit. The merchants trigger also fired on every merchant write, including
+-- columns the public endpoints never return, which serialized unrelated
+-- merchant updates behind menu writes.
+--
+-- Menu tables now bump once per statement and merchant, using transition
+-- tables, in merchant id order so concurrent bulk writes lock the version
+-- rows in the same order. Transition tables cannot be shared between events,
+-- so each table gets one trigger per event.
+--
+-- The merchants trigger stays per row because a column list cannot be
+-- combined with transition tables, but it only fires when a column that the
+-- public merchant or menu endpoints return actually changes.
+
+create or replace function bump_menu_cache_versions() returns trigger
+language plpgsql as $$
+declare
+	merchant_expr text;
+	source_rows text;
+	target bigint;
+	next_version bigint;
+begin
+	merchant_expr := case tg_table_name
+		when 'menu_category_items', 'menu_addon_categories' then
+			'(select merchant_id from menus where id = r.menu_id)'
+		when 'addon_items' then
+			'(select merchant_id from addon_categories where id = r.addon_category_id)'
+		when 'special_price_items', 'special_price_variant_items' then
+			'(select merchant_id from special_prices where id = r.special_price_id)'
+		when 'menu_bundle_slot_options' then
+			'(select merchant_id from menu_bundle_slots where id = r.slot_id)'
+		else
+			'r.merchant_id'
+	end;
+
+	source_rows := case tg_op
+		when 'INSERT' then 'select * from new_rows'
+		when 'DELETE' then 'select * from old_rows'
+		else 'select * from new_rows union all select * from old_rows'
+	end;
+
+	for target in execute format(
+		'select distinct t.merchant_id from (select %s as merchant_id from (%s) r) t '
+		'where t.merchant_id is not null order by t.merchant_id',
+		merchant_expr, source_rows)
+	loop
+		insert into merchant_menu_versions (merchant_id, version, updated_at)
+		values (target, 1, now())
+		on conflict (merchant_id) do update
+		set version = merchant_menu_versions.version + 1, updated_at = now()
+		returning version into next_version;
+
+		perform pg_notify('menu_cache_updates', target::text || ':' || next_version::text);
+	end loop;
+
+	return null;
+end;
+$$;
+
+do $$
+declare
+	t text;
+begin
+	foreach t in array array[
+		'merchant_opening_hours', 'merchant_payment_settings', 'merchant_payment_accounts',
+		'menus', 'menu_categories', 'menu_category_items', 'menu_addon_categories',
+		'addon_categories', 'addon_items', 'special_prices', 'special_price_items',
+		'special_price_variant_items', 'menu_variants', 'menu_bundle_settings', 'menu_bundle_slots',
+		'menu_bundle_slot_options', 'menu_dietary_attributes', 'addon_item_dietary_attributes',
+		'content_translations'
+	] loop
+		execute format('drop trigger if exists menu_cache_version_bump on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_insert on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_update on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_delete on %I', t);
+		execute format(
+			'create trigger menu_cache_version_bump_insert after insert on %I '
+			'referencing new table as new_rows '
+			'for each statement execute function bump_menu_cache_versions()', t);
+		execute format(
+			'create trigger menu_cache_version_bump_update after update on %I '
+			'referencing old table as old_rows new table as new_rows '
+			'for each statement execute function bump_menu_cache_versions()', t);
+		execute format(
+			'create trigger menu_cache_version_bump_delete after delete on %I '
+			'referencing old table as old_rows '
+			'for each statement execute function bump_menu_cache_versions()', t);
+	end loop;
+end;
+$$;
+
+-- The merchant row itself: only the columns the public merchant and menu
+-- endpoints render. A new merchant has no cached responses yet, so inserts
+-- are not tracked.
+drop trigger if exists menu_cache_version_bump on merchants;
+drop trigger if exists menu_cache_version_bump_update on merchants;
+drop trigger if exists menu_cache_version_bump_delete on merchants;
+
+create trigger menu_cache_version_bump_update
+after update of
+	code, name, email, phone, address, city, state, postal_code, country,
+	logo_url, banner_url, map_url, description,
+	is_active, is_open, is_manual_override,
+	is_dine_in_enabled, is_takeaway_enabled, require_table_number_for_dine_in,
+	dine_in_label, takeaway_label, delivery_label,
+	dine_in_schedule_start, dine_in_schedule_end,
+	takeaway_schedule_start, takeaway_schedule_end,
+	delivery_schedule_start, delivery_schedule_end,
+	total_tables,
+	is_delivery_enabled, enforce_delivery_zones,
+	delivery_max_distance_km, delivery_fee_base, delivery_fee_per_km, delivery_fee_min, delivery_fee_max,
+	enable_tax, tax_percentage,
+	enable_service_charge, service_charge_percent,
+	enable_packaging_fee, packaging_fee_amount,
+	currency, timezone, features, latitude, longitude,
+	is_reservation_enabled, reservation_menu_required, reservation_min_item_count,
+	is_scheduled_order_enabled, is_per_day_mode_schedule_enabled
+on merchants
+for each row
+when ((
+	old.code, old.name, old.email, old.phone, old.address, old.city, old.state, old.postal_code, old.country,
+	old.logo_url, old.banner_url, old.map_url, old.description,
+	old.is_active, old.is_open, old.is_manual_override,
+	old.is_dine_in_enabled, old.is_takeaway_enabled, old.require_table_number_for_dine_in,
+	old.dine_in_label, old.takeaway_label, old.delivery_label,
+	old.dine_in_schedule_start, old.dine_in_schedule_end,
+	old.takeaway_schedule_start, old.takeaway_schedule_end,
+	old.delivery_schedule_start, old.delivery_schedule_end,
+	old.total_tables,
+	old.is_delivery_enabled, old.enforce_delivery_zones,
+	old.delivery_max_distance_km, old.delivery_fee_base, old.delivery_fee_per_km, old.delivery_fee_min, old.delivery_fee_max,
+	old.enable_tax, old.tax_percentage,
+	old.enable_service_charge, old.service_charge_percent,
+	old.enable_packaging_fee, old.packaging_fee_amount,
+	old.currency, old.timezone, old.features::text, old.latitude, old.longitude,
+	old.is_reservation_enabled, old.reservation_menu_required, old.reservation_min_item_count,
+	old.is_scheduled_order_enabled, old.is_per_day_mode_schedule_enabled
+) is distinct from (
+	new.code, new.name, new.email, new.phone, new.address, new.city, new.state, new.postal_code, new.country,
+	new.logo_url, new.banner_url, new.map_url, new.description,
+	new.is_active, new.is_open, new.is_manual_override,
+	new.is_dine_in_enabled, new.is_takeaway_enabled, new.require_table_number_for_dine_in,
+	new.dine_in_label, new.takeaway_label, new.delivery_label,
+	new.dine_in_schedule_start, new.dine_in_schedule_end,
+	new.takeaway_schedule_start, new.takeaway_schedule_end,
+	new.delivery_schedule_start, new.delivery_schedule_end,
+	new.total_tables,
+	new.is_delivery_enabled, new.enforce_delivery_zones,
+	new.delivery_max_distance_km, new.delivery_fee_base, new.delivery_fee_per_km, new.delivery_fee_min, new.delivery_fee_max,
+	new.enable_tax, new.tax_percentage,
+	new.enable_service_charge, new.service_charge_percent,
+	new.enable_packaging_fee, new.packaging_fee_amount,
+	new.currency, new.timezone, new.features::text, new.latitude, new.longitude,
+	new.is_reservation_enabled, new.reservation_menu_required, new.reservation_min_item_count,
+	new.is_scheduled_order_enabled, new.is_per_day_mode_schedule_enabled
+))
+execute function bump_menu_cache_version();
+
+create trigger menu_cache_version_bump_delete
+after delete on merchants
+for each row execute function bump_menu_cache_version();
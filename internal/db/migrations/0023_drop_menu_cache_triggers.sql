@@ -0,0 +1,31 @@
+-- The menu cache version is bumped by this service's write paths instead of
+-- triggers. Triggers on tables owned by genfity-order-main were lost whenever
+-- those tables were recreated, and the menus trigger made every order's stock
+-- decrement lock the merchant's version row until commit, serializing that
+-- merchant's orders and flushing its cached menu on each one.
+-- merchant_menu_versions itself stays.
+do $$
+declare
+	t text;
+begin
+	foreach t in array array[
+		'merchants', 'merchant_opening_hours', 'merchant_payment_settings', 'merchant_payment_accounts',
+		'menus', 'menu_categories', 'menu_category_items', 'menu_addon_categories',
+		'addon_categories', 'addon_items', 'special_prices', 'special_price_items',
+		'special_price_variant_items', 'menu_variants', 'menu_bundle_settings', 'menu_bundle_slots',
+		'menu_bundle_slot_options', 'menu_dietary_attributes', 'addon_item_dietary_attributes',
+		'content_translations'
+	] loop
+		if to_regclass(t) is null then
+			continue;
+		end if;
+		execute format('drop trigger if exists menu_cache_version_bump on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_insert on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_update on %I', t);
+		execute format('drop trigger if exists menu_cache_version_bump_delete on %I', t);
+	end loop;
+end;
+$$;
+
+drop function if exists bump_menu_cache_versions();
+drop function if exists bump_menu_cache_version();
@@ -0,0 +1,8 @@
+-- Orders for which auto-dispatch ran out of drivers to offer. attempt_count is
+-- the number of offers made when the merchant was last told, so the notice is
+-- sent once per round of offers instead of on every sweep.
+create table if not exists order_dispatch_exhaustions (
+	order_id bigint primary key references orders(id) on delete cascade,
+	attempt_count integer not null,
+	notified_at timestamp(3) not null default now()
+);
@@ -0,0 +1,12 @@
+-- Carts that have already been counted as an impression of an upsell rule on
+-- a merchant-local day, so repeated offer lookups from the same checkout do
+-- not inflate upsell_rule_impressions. Rows older than yesterday are swept.
+create table if not exists upsell_rule_impression_carts (
+	rule_id bigint not null,
+	day date not null,
+	cart_token text not null,
+	primary key (rule_id, day, cart_token)
+);
+
+create index if not exists upsell_rule_impression_carts_day_idx
+	on upsell_rule_impression_carts (day);
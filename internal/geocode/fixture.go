@@ -0,0 +1,114 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strings"
+)
+
+// fixtureReverseRadiusKm is how far a reverse lookup may be from a fixture
+// point and still resolve to it.
+const fixtureReverseRadiusKm = 0.5
+
+// Fixture answers from a JSON file instead of a remote service, for tests and
+// offline development:
+//
+//	{"search": {"george st sydney": [{"lat": -33.86, "lng": 151.2, "displayName": "...", "address": {...}}]},
+//	 "reverse": [{"lat": -33.86, "lng": 151.2, "displayName": "...", "address": {...}}]}
+//
+// Search keys are matched after NormalizeQuery. Reverse returns the closest
+// place within fixtureReverseRadiusKm.
+type Fixture struct {
+	search  map[string][]Place
+	reverse []Place
+}
+
+type fixtureFile struct {
+	Search  map[string][]Place `json:"search"`
+	Reverse []Place            `json:"reverse"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("geocoder fixture path is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFixture(data)
+}
+
+func NewFixture(data []byte) (*Fixture, error) {
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	search := make(map[string][]Place, len(file.Search))
+	for query, places := range file.Search {
+		search[NormalizeQuery(query)] = places
+	}
+	return &Fixture{search: search, reverse: file.Reverse}, nil
+}
+
+func (f *Fixture) Name() string {
+	return ProviderFixture
+}
+
+func (f *Fixture) Reverse(_ context.Context, lat, lng float64) (Place, error) {
+	best := -1
+	bestDistance := 0.0
+	for i, place := range f.reverse {
+		d := distanceKm(lat, lng, place.Lat, place.Lng)
+		if d <= fixtureReverseRadiusKm && (best < 0 || d < bestDistance) {
+			best = i
+			bestDistance = d
+		}
+	}
+	if best < 0 {
+		return Place{Lat: lat, Lng: lng}, nil
+	}
+	place := f.reverse[best]
+	place.Lat, place.Lng = lat, lng
+	return place, nil
+}
+
+func (f *Fixture) Search(_ context.Context, query string, bias Bias, limit int) ([]Place, error) {
+	places := f.search[NormalizeQuery(query)]
+	out := make([]Place, 0, len(places))
+	for _, place := range places {
+		if len(bias.CountryCodes) > 0 && !matchesCountry(place, bias.CountryCodes) {
+			continue
+		}
+		out = append(out, place)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func matchesCountry(place Place, codes []string) bool {
+	code, _ := place.Address["country_code"].(string)
+	if code == "" {
+		return true
+	}
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
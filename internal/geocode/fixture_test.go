@@ -0,0 +1,46 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+)
+
+const fixtureData = `{
+	"search": {
+		"George St  Sydney": [
+			{"lat": -33.8688, "lng": 151.2093, "displayName": "George Street, Sydney", "address": {"road": "George Street", "country_code": "au"}},
+			{"lat": 51.5072, "lng": -0.1276, "displayName": "George Street, London", "address": {"road": "George Street", "country_code": "gb"}}
+		]
+	},
+	"reverse": [
+		{"lat": -33.8688, "lng": 151.2093, "displayName": "George Street, Sydney", "address": {"road": "George Street"}}
+	]
+}`
+
+func TestFixtureGeocoder(t *testing.T) {
+	fixture, err := NewFixture([]byte(fixtureData))
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	ctx := context.Background()
+
+	places, err := fixture.Search(ctx, "  george st sydney ", Bias{}, 5)
+	if err != nil || len(places) != 2 {
+		t.Fatalf("expected 2 results for normalised query, got %d (%v)", len(places), err)
+	}
+
+	places, _ = fixture.Search(ctx, "George St Sydney", Bias{CountryCodes: []string{"au"}}, 5)
+	if len(places) != 1 || places[0].DisplayName != "George Street, Sydney" {
+		t.Fatalf("expected country bias to keep only the AU result, got %+v", places)
+	}
+
+	place, _ := fixture.Reverse(ctx, -33.8690, 151.2095)
+	if place.DisplayName != "George Street, Sydney" || place.Lat != -33.8690 {
+		t.Fatalf("expected nearby reverse match, got %+v", place)
+	}
+
+	place, _ = fixture.Reverse(ctx, -34.5, 150.0)
+	if place.DisplayName != "" {
+		t.Fatalf("expected no match far from fixture points, got %+v", place)
+	}
+}
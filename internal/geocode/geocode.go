@@ -0,0 +1,82 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ProviderNominatim = "nominatim"
+	ProviderFixture   = "fixture"
+)
+
+var ErrUpstream = errors.New("geocoder upstream error")
+
+// Place is a single geocoding result. Address uses Nominatim's addressdetails
+// keys (road, suburb, city, postcode, country, ...) for every provider.
+type Place struct {
+	Lat         float64        `json:"lat"`
+	Lng         float64        `json:"lng"`
+	DisplayName string         `json:"displayName"`
+	Address     map[string]any `json:"address"`
+}
+
+// ViewBox is a lng/lat bounding box used to prefer nearby results.
+type ViewBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// Bias narrows forward searches toward a merchant's outlet. Results outside
+// the view box are still allowed, only ranked lower.
+type Bias struct {
+	CountryCodes []string
+	ViewBox      *ViewBox
+}
+
+// Key is a stable representation of the bias for cache keys.
+func (b Bias) Key() string {
+	parts := make([]string, 0, 2)
+	if len(b.CountryCodes) > 0 {
+		parts = append(parts, "cc="+strings.Join(b.CountryCodes, ","))
+	}
+	if b.ViewBox != nil {
+		parts = append(parts, fmt.Sprintf("vb=%.2f,%.2f,%.2f,%.2f", b.ViewBox.MinLng, b.ViewBox.MinLat, b.ViewBox.MaxLng, b.ViewBox.MaxLat))
+	}
+	return strings.Join(parts, ";")
+}
+
+type Geocoder interface {
+	Name() string
+	Reverse(ctx context.Context, lat, lng float64) (Place, error)
+	Search(ctx context.Context, query string, bias Bias, limit int) ([]Place, error)
+}
+
+type Config struct {
+	Provider    string
+	BaseURL     string
+	UserAgent   string
+	FixturePath string
+}
+
+// New returns the geocoder selected by cfg.Provider (nominatim by default).
+func New(cfg Config) (Geocoder, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "", ProviderNominatim:
+		return NewNominatim(cfg.BaseURL, cfg.UserAgent), nil
+	case ProviderFixture:
+		return LoadFixture(cfg.FixturePath)
+	default:
+		return nil, fmt.Errorf("unknown geocoder provider %q", cfg.Provider)
+	}
+}
+
+// NormalizeQuery lowercases a search query and collapses whitespace so that
+// equivalent searches share a cache entry.
+func NormalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,161 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimBaseURL   = "https://nominatim.openstreetmap.org"
+	defaultNominatimUserAgent = "Genfity Online Ordering (https://order.genfity.com)"
+	// Nominatim's usage policy allows at most one request per second.
+	nominatimMinInterval = time.Second
+)
+
+type Nominatim struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+func NewNominatim(baseURL, userAgent string) *Nominatim {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultNominatimBaseURL
+	}
+	if strings.TrimSpace(userAgent) == "" {
+		userAgent = defaultNominatimUserAgent
+	}
+	return &Nominatim{
+		baseURL:   baseURL,
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 8 * time.Second},
+	}
+}
+
+func (n *Nominatim) Name() string {
+	return ProviderNominatim
+}
+
+func (n *Nominatim) Reverse(ctx context.Context, lat, lng float64) (Place, error) {
+	params := url.Values{}
+	params.Set("format", "jsonv2")
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', -1, 64))
+	params.Set("zoom", "18")
+	params.Set("addressdetails", "1")
+
+	var row nominatimRow
+	if err := n.get(ctx, "/reverse", params, &row); err != nil {
+		return Place{}, err
+	}
+	place, _ := row.place()
+	place.Lat, place.Lng = lat, lng
+	return place, nil
+}
+
+func (n *Nominatim) Search(ctx context.Context, query string, bias Bias, limit int) ([]Place, error) {
+	params := url.Values{}
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("q", query)
+	if len(bias.CountryCodes) > 0 {
+		params.Set("countrycodes", strings.Join(bias.CountryCodes, ","))
+	}
+	if bias.ViewBox != nil {
+		vb := bias.ViewBox
+		params.Set("viewbox", strings.Join([]string{
+			strconv.FormatFloat(vb.MinLng, 'f', 5, 64),
+			strconv.FormatFloat(vb.MaxLat, 'f', 5, 64),
+			strconv.FormatFloat(vb.MaxLng, 'f', 5, 64),
+			strconv.FormatFloat(vb.MinLat, 'f', 5, 64),
+		}, ","))
+		params.Set("bounded", "0")
+	}
+
+	var rows []nominatimRow
+	if err := n.get(ctx, "/search", params, &rows); err != nil {
+		return nil, err
+	}
+	places := make([]Place, 0, len(rows))
+	for _, row := range rows {
+		if place, ok := row.place(); ok {
+			places = append(places, place)
+		}
+	}
+	return places, nil
+}
+
+func (n *Nominatim) get(ctx context.Context, path string, params url.Values, out any) error {
+	if err := n.throttle(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return ErrUpstream
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// throttle spaces requests from this process nominatimMinInterval apart.
+func (n *Nominatim) throttle(ctx context.Context) error {
+	n.mu.Lock()
+	wait := time.Until(n.lastRequest.Add(nominatimMinInterval))
+	if wait < 0 {
+		wait = 0
+	}
+	n.lastRequest = time.Now().Add(wait)
+	n.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type nominatimRow struct {
+	Lat         string         `json:"lat"`
+	Lon         string         `json:"lon"`
+	DisplayName string         `json:"display_name"`
+	Address     map[string]any `json:"address"`
+}
+
+func (r nominatimRow) place() (Place, bool) {
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(r.Lat), 64)
+	lng, errLng := strconv.ParseFloat(strings.TrimSpace(r.Lon), 64)
+	return Place{
+		Lat:         lat,
+		Lng:         lng,
+		DisplayName: strings.TrimSpace(r.DisplayName),
+		Address:     r.Address,
+	}, errLat == nil && errLng == nil
+}
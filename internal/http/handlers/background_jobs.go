@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// sweeperLeaderLockKey is the session-level pg_advisory_lock key held by the
+	// one replica that runs the periodic sweepers.
+	sweeperLeaderLockKey int64 = 7261530042
+
+	sweeperLeaderRetryInterval = 15 * time.Second
+	sweeperLeaderPingInterval  = 10 * time.Second
+)
+
+// RunBackgroundJobs runs the service's background work until ctx is cancelled.
+// The menu cache listener runs on every replica because each one keeps its own
+// cache; the dispatch, courier, menu publish, stock reset and recommendation
+// sweepers only run on the replica holding the leader advisory lock. If the
+// leader's lock connection is lost the sweepers stop and another replica takes
+// over on its next attempt.
+func (h *Handler) RunBackgroundJobs(ctx context.Context) {
+	if h.DB == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.RunMenuCacheListener(ctx)
+	}()
+
+	for {
+		if err := h.leadSweepers(ctx); err != nil && ctx.Err() == nil && h.Logger != nil {
+			h.Logger.Warn("sweeper leader lock lost", zapError(err))
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-time.After(sweeperLeaderRetryInterval):
+		}
+	}
+}
+
+// leadSweepers tries to take the leader lock and, when it succeeds, runs the
+// sweepers until ctx is cancelled or the lock connection stops answering. It
+// returns immediately when another replica is the leader.
+func (h *Handler) leadSweepers(ctx context.Context) error {
+	conn, err := h.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `select pg_try_advisory_lock($1)`, sweeperLeaderLockKey).Scan(&locked); err != nil {
+		conn.Release()
+		return err
+	}
+	if !locked {
+		conn.Release()
+		return nil
+	}
+	if h.Logger != nil {
+		h.Logger.Info("sweeper leader lock acquired")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, run := range []func(context.Context, time.Duration){
+		h.RunDispatchSweeper,
+		h.RunCourierSweeper,
+		h.RunMenuPublishSweeper,
+		h.RunStockResetSweeper,
+		h.RunRecommendationSweeper,
+	} {
+		wg.Add(1)
+		go func(run func(context.Context, time.Duration)) {
+			defer wg.Done()
+			run(runCtx, 0)
+		}(run)
+	}
+
+	err = holdLeaderLock(ctx, conn)
+	cancel()
+	wg.Wait()
+
+	if err != nil {
+		// The session is gone or unusable, so its lock is already released;
+		// drop the connection instead of returning it to the pool.
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+		return err
+	}
+	unlockCtx, unlockCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer unlockCancel()
+	_, _ = conn.Exec(unlockCtx, `select pg_advisory_unlock($1)`, sweeperLeaderLockKey)
+	conn.Release()
+	return nil
+}
+
+// holdLeaderLock pings the lock connection until ctx is cancelled (nil) or the
+// connection fails (the ping error).
+func holdLeaderLock(ctx context.Context, conn *pgxpool.Conn) error {
+	ticker := time.NewTicker(sweeperLeaderPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := conn.Ping(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,1325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Catalog sync pushes menu content from a main merchant to its branches.
+// Every copied entity stays linked to its source so later syncs update it.
+// Price and availability are branch-controlled once a branch changes them:
+// the link remembers the value the last sync wrote and a sync only
+// overwrites the branch value while it still equals that. Stock is never
+// synced after creation, and branch entities without a link are never
+// touched. Variants travel with their menu, and a synced bundle's slots
+// replace the branch's, pointing at the branch copies of their options.
+
+const (
+	catalogSyncCategory      = "CATEGORY"
+	catalogSyncAddonCategory = "ADDON_CATEGORY"
+	catalogSyncAddonItem     = "ADDON_ITEM"
+	catalogSyncMenu          = "MENU"
+	catalogSyncVariant       = "VARIANT"
+
+	catalogSyncCreate    = "CREATE"
+	catalogSyncUpdate    = "UPDATE"
+	catalogSyncUnchanged = "UNCHANGED"
+	catalogSyncSkip      = "SKIP"
+
+	catalogSyncMaxBranches = 50
+)
+
+type catalogSyncLink struct {
+	TargetID       int64
+	SyncedPrice    *float64
+	SyncedIsActive *bool
+}
+
+// catalogSyncLinks maps entity type and source ID to the branch copy.
+type catalogSyncLinks map[string]map[int64]catalogSyncLink
+
+func (l catalogSyncLinks) get(entityType string, sourceID int64) (catalogSyncLink, bool) {
+	link, ok := l[entityType][sourceID]
+	return link, ok
+}
+
+func (l catalogSyncLinks) targetIDs(entityType string) map[int64]bool {
+	out := make(map[int64]bool, len(l[entityType]))
+	for _, link := range l[entityType] {
+		out[link.TargetID] = true
+	}
+	return out
+}
+
+type catalogSyncSelection struct {
+	CategoryIDs      []int64 `json:"categoryIds"`
+	MenuIDs          []int64 `json:"menuIds"`
+	AddonCategoryIDs []int64 `json:"addonCategoryIds"`
+	AddonItemIDs     []int64 `json:"addonItemIds"`
+}
+
+type catalogSyncAction struct {
+	SourceID      int64    `json:"sourceId"`
+	TargetID      *int64   `json:"targetId"`
+	Name          string   `json:"name"`
+	Action        string   `json:"action"`
+	Changes       []string `json:"changes,omitempty"`
+	KeptOverrides []string `json:"keptOverrides,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+
+	syncedPrice    *float64
+	syncedIsActive *bool
+}
+
+func (a *catalogSyncAction) changed(field string) bool {
+	return containsString(a.Changes, field)
+}
+
+// compare records a synced field that differs between main and branch.
+func (a *catalogSyncAction) compare(field string, source, target any) {
+	if !catalogValuesEqual(source, target) {
+		a.Changes = append(a.Changes, field)
+	}
+}
+
+// override handles a branch-controlled field: it follows the main value
+// only while the branch still holds what the last sync wrote. It reports
+// whether the synced baseline moves to the main value.
+func (a *catalogSyncAction) override(field string, source, target, synced any, hasSynced bool) bool {
+	if catalogValuesEqual(source, target) {
+		return true
+	}
+	if hasSynced && catalogValuesEqual(target, synced) {
+		a.Changes = append(a.Changes, field)
+		return true
+	}
+	a.KeptOverrides = append(a.KeptOverrides, field)
+	return false
+}
+
+func (a *catalogSyncAction) finish() {
+	if a.Action != "" {
+		return
+	}
+	if len(a.Changes) > 0 {
+		a.Action = catalogSyncUpdate
+	} else {
+		a.Action = catalogSyncUnchanged
+	}
+}
+
+// catalogSyncPlan lists one action per selected main entity. Actions are in
+// the same order as the entities of the selected catalog, with addon items
+// flattened category by category and variants menu by menu.
+type catalogSyncPlan struct {
+	Categories      []catalogSyncAction `json:"categories"`
+	AddonCategories []catalogSyncAction `json:"addonCategories"`
+	AddonItems      []catalogSyncAction `json:"addonItems"`
+	Menus           []catalogSyncAction `json:"menus"`
+	Variants        []catalogSyncAction `json:"variants"`
+
+	source catalogSyncSource
+}
+
+type catalogSyncSource struct {
+	catalog menuCatalog
+	// targets resolves main IDs to branch IDs for entities that exist in
+	// the branch; entities the plan creates are added while applying.
+	categoryTargets map[int64]int64
+	addonTargets    map[int64]int64
+	menuTargets     map[int64]int64
+	variantTargets  map[int64]int64
+}
+
+func (p catalogSyncPlan) summary() map[string]int {
+	out := map[string]int{"create": 0, "update": 0, "unchanged": 0, "skipped": 0, "keptOverrides": 0}
+	for _, group := range [][]catalogSyncAction{p.Categories, p.AddonCategories, p.AddonItems, p.Menus, p.Variants} {
+		for _, a := range group {
+			switch a.Action {
+			case catalogSyncCreate:
+				out["create"]++
+			case catalogSyncUpdate:
+				out["update"]++
+			case catalogSyncUnchanged:
+				out["unchanged"]++
+			case catalogSyncSkip:
+				out["skipped"]++
+			}
+			out["keptOverrides"] += len(a.KeptOverrides)
+		}
+	}
+	return out
+}
+
+// selectCatalogForSync expands a selection into the part of the main
+// catalog to push. Menus bring their categories and addon categories,
+// bundles bring the menus their slots offer, selected categories bring
+// their menus, addon categories always bring all their items and addon
+// items bring their category. Entities synced before are always included
+// so main edits keep propagating.
+func selectCatalogForSync(source menuCatalog, links catalogSyncLinks, sel catalogSyncSelection) (menuCatalog, error) {
+	categoryExists := make(map[int64]bool, len(source.Categories))
+	for _, c := range source.Categories {
+		categoryExists[c.ID] = true
+	}
+	addonExists := make(map[int64]bool, len(source.AddonCategories))
+	itemCategory := make(map[int64]int64)
+	for _, c := range source.AddonCategories {
+		addonExists[c.ID] = true
+		for _, item := range c.Items {
+			itemCategory[item.ID] = c.ID
+		}
+	}
+	menuExists := make(map[int64]bool, len(source.Menus))
+	for _, m := range source.Menus {
+		menuExists[m.ID] = true
+	}
+
+	categories := make(map[int64]bool)
+	addons := make(map[int64]bool)
+	menus := make(map[int64]bool)
+	for _, id := range sel.CategoryIDs {
+		if !categoryExists[id] {
+			return menuCatalog{}, fmt.Errorf("Category %d not found in the main menu", id)
+		}
+		categories[id] = true
+	}
+	for _, id := range sel.AddonCategoryIDs {
+		if !addonExists[id] {
+			return menuCatalog{}, fmt.Errorf("Addon category %d not found in the main menu", id)
+		}
+		addons[id] = true
+	}
+	for _, id := range sel.AddonItemIDs {
+		categoryID, ok := itemCategory[id]
+		if !ok {
+			return menuCatalog{}, fmt.Errorf("Addon item %d not found in the main menu", id)
+		}
+		addons[categoryID] = true
+	}
+	for _, id := range sel.MenuIDs {
+		if !menuExists[id] {
+			return menuCatalog{}, fmt.Errorf("Menu %d not found in the main menu", id)
+		}
+		menus[id] = true
+	}
+	for _, m := range source.Menus {
+		for _, categoryID := range m.CategoryIDs {
+			if categories[categoryID] {
+				menus[m.ID] = true
+				break
+			}
+		}
+	}
+
+	for id := range links[catalogSyncCategory] {
+		if categoryExists[id] {
+			categories[id] = true
+		}
+	}
+	for id := range links[catalogSyncAddonCategory] {
+		if addonExists[id] {
+			addons[id] = true
+		}
+	}
+	for id := range links[catalogSyncAddonItem] {
+		if categoryID, ok := itemCategory[id]; ok {
+			addons[categoryID] = true
+		}
+	}
+	for id := range links[catalogSyncMenu] {
+		if menuExists[id] {
+			menus[id] = true
+		}
+	}
+	// Slot options are never bundles themselves, so one pass is enough.
+	for _, m := range source.Menus {
+		if !menus[m.ID] || m.Bundle == nil {
+			continue
+		}
+		for _, slot := range m.Bundle.Slots {
+			for _, option := range slot.Options {
+				if menuExists[option.MenuID] {
+					menus[option.MenuID] = true
+				}
+			}
+		}
+	}
+
+	for _, m := range source.Menus {
+		if !menus[m.ID] {
+			continue
+		}
+		for _, categoryID := range m.CategoryIDs {
+			categories[categoryID] = true
+		}
+		for _, link := range m.AddonCategories {
+			addons[link.AddonCategoryID] = true
+		}
+	}
+
+	out := menuCatalog{
+		Categories:      make([]catalogCategory, 0),
+		AddonCategories: make([]catalogAddonCategory, 0),
+		Menus:           make([]catalogMenu, 0),
+	}
+	for _, c := range source.Categories {
+		if categories[c.ID] {
+			out.Categories = append(out.Categories, c)
+		}
+	}
+	for _, c := range source.AddonCategories {
+		if addons[c.ID] {
+			out.AddonCategories = append(out.AddonCategories, c)
+		}
+	}
+	for _, m := range source.Menus {
+		if menus[m.ID] {
+			out.Menus = append(out.Menus, m)
+		}
+	}
+	return out, nil
+}
+
+// planCatalogSync compares the selected main catalog with a branch catalog.
+// Linked entities whose branch copy was deleted are skipped, and links to
+// them are left out of menus.
+func planCatalogSync(selected, target menuCatalog, links catalogSyncLinks) catalogSyncPlan {
+	plan := catalogSyncPlan{
+		Categories:      make([]catalogSyncAction, 0, len(selected.Categories)),
+		AddonCategories: make([]catalogSyncAction, 0, len(selected.AddonCategories)),
+		AddonItems:      make([]catalogSyncAction, 0),
+		Menus:           make([]catalogSyncAction, 0, len(selected.Menus)),
+		Variants:        make([]catalogSyncAction, 0),
+		source: catalogSyncSource{
+			catalog:         selected,
+			categoryTargets: make(map[int64]int64),
+			addonTargets:    make(map[int64]int64),
+			menuTargets:     make(map[int64]int64),
+			variantTargets:  make(map[int64]int64),
+		},
+	}
+
+	targetCategories := make(map[int64]catalogCategory, len(target.Categories))
+	for _, c := range target.Categories {
+		targetCategories[c.ID] = c
+	}
+	targetAddons := make(map[int64]catalogAddonCategory, len(target.AddonCategories))
+	targetItems := make(map[int64]catalogAddonItem)
+	targetItemCategory := make(map[int64]int64)
+	for _, c := range target.AddonCategories {
+		targetAddons[c.ID] = c
+		for _, item := range c.Items {
+			targetItems[item.ID] = item
+			targetItemCategory[item.ID] = c.ID
+		}
+	}
+	targetMenus := make(map[int64]catalogMenu, len(target.Menus))
+	targetVariants := make(map[int64]catalogVariant)
+	for _, m := range target.Menus {
+		targetMenus[m.ID] = m
+		for _, v := range m.Variants {
+			targetVariants[v.ID] = v
+		}
+	}
+
+	// Entities the plan creates resolve to their negated main ID so they
+	// never equal an existing branch ID.
+	categoryRefs := make(map[int64]int64)
+	addonRefs := make(map[int64]int64)
+	menuRefs := make(map[int64]int64)
+	variantRefs := make(map[int64]int64)
+
+	for _, c := range selected.Categories {
+		a := catalogSyncAction{SourceID: c.ID, Name: c.Name}
+		link, linked := links.get(catalogSyncCategory, c.ID)
+		t, exists := targetCategories[link.TargetID]
+		switch {
+		case !linked:
+			a.Action = catalogSyncCreate
+			a.syncedIsActive = &c.IsActive
+			categoryRefs[c.ID] = -c.ID
+		case !exists:
+			a.TargetID = &link.TargetID
+			a.Action = catalogSyncSkip
+			a.Reason = "Deleted in the branch"
+		default:
+			a.TargetID = &link.TargetID
+			categoryRefs[c.ID] = t.ID
+			plan.source.categoryTargets[c.ID] = t.ID
+			a.compare("name", c.Name, t.Name)
+			a.compare("description", c.Description, t.Description)
+			a.compare("sortOrder", c.SortOrder, t.SortOrder)
+			a.syncedIsActive = link.SyncedIsActive
+			if a.override("isActive", c.IsActive, t.IsActive, link.SyncedIsActive, link.SyncedIsActive != nil) {
+				a.syncedIsActive = &c.IsActive
+			}
+		}
+		a.finish()
+		plan.Categories = append(plan.Categories, a)
+	}
+
+	for _, c := range selected.AddonCategories {
+		a := catalogSyncAction{SourceID: c.ID, Name: c.Name}
+		link, linked := links.get(catalogSyncAddonCategory, c.ID)
+		t, exists := targetAddons[link.TargetID]
+		switch {
+		case !linked:
+			a.Action = catalogSyncCreate
+			a.syncedIsActive = &c.IsActive
+			addonRefs[c.ID] = -c.ID
+		case !exists:
+			a.TargetID = &link.TargetID
+			a.Action = catalogSyncSkip
+			a.Reason = "Deleted in the branch"
+		default:
+			a.TargetID = &link.TargetID
+			addonRefs[c.ID] = t.ID
+			plan.source.addonTargets[c.ID] = t.ID
+			a.compare("name", c.Name, t.Name)
+			a.compare("description", c.Description, t.Description)
+			a.compare("minSelection", c.MinSelection, t.MinSelection)
+			a.compare("maxSelection", c.MaxSelection, t.MaxSelection)
+			a.syncedIsActive = link.SyncedIsActive
+			if a.override("isActive", c.IsActive, t.IsActive, link.SyncedIsActive, link.SyncedIsActive != nil) {
+				a.syncedIsActive = &c.IsActive
+			}
+		}
+		a.finish()
+		plan.AddonCategories = append(plan.AddonCategories, a)
+
+		for _, item := range c.Items {
+			ia := catalogSyncAction{SourceID: item.ID, Name: item.Name}
+			categoryRef, placed := addonRefs[c.ID]
+			itemLink, itemLinked := links.get(catalogSyncAddonItem, item.ID)
+			ti, itemExists := targetItems[itemLink.TargetID]
+			if itemLinked {
+				ia.TargetID = &itemLink.TargetID
+			}
+			switch {
+			case !placed:
+				ia.Action = catalogSyncSkip
+				ia.Reason = "Addon category was deleted in the branch"
+			case !itemLinked:
+				ia.Action = catalogSyncCreate
+				ia.syncedPrice = &item.Price
+				ia.syncedIsActive = &item.IsActive
+			case !itemExists:
+				ia.Action = catalogSyncSkip
+				ia.Reason = "Deleted in the branch"
+			default:
+				ia.compare("addonCategoryId", categoryRef, targetItemCategory[ti.ID])
+				ia.compare("name", item.Name, ti.Name)
+				ia.compare("description", item.Description, ti.Description)
+				ia.compare("inputType", item.InputType, ti.InputType)
+				ia.compare("displayOrder", item.DisplayOrder, ti.DisplayOrder)
+				ia.syncedPrice = itemLink.SyncedPrice
+				if ia.override("price", item.Price, ti.Price, itemLink.SyncedPrice, itemLink.SyncedPrice != nil) {
+					ia.syncedPrice = &item.Price
+				}
+				ia.syncedIsActive = itemLink.SyncedIsActive
+				if ia.override("isActive", item.IsActive, ti.IsActive, itemLink.SyncedIsActive, itemLink.SyncedIsActive != nil) {
+					ia.syncedIsActive = &item.IsActive
+				}
+				if item.Dietary != nil {
+					ia.compare("dietary", item.Dietary, ti.Dietary)
+				}
+			}
+			ia.finish()
+			plan.AddonItems = append(plan.AddonItems, ia)
+		}
+	}
+
+	linkedCategories := links.targetIDs(catalogSyncCategory)
+	linkedAddons := links.targetIDs(catalogSyncAddonCategory)
+	for _, m := range selected.Menus {
+		a := catalogSyncAction{SourceID: m.ID, Name: m.Name}
+		link, linked := links.get(catalogSyncMenu, m.ID)
+		t, exists := targetMenus[link.TargetID]
+		switch {
+		case !linked:
+			a.Action = catalogSyncCreate
+			a.syncedPrice = &m.Price
+			a.syncedIsActive = &m.IsActive
+			menuRefs[m.ID] = -m.ID
+		case !exists:
+			a.TargetID = &link.TargetID
+			a.Action = catalogSyncSkip
+			a.Reason = "Deleted in the branch"
+		default:
+			a.TargetID = &link.TargetID
+			menuRefs[m.ID] = t.ID
+			a.compare("name", m.Name, t.Name)
+			a.compare("description", m.Description, t.Description)
+			a.compare("imageUrl", m.ImageURL, t.ImageURL)
+			a.compare("imageThumbUrl", m.ImageThumbURL, t.ImageThumbURL)
+			a.compare("isSpicy", m.IsSpicy, t.IsSpicy)
+			a.compare("isBestSeller", m.IsBestSeller, t.IsBestSeller)
+			a.compare("isSignature", m.IsSignature, t.IsSignature)
+			a.compare("isRecommended", m.IsRecommended, t.IsRecommended)
+
+			wantCategories := make([]int64, 0, len(m.CategoryIDs))
+			for _, id := range m.CategoryIDs {
+				if ref, ok := categoryRefs[id]; ok {
+					wantCategories = append(wantCategories, ref)
+				}
+			}
+			haveCategories := make([]int64, 0, len(t.CategoryIDs))
+			for _, id := range t.CategoryIDs {
+				if linkedCategories[id] {
+					haveCategories = append(haveCategories, id)
+				}
+			}
+			sort.Slice(wantCategories, func(i, j int) bool { return wantCategories[i] < wantCategories[j] })
+			sort.Slice(haveCategories, func(i, j int) bool { return haveCategories[i] < haveCategories[j] })
+			a.compare("categoryIds", wantCategories, haveCategories)
+
+			wantAddons := make([]catalogMenuAddon, 0, len(m.AddonCategories))
+			for _, l := range m.AddonCategories {
+				if ref, ok := addonRefs[l.AddonCategoryID]; ok {
+					wantAddons = append(wantAddons, catalogMenuAddon{AddonCategoryID: ref, DisplayOrder: l.DisplayOrder, IsRequired: l.IsRequired})
+				}
+			}
+			haveAddons := make([]catalogMenuAddon, 0, len(t.AddonCategories))
+			for _, l := range t.AddonCategories {
+				if linkedAddons[l.AddonCategoryID] {
+					haveAddons = append(haveAddons, l)
+				}
+			}
+			sort.Slice(wantAddons, func(i, j int) bool { return wantAddons[i].AddonCategoryID < wantAddons[j].AddonCategoryID })
+			sort.Slice(haveAddons, func(i, j int) bool { return haveAddons[i].AddonCategoryID < haveAddons[j].AddonCategoryID })
+			a.compare("addonCategories", wantAddons, haveAddons)
+
+			a.syncedPrice = link.SyncedPrice
+			if a.override("price", m.Price, t.Price, link.SyncedPrice, link.SyncedPrice != nil) {
+				a.syncedPrice = &m.Price
+			}
+			a.syncedIsActive = link.SyncedIsActive
+			if a.override("isActive", m.IsActive, t.IsActive, link.SyncedIsActive, link.SyncedIsActive != nil) {
+				a.syncedIsActive = &m.IsActive
+			}
+			if m.Dietary != nil {
+				a.compare("dietary", m.Dietary, t.Dietary)
+			}
+		}
+		plan.Menus = append(plan.Menus, a)
+
+		for _, v := range m.Variants {
+			va := catalogSyncAction{SourceID: v.ID, Name: v.Name}
+			variantLink, variantLinked := links.get(catalogSyncVariant, v.ID)
+			tv, variantExists := targetVariants[variantLink.TargetID]
+			switch {
+			case a.Action == catalogSyncSkip:
+				va.Action = catalogSyncSkip
+				va.Reason = "Menu was deleted in the branch"
+			case a.Action == catalogSyncCreate || !variantLinked:
+				va.Action = catalogSyncCreate
+				va.syncedPrice = &v.Price
+				va.syncedIsActive = &v.IsActive
+				variantRefs[v.ID] = -v.ID
+			case !variantExists:
+				va.TargetID = &variantLink.TargetID
+				va.Action = catalogSyncSkip
+				va.Reason = "Deleted in the branch"
+			default:
+				va.TargetID = &variantLink.TargetID
+				variantRefs[v.ID] = tv.ID
+				va.compare("name", v.Name, tv.Name)
+				va.compare("sku", v.SKU, tv.SKU)
+				va.compare("displayOrder", v.DisplayOrder, tv.DisplayOrder)
+				va.syncedPrice = variantLink.SyncedPrice
+				if va.override("price", v.Price, tv.Price, variantLink.SyncedPrice, variantLink.SyncedPrice != nil) {
+					va.syncedPrice = &v.Price
+				}
+				va.syncedIsActive = variantLink.SyncedIsActive
+				if va.override("isActive", v.IsActive, tv.IsActive, variantLink.SyncedIsActive, variantLink.SyncedIsActive != nil) {
+					va.syncedIsActive = &v.IsActive
+				}
+			}
+			va.finish()
+			plan.Variants = append(plan.Variants, va)
+		}
+	}
+
+	// Bundles are compared once every menu and variant has a branch
+	// reference, since options may point at menus listed after the bundle.
+	for i, m := range selected.Menus {
+		a := &plan.Menus[i]
+		if a.Action == "" && m.Bundle != nil {
+			a.compare("bundle", syncedCatalogBundle(m.Bundle, menuRefs, variantRefs), targetMenus[*a.TargetID].Bundle)
+		}
+		a.finish()
+	}
+
+	return plan
+}
+
+// syncedCatalogBundle maps a main bundle onto branch IDs. Options whose menu
+// or fixed variant has no branch copy are dropped, and so are slots left
+// without options.
+func syncedCatalogBundle(bundle *catalogBundle, menuIDs, variantIDs map[int64]int64) *catalogBundle {
+	out := &catalogBundle{RevenueAllocation: bundle.RevenueAllocation, Slots: make([]catalogBundleSlot, 0, len(bundle.Slots))}
+	for _, slot := range bundle.Slots {
+		mapped := catalogBundleSlot{Name: slot.Name, MinSelection: slot.MinSelection, MaxSelection: slot.MaxSelection}
+		mapped.Options = make([]catalogBundleOption, 0, len(slot.Options))
+		for _, option := range slot.Options {
+			menuID, ok := menuIDs[option.MenuID]
+			if !ok {
+				continue
+			}
+			mappedOption := catalogBundleOption{MenuID: menuID, Upcharge: option.Upcharge}
+			if option.VariantID != nil {
+				variantID, ok := variantIDs[*option.VariantID]
+				if !ok {
+					continue
+				}
+				mappedOption.VariantID = &variantID
+			}
+			mapped.Options = append(mapped.Options, mappedOption)
+		}
+		if len(mapped.Options) > 0 {
+			out.Slots = append(out.Slots, mapped)
+		}
+	}
+	return out
+}
+
+func loadCatalogSyncLinks(ctx context.Context, q catalogQuerier, mainID, branchID int64) (catalogSyncLinks, error) {
+	rows, err := q.Query(ctx, `
+		select entity_type, source_id, target_id, synced_price, synced_is_active
+		from catalog_sync_links
+		where main_merchant_id = $1 and branch_merchant_id = $2
+	`, mainID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(catalogSyncLinks)
+	for rows.Next() {
+		var (
+			entityType string
+			sourceID   int64
+			link       catalogSyncLink
+			price      pgtype.Numeric
+			isActive   pgtype.Bool
+		)
+		if err := rows.Scan(&entityType, &sourceID, &link.TargetID, &price, &isActive); err != nil {
+			return nil, err
+		}
+		if price.Valid {
+			v := utils.NumericToFloat64(price)
+			link.SyncedPrice = &v
+		}
+		if isActive.Valid {
+			v := isActive.Bool
+			link.SyncedIsActive = &v
+		}
+		if links[entityType] == nil {
+			links[entityType] = make(map[int64]catalogSyncLink)
+		}
+		links[entityType][sourceID] = link
+	}
+	return links, rows.Err()
+}
+
+// applyCatalogSyncTx writes a plan to the branch. Skipped and unchanged
+// entities only refresh their link. Bundles are written last, once every
+// menu and variant they offer has a branch copy.
+func applyCatalogSyncTx(ctx context.Context, tx pgx.Tx, mainID, branchID int64, userID *int64, plan *catalogSyncPlan, links catalogSyncLinks) error {
+	saveLink := func(entityType string, a *catalogSyncAction) error {
+		if a.Action == catalogSyncSkip || a.TargetID == nil {
+			return nil
+		}
+		_, err := tx.Exec(ctx, `
+			insert into catalog_sync_links (
+				main_merchant_id, branch_merchant_id, entity_type, source_id, target_id,
+				synced_price, synced_is_active, last_synced_at, created_at
+			) values ($1, $2, $3, $4, $5, $6, $7, now(), now())
+			on conflict (branch_merchant_id, entity_type, source_id) do update set
+				main_merchant_id = excluded.main_merchant_id,
+				target_id = excluded.target_id,
+				synced_price = excluded.synced_price,
+				synced_is_active = excluded.synced_is_active,
+				last_synced_at = now()
+		`, mainID, branchID, entityType, a.SourceID, *a.TargetID, a.syncedPrice, a.syncedIsActive)
+		return err
+	}
+
+	source := plan.source
+	for i, c := range source.catalog.Categories {
+		a := &plan.Categories[i]
+		switch a.Action {
+		case catalogSyncCreate:
+			var newID int64
+			if err := tx.QueryRow(ctx, `
+				insert into menu_categories (merchant_id, name, description, sort_order, is_active, created_at, updated_at, created_by_user_id)
+				values ($1, $2, $3, $4, $5, now(), now(), $6)
+				returning id
+			`, branchID, c.Name, c.Description, c.SortOrder, c.IsActive, userID).Scan(&newID); err != nil {
+				return err
+			}
+			a.TargetID = &newID
+			source.categoryTargets[c.ID] = newID
+		case catalogSyncUpdate:
+			if _, err := tx.Exec(ctx, `
+				update menu_categories set
+					name = $3, description = $4, sort_order = $5,
+					is_active = case when $6 then $7 else is_active end,
+					updated_at = now(), updated_by_user_id = $8
+				where id = $1 and merchant_id = $2
+			`, *a.TargetID, branchID, c.Name, c.Description, c.SortOrder, a.changed("isActive"), c.IsActive, userID); err != nil {
+				return err
+			}
+		}
+		if err := saveLink(catalogSyncCategory, a); err != nil {
+			return err
+		}
+	}
+
+	itemIndex := 0
+	for i, c := range source.catalog.AddonCategories {
+		a := &plan.AddonCategories[i]
+		switch a.Action {
+		case catalogSyncCreate:
+			var newID int64
+			if err := tx.QueryRow(ctx, `
+				insert into addon_categories (
+					merchant_id, name, description, min_selection, max_selection, is_active,
+					created_at, updated_at, created_by_user_id, updated_by_user_id
+				) values ($1, $2, $3, $4, $5, $6, now(), now(), $7, $7)
+				returning id
+			`, branchID, c.Name, c.Description, c.MinSelection, c.MaxSelection, c.IsActive, userID).Scan(&newID); err != nil {
+				return err
+			}
+			a.TargetID = &newID
+			source.addonTargets[c.ID] = newID
+		case catalogSyncUpdate:
+			if _, err := tx.Exec(ctx, `
+				update addon_categories set
+					name = $3, description = $4, min_selection = $5, max_selection = $6,
+					is_active = case when $7 then $8 else is_active end,
+					updated_at = now(), updated_by_user_id = $9
+				where id = $1 and merchant_id = $2
+			`, *a.TargetID, branchID, c.Name, c.Description, c.MinSelection, c.MaxSelection,
+				a.changed("isActive"), c.IsActive, userID); err != nil {
+				return err
+			}
+		}
+		if err := saveLink(catalogSyncAddonCategory, a); err != nil {
+			return err
+		}
+
+		addonID := source.addonTargets[c.ID]
+		for _, item := range c.Items {
+			ia := &plan.AddonItems[itemIndex]
+			itemIndex++
+			switch ia.Action {
+			case catalogSyncCreate:
+				var stockQty *int32
+				if item.TrackStock {
+					zero := int32(0)
+					stockQty = &zero
+				}
+				var newID int64
+				if err := tx.QueryRow(ctx, `
+					insert into addon_items (
+						addon_category_id, name, description, price, input_type, display_order, is_active,
+						track_stock, stock_qty, created_at, updated_at, created_by_user_id, updated_by_user_id
+					) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now(), $10, $10)
+					returning id
+				`, addonID, item.Name, item.Description, item.Price, item.InputType, item.DisplayOrder,
+					item.IsActive, item.TrackStock, stockQty, userID).Scan(&newID); err != nil {
+					return err
+				}
+				ia.TargetID = &newID
+			case catalogSyncUpdate:
+				if _, err := tx.Exec(ctx, `
+					update addon_items set
+						addon_category_id = $2, name = $3, description = $4, input_type = $5, display_order = $6,
+						price = case when $7 then $8 else price end,
+						is_active = case when $9 then $10 else is_active end,
+						updated_at = now(), updated_by_user_id = $11
+					where id = $1
+				`, *ia.TargetID, addonID, item.Name, item.Description, item.InputType, item.DisplayOrder,
+					ia.changed("price"), item.Price, ia.changed("isActive"), item.IsActive, userID); err != nil {
+					return err
+				}
+			}
+			if item.Dietary != nil && (ia.Action == catalogSyncCreate || ia.changed("dietary")) {
+				if err := replaceDietaryAttributesTx(ctx, tx, dietaryTableAddonItems, branchID, *ia.TargetID, *item.Dietary, userID); err != nil {
+					return err
+				}
+			}
+			if err := saveLink(catalogSyncAddonItem, ia); err != nil {
+				return err
+			}
+		}
+	}
+
+	linkedCategories := mapKeys(links.targetIDs(catalogSyncCategory))
+	linkedAddons := mapKeys(links.targetIDs(catalogSyncAddonCategory))
+	for i, m := range source.catalog.Menus {
+		a := &plan.Menus[i]
+		if a.Action == catalogSyncSkip {
+			continue
+		}
+
+		categoryIDs := make([]int64, 0, len(m.CategoryIDs))
+		for _, id := range m.CategoryIDs {
+			if target, ok := source.categoryTargets[id]; ok {
+				categoryIDs = append(categoryIDs, target)
+			}
+		}
+		var primaryCategoryID *int64
+		if len(categoryIDs) > 0 {
+			primaryCategoryID = &categoryIDs[0]
+		}
+		var thumbMeta []byte
+		if len(m.ImageThumbMeta) > 0 {
+			thumbMeta = m.ImageThumbMeta
+		}
+
+		replaceLinks := a.Action == catalogSyncCreate
+		switch a.Action {
+		case catalogSyncCreate:
+			var stockQty *int32
+			if m.TrackStock {
+				zero := int32(0)
+				stockQty = &zero
+			}
+			var newID int64
+			if err := tx.QueryRow(ctx, `
+				insert into menus (
+					merchant_id, name, description, price, category_id, image_url, image_thumb_url, image_thumb_meta,
+					is_active, is_spicy, is_best_seller, is_signature, is_recommended, track_stock, stock_qty,
+					created_at, updated_at, created_by_user_id, updated_by_user_id
+				) values (
+					$1, $2, $3, $4, $5, $6, $7, $8,
+					$9, $10, $11, $12, $13, $14, $15,
+					now(), now(), $16, $16
+				) returning id
+			`, branchID, m.Name, m.Description, m.Price, primaryCategoryID, m.ImageURL, m.ImageThumbURL, thumbMeta,
+				m.IsActive, m.IsSpicy, m.IsBestSeller, m.IsSignature, m.IsRecommended, m.TrackStock, stockQty,
+				userID).Scan(&newID); err != nil {
+				return err
+			}
+			a.TargetID = &newID
+		case catalogSyncUpdate:
+			// The primary category only moves when it is one the sync owns.
+			if _, err := tx.Exec(ctx, `
+				update menus set
+					name = $3, description = $4, image_url = $5, image_thumb_url = $6, image_thumb_meta = $7,
+					is_spicy = $8, is_best_seller = $9, is_signature = $10, is_recommended = $11,
+					price = case when $12 then $13 else price end,
+					is_active = case when $14 then $15 else is_active end,
+					category_id = case
+						when $16 and (category_id is null or category_id = any($17)) then $18
+						else category_id
+					end,
+					updated_at = now(), updated_by_user_id = $19
+				where id = $1 and merchant_id = $2
+			`, *a.TargetID, branchID, m.Name, m.Description, m.ImageURL, m.ImageThumbURL, thumbMeta,
+				m.IsSpicy, m.IsBestSeller, m.IsSignature, m.IsRecommended,
+				a.changed("price"), m.Price, a.changed("isActive"), m.IsActive,
+				a.changed("categoryIds"), linkedCategories, primaryCategoryID, userID); err != nil {
+				return err
+			}
+		}
+		menuID := *a.TargetID
+		source.menuTargets[m.ID] = menuID
+
+		if replaceLinks || a.changed("categoryIds") {
+			if _, err := tx.Exec(ctx, `
+				delete from menu_category_items where menu_id = $1 and category_id = any($2)
+			`, menuID, linkedCategories); err != nil {
+				return err
+			}
+			for _, categoryID := range categoryIDs {
+				if _, err := tx.Exec(ctx, `
+					insert into menu_category_items (menu_id, category_id) values ($1, $2)
+				`, menuID, categoryID); err != nil {
+					return err
+				}
+			}
+		}
+		if replaceLinks || a.changed("addonCategories") {
+			if _, err := tx.Exec(ctx, `
+				delete from menu_addon_categories where menu_id = $1 and addon_category_id = any($2)
+			`, menuID, linkedAddons); err != nil {
+				return err
+			}
+			for _, link := range m.AddonCategories {
+				addonID, ok := source.addonTargets[link.AddonCategoryID]
+				if !ok {
+					continue
+				}
+				if _, err := tx.Exec(ctx, `
+					insert into menu_addon_categories (menu_id, addon_category_id, display_order, is_required, created_at, updated_at)
+					values ($1, $2, $3, $4, now(), now())
+				`, menuID, addonID, link.DisplayOrder, link.IsRequired); err != nil {
+					return err
+				}
+			}
+		}
+		if m.Dietary != nil && (replaceLinks || a.changed("dietary")) {
+			if err := replaceDietaryAttributesTx(ctx, tx, dietaryTableMenus, branchID, menuID, *m.Dietary, userID); err != nil {
+				return err
+			}
+		}
+		if err := saveLink(catalogSyncMenu, a); err != nil {
+			return err
+		}
+	}
+
+	variantIndex := 0
+	for _, m := range source.catalog.Menus {
+		menuID := source.menuTargets[m.ID]
+		for _, v := range m.Variants {
+			va := &plan.Variants[variantIndex]
+			variantIndex++
+			switch va.Action {
+			case catalogSyncCreate:
+				var newID int64
+				if err := tx.QueryRow(ctx, `
+					insert into menu_variants (
+						merchant_id, menu_id, name, sku, price, display_order, is_active, track_stock, stock_qty
+					) values ($1, $2, $3, $4, $5, $6, $7, $8, case when $8 then 0 else null end)
+					returning id
+				`, branchID, menuID, v.Name, v.SKU, v.Price, v.DisplayOrder, v.IsActive, v.TrackStock).Scan(&newID); err != nil {
+					return err
+				}
+				va.TargetID = &newID
+			case catalogSyncUpdate:
+				if _, err := tx.Exec(ctx, `
+					update menu_variants set
+						name = $2, sku = $3, display_order = $4,
+						price = case when $5 then $6 else price end,
+						is_active = case when $7 then $8 else is_active end,
+						updated_at = now()
+					where id = $1
+				`, *va.TargetID, v.Name, v.SKU, v.DisplayOrder,
+					va.changed("price"), v.Price, va.changed("isActive"), v.IsActive); err != nil {
+					return err
+				}
+			}
+			if va.Action != catalogSyncSkip {
+				source.variantTargets[v.ID] = *va.TargetID
+			}
+			if err := saveLink(catalogSyncVariant, va); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, m := range source.catalog.Menus {
+		a := &plan.Menus[i]
+		if a.Action == catalogSyncSkip || m.Bundle == nil || (a.Action != catalogSyncCreate && !a.changed("bundle")) {
+			continue
+		}
+		bundle := syncedCatalogBundle(m.Bundle, source.menuTargets, source.variantTargets)
+		if a.Action == catalogSyncCreate && len(bundle.Slots) == 0 {
+			continue
+		}
+		if err := writeMenuBundleTx(ctx, tx, branchID, *a.TargetID, bundle.RevenueAllocation, bundle.menuBundleSlots()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapKeys(m map[int64]bool) []int64 {
+	out := make([]int64, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+type catalogSyncBranch struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type catalogSyncRequest struct {
+	mainID    int64
+	userID    int64
+	branches  []catalogSyncBranch
+	selection catalogSyncSelection
+	source    menuCatalog
+}
+
+// prepareCatalogSync validates a preview or sync request: the caller owns
+// the merchant, it is a main merchant, and every branch belongs to it.
+func (h *Handler) prepareCatalogSync(w http.ResponseWriter, r *http.Request) (*catalogSyncRequest, bool) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_REQUIRED", "Merchant context required")
+		return nil, false
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return nil, false
+	}
+	mainID := *authCtx.MerchantID
+
+	var body struct {
+		BranchIDs        []any `json:"branchIds"`
+		CategoryIDs      []any `json:"categoryIds"`
+		MenuIDs          []any `json:"menuIds"`
+		AddonCategoryIDs []any `json:"addonCategoryIds"`
+		AddonItemIDs     []any `json:"addonItemIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return nil, false
+	}
+	branchIDs, err := parseAnyIDList(body.BranchIDs)
+	if err != nil || len(branchIDs) == 0 {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "branchIds must list at least one branch")
+		return nil, false
+	}
+	if len(branchIDs) > catalogSyncMaxBranches {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("At most %d branches can be synced at once", catalogSyncMaxBranches))
+		return nil, false
+	}
+
+	var selection catalogSyncSelection
+	for _, field := range []struct {
+		name string
+		raw  []any
+		dest *[]int64
+	}{
+		{"categoryIds", body.CategoryIDs, &selection.CategoryIDs},
+		{"menuIds", body.MenuIDs, &selection.MenuIDs},
+		{"addonCategoryIds", body.AddonCategoryIDs, &selection.AddonCategoryIDs},
+		{"addonItemIds", body.AddonItemIDs, &selection.AddonItemIDs},
+	} {
+		ids, err := parseAnyIDList(field.raw)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", field.name+" must be a list of ids")
+			return nil, false
+		}
+		*field.dest = ids
+	}
+
+	var parentID pgtype.Int8
+	if err := h.DB.QueryRow(ctx, `select parent_merchant_id from merchants where id = $1`, mainID).Scan(&parentID); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return nil, false
+	}
+	if parentID.Valid {
+		response.Error(w, http.StatusBadRequest, "NOT_MAIN_MERCHANT", "Catalog sync runs from the main merchant")
+		return nil, false
+	}
+	if !h.ownerHasMerchant(ctx, authCtx.UserID, mainID) {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "You do not have access to this merchant group")
+		return nil, false
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select id, name from merchants where parent_merchant_id = $1 and id = any($2) order by name, id
+	`, mainID, branchIDs)
+	if err != nil {
+		h.Logger.Error("catalog sync branches query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load branches")
+		return nil, false
+	}
+	branches := make([]catalogSyncBranch, 0, len(branchIDs))
+	found := make(map[int64]bool, len(branchIDs))
+	for rows.Next() {
+		var b catalogSyncBranch
+		if err := rows.Scan(&b.ID, &b.Name); err != nil {
+			rows.Close()
+			h.Logger.Error("catalog sync branches scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load branches")
+			return nil, false
+		}
+		found[b.ID] = true
+		branches = append(branches, b)
+	}
+	rows.Close()
+	for _, id := range branchIDs {
+		if !found[id] {
+			response.Error(w, http.StatusBadRequest, "BRANCH_NOT_FOUND", fmt.Sprintf("Merchant %d is not a branch of this merchant", id))
+			return nil, false
+		}
+	}
+
+	source, err := loadMenuCatalog(ctx, h.DB, mainID)
+	if err != nil {
+		h.Logger.Error("catalog sync source load failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load main menu")
+		return nil, false
+	}
+	// Unknown selection IDs are reported once, before any branch is planned.
+	if _, err := selectCatalogForSync(source, nil, selection); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return nil, false
+	}
+
+	return &catalogSyncRequest{
+		mainID:    mainID,
+		userID:    authCtx.UserID,
+		branches:  branches,
+		selection: selection,
+		source:    source,
+	}, true
+}
+
+func planCatalogSyncFor(ctx context.Context, q catalogQuerier, req *catalogSyncRequest, branchID int64) (catalogSyncPlan, error) {
+	links, err := loadCatalogSyncLinks(ctx, q, req.mainID, branchID)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	target, err := loadMenuCatalog(ctx, q, branchID)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	selected, err := selectCatalogForSync(req.source, links, req.selection)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	return planCatalogSync(selected, target, links), nil
+}
+
+// MerchantCatalogSyncPreview shows, per branch, what a sync would create,
+// update, leave alone or skip, and which branch overrides it keeps.
+func (h *Handler) MerchantCatalogSyncPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := h.prepareCatalogSync(w, r)
+	if !ok {
+		return
+	}
+
+	results := make([]map[string]any, 0, len(req.branches))
+	for _, branch := range req.branches {
+		plan, err := planCatalogSyncFor(ctx, h.DB, req, branch.ID)
+		if err != nil {
+			h.Logger.Error("catalog sync preview failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to preview catalog sync")
+			return
+		}
+		results = append(results, map[string]any{
+			"branch":  branch,
+			"summary": plan.summary(),
+			"changes": plan,
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"selection": req.selection, "branches": results},
+		"message":    "Catalog sync preview generated",
+		"statusCode": 200,
+	})
+}
+
+// MerchantCatalogSync pushes the selection to each branch in its own
+// transaction and records a history entry per branch.
+func (h *Handler) MerchantCatalogSync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := h.prepareCatalogSync(w, r)
+	if !ok {
+		return
+	}
+
+	selectionJSON, _ := json.Marshal(req.selection)
+	results := make([]map[string]any, 0, len(req.branches))
+	failed := 0
+	for _, branch := range req.branches {
+		plan, err := h.syncCatalogToBranch(ctx, req, branch.ID)
+		status := "SUCCESS"
+		var errorMessage *string
+		if err != nil {
+			h.Logger.Error("catalog sync failed", zapError(err))
+			status = "FAILED"
+			msg := "Sync failed; no changes were made to this branch"
+			errorMessage = &msg
+			failed++
+			plan = catalogSyncPlan{}
+		}
+
+		summary := plan.summary()
+		summaryJSON, _ := json.Marshal(summary)
+		actionsJSON, _ := json.Marshal(plan)
+		var runID int64
+		if err := h.DB.QueryRow(ctx, `
+			insert into catalog_sync_runs (
+				main_merchant_id, branch_merchant_id, status, selection, summary, actions,
+				error_message, created_by_user_id, created_at
+			) values ($1, $2, $3, $4, $5, $6, $7, $8, now())
+			returning id
+		`, req.mainID, branch.ID, status, selectionJSON, summaryJSON, actionsJSON, errorMessage, req.userID).Scan(&runID); err != nil {
+			h.Logger.Warn("catalog sync run record failed", zapError(err))
+		}
+
+		result := map[string]any{
+			"branch":  branch,
+			"status":  status,
+			"summary": summary,
+			"runId":   int64ToString(runID),
+		}
+		if errorMessage != nil {
+			result["error"] = *errorMessage
+		}
+		results = append(results, result)
+	}
+
+	message := "Catalog synced to branches"
+	if failed > 0 {
+		message = fmt.Sprintf("Catalog sync failed for %d of %d branches", failed, len(req.branches))
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    failed == 0,
+		"data":       map[string]any{"branches": results},
+		"message":    message,
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) syncCatalogToBranch(ctx context.Context, req *catalogSyncRequest, branchID int64) (catalogSyncPlan, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Serialize syncs to the same branch.
+	if _, err := tx.Exec(ctx, `select id from merchants where id = $1 for update`, branchID); err != nil {
+		return catalogSyncPlan{}, err
+	}
+	links, err := loadCatalogSyncLinks(ctx, tx, req.mainID, branchID)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	target, err := loadMenuCatalog(ctx, tx, branchID)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	selected, err := selectCatalogForSync(req.source, links, req.selection)
+	if err != nil {
+		return catalogSyncPlan{}, err
+	}
+	plan := planCatalogSync(selected, target, links)
+	userID := req.userID
+	if err := applyCatalogSyncTx(ctx, tx, req.mainID, branchID, &userID, &plan, links); err != nil {
+		return plan, err
+	}
+	if err := bumpMenuCacheVersion(ctx, tx, branchID); err != nil {
+		return plan, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// MerchantCatalogSyncHistory lists past syncs from this main merchant.
+func (h *Handler) MerchantCatalogSyncHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_REQUIRED", "Merchant context required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	limit := parseIntQuery(r, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	var branchID *int64
+	if raw := strings.TrimSpace(r.URL.Query().Get("branchId")); raw != "" {
+		id, err := parseStringToInt64(raw)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid branchId")
+			return
+		}
+		branchID = &id
+	}
+	includeActions := r.URL.Query().Get("includeActions") == "true"
+
+	rows, err := h.DB.Query(ctx, `
+		select r.id, r.branch_merchant_id, m.name, r.status, r.selection, r.summary,
+			case when $4 then r.actions else null end, r.error_message, r.created_by_user_id, r.created_at
+		from catalog_sync_runs r
+		left join merchants m on m.id = r.branch_merchant_id
+		where r.main_merchant_id = $1 and ($2::bigint is null or r.branch_merchant_id = $2)
+		order by r.created_at desc, r.id desc
+		limit $3
+	`, *authCtx.MerchantID, branchID, limit, includeActions)
+	if err != nil {
+		h.Logger.Error("catalog sync history query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load catalog sync history")
+		return
+	}
+	defer rows.Close()
+
+	runs := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			id           int64
+			branch       int64
+			branchName   pgtype.Text
+			status       string
+			selection    []byte
+			summary      []byte
+			actions      []byte
+			errorMessage pgtype.Text
+			createdBy    pgtype.Int8
+			createdAt    time.Time
+		)
+		if err := rows.Scan(&id, &branch, &branchName, &status, &selection, &summary, &actions,
+			&errorMessage, &createdBy, &createdAt); err != nil {
+			h.Logger.Error("catalog sync history scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load catalog sync history")
+			return
+		}
+		run := map[string]any{
+			"id":              int64ToString(id),
+			"branch":          map[string]any{"id": branch, "name": ptrString(branchName)},
+			"status":          status,
+			"selection":       json.RawMessage(selection),
+			"summary":         json.RawMessage(summary),
+			"errorMessage":    ptrString(errorMessage),
+			"createdByUserId": nil,
+			"createdAt":       createdAt,
+		}
+		if createdBy.Valid {
+			run["createdByUserId"] = int64ToString(createdBy.Int64)
+		}
+		if includeActions && len(actions) > 0 {
+			run["actions"] = json.RawMessage(actions)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		h.Logger.Error("catalog sync history rows failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load catalog sync history")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       runs,
+		"message":    "Catalog sync history retrieved",
+		"statusCode": 200,
+	})
+}
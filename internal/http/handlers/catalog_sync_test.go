@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSyncMainCatalog() menuCatalog {
+	return menuCatalog{
+		Categories: []catalogCategory{
+			{ID: 1, Name: "Mains", SortOrder: 1, IsActive: true},
+			{ID: 2, Name: "Drinks", SortOrder: 2, IsActive: true},
+		},
+		AddonCategories: []catalogAddonCategory{
+			{ID: 10, Name: "Sauce", IsActive: true, Items: []catalogAddonItem{
+				{ID: 100, Name: "Chili", Price: 1, InputType: "SELECT", IsActive: true},
+			}},
+			{ID: 11, Name: "Ice", IsActive: true, Items: []catalogAddonItem{}},
+		},
+		Menus: []catalogMenu{
+			{ID: 20, Name: "Burger", Price: 10, IsActive: true, CategoryIDs: []int64{1},
+				AddonCategories: []catalogMenuAddon{{AddonCategoryID: 10}}},
+			{ID: 21, Name: "Tea", Price: 3, IsActive: true, CategoryIDs: []int64{2},
+				AddonCategories: []catalogMenuAddon{{AddonCategoryID: 11}}},
+		},
+	}
+}
+
+func syncCatalogIDs(c menuCatalog) []int64 {
+	ids := make([]int64, 0)
+	for _, cat := range c.Categories {
+		ids = append(ids, cat.ID)
+	}
+	for _, cat := range c.AddonCategories {
+		ids = append(ids, cat.ID)
+	}
+	for _, m := range c.Menus {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestSelectCatalogForSync(t *testing.T) {
+	cases := []struct {
+		name    string
+		sel     catalogSyncSelection
+		links   catalogSyncLinks
+		want    []int64
+		wantErr string
+	}{
+		{"menu brings its links", catalogSyncSelection{MenuIDs: []int64{20}}, nil, []int64{1, 10, 20}, ""},
+		{"category brings its menus", catalogSyncSelection{CategoryIDs: []int64{2}}, nil, []int64{2, 11, 21}, ""},
+		{"addon item brings its category", catalogSyncSelection{AddonItemIDs: []int64{100}}, nil, []int64{10}, ""},
+		{"linked entities propagate", catalogSyncSelection{}, catalogSyncLinks{catalogSyncMenu: {21: {TargetID: 51}}}, []int64{2, 11, 21}, ""},
+		{"linked category does not pull menus", catalogSyncSelection{}, catalogSyncLinks{catalogSyncCategory: {1: {TargetID: 31}}}, []int64{1}, ""},
+		{"unknown menu", catalogSyncSelection{MenuIDs: []int64{99}}, nil, nil, "Menu 99 not found"},
+		{"bundle brings its options", catalogSyncSelection{MenuIDs: []int64{22}}, nil, []int64{1, 2, 10, 11, 20, 21, 22}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := selectCatalogForSync(testSyncBundleCatalog(), tc.links, tc.sel)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ids := syncCatalogIDs(got); !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("selected %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanCatalogSync(t *testing.T) {
+	price := 10.0
+	active := true
+	main := testSyncMainCatalog()
+	main.Menus[0].Name = "Cheeseburger"
+	main.Menus[0].Price = 12
+	selected := menuCatalog{Categories: main.Categories[:1], AddonCategories: main.AddonCategories[:1], Menus: main.Menus[:1]}
+
+	cases := []struct {
+		name       string
+		target     menuCatalog
+		links      catalogSyncLinks
+		wantMenu   catalogSyncAction
+		wantCounts map[string]int
+	}{
+		{
+			name:       "new branch",
+			target:     menuCatalog{},
+			links:      catalogSyncLinks{},
+			wantMenu:   catalogSyncAction{Action: catalogSyncCreate},
+			wantCounts: map[string]int{"create": 4, "update": 0, "unchanged": 0, "skipped": 0, "keptOverrides": 0},
+		},
+		{
+			name: "price follows main until overridden",
+			target: menuCatalog{
+				Categories: []catalogCategory{{ID: 31, Name: "Mains", SortOrder: 1, IsActive: true}},
+				AddonCategories: []catalogAddonCategory{{ID: 40, Name: "Sauce", IsActive: true, Items: []catalogAddonItem{
+					{ID: 400, Name: "Chili", Price: 1, InputType: "SELECT", IsActive: true},
+				}}},
+				Menus: []catalogMenu{{ID: 50, Name: "Burger", Price: 10, IsActive: true, CategoryIDs: []int64{31, 99},
+					AddonCategories: []catalogMenuAddon{{AddonCategoryID: 40}}}},
+			},
+			links: catalogSyncLinks{
+				catalogSyncCategory:      {1: {TargetID: 31, SyncedIsActive: &active}},
+				catalogSyncAddonCategory: {10: {TargetID: 40, SyncedIsActive: &active}},
+				catalogSyncAddonItem:     {100: {TargetID: 400, SyncedPrice: ptrFloat(1), SyncedIsActive: &active}},
+				catalogSyncMenu:          {20: {TargetID: 50, SyncedPrice: &price, SyncedIsActive: &active}},
+			},
+			wantMenu:   catalogSyncAction{Action: catalogSyncUpdate, Changes: []string{"name", "price"}},
+			wantCounts: map[string]int{"create": 0, "update": 1, "unchanged": 3, "skipped": 0, "keptOverrides": 0},
+		},
+		{
+			name: "branch override kept",
+			target: menuCatalog{
+				Categories: []catalogCategory{{ID: 31, Name: "Mains", SortOrder: 1, IsActive: true}},
+				Menus: []catalogMenu{{ID: 50, Name: "Cheeseburger", Price: 9, IsActive: false, CategoryIDs: []int64{31},
+					AddonCategories: []catalogMenuAddon{}}},
+			},
+			links: catalogSyncLinks{
+				catalogSyncCategory:      {1: {TargetID: 31, SyncedIsActive: &active}},
+				catalogSyncAddonCategory: {10: {TargetID: 40, SyncedIsActive: &active}},
+				catalogSyncMenu:          {20: {TargetID: 50, SyncedPrice: &price, SyncedIsActive: &active}},
+			},
+			wantMenu:   catalogSyncAction{Action: catalogSyncUnchanged, KeptOverrides: []string{"price", "isActive"}},
+			wantCounts: map[string]int{"create": 0, "update": 0, "unchanged": 2, "skipped": 2, "keptOverrides": 2},
+		},
+		{
+			name:       "deleted in branch",
+			target:     menuCatalog{},
+			links:      catalogSyncLinks{catalogSyncMenu: {20: {TargetID: 50}}},
+			wantMenu:   catalogSyncAction{Action: catalogSyncSkip},
+			wantCounts: map[string]int{"create": 3, "update": 0, "unchanged": 0, "skipped": 1, "keptOverrides": 0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := planCatalogSync(selected, tc.target, tc.links)
+			if got := plan.summary(); !reflect.DeepEqual(got, tc.wantCounts) {
+				t.Fatalf("summary %v, want %v", got, tc.wantCounts)
+			}
+			menu := plan.Menus[0]
+			if menu.Action != tc.wantMenu.Action ||
+				!reflect.DeepEqual(menu.Changes, tc.wantMenu.Changes) ||
+				!reflect.DeepEqual(menu.KeptOverrides, tc.wantMenu.KeptOverrides) {
+				t.Fatalf("menu action %+v, want %+v", menu, tc.wantMenu)
+			}
+		})
+	}
+}
+
+func TestPlanCatalogSyncKeepsOverrideBaseline(t *testing.T) {
+	synced := 10.0
+	main := testSyncMainCatalog()
+	main.Menus[0].Price = 12
+	target := menuCatalog{Menus: []catalogMenu{{ID: 50, Name: "Burger", Price: 9, IsActive: true}}}
+	links := catalogSyncLinks{catalogSyncMenu: {20: {TargetID: 50, SyncedPrice: &synced}}}
+
+	plan := planCatalogSync(menuCatalog{Menus: main.Menus[:1]}, target, links)
+	if got := plan.Menus[0].syncedPrice; got == nil || *got != 10 {
+		t.Fatalf("overridden price should keep the old baseline, got %v", got)
+	}
+}
+
+// testSyncBundleCatalog adds Burger variants and a combo bundle offering
+// the double burger and tea.
+func testSyncBundleCatalog() menuCatalog {
+	c := testSyncMainCatalog()
+	double := int64(201)
+	c.Menus[0].Variants = []catalogVariant{
+		{ID: 200, Name: "Single", Price: 10, IsActive: true},
+		{ID: 201, Name: "Double", Price: 14, DisplayOrder: 1, IsActive: true},
+	}
+	c.Menus[0].Dietary = &dietaryAttributes{Allergens: []string{"GLUTEN"}, DietaryTags: []string{}}
+	c.Menus = append(c.Menus, catalogMenu{ID: 22, Name: "Combo", Price: 15, IsActive: true, CategoryIDs: []int64{1},
+		Bundle: &catalogBundle{RevenueAllocation: bundleAllocationListPrice, Slots: []catalogBundleSlot{
+			{Name: "Burger", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 20, VariantID: &double}}},
+			{Name: "Drink", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 21, Upcharge: 1}}},
+		}}})
+	return c
+}
+
+func TestPlanCatalogSyncVariantsAndBundles(t *testing.T) {
+	active := true
+	main := testSyncBundleCatalog()
+	selected := menuCatalog{Menus: []catalogMenu{main.Menus[2], main.Menus[0], main.Menus[1]}}
+	branchDouble := int64(501)
+
+	target := menuCatalog{Menus: []catalogMenu{
+		{ID: 50, Name: "Burger", Price: 10, IsActive: true,
+			Variants: []catalogVariant{{ID: 500, Name: "Single", Price: 11, IsActive: true}},
+			Dietary:  &dietaryAttributes{Allergens: []string{"GLUTEN"}, DietaryTags: []string{}}},
+		{ID: 51, Name: "Tea", Price: 3, IsActive: true},
+		{ID: 52, Name: "Combo", Price: 15, IsActive: true,
+			Bundle: &catalogBundle{RevenueAllocation: bundleAllocationListPrice, Slots: []catalogBundleSlot{
+				{Name: "Burger", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 50, VariantID: &branchDouble}}},
+				{Name: "Drink", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 51, Upcharge: 1}}},
+			}}},
+	}}
+	links := catalogSyncLinks{
+		catalogSyncMenu: {
+			20: {TargetID: 50, SyncedPrice: ptrFloat(10), SyncedIsActive: &active},
+			21: {TargetID: 51, SyncedPrice: ptrFloat(3), SyncedIsActive: &active},
+			22: {TargetID: 52, SyncedPrice: ptrFloat(15), SyncedIsActive: &active},
+		},
+		catalogSyncVariant: {200: {TargetID: 500, SyncedPrice: ptrFloat(10), SyncedIsActive: &active}},
+	}
+
+	plan := planCatalogSync(selected, target, links)
+	if len(plan.Variants) != 2 {
+		t.Fatalf("expected two variant actions, got %+v", plan.Variants)
+	}
+	if single := plan.Variants[0]; single.Action != catalogSyncUnchanged || !reflect.DeepEqual(single.KeptOverrides, []string{"price"}) {
+		t.Fatalf("branch variant price should be kept, got %+v", single)
+	}
+	if double := plan.Variants[1]; double.Action != catalogSyncCreate {
+		t.Fatalf("expected the new variant to be created, got %+v", double)
+	}
+	// The branch slot points at a variant the sync did not create.
+	if combo := plan.Menus[0]; combo.Action != catalogSyncUpdate || !reflect.DeepEqual(combo.Changes, []string{"bundle"}) {
+		t.Fatalf("expected a bundle change, got %+v", combo)
+	}
+	if burger := plan.Menus[1]; burger.Action != catalogSyncUnchanged {
+		t.Fatalf("matching dietary attributes should not change, got %+v", burger)
+	}
+
+	links[catalogSyncVariant][201] = catalogSyncLink{TargetID: 501, SyncedPrice: ptrFloat(14), SyncedIsActive: &active}
+	target.Menus[0].Variants = append(target.Menus[0].Variants, catalogVariant{ID: 501, Name: "Double", Price: 14, DisplayOrder: 1, IsActive: true})
+	target.Menus[0].Dietary = &dietaryAttributes{Allergens: []string{}, DietaryTags: []string{}}
+	plan = planCatalogSync(selected, target, links)
+	if combo := plan.Menus[0]; combo.Action != catalogSyncUnchanged {
+		t.Fatalf("mapped bundle should match, got %+v", combo)
+	}
+	if burger := plan.Menus[1]; !reflect.DeepEqual(burger.Changes, []string{"dietary"}) {
+		t.Fatalf("expected a dietary change, got %+v", burger)
+	}
+
+	// Variants of a menu deleted in the branch are skipped with it, and
+	// bundles drop the options they offered.
+	target.Menus = target.Menus[1:]
+	plan = planCatalogSync(selected, target, links)
+	if plan.Variants[0].Action != catalogSyncSkip || plan.Variants[1].Action != catalogSyncSkip {
+		t.Fatalf("expected skipped variants, got %+v", plan.Variants)
+	}
+	bundle := syncedCatalogBundle(main.Menus[2].Bundle, map[int64]int64{21: 51}, map[int64]int64{})
+	if len(bundle.Slots) != 1 || bundle.Slots[0].Name != "Drink" || bundle.Slots[0].Options[0].MenuID != 51 {
+		t.Fatalf("expected only the drink slot, got %+v", bundle.Slots)
+	}
+}
+
+func ptrFloat(v float64) *float64 {
+	return &v
+}
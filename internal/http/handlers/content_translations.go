@@ -0,0 +1,656 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	translationEntityMenu          = "MENU"
+	translationEntityMenuCategory  = "MENU_CATEGORY"
+	translationEntityAddonCategory = "ADDON_CATEGORY"
+	translationEntityAddonItem     = "ADDON_ITEM"
+	translationEntityMerchant      = "MERCHANT"
+
+	translationFieldName          = "name"
+	translationFieldDescription   = "description"
+	translationFieldReceiptFooter = "receiptFooter"
+
+	translationMaxBatch       = 500
+	translationMaxValueLength = 1000
+	translationMaxChainLength = 6
+)
+
+// translationFields lists the translatable fields of each entity type.
+var translationFields = map[string][]string{
+	translationEntityMenu:          {translationFieldName, translationFieldDescription},
+	translationEntityMenuCategory:  {translationFieldName, translationFieldDescription},
+	translationEntityAddonCategory: {translationFieldName, translationFieldDescription},
+	translationEntityAddonItem:     {translationFieldName, translationFieldDescription},
+	translationEntityMerchant:      {translationFieldReceiptFooter},
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8}){0,2}$`)
+
+// normalizeLocale lower-cases a BCP 47 style tag and accepts "_" as the
+// subtag separator.
+func normalizeLocale(raw string) (string, bool) {
+	locale := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(raw), "_", "-"))
+	if !localePattern.MatchString(locale) {
+		return "", false
+	}
+	return locale, true
+}
+
+// localeChain returns the locales to try, most preferred first. ?lang= wins
+// over Accept-Language. Each tag is followed by its shorter parents, so
+// "zh-hant-tw" falls back to "zh-hant" and then "zh". The source text is the
+// implicit last step.
+func localeChain(lang, acceptLanguage string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	candidates := make([]weighted, 0)
+	if locale, ok := normalizeLocale(lang); ok {
+		candidates = append(candidates, weighted{locale: locale, q: 1})
+	} else {
+		for _, part := range strings.Split(acceptLanguage, ",") {
+			fields := strings.Split(strings.TrimSpace(part), ";")
+			locale, ok := normalizeLocale(fields[0])
+			if !ok {
+				continue
+			}
+			q := 1.0
+			for _, param := range fields[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+			if q <= 0 {
+				continue
+			}
+			candidates = append(candidates, weighted{locale: locale, q: q})
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	}
+
+	chain := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		parts := strings.Split(candidate.locale, "-")
+		for n := len(parts); n > 0; n-- {
+			locale := strings.Join(parts[:n], "-")
+			if seen[locale] {
+				continue
+			}
+			seen[locale] = true
+			chain = append(chain, locale)
+			if len(chain) == translationMaxChainLength {
+				return chain
+			}
+		}
+	}
+	return chain
+}
+
+func translationKey(entityType string, entityID int64, field string) string {
+	return entityType + ":" + strconv.FormatInt(entityID, 10) + ":" + field
+}
+
+// contentTranslator resolves translated text along a locale chain. A nil
+// translator returns the source text unchanged.
+type contentTranslator struct {
+	chain  []string
+	values map[string]map[string]string
+}
+
+func newContentTranslator(chain []string) *contentTranslator {
+	return &contentTranslator{chain: chain, values: make(map[string]map[string]string)}
+}
+
+func (t *contentTranslator) add(entityType string, entityID int64, field, locale, value string) {
+	key := translationKey(entityType, entityID, field)
+	if t.values[key] == nil {
+		t.values[key] = make(map[string]string)
+	}
+	t.values[key][locale] = value
+}
+
+func (t *contentTranslator) lookup(entityType string, entityID int64, field string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	byLocale := t.values[translationKey(entityType, entityID, field)]
+	for _, locale := range t.chain {
+		if value, ok := byLocale[locale]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (t *contentTranslator) text(entityType string, entityID int64, field, source string) string {
+	if value, ok := t.lookup(entityType, entityID, field); ok {
+		return value
+	}
+	return source
+}
+
+func (t *contentTranslator) textPtr(entityType string, entityID int64, field string, source *string) *string {
+	if value, ok := t.lookup(entityType, entityID, field); ok {
+		return &value
+	}
+	return source
+}
+
+// contentLanguage is the first locale of the chain that has any
+// translation, or "" when only source text will be served.
+func (t *contentTranslator) contentLanguage() string {
+	if t == nil {
+		return ""
+	}
+	for _, locale := range t.chain {
+		for _, byLocale := range t.values {
+			if _, ok := byLocale[locale]; ok {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// loadContentTranslator reads the requested locale from the request and
+// loads the merchant's translations for it. It sets Vary and, when a
+// translation applies, Content-Language on w.
+func (h *Handler) loadContentTranslator(ctx context.Context, w http.ResponseWriter, r *http.Request, merchantID int64) *contentTranslator {
+	w.Header().Add("Vary", "Accept-Language")
+	chain := localeChain(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	if len(chain) == 0 {
+		return nil
+	}
+	rows, err := h.DB.Query(ctx, `
+		select entity_type, entity_id, field, locale, value
+		from content_translations
+		where merchant_id = $1 and locale = any($2)
+	`, merchantID, chain)
+	if err != nil {
+		h.Logger.Warn("content translations lookup failed", zapError(err))
+		return nil
+	}
+	defer rows.Close()
+	translator := newContentTranslator(chain)
+	for rows.Next() {
+		var (
+			entityType, field, locale, value string
+			entityID                         int64
+		)
+		if err := rows.Scan(&entityType, &entityID, &field, &locale, &value); err != nil {
+			continue
+		}
+		translator.add(entityType, entityID, field, locale, value)
+	}
+	if language := translator.contentLanguage(); language != "" {
+		w.Header().Set("Content-Language", language)
+	}
+	return translator
+}
+
+func (t *contentTranslator) translateMenuItems(menus []publicMenuItem) {
+	if t == nil {
+		return
+	}
+	for i := range menus {
+		menus[i].Name = t.text(translationEntityMenu, menus[i].ID, translationFieldName, menus[i].Name)
+		menus[i].Description = t.textPtr(translationEntityMenu, menus[i].ID, translationFieldDescription, menus[i].Description)
+	}
+}
+
+func (t *contentTranslator) translateAddonCategories(addonMap map[int64][]publicAddonCategory) {
+	if t == nil {
+		return
+	}
+	for _, categories := range addonMap {
+		for i := range categories {
+			category := &categories[i]
+			category.Name = t.text(translationEntityAddonCategory, category.ID, translationFieldName, category.Name)
+			category.Description = t.textPtr(translationEntityAddonCategory, category.ID, translationFieldDescription, category.Description)
+			for j := range category.AddonItems {
+				item := &category.AddonItems[j]
+				item.Name = t.text(translationEntityAddonItem, item.ID, translationFieldName, item.Name)
+				item.Description = t.textPtr(translationEntityAddonItem, item.ID, translationFieldDescription, item.Description)
+			}
+		}
+	}
+}
+
+// translateCategoryRefs translates the {id, name} category maps attached to
+// menus in list and search responses.
+func (t *contentTranslator) translateCategoryRefs(refs map[int64][]map[string]any) {
+	if t == nil {
+		return
+	}
+	for _, categories := range refs {
+		for _, category := range categories {
+			id, ok := category["id"].(int64)
+			name, nameOk := category["name"].(string)
+			if ok && nameOk {
+				category["name"] = t.text(translationEntityMenuCategory, id, translationFieldName, name)
+			}
+		}
+	}
+}
+
+type contentTranslationInput struct {
+	EntityType string  `json:"entityType"`
+	EntityID   any     `json:"entityId"`
+	Field      string  `json:"field"`
+	Locale     string  `json:"locale"`
+	Value      *string `json:"value"`
+}
+
+type contentTranslation struct {
+	EntityType string `json:"entityType"`
+	EntityID   int64  `json:"entityId"`
+	Field      string `json:"field"`
+	Locale     string `json:"locale"`
+	// Value is empty when the translation should be removed.
+	Value string `json:"value"`
+}
+
+func normalizeTranslationInputs(inputs []contentTranslationInput) ([]contentTranslation, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("translations are required")
+	}
+	if len(inputs) > translationMaxBatch {
+		return nil, fmt.Errorf("At most %d translations can be saved at once", translationMaxBatch)
+	}
+	out := make([]contentTranslation, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		entityType := strings.ToUpper(strings.TrimSpace(input.EntityType))
+		fields, ok := translationFields[entityType]
+		if !ok {
+			return nil, fmt.Errorf("translations[%d]: unknown entityType", i)
+		}
+		entityID, ok := parseNumericID(input.EntityID)
+		if !ok {
+			return nil, fmt.Errorf("translations[%d]: invalid entityId", i)
+		}
+		field := strings.TrimSpace(input.Field)
+		if !containsString(fields, field) {
+			return nil, fmt.Errorf("translations[%d]: %s has no field %q", i, entityType, field)
+		}
+		locale, ok := normalizeLocale(input.Locale)
+		if !ok {
+			return nil, fmt.Errorf("translations[%d]: invalid locale", i)
+		}
+		value := ""
+		if input.Value != nil {
+			value = strings.TrimSpace(*input.Value)
+		}
+		if len(value) > translationMaxValueLength {
+			return nil, fmt.Errorf("translations[%d]: value must be at most %d characters", i, translationMaxValueLength)
+		}
+		key := translationKey(entityType, entityID, field) + ":" + locale
+		if seen[key] {
+			return nil, fmt.Errorf("translations[%d]: duplicate entry", i)
+		}
+		seen[key] = true
+		out = append(out, contentTranslation{EntityType: entityType, EntityID: entityID, Field: field, Locale: locale, Value: value})
+	}
+	return out, nil
+}
+
+// translationUnit is one piece of source text that can be translated.
+type translationUnit struct {
+	EntityType string
+	EntityID   int64
+	Field      string
+}
+
+// loadTranslationUnits lists the merchant's translatable source text.
+// Descriptions and the receipt footer only count when they are set.
+func (h *Handler) loadTranslationUnits(ctx context.Context, merchantID int64) ([]translationUnit, error) {
+	queries := []struct {
+		entityType string
+		sql        string
+	}{
+		{translationEntityMenu, `select id, coalesce(description, '') <> '' from menus where merchant_id = $1 and deleted_at is null`},
+		{translationEntityMenuCategory, `select id, coalesce(description, '') <> '' from menu_categories where merchant_id = $1 and deleted_at is null`},
+		{translationEntityAddonCategory, `select id, coalesce(description, '') <> '' from addon_categories where merchant_id = $1 and deleted_at is null`},
+		{translationEntityAddonItem, `
+			select ai.id, coalesce(ai.description, '') <> ''
+			from addon_items ai
+			join addon_categories ac on ac.id = ai.addon_category_id
+			where ac.merchant_id = $1 and ai.deleted_at is null
+		`},
+	}
+
+	units := make([]translationUnit, 0)
+	for _, q := range queries {
+		rows, err := h.DB.Query(ctx, q.sql, merchantID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				id             int64
+				hasDescription bool
+			)
+			if err := rows.Scan(&id, &hasDescription); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			units = append(units, translationUnit{EntityType: q.entityType, EntityID: id, Field: translationFieldName})
+			if hasDescription {
+				units = append(units, translationUnit{EntityType: q.entityType, EntityID: id, Field: translationFieldDescription})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var footer pgtype.Text
+	if err := h.DB.QueryRow(ctx, `
+		select receipt_settings->>'customFooterText' from merchants where id = $1
+	`, merchantID).Scan(&footer); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(footer.String) != "" {
+		units = append(units, translationUnit{EntityType: translationEntityMerchant, EntityID: merchantID, Field: translationFieldReceiptFooter})
+	}
+	return units, nil
+}
+
+// buildTranslationCoverage reports, per locale, how many units have a
+// translation. translated maps locale to the translation keys present.
+// Translations whose source text no longer exists are ignored.
+func buildTranslationCoverage(units []translationUnit, translated map[string]map[string]bool, locales []string) []map[string]any {
+	out := make([]map[string]any, 0, len(locales))
+	for _, locale := range locales {
+		type counts struct{ total, done int }
+		byType := make(map[string]*counts)
+		total, done := 0, 0
+		for _, unit := range units {
+			c := byType[unit.EntityType]
+			if c == nil {
+				c = &counts{}
+				byType[unit.EntityType] = c
+			}
+			c.total++
+			total++
+			if translated[locale][translationKey(unit.EntityType, unit.EntityID, unit.Field)] {
+				c.done++
+				done++
+			}
+		}
+		entities := make(map[string]any, len(byType))
+		for entityType, c := range byType {
+			entities[entityType] = map[string]any{
+				"total":      c.total,
+				"translated": c.done,
+				"percentage": coveragePercentage(c.done, c.total),
+			}
+		}
+		out = append(out, map[string]any{
+			"locale":     locale,
+			"total":      total,
+			"translated": done,
+			"missing":    total - done,
+			"percentage": coveragePercentage(done, total),
+			"byEntity":   entities,
+		})
+	}
+	return out
+}
+
+func coveragePercentage(done, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round2(float64(done) / float64(total) * 100)
+}
+
+// MerchantTranslationsList lists stored translations, optionally filtered by
+// locale and entityType.
+func (h *Handler) MerchantTranslationsList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	where := "merchant_id = $1"
+	args := []any{*authCtx.MerchantID}
+	if raw := r.URL.Query().Get("locale"); raw != "" {
+		locale, ok := normalizeLocale(raw)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid locale")
+			return
+		}
+		where += " and locale = $" + strconv.Itoa(len(args)+1)
+		args = append(args, locale)
+	}
+	if raw := r.URL.Query().Get("entityType"); raw != "" {
+		entityType := strings.ToUpper(strings.TrimSpace(raw))
+		if _, ok := translationFields[entityType]; !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid entityType")
+			return
+		}
+		where += " and entity_type = $" + strconv.Itoa(len(args)+1)
+		args = append(args, entityType)
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select entity_type, entity_id, field, locale, value
+		from content_translations
+		where `+where+`
+		order by entity_type, entity_id, field, locale
+	`, args...)
+	if err != nil {
+		h.Logger.Error("translations query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve translations")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]contentTranslation, 0)
+	for rows.Next() {
+		var item contentTranslation
+		if err := rows.Scan(&item.EntityType, &item.EntityID, &item.Field, &item.Locale, &item.Value); err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve translations")
+			return
+		}
+		items = append(items, item)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       items,
+		"message":    "Translations retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantTranslationsUpsert saves translations in bulk. Entries with an
+// empty value remove the stored translation.
+func (h *Handler) MerchantTranslationsUpsert(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body struct {
+		Translations []contentTranslationInput `json:"translations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	translations, err := normalizeTranslationInputs(body.Translations)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	units, err := h.loadTranslationUnits(ctx, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("translation units query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save translations")
+		return
+	}
+	entities := make(map[string]bool, len(units))
+	for _, unit := range units {
+		entities[translationKey(unit.EntityType, unit.EntityID, "")] = true
+	}
+	// The footer can be translated before it is set in receipt settings.
+	entities[translationKey(translationEntityMerchant, *authCtx.MerchantID, "")] = true
+	for _, t := range translations {
+		if !entities[translationKey(t.EntityType, t.EntityID, "")] {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("%s %d not found", t.EntityType, t.EntityID))
+			return
+		}
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save translations")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	saved, removed := 0, 0
+	for _, t := range translations {
+		if t.Value == "" {
+			tag, err := tx.Exec(ctx, `
+				delete from content_translations
+				where merchant_id = $1 and entity_type = $2 and entity_id = $3 and field = $4 and locale = $5
+			`, *authCtx.MerchantID, t.EntityType, t.EntityID, t.Field, t.Locale)
+			if err != nil {
+				h.Logger.Error("translation delete failed", zapError(err))
+				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save translations")
+				return
+			}
+			removed += int(tag.RowsAffected())
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			insert into content_translations (merchant_id, entity_type, entity_id, field, locale, value, updated_by_user_id)
+			values ($1, $2, $3, $4, $5, $6, $7)
+			on conflict (merchant_id, entity_type, entity_id, field, locale)
+			do update set value = excluded.value, updated_at = now(), updated_by_user_id = excluded.updated_by_user_id
+		`, *authCtx.MerchantID, t.EntityType, t.EntityID, t.Field, t.Locale, t.Value, authCtx.UserID); err != nil {
+			h.Logger.Error("translation upsert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save translations")
+			return
+		}
+		saved++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save translations")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"saved":   saved,
+			"removed": removed,
+		},
+		"message":    "Translations saved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantTranslationsCoverage reports translation coverage per locale.
+// Locales are those with stored translations plus any given in ?locale=.
+func (h *Handler) MerchantTranslationsCoverage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	units, err := h.loadTranslationUnits(ctx, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("translation units query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build translation coverage")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select entity_type, entity_id, field, locale
+		from content_translations
+		where merchant_id = $1
+	`, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("translations query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build translation coverage")
+		return
+	}
+	translated := make(map[string]map[string]bool)
+	for rows.Next() {
+		var (
+			entityType, field, locale string
+			entityID                  int64
+		)
+		if err := rows.Scan(&entityType, &entityID, &field, &locale); err != nil {
+			continue
+		}
+		if translated[locale] == nil {
+			translated[locale] = make(map[string]bool)
+		}
+		translated[locale][translationKey(entityType, entityID, field)] = true
+	}
+	rows.Close()
+
+	for _, raw := range strings.Split(r.URL.Query().Get("locale"), ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		locale, ok := normalizeLocale(raw)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid locale")
+			return
+		}
+		if translated[locale] == nil {
+			translated[locale] = make(map[string]bool)
+		}
+	}
+	locales := make([]string, 0, len(translated))
+	for locale := range translated {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"totalUnits": len(units),
+			"locales":    buildTranslationCoverage(units, translated, locales),
+		},
+		"message":    "Translation coverage retrieved successfully",
+		"statusCode": 200,
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocaleChain(t *testing.T) {
+	cases := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		want           string
+	}{
+		{"empty", "", "", ""},
+		{"lang wins", "pt_BR", "de", "pt-br,pt"},
+		{"invalid lang falls back to header", "???", "de", "de"},
+		{"weights", "", "fr;q=0.5, en-US, de;q=0.8", "en-us,en,de,fr"},
+		{"zero weight and wildcard", "", "*, fr;q=0, id", "id"},
+		{"script subtag", "zh-Hant-TW", "", "zh-hant-tw,zh-hant,zh"},
+		{"duplicates", "", "en-GB, en, en-US", "en-gb,en,en-us"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := strings.Join(localeChain(tc.lang, tc.acceptLanguage), ",")
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	long := localeChain("", "a1-b2-c3, aa-bb-cc, ab-cd-ef, ac")
+	if len(long) != translationMaxChainLength {
+		t.Fatalf("expected chain to be capped, got %v", long)
+	}
+}
+
+func TestContentTranslator(t *testing.T) {
+	translator := newContentTranslator([]string{"zh-tw", "zh"})
+	translator.add(translationEntityMenu, 1, translationFieldName, "zh", "茶")
+	translator.add(translationEntityMenu, 1, translationFieldName, "zh-tw", "奶茶")
+	translator.add(translationEntityMenu, 2, translationFieldName, "zh", "咖啡")
+
+	if got := translator.text(translationEntityMenu, 1, translationFieldName, "Milk tea"); got != "奶茶" {
+		t.Fatalf("expected most specific locale, got %q", got)
+	}
+	if got := translator.text(translationEntityMenu, 2, translationFieldName, "Coffee"); got != "咖啡" {
+		t.Fatalf("expected parent locale, got %q", got)
+	}
+	description := "Hot"
+	if got := translator.textPtr(translationEntityMenu, 2, translationFieldDescription, &description); got != &description {
+		t.Fatalf("expected source description, got %v", got)
+	}
+	if got := translator.contentLanguage(); got != "zh-tw" {
+		t.Fatalf("expected zh-tw content language, got %q", got)
+	}
+
+	var none *contentTranslator
+	if got := none.text(translationEntityMenu, 1, translationFieldName, "Milk tea"); got != "Milk tea" {
+		t.Fatalf("expected nil translator to keep source, got %q", got)
+	}
+	refs := map[int64][]map[string]any{1: {{"id": int64(5), "name": "Drinks"}}}
+	translator.add(translationEntityMenuCategory, 5, translationFieldName, "zh", "饮料")
+	translator.translateCategoryRefs(refs)
+	if refs[1][0]["name"] != "饮料" {
+		t.Fatalf("expected translated category ref, got %v", refs)
+	}
+}
+
+func TestNormalizeTranslationInputs(t *testing.T) {
+	value := func(v string) *string { return &v }
+
+	got, err := normalizeTranslationInputs([]contentTranslationInput{
+		{EntityType: "menu", EntityID: "3", Field: "name", Locale: "EN_us", Value: value(" Tea ")},
+		{EntityType: "MERCHANT", EntityID: 1, Field: "receiptFooter", Locale: "id"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].EntityType != translationEntityMenu || got[0].EntityID != 3 || got[0].Locale != "en-us" || got[0].Value != "Tea" {
+		t.Fatalf("unexpected first translation: %+v", got[0])
+	}
+	if got[1].Value != "" {
+		t.Fatalf("expected removal entry, got %+v", got[1])
+	}
+
+	cases := []struct {
+		name   string
+		inputs []contentTranslationInput
+	}{
+		{"empty", nil},
+		{"unknown entity", []contentTranslationInput{{EntityType: "ORDER", EntityID: 1, Field: "name", Locale: "en"}}},
+		{"unknown field", []contentTranslationInput{{EntityType: "MENU", EntityID: 1, Field: "price", Locale: "en"}}},
+		{"footer on menu", []contentTranslationInput{{EntityType: "MENU", EntityID: 1, Field: "receiptFooter", Locale: "en"}}},
+		{"invalid id", []contentTranslationInput{{EntityType: "MENU", EntityID: "x", Field: "name", Locale: "en"}}},
+		{"invalid locale", []contentTranslationInput{{EntityType: "MENU", EntityID: 1, Field: "name", Locale: "english"}}},
+		{"too long", []contentTranslationInput{{EntityType: "MENU", EntityID: 1, Field: "name", Locale: "en", Value: value(strings.Repeat("a", translationMaxValueLength+1))}}},
+		{"duplicate", []contentTranslationInput{
+			{EntityType: "MENU", EntityID: 1, Field: "name", Locale: "en"},
+			{EntityType: "menu", EntityID: "1", Field: "name", Locale: "EN"},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := normalizeTranslationInputs(tc.inputs); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestBuildTranslationCoverage(t *testing.T) {
+	units := []translationUnit{
+		{EntityType: translationEntityMenu, EntityID: 1, Field: translationFieldName},
+		{EntityType: translationEntityMenu, EntityID: 1, Field: translationFieldDescription},
+		{EntityType: translationEntityMenuCategory, EntityID: 2, Field: translationFieldName},
+		{EntityType: translationEntityMenu, EntityID: 3, Field: translationFieldName},
+	}
+	translated := map[string]map[string]bool{
+		"en": {
+			translationKey(translationEntityMenu, 1, translationFieldName):         true,
+			translationKey(translationEntityMenuCategory, 2, translationFieldName): true,
+			translationKey(translationEntityMenu, 9, translationFieldName):         true,
+		},
+	}
+
+	got := buildTranslationCoverage(units, translated, []string{"de", "en"})
+	if got[0]["locale"] != "de" || got[0]["translated"] != 0 || got[0]["percentage"] != 0.0 {
+		t.Fatalf("unexpected de coverage: %+v", got[0])
+	}
+	if got[1]["translated"] != 2 || got[1]["missing"] != 2 || got[1]["percentage"] != 50.0 {
+		t.Fatalf("unexpected en coverage: %+v", got[1])
+	}
+	menus := got[1]["byEntity"].(map[string]any)[translationEntityMenu].(map[string]any)
+	if menus["total"] != 3 || menus["translated"] != 1 || menus["percentage"] != 33.33 {
+		t.Fatalf("unexpected menu coverage: %+v", menus)
+	}
+}
@@ -0,0 +1,662 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/courier"
+	"genfity-order-services/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	courierModeOwnFleet = "OWN_FLEET"
+	courierModeCourier  = "COURIER"
+
+	courierSweepInterval = 30 * time.Second
+	// Bookings updated more recently than this (usually by a webhook) are not
+	// polled again.
+	courierPollMinAge = 20 * time.Second
+	maxCourierZones   = 100
+)
+
+var courierActiveStatuses = []string{courier.StatusAssigned, courier.StatusPickedUp, courier.StatusArrived}
+
+var (
+	errCourierUnavailable   = errors.New("courier provider is not available")
+	errCourierAlreadyBooked = errors.New("order already has an active courier booking")
+	errCourierOrderInvalid  = errors.New("order cannot be handed to a courier")
+	errCourierNoLocation    = errors.New("order has no delivery coordinates")
+)
+
+// courierSettings choose, per delivery zone, whether READY orders go to the
+// merchant's own drivers or to a third-party courier. The first entry in
+// Zones that matches the order's delivery point wins; unmatched orders use
+// DefaultMode. Provider is the courier used when a zone entry names none.
+type courierSettings struct {
+	DefaultMode string             `json:"defaultMode"`
+	Provider    string             `json:"provider"`
+	AutoBook    bool               `json:"autoBook"`
+	Zones       []courierZoneFleet `json:"zones"`
+}
+
+type courierZoneFleet struct {
+	ZoneID   int64  `json:"zoneId"`
+	Mode     string `json:"mode"`
+	Provider string `json:"provider,omitempty"`
+}
+
+type courierRoute struct {
+	Mode     string
+	Provider string
+	ZoneID   *int64
+}
+
+func parseCourierSettings(features []byte) courierSettings {
+	var wrapper struct {
+		CourierDispatch *courierSettings `json:"courierDispatch"`
+	}
+	if len(features) == 0 || json.Unmarshal(features, &wrapper) != nil || wrapper.CourierDispatch == nil {
+		return courierSettings{DefaultMode: courierModeOwnFleet}
+	}
+	settings := *wrapper.CourierDispatch
+	if settings.DefaultMode != courierModeCourier {
+		settings.DefaultMode = courierModeOwnFleet
+	}
+	return settings
+}
+
+func validateCourierSettings(settings *courierSettings, providers []string) error {
+	settings.DefaultMode = strings.ToUpper(strings.TrimSpace(settings.DefaultMode))
+	if settings.DefaultMode == "" {
+		settings.DefaultMode = courierModeOwnFleet
+	}
+	if settings.DefaultMode != courierModeOwnFleet && settings.DefaultMode != courierModeCourier {
+		return errors.New("defaultMode must be OWN_FLEET or COURIER")
+	}
+	settings.Provider = strings.ToLower(strings.TrimSpace(settings.Provider))
+	if settings.Provider != "" && !containsString(providers, settings.Provider) {
+		return fmt.Errorf("Unknown courier provider %q", settings.Provider)
+	}
+	if len(settings.Zones) > maxCourierZones {
+		return fmt.Errorf("At most %d zone entries are allowed", maxCourierZones)
+	}
+
+	needsProvider := settings.DefaultMode == courierModeCourier
+	seen := map[int64]bool{}
+	for i := range settings.Zones {
+		zone := &settings.Zones[i]
+		if zone.ZoneID <= 0 {
+			return errors.New("Zone entry zoneId is required")
+		}
+		if seen[zone.ZoneID] {
+			return errors.New("Each zone can only be listed once")
+		}
+		seen[zone.ZoneID] = true
+		zone.Mode = strings.ToUpper(strings.TrimSpace(zone.Mode))
+		if zone.Mode != courierModeOwnFleet && zone.Mode != courierModeCourier {
+			return errors.New("Zone mode must be OWN_FLEET or COURIER")
+		}
+		zone.Provider = strings.ToLower(strings.TrimSpace(zone.Provider))
+		if zone.Provider != "" && !containsString(providers, zone.Provider) {
+			return fmt.Errorf("Unknown courier provider %q", zone.Provider)
+		}
+		if zone.Mode == courierModeCourier && zone.Provider == "" {
+			needsProvider = true
+		}
+		if zone.Mode == courierModeOwnFleet {
+			zone.Provider = ""
+		}
+	}
+	if needsProvider && settings.Provider == "" {
+		return errors.New("provider is required when a zone uses COURIER")
+	}
+	return nil
+}
+
+// resolveCourierRoute picks the fleet for an order whose delivery point lies
+// in zoneIDs.
+func resolveCourierRoute(settings courierSettings, zoneIDs []int64) courierRoute {
+	for _, zone := range settings.Zones {
+		if !containsInt64(zoneIDs, zone.ZoneID) {
+			continue
+		}
+		zoneID := zone.ZoneID
+		if zone.Mode != courierModeCourier {
+			return courierRoute{Mode: courierModeOwnFleet, ZoneID: &zoneID}
+		}
+		provider := zone.Provider
+		if provider == "" {
+			provider = settings.Provider
+		}
+		return courierRoute{Mode: courierModeCourier, Provider: provider, ZoneID: &zoneID}
+	}
+	if settings.DefaultMode == courierModeCourier {
+		return courierRoute{Mode: courierModeCourier, Provider: settings.Provider}
+	}
+	return courierRoute{Mode: courierModeOwnFleet}
+}
+
+// courierDeliveryStatus maps a provider status onto the order delivery status.
+func courierDeliveryStatus(status string) string {
+	if status == courier.StatusCancelled {
+		return "FAILED"
+	}
+	return status
+}
+
+// courierStatusAdvances reports whether a courier update moves the delivery
+// forward. Couriers may skip intermediate states (a webhook can be lost), but
+// never go backwards, and nothing moves a finished delivery.
+func courierStatusAdvances(current, next string) bool {
+	rank := map[string]int{"ASSIGNED": 1, "PICKED_UP": 2, "ARRIVED": 3, "DELIVERED": 4}
+	currentRank, active := rank[current]
+	if !active || current == "DELIVERED" {
+		return false
+	}
+	if next == "FAILED" {
+		return true
+	}
+	nextRank, ok := rank[next]
+	return ok && nextRank > currentRank
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+type courierOrder struct {
+	OrderID         int64
+	MerchantID      int64
+	OrderNumber     string
+	OrderType       string
+	Status          string
+	DeliveryStatus  pgtype.Text
+	DeliveryLat     pgtype.Numeric
+	DeliveryLng     pgtype.Numeric
+	DeliveryAddress pgtype.Text
+	Instructions    pgtype.Text
+	DistanceKm      pgtype.Numeric
+	CustomerName    pgtype.Text
+	CustomerPhone   pgtype.Text
+	MerchantName    string
+	MerchantPhone   pgtype.Text
+	MerchantAddress pgtype.Text
+	MerchantLat     pgtype.Numeric
+	MerchantLng     pgtype.Numeric
+	Features        []byte
+}
+
+func (h *Handler) loadCourierOrder(ctx context.Context, merchantID, orderID int64) (courierOrder, error) {
+	var o courierOrder
+	err := h.DB.QueryRow(ctx, `
+		select o.id, o.merchant_id, o.order_number, o.order_type, o.status, `+EffectiveDeliveryStatusSQL+`,
+		       o.delivery_latitude, o.delivery_longitude, o.delivery_address, o.delivery_instructions, o.delivery_distance_km,
+		       c.name, c.phone,
+		       m.name, m.phone, m.address, m.latitude, m.longitude, m.features
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		left join customers c on c.id = o.customer_id
+		`+DeliveryProgressJoinSQL+`
+		where o.id = $1 and o.merchant_id = $2
+	`, orderID, merchantID).Scan(
+		&o.OrderID, &o.MerchantID, &o.OrderNumber, &o.OrderType, &o.Status, &o.DeliveryStatus,
+		&o.DeliveryLat, &o.DeliveryLng, &o.DeliveryAddress, &o.Instructions, &o.DistanceKm,
+		&o.CustomerName, &o.CustomerPhone,
+		&o.MerchantName, &o.MerchantPhone, &o.MerchantAddress, &o.MerchantLat, &o.MerchantLng, &o.Features,
+	)
+	return o, err
+}
+
+func (o courierOrder) hasCoordinates() bool {
+	return o.DeliveryLat.Valid && o.DeliveryLng.Valid && o.MerchantLat.Valid && o.MerchantLng.Valid
+}
+
+func (o courierOrder) distanceKm() float64 {
+	if o.DistanceKm.Valid {
+		return utils.NumericToFloat64(o.DistanceKm)
+	}
+	if !o.hasCoordinates() {
+		return 0
+	}
+	return deliveryRound3(haversineDistanceKm(
+		utils.NumericToFloat64(o.MerchantLat), utils.NumericToFloat64(o.MerchantLng),
+		utils.NumericToFloat64(o.DeliveryLat), utils.NumericToFloat64(o.DeliveryLng),
+	))
+}
+
+func (o courierOrder) quoteRequest() courier.QuoteRequest {
+	return courier.QuoteRequest{
+		Pickup: courier.Location{
+			Lat:     utils.NumericToFloat64(o.MerchantLat),
+			Lng:     utils.NumericToFloat64(o.MerchantLng),
+			Address: o.MerchantAddress.String,
+		},
+		Dropoff: courier.Location{
+			Lat:     utils.NumericToFloat64(o.DeliveryLat),
+			Lng:     utils.NumericToFloat64(o.DeliveryLng),
+			Address: o.DeliveryAddress.String,
+		},
+		DistanceKm: o.distanceKm(),
+	}
+}
+
+// routeCourierOrder resolves the fleet for an order from its delivery zone.
+func (h *Handler) routeCourierOrder(ctx context.Context, o courierOrder) (courierRoute, error) {
+	settings := parseCourierSettings(o.Features)
+	if !o.hasCoordinates() {
+		return resolveCourierRoute(settings, nil), nil
+	}
+	zoneIDs, err := h.matchDeliveryZoneIDs(ctx, o.MerchantID,
+		utils.NumericToFloat64(o.MerchantLat), utils.NumericToFloat64(o.MerchantLng),
+		utils.NumericToFloat64(o.DeliveryLat), utils.NumericToFloat64(o.DeliveryLng))
+	if err != nil && !errors.Is(err, errNoZonesConfigured) {
+		return courierRoute{}, err
+	}
+	return resolveCourierRoute(settings, zoneIDs), nil
+}
+
+func (h *Handler) courierCallbackURL(provider string) string {
+	base := strings.TrimRight(strings.TrimSpace(h.Config.CourierCallbackBaseURL), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/api/public/couriers/" + provider + "/webhook"
+}
+
+// bookCourier hands a delivery order to a courier and marks it ASSIGNED. Any
+// driver assignment and open dispatch offers are withdrawn.
+func (h *Handler) bookCourier(ctx context.Context, o courierOrder, providerName string, userID *int64) error {
+	provider, ok := h.Couriers.Get(providerName)
+	if !ok {
+		return errCourierUnavailable
+	}
+	if o.OrderType != "DELIVERY" || o.Status == "CANCELLED" || o.Status == "COMPLETED" ||
+		(o.DeliveryStatus.Valid && (o.DeliveryStatus.String == "DELIVERED" || o.DeliveryStatus.String == "FAILED")) {
+		return errCourierOrderInvalid
+	}
+	if !o.hasCoordinates() {
+		return errCourierNoLocation
+	}
+
+	var active bool
+	if err := h.DB.QueryRow(ctx, `
+		select exists (select 1 from courier_deliveries where order_id = $1 and status = any($2))
+	`, o.OrderID, courierActiveStatuses).Scan(&active); err != nil {
+		return err
+	}
+	if active {
+		return errCourierAlreadyBooked
+	}
+
+	quote := o.quoteRequest()
+	booking, err := provider.Book(ctx, courier.BookingRequest{
+		Reference:     o.OrderNumber,
+		Pickup:        quote.Pickup,
+		Dropoff:       quote.Dropoff,
+		DistanceKm:    quote.DistanceKm,
+		PickupName:    o.MerchantName,
+		PickupPhone:   o.MerchantPhone.String,
+		CustomerName:  o.CustomerName.String,
+		CustomerPhone: o.CustomerPhone.String,
+		Notes:         o.Instructions.String,
+		CallbackURL:   h.courierCallbackURL(provider.Name()),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := h.saveCourierBooking(ctx, o, provider.Name(), booking, userID); err != nil {
+		// The order was booked elsewhere in the meantime; release ours.
+		if cancelErr := provider.Cancel(ctx, booking.ExternalID); cancelErr != nil {
+			h.Logger.Warn("courier booking rollback failed", zapError(cancelErr))
+		}
+		return err
+	}
+	h.cancelOpenDispatchOffers(ctx, o.OrderID, "Handed to courier "+provider.Name())
+	return nil
+}
+
+func (h *Handler) saveCourierBooking(ctx context.Context, o courierOrder, provider string, booking courier.Booking, userID *int64) error {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var now time.Time
+	if err := tx.QueryRow(ctx, `select now()::timestamp`).Scan(&now); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		insert into courier_deliveries (order_id, merchant_id, provider, external_id, status, fee_amount, tracking_url, booked_by_user_id, last_status_at, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, nullif($7, ''), $8, $9, $9, $9)
+	`, o.OrderID, o.MerchantID, provider, booking.ExternalID, courier.StatusAssigned, booking.Fee, booking.TrackingURL, userID, now); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errCourierAlreadyBooked
+		}
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		update orders
+		set delivery_driver_user_id = null,
+			delivery_assigned_at = $3
+		where id = $1 and merchant_id = $2
+	`, o.OrderID, o.MerchantID, now); err != nil {
+		return err
+	}
+	reason := "Booked with courier " + provider
+	if err := recordDeliveryStatus(ctx, tx, o.MerchantID, nil, o.OrderID, "ASSIGNED", &reason, nil, nil, now); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// cancelCourierDelivery cancels the order's active courier booking, if any.
+// The order's delivery status is left to the caller.
+func (h *Handler) cancelCourierDelivery(ctx context.Context, merchantID, orderID int64) error {
+	var (
+		bookingID  int64
+		provider   string
+		externalID string
+	)
+	err := h.DB.QueryRow(ctx, `
+		select id, provider, external_id
+		from courier_deliveries
+		where order_id = $1 and merchant_id = $2 and status = any($3)
+	`, orderID, merchantID, courierActiveStatuses).Scan(&bookingID, &provider, &externalID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if p, ok := h.Couriers.Get(provider); ok {
+		if err := p.Cancel(ctx, externalID); err != nil && !errors.Is(err, courier.ErrBookingNotFound) {
+			return err
+		}
+	}
+	_, err = h.DB.Exec(ctx, `
+		update courier_deliveries
+		set status = 'CANCELLED', cancelled_at = now(), updated_at = now()
+		where id = $1
+	`, bookingID)
+	return err
+}
+
+// autoBookCourier books a courier for a READY order routed to one. It
+// reports whether the order belongs to a courier so own-fleet dispatch can
+// leave it alone, even when the booking itself has to be retried.
+func (h *Handler) autoBookCourier(ctx context.Context, merchantID, orderID int64) (bool, error) {
+	o, err := h.loadCourierOrder(ctx, merchantID, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	settings := parseCourierSettings(o.Features)
+	if !settings.AutoBook || o.OrderType != "DELIVERY" || o.Status != "READY" {
+		return false, nil
+	}
+
+	var handled bool
+	if err := h.DB.QueryRow(ctx, `
+		select o.delivery_driver_user_id is not null
+			or exists (select 1 from courier_deliveries where order_id = o.id and status = any($2))
+			or exists (select 1 from delivery_batch_stops where order_id = o.id)
+		from orders o where o.id = $1
+	`, orderID, courierActiveStatuses).Scan(&handled); err != nil {
+		return false, err
+	}
+	if handled {
+		return false, nil
+	}
+
+	route, err := h.routeCourierOrder(ctx, o)
+	if err != nil {
+		return false, err
+	}
+	if route.Mode != courierModeCourier {
+		return false, nil
+	}
+	if err := h.bookCourier(ctx, o, route.Provider, nil); err != nil {
+		if errors.Is(err, errCourierAlreadyBooked) {
+			return true, nil
+		}
+		return true, err
+	}
+	h.notifyOrderRealtime(ctx, merchantID, o.OrderNumber)
+	h.publishCourierDeliveryEvent(ctx, merchantID, orderID, route.Provider, "ASSIGNED", nil, time.Now())
+	return true, nil
+}
+
+// applyCourierStatus records a provider status update against its booking
+// and the order's delivery status. Stale or repeated updates are ignored.
+func (h *Handler) applyCourierStatus(ctx context.Context, provider string, update courier.StatusUpdate) (bool, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		bookingID      int64
+		orderID        int64
+		merchantID     int64
+		orderNumber    string
+		bookingStatus  string
+		deliveryStatus pgtype.Text
+	)
+	err = tx.QueryRow(ctx, `
+		select cd.id, cd.order_id, cd.merchant_id, o.order_number, cd.status, `+EffectiveDeliveryStatusSQL+`
+		from courier_deliveries cd
+		join orders o on o.id = cd.order_id
+		`+DeliveryProgressJoinSQL+`
+		where cd.provider = $1 and cd.external_id = $2
+		for update of cd, o
+	`, provider, update.ExternalID).Scan(&bookingID, &orderID, &merchantID, &orderNumber, &bookingStatus, &deliveryStatus)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, courier.ErrBookingNotFound
+		}
+		return false, err
+	}
+	if !containsString(courierActiveStatuses, bookingStatus) {
+		return false, nil
+	}
+
+	now := update.OccurredAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if _, err := tx.Exec(ctx, `
+		update courier_deliveries
+		set courier_name = coalesce(nullif($2, ''), courier_name),
+			courier_phone = coalesce(nullif($3, ''), courier_phone),
+			updated_at = now()
+		where id = $1
+	`, bookingID, update.CourierName, update.CourierPhone); err != nil {
+		return false, err
+	}
+
+	next := courierDeliveryStatus(update.Status)
+	if !courierStatusAdvances(deliveryStatus.String, next) {
+		return false, tx.Commit(ctx)
+	}
+
+	var reason *string
+	if update.Reason != "" {
+		reason = &update.Reason
+	} else if update.Status == courier.StatusCancelled {
+		cancelled := "Cancelled by courier"
+		reason = &cancelled
+	}
+	if err := recordDeliveryStatus(ctx, tx, merchantID, nil, orderID, next, reason, update.Latitude, update.Longitude, now); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `
+		update courier_deliveries
+		set status = $2,
+			last_status_at = $3,
+			cancelled_at = case when $2 = 'CANCELLED' then $3 else cancelled_at end
+		where id = $1
+	`, bookingID, update.Status, now); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	h.notifyOrderRealtime(ctx, merchantID, orderNumber)
+	h.publishCourierDeliveryEvent(ctx, merchantID, orderID, provider, next, reason, now)
+	return true, nil
+}
+
+func (h *Handler) publishCourierDeliveryEvent(ctx context.Context, merchantID, orderID int64, provider, status string, reason *string, at time.Time) {
+	if h.Queue == nil {
+		return
+	}
+	event := map[string]any{
+		"type":            "order.delivery.updated",
+		"orderId":         orderID,
+		"merchantId":      merchantID,
+		"deliveryStatus":  status,
+		"reason":          reason,
+		"courierProvider": provider,
+		"updatedAt":       at.UTC(),
+	}
+	_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.delivery.updated", event)
+}
+
+// RunCourierSweeper polls providers for bookings that have not had a webhook
+// recently. It blocks until ctx is done.
+func (h *Handler) RunCourierSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = courierSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepCourierDeliveries(ctx)
+		}
+	}
+}
+
+func (h *Handler) sweepCourierDeliveries(ctx context.Context) {
+	if len(h.Couriers.Names()) == 0 {
+		return
+	}
+	rows, err := h.DB.Query(ctx, `
+		select provider, external_id
+		from courier_deliveries
+		where status = any($1) and updated_at <= now() - $2::interval
+		order by updated_at asc
+		limit 200
+	`, courierActiveStatuses, fmt.Sprintf("%d seconds", int(courierPollMinAge.Seconds())))
+	if err != nil {
+		h.Logger.Warn("courier sweep failed", zapError(err))
+		return
+	}
+	type booking struct{ provider, externalID string }
+	pending := make([]booking, 0)
+	for rows.Next() {
+		var b booking
+		if err := rows.Scan(&b.provider, &b.externalID); err != nil {
+			rows.Close()
+			h.Logger.Warn("courier sweep scan failed", zapError(err))
+			return
+		}
+		pending = append(pending, b)
+	}
+	rows.Close()
+
+	for _, b := range pending {
+		provider, ok := h.Couriers.Get(b.provider)
+		if !ok {
+			continue
+		}
+		update, err := provider.Status(ctx, b.externalID)
+		if err != nil {
+			if !errors.Is(err, courier.ErrStatusUnsupported) {
+				h.Logger.Warn("courier status poll failed", zapError(err))
+			}
+			continue
+		}
+		if _, err := h.DB.Exec(ctx, `
+			update courier_deliveries set updated_at = now() where provider = $1 and external_id = $2
+		`, b.provider, b.externalID); err != nil {
+			h.Logger.Warn("courier sweep touch failed", zapError(err))
+		}
+		if _, err := h.applyCourierStatus(ctx, b.provider, update); err != nil {
+			h.Logger.Warn("courier status apply failed", zapError(err))
+		}
+	}
+}
+
+func (h *Handler) fetchCourierDelivery(ctx context.Context, orderID int64) (map[string]any, error) {
+	var (
+		provider     string
+		externalID   string
+		status       string
+		fee          pgtype.Numeric
+		trackingURL  pgtype.Text
+		courierName  pgtype.Text
+		courierPhone pgtype.Text
+		lastStatusAt pgtype.Timestamptz
+		createdAt    time.Time
+	)
+	err := h.DB.QueryRow(ctx, `
+		select provider, external_id, status, fee_amount, tracking_url, courier_name, courier_phone, last_status_at, created_at
+		from courier_deliveries
+		where order_id = $1
+		order by created_at desc, id desc
+		limit 1
+	`, orderID).Scan(&provider, &externalID, &status, &fee, &trackingURL, &courierName, &courierPhone, &lastStatusAt, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := map[string]any{
+		"provider":     provider,
+		"externalId":   externalID,
+		"status":       status,
+		"fee":          nil,
+		"trackingUrl":  ptrString(trackingURL),
+		"courierName":  ptrString(courierName),
+		"courierPhone": ptrString(courierPhone),
+		"lastStatusAt": nil,
+		"bookedAt":     createdAt,
+	}
+	if fee.Valid {
+		out["fee"] = utils.NumericToFloat64(fee)
+	}
+	if lastStatusAt.Valid {
+		out["lastStatusAt"] = lastStatusAt.Time
+	}
+	return out, nil
+}
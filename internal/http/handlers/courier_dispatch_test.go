@@ -0,0 +1,100 @@
+package handlers
+
+import "testing"
+
+func TestResolveCourierRoute(t *testing.T) {
+	settings := courierSettings{
+		DefaultMode: courierModeOwnFleet,
+		Provider:    "mock",
+		Zones: []courierZoneFleet{
+			{ZoneID: 10, Mode: courierModeOwnFleet},
+			{ZoneID: 20, Mode: courierModeCourier},
+			{ZoneID: 30, Mode: courierModeCourier, Provider: "other"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		settings courierSettings
+		zoneIDs  []int64
+		mode     string
+		provider string
+		zoneID   int64
+	}{
+		{name: "no zone uses the default", settings: settings, zoneIDs: nil, mode: courierModeOwnFleet},
+		{name: "courier zone falls back to merchant provider", settings: settings, zoneIDs: []int64{20}, mode: courierModeCourier, provider: "mock", zoneID: 20},
+		{name: "zone provider wins", settings: settings, zoneIDs: []int64{30}, mode: courierModeCourier, provider: "other", zoneID: 30},
+		{name: "first configured zone wins on overlap", settings: settings, zoneIDs: []int64{30, 10}, mode: courierModeOwnFleet, zoneID: 10},
+		{
+			name:     "courier default for unlisted zones",
+			settings: courierSettings{DefaultMode: courierModeCourier, Provider: "mock"},
+			zoneIDs:  []int64{99},
+			mode:     courierModeCourier,
+			provider: "mock",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			route := resolveCourierRoute(tc.settings, tc.zoneIDs)
+			if route.Mode != tc.mode || route.Provider != tc.provider {
+				t.Fatalf("expected %s/%q, got %s/%q", tc.mode, tc.provider, route.Mode, route.Provider)
+			}
+			if tc.zoneID == 0 && route.ZoneID != nil {
+				t.Fatalf("expected no zone, got %d", *route.ZoneID)
+			}
+			if tc.zoneID != 0 && (route.ZoneID == nil || *route.ZoneID != tc.zoneID) {
+				t.Fatalf("expected zone %d, got %v", tc.zoneID, route.ZoneID)
+			}
+		})
+	}
+}
+
+func TestCourierStatusAdvances(t *testing.T) {
+	cases := []struct {
+		current  string
+		provider string
+		expected bool
+	}{
+		{"ASSIGNED", "PICKED_UP", true},
+		{"ASSIGNED", "DELIVERED", true},
+		{"PICKED_UP", "ASSIGNED", false},
+		{"ARRIVED", "ARRIVED", false},
+		{"ARRIVED", "CANCELLED", true},
+		{"DELIVERED", "FAILED", false},
+		{"FAILED", "DELIVERED", false},
+		{"PENDING_ASSIGNMENT", "PICKED_UP", false},
+	}
+
+	for _, tc := range cases {
+		got := courierStatusAdvances(tc.current, courierDeliveryStatus(tc.provider))
+		if got != tc.expected {
+			t.Fatalf("%s -> %s: expected %v, got %v", tc.current, tc.provider, tc.expected, got)
+		}
+	}
+}
+
+func TestValidateCourierSettings(t *testing.T) {
+	providers := []string{"mock"}
+
+	settings := courierSettings{DefaultMode: "own_fleet", Zones: []courierZoneFleet{{ZoneID: 1, Mode: "courier", Provider: " Mock "}}}
+	if err := validateCourierSettings(&settings, providers); err != nil {
+		t.Fatalf("expected valid settings, got %v", err)
+	}
+	if settings.DefaultMode != courierModeOwnFleet || settings.Zones[0].Mode != courierModeCourier || settings.Zones[0].Provider != "mock" {
+		t.Fatalf("expected normalised settings, got %+v", settings)
+	}
+
+	invalid := []courierSettings{
+		{DefaultMode: "DRONE"},
+		{DefaultMode: courierModeCourier},
+		{Provider: "unknown"},
+		{Zones: []courierZoneFleet{{ZoneID: 1, Mode: courierModeCourier}}},
+		{Provider: "mock", Zones: []courierZoneFleet{{ZoneID: 1, Mode: courierModeOwnFleet}, {ZoneID: 1, Mode: courierModeCourier}}},
+	}
+	for i, s := range invalid {
+		if err := validateCourierSettings(&s, providers); err == nil {
+			t.Fatalf("case %d: expected validation error for %+v", i, s)
+		}
+	}
+}
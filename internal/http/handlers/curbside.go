@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	pickupModeCounter  = "COUNTER"
+	pickupModeCurbside = "CURBSIDE"
+
+	maxCurbsideVehicleLength = 120
+	maxCurbsideSpotLength    = 40
+	maxCurbsideNoteLength    = 200
+)
+
+type curbsideRequest struct {
+	VehicleDescription string
+	ParkingSpot        *string
+}
+
+// parseCurbsideRequest validates the curbside fields of a public order. It
+// returns nil for ordinary counter pickups.
+func parseCurbsideRequest(orderType string, pickupMode, vehicle, parkingSpot *string) (*curbsideRequest, error) {
+	mode := pickupModeCounter
+	if pickupMode != nil && strings.TrimSpace(*pickupMode) != "" {
+		mode = strings.ToUpper(strings.TrimSpace(*pickupMode))
+	}
+	switch mode {
+	case pickupModeCounter:
+		return nil, nil
+	case pickupModeCurbside:
+	default:
+		return nil, errors.New("pickupMode must be COUNTER or CURBSIDE")
+	}
+	if orderType != "TAKEAWAY" {
+		return nil, errors.New("Curbside pickup is only available for TAKEAWAY orders")
+	}
+
+	req := &curbsideRequest{}
+	if vehicle != nil {
+		req.VehicleDescription = strings.TrimSpace(*vehicle)
+	}
+	if req.VehicleDescription == "" {
+		return nil, errors.New("Vehicle description is required for curbside pickup")
+	}
+	if len([]rune(req.VehicleDescription)) > maxCurbsideVehicleLength {
+		return nil, errors.New("Vehicle description is too long (max 120 characters)")
+	}
+	spot, err := normalizeCurbsideSpot(parkingSpot)
+	if err != nil {
+		return nil, err
+	}
+	req.ParkingSpot = spot
+	return req, nil
+}
+
+func normalizeCurbsideSpot(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	spot := strings.TrimSpace(*value)
+	if spot == "" {
+		return nil, nil
+	}
+	if len([]rune(spot)) > maxCurbsideSpotLength {
+		return nil, errors.New("Parking spot is too long (max 40 characters)")
+	}
+	return &spot, nil
+}
+
+func insertCurbsidePickupTx(ctx context.Context, tx pgx.Tx, merchantID, orderID int64, req *curbsideRequest) error {
+	_, err := tx.Exec(ctx, `
+		insert into order_curbside_pickups (order_id, merchant_id, vehicle_description, parking_spot)
+		values ($1, $2, $3, $4)
+	`, orderID, merchantID, req.VehicleDescription, req.ParkingSpot)
+	return err
+}
+
+func scanCurbsidePickup(vehicle string, spot, note pgtype.Text, arrivedAt, handedOverAt pgtype.Timestamptz, waitSeconds pgtype.Int4) *CurbsidePickup {
+	out := &CurbsidePickup{
+		VehicleDescription: vehicle,
+		ParkingSpot:        ptrString(spot),
+		ArrivalNote:        ptrString(note),
+	}
+	if arrivedAt.Valid {
+		t := arrivedAt.Time
+		out.ArrivedAt = &t
+	}
+	if handedOverAt.Valid {
+		t := handedOverAt.Time
+		out.HandedOverAt = &t
+	}
+	if waitSeconds.Valid {
+		v := waitSeconds.Int32
+		out.WaitSeconds = &v
+	}
+	return out
+}
+
+// FetchCurbsidePickup returns the curbside details of an order, or nil for
+// orders collected at the counter.
+func FetchCurbsidePickup(ctx context.Context, db *pgxpool.Pool, orderID int64) (*CurbsidePickup, error) {
+	var (
+		vehicle      string
+		spot         pgtype.Text
+		note         pgtype.Text
+		arrivedAt    pgtype.Timestamptz
+		handedOverAt pgtype.Timestamptz
+		waitSeconds  pgtype.Int4
+	)
+	err := db.QueryRow(ctx, `
+		select vehicle_description, parking_spot, arrival_note, arrived_at, handed_over_at, wait_seconds
+		from order_curbside_pickups
+		where order_id = $1
+	`, orderID).Scan(&vehicle, &spot, &note, &arrivedAt, &handedOverAt, &waitSeconds)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return scanCurbsidePickup(vehicle, spot, note, arrivedAt, handedOverAt, waitSeconds), nil
+}
+
+// AttachCurbsidePickups fills Curbside on the TAKEAWAY orders of a list.
+func AttachCurbsidePickups(ctx context.Context, db *pgxpool.Pool, orders []OrderListItem) error {
+	ids := make([]int64, 0)
+	for _, o := range orders {
+		if o.OrderType == "TAKEAWAY" {
+			ids = append(ids, o.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(ctx, `
+		select order_id, vehicle_description, parking_spot, arrival_note, arrived_at, handed_over_at, wait_seconds
+		from order_curbside_pickups
+		where order_id = any($1)
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byOrder := make(map[int64]*CurbsidePickup, len(ids))
+	for rows.Next() {
+		var (
+			orderID      int64
+			vehicle      string
+			spot         pgtype.Text
+			note         pgtype.Text
+			arrivedAt    pgtype.Timestamptz
+			handedOverAt pgtype.Timestamptz
+			waitSeconds  pgtype.Int4
+		)
+		if err := rows.Scan(&orderID, &vehicle, &spot, &note, &arrivedAt, &handedOverAt, &waitSeconds); err != nil {
+			return err
+		}
+		byOrder[orderID] = scanCurbsidePickup(vehicle, spot, note, arrivedAt, handedOverAt, waitSeconds)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range orders {
+		orders[i].Curbside = byOrder[orders[i].ID]
+	}
+	return nil
+}
+
+type curbsideArrivalRequest struct {
+	ParkingSpot *string `json:"parkingSpot"`
+	Note        *string `json:"note"`
+}
+
+// PublicOrderCurbsideArrived is the customer's "I've arrived" check-in. It is
+// authenticated by the order tracking token and may be repeated to update
+// the parking spot; the arrival time is kept from the first check-in.
+func (h *Handler) PublicOrderCurbsideArrived(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderNumber := readPathString(r, "orderNumber")
+	if orderNumber == "" {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order number is required")
+		return
+	}
+
+	var body curbsideArrivalRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+	spot, err := normalizeCurbsideSpot(body.ParkingSpot)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	var note *string
+	if body.Note != nil && strings.TrimSpace(*body.Note) != "" {
+		trimmed := strings.TrimSpace(*body.Note)
+		if len([]rune(trimmed)) > maxCurbsideNoteLength {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Note is too long (max 200 characters)")
+			return
+		}
+		note = &trimmed
+	}
+
+	var (
+		orderID      int64
+		merchantID   int64
+		merchantCode string
+		status       string
+		customerName pgtype.Text
+		hasCurbside  bool
+	)
+	if err := h.DB.QueryRow(ctx, `
+		select o.id, o.merchant_id, m.code, o.status, c.name, cp.order_id is not null
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		left join customers c on c.id = o.customer_id
+		left join order_curbside_pickups cp on cp.order_id = o.id
+		where o.order_number = $1
+		limit 1
+	`, orderNumber).Scan(&orderID, &merchantID, &merchantCode, &status, &customerName, &hasCurbside); err != nil {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		return
+	}
+	if !utils.VerifyOrderTrackingToken(h.Config.OrderTrackingTokenSecret, r.URL.Query().Get("token"), merchantCode, orderNumber) {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		return
+	}
+	if !hasCurbside {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "This order is not a curbside pickup")
+		return
+	}
+	if status == "COMPLETED" || status == "CANCELLED" {
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "This order is already closed")
+		return
+	}
+
+	var (
+		vehicle      string
+		parkingSpot  pgtype.Text
+		arrivalNote  pgtype.Text
+		arrivedAt    pgtype.Timestamptz
+		handedOverAt pgtype.Timestamptz
+		waitSeconds  pgtype.Int4
+	)
+	if err := h.DB.QueryRow(ctx, `
+		update order_curbside_pickups
+		set arrived_at = coalesce(arrived_at, now()),
+			parking_spot = coalesce($2, parking_spot),
+			arrival_note = coalesce($3, arrival_note),
+			updated_at = now()
+		where order_id = $1
+		returning vehicle_description, parking_spot, arrival_note, arrived_at, handed_over_at, wait_seconds
+	`, orderID, spot, note).Scan(&vehicle, &parkingSpot, &arrivalNote, &arrivedAt, &handedOverAt, &waitSeconds); err != nil {
+		h.Logger.Error("curbside check-in failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record arrival")
+		return
+	}
+	curbside := scanCurbsidePickup(vehicle, parkingSpot, arrivalNote, arrivedAt, handedOverAt, waitSeconds)
+
+	h.notifyMerchantOrderEvent(ctx, merchantID, "customer.arrived", map[string]any{
+		"orderId":      orderID,
+		"orderNumber":  orderNumber,
+		"status":       status,
+		"customerName": ptrString(customerName),
+		"curbside":     curbside,
+	})
+	h.notifyOrderRealtime(ctx, merchantID, orderNumber)
+	if h.Queue != nil {
+		event := map[string]any{
+			"type":               "order.customer.arrived",
+			"orderId":            orderID,
+			"merchantId":         merchantID,
+			"orderNumber":        orderNumber,
+			"vehicleDescription": curbside.VehicleDescription,
+			"parkingSpot":        curbside.ParkingSpot,
+			"arrivedAt":          curbside.ArrivedAt,
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.customer.arrived", event)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       curbside,
+		"message":    "Arrival recorded",
+		"statusCode": 200,
+	})
+}
+
+// MerchantOrderHandover marks a curbside order as handed to the customer,
+// which completes it, and records the wait since the customer's check-in.
+func (h *Handler) MerchantOrderHandover(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to hand over order")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		orderNumber  string
+		orderType    string
+		status       string
+		handedOverAt pgtype.Timestamptz
+	)
+	if err := tx.QueryRow(ctx, `
+		select o.order_number, o.order_type, o.status, cp.handed_over_at
+		from orders o
+		join order_curbside_pickups cp on cp.order_id = o.id
+		where o.id = $1 and o.merchant_id = $2
+		for update of o, cp
+	`, orderID, *authCtx.MerchantID).Scan(&orderNumber, &orderType, &status, &handedOverAt); err != nil {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Curbside order not found")
+		return
+	}
+	if handedOverAt.Valid || status != "READY" {
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "Only READY curbside orders can be handed over")
+		return
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, `
+		update orders
+		set status = 'COMPLETED', completed_at = $2, updated_at = $2
+		where id = $1
+	`, orderID, now); err != nil {
+		h.Logger.Error("curbside handover failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to hand over order")
+		return
+	}
+	if err := markOrderPaidTx(ctx, tx, orderID, orderType, authCtx.UserID, now); err != nil {
+		h.Logger.Error("curbside handover payment failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to hand over order")
+		return
+	}
+	if err := recordCurbsideHandoverTx(ctx, tx, orderID, authCtx.UserID, now); err != nil {
+		h.Logger.Error("curbside handover record failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to hand over order")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to hand over order")
+		return
+	}
+
+	invalidateAnalyticsCacheForMerchant(*authCtx.MerchantID)
+	h.notifyOrderRealtime(ctx, *authCtx.MerchantID, orderNumber)
+	if h.Queue != nil {
+		event := map[string]any{
+			"type":       "order.status.updated",
+			"orderId":    orderID,
+			"merchantId": *authCtx.MerchantID,
+			"status":     "COMPLETED",
+			"note":       "Handed over at curbside",
+			"userId":     authCtx.UserID,
+			"updatedAt":  now.UTC(),
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.status.updated", event)
+	}
+
+	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+		"message": "Order handed over",
+	})
+}
+
+type curbsideWaitSummary struct {
+	Handovers          int      `json:"handovers"`
+	CheckedIn          int      `json:"checkedIn"`
+	AverageWaitSeconds *float64 `json:"averageWaitSeconds"`
+	MedianWaitSeconds  *float64 `json:"medianWaitSeconds"`
+	P90WaitSeconds     *float64 `json:"p90WaitSeconds"`
+	MaxWaitSeconds     *float64 `json:"maxWaitSeconds"`
+}
+
+// summarizeCurbsideWaits aggregates arrival-to-handover waits. Handovers
+// without a check-in count toward Handovers only.
+func summarizeCurbsideWaits(handovers int, waits []float64) curbsideWaitSummary {
+	out := curbsideWaitSummary{Handovers: handovers, CheckedIn: len(waits)}
+	if len(waits) == 0 {
+		return out
+	}
+	sorted := append([]float64(nil), waits...)
+	sort.Float64s(sorted)
+
+	total := 0.0
+	for _, v := range sorted {
+		total += v
+	}
+	avg := deliveryRound2(total / float64(len(sorted)))
+	median := deliveryRound2(percentileSorted(sorted, 0.5))
+	p90 := deliveryRound2(percentileSorted(sorted, 0.9))
+	maxWait := sorted[len(sorted)-1]
+	out.AverageWaitSeconds = &avg
+	out.MedianWaitSeconds = &median
+	out.P90WaitSeconds = &p90
+	out.MaxWaitSeconds = &maxWait
+	return out
+}
+
+// percentileSorted interpolates linearly between the closest ranks.
+func percentileSorted(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+func (h *Handler) buildCurbsideSummary(ctx context.Context, merchantID int64, startDate, endDate time.Time) (curbsideWaitSummary, error) {
+	rows, err := h.DB.Query(ctx, `
+		select wait_seconds
+		from order_curbside_pickups
+		where merchant_id = $1 and handed_over_at >= $2 and handed_over_at <= $3
+	`, merchantID, startDate, endDate)
+	if err != nil {
+		return curbsideWaitSummary{}, err
+	}
+	defer rows.Close()
+
+	handovers := 0
+	waits := make([]float64, 0)
+	for rows.Next() {
+		var wait pgtype.Int4
+		if err := rows.Scan(&wait); err != nil {
+			return curbsideWaitSummary{}, err
+		}
+		handovers++
+		if wait.Valid {
+			waits = append(waits, float64(wait.Int32))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return curbsideWaitSummary{}, err
+	}
+	return summarizeCurbsideWaits(handovers, waits), nil
+}
+
+// recordCurbsideHandoverTx stamps the handover and arrival wait on a curbside
+// order that has not been handed over yet. It is a no-op for other orders, so
+// every path that completes an order can call it.
+func recordCurbsideHandoverTx(ctx context.Context, tx pgx.Tx, orderID, userID int64, now time.Time) error {
+	_, err := tx.Exec(ctx, `
+		update order_curbside_pickups
+		set handed_over_at = $2,
+			handed_over_by_user_id = $3,
+			wait_seconds = case when arrived_at is null then null
+				else greatest(0, extract(epoch from ($2 - arrived_at))::integer) end,
+			updated_at = $2
+		where order_id = $1 and handed_over_at is null
+	`, orderID, now, userID)
+	return err
+}
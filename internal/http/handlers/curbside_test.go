@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCurbsideRequest(t *testing.T) {
+	str := func(v string) *string { return &v }
+
+	cases := []struct {
+		name      string
+		orderType string
+		mode      *string
+		vehicle   *string
+		spot      *string
+		curbside  bool
+		wantErr   bool
+	}{
+		{name: "no mode is counter pickup", orderType: "TAKEAWAY"},
+		{name: "explicit counter", orderType: "TAKEAWAY", mode: str("counter")},
+		{name: "curbside takeaway", orderType: "TAKEAWAY", mode: str(" curbside "), vehicle: str("Red Civic"), spot: str("B4"), curbside: true},
+		{name: "blank spot is dropped", orderType: "TAKEAWAY", mode: str("CURBSIDE"), vehicle: str("Red Civic"), spot: str("  "), curbside: true},
+		{name: "unknown mode", orderType: "TAKEAWAY", mode: str("DRIVE_THRU"), wantErr: true},
+		{name: "curbside only for takeaway", orderType: "DINE_IN", mode: str("CURBSIDE"), vehicle: str("Red Civic"), wantErr: true},
+		{name: "vehicle required", orderType: "TAKEAWAY", mode: str("CURBSIDE"), vehicle: str("   "), wantErr: true},
+		{name: "vehicle too long", orderType: "TAKEAWAY", mode: str("CURBSIDE"), vehicle: str(strings.Repeat("x", 121)), wantErr: true},
+		{name: "spot too long", orderType: "TAKEAWAY", mode: str("CURBSIDE"), vehicle: str("Red Civic"), spot: str(strings.Repeat("x", 41)), wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := parseCurbsideRequest(tc.orderType, tc.mode, tc.vehicle, tc.spot)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", req)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (req != nil) != tc.curbside {
+				t.Fatalf("expected curbside=%v, got %+v", tc.curbside, req)
+			}
+			if req != nil && req.VehicleDescription != strings.TrimSpace(*tc.vehicle) {
+				t.Fatalf("expected trimmed vehicle, got %q", req.VehicleDescription)
+			}
+		})
+	}
+}
+
+func TestSummarizeCurbsideWaits(t *testing.T) {
+	empty := summarizeCurbsideWaits(3, nil)
+	if empty.Handovers != 3 || empty.CheckedIn != 0 || empty.AverageWaitSeconds != nil || empty.MaxWaitSeconds != nil {
+		t.Fatalf("expected counts only, got %+v", empty)
+	}
+
+	summary := summarizeCurbsideWaits(5, []float64{300, 60, 120, 240})
+	if summary.Handovers != 5 || summary.CheckedIn != 4 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	checks := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		{"average", summary.AverageWaitSeconds, 180},
+		{"median", summary.MedianWaitSeconds, 180},
+		{"p90", summary.P90WaitSeconds, 282},
+		{"max", summary.MaxWaitSeconds, 300},
+	}
+	for _, c := range checks {
+		if c.got == nil || *c.got != c.want {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.want, c.got)
+		}
+	}
+}
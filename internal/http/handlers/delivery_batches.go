@@ -0,0 +1,862 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type deliveryBatchCreateRequest struct {
+	OrderIDs     []any `json:"orderIds"`
+	DriverUserID any   `json:"driverUserId"`
+}
+
+type deliveryBatchStopInput struct {
+	OrderID     int64
+	OrderNumber string
+	Point       routePoint
+}
+
+var (
+	errDeliveryBatchNotFound = errors.New("delivery batch not found")
+	errDeliveryBatchStarted  = errors.New("delivery batch already started")
+)
+
+func (h *Handler) MerchantDeliveryBatchSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	radiusKm := defaultBatchRadiusKm
+	if raw := strings.TrimSpace(r.URL.Query().Get("radiusKm")); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || parsed > maxBatchRadiusKm {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("radiusKm must be between 0 and %s", formatFloat(maxBatchRadiusKm)))
+			return
+		}
+		radiusKm = parsed
+	}
+	maxStops := parseIntWithBounds(r.URL.Query().Get("maxStops"), defaultBatchMaxStops, 2, maxBatchMaxStops)
+
+	origin, err := h.fetchMerchantOrigin(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_LOCATION_REQUIRED", "Merchant location is required for delivery batching")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select o.id, o.order_number, o.delivery_latitude, o.delivery_longitude, o.placed_at
+		from orders o
+		where o.merchant_id = $1
+		  and o.order_type = 'DELIVERY'
+		  and o.status = 'READY'
+		  and o.delivery_driver_user_id is null
+		  and o.delivery_latitude is not null
+		  and o.delivery_longitude is not null
+		  and not exists (select 1 from delivery_batch_stops s where s.order_id = o.id)
+		  and not exists (select 1 from courier_deliveries cd where cd.order_id = o.id and cd.status = any($2))
+		order by o.placed_at asc, o.id asc
+		limit 200
+	`, *authCtx.MerchantID, courierActiveStatuses)
+	if err != nil {
+		h.Logger.Error("delivery batch suggestions query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to suggest delivery batches")
+		return
+	}
+	defer rows.Close()
+
+	candidates := make([]batchCandidateOrder, 0)
+	orderNumbers := make(map[int64]string)
+	for rows.Next() {
+		var (
+			orderID     int64
+			orderNumber string
+			lat         pgtype.Numeric
+			lng         pgtype.Numeric
+			placedAt    time.Time
+		)
+		if err := rows.Scan(&orderID, &orderNumber, &lat, &lng, &placedAt); err != nil {
+			h.Logger.Error("delivery batch suggestions scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to suggest delivery batches")
+			return
+		}
+		orderNumbers[orderID] = orderNumber
+		candidates = append(candidates, batchCandidateOrder{
+			OrderID:  orderID,
+			Point:    routePoint{Lat: utils.NumericToFloat64(lat), Lng: utils.NumericToFloat64(lng)},
+			PlacedAt: placedAt,
+		})
+	}
+
+	suggestions := make([]map[string]any, 0)
+	unbatched := make([]string, 0)
+	for _, group := range groupDeliveryBatches(candidates, radiusKm, maxStops) {
+		if len(group) < 2 {
+			unbatched = append(unbatched, fmt.Sprint(group[0].OrderID))
+			continue
+		}
+		points := make([]routePoint, len(group))
+		for i, o := range group {
+			points[i] = o.Point
+		}
+		route := planDeliveryRoute(origin, points)
+		cumulative := cumulativeRouteDistancesKm(origin, points, route)
+
+		stops := make([]map[string]any, len(route))
+		orderIDs := make([]string, len(route))
+		for pos, idx := range route {
+			o := group[idx]
+			orderIDs[pos] = fmt.Sprint(o.OrderID)
+			stops[pos] = map[string]any{
+				"orderId":              fmt.Sprint(o.OrderID),
+				"orderNumber":          orderNumbers[o.OrderID],
+				"position":             pos + 1,
+				"cumulativeDistanceKm": cumulative[pos],
+			}
+		}
+		suggestions = append(suggestions, map[string]any{
+			"orderIds":        orderIDs,
+			"stops":           stops,
+			"totalDistanceKm": cumulative[len(cumulative)-1],
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"batches":           suggestions,
+			"unbatchedOrderIds": unbatched,
+			"radiusKm":          radiusKm,
+			"maxStops":          maxStops,
+		},
+	})
+}
+
+func (h *Handler) MerchantDeliveryBatchCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body deliveryBatchCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	orderIDs := make([]int64, 0, len(body.OrderIDs))
+	seen := make(map[int64]bool)
+	for _, raw := range body.OrderIDs {
+		id, ok := parseNumericID(raw)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "orderIds must contain valid IDs")
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	if len(orderIDs) < 2 || len(orderIDs) > maxBatchMaxStops {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("A batch needs between 2 and %d orders", maxBatchMaxStops))
+		return
+	}
+
+	var driverUserID *int64
+	if body.DriverUserID != nil && strings.TrimSpace(toStringValue(body.DriverUserID)) != "" {
+		id, ok := parseNumericID(body.DriverUserID)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "driverUserId must be a valid ID")
+			return
+		}
+		if !h.isMerchantDriver(ctx, *authCtx.MerchantID, id) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Driver not found for this merchant")
+			return
+		}
+		driverUserID = &id
+	}
+
+	origin, err := h.fetchMerchantOrigin(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_LOCATION_REQUIRED", "Merchant location is required for delivery batching")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+		select o.id, o.order_number, o.order_type, o.status, o.delivery_driver_user_id,
+		       o.delivery_latitude, o.delivery_longitude,
+		       exists (select 1 from delivery_batch_stops s where s.order_id = o.id),
+		       exists (select 1 from courier_deliveries cd where cd.order_id = o.id and cd.status = any($3))
+		from orders o
+		where o.merchant_id = $1 and o.id = any($2)
+		for update of o
+	`, *authCtx.MerchantID, orderIDs, courierActiveStatuses)
+	if err != nil {
+		h.Logger.Error("delivery batch orders query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+		return
+	}
+	stops := make([]deliveryBatchStopInput, 0, len(orderIDs))
+	var invalid string
+	for rows.Next() {
+		var (
+			orderID     int64
+			orderNumber string
+			orderType   string
+			status      string
+			driverID    pgtype.Int8
+			lat         pgtype.Numeric
+			lng         pgtype.Numeric
+			inBatch     bool
+			hasCourier  bool
+		)
+		if err := rows.Scan(&orderID, &orderNumber, &orderType, &status, &driverID, &lat, &lng, &inBatch, &hasCourier); err != nil {
+			rows.Close()
+			h.Logger.Error("delivery batch orders scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+			return
+		}
+		switch {
+		case orderType != "DELIVERY":
+			invalid = fmt.Sprintf("Order %s is not a delivery order", orderNumber)
+		case status != "READY":
+			invalid = fmt.Sprintf("Order %s is not READY", orderNumber)
+		case driverID.Valid:
+			invalid = fmt.Sprintf("Order %s already has a driver", orderNumber)
+		case inBatch:
+			invalid = fmt.Sprintf("Order %s is already in a batch", orderNumber)
+		case hasCourier:
+			invalid = fmt.Sprintf("Order %s is booked with a courier", orderNumber)
+		case !lat.Valid || !lng.Valid:
+			invalid = fmt.Sprintf("Order %s has no delivery coordinates", orderNumber)
+		}
+		stops = append(stops, deliveryBatchStopInput{
+			OrderID:     orderID,
+			OrderNumber: orderNumber,
+			Point:       routePoint{Lat: utils.NumericToFloat64(lat), Lng: utils.NumericToFloat64(lng)},
+		})
+	}
+	rows.Close()
+	if invalid != "" {
+		response.Error(w, http.StatusConflict, "INVALID_STATE", invalid)
+		return
+	}
+	if len(stops) != len(orderIDs) {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "One or more orders were not found")
+		return
+	}
+
+	points := make([]routePoint, len(stops))
+	for i, s := range stops {
+		points[i] = s.Point
+	}
+	route := planDeliveryRoute(origin, points)
+	cumulative := cumulativeRouteDistancesKm(origin, points, route)
+	now := time.Now()
+
+	status := "PLANNED"
+	var assignedAt *time.Time
+	if driverUserID != nil {
+		status = "ASSIGNED"
+		assignedAt = &now
+	}
+
+	var batchID int64
+	if err := tx.QueryRow(ctx, `
+		insert into delivery_batches (merchant_id, driver_user_id, status, origin_latitude, origin_longitude, total_distance_km, created_by_user_id, assigned_at, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		returning id
+	`, *authCtx.MerchantID, driverUserID, status, origin.Lat, origin.Lng, cumulative[len(cumulative)-1], authCtx.UserID, assignedAt, now).Scan(&batchID); err != nil {
+		h.Logger.Error("delivery batch insert failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+		return
+	}
+
+	for pos, idx := range route {
+		if _, err := tx.Exec(ctx, `
+			insert into delivery_batch_stops (batch_id, order_id, position, cumulative_distance_km)
+			values ($1, $2, $3, $4)
+		`, batchID, stops[idx].OrderID, pos+1, cumulative[pos]); err != nil {
+			h.Logger.Error("delivery batch stop insert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+			return
+		}
+	}
+
+	if driverUserID != nil {
+		if err := assignDeliveryBatchOrders(ctx, tx, *authCtx.MerchantID, batchID, *driverUserID, now); err != nil {
+			h.Logger.Error("delivery batch assign failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+		return
+	}
+
+	for _, s := range stops {
+		h.cancelOpenDispatchOffers(ctx, s.OrderID, "Order added to a delivery batch")
+		h.notifyOrderRealtime(ctx, *authCtx.MerchantID, s.OrderNumber)
+	}
+
+	data, err := h.fetchDeliveryBatch(ctx, *authCtx.MerchantID, batchID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delivery batch")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Delivery batch created",
+		"statusCode": 201,
+	})
+}
+
+func (h *Handler) MerchantDeliveryBatchList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	statuses := []string{"PLANNED", "ASSIGNED"}
+	if strings.EqualFold(r.URL.Query().Get("scope"), "history") {
+		statuses = []string{"COMPLETED", "CANCELLED"}
+	}
+	limit := parseIntWithBounds(r.URL.Query().Get("limit"), 50, 1, 200)
+
+	rows, err := h.DB.Query(ctx, `
+		select id from delivery_batches
+		where merchant_id = $1 and status = any($2)
+		order by created_at desc, id desc
+		limit $3
+	`, *authCtx.MerchantID, statuses, limit)
+	if err != nil {
+		h.Logger.Error("delivery batch list query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve delivery batches")
+		return
+	}
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve delivery batches")
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	batches := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		batch, err := h.fetchDeliveryBatch(ctx, *authCtx.MerchantID, id)
+		if err != nil {
+			h.Logger.Error("delivery batch load failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve delivery batches")
+			return
+		}
+		batches = append(batches, batch)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    batches,
+		"count":   len(batches),
+	})
+}
+
+func (h *Handler) MerchantDeliveryBatchDetail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	batchID, err := readPathInt64(r, "batchId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Batch ID is required")
+		return
+	}
+
+	data, err := h.fetchDeliveryBatch(ctx, *authCtx.MerchantID, batchID)
+	if err != nil {
+		h.writeDeliveryBatchError(w, err, "Failed to retrieve delivery batch")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (h *Handler) MerchantDeliveryBatchAssign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	batchID, err := readPathInt64(r, "batchId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Batch ID is required")
+		return
+	}
+
+	var body struct {
+		DriverUserID any `json:"driverUserId"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	driverUserID, ok := parseNumericID(body.DriverUserID)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "driverUserId must be a valid ID")
+		return
+	}
+	if !h.isMerchantDriver(ctx, *authCtx.MerchantID, driverUserID) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Driver not found for this merchant")
+		return
+	}
+
+	orderNumbers, err := h.withUnstartedDeliveryBatch(ctx, *authCtx.MerchantID, batchID, func(tx pgx.Tx, now time.Time) error {
+		if _, err := tx.Exec(ctx, `
+			update delivery_batches
+			set driver_user_id = $1, status = 'ASSIGNED', assigned_at = $2, updated_at = $2
+			where id = $3
+		`, driverUserID, now, batchID); err != nil {
+			return err
+		}
+		return assignDeliveryBatchOrders(ctx, tx, *authCtx.MerchantID, batchID, driverUserID, now)
+	})
+	if err != nil {
+		h.writeDeliveryBatchError(w, err, "Failed to assign delivery batch")
+		return
+	}
+	for _, orderNumber := range orderNumbers {
+		h.notifyOrderRealtime(ctx, *authCtx.MerchantID, orderNumber)
+	}
+
+	data, err := h.fetchDeliveryBatch(ctx, *authCtx.MerchantID, batchID)
+	if err != nil {
+		h.writeDeliveryBatchError(w, err, "Failed to assign delivery batch")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+		"message": "Delivery batch assigned",
+	})
+}
+
+// MerchantDeliveryBatchCancel dissolves a batch that has not been picked up.
+// Orders keep any driver already assigned and go back to being single drops.
+func (h *Handler) MerchantDeliveryBatchCancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	batchID, err := readPathInt64(r, "batchId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Batch ID is required")
+		return
+	}
+
+	orderNumbers, err := h.withUnstartedDeliveryBatch(ctx, *authCtx.MerchantID, batchID, func(tx pgx.Tx, now time.Time) error {
+		if _, err := tx.Exec(ctx, `delete from delivery_batch_stops where batch_id = $1`, batchID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			update delivery_batches set status = 'CANCELLED', updated_at = $2 where id = $1
+		`, batchID, now)
+		return err
+	})
+	if err != nil {
+		h.writeDeliveryBatchError(w, err, "Failed to cancel delivery batch")
+		return
+	}
+	for _, orderNumber := range orderNumbers {
+		h.notifyOrderRealtime(ctx, *authCtx.MerchantID, orderNumber)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"message": "Delivery batch cancelled",
+	})
+}
+
+// withUnstartedDeliveryBatch locks an open batch whose stops have not been
+// picked up yet, runs fn, and returns the order numbers of its stops.
+func (h *Handler) withUnstartedDeliveryBatch(ctx context.Context, merchantID, batchID int64, fn func(tx pgx.Tx, now time.Time) error) ([]string, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var status string
+	if err := tx.QueryRow(ctx, `
+		select status from delivery_batches where id = $1 and merchant_id = $2 for update
+	`, batchID, merchantID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errDeliveryBatchNotFound
+		}
+		return nil, err
+	}
+	if status != "PLANNED" && status != "ASSIGNED" {
+		return nil, errDeliveryBatchStarted
+	}
+
+	rows, err := tx.Query(ctx, `
+		select o.order_number, coalesce(`+EffectiveDeliveryStatusSQL+`, '')
+		from delivery_batch_stops s
+		join orders o on o.id = s.order_id
+		`+DeliveryProgressJoinSQL+`
+		where s.batch_id = $1
+	`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	orderNumbers := make([]string, 0)
+	started := false
+	for rows.Next() {
+		var orderNumber, deliveryStatus string
+		if err := rows.Scan(&orderNumber, &deliveryStatus); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		switch deliveryStatus {
+		case "PICKED_UP", "ARRIVED", "DELIVERED", "FAILED":
+			started = true
+		}
+		orderNumbers = append(orderNumbers, orderNumber)
+	}
+	rows.Close()
+	if started {
+		return nil, errDeliveryBatchStarted
+	}
+
+	if err := fn(tx, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return orderNumbers, nil
+}
+
+func (h *Handler) writeDeliveryBatchError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, errDeliveryBatchNotFound):
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Delivery batch not found")
+	case errors.Is(err, errDeliveryBatchStarted):
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "Delivery batch is already on the road or closed")
+	default:
+		h.Logger.Error("delivery batch operation failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
+
+func assignDeliveryBatchOrders(ctx context.Context, tx pgx.Tx, merchantID, batchID, driverUserID int64, now time.Time) error {
+	_, err := tx.Exec(ctx, `
+		with reset as (
+			delete from order_delivery_progress
+			where order_id in (select order_id from delivery_batch_stops where batch_id = $4)
+		)
+		update orders
+		set delivery_driver_user_id = $1,
+			delivery_assigned_at = $2,
+			delivery_status = 'ASSIGNED'
+		where merchant_id = $3
+		  and id in (select order_id from delivery_batch_stops where batch_id = $4)
+	`, driverUserID, now, merchantID, batchID)
+	return err
+}
+
+func (h *Handler) isMerchantDriver(ctx context.Context, merchantID, userID int64) bool {
+	var exists bool
+	if err := h.DB.QueryRow(ctx, `
+		select exists (
+			select 1
+			from merchant_users mu
+			join users u on u.id = mu.user_id
+			where mu.merchant_id = $1
+				and mu.user_id = $2
+				and mu.is_active = true
+				and u.is_active = true
+				and (
+					mu.role in ('OWNER', 'DRIVER')
+					or (mu.role = 'STAFF' and mu.invitation_status = 'ACCEPTED' and $3 = any(mu.permissions))
+				)
+		)
+	`, merchantID, userID, "driver_dashboard").Scan(&exists); err != nil {
+		return false
+	}
+	return exists
+}
+
+func (h *Handler) fetchMerchantOrigin(ctx context.Context, merchantID int64) (routePoint, error) {
+	var lat, lng pgtype.Numeric
+	if err := h.DB.QueryRow(ctx, `select latitude, longitude from merchants where id = $1`, merchantID).Scan(&lat, &lng); err != nil {
+		return routePoint{}, err
+	}
+	if !lat.Valid || !lng.Valid {
+		return routePoint{}, errors.New("merchant location missing")
+	}
+	return routePoint{Lat: utils.NumericToFloat64(lat), Lng: utils.NumericToFloat64(lng)}, nil
+}
+
+func (h *Handler) fetchDeliveryBatch(ctx context.Context, merchantID, batchID int64) (map[string]any, error) {
+	var (
+		status        string
+		driverUserID  pgtype.Int8
+		driverName    pgtype.Text
+		totalDistance pgtype.Numeric
+		assignedAt    pgtype.Timestamptz
+		completedAt   pgtype.Timestamptz
+		createdAt     time.Time
+	)
+	err := h.DB.QueryRow(ctx, `
+		select b.status, b.driver_user_id, u.name, b.total_distance_km, b.assigned_at, b.completed_at, b.created_at
+		from delivery_batches b
+		left join users u on u.id = b.driver_user_id
+		where b.id = $1 and b.merchant_id = $2
+	`, batchID, merchantID).Scan(&status, &driverUserID, &driverName, &totalDistance, &assignedAt, &completedAt, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errDeliveryBatchNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select s.order_id, s.position, s.cumulative_distance_km,
+		       o.order_number, o.status, `+EffectiveDeliveryStatusSQL+`, o.delivery_address, o.delivery_latitude, o.delivery_longitude
+		from delivery_batch_stops s
+		join orders o on o.id = s.order_id
+		`+DeliveryProgressJoinSQL+`
+		where s.batch_id = $1
+		order by s.position asc
+	`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stops := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			orderID        int64
+			position       int32
+			cumulative     pgtype.Numeric
+			orderNumber    string
+			orderStatus    string
+			deliveryStatus pgtype.Text
+			address        pgtype.Text
+			lat            pgtype.Numeric
+			lng            pgtype.Numeric
+		)
+		if err := rows.Scan(&orderID, &position, &cumulative, &orderNumber, &orderStatus, &deliveryStatus, &address, &lat, &lng); err != nil {
+			return nil, err
+		}
+		stops = append(stops, map[string]any{
+			"orderId":              fmt.Sprint(orderID),
+			"orderNumber":          orderNumber,
+			"position":             position,
+			"cumulativeDistanceKm": nullableNumeric(cumulative),
+			"status":               orderStatus,
+			"deliveryStatus":       nullableText(deliveryStatus),
+			"deliveryAddress":      nullableText(address),
+			"deliveryLatitude":     nullableNumeric(lat),
+			"deliveryLongitude":    nullableNumeric(lng),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var driver any
+	if driverUserID.Valid {
+		driver = map[string]any{"id": driverUserID.Int64, "name": driverName.String}
+	}
+
+	return map[string]any{
+		"id":              fmt.Sprint(batchID),
+		"status":          status,
+		"driver":          driver,
+		"totalDistanceKm": nullableNumeric(totalDistance),
+		"assignedAt":      nullableTime(assignedAt),
+		"completedAt":     nullableTime(completedAt),
+		"createdAt":       createdAt,
+		"stops":           stops,
+	}, nil
+}
+
+// completeDeliveryBatchForOrder closes the batch containing orderID once none
+// of its stops is still on the way, and returns the order numbers of the other
+// stops so their tracking pages can refresh their route position.
+func completeDeliveryBatchForOrder(ctx context.Context, tx pgx.Tx, orderID int64) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		select o.order_number
+		from delivery_batch_stops s
+		join delivery_batch_stops mine on mine.batch_id = s.batch_id and mine.order_id = $1
+		join orders o on o.id = s.order_id
+		where s.order_id <> $1
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	siblings := make([]string, 0)
+	for rows.Next() {
+		var orderNumber string
+		if err := rows.Scan(&orderNumber); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		siblings = append(siblings, orderNumber)
+	}
+	rows.Close()
+
+	_, err = tx.Exec(ctx, `
+		update delivery_batches b
+		set status = 'COMPLETED', completed_at = now(), updated_at = now()
+		where b.id = (select batch_id from delivery_batch_stops where order_id = $1)
+		  and b.status in ('PLANNED', 'ASSIGNED')
+		  and not exists (
+				select 1 from delivery_batch_stops s
+				join orders o on o.id = s.order_id
+				`+DeliveryProgressJoinSQL+`
+				where s.batch_id = b.id
+				  and coalesce(`+EffectiveDeliveryStatusSQL+`, '') not in ('DELIVERED', 'FAILED')
+		  )
+	`, orderID)
+	return siblings, err
+}
+
+// FetchDeliveryRouteInfo reports the order's position in its delivery batch
+// and an ETA adjusted for the stops still ahead of it. It returns nil when
+// the order is not part of a batch.
+func FetchDeliveryRouteInfo(ctx context.Context, db *pgxpool.Pool, orderID int64) (*DeliveryRouteInfo, error) {
+	rows, err := db.Query(ctx, `
+		select s.order_id, s.position, s.cumulative_distance_km, coalesce(`+EffectiveDeliveryStatusSQL+`, '')
+		from delivery_batch_stops s
+		join delivery_batches b on b.id = s.batch_id
+		join orders o on o.id = s.order_id
+		`+DeliveryProgressJoinSQL+`
+		where s.batch_id = (select batch_id from delivery_batch_stops where order_id = $1)
+		  and b.status <> 'CANCELLED'
+		order by s.position asc
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type stop struct {
+		orderID    int64
+		position   int
+		cumulative float64
+		done       bool
+	}
+	stops := make([]stop, 0)
+	for rows.Next() {
+		var (
+			id             int64
+			position       int32
+			cumulative     pgtype.Numeric
+			deliveryStatus string
+		)
+		if err := rows.Scan(&id, &position, &cumulative, &deliveryStatus); err != nil {
+			return nil, err
+		}
+		stops = append(stops, stop{
+			orderID:    id,
+			position:   int(position),
+			cumulative: utils.NumericToFloat64(cumulative),
+			done:       deliveryStatus == "DELIVERED" || deliveryStatus == "FAILED",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stops) == 0 {
+		return nil, nil
+	}
+
+	info := &DeliveryRouteInfo{TotalStops: len(stops)}
+	var target *stop
+	lastDoneKm := 0.0
+	for i := range stops {
+		s := &stops[i]
+		if s.orderID == orderID {
+			target = s
+			break
+		}
+		if s.done {
+			lastDoneKm = s.cumulative
+		} else {
+			info.StopsAhead++
+		}
+	}
+	if target == nil {
+		return nil, nil
+	}
+	info.Position = target.position
+	if !target.done {
+		var (
+			merchantID int64
+			timezone   pgtype.Text
+			features   []byte
+		)
+		if err := db.QueryRow(ctx, `
+			select o.merchant_id, m.timezone, m.features
+			from orders o
+			join merchants m on m.id = o.merchant_id
+			where o.id = $1
+		`, orderID).Scan(&merchantID, &timezone, &features); err != nil {
+			return nil, err
+		}
+		tz := timezoneOrDefault(timezone.String)
+		model := loadDeliveryEtaModel(ctx, db, merchantID, features, tz)
+		now := time.Now()
+		eta := routeEtaMinutes(model, target.cumulative-lastDoneKm, info.StopsAhead, now.In(loadTimezone(tz)))
+		arrival := now.Add(time.Duration(eta * float64(time.Minute)))
+		info.EtaMinutes = &eta
+		info.EstimatedArrivalAt = &arrival
+	}
+	return info, nil
+}
@@ -0,0 +1,561 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultEtaPickupLagMinutes = 8.0
+	defaultEtaSpeedKmh         = 25.0
+	maxEtaPickupLagMinutes     = 60.0
+	minEtaSpeedKmh             = 5.0
+	maxEtaSpeedKmh             = 80.0
+	minEtaFactor               = 0.5
+	maxEtaFactor               = 2.5
+	etaMinSamples              = 5
+	etaHistoryLimit            = 200
+	etaModelCacheTTL           = 10 * time.Minute
+	maxEtaTimeFactors          = 12
+
+	deliveryEtaSourceLearned = "LEARNED"
+	deliveryEtaSourceDefault = "DEFAULT"
+)
+
+// deliveryEtaSettings is stored under merchants.features.deliveryEta.
+// AverageSpeedKmh and PickupLagMinutes are used until enough delivered
+// orders exist to learn them; TimeFactors multiply travel time in the given
+// windows and take precedence over learned hourly factors.
+type deliveryEtaSettings struct {
+	AverageSpeedKmh  *float64                `json:"averageSpeedKmh"`
+	PickupLagMinutes *float64                `json:"pickupLagMinutes"`
+	LearnFromHistory *bool                   `json:"learnFromHistory"`
+	TimeFactors      []deliveryEtaTimeFactor `json:"timeFactors"`
+}
+
+type deliveryEtaTimeFactor struct {
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+	Factor    float64 `json:"factor"`
+}
+
+type deliveryEtaSample struct {
+	ReadyAt     time.Time
+	PickedUpAt  *time.Time
+	DeliveredAt time.Time
+	DistanceKm  float64
+}
+
+type deliveryEtaModel struct {
+	PickupLagMinutes float64
+	SpeedKmh         float64
+	HourlyFactors    map[int]float64
+	TimeFactors      []deliveryEtaTimeFactor
+	Samples          int
+	Source           string
+}
+
+type deliveryEtaModelCacheEntry struct {
+	model     deliveryEtaModel
+	expiresAt time.Time
+}
+
+var (
+	deliveryEtaModelCacheMu sync.Mutex
+	deliveryEtaModelCache   = make(map[int64]deliveryEtaModelCacheEntry)
+)
+
+func parseDeliveryEtaSettings(features []byte) deliveryEtaSettings {
+	var wrapper struct {
+		DeliveryEta *deliveryEtaSettings `json:"deliveryEta"`
+	}
+	if len(features) == 0 || json.Unmarshal(features, &wrapper) != nil || wrapper.DeliveryEta == nil {
+		return deliveryEtaSettings{}
+	}
+	return *wrapper.DeliveryEta
+}
+
+// learnDeliveryEta derives pickup lag (ready -> picked up), travel speed and
+// per-hour traffic factors from delivered orders. Orders without a pickup
+// timestamp count toward speed using the learned lag. Anything with fewer
+// than etaMinSamples falls back to the merchant settings.
+func learnDeliveryEta(samples []deliveryEtaSample, settings deliveryEtaSettings, loc *time.Location) deliveryEtaModel {
+	model := deliveryEtaModel{
+		PickupLagMinutes: defaultEtaPickupLagMinutes,
+		SpeedKmh:         defaultEtaSpeedKmh,
+		HourlyFactors:    map[int]float64{},
+		TimeFactors:      settings.TimeFactors,
+		Source:           deliveryEtaSourceDefault,
+	}
+	if settings.PickupLagMinutes != nil {
+		model.PickupLagMinutes = *settings.PickupLagMinutes
+	}
+	if settings.AverageSpeedKmh != nil {
+		model.SpeedKmh = *settings.AverageSpeedKmh
+	}
+	if settings.LearnFromHistory != nil && !*settings.LearnFromHistory {
+		return model
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	lags := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if s.PickedUpAt == nil {
+			continue
+		}
+		lag := s.PickedUpAt.Sub(s.ReadyAt).Minutes()
+		if lag >= 0 && lag <= maxEtaPickupLagMinutes {
+			lags = append(lags, lag)
+		}
+	}
+	learned := false
+	if len(lags) >= etaMinSamples {
+		model.PickupLagMinutes = deliveryRound2(medianFloat(lags))
+		learned = true
+	}
+
+	type speedSample struct {
+		hour  int
+		speed float64
+	}
+	speeds := make([]speedSample, 0, len(samples))
+	for _, s := range samples {
+		if s.DistanceKm < 0.2 {
+			continue
+		}
+		departedAt := s.ReadyAt.Add(time.Duration(model.PickupLagMinutes * float64(time.Minute)))
+		if s.PickedUpAt != nil {
+			departedAt = *s.PickedUpAt
+		}
+		travel := s.DeliveredAt.Sub(departedAt).Minutes()
+		if travel < 1 {
+			continue
+		}
+		speed := s.DistanceKm / (travel / 60)
+		if speed < minEtaSpeedKmh || speed > maxEtaSpeedKmh {
+			continue
+		}
+		speeds = append(speeds, speedSample{hour: departedAt.In(loc).Hour(), speed: speed})
+	}
+	model.Samples = len(speeds)
+	if len(speeds) < etaMinSamples {
+		if learned {
+			model.Source = deliveryEtaSourceLearned
+		}
+		return model
+	}
+
+	all := make([]float64, 0, len(speeds))
+	for _, s := range speeds {
+		all = append(all, s.speed)
+	}
+	model.SpeedKmh = deliveryRound2(medianFloat(all))
+	model.Source = deliveryEtaSourceLearned
+
+	// A factor for hour h compares the overall speed with speeds from
+	// departures within an hour of h, so quiet hours need fewer samples.
+	for hour := 0; hour < 24; hour++ {
+		window := make([]float64, 0)
+		for _, s := range speeds {
+			diff := int(math.Abs(float64(s.hour - hour)))
+			if diff > 12 {
+				diff = 24 - diff
+			}
+			if diff <= 1 {
+				window = append(window, s.speed)
+			}
+		}
+		if len(window) < etaMinSamples {
+			continue
+		}
+		factor := model.SpeedKmh / medianFloat(window)
+		model.HourlyFactors[hour] = deliveryRound2(math.Min(maxEtaFactor, math.Max(minEtaFactor, factor)))
+	}
+	return model
+}
+
+// travelFactor returns the configured factor whose window contains local,
+// else the learned factor for its hour, else 1.
+func (m deliveryEtaModel) travelFactor(local time.Time) float64 {
+	now := local.Format("15:04")
+	for _, f := range m.TimeFactors {
+		inWindow := false
+		if f.StartTime <= f.EndTime {
+			inWindow = now >= f.StartTime && now < f.EndTime
+		} else {
+			inWindow = now >= f.StartTime || now < f.EndTime
+		}
+		if inWindow && f.Factor > 0 {
+			return f.Factor
+		}
+	}
+	if factor, ok := m.HourlyFactors[local.Hour()]; ok {
+		return factor
+	}
+	return 1
+}
+
+// travelMinutes estimates driving time for distanceKm departing at local.
+func (m deliveryEtaModel) travelMinutes(distanceKm float64, local time.Time) (float64, float64) {
+	speed := m.SpeedKmh
+	if speed <= 0 {
+		speed = defaultEtaSpeedKmh
+	}
+	factor := m.travelFactor(local)
+	return distanceKm / speed * 60 * factor, factor
+}
+
+// buildDeliveryEta sums the remaining stages and derives the range shown to
+// customers.
+func (m deliveryEtaModel) buildDeliveryEta(prepMinutes, pickupMinutes, travelMinutes, factor float64, now time.Time) *DeliveryEta {
+	prepMinutes = math.Max(0, prepMinutes)
+	pickupMinutes = math.Max(0, pickupMinutes)
+	travelMinutes = math.Max(0, travelMinutes)
+	total := prepMinutes + pickupMinutes + travelMinutes
+	arrival := now.Add(time.Duration(total * float64(time.Minute)))
+	minMinutes := int(math.Floor(total * 0.85))
+	maxMinutes := int(math.Ceil(total * 1.2))
+	if maxMinutes < minMinutes {
+		maxMinutes = minMinutes
+	}
+	return &DeliveryEta{
+		PrepMinutes:        deliveryRound2(prepMinutes),
+		PickupMinutes:      deliveryRound2(pickupMinutes),
+		TravelMinutes:      deliveryRound2(travelMinutes),
+		TotalMinutes:       deliveryRound2(total),
+		MinMinutes:         minMinutes,
+		MaxMinutes:         maxMinutes,
+		TrafficFactor:      factor,
+		EstimatedArrivalAt: &arrival,
+		Source:             m.Source,
+	}
+}
+
+// deliveryPrepMinutes scales the median prep time by the orders ahead,
+// assuming the kitchen works on roughly 70% of the queue at once.
+func deliveryPrepMinutes(basePrep, queueAhead int) float64 {
+	multiplier := math.Max(1, math.Ceil(float64(queueAhead+1)*0.7))
+	return math.Min(90, float64(basePrep)*multiplier)
+}
+
+func loadDeliveryEtaModel(ctx context.Context, db *pgxpool.Pool, merchantID int64, features []byte, timezone string) deliveryEtaModel {
+	deliveryEtaModelCacheMu.Lock()
+	entry, ok := deliveryEtaModelCache[merchantID]
+	deliveryEtaModelCacheMu.Unlock()
+	if ok && entry.expiresAt.After(time.Now()) {
+		return entry.model
+	}
+
+	settings := parseDeliveryEtaSettings(features)
+	samples := make([]deliveryEtaSample, 0)
+	rows, err := db.Query(ctx, `
+		select o.actual_ready_at, dp.picked_up_at, o.delivery_delivered_at, o.delivery_distance_km
+		from orders o
+		`+DeliveryProgressJoinSQL+`
+		where o.merchant_id = $1
+		  and o.order_type = 'DELIVERY'
+		  and o.delivery_status::text = 'DELIVERED'
+		  and o.actual_ready_at is not null
+		  and o.delivery_delivered_at is not null
+		  and o.delivery_distance_km is not null
+		order by o.delivery_delivered_at desc
+		limit $2
+	`, merchantID, etaHistoryLimit)
+	if err == nil {
+		for rows.Next() {
+			var (
+				readyAt     time.Time
+				pickedUpAt  pgtype.Timestamptz
+				deliveredAt time.Time
+				distance    pgtype.Numeric
+			)
+			if err := rows.Scan(&readyAt, &pickedUpAt, &deliveredAt, &distance); err != nil {
+				continue
+			}
+			sample := deliveryEtaSample{ReadyAt: readyAt, DeliveredAt: deliveredAt, DistanceKm: utils.NumericToFloat64(distance)}
+			if pickedUpAt.Valid {
+				t := pickedUpAt.Time
+				sample.PickedUpAt = &t
+			}
+			samples = append(samples, sample)
+		}
+		rows.Close()
+	}
+
+	model := learnDeliveryEta(samples, settings, loadTimezone(timezoneOrDefault(timezone)))
+
+	deliveryEtaModelCacheMu.Lock()
+	deliveryEtaModelCache[merchantID] = deliveryEtaModelCacheEntry{model: model, expiresAt: time.Now().Add(etaModelCacheTTL)}
+	if len(deliveryEtaModelCache) > 500 {
+		deliveryEtaModelCache = make(map[int64]deliveryEtaModelCacheEntry)
+	}
+	deliveryEtaModelCacheMu.Unlock()
+	return model
+}
+
+func invalidateDeliveryEtaModel(merchantID int64) {
+	deliveryEtaModelCacheMu.Lock()
+	delete(deliveryEtaModelCache, merchantID)
+	deliveryEtaModelCacheMu.Unlock()
+}
+
+// quoteDeliveryEta estimates arrival for an order placed now.
+func quoteDeliveryEta(ctx context.Context, db *pgxpool.Pool, config groupOrderDeliveryConfig, distanceKm float64) *DeliveryEta {
+	model := loadDeliveryEtaModel(ctx, db, config.MerchantID, config.Features, config.Timezone)
+	now := time.Now()
+	basePrep := basePrepMinutesCached(ctx, db, config.MerchantID, "DELIVERY")
+	queueAhead := queryQueueAhead(ctx, db, config.MerchantID, "DELIVERY", now)
+	prep := deliveryPrepMinutes(basePrep, queueAhead)
+
+	departure := now.Add(time.Duration((prep + model.PickupLagMinutes) * float64(time.Minute)))
+	travel, factor := model.travelMinutes(distanceKm, departure.In(loadTimezone(timezoneOrDefault(config.Timezone))))
+	return model.buildDeliveryEta(prep, model.PickupLagMinutes, travel, factor, now)
+}
+
+// FetchDeliveryEta estimates when a delivery order reaches the customer from
+// its current stage. It returns nil for finished or undeliverable orders.
+// route, when the order is batched, replaces the travel estimate after pickup.
+func FetchDeliveryEta(ctx context.Context, db *pgxpool.Pool, orderID int64, route *DeliveryRouteInfo) (*DeliveryEta, error) {
+	var (
+		merchantID     int64
+		status         string
+		placedAt       time.Time
+		actualReadyAt  pgtype.Timestamptz
+		isScheduled    bool
+		scheduledDate  pgtype.Text
+		scheduledTime  pgtype.Text
+		deliveryStatus pgtype.Text
+		pickedUpAt     pgtype.Timestamptz
+		distance       pgtype.Numeric
+		timezone       pgtype.Text
+		features       []byte
+	)
+	err := db.QueryRow(ctx, `
+		select o.merchant_id, o.status::text, o.placed_at, o.actual_ready_at,
+		       o.is_scheduled, o.scheduled_date, o.scheduled_time,
+		       `+EffectiveDeliveryStatusSQL+`, dp.picked_up_at, o.delivery_distance_km,
+		       m.timezone, m.features
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		`+DeliveryProgressJoinSQL+`
+		where o.id = $1 and o.order_type = 'DELIVERY'
+	`, orderID).Scan(
+		&merchantID, &status, &placedAt, &actualReadyAt,
+		&isScheduled, &scheduledDate, &scheduledTime,
+		&deliveryStatus, &pickedUpAt, &distance,
+		&timezone, &features,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if status == "CANCELLED" || !distance.Valid {
+		return nil, nil
+	}
+	switch deliveryStatus.String {
+	case "DELIVERED", "FAILED", "ARRIVED":
+		return nil, nil
+	}
+
+	tz := timezoneOrDefault(timezone.String)
+	loc := loadTimezone(tz)
+	model := loadDeliveryEtaModel(ctx, db, merchantID, features, tz)
+	distanceKm := utils.NumericToFloat64(distance)
+	now := time.Now()
+
+	if deliveryStatus.String == "PICKED_UP" {
+		if route != nil && route.EtaMinutes != nil {
+			return model.buildDeliveryEta(0, 0, *route.EtaMinutes, 1, now), nil
+		}
+		travel, factor := model.travelMinutes(distanceKm, now.In(loc))
+		if pickedUpAt.Valid {
+			travel -= now.Sub(pickedUpAt.Time).Minutes()
+		}
+		return model.buildDeliveryEta(0, 0, math.Max(1, travel), factor, now), nil
+	}
+
+	prep := 0.0
+	pickup := model.PickupLagMinutes
+	if actualReadyAt.Valid || status == "READY" || status == "COMPLETED" {
+		if actualReadyAt.Valid {
+			pickup = math.Max(1, pickup-now.Sub(actualReadyAt.Time).Minutes())
+		}
+	} else {
+		var scheduledAt *time.Time
+		if isScheduled && scheduledDate.Valid && scheduledTime.Valid {
+			scheduledAt = parseScheduledAt(scheduledDate.String, scheduledTime.String, tz)
+		}
+		if scheduledAt != nil && scheduledAt.After(now) {
+			prep = scheduledAt.Sub(now).Minutes()
+		} else {
+			basePrep := basePrepMinutesCached(ctx, db, merchantID, "DELIVERY")
+			queueAhead := queryQueueAhead(ctx, db, merchantID, "DELIVERY", placedAt)
+			prep = math.Max(2, deliveryPrepMinutes(basePrep, queueAhead)-now.Sub(placedAt).Minutes())
+		}
+	}
+
+	departure := now.Add(time.Duration((prep + pickup) * float64(time.Minute)))
+	travel, factor := model.travelMinutes(distanceKm, departure.In(loc))
+	return model.buildDeliveryEta(prep, pickup, travel, factor, now), nil
+}
+
+func medianFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func (h *Handler) MerchantDeliveryEtaSettingsGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var (
+		features []byte
+		timezone pgtype.Text
+	)
+	if err := h.DB.QueryRow(ctx, `select features, timezone from merchants where id = $1`, *authCtx.MerchantID).Scan(&features, &timezone); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       h.buildDeliveryEtaSettingsResponse(r.Context(), *authCtx.MerchantID, features, timezone.String),
+		"message":    "Delivery ETA settings retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantDeliveryEtaSettingsPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var body deliveryEtaSettings
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if body.AverageSpeedKmh != nil && (*body.AverageSpeedKmh < minEtaSpeedKmh || *body.AverageSpeedKmh > maxEtaSpeedKmh) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("averageSpeedKmh must be between %v and %v", minEtaSpeedKmh, maxEtaSpeedKmh))
+		return
+	}
+	if body.PickupLagMinutes != nil && (*body.PickupLagMinutes < 0 || *body.PickupLagMinutes > maxEtaPickupLagMinutes) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("pickupLagMinutes must be between 0 and %v", maxEtaPickupLagMinutes))
+		return
+	}
+	if len(body.TimeFactors) > maxEtaTimeFactors {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("At most %d time factors are allowed", maxEtaTimeFactors))
+		return
+	}
+	for _, f := range body.TimeFactors {
+		if !isValidHHMM(f.StartTime) || !isValidHHMM(f.EndTime) || f.StartTime == f.EndTime {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Time factor startTime and endTime must be different HH:MM values")
+			return
+		}
+		if f.Factor < minEtaFactor || f.Factor > maxEtaFactor {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Time factor must be between %v and %v", minEtaFactor, maxEtaFactor))
+			return
+		}
+	}
+
+	// Only the deliveryEta key is replaced, so concurrent writes to other
+	// feature settings are not lost.
+	settingsJSON, _ := json.Marshal(body)
+
+	var (
+		updatedFeatures []byte
+		timezone        pgtype.Text
+	)
+	if err := h.DB.QueryRow(ctx, `
+		update merchants
+		set features = jsonb_set(
+				case when jsonb_typeof(features) = 'object' then features else '{}'::jsonb end,
+				'{deliveryEta}', $1::jsonb),
+			updated_at = now()
+		where id = $2
+		returning features, timezone
+	`, settingsJSON, *authCtx.MerchantID).Scan(&updatedFeatures, &timezone); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+			return
+		}
+		h.Logger.Error("delivery eta settings update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update delivery ETA settings")
+		return
+	}
+	invalidateDeliveryEtaModel(*authCtx.MerchantID)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       h.buildDeliveryEtaSettingsResponse(ctx, *authCtx.MerchantID, updatedFeatures, timezone.String),
+		"message":    "Delivery ETA settings updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// buildDeliveryEtaSettingsResponse returns the stored settings alongside the
+// model currently in use, so merchants can see what was learned.
+func (h *Handler) buildDeliveryEtaSettingsResponse(ctx context.Context, merchantID int64, features []byte, timezone string) map[string]any {
+	settings := parseDeliveryEtaSettings(features)
+	if settings.TimeFactors == nil {
+		settings.TimeFactors = []deliveryEtaTimeFactor{}
+	}
+	model := loadDeliveryEtaModel(ctx, h.DB, merchantID, features, timezone)
+	hourly := make(map[string]float64, len(model.HourlyFactors))
+	for hour, factor := range model.HourlyFactors {
+		hourly[fmt.Sprintf("%02d", hour)] = factor
+	}
+	return map[string]any{
+		"settings": settings,
+		"model": map[string]any{
+			"source":           model.Source,
+			"samples":          model.Samples,
+			"pickupLagMinutes": model.PickupLagMinutes,
+			"averageSpeedKmh":  model.SpeedKmh,
+			"hourlyFactors":    hourly,
+		},
+	}
+}
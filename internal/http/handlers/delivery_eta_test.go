@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLearnDeliveryEta(t *testing.T) {
+	base := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	samples := make([]deliveryEtaSample, 0)
+	// Midday: 5 km in 15 minutes (20 km/h) after a 6 minute pickup lag.
+	for i := 0; i < 6; i++ {
+		ready := base.Add(time.Duration(12*60+i) * time.Minute)
+		picked := ready.Add(6 * time.Minute)
+		samples = append(samples, deliveryEtaSample{ReadyAt: ready, PickedUpAt: &picked, DeliveredAt: picked.Add(15 * time.Minute), DistanceKm: 5})
+	}
+	// Evening rush: 5 km in 30 minutes (10 km/h).
+	for i := 0; i < 6; i++ {
+		ready := base.Add(time.Duration(18*60+i) * time.Minute)
+		picked := ready.Add(6 * time.Minute)
+		samples = append(samples, deliveryEtaSample{ReadyAt: ready, PickedUpAt: &picked, DeliveredAt: picked.Add(30 * time.Minute), DistanceKm: 5})
+	}
+
+	model := learnDeliveryEta(samples, deliveryEtaSettings{}, time.UTC)
+	if model.Source != deliveryEtaSourceLearned || model.Samples != 12 {
+		t.Fatalf("expected learned model from 12 samples, got %s/%d", model.Source, model.Samples)
+	}
+	if model.PickupLagMinutes != 6 {
+		t.Fatalf("expected 6 minute pickup lag, got %v", model.PickupLagMinutes)
+	}
+	if model.SpeedKmh != 15 {
+		t.Fatalf("expected median speed 15 km/h, got %v", model.SpeedKmh)
+	}
+
+	noon := model.travelFactor(base.Add(12 * time.Hour))
+	evening := model.travelFactor(base.Add(18 * time.Hour))
+	if noon >= 1 || evening <= 1 {
+		t.Fatalf("expected faster noon and slower evening factors, got %v and %v", noon, evening)
+	}
+	if got := model.travelFactor(base.Add(3 * time.Hour)); got != 1 {
+		t.Fatalf("expected neutral factor without samples, got %v", got)
+	}
+
+	model.TimeFactors = []deliveryEtaTimeFactor{{StartTime: "17:00", EndTime: "20:00", Factor: 1.5}}
+	travel, factor := model.travelMinutes(5, base.Add(18*time.Hour))
+	if factor != 1.5 || math.Abs(travel-30) > 1e-9 {
+		t.Fatalf("expected configured factor to override learned one, got %v minutes x%v", travel, factor)
+	}
+}
+
+func TestLearnDeliveryEtaFallsBackToSettings(t *testing.T) {
+	speed, lag := 30.0, 10.0
+	model := learnDeliveryEta(nil, deliveryEtaSettings{AverageSpeedKmh: &speed, PickupLagMinutes: &lag}, time.UTC)
+	if model.Source != deliveryEtaSourceDefault || model.SpeedKmh != 30 || model.PickupLagMinutes != 10 {
+		t.Fatalf("expected configured defaults, got %+v", model)
+	}
+
+	eta := model.buildDeliveryEta(20, 10, 12, 1, time.Now())
+	if eta.TotalMinutes != 42 || eta.MinMinutes > 42 || eta.MaxMinutes < 42 {
+		t.Fatalf("unexpected eta %+v", eta)
+	}
+}
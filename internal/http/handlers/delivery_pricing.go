@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	deliveryPricingRuleZone     = "ZONE_OVERRIDE"
+	deliveryPricingRuleBand     = "DISTANCE_BAND"
+	deliveryPricingRuleBaseRate = "BASE_RATE"
+
+	maxDeliveryPricingBands      = 20
+	maxDeliveryPricingSurcharges = 10
+)
+
+// deliveryPricingRules is stored under merchants.features.deliveryPricing.
+// Bands and zone overrides replace the merchant's base + per-km rate; the
+// merchant's min/max fee, surcharges and the free-delivery threshold apply
+// on top of whichever rule priced the distance.
+type deliveryPricingRules struct {
+	Bands                   []deliveryPricingBand         `json:"bands"`
+	ZoneOverrides           []deliveryPricingZoneOverride `json:"zoneOverrides"`
+	FreeDeliveryMinSubtotal *float64                      `json:"freeDeliveryMinSubtotal"`
+	Surcharges              []deliveryPricingSurcharge    `json:"surcharges"`
+}
+
+type deliveryPricingBand struct {
+	FromKm   float64  `json:"fromKm"`
+	ToKm     *float64 `json:"toKm"`
+	FlatFee  float64  `json:"flatFee"`
+	PerKmFee float64  `json:"perKmFee"`
+}
+
+type deliveryPricingZoneOverride struct {
+	ZoneID   int64   `json:"zoneId"`
+	FlatFee  float64 `json:"flatFee"`
+	PerKmFee float64 `json:"perKmFee"`
+}
+
+// deliveryPricingSurcharge adds a fixed amount and/or a percentage of the fee
+// between StartTime and EndTime (HH:MM, merchant timezone). Windows may wrap
+// past midnight. Days uses 0 = Sunday; empty means every day.
+type deliveryPricingSurcharge struct {
+	Name      string  `json:"name"`
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+	Days      []int   `json:"days"`
+	Amount    float64 `json:"amount"`
+	Percent   float64 `json:"percent"`
+}
+
+type deliveryPricingInput struct {
+	DistanceKm float64
+	ZoneIDs    []int64
+	Subtotal   *float64
+	LocalTime  time.Time
+	BaseFee    float64
+	PerKmFee   float64
+	MinFee     *float64
+	MaxFee     *float64
+	Rules      deliveryPricingRules
+}
+
+type deliveryFeeBreakdown struct {
+	Rule                    string                     `json:"rule"`
+	ZoneID                  *int64                     `json:"zoneId,omitempty"`
+	Band                    *deliveryPricingBand       `json:"band,omitempty"`
+	FlatFee                 float64                    `json:"flatFee"`
+	DistanceFee             float64                    `json:"distanceFee"`
+	MinFeeApplied           bool                       `json:"minFeeApplied"`
+	MaxFeeApplied           bool                       `json:"maxFeeApplied"`
+	Surcharges              []appliedDeliverySurcharge `json:"surcharges"`
+	FeeBeforeDiscount       float64                    `json:"feeBeforeDiscount"`
+	FreeDelivery            bool                       `json:"freeDelivery"`
+	FreeDeliveryMinSubtotal *float64                   `json:"freeDeliveryMinSubtotal"`
+	Fee                     float64                    `json:"fee"`
+}
+
+type appliedDeliverySurcharge struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// errDeliveryBeyondBands is returned when a merchant prices by distance bands
+// and the distance falls outside all of them.
+var errDeliveryBeyondBands = errors.New("distance is not covered by any delivery band")
+
+// evaluateDeliveryPricing prices a delivery. The distance is priced by the
+// first zone override matching ZoneIDs, else the band containing the
+// distance, else (only when no bands are configured) the base + per-km rate.
+// A distance outside every configured band is errDeliveryBeyondBands. The
+// result is clamped to the min/max fee, surcharges active at LocalTime are
+// added, and the whole fee is waived when Subtotal reaches the free-delivery
+// threshold.
+func evaluateDeliveryPricing(in deliveryPricingInput) (deliveryFeeBreakdown, error) {
+	out := deliveryFeeBreakdown{
+		Rule:                    deliveryPricingRuleBaseRate,
+		Surcharges:              make([]appliedDeliverySurcharge, 0),
+		FreeDeliveryMinSubtotal: in.Rules.FreeDeliveryMinSubtotal,
+	}
+
+	priced := false
+	for _, override := range in.Rules.ZoneOverrides {
+		if !containsInt64(in.ZoneIDs, override.ZoneID) {
+			continue
+		}
+		zoneID := override.ZoneID
+		out.Rule = deliveryPricingRuleZone
+		out.ZoneID = &zoneID
+		out.FlatFee = override.FlatFee
+		out.DistanceFee = override.PerKmFee * in.DistanceKm
+		priced = true
+		break
+	}
+
+	if !priced {
+		for i := range in.Rules.Bands {
+			band := in.Rules.Bands[i]
+			if in.DistanceKm < band.FromKm || (band.ToKm != nil && in.DistanceKm >= *band.ToKm) {
+				continue
+			}
+			out.Rule = deliveryPricingRuleBand
+			out.Band = &band
+			out.FlatFee = band.FlatFee
+			out.DistanceFee = band.PerKmFee * (in.DistanceKm - band.FromKm)
+			priced = true
+			break
+		}
+	}
+
+	if !priced && len(in.Rules.Bands) > 0 {
+		return deliveryFeeBreakdown{}, errDeliveryBeyondBands
+	}
+	if !priced {
+		out.FlatFee = in.BaseFee
+		out.DistanceFee = in.PerKmFee * in.DistanceKm
+	}
+	out.FlatFee = deliveryRound2(out.FlatFee)
+	out.DistanceFee = deliveryRound2(out.DistanceFee)
+
+	fee := deliveryRound2(out.FlatFee + out.DistanceFee)
+	if in.MinFee != nil && fee < *in.MinFee {
+		fee = *in.MinFee
+		out.MinFeeApplied = true
+	}
+	if in.MaxFee != nil && fee > *in.MaxFee {
+		fee = *in.MaxFee
+		out.MaxFeeApplied = true
+	}
+
+	base := fee
+	for _, surcharge := range in.Rules.Surcharges {
+		if !deliverySurchargeActive(surcharge, in.LocalTime) {
+			continue
+		}
+		amount := deliveryRound2(surcharge.Amount + base*surcharge.Percent/100)
+		if amount <= 0 {
+			continue
+		}
+		out.Surcharges = append(out.Surcharges, appliedDeliverySurcharge{Name: surcharge.Name, Amount: amount})
+		fee += amount
+	}
+	out.FeeBeforeDiscount = deliveryRound2(fee)
+
+	if in.Rules.FreeDeliveryMinSubtotal != nil && in.Subtotal != nil && *in.Subtotal >= *in.Rules.FreeDeliveryMinSubtotal {
+		out.FreeDelivery = true
+		fee = 0
+	}
+	out.Fee = deliveryRound2(fee)
+	return out, nil
+}
+
+// deliveryBandsMaxKm is the upper bound of the last distance band, if bounded.
+func deliveryBandsMaxKm(bands []deliveryPricingBand) (float64, bool) {
+	maxKm, bounded := 0.0, false
+	for _, band := range bands {
+		if band.ToKm == nil {
+			return 0, false
+		}
+		if *band.ToKm > maxKm {
+			maxKm, bounded = *band.ToKm, true
+		}
+	}
+	return maxKm, bounded
+}
+
+func deliverySurchargeActive(surcharge deliveryPricingSurcharge, local time.Time) bool {
+	if len(surcharge.Days) > 0 {
+		day := int(local.Weekday())
+		// A window wrapping past midnight belongs to the day it started on.
+		if surcharge.StartTime > surcharge.EndTime && local.Format("15:04") < surcharge.EndTime {
+			day = (day + 6) % 7
+		}
+		found := false
+		for _, d := range surcharge.Days {
+			if d == day {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	now := local.Format("15:04")
+	if surcharge.StartTime <= surcharge.EndTime {
+		return now >= surcharge.StartTime && now < surcharge.EndTime
+	}
+	return now >= surcharge.StartTime || now < surcharge.EndTime
+}
+
+func containsInt64(values []int64, target int64) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDeliveryPricingRules(features []byte) deliveryPricingRules {
+	var wrapper struct {
+		DeliveryPricing *deliveryPricingRules `json:"deliveryPricing"`
+	}
+	if len(features) == 0 || json.Unmarshal(features, &wrapper) != nil || wrapper.DeliveryPricing == nil {
+		return deliveryPricingRules{}
+	}
+	return *wrapper.DeliveryPricing
+}
+
+func validateDeliveryPricingRules(rules *deliveryPricingRules) error {
+	if len(rules.Bands) > maxDeliveryPricingBands {
+		return fmt.Errorf("At most %d distance bands are allowed", maxDeliveryPricingBands)
+	}
+	if len(rules.Surcharges) > maxDeliveryPricingSurcharges {
+		return fmt.Errorf("At most %d surcharges are allowed", maxDeliveryPricingSurcharges)
+	}
+
+	sort.SliceStable(rules.Bands, func(i, j int) bool { return rules.Bands[i].FromKm < rules.Bands[j].FromKm })
+	for i, band := range rules.Bands {
+		if !deliveryIsFinite(band.FromKm) || band.FromKm < 0 {
+			return errors.New("Band fromKm must be 0 or greater")
+		}
+		if band.ToKm != nil && (!deliveryIsFinite(*band.ToKm) || *band.ToKm <= band.FromKm) {
+			return errors.New("Band toKm must be greater than fromKm")
+		}
+		if band.FlatFee < 0 || band.PerKmFee < 0 {
+			return errors.New("Band fees cannot be negative")
+		}
+		if i == 0 && band.FromKm != 0 {
+			return errors.New("The first distance band must start at 0 km")
+		}
+		if i > 0 {
+			prev := rules.Bands[i-1]
+			if prev.ToKm == nil || *prev.ToKm > band.FromKm {
+				return errors.New("Distance bands must not overlap")
+			}
+			if *prev.ToKm < band.FromKm {
+				return errors.New("Distance bands must not leave gaps")
+			}
+		}
+	}
+
+	seenZones := map[int64]bool{}
+	for _, override := range rules.ZoneOverrides {
+		if override.ZoneID <= 0 {
+			return errors.New("Zone override zoneId is required")
+		}
+		if seenZones[override.ZoneID] {
+			return errors.New("Each zone can only have one override")
+		}
+		seenZones[override.ZoneID] = true
+		if override.FlatFee < 0 || override.PerKmFee < 0 {
+			return errors.New("Zone override fees cannot be negative")
+		}
+	}
+
+	if rules.FreeDeliveryMinSubtotal != nil && (!deliveryIsFinite(*rules.FreeDeliveryMinSubtotal) || *rules.FreeDeliveryMinSubtotal <= 0) {
+		return errors.New("freeDeliveryMinSubtotal must be greater than 0")
+	}
+
+	for i := range rules.Surcharges {
+		surcharge := &rules.Surcharges[i]
+		surcharge.Name = strings.TrimSpace(surcharge.Name)
+		if surcharge.Name == "" {
+			return errors.New("Surcharge name is required")
+		}
+		if !isValidHHMM(surcharge.StartTime) || !isValidHHMM(surcharge.EndTime) || surcharge.StartTime == surcharge.EndTime {
+			return errors.New("Surcharge startTime and endTime must be different HH:MM values")
+		}
+		for _, d := range surcharge.Days {
+			if d < 0 || d > 6 {
+				return errors.New("Surcharge days must be between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+		if surcharge.Amount < 0 || surcharge.Percent < 0 || surcharge.Percent > 100 {
+			return errors.New("Surcharge amount cannot be negative and percent must be between 0 and 100")
+		}
+		if surcharge.Amount == 0 && surcharge.Percent == 0 {
+			return errors.New("Surcharge needs an amount or a percent")
+		}
+	}
+	return nil
+}
+
+func (h *Handler) MerchantDeliveryPricingGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var features []byte
+	if err := h.DB.QueryRow(ctx, `select features from merchants where id = $1`, *authCtx.MerchantID).Scan(&features); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       buildDeliveryPricingResponse(parseDeliveryPricingRules(features)),
+		"message":    "Delivery pricing retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantDeliveryPricingPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var body deliveryPricingRules
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if err := validateDeliveryPricingRules(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if len(body.ZoneOverrides) > 0 {
+		zoneIDs := make([]int64, 0, len(body.ZoneOverrides))
+		for _, override := range body.ZoneOverrides {
+			zoneIDs = append(zoneIDs, override.ZoneID)
+		}
+		var found int
+		if err := h.DB.QueryRow(ctx, `
+			select count(*) from merchant_delivery_zones where merchant_id = $1 and id = any($2)
+		`, *authCtx.MerchantID, zoneIDs).Scan(&found); err != nil {
+			h.Logger.Error("delivery pricing zone lookup failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update delivery pricing")
+			return
+		}
+		if found != len(zoneIDs) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Zone overrides must reference this merchant's delivery zones")
+			return
+		}
+	}
+
+	// Only the deliveryPricing key is replaced, so concurrent writes to other
+	// feature settings are not lost.
+	pricing, _ := json.Marshal(body)
+
+	var updatedFeatures []byte
+	if err := h.DB.QueryRow(ctx, `
+		update merchants
+		set features = jsonb_set(
+				case when jsonb_typeof(features) = 'object' then features else '{}'::jsonb end,
+				'{deliveryPricing}', $1::jsonb),
+			updated_at = now()
+		where id = $2
+		returning features
+	`, pricing, *authCtx.MerchantID).Scan(&updatedFeatures); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+			return
+		}
+		h.Logger.Error("delivery pricing update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update delivery pricing")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       buildDeliveryPricingResponse(parseDeliveryPricingRules(updatedFeatures)),
+		"message":    "Delivery pricing updated successfully",
+		"statusCode": 200,
+	})
+}
+
+func buildDeliveryPricingResponse(rules deliveryPricingRules) deliveryPricingRules {
+	if rules.Bands == nil {
+		rules.Bands = []deliveryPricingBand{}
+	}
+	if rules.ZoneOverrides == nil {
+		rules.ZoneOverrides = []deliveryPricingZoneOverride{}
+	}
+	if rules.Surcharges == nil {
+		rules.Surcharges = []deliveryPricingSurcharge{}
+	}
+	for i := range rules.Surcharges {
+		if rules.Surcharges[i].Days == nil {
+			rules.Surcharges[i].Days = []int{}
+		}
+	}
+	return rules
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvaluateDeliveryPricing(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	// Saturday 2026-01-10.
+	evening := time.Date(2026, 1, 10, 19, 30, 0, 0, time.UTC)
+	lateNight := time.Date(2026, 1, 11, 1, 0, 0, 0, time.UTC)
+	noon := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	rules := deliveryPricingRules{
+		Bands: []deliveryPricingBand{
+			{FromKm: 0, ToKm: f(3), FlatFee: 5},
+			{FromKm: 3, ToKm: f(7), FlatFee: 5, PerKmFee: 2},
+		},
+		ZoneOverrides: []deliveryPricingZoneOverride{{ZoneID: 9, FlatFee: 3}},
+		Surcharges: []deliveryPricingSurcharge{
+			{Name: "Dinner rush", StartTime: "18:00", EndTime: "20:00", Amount: 1, Percent: 10},
+			{Name: "Late night", StartTime: "23:00", EndTime: "02:00", Days: []int{6}, Amount: 2},
+		},
+		FreeDeliveryMinSubtotal: f(50),
+	}
+
+	cases := []struct {
+		name       string
+		in         deliveryPricingInput
+		rule       string
+		fee        float64
+		surcharges int
+		free       bool
+		err        error
+	}{
+		{
+			name: "flat band",
+			in:   deliveryPricingInput{DistanceKm: 2, LocalTime: noon, Rules: rules},
+			rule: deliveryPricingRuleBand,
+			fee:  5,
+		},
+		{
+			name: "per km band charges from band start",
+			in:   deliveryPricingInput{DistanceKm: 5, LocalTime: noon, Rules: rules},
+			rule: deliveryPricingRuleBand,
+			fee:  9,
+		},
+		{
+			name: "zone override wins over band",
+			in:   deliveryPricingInput{DistanceKm: 5, ZoneIDs: []int64{4, 9}, LocalTime: noon, Rules: rules},
+			rule: deliveryPricingRuleZone,
+			fee:  3,
+		},
+		{
+			name: "beyond the last band is out of range",
+			in:   deliveryPricingInput{DistanceKm: 10, BaseFee: 4, PerKmFee: 1.5, LocalTime: noon, Rules: rules},
+			err:  errDeliveryBeyondBands,
+		},
+		{
+			name: "zone override still prices beyond the bands",
+			in:   deliveryPricingInput{DistanceKm: 10, ZoneIDs: []int64{9}, LocalTime: noon, Rules: rules},
+			rule: deliveryPricingRuleZone,
+			fee:  3,
+		},
+		{
+			name: "base rate without bands clamps to max",
+			in:   deliveryPricingInput{DistanceKm: 10, BaseFee: 4, PerKmFee: 1.5, MaxFee: f(15), LocalTime: noon},
+			rule: deliveryPricingRuleBaseRate,
+			fee:  15,
+		},
+		{
+			name:       "time of day surcharge",
+			in:         deliveryPricingInput{DistanceKm: 2, LocalTime: evening, Rules: rules},
+			rule:       deliveryPricingRuleBand,
+			fee:        6.5,
+			surcharges: 1,
+		},
+		{
+			name:       "overnight surcharge keeps the starting day",
+			in:         deliveryPricingInput{DistanceKm: 2, LocalTime: lateNight, Rules: rules},
+			rule:       deliveryPricingRuleBand,
+			fee:        7,
+			surcharges: 1,
+		},
+		{
+			name:       "free delivery above subtotal waives surcharges",
+			in:         deliveryPricingInput{DistanceKm: 2, Subtotal: f(60), LocalTime: evening, Rules: rules},
+			rule:       deliveryPricingRuleBand,
+			fee:        0,
+			surcharges: 1,
+			free:       true,
+		},
+		{
+			name: "min fee without rules",
+			in:   deliveryPricingInput{DistanceKm: 1, BaseFee: 1, PerKmFee: 1, MinFee: f(4), LocalTime: noon},
+			rule: deliveryPricingRuleBaseRate,
+			fee:  4,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := evaluateDeliveryPricing(tc.in)
+			if tc.err != nil || err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if got.Rule != tc.rule || got.Fee != tc.fee || len(got.Surcharges) != tc.surcharges || got.FreeDelivery != tc.free {
+				t.Fatalf("expected %s fee=%v surcharges=%d free=%v, got %s fee=%v surcharges=%d free=%v",
+					tc.rule, tc.fee, tc.surcharges, tc.free, got.Rule, got.Fee, len(got.Surcharges), got.FreeDelivery)
+			}
+		})
+	}
+}
+
+func TestValidateDeliveryPricingBands(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name  string
+		bands []deliveryPricingBand
+		ok    bool
+	}{
+		{name: "contiguous", bands: []deliveryPricingBand{{FromKm: 3, ToKm: f(7)}, {FromKm: 0, ToKm: f(3)}, {FromKm: 7}}, ok: true},
+		{name: "open ended single band", bands: []deliveryPricingBand{{FromKm: 0}}, ok: true},
+		{name: "first band starts late", bands: []deliveryPricingBand{{FromKm: 1, ToKm: f(3)}}},
+		{name: "gap between bands", bands: []deliveryPricingBand{{FromKm: 0, ToKm: f(3)}, {FromKm: 4, ToKm: f(7)}}},
+		{name: "overlap", bands: []deliveryPricingBand{{FromKm: 0, ToKm: f(3)}, {FromKm: 2, ToKm: f(7)}}},
+		{name: "band after open ended band", bands: []deliveryPricingBand{{FromKm: 0}, {FromKm: 3, ToKm: f(7)}}},
+	}
+	for _, tc := range cases {
+		rules := deliveryPricingRules{Bands: tc.bands}
+		if err := validateDeliveryPricingRules(&rules); (err == nil) != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tc.name, tc.ok, err)
+		}
+	}
+}
+
+func TestDeliveryBandsMaxKm(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	if maxKm, ok := deliveryBandsMaxKm([]deliveryPricingBand{{FromKm: 0, ToKm: f(3)}, {FromKm: 3, ToKm: f(7.5)}}); !ok || maxKm != 7.5 {
+		t.Fatalf("expected 7.5, got %v/%v", maxKm, ok)
+	}
+	if _, ok := deliveryBandsMaxKm([]deliveryPricingBand{{FromKm: 0, ToKm: f(3)}, {FromKm: 3}}); ok {
+		t.Fatal("expected open-ended bands to be unbounded")
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	defaultBatchMaxStops  = 4
+	maxBatchMaxStops      = 8
+	defaultBatchRadiusKm  = 2.0
+	maxBatchRadiusKm      = 20.0
+	twoOptMaxPasses       = 50
+	routeStopDwellMinutes = 4.0
+)
+
+type routePoint struct {
+	Lat float64
+	Lng float64
+}
+
+type batchCandidateOrder struct {
+	OrderID  int64
+	Point    routePoint
+	PlacedAt time.Time
+}
+
+// groupDeliveryBatches clusters orders whose destinations are close to each
+// other. The oldest order seeds a group, which then takes the closest
+// remaining orders within radiusKm of any member until it holds maxStops.
+// Orders that find no partner stay in a group of one.
+func groupDeliveryBatches(orders []batchCandidateOrder, radiusKm float64, maxStops int) [][]batchCandidateOrder {
+	if maxStops < 1 {
+		maxStops = 1
+	}
+	pending := make([]batchCandidateOrder, len(orders))
+	copy(pending, orders)
+	sort.SliceStable(pending, func(i, j int) bool {
+		if !pending[i].PlacedAt.Equal(pending[j].PlacedAt) {
+			return pending[i].PlacedAt.Before(pending[j].PlacedAt)
+		}
+		return pending[i].OrderID < pending[j].OrderID
+	})
+
+	used := make([]bool, len(pending))
+	groups := make([][]batchCandidateOrder, 0)
+	for seed := range pending {
+		if used[seed] {
+			continue
+		}
+		used[seed] = true
+		group := []batchCandidateOrder{pending[seed]}
+
+		for len(group) < maxStops {
+			best := -1
+			bestDistance := 0.0
+			for i := range pending {
+				if used[i] {
+					continue
+				}
+				d := distanceToGroup(pending[i].Point, group)
+				if d > radiusKm {
+					continue
+				}
+				if best < 0 || d < bestDistance {
+					best = i
+					bestDistance = d
+				}
+			}
+			if best < 0 {
+				break
+			}
+			used[best] = true
+			group = append(group, pending[best])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func distanceToGroup(p routePoint, group []batchCandidateOrder) float64 {
+	best := -1.0
+	for _, member := range group {
+		d := haversineDistanceKm(p.Lat, p.Lng, member.Point.Lat, member.Point.Lng)
+		if best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// planDeliveryRoute returns the visiting order of stops (as indexes into
+// stops) for an open route starting at origin, built with nearest neighbour
+// and improved with 2-opt.
+func planDeliveryRoute(origin routePoint, stops []routePoint) []int {
+	n := len(stops)
+	if n == 0 {
+		return []int{}
+	}
+
+	route := make([]int, 0, n)
+	visited := make([]bool, n)
+	current := origin
+	for len(route) < n {
+		best := -1
+		bestDistance := 0.0
+		for i, stop := range stops {
+			if visited[i] {
+				continue
+			}
+			d := haversineDistanceKm(current.Lat, current.Lng, stop.Lat, stop.Lng)
+			if best < 0 || d < bestDistance {
+				best = i
+				bestDistance = d
+			}
+		}
+		visited[best] = true
+		route = append(route, best)
+		current = stops[best]
+	}
+
+	if n < 3 {
+		return route
+	}
+
+	for pass := 0; pass < twoOptMaxPasses; pass++ {
+		improved := false
+		for i := 0; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				candidate := twoOptSwap(route, i, k)
+				if routeDistanceKm(origin, stops, candidate) < routeDistanceKm(origin, stops, route)-1e-9 {
+					route = candidate
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return route
+}
+
+func twoOptSwap(route []int, i, k int) []int {
+	out := make([]int, len(route))
+	copy(out, route)
+	for left, right := i, k; left < right; left, right = left+1, right-1 {
+		out[left], out[right] = out[right], out[left]
+	}
+	return out
+}
+
+func routeDistanceKm(origin routePoint, stops []routePoint, route []int) float64 {
+	total := 0.0
+	current := origin
+	for _, idx := range route {
+		next := stops[idx]
+		total += haversineDistanceKm(current.Lat, current.Lng, next.Lat, next.Lng)
+		current = next
+	}
+	return total
+}
+
+// cumulativeRouteDistancesKm returns, for each position in route, the distance
+// travelled from origin when that stop is reached.
+func cumulativeRouteDistancesKm(origin routePoint, stops []routePoint, route []int) []float64 {
+	out := make([]float64, len(route))
+	total := 0.0
+	current := origin
+	for pos, idx := range route {
+		next := stops[idx]
+		total += haversineDistanceKm(current.Lat, current.Lng, next.Lat, next.Lng)
+		out[pos] = deliveryRound3(total)
+		current = next
+	}
+	return out
+}
+
+// routeEtaMinutes estimates the minutes until a stop is reached given the
+// distance still to travel and how many stops come before it. Travel time
+// comes from the merchant's delivery ETA model at local.
+func routeEtaMinutes(model deliveryEtaModel, remainingKm float64, stopsAhead int, local time.Time) float64 {
+	if remainingKm < 0 {
+		remainingKm = 0
+	}
+	travel, _ := model.travelMinutes(remainingKm, local)
+	minutes := travel + float64(stopsAhead)*routeStopDwellMinutes
+	return deliveryRound2(minutes)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanDeliveryRoute(t *testing.T) {
+	origin := routePoint{Lat: 0, Lng: 0}
+	// Stops laid out on a line east of the origin, given out of order.
+	stops := []routePoint{
+		{Lat: 0, Lng: 0.03},
+		{Lat: 0, Lng: 0.01},
+		{Lat: 0, Lng: 0.04},
+		{Lat: 0, Lng: 0.02},
+	}
+
+	route := planDeliveryRoute(origin, stops)
+	expected := []int{1, 3, 0, 2}
+	if len(route) != len(expected) {
+		t.Fatalf("expected %d stops, got %d", len(expected), len(route))
+	}
+	for i := range expected {
+		if route[i] != expected[i] {
+			t.Fatalf("expected route %v, got %v", expected, route)
+		}
+	}
+
+	cumulative := cumulativeRouteDistancesKm(origin, stops, route)
+	for i := 1; i < len(cumulative); i++ {
+		if cumulative[i] < cumulative[i-1] {
+			t.Fatalf("cumulative distances must not decrease: %v", cumulative)
+		}
+	}
+}
+
+func TestPlanDeliveryRouteTwoOptRemovesCrossing(t *testing.T) {
+	origin := routePoint{Lat: 0, Lng: 0}
+	stops := []routePoint{
+		{Lat: 0.010, Lng: 0.000},
+		{Lat: 0.011, Lng: 0.020},
+		{Lat: 0.000, Lng: 0.021},
+		{Lat: 0.000, Lng: 0.009},
+	}
+
+	route := planDeliveryRoute(origin, stops)
+	nn := []int{3, 0, 1, 2}
+	if routeDistanceKm(origin, stops, route) > routeDistanceKm(origin, stops, nn)+1e-9 {
+		t.Fatalf("2-opt route %v is longer than nearest neighbour %v", route, nn)
+	}
+}
+
+func TestGroupDeliveryBatches(t *testing.T) {
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	orders := []batchCandidateOrder{
+		{OrderID: 1, Point: routePoint{Lat: -6.2000, Lng: 106.8000}, PlacedAt: base},
+		{OrderID: 2, Point: routePoint{Lat: -6.2050, Lng: 106.8050}, PlacedAt: base.Add(time.Minute)},
+		{OrderID: 3, Point: routePoint{Lat: -6.5000, Lng: 106.9000}, PlacedAt: base.Add(2 * time.Minute)},
+		{OrderID: 4, Point: routePoint{Lat: -6.2010, Lng: 106.8010}, PlacedAt: base.Add(3 * time.Minute)},
+		{OrderID: 5, Point: routePoint{Lat: -6.2020, Lng: 106.8020}, PlacedAt: base.Add(4 * time.Minute)},
+	}
+
+	groups := groupDeliveryBatches(orders, 2, 3)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if len(groups[0]) != 3 || groups[0][0].OrderID != 1 {
+		t.Fatalf("expected first group seeded by order 1 with 3 stops, got %+v", groups[0])
+	}
+	for _, o := range groups[0] {
+		if o.OrderID == 3 {
+			t.Fatalf("distant order should not be batched: %+v", groups[0])
+		}
+	}
+}
+
+func TestRouteEtaMinutes(t *testing.T) {
+	noon := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	model := deliveryEtaModel{SpeedKmh: 25}
+	if got := routeEtaMinutes(model, 5, 0, noon); got != 12 {
+		t.Fatalf("expected 12 minutes for 5km with no stops ahead, got %v", got)
+	}
+	if got := routeEtaMinutes(model, 5, 2, noon); got != 20 {
+		t.Fatalf("expected 20 minutes for 5km with 2 stops ahead, got %v", got)
+	}
+
+	// The merchant's speed and time-of-day factors apply to route legs too.
+	learned := deliveryEtaModel{
+		SpeedKmh:    20,
+		TimeFactors: []deliveryEtaTimeFactor{{StartTime: "11:00", EndTime: "13:00", Factor: 1.5}},
+	}
+	if got := routeEtaMinutes(learned, 5, 1, noon); got != 26.5 {
+		t.Fatalf("expected 26.5 minutes with the merchant model, got %v", got)
+	}
+	if got := routeEtaMinutes(learned, -1, 0, noon); got != 0 {
+		t.Fatalf("expected negative distance to clamp to 0, got %v", got)
+	}
+}
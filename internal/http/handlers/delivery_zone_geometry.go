@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	maxZoneRingPoints    = 250
+	maxZoneGeometryParts = 50
+	maxZoneTotalPoints   = 5000
+)
+
+// zonePolygon is an outer ring followed by zero or more holes.
+type zonePolygon [][]deliveryZonePoint
+
+// zoneGeometry is one or more polygons (a MultiPolygon). Rings are stored
+// open: the closing point of a GeoJSON ring is dropped.
+type zoneGeometry []zonePolygon
+
+var errInvalidZoneGeometry = errors.New("invalid polygon")
+
+// decodeZoneGeometry reads merchant_delivery_zones.polygon. A flat list of
+// points is the original single-ring format; a list of polygons (each a list
+// of rings) carries holes and multiple parts.
+func decodeZoneGeometry(raw []byte) (zoneGeometry, error) {
+	if len(raw) == 0 {
+		return nil, errInvalidZoneGeometry
+	}
+	var ring []deliveryZonePoint
+	if err := json.Unmarshal(raw, &ring); err == nil {
+		return zoneGeometry{zonePolygon{ring}}, nil
+	}
+	var geometry zoneGeometry
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return nil, err
+	}
+	return geometry, nil
+}
+
+// encodeZoneGeometry keeps single-ring zones in the original flat format so
+// existing readers of the polygon column are unaffected.
+func encodeZoneGeometry(geometry zoneGeometry) ([]byte, error) {
+	if len(geometry) == 1 && len(geometry[0]) == 1 {
+		return json.Marshal(geometry[0][0])
+	}
+	return json.Marshal(geometry)
+}
+
+// parseZoneGeometry accepts the upsert payload's polygon: a ring of points, a
+// list of rings (outer first, then holes) or a list of such polygons.
+func parseZoneGeometry(value any) (zoneGeometry, error) {
+	items, ok := value.([]any)
+	if !ok || len(items) == 0 {
+		return nil, errInvalidZoneGeometry
+	}
+	switch first := items[0].(type) {
+	case map[string]any:
+		ring, err := parsePolygon(items)
+		if err != nil {
+			return nil, err
+		}
+		return zoneGeometry{zonePolygon{ring}}, nil
+	case []any:
+		if len(first) > 0 {
+			if _, nested := first[0].([]any); nested {
+				geometry := make(zoneGeometry, 0, len(items))
+				for _, rawPolygon := range items {
+					polygon, err := parseZonePolygonRings(rawPolygon)
+					if err != nil {
+						return nil, err
+					}
+					geometry = append(geometry, polygon)
+				}
+				return geometry, nil
+			}
+		}
+		polygon, err := parseZonePolygonRings(items)
+		if err != nil {
+			return nil, err
+		}
+		return zoneGeometry{polygon}, nil
+	}
+	return nil, errInvalidZoneGeometry
+}
+
+func parseZonePolygonRings(value any) (zonePolygon, error) {
+	rings, ok := value.([]any)
+	if !ok || len(rings) == 0 {
+		return nil, errInvalidZoneGeometry
+	}
+	polygon := make(zonePolygon, 0, len(rings))
+	for _, rawRing := range rings {
+		ring, err := parsePolygon(rawRing)
+		if err != nil {
+			return nil, err
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+// parsePolygonFromGeoJSON converts a GeoJSON Polygon or MultiPolygon geometry.
+func parsePolygonFromGeoJSON(geometry map[string]any) (zoneGeometry, error) {
+	if geometry == nil {
+		return nil, errors.New("invalid geometry")
+	}
+	coords, ok := geometry["coordinates"].([]any)
+	if !ok || len(coords) == 0 {
+		return nil, errInvalidZoneGeometry
+	}
+
+	switch strings.ToLower(toStringValue(geometry["type"])) {
+	case "polygon":
+		polygon, err := parseGeoJSONPolygon(coords)
+		if err != nil {
+			return nil, err
+		}
+		return zoneGeometry{polygon}, nil
+	case "multipolygon":
+		out := make(zoneGeometry, 0, len(coords))
+		for _, rawPolygon := range coords {
+			rings, ok := rawPolygon.([]any)
+			if !ok || len(rings) == 0 {
+				return nil, errInvalidZoneGeometry
+			}
+			polygon, err := parseGeoJSONPolygon(rings)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, polygon)
+		}
+		return out, nil
+	}
+	return nil, errors.New("invalid geometry")
+}
+
+func parseGeoJSONPolygon(rings []any) (zonePolygon, error) {
+	polygon := make(zonePolygon, 0, len(rings))
+	for _, rawRing := range rings {
+		ring, ok := rawRing.([]any)
+		if !ok || len(ring) < 4 {
+			return nil, errInvalidZoneGeometry
+		}
+		points := make([]deliveryZonePoint, 0, len(ring))
+		for _, raw := range ring {
+			pair, ok := raw.([]any)
+			if !ok || len(pair) < 2 {
+				return nil, errInvalidZoneGeometry
+			}
+			lng, err := parseOptionalFloat(pair[0])
+			if err != nil || lng == nil {
+				return nil, errInvalidZoneGeometry
+			}
+			lat, err := parseOptionalFloat(pair[1])
+			if err != nil || lat == nil {
+				return nil, errInvalidZoneGeometry
+			}
+			if *lat < -90 || *lat > 90 || *lng < -180 || *lng > 180 {
+				return nil, errInvalidZoneGeometry
+			}
+			points = append(points, deliveryZonePoint{Lat: *lat, Lng: *lng})
+		}
+		first := points[0]
+		last := points[len(points)-1]
+		if first.Lat == last.Lat && first.Lng == last.Lng {
+			points = points[:len(points)-1]
+		}
+		polygon = append(polygon, points)
+	}
+	return polygon, nil
+}
+
+// validateZoneGeometry checks ring sizes and that every hole starts inside
+// its outer ring.
+func validateZoneGeometry(geometry zoneGeometry) error {
+	if len(geometry) == 0 {
+		return errors.New("polygon is required for POLYGON zones")
+	}
+	if len(geometry) > maxZoneGeometryParts {
+		return errors.New("polygon has too many parts (max 50)")
+	}
+	total := 0
+	for _, polygon := range geometry {
+		if len(polygon) == 0 {
+			return errors.New("polygon must have at least 3 points")
+		}
+		for i, ring := range polygon {
+			if len(ring) < 3 {
+				return errors.New("polygon must have at least 3 points")
+			}
+			if len(ring) > maxZoneRingPoints {
+				return errors.New("polygon has too many points (max 250)")
+			}
+			total += len(ring)
+			if i > 0 && !pointInPolygon(ring[0].Lat, ring[0].Lng, polygon[0]) {
+				return errors.New("polygon holes must lie inside the outer ring")
+			}
+		}
+	}
+	if total > maxZoneTotalPoints {
+		return errors.New("polygon has too many points (max 5000)")
+	}
+	return nil
+}
+
+// zoneGeometryContains reports whether the point is inside any part and
+// outside all of that part's holes.
+func zoneGeometryContains(geometry zoneGeometry, lat, lng float64) bool {
+	for _, polygon := range geometry {
+		if len(polygon) == 0 || len(polygon[0]) < 3 {
+			continue
+		}
+		if !pointInPolygon(lat, lng, polygon[0]) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if len(hole) >= 3 && pointInPolygon(lat, lng, hole) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneGeometryToGeoJSON returns a Polygon for single-part zones and a
+// MultiPolygon otherwise, with closed [lng, lat] rings.
+func zoneGeometryToGeoJSON(geometry zoneGeometry) map[string]any {
+	polygons := make([]any, 0, len(geometry))
+	for _, polygon := range geometry {
+		rings := make([]any, 0, len(polygon))
+		for _, ring := range polygon {
+			coords := make([]any, 0, len(ring)+1)
+			for _, p := range ring {
+				coords = append(coords, []float64{p.Lng, p.Lat})
+			}
+			if len(ring) > 0 {
+				coords = append(coords, []float64{ring[0].Lng, ring[0].Lat})
+			}
+			rings = append(rings, coords)
+		}
+		polygons = append(polygons, rings)
+	}
+	if len(polygons) == 1 {
+		return map[string]any{"type": "Polygon", "coordinates": polygons[0]}
+	}
+	return map[string]any{"type": "MultiPolygon", "coordinates": polygons}
+}
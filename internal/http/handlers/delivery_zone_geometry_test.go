@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestZoneGeometryContains(t *testing.T) {
+	square := func(minLat, minLng, maxLat, maxLng float64) []deliveryZonePoint {
+		return []deliveryZonePoint{
+			{Lat: minLat, Lng: minLng},
+			{Lat: minLat, Lng: maxLng},
+			{Lat: maxLat, Lng: maxLng},
+			{Lat: maxLat, Lng: minLng},
+		}
+	}
+
+	city := zonePolygon{square(0, 0, 10, 10), square(4, 4, 6, 6)}
+	island := zonePolygon{square(20, 20, 22, 22)}
+	geometry := zoneGeometry{city, island}
+
+	cases := []struct {
+		name     string
+		lat, lng float64
+		expected bool
+	}{
+		{name: "inside outer ring", lat: 1, lng: 1, expected: true},
+		{name: "inside hole", lat: 5, lng: 5, expected: false},
+		{name: "inside second part", lat: 21, lng: 21, expected: true},
+		{name: "outside every part", lat: 15, lng: 15, expected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := zoneGeometryContains(geometry, tc.lat, tc.lng); got != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	if err := validateZoneGeometry(geometry); err != nil {
+		t.Fatalf("expected valid geometry, got %v", err)
+	}
+	if err := validateZoneGeometry(zoneGeometry{{square(0, 0, 1, 1), square(5, 5, 6, 6)}}); err == nil {
+		t.Fatalf("expected hole outside the outer ring to be rejected")
+	}
+}
+
+func TestZoneGeometryGeoJSONRoundTrip(t *testing.T) {
+	raw := `{"type":"MultiPolygon","coordinates":[
+		[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]],
+		[[[20,20],[22,20],[22,22],[20,22],[20,20]]]
+	]}`
+	var geometry map[string]any
+	if err := json.Unmarshal([]byte(raw), &geometry); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parsePolygonFromGeoJSON(geometry)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(parsed) != 2 || len(parsed[0]) != 2 || len(parsed[0][0]) != 4 {
+		t.Fatalf("unexpected shape: %+v", parsed)
+	}
+
+	stored, err := encodeZoneGeometry(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeZoneGeometry(stored)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	exported, _ := json.Marshal(zoneGeometryToGeoJSON(decoded))
+	var again map[string]any
+	_ = json.Unmarshal(exported, &again)
+	reparsed, err := parsePolygonFromGeoJSON(again)
+	if err != nil || len(reparsed) != 2 || len(reparsed[0][1]) != 4 {
+		t.Fatalf("round trip failed: %v %+v", err, reparsed)
+	}
+
+	legacy, err := decodeZoneGeometry([]byte(`[{"lat":0,"lng":0},{"lat":0,"lng":1},{"lat":1,"lng":1}]`))
+	if err != nil || len(legacy) != 1 || len(legacy[0]) != 1 || len(legacy[0][0]) != 3 {
+		t.Fatalf("legacy ring decode failed: %v %+v", err, legacy)
+	}
+}
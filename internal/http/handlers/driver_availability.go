@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var driverAvailabilityStatuses = map[string]bool{
+	"ON_SHIFT": true,
+	"BUSY":     true,
+	"OFFLINE":  true,
+}
+
+type driverAvailabilityRequest struct {
+	Status    *string  `json:"status"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+func (h *Handler) DriverAvailabilityGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	data, err := h.fetchDriverAvailability(ctx, *authCtx.MerchantID, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("driver availability query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve availability")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) DriverAvailabilityPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body driverAvailabilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	var status *string
+	if body.Status != nil {
+		normalized := strings.ToUpper(strings.TrimSpace(*body.Status))
+		if !driverAvailabilityStatuses[normalized] {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "status must be one of ON_SHIFT, BUSY, OFFLINE")
+			return
+		}
+		status = &normalized
+	}
+	if (body.Latitude == nil) != (body.Longitude == nil) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "latitude and longitude must be provided together")
+		return
+	}
+	if body.Latitude != nil && (*body.Latitude < -90 || *body.Latitude > 90 || *body.Longitude < -180 || *body.Longitude > 180) {
+		response.Error(w, http.StatusBadRequest, "INVALID_COORDS", "Valid latitude and longitude are required")
+		return
+	}
+	if status == nil && body.Latitude == nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "status or location is required")
+		return
+	}
+
+	if err := h.upsertDriverAvailability(ctx, *authCtx.MerchantID, authCtx.UserID, status, body.Latitude, body.Longitude); err != nil {
+		h.Logger.Error("driver availability update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update availability")
+		return
+	}
+
+	// Going off shift withdraws any offer the driver has not answered yet.
+	if status != nil && *status == "OFFLINE" {
+		if _, err := h.DB.Exec(ctx, `
+			update order_dispatch_attempts
+			set status = 'CANCELLED', reason = 'Driver went offline', responded_at = now()
+			where merchant_id = $1 and driver_user_id = $2 and status = 'OFFERED'
+		`, *authCtx.MerchantID, authCtx.UserID); err != nil {
+			h.Logger.Warn("driver offer withdrawal failed", zapError(err))
+		}
+	}
+
+	data, err := h.fetchDriverAvailability(ctx, *authCtx.MerchantID, authCtx.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update availability")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Availability updated",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) DriverOffersList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select a.id, a.order_id, a.strategy, a.distance_km, a.status, a.reason, a.offered_at, a.expires_at, a.responded_at,
+		       o.order_number, o.delivery_address, o.delivery_latitude, o.delivery_longitude, o.delivery_distance_km, o.total_amount
+		from order_dispatch_attempts a
+		join orders o on o.id = a.order_id
+		where a.merchant_id = $1
+		  and a.driver_user_id = $2
+		  and a.status = 'OFFERED'
+		  and a.expires_at > now()
+		order by a.offered_at asc
+	`, *authCtx.MerchantID, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("driver offers query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve offers")
+		return
+	}
+	defer rows.Close()
+
+	offers := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			attemptID    int64
+			orderID      int64
+			strategy     string
+			distanceKm   pgtype.Numeric
+			status       string
+			reason       pgtype.Text
+			offeredAt    time.Time
+			expiresAt    time.Time
+			respondedAt  pgtype.Timestamptz
+			orderNumber  string
+			address      pgtype.Text
+			deliveryLat  pgtype.Numeric
+			deliveryLng  pgtype.Numeric
+			deliveryDist pgtype.Numeric
+			totalAmount  pgtype.Numeric
+		)
+		if err := rows.Scan(&attemptID, &orderID, &strategy, &distanceKm, &status, &reason, &offeredAt, &expiresAt, &respondedAt,
+			&orderNumber, &address, &deliveryLat, &deliveryLng, &deliveryDist, &totalAmount); err != nil {
+			h.Logger.Error("driver offers scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve offers")
+			return
+		}
+		offer := dispatchOfferPayload(attemptID, orderID, strategy, distanceKm, status, reason, offeredAt, expiresAt, respondedAt)
+		offer["order"] = map[string]any{
+			"id":                 fmt.Sprint(orderID),
+			"orderNumber":        orderNumber,
+			"deliveryAddress":    nullableText(address),
+			"deliveryLatitude":   nullableNumeric(deliveryLat),
+			"deliveryLongitude":  nullableNumeric(deliveryLng),
+			"deliveryDistanceKm": nullableNumeric(deliveryDist),
+			"totalAmount":        nullableNumeric(totalAmount),
+		}
+		offers = append(offers, offer)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    offers,
+		"count":   len(offers),
+	})
+}
+
+func (h *Handler) DriverOfferAccept(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	attemptID, err := readPathInt64(r, "attemptId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Offer ID is required")
+		return
+	}
+
+	orderID, orderNumber, err := h.acceptDispatchOffer(ctx, *authCtx.MerchantID, authCtx.UserID, attemptID)
+	if err != nil {
+		if errors.Is(err, errDispatchOfferUnavailable) {
+			response.Error(w, http.StatusConflict, "OFFER_UNAVAILABLE", "This offer has expired or is no longer available")
+			return
+		}
+		h.Logger.Error("dispatch offer accept failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to accept offer")
+		return
+	}
+
+	h.notifyOrderRealtime(ctx, *authCtx.MerchantID, orderNumber)
+
+	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+		"message": "Delivery accepted",
+	})
+}
+
+func (h *Handler) DriverOfferDecline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	attemptID, err := readPathInt64(r, "attemptId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Offer ID is required")
+		return
+	}
+
+	var body struct {
+		Reason *string `json:"reason"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	var reason *string
+	if body.Reason != nil {
+		if trimmed := strings.TrimSpace(*body.Reason); trimmed != "" {
+			reason = &trimmed
+		}
+	}
+
+	var orderID int64
+	err = h.DB.QueryRow(ctx, `
+		update order_dispatch_attempts
+		set status = 'DECLINED', reason = $4, responded_at = now()
+		where id = $1 and merchant_id = $2 and driver_user_id = $3 and status = 'OFFERED'
+		returning order_id
+	`, attemptID, *authCtx.MerchantID, authCtx.UserID, reason).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusConflict, "OFFER_UNAVAILABLE", "This offer has expired or is no longer available")
+			return
+		}
+		h.Logger.Error("dispatch offer decline failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to decline offer")
+		return
+	}
+
+	h.autoDispatchOrder(ctx, *authCtx.MerchantID, orderID)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"message": "Offer declined",
+	})
+}
+
+var errDispatchOfferUnavailable = errors.New("dispatch offer unavailable")
+
+func (h *Handler) acceptDispatchOffer(ctx context.Context, merchantID, driverUserID, attemptID int64) (int64, string, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var orderID int64
+	err = tx.QueryRow(ctx, `
+		select order_id
+		from order_dispatch_attempts
+		where id = $1 and merchant_id = $2 and driver_user_id = $3 and status = 'OFFERED' and expires_at > now()
+		for update
+	`, attemptID, merchantID, driverUserID).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", errDispatchOfferUnavailable
+		}
+		return 0, "", err
+	}
+
+	var (
+		orderNumber   string
+		status        string
+		currentDriver pgtype.Int8
+	)
+	if err := tx.QueryRow(ctx, `
+		select order_number, status, delivery_driver_user_id
+		from orders
+		where id = $1 and merchant_id = $2
+		for update
+	`, orderID, merchantID).Scan(&orderNumber, &status, &currentDriver); err != nil {
+		return 0, "", err
+	}
+	if currentDriver.Valid || status == "CANCELLED" || status == "COMPLETED" {
+		return 0, "", errDispatchOfferUnavailable
+	}
+
+	if _, err := tx.Exec(ctx, `
+		update order_dispatch_attempts
+		set status = 'ACCEPTED', responded_at = now()
+		where id = $1
+	`, attemptID); err != nil {
+		return 0, "", err
+	}
+	if _, err := tx.Exec(ctx, `
+		update orders
+		set delivery_driver_user_id = $1,
+			delivery_assigned_at = $2,
+			delivery_status = 'ASSIGNED'
+		where id = $3 and merchant_id = $4
+	`, driverUserID, time.Now(), orderID, merchantID); err != nil {
+		return 0, "", err
+	}
+	if err := resetDeliveryProgress(ctx, tx, orderID); err != nil {
+		return 0, "", err
+	}
+	if _, err := tx.Exec(ctx, `
+		update driver_availability set status = 'BUSY', updated_at = now()
+		where merchant_id = $1 and user_id = $2
+	`, merchantID, driverUserID); err != nil {
+		return 0, "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", err
+	}
+	return orderID, orderNumber, nil
+}
+
+func (h *Handler) upsertDriverAvailability(ctx context.Context, merchantID, userID int64, status *string, latitude, longitude *float64) error {
+	_, err := h.DB.Exec(ctx, `
+		insert into driver_availability (merchant_id, user_id, status, latitude, longitude, location_updated_at, updated_at)
+		values ($1, $2, coalesce($3, 'OFFLINE'), $4, $5, case when $4::numeric is null then null else now() end, now())
+		on conflict (merchant_id, user_id) do update
+		set status = coalesce($3, driver_availability.status),
+			latitude = coalesce($4, driver_availability.latitude),
+			longitude = coalesce($5, driver_availability.longitude),
+			location_updated_at = case when $4::numeric is null then driver_availability.location_updated_at else now() end,
+			updated_at = now()
+	`, merchantID, userID, status, latitude, longitude)
+	return err
+}
+
+func (h *Handler) fetchDriverAvailability(ctx context.Context, merchantID, userID int64) (map[string]any, error) {
+	var (
+		status            string
+		latitude          pgtype.Numeric
+		longitude         pgtype.Numeric
+		locationUpdatedAt pgtype.Timestamptz
+		updatedAt         pgtype.Timestamptz
+	)
+	err := h.DB.QueryRow(ctx, `
+		select status, latitude, longitude, location_updated_at, updated_at
+		from driver_availability
+		where merchant_id = $1 and user_id = $2
+	`, merchantID, userID).Scan(&status, &latitude, &longitude, &locationUpdatedAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return map[string]any{
+				"userId":            fmt.Sprint(userID),
+				"status":            "OFFLINE",
+				"latitude":          nil,
+				"longitude":         nil,
+				"locationUpdatedAt": nil,
+				"updatedAt":         nil,
+			}, nil
+		}
+		return nil, err
+	}
+
+	return map[string]any{
+		"userId":            fmt.Sprint(userID),
+		"status":            status,
+		"latitude":          nullableNumeric(latitude),
+		"longitude":         nullableNumeric(longitude),
+		"locationUpdatedAt": nullableTime(locationUpdatedAt),
+		"updatedAt":         nullableTime(updatedAt),
+	}, nil
+}
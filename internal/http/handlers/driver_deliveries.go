@@ -0,0 +1,630 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delivery states a driver can move an order through. ASSIGNED is set by the
+// merchant (or dispatch); everything after that is driven from the driver app.
+var driverDeliveryTransitions = map[string][]string{
+	"ASSIGNED":  {"PICKED_UP", "FAILED"},
+	"PICKED_UP": {"ARRIVED", "DELIVERED", "FAILED"},
+	"ARRIVED":   {"DELIVERED", "FAILED"},
+	"DELIVERED": {},
+	"FAILED":    {},
+}
+
+var driverActiveDeliveryStatuses = []string{"ASSIGNED", "PICKED_UP", "ARRIVED"}
+
+// The core "DeliveryStatus" enum only knows PENDING_ASSIGNMENT, ASSIGNED and
+// DELIVERED. Driver progress is kept in order_delivery_progress and refines
+// ASSIGNED; queries join DeliveryProgressJoinSQL and select
+// EffectiveDeliveryStatusSQL wherever they need the full status.
+const (
+	DeliveryProgressJoinSQL    = `left join order_delivery_progress dp on dp.order_id = o.id`
+	EffectiveDeliveryStatusSQL = `case when o.delivery_status::text = 'ASSIGNED' and dp.status is not null then dp.status else o.delivery_status::text end`
+)
+
+type driverOrderRef struct {
+	OrderID        int64
+	OrderNumber    string
+	MerchantCode   string
+	Status         string
+	DeliveryStatus string
+}
+
+var errDriverOrderNotFound = errors.New("delivery order not found")
+
+// driverDeliveryConflictError reports a status change that is no longer
+// allowed once the order row is locked, e.g. after a concurrent update.
+type driverDeliveryConflictError struct {
+	Message string
+}
+
+func (e *driverDeliveryConflictError) Error() string { return e.Message }
+
+// checkDriverDeliveryUpdate validates a driver status change against the
+// order's current status. Every driver step moves the delivery past ASSIGNED,
+// so the kitchen must have marked the order READY; a COMPLETED delivery order
+// is already DELIVERED.
+func checkDriverDeliveryUpdate(orderStatus, current, next string) error {
+	if orderStatus != "READY" {
+		return &driverDeliveryConflictError{Message: "Order is not ready for delivery"}
+	}
+	if !isValidDriverDeliveryTransition(current, next) {
+		return &driverDeliveryConflictError{Message: fmt.Sprintf("Cannot change delivery status from %s to %s", current, next)}
+	}
+	return nil
+}
+
+type driverDeliveryStatusRequest struct {
+	Status    string   `json:"status"`
+	Reason    *string  `json:"reason"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+func (h *Handler) DriverOrdersList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	scope := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("scope")))
+	limit := parseIntWithBounds(r.URL.Query().Get("limit"), 50, 1, 200)
+
+	statusFilter := EffectiveDeliveryStatusSQL + " = any($3) and o.status not in ('CANCELLED')"
+	statuses := driverActiveDeliveryStatuses
+	orderBy := "o.delivery_assigned_at asc nulls last, s.position asc nulls last, o.id asc"
+	if scope == "history" {
+		statusFilter = EffectiveDeliveryStatusSQL + " = any($3)"
+		statuses = []string{"DELIVERED", "FAILED"}
+		orderBy = "o.updated_at desc, o.id desc"
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select o.id, o.order_number, o.status, `+EffectiveDeliveryStatusSQL+`, o.delivery_assigned_at,
+		       o.delivery_address, o.delivery_unit, o.delivery_instructions,
+		       o.delivery_latitude, o.delivery_longitude, o.delivery_distance_km,
+		       o.total_amount, o.placed_at, o.updated_at,
+		       c.name, c.phone,
+		       p.payment_method, p.status,
+		       s.batch_id, s.position
+		from orders o
+		left join customers c on c.id = o.customer_id
+		left join payments p on p.order_id = o.id
+		left join delivery_batch_stops s on s.order_id = o.id
+		`+DeliveryProgressJoinSQL+`
+		where o.merchant_id = $1
+		  and o.delivery_driver_user_id = $2
+		  and o.order_type = 'DELIVERY'
+		  and `+statusFilter+`
+		order by `+orderBy+`
+		limit $4
+	`, *authCtx.MerchantID, authCtx.UserID, statuses, limit)
+	if err != nil {
+		h.Logger.Error("driver orders query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch deliveries")
+		return
+	}
+	defer rows.Close()
+
+	orders := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			orderID        int64
+			orderNumber    string
+			status         string
+			deliveryStatus pgtype.Text
+			assignedAt     pgtype.Timestamptz
+			address        pgtype.Text
+			unit           pgtype.Text
+			instructions   pgtype.Text
+			latitude       pgtype.Numeric
+			longitude      pgtype.Numeric
+			distanceKm     pgtype.Numeric
+			totalAmount    pgtype.Numeric
+			placedAt       time.Time
+			updatedAt      time.Time
+			customerName   pgtype.Text
+			customerPhone  pgtype.Text
+			paymentMethod  pgtype.Text
+			paymentStatus  pgtype.Text
+			batchID        pgtype.Int8
+			routePosition  pgtype.Int4
+		)
+		if err := rows.Scan(
+			&orderID, &orderNumber, &status, &deliveryStatus, &assignedAt,
+			&address, &unit, &instructions,
+			&latitude, &longitude, &distanceKm,
+			&totalAmount, &placedAt, &updatedAt,
+			&customerName, &customerPhone,
+			&paymentMethod, &paymentStatus,
+			&batchID, &routePosition,
+		); err != nil {
+			h.Logger.Error("driver orders scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch deliveries")
+			return
+		}
+
+		orders = append(orders, map[string]any{
+			"id":                   fmt.Sprint(orderID),
+			"orderNumber":          orderNumber,
+			"status":               status,
+			"deliveryStatus":       nullableText(deliveryStatus),
+			"deliveryAssignedAt":   nullableTime(assignedAt),
+			"deliveryAddress":      nullableText(address),
+			"deliveryUnit":         nullableText(unit),
+			"deliveryInstructions": nullableText(instructions),
+			"deliveryLatitude":     nullableNumeric(latitude),
+			"deliveryLongitude":    nullableNumeric(longitude),
+			"deliveryDistanceKm":   nullableNumeric(distanceKm),
+			"totalAmount":          utils.NumericToFloat64(totalAmount),
+			"placedAt":             placedAt,
+			"updatedAt":            updatedAt,
+			"customerName":         nullableText(customerName),
+			"customerPhone":        nullableText(customerPhone),
+			"paymentMethod":        nullableText(paymentMethod),
+			"paymentStatus":        nullableText(paymentStatus),
+			"isCashOnDelivery":     paymentMethod.Valid && paymentMethod.String == "CASH_ON_DELIVERY",
+			"batchId":              nullableInt64(batchID),
+			"routePosition":        nullIfEmptyInt32(routePosition),
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    orders,
+		"count":   len(orders),
+	})
+}
+
+func (h *Handler) DriverOrderDetail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	if _, err := h.loadDriverOrder(ctx, *authCtx.MerchantID, authCtx.UserID, orderID); err != nil {
+		h.writeDriverOrderError(w, err)
+		return
+	}
+
+	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (h *Handler) DriverOrderDeliveryStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	var body driverDeliveryStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	next := strings.ToUpper(strings.TrimSpace(body.Status))
+	if _, known := driverDeliveryTransitions[next]; !known || next == "ASSIGNED" {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "status must be one of PICKED_UP, ARRIVED, DELIVERED, FAILED")
+		return
+	}
+
+	var reason *string
+	if body.Reason != nil {
+		trimmed := strings.TrimSpace(*body.Reason)
+		if len(trimmed) > 500 {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Reason is too long")
+			return
+		}
+		if trimmed != "" {
+			reason = &trimmed
+		}
+	}
+	if next == "FAILED" && reason == nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "A reason is required when a delivery fails")
+		return
+	}
+	if (body.Latitude == nil) != (body.Longitude == nil) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "latitude and longitude must be provided together")
+		return
+	}
+
+	ref, err := h.loadDriverOrder(ctx, *authCtx.MerchantID, authCtx.UserID, orderID)
+	if err != nil {
+		h.writeDriverOrderError(w, err)
+		return
+	}
+
+	if err := checkDriverDeliveryUpdate(ref.Status, ref.DeliveryStatus, next); err != nil {
+		response.Error(w, http.StatusConflict, "INVALID_STATE", err.Error())
+		return
+	}
+
+	now := time.Now()
+	batchSiblings, err := h.applyDriverDeliveryStatus(ctx, *authCtx.MerchantID, authCtx.UserID, orderID, next, reason, body.Latitude, body.Longitude, now)
+	if err != nil {
+		var conflict *driverDeliveryConflictError
+		switch {
+		case errors.As(err, &conflict):
+			response.Error(w, http.StatusConflict, "INVALID_STATE", conflict.Message)
+		case errors.Is(err, errDriverOrderNotFound):
+			h.writeDriverOrderError(w, err)
+		default:
+			h.Logger.Error("driver delivery status update failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update delivery status")
+		}
+		return
+	}
+
+	h.notifyOrderRealtime(ctx, *authCtx.MerchantID, ref.OrderNumber)
+	for _, orderNumber := range batchSiblings {
+		h.notifyOrderRealtime(ctx, *authCtx.MerchantID, orderNumber)
+	}
+	if h.Queue != nil {
+		event := map[string]any{
+			"type":           "order.delivery.updated",
+			"orderId":        orderID,
+			"merchantId":     *authCtx.MerchantID,
+			"deliveryStatus": next,
+			"reason":         reason,
+			"userId":         authCtx.UserID,
+			"updatedAt":      now.UTC(),
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.delivery.updated", event)
+	}
+
+	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update delivery status")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"message": "Delivery status updated",
+		"data":    data,
+	})
+}
+
+func (h *Handler) DriverOrderProofUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	ref, err := h.loadDriverOrder(ctx, *authCtx.MerchantID, authCtx.UserID, orderID)
+	if err != nil {
+		h.writeDriverOrderError(w, err)
+		return
+	}
+	switch ref.DeliveryStatus {
+	case "PICKED_UP", "ARRIVED", "DELIVERED":
+	default:
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "Proof of delivery can only be uploaded after pickup")
+		return
+	}
+
+	data, _, _, ferr := readFileBytes(r, "file", true, h.Config.MaxFileSizeBytes)
+	if ferr != nil {
+		switch ferr.Kind {
+		case fileReadErrMissing:
+			response.Error(w, http.StatusBadRequest, "FILE_REQUIRED", "File is required")
+		case fileReadErrTooLarge, fileReadErrInvalidType:
+			response.Error(w, http.StatusBadRequest, "INVALID_FILE", ferr.Message)
+		default:
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload proof of delivery")
+		}
+		return
+	}
+
+	jpegBytes, sourceMeta, err := utils.EncodeJpegFitInside(data, maxSideProof, 85)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload proof of delivery")
+		return
+	}
+
+	store, err := h.makeStore(r)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload proof of delivery")
+		return
+	}
+
+	prefix := fmt.Sprintf("merchants/%s/orders/%s/delivery-proof-", ref.MerchantCode, ref.OrderNumber)
+	key := fmt.Sprintf("%s%d-%s.jpg", prefix, time.Now().UnixMilli(), randomSuffix8())
+	url, err := store.PutObject(ctx, key, jpegBytes, "image/jpeg", "public, max-age=31536000, immutable")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload proof of delivery")
+		return
+	}
+
+	metaJSON, _ := json.Marshal(imageMetaPayload{Format: "jpeg", Source: sourceMeta})
+	if _, err := h.DB.Exec(ctx, `
+		insert into order_delivery_progress (order_id, merchant_id, status, proof_url, proof_meta, updated_at)
+		values ($3, $4, $5, $1, $2, now())
+		on conflict (order_id) do update set
+			proof_url = excluded.proof_url,
+			proof_meta = excluded.proof_meta,
+			updated_at = excluded.updated_at
+	`, url, metaJSON, orderID, *authCtx.MerchantID, ref.DeliveryStatus); err != nil {
+		h.Logger.Error("driver proof update failed", zapError(err))
+		_ = store.DeleteKey(ctx, key)
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload proof of delivery")
+		return
+	}
+
+	// Best-effort cleanup of earlier proofs for the same order, only once the
+	// new one is stored and recorded so a failed upload keeps the old proof.
+	if keys, err := store.ListKeys(ctx, prefix); err == nil {
+		for _, old := range keys {
+			if old != key {
+				_ = store.DeleteKey(ctx, old)
+			}
+		}
+	}
+
+	h.notifyOrderRealtime(ctx, *authCtx.MerchantID, ref.OrderNumber)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"proofUrl":  url,
+			"proofMeta": json.RawMessage(metaJSON),
+		},
+		"message":    "Proof of delivery uploaded",
+		"statusCode": 200,
+	})
+}
+
+// DriverOrderCashOnDeliveryConfirm lets the assigned driver record COD cash
+// collection. It shares the payment flow with MerchantOrderCashOnDeliveryConfirm.
+func (h *Handler) DriverOrderCashOnDeliveryConfirm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	ref, err := h.loadDriverOrder(ctx, *authCtx.MerchantID, authCtx.UserID, orderID)
+	if err != nil {
+		h.writeDriverOrderError(w, err)
+		return
+	}
+	if ref.DeliveryStatus == "ASSIGNED" || ref.DeliveryStatus == "FAILED" {
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "Cash can only be collected after pickup")
+		return
+	}
+
+	h.MerchantOrderCashOnDeliveryConfirm(w, r)
+}
+
+func (h *Handler) loadDriverOrder(ctx context.Context, merchantID, driverUserID, orderID int64) (driverOrderRef, error) {
+	var (
+		ref            driverOrderRef
+		orderType      string
+		deliveryStatus pgtype.Text
+		assignedDriver pgtype.Int8
+	)
+	err := h.DB.QueryRow(ctx, `
+		select o.id, o.order_number, m.code, o.status, o.order_type, `+EffectiveDeliveryStatusSQL+`, o.delivery_driver_user_id
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		`+DeliveryProgressJoinSQL+`
+		where o.id = $1 and o.merchant_id = $2
+	`, orderID, merchantID).Scan(&ref.OrderID, &ref.OrderNumber, &ref.MerchantCode, &ref.Status, &orderType, &deliveryStatus, &assignedDriver)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ref, errDriverOrderNotFound
+		}
+		return ref, err
+	}
+	if orderType != "DELIVERY" || !assignedDriver.Valid || assignedDriver.Int64 != driverUserID {
+		return ref, errDriverOrderNotFound
+	}
+	ref.DeliveryStatus = deliveryStatus.String
+	return ref, nil
+}
+
+func (h *Handler) writeDriverOrderError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errDriverOrderNotFound) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Delivery order not found")
+		return
+	}
+	h.Logger.Error("driver order lookup failed", zapError(err))
+	response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load delivery order")
+}
+
+func (h *Handler) applyDriverDeliveryStatus(ctx context.Context, merchantID, driverUserID, orderID int64, next string, reason *string, latitude, longitude *float64, now time.Time) ([]string, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// The handler's check ran on an unlocked read; repeat it under the row
+	// lock so concurrent updates (a second tap, a reassignment, a merchant
+	// cancelling) cannot slip an invalid transition through.
+	var (
+		orderStatus    string
+		current        string
+		assignedDriver pgtype.Int8
+	)
+	if err := tx.QueryRow(ctx, `
+		select o.status, coalesce(`+EffectiveDeliveryStatusSQL+`, ''), o.delivery_driver_user_id
+		from orders o
+		`+DeliveryProgressJoinSQL+`
+		where o.id = $1 and o.merchant_id = $2 and o.order_type = 'DELIVERY'
+		for update of o
+	`, orderID, merchantID).Scan(&orderStatus, &current, &assignedDriver); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errDriverOrderNotFound
+		}
+		return nil, err
+	}
+	if !assignedDriver.Valid || assignedDriver.Int64 != driverUserID {
+		return nil, errDriverOrderNotFound
+	}
+	if err := checkDriverDeliveryUpdate(orderStatus, current, next); err != nil {
+		return nil, err
+	}
+
+	if err := recordDeliveryStatus(ctx, tx, merchantID, &driverUserID, orderID, next, reason, latitude, longitude, now); err != nil {
+		return nil, err
+	}
+
+	if latitude != nil && longitude != nil {
+		if _, err := tx.Exec(ctx, `
+			update driver_availability
+			set latitude = $3, longitude = $4, location_updated_at = now(), updated_at = now()
+			where merchant_id = $1 and user_id = $2
+		`, merchantID, driverUserID, latitude, longitude); err != nil {
+			return nil, err
+		}
+	}
+
+	// A driver marked BUSY by dispatch goes back on shift after the last
+	// active delivery is closed, and a finished batch is closed with it.
+	var batchSiblings []string
+	if next == "DELIVERED" || next == "FAILED" {
+		if _, err := tx.Exec(ctx, `
+			update driver_availability
+			set status = 'ON_SHIFT', updated_at = now()
+			where merchant_id = $1 and user_id = $2 and status = 'BUSY'
+			  and not exists (
+					select 1 from orders o
+					`+DeliveryProgressJoinSQL+`
+					where o.merchant_id = $1 and o.delivery_driver_user_id = $2
+					  and `+EffectiveDeliveryStatusSQL+` in ('ASSIGNED', 'PICKED_UP', 'ARRIVED')
+			  )
+		`, merchantID, driverUserID); err != nil {
+			return nil, err
+		}
+		batchSiblings, err = completeDeliveryBatchForOrder(ctx, tx, orderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return batchSiblings, nil
+}
+
+// recordDeliveryStatus stamps the order with a new delivery status and logs
+// it to order_delivery_events. ASSIGNED and DELIVERED are written to the core
+// orders.delivery_status; the driver progress states only touch
+// order_delivery_progress. driverUserID is nil for courier deliveries.
+func recordDeliveryStatus(ctx context.Context, tx pgx.Tx, merchantID int64, driverUserID *int64, orderID int64, next string, reason *string, latitude, longitude *float64, now time.Time) error {
+	if _, err := tx.Exec(ctx, `
+		update orders
+		set delivery_status = case when $1::text in ('ASSIGNED', 'DELIVERED') then $1::text::"DeliveryStatus" else delivery_status end,
+			delivery_delivered_at = case when $1::text = 'DELIVERED' then $2 else delivery_delivered_at end,
+			updated_at = $2
+		where id = $3 and merchant_id = $4
+	`, next, now, orderID, merchantID); err != nil {
+		return err
+	}
+
+	if next == "ASSIGNED" {
+		if err := resetDeliveryProgress(ctx, tx, orderID); err != nil {
+			return err
+		}
+	} else if _, err := tx.Exec(ctx, `
+		insert into order_delivery_progress (order_id, merchant_id, status, picked_up_at, arrived_at, failed_at, failure_reason, updated_at)
+		values (
+			$1, $2, $3::text,
+			case when $3::text = 'PICKED_UP' then $4::timestamp end,
+			case when $3::text = 'ARRIVED' then $4::timestamp end,
+			case when $3::text = 'FAILED' then $4::timestamp end,
+			case when $3::text = 'FAILED' then $5::text end,
+			$4
+		)
+		on conflict (order_id) do update set
+			status = excluded.status,
+			picked_up_at = coalesce(excluded.picked_up_at, order_delivery_progress.picked_up_at),
+			arrived_at = coalesce(excluded.arrived_at, order_delivery_progress.arrived_at),
+			failed_at = coalesce(excluded.failed_at, order_delivery_progress.failed_at),
+			failure_reason = coalesce(excluded.failure_reason, order_delivery_progress.failure_reason),
+			updated_at = excluded.updated_at
+	`, orderID, merchantID, next, now, reason); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `
+		insert into order_delivery_events (order_id, merchant_id, driver_user_id, delivery_status, reason, latitude, longitude, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, orderID, merchantID, driverUserID, next, reason, latitude, longitude, now)
+	return err
+}
+
+// resetDeliveryProgress drops the driver progress of an order whose driver,
+// courier or assignment changed, so it starts again from ASSIGNED.
+func resetDeliveryProgress(ctx context.Context, tx pgx.Tx, orderID int64) error {
+	_, err := tx.Exec(ctx, `delete from order_delivery_progress where order_id = $1`, orderID)
+	return err
+}
+
+func isValidDriverDeliveryTransition(current, next string) bool {
+	for _, s := range driverDeliveryTransitions[current] {
+		if s == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsValidDriverDeliveryTransition(t *testing.T) {
+	cases := []struct {
+		current, next string
+		want          bool
+	}{
+		{"ASSIGNED", "PICKED_UP", true},
+		{"ASSIGNED", "FAILED", true},
+		{"ASSIGNED", "ARRIVED", false},
+		{"ASSIGNED", "DELIVERED", false},
+		{"ASSIGNED", "ASSIGNED", false},
+		{"PICKED_UP", "ARRIVED", true},
+		{"PICKED_UP", "DELIVERED", true},
+		{"PICKED_UP", "FAILED", true},
+		{"PICKED_UP", "PICKED_UP", false},
+		{"PICKED_UP", "ASSIGNED", false},
+		{"ARRIVED", "DELIVERED", true},
+		{"ARRIVED", "FAILED", true},
+		{"ARRIVED", "PICKED_UP", false},
+		{"DELIVERED", "FAILED", false},
+		{"DELIVERED", "DELIVERED", false},
+		{"FAILED", "PICKED_UP", false},
+		{"FAILED", "DELIVERED", false},
+		{"PENDING_ASSIGNMENT", "PICKED_UP", false},
+		{"", "PICKED_UP", false},
+		{"ASSIGNED", "", false},
+		{"assigned", "PICKED_UP", false},
+	}
+	for _, tc := range cases {
+		if got := isValidDriverDeliveryTransition(tc.current, tc.next); got != tc.want {
+			t.Errorf("%q -> %q: expected %v, got %v", tc.current, tc.next, tc.want, got)
+		}
+	}
+}
+
+func TestCheckDriverDeliveryUpdate(t *testing.T) {
+	cases := []struct {
+		orderStatus, current, next string
+		wantErr                    bool
+	}{
+		{"READY", "ASSIGNED", "PICKED_UP", false},
+		{"READY", "PICKED_UP", "ARRIVED", false},
+		{"READY", "ARRIVED", "DELIVERED", false},
+		{"READY", "ASSIGNED", "FAILED", false},
+		{"COMPLETED", "ARRIVED", "DELIVERED", true},
+		{"CANCELLED", "ASSIGNED", "PICKED_UP", true},
+		{"PENDING", "ASSIGNED", "PICKED_UP", true},
+		{"ACCEPTED", "ASSIGNED", "PICKED_UP", true},
+		{"IN_PROGRESS", "ASSIGNED", "PICKED_UP", true},
+		{"IN_PROGRESS", "ASSIGNED", "FAILED", true},
+		// A second tap after the first one committed.
+		{"READY", "PICKED_UP", "PICKED_UP", true},
+		// Reassigned to courier or unassigned in the meantime.
+		{"READY", "PENDING_ASSIGNMENT", "DELIVERED", true},
+	}
+	for _, tc := range cases {
+		err := checkDriverDeliveryUpdate(tc.orderStatus, tc.current, tc.next)
+		var conflict *driverDeliveryConflictError
+		if tc.wantErr != (err != nil) || (err != nil && !errors.As(err, &conflict)) {
+			t.Errorf("%s %s -> %s: unexpected error %v", tc.orderStatus, tc.current, tc.next, err)
+		}
+	}
+}
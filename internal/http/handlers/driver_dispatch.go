@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"genfity-order-services/internal/auth"
+	"genfity-order-services/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	dispatchStrategyNearest    = "NEAREST"
+	dispatchStrategyRoundRobin = "ROUND_ROBIN"
+
+	// A driver location older than this is not trusted for nearest matching.
+	dispatchLocationMaxAge = 15 * time.Minute
+	dispatchSweepInterval  = 10 * time.Second
+	// A driver whose offer for an order expired, was declined or was withdrawn
+	// can be offered the same order again after this long.
+	dispatchReofferCooldown = 5 * time.Minute
+
+	defaultDispatchOfferTimeoutSeconds = 60
+	minDispatchOfferTimeoutSeconds     = 15
+	maxDispatchOfferTimeoutSeconds     = 600
+)
+
+type dispatchSettings struct {
+	Enabled             bool
+	OfferTimeoutSeconds int
+}
+
+type dispatchCandidate struct {
+	UserID            int64
+	Latitude          *float64
+	Longitude         *float64
+	LocationUpdatedAt *time.Time
+	LastOfferedAt     *time.Time
+}
+
+type dispatchChoice struct {
+	UserID     int64
+	Strategy   string
+	DistanceKm *float64
+}
+
+func parseDispatchSettings(features []byte) dispatchSettings {
+	settings := dispatchSettings{OfferTimeoutSeconds: defaultDispatchOfferTimeoutSeconds}
+	obj := parseJSONMap(features)
+	dispatch, _ := obj["autoDispatch"].(map[string]any)
+	if dispatch == nil {
+		return settings
+	}
+	if enabled, ok := dispatch["enabled"].(bool); ok {
+		settings.Enabled = enabled
+	}
+	if timeout, ok := parseFloatFromAny(dispatch["offerTimeoutSeconds"]); ok {
+		settings.OfferTimeoutSeconds = clampDispatchOfferTimeout(int(timeout))
+	}
+	return settings
+}
+
+func clampDispatchOfferTimeout(seconds int) int {
+	if seconds < minDispatchOfferTimeoutSeconds {
+		return minDispatchOfferTimeoutSeconds
+	}
+	if seconds > maxDispatchOfferTimeoutSeconds {
+		return maxDispatchOfferTimeoutSeconds
+	}
+	return seconds
+}
+
+// pickDispatchCandidate prefers the driver closest to the pickup point among
+// those with a recent location. When the merchant has no coordinates or no
+// driver has reported a fresh location, it falls back to round-robin by the
+// time each driver was last offered an order.
+func pickDispatchCandidate(candidates []dispatchCandidate, originLat, originLng *float64, now time.Time) (dispatchChoice, bool) {
+	if len(candidates) == 0 {
+		return dispatchChoice{}, false
+	}
+
+	if originLat != nil && originLng != nil {
+		bestIdx := -1
+		bestDistance := math.MaxFloat64
+		for i, c := range candidates {
+			if c.Latitude == nil || c.Longitude == nil || c.LocationUpdatedAt == nil {
+				continue
+			}
+			if now.Sub(*c.LocationUpdatedAt) > dispatchLocationMaxAge {
+				continue
+			}
+			d := haversineDistanceKm(*originLat, *originLng, *c.Latitude, *c.Longitude)
+			if bestIdx < 0 || d < bestDistance || (d == bestDistance && c.UserID < candidates[bestIdx].UserID) {
+				bestIdx = i
+				bestDistance = d
+			}
+		}
+		if bestIdx >= 0 {
+			distance := deliveryRound3(bestDistance)
+			return dispatchChoice{UserID: candidates[bestIdx].UserID, Strategy: dispatchStrategyNearest, DistanceKm: &distance}, true
+		}
+	}
+
+	ordered := make([]dispatchCandidate, len(candidates))
+	copy(ordered, candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].LastOfferedAt, ordered[j].LastOfferedAt
+		if a == nil || b == nil {
+			if a == nil && b == nil {
+				return ordered[i].UserID < ordered[j].UserID
+			}
+			return a == nil
+		}
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return ordered[i].UserID < ordered[j].UserID
+	})
+	return dispatchChoice{UserID: ordered[0].UserID, Strategy: dispatchStrategyRoundRobin}, true
+}
+
+// autoDispatchOrder books a courier for READY delivery orders whose zone is
+// routed to one, and otherwise offers the order to the next driver when the
+// merchant has auto-dispatch enabled. It is safe to call repeatedly: orders
+// that already have a driver, a courier or an open offer are left alone.
+func (h *Handler) autoDispatchOrder(ctx context.Context, merchantID, orderID int64) {
+	courierRouted, err := h.autoBookCourier(ctx, merchantID, orderID)
+	if err != nil {
+		h.Logger.Warn("courier auto booking failed", zapError(err))
+	}
+	if courierRouted {
+		return
+	}
+
+	offered, err := h.dispatchNextDriver(ctx, merchantID, orderID)
+	if err != nil {
+		h.Logger.Warn("auto dispatch failed", zapError(err))
+		return
+	}
+	if offered != "" {
+		h.notifyOrderRealtime(ctx, merchantID, offered)
+	}
+}
+
+// dispatchNextDriver creates a dispatch offer and returns the order number
+// when one was made. When no driver can be offered the order, the merchant is
+// told once per round of offers with a dispatch.exhausted event; drivers who
+// already had the order become eligible again after dispatchReofferCooldown.
+func (h *Handler) dispatchNextDriver(ctx context.Context, merchantID, orderID int64) (string, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		orderNumber  string
+		orderType    string
+		status       string
+		driverUserID pgtype.Int8
+		features     []byte
+		merchantLat  pgtype.Numeric
+		merchantLng  pgtype.Numeric
+	)
+	err = tx.QueryRow(ctx, `
+		select o.order_number, o.order_type, o.status, o.delivery_driver_user_id, m.features, m.latitude, m.longitude
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		where o.id = $1 and o.merchant_id = $2
+		for update of o
+	`, orderID, merchantID).Scan(&orderNumber, &orderType, &status, &driverUserID, &features, &merchantLat, &merchantLng)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	settings := parseDispatchSettings(features)
+	if !settings.Enabled || orderType != "DELIVERY" || status != "READY" || driverUserID.Valid {
+		return "", nil
+	}
+
+	// Batched orders are assigned together with their batch, not one by one.
+	var hasOpenOffer, inBatch, hasCourier bool
+	if err := tx.QueryRow(ctx, `
+		select
+			exists (select 1 from order_dispatch_attempts where order_id = $1 and status = 'OFFERED'),
+			exists (select 1 from delivery_batch_stops where order_id = $1),
+			exists (select 1 from courier_deliveries where order_id = $1 and status = any($2))
+	`, orderID, courierActiveStatuses).Scan(&hasOpenOffer, &inBatch, &hasCourier); err != nil {
+		return "", err
+	}
+	if hasOpenOffer || inBatch || hasCourier {
+		return "", nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		select da.user_id, da.latitude, da.longitude, da.location_updated_at, da.last_offered_at
+		from driver_availability da
+		join merchant_users mu on mu.merchant_id = da.merchant_id and mu.user_id = da.user_id
+		join users u on u.id = da.user_id
+		where da.merchant_id = $1
+		  and da.status = 'ON_SHIFT'
+		  and mu.is_active = true
+		  and u.is_active = true
+		  and (
+				mu.role in ('OWNER', 'DRIVER')
+				or (mu.role = 'STAFF' and mu.invitation_status = 'ACCEPTED' and $3 = any(mu.permissions))
+		  )
+		  and not exists (
+				select 1 from order_dispatch_attempts a
+				where a.order_id = $2 and a.driver_user_id = da.user_id
+				  and (
+						a.status = 'OFFERED'
+						or coalesce(a.responded_at, a.expires_at) > now()::timestamp - $4::int * interval '1 second'
+				  )
+		  )
+		  and not exists (
+				select 1 from order_dispatch_attempts a
+				where a.driver_user_id = da.user_id and a.merchant_id = da.merchant_id and a.status = 'OFFERED'
+		  )
+	`, merchantID, orderID, string(auth.PermDriverDashboard), int(dispatchReofferCooldown/time.Second))
+	if err != nil {
+		return "", err
+	}
+	candidates := make([]dispatchCandidate, 0)
+	for rows.Next() {
+		var (
+			userID            int64
+			latitude          pgtype.Numeric
+			longitude         pgtype.Numeric
+			locationUpdatedAt pgtype.Timestamptz
+			lastOfferedAt     pgtype.Timestamptz
+		)
+		if err := rows.Scan(&userID, &latitude, &longitude, &locationUpdatedAt, &lastOfferedAt); err != nil {
+			rows.Close()
+			return "", err
+		}
+		c := dispatchCandidate{UserID: userID}
+		if latitude.Valid && longitude.Valid {
+			lat := utils.NumericToFloat64(latitude)
+			lng := utils.NumericToFloat64(longitude)
+			c.Latitude, c.Longitude = &lat, &lng
+		}
+		if locationUpdatedAt.Valid {
+			t := locationUpdatedAt.Time
+			c.LocationUpdatedAt = &t
+		}
+		if lastOfferedAt.Valid {
+			t := lastOfferedAt.Time
+			c.LastOfferedAt = &t
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	var originLat, originLng *float64
+	if merchantLat.Valid && merchantLng.Valid {
+		lat := utils.NumericToFloat64(merchantLat)
+		lng := utils.NumericToFloat64(merchantLng)
+		originLat, originLng = &lat, &lng
+	}
+
+	// Timestamps are compared against timestamp(3) columns written with now(),
+	// so keep everything in database time to avoid clock skew between nodes.
+	var now time.Time
+	if err := tx.QueryRow(ctx, `select now()::timestamp`).Scan(&now); err != nil {
+		return "", err
+	}
+
+	choice, ok := pickDispatchCandidate(candidates, originLat, originLng, now)
+	if !ok {
+		var attempts int
+		err := tx.QueryRow(ctx, `
+			insert into order_dispatch_exhaustions (order_id, attempt_count, notified_at)
+			select $1, count(*)::int, now() from order_dispatch_attempts where order_id = $1
+			on conflict (order_id) do update
+			set attempt_count = excluded.attempt_count, notified_at = excluded.notified_at
+			where order_dispatch_exhaustions.attempt_count <> excluded.attempt_count
+			returning attempt_count
+		`, orderID).Scan(&attempts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return "", err
+		}
+		h.notifyDispatchExhausted(ctx, merchantID, orderID, orderNumber, attempts)
+		return "", nil
+	}
+
+	expiresAt := now.Add(time.Duration(settings.OfferTimeoutSeconds) * time.Second)
+	if _, err := tx.Exec(ctx, `
+		insert into order_dispatch_attempts (order_id, merchant_id, driver_user_id, strategy, distance_km, status, offered_at, expires_at)
+		values ($1, $2, $3, $4, $5, 'OFFERED', $6, $7)
+	`, orderID, merchantID, choice.UserID, choice.Strategy, choice.DistanceKm, now, expiresAt); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `
+		update driver_availability set last_offered_at = $3, updated_at = $3
+		where merchant_id = $1 and user_id = $2
+	`, merchantID, choice.UserID, now); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return orderNumber, nil
+}
+
+// notifyDispatchExhausted tells the merchant that no driver is left to offer
+// the order to, so they can assign one manually or book a courier.
+func (h *Handler) notifyDispatchExhausted(ctx context.Context, merchantID, orderID int64, orderNumber string, attempts int) {
+	data := map[string]any{
+		"orderId":           fmt.Sprint(orderID),
+		"orderNumber":       orderNumber,
+		"attempts":          attempts,
+		"retryAfterSeconds": int(dispatchReofferCooldown / time.Second),
+	}
+	h.notifyMerchantOrderEvent(ctx, merchantID, "dispatch.exhausted", data)
+	if h.Queue != nil {
+		event := map[string]any{
+			"type":        "order.dispatch.exhausted",
+			"orderId":     orderID,
+			"merchantId":  merchantID,
+			"orderNumber": orderNumber,
+			"attempts":    attempts,
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.dispatch.exhausted", event)
+	}
+}
+
+// cancelOpenDispatchOffers withdraws pending offers once a merchant takes over
+// the assignment manually.
+func (h *Handler) cancelOpenDispatchOffers(ctx context.Context, orderID int64, reason string) {
+	if _, err := h.DB.Exec(ctx, `
+		update order_dispatch_attempts
+		set status = 'CANCELLED', reason = $2, responded_at = now()
+		where order_id = $1 and status = 'OFFERED'
+	`, orderID, reason); err != nil {
+		h.Logger.Warn("dispatch offer cancel failed", zapError(err))
+	}
+}
+
+// RunDispatchSweeper expires unanswered offers and re-offers READY delivery
+// orders that are still waiting for a driver or courier. It blocks until ctx
+// is done.
+func (h *Handler) RunDispatchSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = dispatchSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepDispatch(ctx)
+		}
+	}
+}
+
+func (h *Handler) sweepDispatch(ctx context.Context) {
+	if _, err := h.DB.Exec(ctx, `
+		update order_dispatch_attempts
+		set status = 'EXPIRED', responded_at = now()
+		where id in (
+			select id from order_dispatch_attempts
+			where status = 'OFFERED' and expires_at <= now()
+			for update skip locked
+		)
+	`); err != nil {
+		h.Logger.Warn("dispatch expiry sweep failed", zapError(err))
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select o.id, o.merchant_id, m.features
+		from orders o
+		join merchants m on m.id = o.merchant_id
+		where o.order_type = 'DELIVERY'
+		  and o.status = 'READY'
+		  and o.delivery_driver_user_id is null
+		  and not exists (
+				select 1 from order_dispatch_attempts a
+				where a.order_id = o.id and a.status = 'OFFERED'
+		  )
+		  and not exists (select 1 from delivery_batch_stops s where s.order_id = o.id)
+		  and not exists (
+				select 1 from courier_deliveries cd
+				where cd.order_id = o.id and cd.status = any($1)
+		  )
+		order by o.actual_ready_at asc nulls last, o.id asc
+		limit 200
+	`, courierActiveStatuses)
+	if err != nil {
+		h.Logger.Warn("dispatch pending sweep failed", zapError(err))
+		return
+	}
+
+	type pendingOrder struct{ orderID, merchantID int64 }
+	pending := make([]pendingOrder, 0)
+	for rows.Next() {
+		var (
+			orderID    int64
+			merchantID int64
+			features   []byte
+		)
+		if err := rows.Scan(&orderID, &merchantID, &features); err != nil {
+			rows.Close()
+			h.Logger.Warn("dispatch pending scan failed", zapError(err))
+			return
+		}
+		if parseDispatchSettings(features).Enabled || parseCourierSettings(features).AutoBook {
+			pending = append(pending, pendingOrder{orderID: orderID, merchantID: merchantID})
+		}
+	}
+	rows.Close()
+
+	for _, p := range pending {
+		h.autoDispatchOrder(ctx, p.merchantID, p.orderID)
+	}
+}
+
+func dispatchOfferPayload(attemptID, orderID int64, strategy string, distanceKm pgtype.Numeric, status string, reason pgtype.Text, offeredAt, expiresAt time.Time, respondedAt pgtype.Timestamptz) map[string]any {
+	return map[string]any{
+		"id":          fmt.Sprint(attemptID),
+		"orderId":     fmt.Sprint(orderID),
+		"strategy":    strategy,
+		"distanceKm":  nullableNumeric(distanceKm),
+		"status":      status,
+		"reason":      nullableText(reason),
+		"offeredAt":   offeredAt,
+		"expiresAt":   expiresAt,
+		"respondedAt": nullableTime(respondedAt),
+	}
+}
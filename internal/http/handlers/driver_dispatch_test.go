@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPickDispatchCandidate(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	fresh := now.Add(-2 * time.Minute)
+	stale := now.Add(-2 * time.Hour)
+	earlier := now.Add(-30 * time.Minute)
+	later := now.Add(-5 * time.Minute)
+	f := func(v float64) *float64 { return &v }
+
+	originLat, originLng := f(-6.2000), f(106.8166)
+
+	cases := []struct {
+		name       string
+		candidates []dispatchCandidate
+		originLat  *float64
+		originLng  *float64
+		expectedID int64
+		strategy   string
+	}{
+		{
+			name: "nearest with fresh location wins",
+			candidates: []dispatchCandidate{
+				{UserID: 1, Latitude: f(-6.3000), Longitude: f(106.9000), LocationUpdatedAt: &fresh},
+				{UserID: 2, Latitude: f(-6.2010), Longitude: f(106.8170), LocationUpdatedAt: &fresh},
+			},
+			originLat:  originLat,
+			originLng:  originLng,
+			expectedID: 2,
+			strategy:   dispatchStrategyNearest,
+		},
+		{
+			name: "stale location is ignored",
+			candidates: []dispatchCandidate{
+				{UserID: 1, Latitude: f(-6.2001), Longitude: f(106.8166), LocationUpdatedAt: &stale},
+				{UserID: 2, Latitude: f(-6.2500), Longitude: f(106.8500), LocationUpdatedAt: &fresh},
+			},
+			originLat:  originLat,
+			originLng:  originLng,
+			expectedID: 2,
+			strategy:   dispatchStrategyNearest,
+		},
+		{
+			name: "round robin when no location is usable",
+			candidates: []dispatchCandidate{
+				{UserID: 1, LastOfferedAt: &later},
+				{UserID: 2, LastOfferedAt: &earlier},
+			},
+			originLat:  originLat,
+			originLng:  originLng,
+			expectedID: 2,
+			strategy:   dispatchStrategyRoundRobin,
+		},
+		{
+			name: "round robin prefers drivers never offered",
+			candidates: []dispatchCandidate{
+				{UserID: 1, LastOfferedAt: &earlier},
+				{UserID: 3},
+			},
+			expectedID: 3,
+			strategy:   dispatchStrategyRoundRobin,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			choice, ok := pickDispatchCandidate(tc.candidates, tc.originLat, tc.originLng, now)
+			if !ok {
+				t.Fatalf("expected a candidate")
+			}
+			if choice.UserID != tc.expectedID || choice.Strategy != tc.strategy {
+				t.Fatalf("expected %d/%s, got %d/%s", tc.expectedID, tc.strategy, choice.UserID, choice.Strategy)
+			}
+		})
+	}
+
+	if _, ok := pickDispatchCandidate(nil, originLat, originLng, now); ok {
+		t.Fatalf("expected no candidate for empty list")
+	}
+}
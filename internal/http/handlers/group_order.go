@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -367,13 +368,15 @@ type groupOrderSubmitRequest struct {
 }
 
 type groupOrderCartItem struct {
-	CartItemID string                `json:"cartItemId"`
-	MenuID     string                `json:"menuId"`
-	MenuName   string                `json:"menuName"`
-	Price      float64               `json:"price"`
-	Quantity   int32                 `json:"quantity"`
-	Addons     []groupOrderCartAddon `json:"addons"`
-	Notes      string                `json:"notes"`
+	CartItemID string                 `json:"cartItemId"`
+	MenuID     string                 `json:"menuId"`
+	VariantID  string                 `json:"variantId,omitempty"`
+	MenuName   string                 `json:"menuName"`
+	Price      float64                `json:"price"`
+	Quantity   int32                  `json:"quantity"`
+	Addons     []groupOrderCartAddon  `json:"addons"`
+	Components []bundleComponentInput `json:"components,omitempty"`
+	Notes      string                 `json:"notes"`
 }
 
 type groupOrderCartAddon struct {
@@ -384,7 +387,10 @@ type groupOrderCartAddon struct {
 
 type groupOrderOrderItemData struct {
 	MenuID          int64
+	VariantID       *int64
 	MenuName        string
+	VariantName     *string
+	Components      []orderItemComponent
 	MenuPrice       float64
 	Quantity        int32
 	Subtotal        float64
@@ -1170,6 +1176,16 @@ func (h *Handler) PublicGroupOrderSubmit(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	promoMap := h.fetchGroupOrderPromoPrices(ctx, menuIDs, session.MerchantID)
+	variantMap, err := h.fetchMenuVariants(ctx, session.MerchantID, menuIDs, false)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch menu data")
+		return
+	}
+	bundleMap, err := h.fetchMenuBundles(ctx, session.MerchantID, menuIDs)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch menu data")
+		return
+	}
 
 	orderItems := make([]groupOrderOrderItemData, 0)
 	var subtotal float64
@@ -1189,12 +1205,33 @@ func (h *Handler) PublicGroupOrderSubmit(w http.ResponseWriter, r *http.Request)
 				response.Error(w, http.StatusBadRequest, "INSUFFICIENT_STOCK", "Insufficient stock for \""+menu.Name+"\" (ordered by "+participant.Name+")")
 				return
 			}
+			var requestedVariant any
+			if item.VariantID != "" {
+				requestedVariant = item.VariantID
+			}
+			variant, err := resolveMenuVariant(menu.Name, variantMap[menuID], requestedVariant, item.Quantity)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "INVALID_VARIANT", err.Error()+" (ordered by "+participant.Name+")")
+				return
+			}
+			components, upcharge, err := resolveBundleComponents(menu.Name, bundleMap[menuID], item.Components, item.Quantity)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "INVALID_COMPONENTS", err.Error()+" (ordered by "+participant.Name+")")
+				return
+			}
 
 			price := menu.Price
 			if promo, ok := promoMap[menuID]; ok {
 				price = promo
 			}
-			menuPrice := round2(price)
+			var variantID *int64
+			var variantName *string
+			if variant != nil {
+				price = variant.effectivePrice()
+				variantID = &variant.ID
+				variantName = &variant.Name
+			}
+			menuPrice := round2(price + upcharge)
 			itemTotal := round2(menuPrice * float64(item.Quantity))
 
 			addons := make([]groupOrderAddonData, 0)
@@ -1231,7 +1268,10 @@ func (h *Handler) PublicGroupOrderSubmit(w http.ResponseWriter, r *http.Request)
 
 			orderItems = append(orderItems, groupOrderOrderItemData{
 				MenuID:          menuID,
+				VariantID:       variantID,
 				MenuName:        menu.Name,
+				VariantName:     variantName,
+				Components:      components,
 				MenuPrice:       menuPrice,
 				Quantity:        item.Quantity,
 				Subtotal:        itemTotal,
@@ -1280,12 +1320,12 @@ func (h *Handler) PublicGroupOrderSubmit(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		fee, distance, errCode, errMessage := h.calculateGroupOrderDeliveryFee(ctx, deliveryConfig, *body.DeliveryLatitude, *body.DeliveryLongitude)
+		breakdown, distance, errCode, errMessage := h.evaluateMerchantDeliveryFee(ctx, deliveryConfig, *body.DeliveryLatitude, *body.DeliveryLongitude, &subtotal, time.Now())
 		if errCode != "" {
 			response.Error(w, http.StatusBadRequest, errCode, errMessage)
 			return
 		}
-		deliveryFeeAmount = fee
+		deliveryFeeAmount = breakdown.Fee
 		deliveryDistance = &distance
 		deliveryLat = body.DeliveryLatitude
 		deliveryLng = body.DeliveryLongitude
@@ -1312,7 +1352,10 @@ func (h *Handler) PublicGroupOrderSubmit(w http.ResponseWriter, r *http.Request)
 	}
 
 	for _, item := range orderItems {
-		h.decrementPOSStock(ctx, item.MenuID, item.Quantity)
+		h.decrementOrderItemStock(ctx, item.MenuID, item.VariantID, item.Quantity)
+		for _, ref := range bundleComponentRefs(item.Components, item.Quantity) {
+			h.decrementOrderItemStock(ctx, ref.MenuID, ref.VariantID, ref.Quantity)
+		}
 	}
 	if customerID != nil {
 		_, _ = h.DB.Exec(ctx, `
@@ -1415,6 +1458,8 @@ type groupOrderDeliveryConfig struct {
 	DeliveryFeePerKm     pgtype.Numeric
 	DeliveryFeeMin       pgtype.Numeric
 	DeliveryFeeMax       pgtype.Numeric
+	Timezone             string
+	Features             []byte
 }
 
 func (h *Handler) fetchGroupOrderSessionByID(ctx context.Context, sessionID int64) (GroupOrderSession, []GroupOrderParticipant, error) {
@@ -1647,7 +1692,8 @@ func (h *Handler) fetchGroupOrderMerchant(ctx context.Context, merchantID int64)
 		select id, code, name, currency, enable_tax, tax_percentage, enable_service_charge, service_charge_percent,
 		       enable_packaging_fee, packaging_fee_amount,
 		       is_active, is_delivery_enabled, enforce_delivery_zones, latitude, longitude, delivery_max_distance_km,
-		       delivery_fee_base, delivery_fee_per_km, delivery_fee_min, delivery_fee_max
+		       delivery_fee_base, delivery_fee_per_km, delivery_fee_min, delivery_fee_max,
+		       timezone, features
 		from merchants where id = $1
 	`, merchantID).Scan(
 		&merchant.ID,
@@ -1670,6 +1716,8 @@ func (h *Handler) fetchGroupOrderMerchant(ctx context.Context, merchantID int64)
 		&delivery.DeliveryFeePerKm,
 		&delivery.DeliveryFeeMin,
 		&delivery.DeliveryFeeMax,
+		&delivery.Timezone,
+		&delivery.Features,
 	); err != nil {
 		return groupOrderMerchantRow{}, groupOrderDeliveryConfig{}, err
 	}
@@ -1800,6 +1848,12 @@ func (h *Handler) createGroupOrder(ctx context.Context, session GroupOrderSessio
 		`, orderID, item.MenuID, item.MenuName, item.MenuPrice, item.Quantity, item.Subtotal, nullIfEmptyPtr(item.Notes)).Scan(&orderItemID); err != nil {
 			return 0, err
 		}
+		if err := insertOrderItemVariant(ctx, tx, orderItemID, item.VariantID, item.VariantName); err != nil {
+			return 0, err
+		}
+		if err := insertOrderItemComponents(ctx, tx, orderItemID, item.Components); err != nil {
+			return 0, err
+		}
 		if _, err := tx.Exec(ctx, `
 			insert into group_order_details (session_id, participant_id, order_item_id, participant_name, item_subtotal)
 			values ($1,$2,$3,$4,$5)
@@ -1831,15 +1885,19 @@ func (h *Handler) createGroupOrder(ctx context.Context, session GroupOrderSessio
 	return orderID, nil
 }
 
-func (h *Handler) calculateGroupOrderDeliveryFee(ctx context.Context, config groupOrderDeliveryConfig, lat, lng float64) (float64, float64, string, string) {
+// evaluateMerchantDeliveryFee validates the delivery destination and prices
+// it with the merchant's delivery pricing rules. It backs public quotes,
+// public orders and group orders. subtotal may be nil when it is not
+// known yet (quotes); at is evaluated in the merchant timezone.
+func (h *Handler) evaluateMerchantDeliveryFee(ctx context.Context, config groupOrderDeliveryConfig, lat, lng float64, subtotal *float64, at time.Time) (deliveryFeeBreakdown, float64, string, string) {
 	if !config.IsActive {
-		return 0, 0, "MERCHANT_NOT_FOUND", "Merchant not found or inactive"
+		return deliveryFeeBreakdown{}, 0, "MERCHANT_NOT_FOUND", "Merchant not found or inactive"
 	}
 	if !config.IsDeliveryEnabled {
-		return 0, 0, "DELIVERY_NOT_ENABLED", "Delivery is not available for this merchant"
+		return deliveryFeeBreakdown{}, 0, "DELIVERY_NOT_ENABLED", "Delivery is not available for this merchant"
 	}
 	if !config.Latitude.Valid || !config.Longitude.Valid {
-		return 0, 0, "MERCHANT_LOCATION_NOT_SET", "Merchant location is not configured for delivery"
+		return deliveryFeeBreakdown{}, 0, "MERCHANT_LOCATION_NOT_SET", "Merchant location is not configured for delivery"
 	}
 
 	merchantLat := utils.NumericToFloat64(config.Latitude)
@@ -1849,45 +1907,58 @@ func (h *Handler) calculateGroupOrderDeliveryFee(ctx context.Context, config gro
 	if config.DeliveryMaxDistance.Valid {
 		maxDistance := utils.NumericToFloat64(config.DeliveryMaxDistance)
 		if distanceKm > maxDistance {
-			return 0, 0, "OUT_OF_RANGE", "Delivery is only available within " + formatFloat(maxDistance) + " km"
+			return deliveryFeeBreakdown{}, 0, "OUT_OF_RANGE", "Delivery is only available within " + formatFloat(maxDistance) + " km"
 		}
 	}
-	if config.EnforceDeliveryZones {
-		ok, err := h.validateDeliveryZones(ctx, config.MerchantID, merchantLat, merchantLng, lat, lng)
-		if err == errNoZonesConfigured {
-			return 0, 0, "NO_ZONES_CONFIGURED", "Delivery zones are not properly configured"
-		}
-		if err != nil {
-			return 0, 0, "VALIDATION_ERROR", "Delivery validation failed"
-		}
-		if !ok {
-			return 0, 0, "OUT_OF_ZONE", "Delivery is not available for this location"
+
+	rules := parseDeliveryPricingRules(config.Features)
+	var zoneIDs []int64
+	if config.EnforceDeliveryZones || len(rules.ZoneOverrides) > 0 {
+		ids, err := h.matchDeliveryZoneIDs(ctx, config.MerchantID, merchantLat, merchantLng, lat, lng)
+		if config.EnforceDeliveryZones {
+			if errors.Is(err, errNoZonesConfigured) {
+				return deliveryFeeBreakdown{}, 0, "NO_ZONES_CONFIGURED", "Delivery zones are not properly configured"
+			}
+			if err != nil {
+				return deliveryFeeBreakdown{}, 0, "VALIDATION_ERROR", "Delivery validation failed"
+			}
+			if len(ids) == 0 {
+				return deliveryFeeBreakdown{}, 0, "OUT_OF_ZONE", "Delivery is not available for this location"
+			}
 		}
+		zoneIDs = ids
 	}
 
-	feeBase := 0.0
+	input := deliveryPricingInput{
+		DistanceKm: distanceKm,
+		ZoneIDs:    zoneIDs,
+		Subtotal:   subtotal,
+		LocalTime:  at.In(loadTimezone(timezoneOrDefault(config.Timezone))),
+		Rules:      rules,
+	}
 	if config.DeliveryFeeBase.Valid {
-		feeBase = utils.NumericToFloat64(config.DeliveryFeeBase)
+		input.BaseFee = utils.NumericToFloat64(config.DeliveryFeeBase)
 	}
-	feePerKm := 0.0
 	if config.DeliveryFeePerKm.Valid {
-		feePerKm = utils.NumericToFloat64(config.DeliveryFeePerKm)
+		input.PerKmFee = utils.NumericToFloat64(config.DeliveryFeePerKm)
 	}
-	fee := deliveryRound2(feeBase + feePerKm*distanceKm)
 	if config.DeliveryFeeMin.Valid {
 		minFee := utils.NumericToFloat64(config.DeliveryFeeMin)
-		if fee < minFee {
-			fee = minFee
-		}
+		input.MinFee = &minFee
 	}
 	if config.DeliveryFeeMax.Valid {
 		maxFee := utils.NumericToFloat64(config.DeliveryFeeMax)
-		if fee > maxFee {
-			fee = maxFee
-		}
+		input.MaxFee = &maxFee
 	}
 
-	return deliveryRound2(fee), distanceKm, "", ""
+	breakdown, err := evaluateDeliveryPricing(input)
+	if errors.Is(err, errDeliveryBeyondBands) {
+		if maxKm, ok := deliveryBandsMaxKm(rules.Bands); ok {
+			return deliveryFeeBreakdown{}, 0, "OUT_OF_RANGE", "Delivery is only available within " + formatFloat(maxKm) + " km"
+		}
+		return deliveryFeeBreakdown{}, 0, "OUT_OF_RANGE", "Delivery is not available for this distance"
+	}
+	return breakdown, distanceKm, "", ""
 }
 
 func (h *Handler) fetchGroupOrderParticipants(ctx context.Context, sessionID int64) ([]GroupOrderParticipant, error) {
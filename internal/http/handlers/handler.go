@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"genfity-order-services/internal/config"
+	"genfity-order-services/internal/courier"
+	"genfity-order-services/internal/geocode"
 	"genfity-order-services/internal/queue"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -13,4 +15,7 @@ type Handler struct {
 	Logger *zap.Logger
 	Config config.Config
 	Queue  *queue.Client
+
+	Geocoder geocode.Geocoder
+	Couriers *courier.Registry
 }
@@ -0,0 +1,1048 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ingredientMovementOrder      = "ORDER"
+	ingredientMovementRefund     = "REFUND"
+	ingredientMovementAdjustment = "ADJUSTMENT"
+	ingredientMovementWaste      = "WASTE"
+	ingredientMovementPurchase   = "PURCHASE"
+	ingredientMovementStocktake  = "STOCKTAKE"
+
+	ingredientMaxQuantity      = 1000000
+	ingredientMaxStocktakeRows = 500
+)
+
+var ingredientUnits = []string{"g", "kg", "ml", "l", "pcs"}
+
+func round3(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+func normalizeIngredientUnit(raw string) (string, error) {
+	unit := strings.ToLower(strings.TrimSpace(raw))
+	if !containsString(ingredientUnits, unit) {
+		return "", fmt.Errorf("unit must be one of %s", strings.Join(ingredientUnits, ", "))
+	}
+	return unit, nil
+}
+
+type recipeLine struct {
+	IngredientID int64   `json:"ingredientId"`
+	Quantity     float64 `json:"quantity"`
+	IsOptional   bool    `json:"isOptional"`
+}
+
+// recipeBook holds the recipes that apply to an order, keyed by menu,
+// variant and addon item ID.
+type recipeBook struct {
+	menus    map[int64][]recipeLine
+	variants map[int64][]recipeLine
+	addons   map[int64][]recipeLine
+}
+
+// orderRecipeUse is one thing an order consumes: an order item, a bundle
+// component or an addon, with its total quantity.
+type orderRecipeUse struct {
+	menuID      int64
+	variantID   int64
+	addonItemID int64
+	quantity    float64
+}
+
+// ingredientUsage totals the ingredients an order needs. A variant with its
+// own recipe replaces the menu recipe; otherwise the menu recipe applies.
+func ingredientUsage(uses []orderRecipeUse, book recipeBook) map[int64]float64 {
+	usage := make(map[int64]float64)
+	for _, use := range uses {
+		var lines []recipeLine
+		switch {
+		case use.addonItemID != 0:
+			lines = book.addons[use.addonItemID]
+		case use.variantID != 0 && len(book.variants[use.variantID]) > 0:
+			lines = book.variants[use.variantID]
+		default:
+			lines = book.menus[use.menuID]
+		}
+		for _, line := range lines {
+			usage[line.IngredientID] += line.Quantity * use.quantity
+		}
+	}
+	for id, qty := range usage {
+		usage[id] = round3(qty)
+	}
+	return usage
+}
+
+// ingredientDeltas returns how much more of each ingredient to deplete so
+// that what an order has consumed matches what it now requires. Negative
+// values are returned to stock.
+func ingredientDeltas(required, consumed map[int64]float64) map[int64]float64 {
+	out := make(map[int64]float64)
+	for id, qty := range required {
+		if diff := round3(qty - consumed[id]); diff != 0 {
+			out[id] = diff
+		}
+	}
+	for id, qty := range consumed {
+		if _, ok := required[id]; ok {
+			continue
+		}
+		if diff := round3(-qty); diff != 0 {
+			out[id] = diff
+		}
+	}
+	return out
+}
+
+func loadOrderRecipeUses(ctx context.Context, tx pgx.Tx, orderID int64) ([]orderRecipeUse, error) {
+	rows, err := tx.Query(ctx, `
+		select oi.menu_id, coalesce(oiv.variant_id, 0), 0::bigint, oi.quantity::float8
+		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
+		where oi.order_id = $1 and oi.menu_id is not null
+		union all
+		select oic.menu_id, coalesce(oic.variant_id, 0), 0::bigint, (oic.quantity * oi.quantity)::float8
+		from order_item_components oic
+		join order_items oi on oi.id = oic.order_item_id
+		where oi.order_id = $1
+		union all
+		select 0::bigint, 0::bigint, oia.addon_item_id, oia.quantity::float8
+		from order_item_addons oia
+		join order_items oi on oi.id = oia.order_item_id
+		where oi.order_id = $1 and oia.addon_item_id is not null
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	uses := make([]orderRecipeUse, 0)
+	for rows.Next() {
+		var use orderRecipeUse
+		if err := rows.Scan(&use.menuID, &use.variantID, &use.addonItemID, &use.quantity); err != nil {
+			return nil, err
+		}
+		uses = append(uses, use)
+	}
+	return uses, rows.Err()
+}
+
+func loadRecipeBook(ctx context.Context, q catalogQuerier, uses []orderRecipeUse) (recipeBook, error) {
+	book := recipeBook{
+		menus:    make(map[int64][]recipeLine),
+		variants: make(map[int64][]recipeLine),
+		addons:   make(map[int64][]recipeLine),
+	}
+	menuIDs := make([]int64, 0)
+	variantIDs := make([]int64, 0)
+	addonIDs := make([]int64, 0)
+	for _, use := range uses {
+		if use.addonItemID != 0 {
+			addonIDs = append(addonIDs, use.addonItemID)
+			continue
+		}
+		menuIDs = append(menuIDs, use.menuID)
+		if use.variantID != 0 {
+			variantIDs = append(variantIDs, use.variantID)
+		}
+	}
+	if len(menuIDs)+len(addonIDs) == 0 {
+		return book, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		select rl.menu_id, rl.variant_id, rl.addon_item_id, rl.ingredient_id, rl.quantity, rl.is_optional
+		from recipe_lines rl
+		join ingredients i on i.id = rl.ingredient_id and i.deleted_at is null
+		where rl.menu_id = any($1) or rl.variant_id = any($2) or rl.addon_item_id = any($3)
+		order by rl.id
+	`, menuIDs, variantIDs, addonIDs)
+	if err != nil {
+		return book, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			menuID, variantID, addonItemID pgtype.Int8
+			line                           recipeLine
+			quantity                       pgtype.Numeric
+		)
+		if err := rows.Scan(&menuID, &variantID, &addonItemID, &line.IngredientID, &quantity, &line.IsOptional); err != nil {
+			return book, err
+		}
+		line.Quantity = utils.NumericToFloat64(quantity)
+		switch {
+		case menuID.Valid:
+			book.menus[menuID.Int64] = append(book.menus[menuID.Int64], line)
+		case variantID.Valid:
+			book.variants[variantID.Int64] = append(book.variants[variantID.Int64], line)
+		case addonItemID.Valid:
+			book.addons[addonItemID.Int64] = append(book.addons[addonItemID.Int64], line)
+		}
+	}
+	return book, rows.Err()
+}
+
+func loadOrderIngredientConsumption(ctx context.Context, tx pgx.Tx, orderID int64) (map[int64]float64, error) {
+	rows, err := tx.Query(ctx, `
+		select ingredient_id, -sum(quantity)
+		from ingredient_movements
+		where order_id = $1 and movement_type in ('ORDER', 'REFUND')
+		group by ingredient_id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	consumed := make(map[int64]float64)
+	for rows.Next() {
+		var id int64
+		var qty pgtype.Numeric
+		if err := rows.Scan(&id, &qty); err != nil {
+			return nil, err
+		}
+		consumed[id] = utils.NumericToFloat64(qty)
+	}
+	return consumed, rows.Err()
+}
+
+// recordIngredientMovementTx changes an ingredient's on-hand quantity by
+// delta and records the movement. It returns the new balance.
+func recordIngredientMovementTx(ctx context.Context, tx pgx.Tx, merchantID, ingredientID int64, delta float64, movementType string, reason *string, orderID *int64, userID *int64) (float64, error) {
+	var balance pgtype.Numeric
+	if err := tx.QueryRow(ctx, `
+		update ingredients set on_hand = on_hand + $3, updated_at = now()
+		where id = $1 and merchant_id = $2
+		returning on_hand
+	`, ingredientID, merchantID, delta).Scan(&balance); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		insert into ingredient_movements (
+			merchant_id, ingredient_id, movement_type, quantity, balance_after, reason, order_id, created_by_user_id, created_at
+		) values ($1, $2, $3, $4, $5, $6, $7, $8, now())
+	`, merchantID, ingredientID, movementType, delta, balance, reason, orderID, userID); err != nil {
+		return 0, err
+	}
+	return utils.NumericToFloat64(balance), nil
+}
+
+// syncOrderIngredientsTx depletes the ingredients an accepted order needs.
+// It is safe to call again: it only applies the difference between what the
+// order requires and what its movements already took, so edits after
+// acceptance top up or return stock.
+func (h *Handler) syncOrderIngredientsTx(ctx context.Context, tx pgx.Tx, orderID int64, userID *int64) error {
+	var merchantID int64
+	if err := tx.QueryRow(ctx, `select merchant_id from orders where id = $1`, orderID).Scan(&merchantID); err != nil {
+		return err
+	}
+	uses, err := loadOrderRecipeUses(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	book, err := loadRecipeBook(ctx, tx, uses)
+	if err != nil {
+		return err
+	}
+	consumed, err := loadOrderIngredientConsumption(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	deltas := ingredientDeltas(ingredientUsage(uses, book), consumed)
+	for ingredientID, qty := range deltas {
+		if _, err := recordIngredientMovementTx(ctx, tx, merchantID, ingredientID, -qty, ingredientMovementOrder, nil, &orderID, userID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		insert into order_ingredient_depletions (order_id) values ($1)
+		on conflict (order_id) do nothing
+	`, orderID); err != nil {
+		return err
+	}
+	if len(deltas) > 0 {
+		return refreshIngredientAvailabilityTx(ctx, tx, merchantID)
+	}
+	return nil
+}
+
+// resyncOrderIngredientsTx re-applies depletion after an order was edited,
+// for orders that were already depleted.
+func (h *Handler) resyncOrderIngredientsTx(ctx context.Context, tx pgx.Tx, orderID int64, userID *int64) error {
+	var depleted bool
+	if err := tx.QueryRow(ctx, `
+		select exists (select 1 from order_ingredient_depletions where order_id = $1)
+	`, orderID).Scan(&depleted); err != nil {
+		return err
+	}
+	if !depleted {
+		return nil
+	}
+	return h.syncOrderIngredientsTx(ctx, tx, orderID, userID)
+}
+
+// restoreOrderIngredients returns everything a refunded order consumed.
+func (h *Handler) restoreOrderIngredients(ctx context.Context, orderID int64, userID *int64) error {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := restoreOrderIngredientsTx(ctx, tx, orderID, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// restoreOrderIngredientsTx returns everything a refunded or cancelled order
+// consumed. The order stops counting as depleted, so later edits do not
+// take stock again. Orders that were never accepted have nothing to return.
+func restoreOrderIngredientsTx(ctx context.Context, tx pgx.Tx, orderID int64, userID *int64) error {
+	var merchantID int64
+	if err := tx.QueryRow(ctx, `select merchant_id from orders where id = $1 for update`, orderID).Scan(&merchantID); err != nil {
+		return err
+	}
+	consumed, err := loadOrderIngredientConsumption(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	restored := 0
+	for ingredientID, qty := range consumed {
+		if round3(qty) == 0 {
+			continue
+		}
+		if _, err := recordIngredientMovementTx(ctx, tx, merchantID, ingredientID, qty, ingredientMovementRefund, nil, &orderID, userID); err != nil {
+			return err
+		}
+		restored++
+	}
+	if _, err := tx.Exec(ctx, `delete from order_ingredient_depletions where order_id = $1`, orderID); err != nil {
+		return err
+	}
+	if restored > 0 {
+		return refreshIngredientAvailabilityTx(ctx, tx, merchantID)
+	}
+	return nil
+}
+
+// ingredientShortSQL matches recipe lines whose ingredient can no longer
+// cover one portion. Optional lines never make an item unavailable.
+const ingredientShortSQL = `
+	select 1 from recipe_lines rl
+	join ingredients i on i.id = rl.ingredient_id and i.deleted_at is null
+	where not rl.is_optional and i.on_hand < rl.quantity and %s
+`
+
+// refreshIngredientAvailabilityTx switches off menus, variants and addon
+// items that are short of a required ingredient, and switches back on the
+// ones it switched off once they are covered again. Items a merchant turned
+// off by hand are left alone. Switched-off menus and addon items are tracked
+// in ingredient_disabled_items.
+func refreshIngredientAvailabilityTx(ctx context.Context, tx pgx.Tx, merchantID int64) error {
+	menuShort := fmt.Sprintf(ingredientShortSQL, "rl.menu_id = m.id")
+	variantShort := fmt.Sprintf(ingredientShortSQL, "rl.variant_id = v.id")
+	addonShort := fmt.Sprintf(ingredientShortSQL, "rl.addon_item_id = ai.id")
+
+	statements := []string{
+		`with disabled as (
+			update menus m set is_active = false, updated_at = now()
+			where m.merchant_id = $1 and m.deleted_at is null and m.is_active and exists (` + menuShort + `)
+			returning m.id
+		)
+		insert into ingredient_disabled_items (item_type, item_id, merchant_id)
+		select 'MENU', id, $1 from disabled
+		on conflict do nothing`,
+		`with restored as (
+			delete from ingredient_disabled_items d
+			where d.merchant_id = $1 and d.item_type = 'MENU'
+				and not exists (` + fmt.Sprintf(ingredientShortSQL, "rl.menu_id = d.item_id") + `)
+			returning d.item_id
+		)
+		update menus m set is_active = true, updated_at = now()
+		from restored where m.id = restored.item_id`,
+		`update menu_variants v set is_active = false, disabled_by_ingredients = true, updated_at = now()
+		where v.merchant_id = $1 and v.is_active and exists (` + variantShort + `)`,
+		`update menu_variants v set is_active = true, disabled_by_ingredients = false, updated_at = now()
+		where v.merchant_id = $1 and v.disabled_by_ingredients and not exists (` + variantShort + `)`,
+		`with disabled as (
+			update addon_items ai set is_active = false, updated_at = now()
+			from addon_categories ac
+			where ac.id = ai.addon_category_id and ac.merchant_id = $1 and ai.deleted_at is null
+				and ai.is_active and exists (` + addonShort + `)
+			returning ai.id
+		)
+		insert into ingredient_disabled_items (item_type, item_id, merchant_id)
+		select 'ADDON_ITEM', id, $1 from disabled
+		on conflict do nothing`,
+		`with restored as (
+			delete from ingredient_disabled_items d
+			where d.merchant_id = $1 and d.item_type = 'ADDON_ITEM'
+				and not exists (` + fmt.Sprintf(ingredientShortSQL, "rl.addon_item_id = d.item_id") + `)
+			returning d.item_id
+		)
+		update addon_items ai set is_active = true, updated_at = now()
+		from restored where ai.id = restored.item_id`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt, merchantID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ingredientRecord struct {
+	ID        int64
+	Name      string
+	Unit      string
+	OnHand    float64
+	UsedBy    int64
+	UpdatedAt time.Time
+}
+
+func (i ingredientRecord) payload() map[string]any {
+	return map[string]any{
+		"id":        i.ID,
+		"name":      i.Name,
+		"unit":      i.Unit,
+		"onHand":    i.OnHand,
+		"isOut":     i.OnHand <= 0,
+		"usedBy":    i.UsedBy,
+		"updatedAt": i.UpdatedAt,
+	}
+}
+
+const ingredientColumns = `
+	i.id, i.name, i.unit, i.on_hand,
+	(select count(*) from recipe_lines rl where rl.ingredient_id = i.id),
+	i.updated_at
+`
+
+func scanIngredient(row pgx.Row) (ingredientRecord, error) {
+	var rec ingredientRecord
+	var onHand pgtype.Numeric
+	if err := row.Scan(&rec.ID, &rec.Name, &rec.Unit, &onHand, &rec.UsedBy, &rec.UpdatedAt); err != nil {
+		return rec, err
+	}
+	rec.OnHand = utils.NumericToFloat64(onHand)
+	return rec, nil
+}
+
+func (h *Handler) fetchIngredient(ctx context.Context, merchantID, id int64) (ingredientRecord, error) {
+	return scanIngredient(h.DB.QueryRow(ctx, `
+		select `+ingredientColumns+`
+		from ingredients i
+		where i.id = $1 and i.merchant_id = $2 and i.deleted_at is null
+	`, id, merchantID))
+}
+
+// MerchantIngredientsList lists the merchant's ingredients with their
+// on-hand quantity.
+func (h *Handler) MerchantIngredientsList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	outOnly := r.URL.Query().Get("outOfStock") == "true"
+	rows, err := h.DB.Query(ctx, `
+		select `+ingredientColumns+`
+		from ingredients i
+		where i.merchant_id = $1 and i.deleted_at is null
+			and ($2 = '' or i.name ilike '%' || $2 || '%')
+			and (not $3 or i.on_hand <= 0)
+		order by lower(i.name), i.id
+	`, *authCtx.MerchantID, search, outOnly)
+	if err != nil {
+		h.Logger.Error("ingredients query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve ingredients")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]map[string]any, 0)
+	for rows.Next() {
+		rec, err := scanIngredient(rows)
+		if err != nil {
+			h.Logger.Error("ingredients scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve ingredients")
+			return
+		}
+		items = append(items, rec.payload())
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       items,
+		"message":    "Ingredients retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+type ingredientInput struct {
+	Name   string   `json:"name"`
+	Unit   string   `json:"unit"`
+	OnHand *float64 `json:"onHand"`
+}
+
+func (in ingredientInput) normalize() (string, string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return "", "", errors.New("name is required")
+	}
+	if len(name) > 120 {
+		return "", "", errors.New("name must be at most 120 characters")
+	}
+	unit, err := normalizeIngredientUnit(in.Unit)
+	if err != nil {
+		return "", "", err
+	}
+	return name, unit, nil
+}
+
+// MerchantIngredientCreate adds an ingredient. An opening quantity is
+// recorded as an adjustment.
+func (h *Handler) MerchantIngredientCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+
+	var body ingredientInput
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	name, unit, err := body.normalize()
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	opening := 0.0
+	if body.OnHand != nil {
+		opening = round3(*body.OnHand)
+		if opening < 0 || opening > ingredientMaxQuantity {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "onHand must be between 0 and 1000000")
+			return
+		}
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create ingredient")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var id int64
+	if err := tx.QueryRow(ctx, `
+		insert into ingredients (merchant_id, name, unit, on_hand, created_at, updated_at, created_by_user_id, updated_by_user_id)
+		values ($1, $2, $3, 0, now(), now(), $4, $4)
+		returning id
+	`, merchantID, name, unit, authCtx.UserID).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			response.Error(w, http.StatusConflict, "INGREDIENT_EXISTS", "An ingredient with this name already exists")
+			return
+		}
+		h.Logger.Error("ingredient create failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create ingredient")
+		return
+	}
+	if opening > 0 {
+		reason := "Opening balance"
+		if _, err := recordIngredientMovementTx(ctx, tx, merchantID, id, opening, ingredientMovementAdjustment, &reason, nil, &authCtx.UserID); err != nil {
+			h.Logger.Error("ingredient opening balance failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create ingredient")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create ingredient")
+		return
+	}
+
+	rec, err := h.fetchIngredient(ctx, merchantID, id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create ingredient")
+		return
+	}
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"success":    true,
+		"data":       rec.payload(),
+		"message":    "Ingredient created successfully",
+		"statusCode": 201,
+	})
+}
+
+// MerchantIngredientUpdate renames an ingredient or changes its unit. The
+// on-hand quantity only changes through movements.
+func (h *Handler) MerchantIngredientUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid ingredient id")
+		return
+	}
+
+	var body ingredientInput
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if body.OnHand != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Use a movement or stocktake to change onHand")
+		return
+	}
+	name, unit, err := body.normalize()
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	tag, err := h.DB.Exec(ctx, `
+		update ingredients set name = $3, unit = $4, updated_at = now(), updated_by_user_id = $5
+		where id = $1 and merchant_id = $2 and deleted_at is null
+	`, id, *authCtx.MerchantID, name, unit, authCtx.UserID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			response.Error(w, http.StatusConflict, "INGREDIENT_EXISTS", "An ingredient with this name already exists")
+			return
+		}
+		h.Logger.Error("ingredient update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update ingredient")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Ingredient not found")
+		return
+	}
+
+	rec, err := h.fetchIngredient(ctx, *authCtx.MerchantID, id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update ingredient")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       rec.payload(),
+		"message":    "Ingredient updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantIngredientDelete soft-deletes an ingredient and removes it from
+// every recipe.
+func (h *Handler) MerchantIngredientDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid ingredient id")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete ingredient")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+		update ingredients set deleted_at = now(), deleted_by_user_id = $3
+		where id = $1 and merchant_id = $2 and deleted_at is null
+	`, id, merchantID, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("ingredient delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete ingredient")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Ingredient not found")
+		return
+	}
+	if _, err := tx.Exec(ctx, `delete from recipe_lines where ingredient_id = $1`, id); err != nil {
+		h.Logger.Error("ingredient recipe cleanup failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete ingredient")
+		return
+	}
+	if err := refreshIngredientAvailabilityTx(ctx, tx, merchantID); err != nil {
+		h.Logger.Error("ingredient availability refresh failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete ingredient")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete ingredient")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"message":    "Ingredient deleted successfully",
+		"statusCode": 200,
+	})
+}
+
+// manualIngredientDelta turns a manual movement into a signed change.
+// Adjustments are signed; waste and purchases are positive amounts that
+// remove or add stock.
+func manualIngredientDelta(movementType string, quantity float64, reason string) (float64, error) {
+	quantity = round3(quantity)
+	if quantity == 0 || math.Abs(quantity) > ingredientMaxQuantity {
+		return 0, errors.New("quantity must be non-zero and at most 1000000")
+	}
+	switch movementType {
+	case ingredientMovementAdjustment:
+		if reason == "" {
+			return 0, errors.New("reason is required for adjustments")
+		}
+		return quantity, nil
+	case ingredientMovementWaste:
+		if reason == "" {
+			return 0, errors.New("reason is required for waste")
+		}
+		if quantity < 0 {
+			return 0, errors.New("waste quantity must be positive")
+		}
+		return -quantity, nil
+	case ingredientMovementPurchase:
+		if quantity < 0 {
+			return 0, errors.New("purchase quantity must be positive")
+		}
+		return quantity, nil
+	}
+	return 0, errors.New("type must be ADJUSTMENT, WASTE or PURCHASE")
+}
+
+// MerchantIngredientMovementCreate records a manual adjustment, waste entry
+// or purchase for one ingredient.
+func (h *Handler) MerchantIngredientMovementCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid ingredient id")
+		return
+	}
+
+	var body struct {
+		Type     string  `json:"type"`
+		Quantity float64 `json:"quantity"`
+		Reason   string  `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	movementType := strings.ToUpper(strings.TrimSpace(body.Type))
+	reason := strings.TrimSpace(body.Reason)
+	delta, err := manualIngredientDelta(movementType, body.Quantity, reason)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if _, err := h.fetchIngredient(ctx, merchantID, id); err != nil {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Ingredient not found")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record movement")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	if _, err := recordIngredientMovementTx(ctx, tx, merchantID, id, delta, movementType, reasonPtr, nil, &authCtx.UserID); err != nil {
+		h.Logger.Error("ingredient movement failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record movement")
+		return
+	}
+	if err := refreshIngredientAvailabilityTx(ctx, tx, merchantID); err != nil {
+		h.Logger.Error("ingredient availability refresh failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record movement")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record movement")
+		return
+	}
+
+	rec, err := h.fetchIngredient(ctx, merchantID, id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record movement")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       rec.payload(),
+		"message":    "Movement recorded successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantIngredientStocktake sets counted quantities. Each count is
+// recorded as a STOCKTAKE movement of the difference, including counts that
+// matched.
+func (h *Handler) MerchantIngredientStocktake(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+
+	var body struct {
+		Reason string `json:"reason"`
+		Counts []struct {
+			IngredientID    any     `json:"ingredientId"`
+			CountedQuantity float64 `json:"countedQuantity"`
+		} `json:"counts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "reason is required")
+		return
+	}
+	if len(body.Counts) == 0 || len(body.Counts) > ingredientMaxStocktakeRows {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("counts must have between 1 and %d entries", ingredientMaxStocktakeRows))
+		return
+	}
+	counts := make(map[int64]float64, len(body.Counts))
+	order := make([]int64, 0, len(body.Counts))
+	for _, c := range body.Counts {
+		id, ok := parseNumericID(c.IngredientID)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Each count needs an ingredientId")
+			return
+		}
+		if _, dup := counts[id]; dup {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Ingredient %d is counted twice", id))
+			return
+		}
+		qty := round3(c.CountedQuantity)
+		if qty < 0 || qty > ingredientMaxQuantity {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "countedQuantity must be between 0 and 1000000")
+			return
+		}
+		counts[id] = qty
+		order = append(order, id)
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, `
+		select id, on_hand from ingredients
+		where merchant_id = $1 and id = any($2) and deleted_at is null
+		for update
+	`, merchantID, order)
+	if err != nil {
+		h.Logger.Error("stocktake ingredients query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+		return
+	}
+	onHand := make(map[int64]float64, len(order))
+	for rows.Next() {
+		var id int64
+		var qty pgtype.Numeric
+		if err := rows.Scan(&id, &qty); err != nil {
+			rows.Close()
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+			return
+		}
+		onHand[id] = utils.NumericToFloat64(qty)
+	}
+	rows.Close()
+
+	results := make([]map[string]any, 0, len(order))
+	for _, id := range order {
+		previous, ok := onHand[id]
+		if !ok {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Ingredient %d not found", id))
+			return
+		}
+		delta := round3(counts[id] - previous)
+		if _, err := recordIngredientMovementTx(ctx, tx, merchantID, id, delta, ingredientMovementStocktake, &reason, nil, &authCtx.UserID); err != nil {
+			h.Logger.Error("stocktake movement failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+			return
+		}
+		results = append(results, map[string]any{
+			"ingredientId": id,
+			"previous":     previous,
+			"counted":      counts[id],
+			"difference":   delta,
+		})
+	}
+	if err := refreshIngredientAvailabilityTx(ctx, tx, merchantID); err != nil {
+		h.Logger.Error("ingredient availability refresh failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to record stocktake")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       results,
+		"message":    "Stocktake recorded successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantIngredientMovements lists movements, newest first.
+func (h *Handler) MerchantIngredientMovements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	var ingredientID *int64
+	if raw := strings.TrimSpace(query.Get("ingredientId")); raw != "" {
+		id, err := parseStringToInt64(raw)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid ingredientId")
+			return
+		}
+		ingredientID = &id
+	}
+	movementType := strings.ToUpper(strings.TrimSpace(query.Get("type")))
+	limit := parseIntQuery(r, "limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select m.id, m.ingredient_id, i.name, i.unit, m.movement_type, m.quantity, m.balance_after,
+			m.reason, m.order_id, o.order_number, m.created_by_user_id, m.created_at
+		from ingredient_movements m
+		join ingredients i on i.id = m.ingredient_id
+		left join orders o on o.id = m.order_id
+		where m.merchant_id = $1
+			and ($2::bigint is null or m.ingredient_id = $2)
+			and ($3 = '' or m.movement_type = $3)
+		order by m.created_at desc, m.id desc
+		limit $4
+	`, *authCtx.MerchantID, ingredientID, movementType, limit)
+	if err != nil {
+		h.Logger.Error("ingredient movements query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve movements")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			id, ingID    int64
+			name, unit   string
+			kind         string
+			quantity     pgtype.Numeric
+			balance      pgtype.Numeric
+			reason       pgtype.Text
+			orderID      pgtype.Int8
+			orderNumber  pgtype.Text
+			createdBy    pgtype.Int8
+			createdAt    time.Time
+			orderPayload any
+		)
+		if err := rows.Scan(&id, &ingID, &name, &unit, &kind, &quantity, &balance, &reason,
+			&orderID, &orderNumber, &createdBy, &createdAt); err != nil {
+			h.Logger.Error("ingredient movements scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve movements")
+			return
+		}
+		if orderID.Valid {
+			orderPayload = map[string]any{"id": orderID.Int64, "orderNumber": ptrString(orderNumber)}
+		}
+		var createdByUserID any
+		if createdBy.Valid {
+			createdByUserID = int64ToString(createdBy.Int64)
+		}
+		items = append(items, map[string]any{
+			"id":              int64ToString(id),
+			"ingredient":      map[string]any{"id": ingID, "name": name, "unit": unit},
+			"type":            kind,
+			"quantity":        utils.NumericToFloat64(quantity),
+			"balanceAfter":    utils.NumericToFloat64(balance),
+			"reason":          ptrString(reason),
+			"order":           orderPayload,
+			"createdByUserId": createdByUserID,
+			"createdAt":       createdAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		h.Logger.Error("ingredient movements rows failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve movements")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       items,
+		"message":    "Movements retrieved successfully",
+		"statusCode": 200,
+	})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIngredientUsage(t *testing.T) {
+	book := recipeBook{
+		menus: map[int64][]recipeLine{
+			1: {{IngredientID: 100, Quantity: 18}, {IngredientID: 200, Quantity: 150}},
+			2: {{IngredientID: 300, Quantity: 1}},
+		},
+		variants: map[int64][]recipeLine{
+			11: {{IngredientID: 100, Quantity: 18}, {IngredientID: 200, Quantity: 250}},
+		},
+		addons: map[int64][]recipeLine{
+			50: {{IngredientID: 200, Quantity: 30.5}},
+		},
+	}
+	cases := []struct {
+		name string
+		uses []orderRecipeUse
+		want map[int64]float64
+	}{
+		{"menu recipe", []orderRecipeUse{{menuID: 1, quantity: 2}}, map[int64]float64{100: 36, 200: 300}},
+		{"variant recipe replaces menu", []orderRecipeUse{{menuID: 1, variantID: 11, quantity: 1}}, map[int64]float64{100: 18, 200: 250}},
+		{"variant without recipe uses menu", []orderRecipeUse{{menuID: 1, variantID: 12, quantity: 1}}, map[int64]float64{100: 18, 200: 150}},
+		{"addons and components add up", []orderRecipeUse{
+			{menuID: 1, quantity: 1},
+			{menuID: 2, quantity: 3},
+			{addonItemID: 50, quantity: 2},
+		}, map[int64]float64{100: 18, 200: 211, 300: 3}},
+		{"no recipe", []orderRecipeUse{{menuID: 9, quantity: 4}}, map[int64]float64{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ingredientUsage(tc.uses, book); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("usage %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIngredientDeltas(t *testing.T) {
+	cases := []struct {
+		name     string
+		required map[int64]float64
+		consumed map[int64]float64
+		want     map[int64]float64
+	}{
+		{"first depletion", map[int64]float64{1: 36}, map[int64]float64{}, map[int64]float64{1: 36}},
+		{"already depleted", map[int64]float64{1: 36}, map[int64]float64{1: 36}, map[int64]float64{}},
+		{"quantity raised", map[int64]float64{1: 54}, map[int64]float64{1: 36}, map[int64]float64{1: 18}},
+		{"item removed", map[int64]float64{1: 18}, map[int64]float64{1: 18, 2: 0.25}, map[int64]float64{2: -0.25}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ingredientDeltas(tc.required, tc.consumed); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("deltas %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestManualIngredientDelta(t *testing.T) {
+	cases := []struct {
+		name     string
+		kind     string
+		quantity float64
+		reason   string
+		want     float64
+		wantErr  string
+	}{
+		{"adjustment down", ingredientMovementAdjustment, -2.5, "Spilled", -2.5, ""},
+		{"adjustment needs reason", ingredientMovementAdjustment, 1, "", 0, "reason is required"},
+		{"waste removes", ingredientMovementWaste, 3, "Expired", -3, ""},
+		{"negative waste", ingredientMovementWaste, -3, "Expired", 0, "must be positive"},
+		{"purchase adds", ingredientMovementPurchase, 1000, "", 1000, ""},
+		{"zero", ingredientMovementPurchase, 0, "", 0, "non-zero"},
+		{"unknown type", "ORDER", 1, "x", 0, "type must be"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := manualIngredientDelta(tc.kind, tc.quantity, tc.reason)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("got %v, %v; want %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecipeLines(t *testing.T) {
+	cases := []struct {
+		name    string
+		inputs  []recipeLineInput
+		want    []recipeLine
+		wantErr string
+	}{
+		{"valid", []recipeLineInput{{IngredientID: "5", Quantity: 0.12345}, {IngredientID: float64(6), Quantity: 2, IsOptional: true}},
+			[]recipeLine{{IngredientID: 5, Quantity: 0.123}, {IngredientID: 6, Quantity: 2, IsOptional: true}}, ""},
+		{"empty", nil, []recipeLine{}, ""},
+		{"duplicate", []recipeLineInput{{IngredientID: 5, Quantity: 1}, {IngredientID: "5", Quantity: 2}}, nil, "listed twice"},
+		{"zero quantity", []recipeLineInput{{IngredientID: 5, Quantity: 0}}, nil, "must be positive"},
+		{"missing id", []recipeLineInput{{Quantity: 1}}, nil, "needs an ingredientId"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeRecipeLines(tc.inputs)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("lines %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const recipeMaxLines = 30
+
+type recipeLineInput struct {
+	IngredientID any     `json:"ingredientId"`
+	Quantity     float64 `json:"quantity"`
+	IsOptional   bool    `json:"isOptional"`
+}
+
+// normalizeRecipeLines validates one recipe: each ingredient once, with a
+// positive quantity per portion.
+func normalizeRecipeLines(inputs []recipeLineInput) ([]recipeLine, error) {
+	if len(inputs) > recipeMaxLines {
+		return nil, fmt.Errorf("A recipe can have at most %d ingredients", recipeMaxLines)
+	}
+	lines := make([]recipeLine, 0, len(inputs))
+	seen := make(map[int64]bool, len(inputs))
+	for _, in := range inputs {
+		id, ok := parseNumericID(in.IngredientID)
+		if !ok {
+			return nil, fmt.Errorf("Each recipe line needs an ingredientId")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("Ingredient %d is listed twice", id)
+		}
+		seen[id] = true
+		qty := round3(in.Quantity)
+		if qty <= 0 || qty > ingredientMaxQuantity {
+			return nil, fmt.Errorf("Quantity for ingredient %d must be positive", id)
+		}
+		lines = append(lines, recipeLine{IngredientID: id, Quantity: qty, IsOptional: in.IsOptional})
+	}
+	return lines, nil
+}
+
+type recipeLinePayload struct {
+	IngredientID int64   `json:"ingredientId"`
+	Name         string  `json:"name"`
+	Unit         string  `json:"unit"`
+	Quantity     float64 `json:"quantity"`
+	IsOptional   bool    `json:"isOptional"`
+	OnHand       float64 `json:"onHand"`
+}
+
+// fetchRecipeLines loads recipe lines for one owner column (menu_id,
+// variant_id or addon_item_id), grouped by owner ID.
+func (h *Handler) fetchRecipeLines(ctx context.Context, column string, ids []int64) (map[int64][]recipeLinePayload, error) {
+	out := make(map[int64][]recipeLinePayload, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := h.DB.Query(ctx, `
+		select rl.`+column+`, i.id, i.name, i.unit, rl.quantity, rl.is_optional, i.on_hand
+		from recipe_lines rl
+		join ingredients i on i.id = rl.ingredient_id and i.deleted_at is null
+		where rl.`+column+` = any($1)
+		order by rl.id
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			ownerID  int64
+			line     recipeLinePayload
+			quantity pgtype.Numeric
+			onHand   pgtype.Numeric
+		)
+		if err := rows.Scan(&ownerID, &line.IngredientID, &line.Name, &line.Unit, &quantity, &line.IsOptional, &onHand); err != nil {
+			return nil, err
+		}
+		line.Quantity = utils.NumericToFloat64(quantity)
+		line.OnHand = utils.NumericToFloat64(onHand)
+		out[ownerID] = append(out[ownerID], line)
+	}
+	return out, rows.Err()
+}
+
+func recipeLinesOrEmpty(lines []recipeLinePayload) []recipeLinePayload {
+	if lines == nil {
+		return []recipeLinePayload{}
+	}
+	return lines
+}
+
+// ensureRecipeIngredients checks that every ingredient belongs to the
+// merchant and is not deleted.
+func ensureRecipeIngredients(ctx context.Context, tx pgx.Tx, merchantID int64, lines ...[]recipeLine) error {
+	ids := make([]int64, 0)
+	for _, group := range lines {
+		for _, line := range group {
+			ids = append(ids, line.IngredientID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := tx.Query(ctx, `
+		select id from ingredients where merchant_id = $1 and id = any($2) and deleted_at is null
+	`, merchantID, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	found := make(map[int64]bool, len(ids))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return recipeValidationError(fmt.Sprintf("Ingredient %d not found", id))
+		}
+	}
+	return nil
+}
+
+// recipeValidationError is a recipe problem to report to the caller, as
+// opposed to a database failure.
+type recipeValidationError string
+
+func (e recipeValidationError) Error() string { return string(e) }
+
+func insertRecipeLines(ctx context.Context, tx pgx.Tx, merchantID int64, column string, ownerID int64, lines []recipeLine) error {
+	for _, line := range lines {
+		if _, err := tx.Exec(ctx, `
+			insert into recipe_lines (merchant_id, ingredient_id, `+column+`, quantity, is_optional, created_at)
+			values ($1, $2, $3, $4, $5, now())
+		`, merchantID, line.IngredientID, ownerID, line.Quantity, line.IsOptional); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type menuRecipeVariant struct {
+	ID   int64
+	Name string
+}
+
+func (h *Handler) fetchMenuRecipe(ctx context.Context, merchantID, menuID int64) (map[string]any, error) {
+	rows, err := h.DB.Query(ctx, `
+		select id, name from menu_variants where merchant_id = $1 and menu_id = $2 order by display_order, id
+	`, merchantID, menuID)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]menuRecipeVariant, 0)
+	variantIDs := make([]int64, 0)
+	for rows.Next() {
+		var v menuRecipeVariant
+		if err := rows.Scan(&v.ID, &v.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		variants = append(variants, v)
+		variantIDs = append(variantIDs, v.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	menuLines, err := h.fetchRecipeLines(ctx, "menu_id", []int64{menuID})
+	if err != nil {
+		return nil, err
+	}
+	variantLines, err := h.fetchRecipeLines(ctx, "variant_id", variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	variantPayloads := make([]map[string]any, 0, len(variants))
+	for _, v := range variants {
+		variantPayloads = append(variantPayloads, map[string]any{
+			"variantId": v.ID,
+			"name":      v.Name,
+			"lines":     recipeLinesOrEmpty(variantLines[v.ID]),
+		})
+	}
+	return map[string]any{
+		"menuId":   menuID,
+		"lines":    recipeLinesOrEmpty(menuLines[menuID]),
+		"variants": variantPayloads,
+	}, nil
+}
+
+// MerchantMenuRecipe returns the recipe of a menu and of each of its
+// variants.
+func (h *Handler) MerchantMenuRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+	if !h.dietaryTargetExists(ctx, dietaryTableMenus, *authCtx.MerchantID, menuID) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	data, err := h.fetchMenuRecipe(ctx, *authCtx.MerchantID, menuID)
+	if err != nil {
+		h.Logger.Error("menu recipe query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve recipe")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Recipe retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuRecipeReplace replaces the recipe of a menu and its variants.
+// Variants left out of the body lose their own recipe and use the menu's.
+func (h *Handler) MerchantMenuRecipeReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+
+	var body struct {
+		Lines    []recipeLineInput `json:"lines"`
+		Variants []struct {
+			VariantID any               `json:"variantId"`
+			Lines     []recipeLineInput `json:"lines"`
+		} `json:"variants"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	menuLines, err := normalizeRecipeLines(body.Lines)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	variantLines := make(map[int64][]recipeLine, len(body.Variants))
+	variantOrder := make([]int64, 0, len(body.Variants))
+	for _, v := range body.Variants {
+		id, ok := parseNumericID(v.VariantID)
+		if !ok {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Each variant recipe needs a variantId")
+			return
+		}
+		if _, dup := variantLines[id]; dup {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Variant %d is listed twice", id))
+			return
+		}
+		lines, err := normalizeRecipeLines(v.Lines)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+		variantLines[id] = lines
+		variantOrder = append(variantOrder, id)
+	}
+
+	if !h.dietaryTargetExists(ctx, dietaryTableMenus, merchantID, menuID) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	menuVariants := make(map[int64]bool)
+	rows, err := tx.Query(ctx, `select id from menu_variants where merchant_id = $1 and menu_id = $2`, merchantID, menuID)
+	if err != nil {
+		h.Logger.Error("menu recipe variants query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			menuVariants[id] = true
+		}
+	}
+	rows.Close()
+	for _, id := range variantOrder {
+		if !menuVariants[id] {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Variant %d does not belong to this menu", id))
+			return
+		}
+	}
+
+	groups := [][]recipeLine{menuLines}
+	for _, id := range variantOrder {
+		groups = append(groups, variantLines[id])
+	}
+	if err := ensureRecipeIngredients(ctx, tx, merchantID, groups...); err != nil {
+		var invalid recipeValidationError
+		if errors.As(err, &invalid) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", invalid.Error())
+			return
+		}
+		h.Logger.Error("recipe ingredients check failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `
+		delete from recipe_lines
+		where menu_id = $1 or variant_id in (select id from menu_variants where menu_id = $1)
+	`, menuID); err != nil {
+		h.Logger.Error("menu recipe delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if err := insertRecipeLines(ctx, tx, merchantID, "menu_id", menuID, menuLines); err != nil {
+		h.Logger.Error("menu recipe insert failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	for _, id := range variantOrder {
+		if err := insertRecipeLines(ctx, tx, merchantID, "variant_id", id, variantLines[id]); err != nil {
+			h.Logger.Error("variant recipe insert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+			return
+		}
+	}
+	if err := refreshIngredientAvailabilityTx(ctx, tx, merchantID); err != nil {
+		h.Logger.Error("ingredient availability refresh failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+
+	data, err := h.fetchMenuRecipe(ctx, merchantID, menuID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Recipe updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantAddonItemRecipe returns the recipe of an addon item.
+func (h *Handler) MerchantAddonItemRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	itemID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid addon item id")
+		return
+	}
+	if !h.dietaryTargetExists(ctx, dietaryTableAddonItems, *authCtx.MerchantID, itemID) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Addon item not found")
+		return
+	}
+
+	lines, err := h.fetchRecipeLines(ctx, "addon_item_id", []int64{itemID})
+	if err != nil {
+		h.Logger.Error("addon recipe query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve recipe")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"addonItemId": itemID, "lines": recipeLinesOrEmpty(lines[itemID])},
+		"message":    "Recipe retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantAddonItemRecipeReplace replaces the recipe of an addon item.
+func (h *Handler) MerchantAddonItemRecipeReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	merchantID := *authCtx.MerchantID
+	itemID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid addon item id")
+		return
+	}
+
+	var body struct {
+		Lines []recipeLineInput `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	lines, err := normalizeRecipeLines(body.Lines)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if !h.dietaryTargetExists(ctx, dietaryTableAddonItems, merchantID, itemID) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Addon item not found")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := ensureRecipeIngredients(ctx, tx, merchantID, lines); err != nil {
+		var invalid recipeValidationError
+		if errors.As(err, &invalid) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", invalid.Error())
+			return
+		}
+		h.Logger.Error("recipe ingredients check failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if _, err := tx.Exec(ctx, `delete from recipe_lines where addon_item_id = $1`, itemID); err != nil {
+		h.Logger.Error("addon recipe delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if err := insertRecipeLines(ctx, tx, merchantID, "addon_item_id", itemID, lines); err != nil {
+		h.Logger.Error("addon recipe insert failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if err := refreshIngredientAvailabilityTx(ctx, tx, merchantID); err != nil {
+		h.Logger.Error("ingredient availability refresh failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+
+	saved, err := h.fetchRecipeLines(ctx, "addon_item_id", []int64{itemID})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update recipe")
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"addonItemId": itemID, "lines": recipeLinesOrEmpty(saved[itemID])},
+		"message":    "Recipe updated successfully",
+		"statusCode": 200,
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keysetCursor marks the last row of a page in lists ordered by
+// (timestamp desc, id desc). It is handed to clients as an opaque string.
+type keysetCursor struct {
+	At time.Time
+	ID int64
+}
+
+type keysetCursorPayload struct {
+	At string `json:"t"`
+	ID string `json:"i"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeKeysetCursor(at time.Time, id int64) string {
+	payload, _ := json.Marshal(keysetCursorPayload{
+		At: at.UTC().Format(time.RFC3339Nano),
+		ID: strconv.FormatInt(id, 10),
+	})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeKeysetCursor(value string) (*keysetCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var payload keysetCursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, errInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, payload.At)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(payload.ID, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, errInvalidCursor
+	}
+	return &keysetCursor{At: at, ID: id}, nil
+}
+
+// readKeysetCursor reports whether the request asked for cursor pagination
+// (a `cursor` query parameter is present, empty for the first page) and
+// decodes the cursor when one was supplied.
+func readKeysetCursor(r *http.Request) (bool, *keysetCursor, error) {
+	query := r.URL.Query()
+	if !query.Has("cursor") {
+		return false, nil, nil
+	}
+	value := strings.TrimSpace(query.Get("cursor"))
+	if value == "" {
+		return true, nil, nil
+	}
+	cursor, err := decodeKeysetCursor(value)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, cursor, nil
+}
+
+// keysetCondition renders `(timeCol, idCol) < (cursor)` with placeholders
+// appended to args.
+func keysetCondition(timeCol, idCol string, cursor *keysetCursor, args []any) (string, []any) {
+	args = append(args, cursor.At, cursor.ID)
+	return "(" + timeCol + ", " + idCol + ") < ($" + strconv.Itoa(len(args)-1) + ", $" + strconv.Itoa(len(args)) + ")", args
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 14, 9, 26, 53, 589793000, time.FixedZone("WIB", 7*3600))
+	encoded := encodeKeysetCursor(at, 4211)
+
+	cursor, err := decodeKeysetCursor(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cursor.At.Equal(at) || cursor.ID != 4211 {
+		t.Fatalf("expected %v/4211, got %v/%d", at, cursor.At, cursor.ID)
+	}
+
+	// Surrounding whitespace from copied URLs is tolerated.
+	if _, err := decodeKeysetCursor("  " + encoded + " "); err != nil {
+		t.Fatalf("expected padded cursor to decode, got %v", err)
+	}
+}
+
+func TestDecodeKeysetCursorRejects(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	valid := encodeKeysetCursor(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), 10)
+
+	cases := map[string]string{
+		"empty":             "",
+		"not base64":        "%%%not-a-cursor%%%",
+		"std base64 padded": base64.StdEncoding.EncodeToString([]byte(`{"t":"2026-01-02T03:04:05Z","i":"10"}`)),
+		"truncated":         valid[:len(valid)-4],
+		"not json":          raw("garbage"),
+		"json array":        raw(`["2026-01-02T03:04:05Z","10"]`),
+		"missing time":      raw(`{"i":"10"}`),
+		"bad time":          raw(`{"t":"yesterday","i":"10"}`),
+		"missing id":        raw(`{"t":"2026-01-02T03:04:05Z"}`),
+		"numeric id":        raw(`{"t":"2026-01-02T03:04:05Z","i":10}`),
+		"zero id":           raw(`{"t":"2026-01-02T03:04:05Z","i":"0"}`),
+		"negative id":       raw(`{"t":"2026-01-02T03:04:05Z","i":"-10"}`),
+		"non-numeric id":    raw(`{"t":"2026-01-02T03:04:05Z","i":"10 or 1=1"}`),
+		"overflowing id":    raw(`{"t":"2026-01-02T03:04:05Z","i":"99999999999999999999"}`),
+	}
+	for name, value := range cases {
+		cursor, err := decodeKeysetCursor(value)
+		if !errors.Is(err, errInvalidCursor) || cursor != nil {
+			t.Errorf("%s: expected errInvalidCursor, got %v / %+v", name, err, cursor)
+		}
+	}
+}
+
+func TestReadKeysetCursor(t *testing.T) {
+	cases := []struct {
+		query     string
+		useCursor bool
+		hasCursor bool
+		wantErr   bool
+	}{
+		{query: "", useCursor: false},
+		{query: "?page=2", useCursor: false},
+		{query: "?cursor=", useCursor: true},
+		{query: "?cursor=" + encodeKeysetCursor(time.Now(), 7), useCursor: true, hasCursor: true},
+		{query: "?cursor=bogus", useCursor: true, wantErr: true},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/orders"+tc.query, nil)
+		useCursor, cursor, err := readKeysetCursor(r)
+		if useCursor != tc.useCursor || (cursor != nil) != tc.hasCursor || (err != nil) != tc.wantErr {
+			t.Errorf("%q: got use=%v cursor=%+v err=%v", tc.query, useCursor, cursor, err)
+		}
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	cursor := &keysetCursor{At: time.Unix(0, 0), ID: 5}
+	cond, args := keysetCondition("o.placed_at", "o.id", cursor, []any{int64(1), "PENDING"})
+	if cond != "(o.placed_at, o.id) < ($3, $4)" {
+		t.Fatalf("unexpected condition %q", cond)
+	}
+	if len(args) != 4 || args[3] != int64(5) {
+		t.Fatalf("unexpected args %v", args)
+	}
+}
@@ -0,0 +1,848 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	bundleAllocationListPrice = "LIST_PRICE"
+	bundleAllocationEqual     = "EQUAL"
+	bundleAllocationBundle    = "BUNDLE"
+
+	menuBundleMaxSlots          = 10
+	menuBundleMaxOptionsPerSlot = 30
+	menuBundleMaxSelection      = 10
+)
+
+// menuBundle is a combo menu made of slots. The bundle menu's own price
+// covers the picks of every slot; options may add an upcharge.
+type menuBundle struct {
+	MenuID            int64            `json:"menuId"`
+	RevenueAllocation string           `json:"revenueAllocation"`
+	Slots             []menuBundleSlot `json:"slots"`
+}
+
+type menuBundleSlot struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	MinSelection int32              `json:"minSelection"`
+	MaxSelection int32              `json:"maxSelection"`
+	DisplayOrder int32              `json:"displayOrder"`
+	Options      []menuBundleOption `json:"options"`
+}
+
+// menuBundleOption is one eligible pick. Options without a fixed variant
+// list the menu's active variants to choose from.
+type menuBundleOption struct {
+	ID          int64         `json:"id"`
+	MenuID      int64         `json:"menuId"`
+	MenuName    string        `json:"menuName"`
+	VariantID   *int64        `json:"variantId"`
+	VariantName *string       `json:"variantName"`
+	Upcharge    float64       `json:"upcharge"`
+	ListPrice   float64       `json:"listPrice"`
+	IsAvailable bool          `json:"isAvailable"`
+	Variants    []menuVariant `json:"variants"`
+
+	menuTrackStock bool
+	menuStockQty   *int32
+	fixedVariant   *menuVariant
+}
+
+// bundleComponentInput is one pick sent with a bundle order item.
+type bundleComponentInput struct {
+	SlotID    any `json:"slotId"`
+	MenuID    any `json:"menuId"`
+	VariantID any `json:"variantId"`
+}
+
+// orderItemComponent is a resolved pick of a bundle order item. Quantity is
+// per bundle unit.
+type orderItemComponent struct {
+	SlotID      *int64  `json:"slotId"`
+	SlotName    string  `json:"slotName"`
+	MenuID      int64   `json:"menuId"`
+	MenuName    string  `json:"menuName"`
+	VariantID   *int64  `json:"variantId"`
+	VariantName *string `json:"variantName"`
+	Quantity    int32   `json:"quantity"`
+	Upcharge    float64 `json:"upcharge"`
+	ListPrice   float64 `json:"listPrice"`
+}
+
+func isValidBundleAllocation(method string) bool {
+	switch method {
+	case bundleAllocationListPrice, bundleAllocationEqual, bundleAllocationBundle:
+		return true
+	}
+	return false
+}
+
+// resolveBundleComponents checks the picks of a bundle order item against
+// its slots and stock, merging identical picks. It returns the components
+// and the total upcharge per bundle unit. Menus that are not bundles take no
+// components.
+func resolveBundleComponents(bundleName string, bundle *menuBundle, inputs []bundleComponentInput, quantity int32) ([]orderItemComponent, float64, error) {
+	if bundle == nil || len(bundle.Slots) == 0 {
+		if len(inputs) > 0 {
+			return nil, 0, errInvalid(fmt.Sprintf("%s has no components to choose", bundleName))
+		}
+		return nil, 0, nil
+	}
+
+	counts := make(map[int64]int32)
+	components := make([]orderItemComponent, 0, len(inputs))
+	options := make([]*menuBundleOption, 0, len(inputs))
+	upcharge := 0.0
+
+	for _, input := range inputs {
+		slotID, ok := parseNumericID(input.SlotID)
+		if !ok {
+			return nil, 0, errInvalid("Invalid slotId")
+		}
+		var slot *menuBundleSlot
+		for i := range bundle.Slots {
+			if bundle.Slots[i].ID == slotID {
+				slot = &bundle.Slots[i]
+				break
+			}
+		}
+		if slot == nil {
+			return nil, 0, errInvalid(fmt.Sprintf("Choice not found in %s", bundleName))
+		}
+		menuID, ok := parseNumericID(input.MenuID)
+		if !ok {
+			return nil, 0, errInvalid("Invalid menuId")
+		}
+
+		option := matchBundleOption(slot, menuID, input.VariantID)
+		if option == nil {
+			return nil, 0, errInvalid(fmt.Sprintf("Invalid choice for %s in %s", slot.Name, bundleName))
+		}
+		if !option.IsAvailable {
+			return nil, 0, errInvalid(fmt.Sprintf("%s is not available", option.MenuName))
+		}
+		variant := option.fixedVariant
+		if variant == nil {
+			resolved, err := resolveMenuVariant(option.MenuName, option.Variants, input.VariantID, 0)
+			if err != nil {
+				return nil, 0, err
+			}
+			variant = resolved
+		}
+
+		counts[slot.ID]++
+		upcharge = round2(upcharge + option.Upcharge)
+
+		component := orderItemComponent{
+			SlotID:    &slot.ID,
+			SlotName:  slot.Name,
+			MenuID:    option.MenuID,
+			MenuName:  option.MenuName,
+			Quantity:  1,
+			Upcharge:  option.Upcharge,
+			ListPrice: option.ListPrice,
+		}
+		if variant != nil {
+			component.VariantID = &variant.ID
+			component.VariantName = &variant.Name
+			component.ListPrice = variant.Price
+		}
+		merged := false
+		for i := range components {
+			if sameBundleComponent(components[i], component) {
+				components[i].Quantity++
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			components = append(components, component)
+			options = append(options, option)
+		}
+	}
+
+	for _, slot := range bundle.Slots {
+		count := counts[slot.ID]
+		if count < slot.MinSelection {
+			return nil, 0, errInvalid(fmt.Sprintf("Please choose %d for %s in %s", slot.MinSelection, slot.Name, bundleName))
+		}
+		if count > slot.MaxSelection {
+			return nil, 0, errInvalid(fmt.Sprintf("Choose at most %d for %s in %s", slot.MaxSelection, slot.Name, bundleName))
+		}
+	}
+
+	menuNeed := make(map[int64]int32)
+	variantNeed := make(map[int64]int32)
+	for i, component := range components {
+		need := component.Quantity * quantity
+		option := options[i]
+		menuNeed[component.MenuID] += need
+		if option.menuTrackStock && (option.menuStockQty == nil || *option.menuStockQty < menuNeed[component.MenuID]) {
+			return nil, 0, errInvalid(fmt.Sprintf("Insufficient stock for %s", option.MenuName))
+		}
+		if component.VariantID == nil {
+			continue
+		}
+		variantNeed[*component.VariantID] += need
+		variant := option.fixedVariant
+		if variant == nil {
+			for j := range option.Variants {
+				if option.Variants[j].ID == *component.VariantID {
+					variant = &option.Variants[j]
+					break
+				}
+			}
+		}
+		if variant != nil && variant.TrackStock && (variant.StockQty == nil || *variant.StockQty < variantNeed[variant.ID]) {
+			return nil, 0, errInvalid(fmt.Sprintf("Insufficient stock for %s (%s)", option.MenuName, variant.Name))
+		}
+	}
+
+	return components, upcharge, nil
+}
+
+// matchBundleOption finds the slot option for a pick. Options fixed to the
+// requested variant win over an open option of the same menu.
+func matchBundleOption(slot *menuBundleSlot, menuID int64, requestedVariant any) *menuBundleOption {
+	variantID, hasVariant := parseNumericID(requestedVariant)
+	var open *menuBundleOption
+	fixed := make([]*menuBundleOption, 0)
+	for i := range slot.Options {
+		option := &slot.Options[i]
+		if option.MenuID != menuID {
+			continue
+		}
+		if option.VariantID == nil {
+			open = option
+		} else {
+			fixed = append(fixed, option)
+		}
+	}
+	if hasVariant {
+		for _, option := range fixed {
+			if *option.VariantID == variantID {
+				return option
+			}
+		}
+	}
+	if open != nil {
+		return open
+	}
+	if !hasVariant && len(fixed) == 1 {
+		return fixed[0]
+	}
+	return nil
+}
+
+func sameBundleComponent(a, b orderItemComponent) bool {
+	if *a.SlotID != *b.SlotID || a.MenuID != b.MenuID {
+		return false
+	}
+	if a.VariantID == nil || b.VariantID == nil {
+		return a.VariantID == nil && b.VariantID == nil
+	}
+	return *a.VariantID == *b.VariantID
+}
+
+// allocateBundleRevenue splits the revenue of a bundle order item over its
+// components. Upcharges go to the component that caused them; the rest is
+// shared by list price or evenly. BUNDLE keeps everything on the bundle and
+// returns nil. Amounts are rounded so they add up to revenue.
+func allocateBundleRevenue(revenue float64, quantity int32, components []orderItemComponent, method string) []float64 {
+	if len(components) == 0 || method == bundleAllocationBundle {
+		return nil
+	}
+	upcharges := make([]float64, len(components))
+	weights := make([]float64, len(components))
+	upchargeTotal, weightTotal := 0.0, 0.0
+	for i, component := range components {
+		units := float64(component.Quantity) * float64(quantity)
+		upcharges[i] = component.Upcharge * units
+		upchargeTotal += upcharges[i]
+		if method == bundleAllocationListPrice {
+			weights[i] = component.ListPrice * units
+		}
+		weightTotal += weights[i]
+	}
+	if weightTotal <= 0 {
+		weightTotal = 0
+		for i, component := range components {
+			weights[i] = float64(component.Quantity) * float64(quantity)
+			weightTotal += weights[i]
+		}
+	}
+
+	base := math.Max(0, revenue-upchargeTotal)
+	out := make([]float64, len(components))
+	allocated := 0.0
+	for i := range components {
+		if i == len(components)-1 {
+			out[i] = round2(revenue - allocated)
+			break
+		}
+		out[i] = round2(base*weights[i]/weightTotal + upcharges[i])
+		allocated += out[i]
+	}
+	return out
+}
+
+// fetchMenuBundles loads the slots and options of the bundle menus among
+// menuIDs. Menus that are not bundles are left out of the map.
+func (h *Handler) fetchMenuBundles(ctx context.Context, merchantID int64, menuIDs []int64) (map[int64]*menuBundle, error) {
+	bundles := make(map[int64]*menuBundle)
+	if len(menuIDs) == 0 {
+		return bundles, nil
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select m.id, bs.revenue_allocation, s.id, s.name, s.min_selection, s.max_selection, s.display_order
+		from menus m
+		join menu_bundle_settings bs on bs.menu_id = m.id
+		join menu_bundle_slots s on s.bundle_menu_id = m.id
+		where m.id = any($1) and m.merchant_id = $2 and bs.is_bundle = true
+		order by m.id, s.display_order, s.id
+	`, menuIDs, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	type slotRef struct {
+		bundleID int64
+		index    int
+	}
+	slotRefs := make(map[int64]slotRef)
+	slotIDs := make([]int64, 0)
+	for rows.Next() {
+		var (
+			menuID     int64
+			allocation string
+			slot       menuBundleSlot
+		)
+		if err := rows.Scan(&menuID, &allocation, &slot.ID, &slot.Name, &slot.MinSelection, &slot.MaxSelection, &slot.DisplayOrder); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bundle := bundles[menuID]
+		if bundle == nil {
+			bundle = &menuBundle{MenuID: menuID, RevenueAllocation: allocation, Slots: []menuBundleSlot{}}
+			bundles[menuID] = bundle
+		}
+		slot.Options = []menuBundleOption{}
+		bundle.Slots = append(bundle.Slots, slot)
+		slotRefs[slot.ID] = slotRef{bundleID: menuID, index: len(bundle.Slots) - 1}
+		slotIDs = append(slotIDs, slot.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(slotIDs) == 0 {
+		return bundles, nil
+	}
+
+	optionRows, err := h.DB.Query(ctx, `
+		select o.id, o.slot_id, o.menu_id, o.variant_id, o.upcharge,
+		       m.name, m.price, m.is_active and m.deleted_at is null, m.track_stock, m.stock_qty
+		from menu_bundle_slot_options o
+		join menus m on m.id = o.menu_id
+		where o.slot_id = any($1)
+		order by o.slot_id, o.display_order, o.id
+	`, slotIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	type optionRef struct {
+		slot   slotRef
+		option menuBundleOption
+	}
+	refs := make([]optionRef, 0)
+	optionMenuIDs := make([]int64, 0)
+	for optionRows.Next() {
+		var (
+			option    menuBundleOption
+			slotID    int64
+			variantID pgtype.Int8
+			upcharge  pgtype.Numeric
+			price     pgtype.Numeric
+			stockQty  pgtype.Int4
+		)
+		if err := optionRows.Scan(&option.ID, &slotID, &option.MenuID, &variantID, &upcharge,
+			&option.MenuName, &price, &option.IsAvailable, &option.menuTrackStock, &stockQty); err != nil {
+			return nil, err
+		}
+		option.VariantID = int8Ptr(variantID)
+		option.Upcharge = utils.NumericToFloat64(upcharge)
+		option.ListPrice = utils.NumericToFloat64(price)
+		option.menuStockQty = int4Ptr(stockQty)
+		option.Variants = []menuVariant{}
+		refs = append(refs, optionRef{slot: slotRefs[slotID], option: option})
+		optionMenuIDs = append(optionMenuIDs, option.MenuID)
+	}
+	if err := optionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	variants, err := h.fetchMenuVariants(ctx, merchantID, optionMenuIDs, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		option := ref.option
+		if option.VariantID != nil {
+			option.IsAvailable = false
+			for i := range variants[option.MenuID] {
+				v := variants[option.MenuID][i]
+				if v.ID == *option.VariantID {
+					option.fixedVariant = &v
+					option.VariantName = &v.Name
+					option.ListPrice = v.Price
+					option.IsAvailable = ref.option.IsAvailable && v.IsActive
+					break
+				}
+			}
+		} else {
+			for _, v := range variants[option.MenuID] {
+				if v.IsActive {
+					option.Variants = append(option.Variants, v)
+				}
+			}
+		}
+		slot := &bundles[ref.slot.bundleID].Slots[ref.slot.index]
+		slot.Options = append(slot.Options, option)
+	}
+	return bundles, nil
+}
+
+// insertOrderItemComponents stores the picks of a bundle order item.
+func insertOrderItemComponents(ctx context.Context, tx pgx.Tx, orderItemID int64, components []orderItemComponent) error {
+	for _, component := range components {
+		if _, err := tx.Exec(ctx, `
+			insert into order_item_components (
+				order_item_id, slot_id, slot_name, menu_id, menu_name, variant_id, variant_name,
+				quantity, upcharge, list_price
+			) values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		`, orderItemID, component.SlotID, component.SlotName, component.MenuID, component.MenuName,
+			component.VariantID, component.VariantName, component.Quantity, component.Upcharge, component.ListPrice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchOrderItemComponents loads the bundle picks of the given order items.
+func (h *Handler) fetchOrderItemComponents(ctx context.Context, orderItemIDs []int64) (map[int64][]orderItemComponent, error) {
+	out := make(map[int64][]orderItemComponent)
+	if len(orderItemIDs) == 0 {
+		return out, nil
+	}
+	rows, err := h.DB.Query(ctx, `
+		select order_item_id, slot_id, slot_name, menu_id, menu_name, variant_id, variant_name,
+		       quantity, upcharge, list_price
+		from order_item_components
+		where order_item_id = any($1)
+		order by id asc
+	`, orderItemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			orderItemID int64
+			component   orderItemComponent
+			slotID      pgtype.Int8
+			variantID   pgtype.Int8
+			variantName pgtype.Text
+			upcharge    pgtype.Numeric
+			listPrice   pgtype.Numeric
+		)
+		if err := rows.Scan(&orderItemID, &slotID, &component.SlotName, &component.MenuID, &component.MenuName,
+			&variantID, &variantName, &component.Quantity, &upcharge, &listPrice); err != nil {
+			return nil, err
+		}
+		component.SlotID = int8Ptr(slotID)
+		component.VariantID = int8Ptr(variantID)
+		component.VariantName = textPtr(variantName)
+		component.Upcharge = utils.NumericToFloat64(upcharge)
+		component.ListPrice = utils.NumericToFloat64(listPrice)
+		out[orderItemID] = append(out[orderItemID], component)
+	}
+	return out, rows.Err()
+}
+
+// bundleComponentRefs expands bundle components into stock references for
+// quantity bundle units.
+func bundleComponentRefs(components []orderItemComponent, quantity int32) []menuItemRef {
+	refs := make([]menuItemRef, 0, len(components))
+	for _, component := range components {
+		refs = append(refs, menuItemRef{MenuID: component.MenuID, VariantID: component.VariantID, Quantity: component.Quantity * quantity})
+	}
+	return refs
+}
+
+func componentPayloads(components []orderItemComponent) []orderItemComponent {
+	if components == nil {
+		return []orderItemComponent{}
+	}
+	return components
+}
+
+type menuBundleSlotInput struct {
+	Name         string                  `json:"name"`
+	MinSelection *int32                  `json:"minSelection"`
+	MaxSelection *int32                  `json:"maxSelection"`
+	Options      []menuBundleOptionInput `json:"options"`
+}
+
+type menuBundleOptionInput struct {
+	MenuID    any      `json:"menuId"`
+	VariantID any      `json:"variantId"`
+	Upcharge  *float64 `json:"upcharge"`
+}
+
+// normalizeMenuBundleInput validates a full slot list. Slot order follows
+// the list; min and max selection default to one.
+func normalizeMenuBundleInput(inputs []menuBundleSlotInput) ([]menuBundleSlot, error) {
+	if len(inputs) > menuBundleMaxSlots {
+		return nil, fmt.Errorf("A bundle can have at most %d slots", menuBundleMaxSlots)
+	}
+	slots := make([]menuBundleSlot, 0, len(inputs))
+	names := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		slot := menuBundleSlot{Name: strings.TrimSpace(input.Name), MinSelection: 1, MaxSelection: 1, DisplayOrder: int32(i)}
+		if slot.Name == "" {
+			return nil, fmt.Errorf("Slot name is required")
+		}
+		if len(slot.Name) > menuVariantMaxNameLength {
+			return nil, fmt.Errorf("Slot name must be at most %d characters", menuVariantMaxNameLength)
+		}
+		if names[strings.ToLower(slot.Name)] {
+			return nil, fmt.Errorf("Slot name %q is used twice", slot.Name)
+		}
+		names[strings.ToLower(slot.Name)] = true
+		if input.MinSelection != nil {
+			slot.MinSelection = *input.MinSelection
+		}
+		if input.MaxSelection != nil {
+			slot.MaxSelection = *input.MaxSelection
+		}
+		if slot.MinSelection < 0 || slot.MaxSelection < 1 || slot.MaxSelection > menuBundleMaxSelection || slot.MinSelection > slot.MaxSelection {
+			return nil, fmt.Errorf("Slot %q has an invalid selection range", slot.Name)
+		}
+
+		if len(input.Options) == 0 {
+			return nil, fmt.Errorf("Slot %q needs at least one option", slot.Name)
+		}
+		if len(input.Options) > menuBundleMaxOptionsPerSlot {
+			return nil, fmt.Errorf("Slot %q can have at most %d options", slot.Name, menuBundleMaxOptionsPerSlot)
+		}
+		seen := make(map[string]bool, len(input.Options))
+		slot.Options = make([]menuBundleOption, 0, len(input.Options))
+		for _, optionInput := range input.Options {
+			menuID, ok := parseNumericID(optionInput.MenuID)
+			if !ok {
+				return nil, fmt.Errorf("Slot %q has an invalid menuId", slot.Name)
+			}
+			option := menuBundleOption{MenuID: menuID}
+			key := fmt.Sprintf("%d:", menuID)
+			if optionInput.VariantID != nil {
+				variantID, ok := parseNumericID(optionInput.VariantID)
+				if !ok {
+					return nil, fmt.Errorf("Slot %q has an invalid variantId", slot.Name)
+				}
+				option.VariantID = &variantID
+				key += fmt.Sprint(variantID)
+			}
+			if seen[key] {
+				return nil, fmt.Errorf("Slot %q lists the same option twice", slot.Name)
+			}
+			seen[key] = true
+			if optionInput.Upcharge != nil {
+				value := *optionInput.Upcharge
+				if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+					return nil, fmt.Errorf("Slot %q has an invalid upcharge", slot.Name)
+				}
+				option.Upcharge = round2(value)
+			}
+			slot.Options = append(slot.Options, option)
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+func bundleResponse(menuID int64, isBundle bool, allocation string, bundle *menuBundle) map[string]any {
+	slots := []menuBundleSlot{}
+	if bundle != nil {
+		slots = bundle.Slots
+	}
+	return map[string]any{
+		"menuId":            menuID,
+		"isBundle":          isBundle,
+		"revenueAllocation": allocation,
+		"slots":             slots,
+	}
+}
+
+// MerchantMenuBundle returns a menu's bundle slots.
+func (h *Handler) MerchantMenuBundle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+
+	var isBundle bool
+	var allocation string
+	if err := h.DB.QueryRow(ctx, `
+		select coalesce(bs.is_bundle, false), coalesce(bs.revenue_allocation, 'LIST_PRICE')
+		from menus m
+		left join menu_bundle_settings bs on bs.menu_id = m.id
+		where m.id = $1 and m.merchant_id = $2 and m.deleted_at is null
+	`, menuID, *authCtx.MerchantID).Scan(&isBundle, &allocation); err != nil {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	bundles, err := h.fetchMenuBundles(ctx, *authCtx.MerchantID, []int64{menuID})
+	if err != nil {
+		h.Logger.Error("menu bundle query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve bundle")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       bundleResponse(menuID, isBundle, allocation, bundles[menuID]),
+		"message":    "Bundle retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuBundleReplace replaces a menu's bundle slots. An empty slot
+// list turns the menu back into a regular item.
+func (h *Handler) MerchantMenuBundleReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+
+	var body struct {
+		RevenueAllocation *string               `json:"revenueAllocation"`
+		Slots             []menuBundleSlotInput `json:"slots"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	slots, err := normalizeMenuBundleInput(body.Slots)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update bundle")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var allocation string
+	if err := tx.QueryRow(ctx, `
+		select coalesce(bs.revenue_allocation, 'LIST_PRICE')
+		from menus m
+		left join menu_bundle_settings bs on bs.menu_id = m.id
+		where m.id = $1 and m.merchant_id = $2 and m.deleted_at is null
+		for update of m
+	`, menuID, *authCtx.MerchantID).Scan(&allocation); err != nil {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+	if body.RevenueAllocation != nil {
+		allocation = strings.ToUpper(strings.TrimSpace(*body.RevenueAllocation))
+		if !isValidBundleAllocation(allocation) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "revenueAllocation must be LIST_PRICE, EQUAL or BUNDLE")
+			return
+		}
+	}
+
+	if msg, err := validateBundleOptionsTx(ctx, tx, *authCtx.MerchantID, menuID, slots); err != nil {
+		h.Logger.Error("menu bundle option check failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update bundle")
+		return
+	} else if msg != "" {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+
+	isBundle := len(slots) > 0
+	if err := writeMenuBundleTx(ctx, tx, *authCtx.MerchantID, menuID, allocation, slots); err != nil {
+		h.Logger.Error("menu bundle write failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update bundle")
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		update menus set updated_at = now(), updated_by_user_id = $2 where id = $1
+	`, menuID, authCtx.UserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update bundle")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update bundle")
+		return
+	}
+
+	bundles, err := h.fetchMenuBundles(ctx, *authCtx.MerchantID, []int64{menuID})
+	if err != nil {
+		h.Logger.Error("menu bundle query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve bundle")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       bundleResponse(menuID, isBundle, allocation, bundles[menuID]),
+		"message":    "Bundle updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// writeMenuBundleTx replaces the slots of a bundle menu and stores its
+// settings. An empty slot list makes it a regular menu again. Options must
+// already be validated.
+func writeMenuBundleTx(ctx context.Context, tx pgx.Tx, merchantID, menuID int64, allocation string, slots []menuBundleSlot) error {
+	if _, err := tx.Exec(ctx, `delete from menu_bundle_slots where bundle_menu_id = $1`, menuID); err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		var slotID int64
+		if err := tx.QueryRow(ctx, `
+			insert into menu_bundle_slots (merchant_id, bundle_menu_id, name, min_selection, max_selection, display_order)
+			values ($1,$2,$3,$4,$5,$6)
+			returning id
+		`, merchantID, menuID, slot.Name, slot.MinSelection, slot.MaxSelection, slot.DisplayOrder).Scan(&slotID); err != nil {
+			return err
+		}
+		for i, option := range slot.Options {
+			if _, err := tx.Exec(ctx, `
+				insert into menu_bundle_slot_options (slot_id, menu_id, variant_id, upcharge, display_order)
+				values ($1,$2,$3,$4,$5)
+			`, slotID, option.MenuID, option.VariantID, option.Upcharge, i); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := tx.Exec(ctx, `
+		insert into menu_bundle_settings (menu_id, merchant_id, is_bundle, revenue_allocation, updated_at)
+		values ($1, $2, $3, $4, now())
+		on conflict (menu_id) do update set
+			is_bundle = excluded.is_bundle,
+			revenue_allocation = excluded.revenue_allocation,
+			updated_at = excluded.updated_at
+	`, menuID, merchantID, len(slots) > 0, allocation)
+	return err
+}
+
+// validateBundleOptionsTx checks that every option points at a live,
+// non-bundle menu of the merchant and, when fixed, at one of its variants.
+// It returns a user-facing message for invalid options.
+func validateBundleOptionsTx(ctx context.Context, tx pgx.Tx, merchantID, bundleMenuID int64, slots []menuBundleSlot) (string, error) {
+	menuIDs := make([]int64, 0)
+	variantIDs := make([]int64, 0)
+	for _, slot := range slots {
+		for _, option := range slot.Options {
+			menuIDs = append(menuIDs, option.MenuID)
+			if option.VariantID != nil {
+				variantIDs = append(variantIDs, *option.VariantID)
+			}
+		}
+	}
+	if len(menuIDs) == 0 {
+		return "", nil
+	}
+
+	menus := make(map[int64]bool)
+	rows, err := tx.Query(ctx, `
+		select m.id, coalesce(bs.is_bundle, false)
+		from menus m
+		left join menu_bundle_settings bs on bs.menu_id = m.id
+		where m.id = any($1) and m.merchant_id = $2 and m.deleted_at is null
+	`, menuIDs, merchantID)
+	if err != nil {
+		return "", err
+	}
+	for rows.Next() {
+		var id int64
+		var isBundle bool
+		if err := rows.Scan(&id, &isBundle); err != nil {
+			rows.Close()
+			return "", err
+		}
+		menus[id] = isBundle
+	}
+	rows.Close()
+
+	variantMenus := make(map[int64]int64)
+	if len(variantIDs) > 0 {
+		rows, err := tx.Query(ctx, `
+			select id, menu_id from menu_variants
+			where id = any($1) and merchant_id = $2 and deleted_at is null
+		`, variantIDs, merchantID)
+		if err != nil {
+			return "", err
+		}
+		for rows.Next() {
+			var id, menuID int64
+			if err := rows.Scan(&id, &menuID); err != nil {
+				rows.Close()
+				return "", err
+			}
+			variantMenus[id] = menuID
+		}
+		rows.Close()
+	}
+
+	for _, slot := range slots {
+		for _, option := range slot.Options {
+			isBundle, ok := menus[option.MenuID]
+			if !ok {
+				return fmt.Sprintf("Menu %d in slot %q not found", option.MenuID, slot.Name), nil
+			}
+			if option.MenuID == bundleMenuID || isBundle {
+				return fmt.Sprintf("Slot %q cannot include a bundle", slot.Name), nil
+			}
+			if option.VariantID != nil && variantMenus[*option.VariantID] != option.MenuID {
+				return fmt.Sprintf("Variant %d in slot %q not found on menu %d", *option.VariantID, slot.Name, option.MenuID), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func testMenuBundle() *menuBundle {
+	stock := int32(3)
+	large := menuVariant{ID: 21, Name: "Large", Price: 8, IsActive: true}
+	return &menuBundle{
+		MenuID:            1,
+		RevenueAllocation: bundleAllocationListPrice,
+		Slots: []menuBundleSlot{
+			{ID: 10, Name: "Main", MinSelection: 1, MaxSelection: 1, Options: []menuBundleOption{
+				{MenuID: 2, MenuName: "Burger", ListPrice: 20, IsAvailable: true},
+				{MenuID: 3, MenuName: "Chicken", ListPrice: 22, Upcharge: 2, IsAvailable: true, menuTrackStock: true, menuStockQty: &stock},
+				{MenuID: 4, MenuName: "Steak", ListPrice: 40, IsAvailable: false},
+			}},
+			{ID: 11, Name: "Drink", MinSelection: 1, MaxSelection: 2, Options: []menuBundleOption{
+				{MenuID: 5, MenuName: "Tea", ListPrice: 5, IsAvailable: true, Variants: []menuVariant{
+					{ID: 20, Name: "Regular", Price: 5, IsActive: true},
+					large,
+				}},
+				{MenuID: 6, MenuName: "Soda", VariantID: &large.ID, ListPrice: 8, Upcharge: 1.5, IsAvailable: true, fixedVariant: &large},
+			}},
+		},
+	}
+}
+
+func TestResolveBundleComponents(t *testing.T) {
+	cases := []struct {
+		name         string
+		bundle       *menuBundle
+		inputs       []bundleComponentInput
+		quantity     int32
+		wantCount    int
+		wantUpcharge float64
+		wantErr      string
+	}{
+		{"not a bundle", nil, nil, 1, 0, 0, ""},
+		{"components on regular menu", nil, []bundleComponentInput{{SlotID: 10, MenuID: 2}}, 1, 0, 0, "no components"},
+		{"valid picks", testMenuBundle(), []bundleComponentInput{{SlotID: "10", MenuID: "3"}, {SlotID: 11, MenuID: 5, VariantID: "21"}}, 1, 2, 2, ""},
+		{"merged picks", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 2}, {SlotID: 11, MenuID: 6}, {SlotID: 11, MenuID: 6}}, 1, 2, 3, ""},
+		{"missing slot", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 2}}, 1, 0, 0, "Please choose 1 for Drink"},
+		{"too many", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 2}, {SlotID: 10, MenuID: 3}, {SlotID: 11, MenuID: 6}}, 1, 0, 0, "at most 1 for Main"},
+		{"unknown slot", testMenuBundle(), []bundleComponentInput{{SlotID: 99, MenuID: 2}}, 1, 0, 0, "Choice not found"},
+		{"menu not in slot", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 5}}, 1, 0, 0, "Invalid choice"},
+		{"unavailable", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 4}}, 1, 0, 0, "not available"},
+		{"variant required", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 2}, {SlotID: 11, MenuID: 5}}, 1, 0, 0, "choose a variant"},
+		{"insufficient stock", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 3}, {SlotID: 11, MenuID: 6}}, 4, 0, 0, "Insufficient stock for Chicken"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, upcharge, err := resolveBundleComponents("Combo", tc.bundle, tc.inputs, tc.quantity)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tc.wantCount || upcharge != tc.wantUpcharge {
+				t.Fatalf("expected %d components and upcharge %v, got %+v and %v", tc.wantCount, tc.wantUpcharge, got, upcharge)
+			}
+		})
+	}
+
+	got, _, err := resolveBundleComponents("Combo", testMenuBundle(), []bundleComponentInput{{SlotID: 10, MenuID: 2}, {SlotID: 11, MenuID: 5, VariantID: 21}, {SlotID: 11, MenuID: 5, VariantID: 21}}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[1].Quantity != 2 || *got[1].VariantName != "Large" || got[1].ListPrice != 8 {
+		t.Fatalf("expected merged Large tea component, got %+v", got[1])
+	}
+}
+
+func TestMatchBundleOption(t *testing.T) {
+	variantA, variantB := int64(1), int64(2)
+	slot := &menuBundleSlot{Options: []menuBundleOption{
+		{ID: 1, MenuID: 5},
+		{ID: 2, MenuID: 5, VariantID: &variantA},
+		{ID: 3, MenuID: 6, VariantID: &variantB},
+	}}
+
+	cases := []struct {
+		name      string
+		menuID    int64
+		requested any
+		wantID    int64
+	}{
+		{"fixed variant wins", 5, "1", 2},
+		{"open option", 5, nil, 1},
+		{"open option for other variant", 5, 7, 1},
+		{"single fixed option", 6, nil, 3},
+		{"wrong fixed variant", 6, 1, 0},
+		{"unknown menu", 9, nil, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matchBundleOption(slot, tc.menuID, tc.requested)
+			if tc.wantID == 0 {
+				if got != nil {
+					t.Fatalf("expected no option, got %+v", got)
+				}
+				return
+			}
+			if got == nil || got.ID != tc.wantID {
+				t.Fatalf("expected option %d, got %+v", tc.wantID, got)
+			}
+		})
+	}
+}
+
+func TestAllocateBundleRevenue(t *testing.T) {
+	components := []orderItemComponent{
+		{MenuID: 2, Quantity: 1, ListPrice: 30, Upcharge: 2},
+		{MenuID: 5, Quantity: 2, ListPrice: 5},
+	}
+
+	cases := []struct {
+		name       string
+		revenue    float64
+		quantity   int32
+		components []orderItemComponent
+		method     string
+		want       []float64
+	}{
+		{"list price", 84, 2, components, bundleAllocationListPrice, []float64{64, 20}},
+		{"equal", 84, 2, components, bundleAllocationEqual, []float64{30.67, 53.33}},
+		{"bundle keeps revenue", 84, 2, components, bundleAllocationBundle, nil},
+		{"no components", 84, 2, nil, bundleAllocationListPrice, nil},
+		{"zero list prices fall back to quantity", 30, 1, []orderItemComponent{{Quantity: 1}, {Quantity: 2}}, bundleAllocationListPrice, []float64{10, 20}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := allocateBundleRevenue(tc.revenue, tc.quantity, tc.components, tc.method)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeMenuBundleInput(t *testing.T) {
+	count := func(v int32) *int32 { return &v }
+	upcharge := func(v float64) *float64 { return &v }
+
+	got, err := normalizeMenuBundleInput([]menuBundleSlotInput{
+		{Name: " Main ", Options: []menuBundleOptionInput{{MenuID: "2"}, {MenuID: 3, Upcharge: upcharge(1.256)}}},
+		{Name: "Sides", MinSelection: count(0), MaxSelection: count(2), Options: []menuBundleOptionInput{{MenuID: 4, VariantID: "9"}, {MenuID: 4}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Name != "Main" || got[0].MinSelection != 1 || got[0].MaxSelection != 1 || got[0].Options[1].Upcharge != 1.26 {
+		t.Fatalf("unexpected first slot: %+v", got[0])
+	}
+	if got[1].DisplayOrder != 1 || *got[1].Options[0].VariantID != 9 || got[1].Options[1].VariantID != nil {
+		t.Fatalf("unexpected second slot: %+v", got[1])
+	}
+
+	cases := []struct {
+		name   string
+		inputs []menuBundleSlotInput
+	}{
+		{"blank name", []menuBundleSlotInput{{Name: " ", Options: []menuBundleOptionInput{{MenuID: 1}}}}},
+		{"duplicate name", []menuBundleSlotInput{{Name: "Main", Options: []menuBundleOptionInput{{MenuID: 1}}}, {Name: "main", Options: []menuBundleOptionInput{{MenuID: 2}}}}},
+		{"no options", []menuBundleSlotInput{{Name: "Main"}}},
+		{"min above max", []menuBundleSlotInput{{Name: "Main", MinSelection: count(2), MaxSelection: count(1), Options: []menuBundleOptionInput{{MenuID: 1}}}}},
+		{"max too high", []menuBundleSlotInput{{Name: "Main", MaxSelection: count(menuBundleMaxSelection + 1), Options: []menuBundleOptionInput{{MenuID: 1}}}}},
+		{"duplicate option", []menuBundleSlotInput{{Name: "Main", Options: []menuBundleOptionInput{{MenuID: 1}, {MenuID: "1"}}}}},
+		{"invalid menu", []menuBundleSlotInput{{Name: "Main", Options: []menuBundleOptionInput{{MenuID: "x"}}}}},
+		{"negative upcharge", []menuBundleSlotInput{{Name: "Main", Options: []menuBundleOptionInput{{MenuID: 1, Upcharge: upcharge(-1)}}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := normalizeMenuBundleInput(tc.inputs); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestAttributeBundleRevenue(t *testing.T) {
+	menus := map[int64]*menuPerformanceRow{
+		1: {MenuID: 1, BundleAllocation: bundleAllocationEqual},
+		2: {MenuID: 2},
+	}
+	items := []orderItemSnapshot{
+		{ID: 100, MenuID: 1, MenuPrice: 20, Quantity: 2, Subtotal: 46},
+		{ID: 101, MenuID: 2, MenuPrice: 10, Quantity: 1, Subtotal: 10},
+	}
+	components := map[int64][]orderItemComponent{
+		100: {{MenuID: 2, Quantity: 1, ListPrice: 10}, {MenuID: 3, Quantity: 1, ListPrice: 5}},
+	}
+
+	got := attributeBundleRevenue(items, components, menus, "")
+	if len(got) != 4 {
+		t.Fatalf("expected bundle, two components and regular item, got %+v", got)
+	}
+	if got[0].Subtotal != 6 || got[0].Quantity != 2 || got[0].FromBundle {
+		t.Fatalf("expected bundle to keep addon revenue, got %+v", got[0])
+	}
+	if !got[1].FromBundle || got[1].Subtotal != 20 || got[1].Quantity != 2 || got[2].Subtotal != 20 {
+		t.Fatalf("unexpected components: %+v", got[1:3])
+	}
+
+	kept := attributeBundleRevenue(items, components, menus, bundleAllocationBundle)
+	if len(kept) != 2 || kept[0].Subtotal != 46 {
+		t.Fatalf("expected override to keep revenue on the bundle, got %+v", kept)
+	}
+}
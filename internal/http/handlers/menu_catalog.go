@@ -0,0 +1,1380 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"genfity-order-services/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// menuCatalog is a full copy of a merchant's menu content, used for drafts
+// and published versions. Stock levels, schedules and cost prices are
+// operational data and are not part of it, so publishing or rolling back
+// never touches them. Entities a draft adds carry negative IDs until they
+// are published. Variants, bundles and dietary attributes left null (as in
+// catalogs saved before they were part of it) are left as they are.
+type menuCatalog struct {
+	Categories      []catalogCategory      `json:"categories"`
+	AddonCategories []catalogAddonCategory `json:"addonCategories"`
+	Menus           []catalogMenu          `json:"menus"`
+}
+
+type catalogCategory struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	SortOrder   int32   `json:"sortOrder"`
+	IsActive    bool    `json:"isActive"`
+}
+
+type catalogAddonCategory struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	Description  *string            `json:"description"`
+	MinSelection int32              `json:"minSelection"`
+	MaxSelection *int32             `json:"maxSelection"`
+	IsActive     bool               `json:"isActive"`
+	Items        []catalogAddonItem `json:"items"`
+}
+
+type catalogAddonItem struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Description  *string `json:"description"`
+	Price        float64 `json:"price"`
+	InputType    string  `json:"inputType"`
+	DisplayOrder int32   `json:"displayOrder"`
+	IsActive     bool    `json:"isActive"`
+	TrackStock   bool    `json:"trackStock"`
+
+	Dietary *dietaryAttributes `json:"dietary"`
+}
+
+type catalogMenu struct {
+	ID              int64              `json:"id"`
+	Name            string             `json:"name"`
+	Description     *string            `json:"description"`
+	Price           float64            `json:"price"`
+	ImageURL        *string            `json:"imageUrl"`
+	ImageThumbURL   *string            `json:"imageThumbUrl"`
+	ImageThumbMeta  json.RawMessage    `json:"imageThumbMeta,omitempty"`
+	IsActive        bool               `json:"isActive"`
+	IsSpicy         bool               `json:"isSpicy"`
+	IsBestSeller    bool               `json:"isBestSeller"`
+	IsSignature     bool               `json:"isSignature"`
+	IsRecommended   bool               `json:"isRecommended"`
+	TrackStock      bool               `json:"trackStock"`
+	CategoryIDs     []int64            `json:"categoryIds"`
+	AddonCategories []catalogMenuAddon `json:"addonCategories"`
+
+	Variants []catalogVariant   `json:"variants"`
+	Bundle   *catalogBundle     `json:"bundle"`
+	Dietary  *dietaryAttributes `json:"dietary"`
+}
+
+// catalogVariant is a menu variant without its stock settings.
+type catalogVariant struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	SKU          *string `json:"sku"`
+	Price        float64 `json:"price"`
+	DisplayOrder int32   `json:"displayOrder"`
+	IsActive     bool    `json:"isActive"`
+	TrackStock   bool    `json:"trackStock"`
+}
+
+// catalogBundle holds the slots of a bundle menu; regular menus have none.
+// Slots are ordered as listed.
+type catalogBundle struct {
+	RevenueAllocation string              `json:"revenueAllocation"`
+	Slots             []catalogBundleSlot `json:"slots"`
+}
+
+type catalogBundleSlot struct {
+	Name         string                `json:"name"`
+	MinSelection int32                 `json:"minSelection"`
+	MaxSelection int32                 `json:"maxSelection"`
+	Options      []catalogBundleOption `json:"options"`
+}
+
+type catalogBundleOption struct {
+	MenuID    int64   `json:"menuId"`
+	VariantID *int64  `json:"variantId"`
+	Upcharge  float64 `json:"upcharge"`
+}
+
+type catalogMenuAddon struct {
+	AddonCategoryID int64 `json:"addonCategoryId"`
+	DisplayOrder    int32 `json:"displayOrder"`
+	IsRequired      bool  `json:"isRequired"`
+}
+
+type catalogQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// loadMenuCatalog reads the merchant's live (not deleted) menu content.
+func loadMenuCatalog(ctx context.Context, q catalogQuerier, merchantID int64) (menuCatalog, error) {
+	catalog := menuCatalog{
+		Categories:      make([]catalogCategory, 0),
+		AddonCategories: make([]catalogAddonCategory, 0),
+		Menus:           make([]catalogMenu, 0),
+	}
+
+	rows, err := q.Query(ctx, `
+		select id, name, description, sort_order, is_active
+		from menu_categories
+		where merchant_id = $1 and deleted_at is null
+		order by sort_order, id
+	`, merchantID)
+	if err != nil {
+		return catalog, err
+	}
+	for rows.Next() {
+		var c catalogCategory
+		var description pgtype.Text
+		if err := rows.Scan(&c.ID, &c.Name, &description, &c.SortOrder, &c.IsActive); err != nil {
+			rows.Close()
+			return catalog, err
+		}
+		c.Description = ptrString(description)
+		catalog.Categories = append(catalog.Categories, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return catalog, err
+	}
+
+	rows, err = q.Query(ctx, `
+		select id, name, description, min_selection, max_selection, is_active
+		from addon_categories
+		where merchant_id = $1 and deleted_at is null
+		order by id
+	`, merchantID)
+	if err != nil {
+		return catalog, err
+	}
+	addonIndex := make(map[int64]int)
+	for rows.Next() {
+		var c catalogAddonCategory
+		var description pgtype.Text
+		var maxSelection pgtype.Int4
+		if err := rows.Scan(&c.ID, &c.Name, &description, &c.MinSelection, &maxSelection, &c.IsActive); err != nil {
+			rows.Close()
+			return catalog, err
+		}
+		c.Description = ptrString(description)
+		if maxSelection.Valid {
+			v := maxSelection.Int32
+			c.MaxSelection = &v
+		}
+		c.Items = make([]catalogAddonItem, 0)
+		addonIndex[c.ID] = len(catalog.AddonCategories)
+		catalog.AddonCategories = append(catalog.AddonCategories, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return catalog, err
+	}
+
+	rows, err = q.Query(ctx, `
+		select ai.id, ai.addon_category_id, ai.name, ai.description, ai.price, ai.input_type,
+			ai.display_order, ai.is_active, ai.track_stock
+		from addon_items ai
+		join addon_categories ac on ac.id = ai.addon_category_id
+		where ac.merchant_id = $1 and ac.deleted_at is null and ai.deleted_at is null
+		order by ai.addon_category_id, ai.display_order, ai.id
+	`, merchantID)
+	if err != nil {
+		return catalog, err
+	}
+	for rows.Next() {
+		var (
+			item        catalogAddonItem
+			categoryID  int64
+			description pgtype.Text
+			price       pgtype.Numeric
+		)
+		if err := rows.Scan(&item.ID, &categoryID, &item.Name, &description, &price, &item.InputType,
+			&item.DisplayOrder, &item.IsActive, &item.TrackStock); err != nil {
+			rows.Close()
+			return catalog, err
+		}
+		item.Description = ptrString(description)
+		item.Price = utils.NumericToFloat64(price)
+		if idx, ok := addonIndex[categoryID]; ok {
+			catalog.AddonCategories[idx].Items = append(catalog.AddonCategories[idx].Items, item)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return catalog, err
+	}
+
+	rows, err = q.Query(ctx, `
+		select
+			m.id, m.name, m.description, m.price, m.image_url, m.image_thumb_url, m.image_thumb_meta,
+			m.is_active, m.is_spicy, m.is_best_seller, m.is_signature, m.is_recommended, m.track_stock,
+			coalesce((
+				select array_agg(mci.category_id order by (mci.category_id = m.category_id) desc, mci.category_id)
+				from menu_category_items mci
+				join menu_categories mc on mc.id = mci.category_id and mc.deleted_at is null
+				where mci.menu_id = m.id
+			), '{}')
+		from menus m
+		where m.merchant_id = $1 and m.deleted_at is null
+		order by m.id
+	`, merchantID)
+	if err != nil {
+		return catalog, err
+	}
+	menuIndex := make(map[int64]int)
+	for rows.Next() {
+		var (
+			m           catalogMenu
+			description pgtype.Text
+			price       pgtype.Numeric
+			imageURL    pgtype.Text
+			thumbURL    pgtype.Text
+			thumbMeta   []byte
+		)
+		if err := rows.Scan(&m.ID, &m.Name, &description, &price, &imageURL, &thumbURL, &thumbMeta,
+			&m.IsActive, &m.IsSpicy, &m.IsBestSeller, &m.IsSignature, &m.IsRecommended, &m.TrackStock,
+			&m.CategoryIDs); err != nil {
+			rows.Close()
+			return catalog, err
+		}
+		m.Description = ptrString(description)
+		m.Price = utils.NumericToFloat64(price)
+		m.ImageURL = ptrString(imageURL)
+		m.ImageThumbURL = ptrString(thumbURL)
+		if len(thumbMeta) > 0 {
+			m.ImageThumbMeta = json.RawMessage(thumbMeta)
+		}
+		m.AddonCategories = make([]catalogMenuAddon, 0)
+		menuIndex[m.ID] = len(catalog.Menus)
+		catalog.Menus = append(catalog.Menus, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return catalog, err
+	}
+
+	rows, err = q.Query(ctx, `
+		select mac.menu_id, mac.addon_category_id, mac.display_order, mac.is_required
+		from menu_addon_categories mac
+		join menus m on m.id = mac.menu_id
+		join addon_categories ac on ac.id = mac.addon_category_id and ac.deleted_at is null
+		where m.merchant_id = $1 and m.deleted_at is null
+		order by mac.menu_id, mac.display_order, mac.addon_category_id
+	`, merchantID)
+	if err != nil {
+		return catalog, err
+	}
+	for rows.Next() {
+		var menuID int64
+		var link catalogMenuAddon
+		if err := rows.Scan(&menuID, &link.AddonCategoryID, &link.DisplayOrder, &link.IsRequired); err != nil {
+			rows.Close()
+			return catalog, err
+		}
+		if idx, ok := menuIndex[menuID]; ok {
+			catalog.Menus[idx].AddonCategories = append(catalog.Menus[idx].AddonCategories, link)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return catalog, err
+	}
+
+	if err := loadCatalogVariants(ctx, q, merchantID, &catalog, menuIndex); err != nil {
+		return catalog, err
+	}
+	if err := loadCatalogBundles(ctx, q, merchantID, &catalog, menuIndex); err != nil {
+		return catalog, err
+	}
+	return catalog, loadCatalogDietary(ctx, q, merchantID, &catalog)
+}
+
+func loadCatalogVariants(ctx context.Context, q catalogQuerier, merchantID int64, catalog *menuCatalog, menuIndex map[int64]int) error {
+	for i := range catalog.Menus {
+		catalog.Menus[i].Variants = make([]catalogVariant, 0)
+	}
+	rows, err := q.Query(ctx, `
+		select id, menu_id, name, sku, price, display_order, is_active, track_stock
+		from menu_variants
+		where merchant_id = $1 and deleted_at is null
+		order by menu_id, display_order, id
+	`, merchantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			v      catalogVariant
+			menuID int64
+			sku    pgtype.Text
+			price  pgtype.Numeric
+		)
+		if err := rows.Scan(&v.ID, &menuID, &v.Name, &sku, &price, &v.DisplayOrder, &v.IsActive, &v.TrackStock); err != nil {
+			return err
+		}
+		v.SKU = ptrString(sku)
+		v.Price = utils.NumericToFloat64(price)
+		if idx, ok := menuIndex[menuID]; ok {
+			catalog.Menus[idx].Variants = append(catalog.Menus[idx].Variants, v)
+		}
+	}
+	return rows.Err()
+}
+
+// loadCatalogBundles reads bundle slots. Options pointing at deleted menus
+// or variants are dropped, and so are slots left without options, so the
+// catalog only references its own entities.
+func loadCatalogBundles(ctx context.Context, q catalogQuerier, merchantID int64, catalog *menuCatalog, menuIndex map[int64]int) error {
+	isBundle := make(map[int64]bool)
+	for i := range catalog.Menus {
+		catalog.Menus[i].Bundle = &catalogBundle{RevenueAllocation: bundleAllocationListPrice, Slots: make([]catalogBundleSlot, 0)}
+	}
+	rows, err := q.Query(ctx, `
+		select menu_id, is_bundle, revenue_allocation from menu_bundle_settings where merchant_id = $1
+	`, merchantID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var menuID int64
+		var bundle bool
+		var allocation string
+		if err := rows.Scan(&menuID, &bundle, &allocation); err != nil {
+			rows.Close()
+			return err
+		}
+		if idx, ok := menuIndex[menuID]; ok {
+			catalog.Menus[idx].Bundle.RevenueAllocation = allocation
+			isBundle[menuID] = bundle
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	variantMenus := make(map[int64]int64)
+	for _, m := range catalog.Menus {
+		for _, v := range m.Variants {
+			variantMenus[v.ID] = m.ID
+		}
+	}
+	rows, err = q.Query(ctx, `
+		select s.id, s.bundle_menu_id, s.name, s.min_selection, s.max_selection,
+			o.menu_id, o.variant_id, o.upcharge
+		from menu_bundle_slots s
+		join menu_bundle_slot_options o on o.slot_id = s.id
+		where s.merchant_id = $1
+		order by s.bundle_menu_id, s.display_order, s.id, o.display_order, o.id
+	`, merchantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	lastSlotID := int64(0)
+	for rows.Next() {
+		var (
+			slotID, bundleMenuID int64
+			slot                 catalogBundleSlot
+			option               catalogBundleOption
+			variantID            pgtype.Int8
+			upcharge             pgtype.Numeric
+		)
+		if err := rows.Scan(&slotID, &bundleMenuID, &slot.Name, &slot.MinSelection, &slot.MaxSelection,
+			&option.MenuID, &variantID, &upcharge); err != nil {
+			return err
+		}
+		idx, ok := menuIndex[bundleMenuID]
+		if !ok || !isBundle[bundleMenuID] {
+			continue
+		}
+		if _, ok := menuIndex[option.MenuID]; !ok {
+			continue
+		}
+		if variantID.Valid {
+			if variantMenus[variantID.Int64] != option.MenuID {
+				continue
+			}
+			id := variantID.Int64
+			option.VariantID = &id
+		}
+		option.Upcharge = utils.NumericToFloat64(upcharge)
+		bundle := catalog.Menus[idx].Bundle
+		if slotID != lastSlotID {
+			lastSlotID = slotID
+			slot.Options = make([]catalogBundleOption, 0)
+			bundle.Slots = append(bundle.Slots, slot)
+		}
+		last := &bundle.Slots[len(bundle.Slots)-1]
+		last.Options = append(last.Options, option)
+	}
+	return rows.Err()
+}
+
+func loadCatalogDietary(ctx context.Context, q catalogQuerier, merchantID int64, catalog *menuCatalog) error {
+	byTable := make(map[string]map[int64]dietaryAttributes, 2)
+	for _, table := range []string{dietaryTableMenus, dietaryTableAddonItems} {
+		key := dietaryKeyColumn(table)
+		rows, err := q.Query(ctx, `
+			select `+key+`, allergens, dietary_tags, spicy_level, calories, nutrition
+			from `+table+`
+			where merchant_id = $1
+		`, merchantID)
+		if err != nil {
+			return err
+		}
+		attributes, err := scanDietaryAttributes(rows)
+		if err != nil {
+			return err
+		}
+		byTable[table] = attributes
+	}
+	for i := range catalog.Menus {
+		attrs := dietaryFor(byTable[dietaryTableMenus], catalog.Menus[i].ID)
+		catalog.Menus[i].Dietary = &attrs
+	}
+	for i := range catalog.AddonCategories {
+		items := catalog.AddonCategories[i].Items
+		for j := range items {
+			attrs := dietaryFor(byTable[dietaryTableAddonItems], items[j].ID)
+			items[j].Dietary = &attrs
+		}
+	}
+	return nil
+}
+
+// validateMenuCatalog checks a submitted draft catalog: names and prices are
+// set, IDs are unique per kind and every link points at an entity in the
+// same document.
+func validateMenuCatalog(catalog *menuCatalog) error {
+	if catalog.Categories == nil {
+		catalog.Categories = []catalogCategory{}
+	}
+	if catalog.AddonCategories == nil {
+		catalog.AddonCategories = []catalogAddonCategory{}
+	}
+	if catalog.Menus == nil {
+		catalog.Menus = []catalogMenu{}
+	}
+
+	categoryIDs := make(map[int64]bool)
+	for i := range catalog.Categories {
+		c := &catalog.Categories[i]
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			return errors.New("Category name is required")
+		}
+		if c.ID == 0 || categoryIDs[c.ID] {
+			return fmt.Errorf("Category %q needs a unique id (use negative ids for new categories)", c.Name)
+		}
+		categoryIDs[c.ID] = true
+	}
+
+	addonIDs := make(map[int64]bool)
+	itemIDs := make(map[int64]bool)
+	for i := range catalog.AddonCategories {
+		c := &catalog.AddonCategories[i]
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			return errors.New("Addon category name is required")
+		}
+		if c.ID == 0 || addonIDs[c.ID] {
+			return fmt.Errorf("Addon category %q needs a unique id (use negative ids for new addon categories)", c.Name)
+		}
+		addonIDs[c.ID] = true
+		if c.MinSelection < 0 || (c.MaxSelection != nil && *c.MaxSelection < c.MinSelection) {
+			return fmt.Errorf("Addon category %q has an invalid selection range", c.Name)
+		}
+		if c.Items == nil {
+			c.Items = []catalogAddonItem{}
+		}
+		for j := range c.Items {
+			item := &c.Items[j]
+			item.Name = strings.TrimSpace(item.Name)
+			if item.Name == "" {
+				return fmt.Errorf("Addon item name is required in %q", c.Name)
+			}
+			if item.ID == 0 || itemIDs[item.ID] {
+				return fmt.Errorf("Addon item %q needs a unique id (use negative ids for new items)", item.Name)
+			}
+			itemIDs[item.ID] = true
+			if item.Price < 0 {
+				return fmt.Errorf("Addon item %q has a negative price", item.Name)
+			}
+			item.InputType = strings.TrimSpace(item.InputType)
+			if item.InputType == "" {
+				item.InputType = "SELECT"
+			}
+			if item.Dietary != nil {
+				attrs, err := normalizeDietaryAttributes(*item.Dietary)
+				if err != nil {
+					return fmt.Errorf("Addon item %q: %v", item.Name, err)
+				}
+				item.Dietary = &attrs
+			}
+		}
+	}
+
+	menuIDs := make(map[int64]bool)
+	variantIDs := make(map[int64]bool)
+	skus := make(map[string]bool)
+	for i := range catalog.Menus {
+		m := &catalog.Menus[i]
+		m.Name = strings.TrimSpace(m.Name)
+		if m.Name == "" {
+			return errors.New("Menu name is required")
+		}
+		if m.ID == 0 || menuIDs[m.ID] {
+			return fmt.Errorf("Menu %q needs a unique id (use negative ids for new menus)", m.Name)
+		}
+		menuIDs[m.ID] = true
+		if m.Price < 0 {
+			return fmt.Errorf("Menu %q has a negative price", m.Name)
+		}
+		if m.CategoryIDs == nil {
+			m.CategoryIDs = []int64{}
+		}
+		for _, id := range m.CategoryIDs {
+			if !categoryIDs[id] {
+				return fmt.Errorf("Menu %q references unknown category %d", m.Name, id)
+			}
+		}
+		if m.AddonCategories == nil {
+			m.AddonCategories = []catalogMenuAddon{}
+		}
+		for _, link := range m.AddonCategories {
+			if !addonIDs[link.AddonCategoryID] {
+				return fmt.Errorf("Menu %q references unknown addon category %d", m.Name, link.AddonCategoryID)
+			}
+		}
+		if err := validateCatalogVariants(m, variantIDs, skus); err != nil {
+			return err
+		}
+		if m.Dietary != nil {
+			attrs, err := normalizeDietaryAttributes(*m.Dietary)
+			if err != nil {
+				return fmt.Errorf("Menu %q: %v", m.Name, err)
+			}
+			m.Dietary = &attrs
+		}
+	}
+	return validateCatalogBundles(catalog.Menus)
+}
+
+// validateCatalogVariants applies the variant editor's rules to one menu.
+// SKUs are unique per merchant, so skus is shared by all menus.
+func validateCatalogVariants(m *catalogMenu, ids map[int64]bool, skus map[string]bool) error {
+	if len(m.Variants) > menuVariantMaxPerMenu {
+		return fmt.Errorf("Menu %q can have at most %d variants", m.Name, menuVariantMaxPerMenu)
+	}
+	names := make(map[string]bool, len(m.Variants))
+	for i := range m.Variants {
+		v := &m.Variants[i]
+		v.Name = strings.TrimSpace(v.Name)
+		if v.Name == "" {
+			return fmt.Errorf("Variant name is required in %q", m.Name)
+		}
+		if len(v.Name) > menuVariantMaxNameLength {
+			return fmt.Errorf("Variant name must be at most %d characters", menuVariantMaxNameLength)
+		}
+		if v.ID == 0 || ids[v.ID] {
+			return fmt.Errorf("Variant %q needs a unique id (use negative ids for new variants)", v.Name)
+		}
+		ids[v.ID] = true
+		if names[strings.ToLower(v.Name)] {
+			return fmt.Errorf("Variant name %q is used twice in %q", v.Name, m.Name)
+		}
+		names[strings.ToLower(v.Name)] = true
+		if v.SKU != nil {
+			sku := strings.TrimSpace(*v.SKU)
+			if len(sku) > menuVariantMaxSKULength {
+				return fmt.Errorf("SKU must be at most %d characters", menuVariantMaxSKULength)
+			}
+			v.SKU = nil
+			if sku != "" {
+				if skus[strings.ToLower(sku)] {
+					return fmt.Errorf("SKU %q is used twice", sku)
+				}
+				skus[strings.ToLower(sku)] = true
+				v.SKU = &sku
+			}
+		}
+		if math.IsNaN(v.Price) || math.IsInf(v.Price, 0) || v.Price < 0 {
+			return fmt.Errorf("Variant %q needs a valid price", v.Name)
+		}
+		v.Price = round2(v.Price)
+	}
+	return nil
+}
+
+// validateCatalogBundles applies the bundle editor's rules. Options must
+// point at a non-bundle menu of the catalog and, when fixed, at one of that
+// menu's variants. Fixed variants of menus whose variants the catalog
+// leaves unchanged cannot be checked here.
+func validateCatalogBundles(menus []catalogMenu) error {
+	byID := make(map[int64]*catalogMenu, len(menus))
+	for i := range menus {
+		byID[menus[i].ID] = &menus[i]
+	}
+	for i := range menus {
+		m := &menus[i]
+		if m.Bundle == nil {
+			continue
+		}
+		b := m.Bundle
+		b.RevenueAllocation = strings.ToUpper(strings.TrimSpace(b.RevenueAllocation))
+		if b.RevenueAllocation == "" {
+			b.RevenueAllocation = bundleAllocationListPrice
+		}
+		if !isValidBundleAllocation(b.RevenueAllocation) {
+			return fmt.Errorf("Bundle %q: revenueAllocation must be LIST_PRICE, EQUAL or BUNDLE", m.Name)
+		}
+		if b.Slots == nil {
+			b.Slots = []catalogBundleSlot{}
+		}
+		if len(b.Slots) > menuBundleMaxSlots {
+			return fmt.Errorf("Bundle %q can have at most %d slots", m.Name, menuBundleMaxSlots)
+		}
+		names := make(map[string]bool, len(b.Slots))
+		for j := range b.Slots {
+			slot := &b.Slots[j]
+			slot.Name = strings.TrimSpace(slot.Name)
+			if slot.Name == "" {
+				return fmt.Errorf("Slot name is required in %q", m.Name)
+			}
+			if len(slot.Name) > menuVariantMaxNameLength {
+				return fmt.Errorf("Slot name must be at most %d characters", menuVariantMaxNameLength)
+			}
+			if names[strings.ToLower(slot.Name)] {
+				return fmt.Errorf("Slot name %q is used twice in %q", slot.Name, m.Name)
+			}
+			names[strings.ToLower(slot.Name)] = true
+			if slot.MinSelection < 0 || slot.MaxSelection < 1 || slot.MaxSelection > menuBundleMaxSelection || slot.MinSelection > slot.MaxSelection {
+				return fmt.Errorf("Slot %q has an invalid selection range", slot.Name)
+			}
+			if len(slot.Options) == 0 {
+				return fmt.Errorf("Slot %q needs at least one option", slot.Name)
+			}
+			if len(slot.Options) > menuBundleMaxOptionsPerSlot {
+				return fmt.Errorf("Slot %q can have at most %d options", slot.Name, menuBundleMaxOptionsPerSlot)
+			}
+			seen := make(map[string]bool, len(slot.Options))
+			for k := range slot.Options {
+				option := &slot.Options[k]
+				target, ok := byID[option.MenuID]
+				if !ok {
+					return fmt.Errorf("Slot %q references unknown menu %d", slot.Name, option.MenuID)
+				}
+				if target.ID == m.ID || (target.Bundle != nil && len(target.Bundle.Slots) > 0) {
+					return fmt.Errorf("Slot %q cannot include a bundle", slot.Name)
+				}
+				key := fmt.Sprintf("%d:", option.MenuID)
+				if option.VariantID != nil {
+					key += fmt.Sprint(*option.VariantID)
+					if target.Variants != nil && !catalogMenuHasVariant(target, *option.VariantID) {
+						return fmt.Errorf("Variant %d in slot %q not found on menu %q", *option.VariantID, slot.Name, target.Name)
+					}
+				}
+				if seen[key] {
+					return fmt.Errorf("Slot %q lists the same option twice", slot.Name)
+				}
+				seen[key] = true
+				if math.IsNaN(option.Upcharge) || math.IsInf(option.Upcharge, 0) || option.Upcharge < 0 {
+					return fmt.Errorf("Slot %q has an invalid upcharge", slot.Name)
+				}
+				option.Upcharge = round2(option.Upcharge)
+			}
+		}
+	}
+	return nil
+}
+
+func catalogMenuHasVariant(m *catalogMenu, variantID int64) bool {
+	for _, v := range m.Variants {
+		if v.ID == variantID {
+			return true
+		}
+	}
+	return false
+}
+
+type catalogEntityChange struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+type catalogEntityDiff struct {
+	Added   []catalogEntityChange `json:"added"`
+	Removed []catalogEntityChange `json:"removed"`
+	Changed []catalogEntityChange `json:"changed"`
+}
+
+type menuCatalogDiff struct {
+	Categories      catalogEntityDiff `json:"categories"`
+	AddonCategories catalogEntityDiff `json:"addonCategories"`
+	AddonItems      catalogEntityDiff `json:"addonItems"`
+	Menus           catalogEntityDiff `json:"menus"`
+}
+
+func (d menuCatalogDiff) isEmpty() bool {
+	for _, e := range []catalogEntityDiff{d.Categories, d.AddonCategories, d.AddonItems, d.Menus} {
+		if len(e.Added)+len(e.Removed)+len(e.Changed) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type catalogDiffEntity struct {
+	id     int64
+	name   string
+	fields map[string]any
+}
+
+// diffMenuCatalogs lists what changes going from one catalog to another.
+// Entities are matched by ID; changed entries name the JSON fields that
+// differ.
+func diffMenuCatalogs(from, to menuCatalog) menuCatalogDiff {
+	return menuCatalogDiff{
+		Categories:      diffCatalogEntities(catalogCategoryEntities(from), catalogCategoryEntities(to)),
+		AddonCategories: diffCatalogEntities(catalogAddonCategoryEntities(from), catalogAddonCategoryEntities(to)),
+		AddonItems:      diffCatalogEntities(catalogAddonItemEntities(from), catalogAddonItemEntities(to)),
+		Menus:           diffCatalogEntities(catalogMenuEntities(from), catalogMenuEntities(to)),
+	}
+}
+
+func catalogCategoryEntities(c menuCatalog) []catalogDiffEntity {
+	out := make([]catalogDiffEntity, 0, len(c.Categories))
+	for _, cat := range c.Categories {
+		out = append(out, catalogDiffEntity{id: cat.ID, name: cat.Name, fields: map[string]any{
+			"name": cat.Name, "description": cat.Description, "sortOrder": cat.SortOrder, "isActive": cat.IsActive,
+		}})
+	}
+	return out
+}
+
+func catalogAddonCategoryEntities(c menuCatalog) []catalogDiffEntity {
+	out := make([]catalogDiffEntity, 0, len(c.AddonCategories))
+	for _, cat := range c.AddonCategories {
+		out = append(out, catalogDiffEntity{id: cat.ID, name: cat.Name, fields: map[string]any{
+			"name": cat.Name, "description": cat.Description, "minSelection": cat.MinSelection,
+			"maxSelection": cat.MaxSelection, "isActive": cat.IsActive,
+		}})
+	}
+	return out
+}
+
+func catalogAddonItemEntities(c menuCatalog) []catalogDiffEntity {
+	out := make([]catalogDiffEntity, 0)
+	for _, cat := range c.AddonCategories {
+		for _, item := range cat.Items {
+			fields := map[string]any{
+				"addonCategoryId": cat.ID, "name": item.Name, "description": item.Description, "price": item.Price,
+				"inputType": item.InputType, "displayOrder": item.DisplayOrder, "isActive": item.IsActive,
+				"trackStock": item.TrackStock,
+			}
+			if item.Dietary != nil {
+				fields["dietary"] = item.Dietary
+			}
+			out = append(out, catalogDiffEntity{id: item.ID, name: item.Name, fields: fields})
+		}
+	}
+	return out
+}
+
+func catalogMenuEntities(c menuCatalog) []catalogDiffEntity {
+	out := make([]catalogDiffEntity, 0, len(c.Menus))
+	for _, m := range c.Menus {
+		fields := map[string]any{
+			"name": m.Name, "description": m.Description, "price": m.Price, "imageUrl": m.ImageURL,
+			"isActive": m.IsActive, "isSpicy": m.IsSpicy, "isBestSeller": m.IsBestSeller,
+			"isSignature": m.IsSignature, "isRecommended": m.IsRecommended, "trackStock": m.TrackStock,
+			"categoryIds": m.CategoryIDs, "addonCategories": m.AddonCategories,
+		}
+		// Fields a catalog leaves unchanged are not compared.
+		if m.Variants != nil {
+			fields["variants"] = m.Variants
+		}
+		if m.Bundle != nil {
+			fields["bundle"] = m.Bundle
+		}
+		if m.Dietary != nil {
+			fields["dietary"] = m.Dietary
+		}
+		out = append(out, catalogDiffEntity{id: m.ID, name: m.Name, fields: fields})
+	}
+	return out
+}
+
+func diffCatalogEntities(from, to []catalogDiffEntity) catalogEntityDiff {
+	diff := catalogEntityDiff{
+		Added:   make([]catalogEntityChange, 0),
+		Removed: make([]catalogEntityChange, 0),
+		Changed: make([]catalogEntityChange, 0),
+	}
+	before := make(map[int64]catalogDiffEntity, len(from))
+	for _, e := range from {
+		before[e.id] = e
+	}
+	seen := make(map[int64]bool, len(to))
+	for _, e := range to {
+		seen[e.id] = true
+		prev, ok := before[e.id]
+		if !ok {
+			diff.Added = append(diff.Added, catalogEntityChange{ID: e.id, Name: e.name})
+			continue
+		}
+		fields := make([]string, 0)
+		for key, value := range e.fields {
+			if !catalogValuesEqual(prev.fields[key], value) {
+				fields = append(fields, key)
+			}
+		}
+		if len(fields) > 0 {
+			sort.Strings(fields)
+			diff.Changed = append(diff.Changed, catalogEntityChange{ID: e.id, Name: e.name, Fields: fields})
+		}
+	}
+	for _, e := range from {
+		if !seen[e.id] {
+			diff.Removed = append(diff.Removed, catalogEntityChange{ID: e.id, Name: e.name})
+		}
+	}
+	return diff
+}
+
+// catalogValuesEqual compares through JSON so nil and empty slices, and
+// pointers to equal values, count as equal.
+func catalogValuesEqual(a, b any) bool {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+	if string(left) == "[]" {
+		left = []byte("null")
+	}
+	if string(right) == "[]" {
+		right = []byte("null")
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// renderMenuCatalogPreview builds the menusByCategory payload of the public
+// menu from a catalog, so a draft can be previewed as customers would see
+// it. Promo prices and stock levels are live data and are left out.
+func renderMenuCatalogPreview(catalog menuCatalog) []map[string]any {
+	categories := make([]catalogCategory, 0, len(catalog.Categories))
+	for _, c := range catalog.Categories {
+		if c.IsActive {
+			categories = append(categories, c)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].SortOrder < categories[j].SortOrder })
+
+	addons := make(map[int64]catalogAddonCategory, len(catalog.AddonCategories))
+	for _, c := range catalog.AddonCategories {
+		if c.IsActive {
+			addons[c.ID] = c
+		}
+	}
+
+	result := make([]map[string]any, 0, len(categories))
+	for _, category := range categories {
+		menus := make([]map[string]any, 0)
+		for _, m := range catalog.Menus {
+			if !m.IsActive || !containsInt64(m.CategoryIDs, category.ID) {
+				continue
+			}
+			links := append([]catalogMenuAddon(nil), m.AddonCategories...)
+			sort.SliceStable(links, func(i, j int) bool { return links[i].DisplayOrder < links[j].DisplayOrder })
+			addonPayloads := make([]map[string]any, 0, len(links))
+			for _, link := range links {
+				addon, ok := addons[link.AddonCategoryID]
+				if !ok {
+					continue
+				}
+				items := make([]map[string]any, 0, len(addon.Items))
+				sorted := append([]catalogAddonItem(nil), addon.Items...)
+				sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DisplayOrder < sorted[j].DisplayOrder })
+				for _, item := range sorted {
+					if !item.IsActive {
+						continue
+					}
+					items = append(items, map[string]any{
+						"id":           item.ID,
+						"name":         item.Name,
+						"description":  item.Description,
+						"price":        item.Price,
+						"inputType":    item.InputType,
+						"displayOrder": item.DisplayOrder,
+						"trackStock":   item.TrackStock,
+						"dietary":      catalogDietaryPayload(item.Dietary),
+					})
+				}
+				addonPayloads = append(addonPayloads, map[string]any{
+					"id":           addon.ID,
+					"name":         addon.Name,
+					"description":  addon.Description,
+					"minSelection": addon.MinSelection,
+					"maxSelection": addon.MaxSelection,
+					"isRequired":   link.IsRequired,
+					"displayOrder": link.DisplayOrder,
+					"addonItems":   items,
+				})
+			}
+
+			var thumbMeta any
+			if len(m.ImageThumbMeta) > 0 {
+				_ = json.Unmarshal(m.ImageThumbMeta, &thumbMeta)
+			}
+			menus = append(menus, map[string]any{
+				"id":              m.ID,
+				"name":            m.Name,
+				"description":     m.Description,
+				"price":           m.Price,
+				"imageUrl":        m.ImageURL,
+				"imageThumbUrl":   m.ImageThumbURL,
+				"imageThumbMeta":  thumbMeta,
+				"isActive":        m.IsActive,
+				"isSpicy":         m.IsSpicy,
+				"isBestSeller":    m.IsBestSeller,
+				"isSignature":     m.IsSignature,
+				"isRecommended":   m.IsRecommended,
+				"trackStock":      m.TrackStock,
+				"variants":        catalogVariantPayloads(m.Variants),
+				"bundle":          catalogBundlePayload(m.Bundle),
+				"dietary":         catalogDietaryPayload(m.Dietary),
+				"addonCategories": addonPayloads,
+			})
+		}
+		result = append(result, map[string]any{
+			"category": map[string]any{
+				"id":          category.ID,
+				"name":        category.Name,
+				"description": category.Description,
+				"sortOrder":   category.SortOrder,
+			},
+			"menus": menus,
+		})
+	}
+	return result
+}
+
+// catalogVariantPayloads lists the active variants, as the public menu does.
+func catalogVariantPayloads(variants []catalogVariant) []catalogVariant {
+	out := make([]catalogVariant, 0, len(variants))
+	for _, v := range variants {
+		if v.IsActive {
+			out = append(out, v)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DisplayOrder < out[j].DisplayOrder })
+	return out
+}
+
+func catalogBundlePayload(bundle *catalogBundle) *catalogBundle {
+	if bundle == nil || len(bundle.Slots) == 0 {
+		return nil
+	}
+	return bundle
+}
+
+func catalogDietaryPayload(attrs *dietaryAttributes) dietaryAttributes {
+	var out dietaryAttributes
+	if attrs != nil {
+		out = *attrs
+	}
+	if out.Allergens == nil {
+		out.Allergens = []string{}
+	}
+	if out.DietaryTags == nil {
+		out.DietaryTags = []string{}
+	}
+	return out
+}
+
+// applyMenuCatalogTx makes the live tables match the catalog: entities are
+// updated (and restored if soft-deleted), entities with unknown or negative
+// IDs are created, and live entities missing from the catalog are
+// soft-deleted. Links of every menu in the catalog are replaced, and so are
+// its variants, bundle slots and dietary attributes unless they are null.
+func applyMenuCatalogTx(ctx context.Context, tx pgx.Tx, merchantID int64, userID *int64, catalog menuCatalog) error {
+	existingIDs := func(query string) (map[int64]bool, error) {
+		rows, err := tx.Query(ctx, query, merchantID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		ids := make(map[int64]bool)
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids[id] = true
+		}
+		return ids, rows.Err()
+	}
+
+	liveCategories, err := existingIDs(`select id from menu_categories where merchant_id = $1`)
+	if err != nil {
+		return err
+	}
+	categoryIDs := make(map[int64]int64, len(catalog.Categories))
+	for _, c := range catalog.Categories {
+		if c.ID > 0 && liveCategories[c.ID] {
+			if _, err := tx.Exec(ctx, `
+				update menu_categories set
+					name = $3, description = $4, sort_order = $5, is_active = $6,
+					restored_at = case when deleted_at is not null then now() else restored_at end,
+					restored_by_user_id = case when deleted_at is not null then $7 else restored_by_user_id end,
+					deleted_at = null, deleted_by_user_id = null,
+					updated_at = now(), updated_by_user_id = $7
+				where id = $1 and merchant_id = $2
+			`, c.ID, merchantID, c.Name, c.Description, c.SortOrder, c.IsActive, userID); err != nil {
+				return err
+			}
+			categoryIDs[c.ID] = c.ID
+			continue
+		}
+		var newID int64
+		if err := tx.QueryRow(ctx, `
+			insert into menu_categories (merchant_id, name, description, sort_order, is_active, created_at, updated_at, created_by_user_id)
+			values ($1, $2, $3, $4, $5, now(), now(), $6)
+			returning id
+		`, merchantID, c.Name, c.Description, c.SortOrder, c.IsActive, userID).Scan(&newID); err != nil {
+			return err
+		}
+		categoryIDs[c.ID] = newID
+	}
+	if _, err := tx.Exec(ctx, `
+		update menu_categories set deleted_at = now(), deleted_by_user_id = $3
+		where merchant_id = $1 and deleted_at is null and not (id = any($2))
+	`, merchantID, mapValues(categoryIDs), userID); err != nil {
+		return err
+	}
+
+	liveAddons, err := existingIDs(`select id from addon_categories where merchant_id = $1`)
+	if err != nil {
+		return err
+	}
+	liveItems, err := existingIDs(`
+		select ai.id from addon_items ai
+		join addon_categories ac on ac.id = ai.addon_category_id
+		where ac.merchant_id = $1
+	`)
+	if err != nil {
+		return err
+	}
+	addonIDs := make(map[int64]int64, len(catalog.AddonCategories))
+	itemIDs := make([]int64, 0)
+	for _, c := range catalog.AddonCategories {
+		addonID := c.ID
+		if c.ID > 0 && liveAddons[c.ID] {
+			if _, err := tx.Exec(ctx, `
+				update addon_categories set
+					name = $3, description = $4, min_selection = $5, max_selection = $6, is_active = $7,
+					restored_at = case when deleted_at is not null then now() else restored_at end,
+					restored_by_user_id = case when deleted_at is not null then $8 else restored_by_user_id end,
+					deleted_at = null, deleted_by_user_id = null,
+					updated_at = now(), updated_by_user_id = $8
+				where id = $1 and merchant_id = $2
+			`, c.ID, merchantID, c.Name, c.Description, c.MinSelection, c.MaxSelection, c.IsActive, userID); err != nil {
+				return err
+			}
+		} else if err := tx.QueryRow(ctx, `
+			insert into addon_categories (
+				merchant_id, name, description, min_selection, max_selection, is_active,
+				created_at, updated_at, created_by_user_id, updated_by_user_id
+			) values ($1, $2, $3, $4, $5, $6, now(), now(), $7, $7)
+			returning id
+		`, merchantID, c.Name, c.Description, c.MinSelection, c.MaxSelection, c.IsActive, userID).Scan(&addonID); err != nil {
+			return err
+		}
+		addonIDs[c.ID] = addonID
+
+		for _, item := range c.Items {
+			itemID := item.ID
+			if item.ID > 0 && liveItems[item.ID] {
+				if _, err := tx.Exec(ctx, `
+					update addon_items set
+						addon_category_id = $2, name = $3, description = $4, price = $5, input_type = $6,
+						display_order = $7, is_active = $8,
+						track_stock = $9,
+						stock_qty = case when $9 and not track_stock then 0 else stock_qty end,
+						restored_at = case when deleted_at is not null then now() else restored_at end,
+						restored_by_user_id = case when deleted_at is not null then $10 else restored_by_user_id end,
+						deleted_at = null, deleted_by_user_id = null,
+						updated_at = now(), updated_by_user_id = $10
+					where id = $1
+				`, item.ID, addonID, item.Name, item.Description, item.Price, item.InputType,
+					item.DisplayOrder, item.IsActive, item.TrackStock, userID); err != nil {
+					return err
+				}
+			} else {
+				var stockQty *int32
+				if item.TrackStock {
+					zero := int32(0)
+					stockQty = &zero
+				}
+				if err := tx.QueryRow(ctx, `
+					insert into addon_items (
+						addon_category_id, name, description, price, input_type, display_order, is_active,
+						track_stock, stock_qty, created_at, updated_at, created_by_user_id, updated_by_user_id
+					) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now(), $10, $10)
+					returning id
+				`, addonID, item.Name, item.Description, item.Price, item.InputType, item.DisplayOrder,
+					item.IsActive, item.TrackStock, stockQty, userID).Scan(&itemID); err != nil {
+					return err
+				}
+			}
+			itemIDs = append(itemIDs, itemID)
+			if item.Dietary != nil {
+				if err := replaceDietaryAttributesTx(ctx, tx, dietaryTableAddonItems, merchantID, itemID, *item.Dietary, userID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		update addon_items ai set deleted_at = now(), deleted_by_user_id = $3
+		from addon_categories ac
+		where ac.id = ai.addon_category_id and ac.merchant_id = $1
+			and ai.deleted_at is null and not (ai.id = any($2))
+	`, merchantID, itemIDs, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		update addon_categories set deleted_at = now(), deleted_by_user_id = $3
+		where merchant_id = $1 and deleted_at is null and not (id = any($2))
+	`, merchantID, mapValues(addonIDs), userID); err != nil {
+		return err
+	}
+
+	liveMenus, err := existingIDs(`select id from menus where merchant_id = $1`)
+	if err != nil {
+		return err
+	}
+	liveVariants := make(map[int64]int64)
+	rows, err := tx.Query(ctx, `select id, menu_id from menu_variants where merchant_id = $1`, merchantID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, menuID int64
+		if err := rows.Scan(&id, &menuID); err != nil {
+			rows.Close()
+			return err
+		}
+		liveVariants[id] = menuID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// Variants left out are deleted before any are written so their names
+	// and SKUs can be reused. Only menus whose variants the catalog lists
+	// are touched.
+	variantMenus := make([]int64, 0)
+	keepVariants := make([]int64, 0)
+	for _, m := range catalog.Menus {
+		if m.Variants == nil || m.ID <= 0 || !liveMenus[m.ID] {
+			continue
+		}
+		variantMenus = append(variantMenus, m.ID)
+		for _, v := range m.Variants {
+			if v.ID > 0 && liveVariants[v.ID] == m.ID {
+				keepVariants = append(keepVariants, v.ID)
+			}
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		update menu_variants set deleted_at = now(), updated_at = now()
+		where merchant_id = $1 and menu_id = any($2) and deleted_at is null and not (id = any($3))
+	`, merchantID, variantMenus, keepVariants); err != nil {
+		return err
+	}
+
+	menuIDs := make(map[int64]int64, len(catalog.Menus))
+	variantIDs := make(map[int64]int64)
+	for _, m := range catalog.Menus {
+		var primaryCategoryID *int64
+		if len(m.CategoryIDs) > 0 {
+			id := categoryIDs[m.CategoryIDs[0]]
+			primaryCategoryID = &id
+		}
+		var thumbMeta []byte
+		if len(m.ImageThumbMeta) > 0 {
+			thumbMeta = m.ImageThumbMeta
+		}
+
+		menuID := m.ID
+		if m.ID > 0 && liveMenus[m.ID] {
+			if _, err := tx.Exec(ctx, `
+				update menus set
+					name = $3, description = $4, price = $5, category_id = $6,
+					image_url = $7, image_thumb_url = $8, image_thumb_meta = $9,
+					is_active = $10, is_spicy = $11, is_best_seller = $12, is_signature = $13, is_recommended = $14,
+					track_stock = $15,
+					stock_qty = case when $15 and not track_stock then 0 else stock_qty end,
+					restored_at = case when deleted_at is not null then now() else restored_at end,
+					restored_by_user_id = case when deleted_at is not null then $16 else restored_by_user_id end,
+					deleted_at = null, deleted_by_user_id = null,
+					updated_at = now(), updated_by_user_id = $16
+				where id = $1 and merchant_id = $2
+			`, m.ID, merchantID, m.Name, m.Description, m.Price, primaryCategoryID,
+				m.ImageURL, m.ImageThumbURL, thumbMeta,
+				m.IsActive, m.IsSpicy, m.IsBestSeller, m.IsSignature, m.IsRecommended, m.TrackStock, userID); err != nil {
+				return err
+			}
+		} else {
+			var stockQty *int32
+			if m.TrackStock {
+				zero := int32(0)
+				stockQty = &zero
+			}
+			if err := tx.QueryRow(ctx, `
+				insert into menus (
+					merchant_id, name, description, price, category_id, image_url, image_thumb_url, image_thumb_meta,
+					is_active, is_spicy, is_best_seller, is_signature, is_recommended, track_stock, stock_qty,
+					created_at, updated_at, created_by_user_id, updated_by_user_id
+				) values (
+					$1, $2, $3, $4, $5, $6, $7, $8,
+					$9, $10, $11, $12, $13, $14, $15,
+					now(), now(), $16, $16
+				) returning id
+			`, merchantID, m.Name, m.Description, m.Price, primaryCategoryID, m.ImageURL, m.ImageThumbURL, thumbMeta,
+				m.IsActive, m.IsSpicy, m.IsBestSeller, m.IsSignature, m.IsRecommended, m.TrackStock, stockQty,
+				userID).Scan(&menuID); err != nil {
+				return err
+			}
+		}
+		menuIDs[m.ID] = menuID
+
+		if _, err := tx.Exec(ctx, `delete from menu_category_items where menu_id = $1`, menuID); err != nil {
+			return err
+		}
+		for _, categoryID := range m.CategoryIDs {
+			if _, err := tx.Exec(ctx, `
+				insert into menu_category_items (menu_id, category_id) values ($1, $2)
+			`, menuID, categoryIDs[categoryID]); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, `delete from menu_addon_categories where menu_id = $1`, menuID); err != nil {
+			return err
+		}
+		for _, link := range m.AddonCategories {
+			if _, err := tx.Exec(ctx, `
+				insert into menu_addon_categories (menu_id, addon_category_id, display_order, is_required, created_at, updated_at)
+				values ($1, $2, $3, $4, now(), now())
+			`, menuID, addonIDs[link.AddonCategoryID], link.DisplayOrder, link.IsRequired); err != nil {
+				return err
+			}
+		}
+
+		for _, v := range m.Variants {
+			variantID := v.ID
+			if v.ID > 0 && liveVariants[v.ID] == menuID {
+				if _, err := tx.Exec(ctx, `
+					update menu_variants set
+						name = $2, sku = $3, price = $4, display_order = $5, is_active = $6,
+						track_stock = $7,
+						stock_qty = case when $7 then coalesce(stock_qty, 0) else null end,
+						deleted_at = null, updated_at = now()
+					where id = $1
+				`, v.ID, v.Name, v.SKU, v.Price, v.DisplayOrder, v.IsActive, v.TrackStock); err != nil {
+					return err
+				}
+			} else if err := tx.QueryRow(ctx, `
+				insert into menu_variants (
+					merchant_id, menu_id, name, sku, price, display_order, is_active, track_stock, stock_qty
+				) values ($1, $2, $3, $4, $5, $6, $7, $8, case when $8 then 0 else null end)
+				returning id
+			`, merchantID, menuID, v.Name, v.SKU, v.Price, v.DisplayOrder, v.IsActive, v.TrackStock).Scan(&variantID); err != nil {
+				return err
+			}
+			variantIDs[v.ID] = variantID
+		}
+		if m.Dietary != nil {
+			if err := replaceDietaryAttributesTx(ctx, tx, dietaryTableMenus, merchantID, menuID, *m.Dietary, userID); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		update menus set deleted_at = now(), deleted_by_user_id = $3
+		where merchant_id = $1 and deleted_at is null and not (id = any($2))
+	`, merchantID, mapValues(menuIDs), userID); err != nil {
+		return err
+	}
+
+	// Bundles go last so options can point at menus and variants the
+	// catalog created. Fixed variants of menus whose variants were left
+	// unchanged keep their ID.
+	for _, m := range catalog.Menus {
+		if m.Bundle == nil {
+			continue
+		}
+		bundle := catalogBundle{RevenueAllocation: m.Bundle.RevenueAllocation, Slots: make([]catalogBundleSlot, 0, len(m.Bundle.Slots))}
+		for _, slot := range m.Bundle.Slots {
+			mapped := slot
+			mapped.Options = make([]catalogBundleOption, 0, len(slot.Options))
+			for _, option := range slot.Options {
+				option.MenuID = menuIDs[option.MenuID]
+				if option.VariantID != nil {
+					if id, ok := variantIDs[*option.VariantID]; ok {
+						option.VariantID = &id
+					}
+				}
+				mapped.Options = append(mapped.Options, option)
+			}
+			bundle.Slots = append(bundle.Slots, mapped)
+		}
+		if err := writeMenuBundleTx(ctx, tx, merchantID, menuIDs[m.ID], bundle.RevenueAllocation, bundle.menuBundleSlots()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// menuBundleSlots converts slots whose IDs already point at live menus and
+// variants for writeMenuBundleTx.
+func (b *catalogBundle) menuBundleSlots() []menuBundleSlot {
+	slots := make([]menuBundleSlot, 0, len(b.Slots))
+	for i, slot := range b.Slots {
+		options := make([]menuBundleOption, 0, len(slot.Options))
+		for _, option := range slot.Options {
+			options = append(options, menuBundleOption{MenuID: option.MenuID, VariantID: option.VariantID, Upcharge: option.Upcharge})
+		}
+		slots = append(slots, menuBundleSlot{
+			Name: slot.Name, MinSelection: slot.MinSelection, MaxSelection: slot.MaxSelection,
+			DisplayOrder: int32(i), Options: options,
+		})
+	}
+	return slots
+}
+
+func mapValues(m map[int64]int64) []int64 {
+	out := make([]int64, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
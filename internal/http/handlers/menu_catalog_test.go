@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testMenuCatalog() menuCatalog {
+	maxSelection := int32(2)
+	return menuCatalog{
+		Categories: []catalogCategory{
+			{ID: 1, Name: "Mains", SortOrder: 2, IsActive: true},
+			{ID: 2, Name: "Drinks", SortOrder: 1, IsActive: true},
+			{ID: 3, Name: "Hidden", SortOrder: 0, IsActive: false},
+		},
+		AddonCategories: []catalogAddonCategory{
+			{ID: 10, Name: "Toppings", MaxSelection: &maxSelection, IsActive: true, Items: []catalogAddonItem{
+				{ID: 100, Name: "Egg", Price: 3, InputType: "SELECT", DisplayOrder: 2, IsActive: true},
+				{ID: 101, Name: "Cheese", Price: 4, InputType: "SELECT", DisplayOrder: 1, IsActive: true},
+				{ID: 102, Name: "Truffle", Price: 40, InputType: "SELECT", IsActive: false},
+			}},
+		},
+		Menus: []catalogMenu{
+			{ID: 1000, Name: "Noodles", Price: 25, IsActive: true, CategoryIDs: []int64{1, 3},
+				AddonCategories: []catalogMenuAddon{{AddonCategoryID: 10, IsRequired: true}}},
+			{ID: 1001, Name: "Tea", Price: 8, IsActive: true, CategoryIDs: []int64{2}},
+			{ID: 1002, Name: "Old Soup", Price: 9, IsActive: false, CategoryIDs: []int64{1}},
+		},
+	}
+}
+
+func TestValidateMenuCatalog(t *testing.T) {
+	valid := testMenuCatalog()
+	valid.Menus = append(valid.Menus, catalogMenu{ID: -1, Name: " New ", CategoryIDs: []int64{2}})
+	if err := validateMenuCatalog(&valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if valid.Menus[3].Name != "New" || valid.Menus[3].AddonCategories == nil {
+		t.Fatalf("expected normalized menu, got %+v", valid.Menus[3])
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(c *menuCatalog)
+	}{
+		{"duplicate category id", func(c *menuCatalog) { c.Categories[1].ID = 1 }},
+		{"zero menu id", func(c *menuCatalog) { c.Menus[0].ID = 0 }},
+		{"blank addon item name", func(c *menuCatalog) { c.AddonCategories[0].Items[0].Name = " " }},
+		{"negative price", func(c *menuCatalog) { c.Menus[1].Price = -1 }},
+		{"max below min", func(c *menuCatalog) { c.AddonCategories[0].MinSelection = 3 }},
+		{"unknown category", func(c *menuCatalog) { c.Menus[1].CategoryIDs = []int64{9} }},
+		{"unknown addon category", func(c *menuCatalog) {
+			c.Menus[1].AddonCategories = []catalogMenuAddon{{AddonCategoryID: -5}}
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog := testMenuCatalog()
+			tc.mutate(&catalog)
+			if err := validateMenuCatalog(&catalog); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestDiffMenuCatalogs(t *testing.T) {
+	from := testMenuCatalog()
+	to := testMenuCatalog()
+	if diff := diffMenuCatalogs(from, to); !diff.isEmpty() {
+		t.Fatalf("expected no changes, got %+v", diff)
+	}
+
+	to.Menus[0].Price = 27
+	to.Menus[0].CategoryIDs = []int64{1}
+	to.Menus = append(to.Menus[:2], catalogMenu{ID: -1, Name: "Coffee", CategoryIDs: []int64{2}})
+	to.AddonCategories[0].Items[1].Price = 5
+	to.Categories[0].Description = nil
+	to.Menus[1].AddonCategories = []catalogMenuAddon{}
+
+	diff := diffMenuCatalogs(from, to)
+	if len(diff.Categories.Changed) != 0 {
+		t.Fatalf("nil description should match, got %+v", diff.Categories)
+	}
+	wantMenus := catalogEntityDiff{
+		Added:   []catalogEntityChange{{ID: -1, Name: "Coffee"}},
+		Removed: []catalogEntityChange{{ID: 1002, Name: "Old Soup"}},
+		Changed: []catalogEntityChange{{ID: 1000, Name: "Noodles", Fields: []string{"categoryIds", "price"}}},
+	}
+	if !reflect.DeepEqual(diff.Menus, wantMenus) {
+		t.Fatalf("expected %+v, got %+v", wantMenus, diff.Menus)
+	}
+	if len(diff.AddonItems.Changed) != 1 || diff.AddonItems.Changed[0].ID != 101 {
+		t.Fatalf("expected cheese price change, got %+v", diff.AddonItems)
+	}
+}
+
+func TestRenderMenuCatalogPreview(t *testing.T) {
+	preview := renderMenuCatalogPreview(testMenuCatalog())
+	if len(preview) != 2 {
+		t.Fatalf("expected two active categories, got %d", len(preview))
+	}
+	first := preview[0]["category"].(map[string]any)
+	if first["name"] != "Drinks" {
+		t.Fatalf("expected categories sorted by sortOrder, got %v", first["name"])
+	}
+
+	mains := preview[1]["menus"].([]map[string]any)
+	if len(mains) != 1 || mains[0]["name"] != "Noodles" {
+		t.Fatalf("expected only active menus, got %+v", mains)
+	}
+	addons := mains[0]["addonCategories"].([]map[string]any)
+	if len(addons) != 1 || addons[0]["isRequired"] != true {
+		t.Fatalf("unexpected addon categories: %+v", addons)
+	}
+	items := addons[0]["addonItems"].([]map[string]any)
+	if len(items) != 2 || items[0]["name"] != "Cheese" || items[1]["name"] != "Egg" {
+		t.Fatalf("expected active items by display order, got %+v", items)
+	}
+}
+
+func testMenuCatalogWithBundle() menuCatalog {
+	c := testMenuCatalog()
+	large := int64(11)
+	c.Menus[1].Variants = []catalogVariant{
+		{ID: 10, Name: "Regular", Price: 8, IsActive: true},
+		{ID: 11, Name: "Large", Price: 10, DisplayOrder: 1, IsActive: true},
+		{ID: 12, Name: "Jumbo", Price: 12, DisplayOrder: 2, IsActive: false},
+	}
+	c.Menus[1].Dietary = &dietaryAttributes{Allergens: []string{"milk"}, DietaryTags: []string{}}
+	c.Menus = append(c.Menus, catalogMenu{ID: -2, Name: "Lunch Set", Price: 30, IsActive: true, CategoryIDs: []int64{1},
+		Bundle: &catalogBundle{Slots: []catalogBundleSlot{
+			{Name: "Main", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 1000}}},
+			{Name: "Drink", MinSelection: 1, MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 1001, VariantID: &large, Upcharge: 2}}},
+		}}})
+	return c
+}
+
+func TestValidateMenuCatalogVariantsBundlesAndDietary(t *testing.T) {
+	valid := testMenuCatalogWithBundle()
+	if err := validateMenuCatalog(&valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := valid.Menus[3].Bundle.RevenueAllocation; got != bundleAllocationListPrice {
+		t.Fatalf("expected default allocation, got %q", got)
+	}
+	if got := valid.Menus[1].Dietary.Allergens; !reflect.DeepEqual(got, []string{"MILK"}) {
+		t.Fatalf("expected normalized allergens, got %v", got)
+	}
+
+	sku := "TEA-L"
+	cases := []struct {
+		name   string
+		mutate func(c *menuCatalog)
+	}{
+		{"duplicate variant id", func(c *menuCatalog) { c.Menus[0].Variants = []catalogVariant{{ID: 10, Name: "Small"}} }},
+		{"duplicate variant name", func(c *menuCatalog) { c.Menus[1].Variants[1].Name = " regular" }},
+		{"sku used twice", func(c *menuCatalog) {
+			c.Menus[1].Variants[0].SKU = &sku
+			c.Menus[0].Variants = []catalogVariant{{ID: -5, Name: "Small", SKU: &sku}}
+		}},
+		{"negative variant price", func(c *menuCatalog) { c.Menus[1].Variants[2].Price = -1 }},
+		{"unknown dietary tag", func(c *menuCatalog) { c.Menus[1].Dietary.DietaryTags = []string{"PALEO"} }},
+		{"addon item spicy level", func(c *menuCatalog) {
+			level := int32(9)
+			c.AddonCategories[0].Items[0].Dietary = &dietaryAttributes{SpicyLevel: &level}
+		}},
+		{"bundle option unknown menu", func(c *menuCatalog) { c.Menus[3].Bundle.Slots[0].Options[0].MenuID = 77 }},
+		{"bundle option is itself", func(c *menuCatalog) { c.Menus[3].Bundle.Slots[0].Options[0].MenuID = -2 }},
+		{"bundle option is a bundle", func(c *menuCatalog) {
+			c.Menus[0].Bundle = &catalogBundle{Slots: []catalogBundleSlot{
+				{Name: "Side", MaxSelection: 1, Options: []catalogBundleOption{{MenuID: 1002}}},
+			}}
+		}},
+		{"fixed variant of another menu", func(c *menuCatalog) {
+			other := int64(99)
+			c.Menus[3].Bundle.Slots[1].Options[0].VariantID = &other
+		}},
+		{"slot without options", func(c *menuCatalog) { c.Menus[3].Bundle.Slots[0].Options = nil }},
+		{"invalid allocation", func(c *menuCatalog) { c.Menus[3].Bundle.RevenueAllocation = "RANDOM" }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog := testMenuCatalogWithBundle()
+			tc.mutate(&catalog)
+			if err := validateMenuCatalog(&catalog); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+
+	// Variants of a menu the catalog leaves unchanged cannot be checked.
+	legacy := testMenuCatalogWithBundle()
+	legacy.Menus[1].Variants = nil
+	if err := validateMenuCatalog(&legacy); err != nil {
+		t.Fatalf("unexpected error for unchanged variants: %v", err)
+	}
+}
+
+func TestDiffMenuCatalogsVariantsBundlesAndDietary(t *testing.T) {
+	from := testMenuCatalogWithBundle()
+	to := testMenuCatalogWithBundle()
+	to.Menus[1].Variants[1].Price = 11
+	to.Menus[3].Bundle.Slots[1].Options[0].Upcharge = 3
+	to.AddonCategories[0].Items[0].Dietary = &dietaryAttributes{Allergens: []string{"EGGS"}}
+
+	diff := diffMenuCatalogs(from, to)
+	want := []catalogEntityChange{
+		{ID: 1001, Name: "Tea", Fields: []string{"variants"}},
+		{ID: -2, Name: "Lunch Set", Fields: []string{"bundle"}},
+	}
+	if !reflect.DeepEqual(diff.Menus.Changed, want) {
+		t.Fatalf("expected %+v, got %+v", want, diff.Menus.Changed)
+	}
+	if len(diff.AddonItems.Changed) != 1 || diff.AddonItems.Changed[0].Fields[0] != "dietary" {
+		t.Fatalf("expected egg dietary change, got %+v", diff.AddonItems)
+	}
+
+	// A catalog saved before variants were part of it leaves them alone.
+	legacy := testMenuCatalogWithBundle()
+	legacy.Menus[1].Variants = nil
+	legacy.Menus[1].Dietary = nil
+	if diff := diffMenuCatalogs(from, legacy); !diff.isEmpty() {
+		t.Fatalf("expected null fields to be ignored, got %+v", diff)
+	}
+}
+
+func TestRenderMenuCatalogPreviewVariantsAndBundle(t *testing.T) {
+	preview := renderMenuCatalogPreview(testMenuCatalogWithBundle())
+	drinks := preview[0]["menus"].([]map[string]any)
+	variants := drinks[0]["variants"].([]catalogVariant)
+	if len(variants) != 2 || variants[1].Name != "Large" {
+		t.Fatalf("expected active variants by display order, got %+v", variants)
+	}
+	if drinks[0]["bundle"].(*catalogBundle) != nil {
+		t.Fatalf("expected no bundle for a regular menu")
+	}
+	if allergens := drinks[0]["dietary"].(dietaryAttributes).Allergens; len(allergens) != 1 {
+		t.Fatalf("expected dietary attributes, got %v", allergens)
+	}
+
+	mains := preview[1]["menus"].([]map[string]any)
+	if len(mains) != 2 || mains[1]["name"] != "Lunch Set" {
+		t.Fatalf("expected the bundle among mains, got %+v", mains)
+	}
+	if bundle := mains[1]["bundle"].(*catalogBundle); bundle == nil || len(bundle.Slots) != 2 {
+		t.Fatalf("expected bundle slots, got %+v", bundle)
+	}
+}
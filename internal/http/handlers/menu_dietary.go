@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Allergen codes follow the 14 major allergens of EU FIC Annex II.
+var dietaryAllergens = []string{
+	"GLUTEN", "CRUSTACEANS", "EGGS", "FISH", "PEANUTS", "SOYBEANS", "MILK",
+	"TREE_NUTS", "CELERY", "MUSTARD", "SESAME", "SULPHITES", "LUPIN", "MOLLUSCS",
+}
+
+var dietaryTags = []string{
+	"VEGETARIAN", "VEGAN", "HALAL", "KOSHER", "GLUTEN_FREE", "DAIRY_FREE", "NUT_FREE", "LOW_CARB",
+}
+
+const (
+	dietaryTableMenus      = "menu_dietary_attributes"
+	dietaryTableAddonItems = "addon_item_dietary_attributes"
+
+	dietaryMaxSpicyLevel = 5
+	dietaryMaxCalories   = 10000
+)
+
+type dietaryNutrition struct {
+	ProteinG *float64 `json:"proteinG"`
+	CarbsG   *float64 `json:"carbsG"`
+	FatG     *float64 `json:"fatG"`
+	SugarG   *float64 `json:"sugarG"`
+	FiberG   *float64 `json:"fiberG"`
+	SodiumMg *float64 `json:"sodiumMg"`
+}
+
+// dietaryAttributes is the allergen and nutrition profile of a menu or
+// addon item. Nutrition is per serving.
+type dietaryAttributes struct {
+	Allergens   []string          `json:"allergens"`
+	DietaryTags []string          `json:"dietaryTags"`
+	SpicyLevel  *int32            `json:"spicyLevel"`
+	Calories    *int32            `json:"calories"`
+	Nutrition   *dietaryNutrition `json:"nutrition"`
+}
+
+// parseDietaryCodes upper-cases and de-duplicates codes, rejecting any that
+// are not in allowed. The result keeps the order of allowed.
+func parseDietaryCodes(values []string, allowed []string, kind string) ([]string, error) {
+	picked := make(map[string]bool, len(values))
+	for _, value := range values {
+		code := strings.ToUpper(strings.TrimSpace(value))
+		if code == "" {
+			continue
+		}
+		known := false
+		for _, candidate := range allowed {
+			if candidate == code {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown %s %q", kind, value)
+		}
+		picked[code] = true
+	}
+	out := make([]string, 0, len(picked))
+	for _, code := range allowed {
+		if picked[code] {
+			out = append(out, code)
+		}
+	}
+	return out, nil
+}
+
+// splitDietaryParam parses a comma-separated query parameter.
+func splitDietaryParam(raw string, allowed []string, kind string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	return parseDietaryCodes(strings.Split(raw, ","), allowed, kind)
+}
+
+func normalizeDietaryAttributes(input dietaryAttributes) (dietaryAttributes, error) {
+	var out dietaryAttributes
+	var err error
+	if out.Allergens, err = parseDietaryCodes(input.Allergens, dietaryAllergens, "allergen"); err != nil {
+		return out, err
+	}
+	if out.DietaryTags, err = parseDietaryCodes(input.DietaryTags, dietaryTags, "dietary tag"); err != nil {
+		return out, err
+	}
+	if input.SpicyLevel != nil {
+		if *input.SpicyLevel < 0 || *input.SpicyLevel > dietaryMaxSpicyLevel {
+			return out, fmt.Errorf("spicyLevel must be between 0 and %d", dietaryMaxSpicyLevel)
+		}
+		out.SpicyLevel = input.SpicyLevel
+	}
+	if input.Calories != nil {
+		if *input.Calories < 0 || *input.Calories > dietaryMaxCalories {
+			return out, fmt.Errorf("calories must be between 0 and %d", dietaryMaxCalories)
+		}
+		out.Calories = input.Calories
+	}
+	if input.Nutrition != nil {
+		nutrition := *input.Nutrition
+		set := false
+		for _, value := range []**float64{&nutrition.ProteinG, &nutrition.CarbsG, &nutrition.FatG, &nutrition.SugarG, &nutrition.FiberG, &nutrition.SodiumMg} {
+			if *value == nil {
+				continue
+			}
+			v := **value
+			if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+				return out, fmt.Errorf("Nutrition values cannot be negative")
+			}
+			rounded := round2(v)
+			*value = &rounded
+			set = true
+		}
+		if set {
+			out.Nutrition = &nutrition
+		}
+	}
+	return out, nil
+}
+
+// dietaryFor returns the attributes of id, with empty lists when none are
+// stored so payloads always carry arrays.
+func dietaryFor(attributes map[int64]dietaryAttributes, id int64) dietaryAttributes {
+	attrs := attributes[id]
+	if attrs.Allergens == nil {
+		attrs.Allergens = []string{}
+	}
+	if attrs.DietaryTags == nil {
+		attrs.DietaryTags = []string{}
+	}
+	return attrs
+}
+
+// dietaryKeyColumn is the owner column of a dietary attributes table.
+func dietaryKeyColumn(table string) string {
+	if table == dietaryTableAddonItems {
+		return "addon_item_id"
+	}
+	return "menu_id"
+}
+
+// fetchDietaryAttributes loads attributes for ids from table, which must be
+// dietaryTableMenus or dietaryTableAddonItems.
+func (h *Handler) fetchDietaryAttributes(ctx context.Context, table string, ids []int64) (map[int64]dietaryAttributes, error) {
+	out := make(map[int64]dietaryAttributes)
+	if len(ids) == 0 {
+		return out, nil
+	}
+	key := dietaryKeyColumn(table)
+	rows, err := h.DB.Query(ctx, `
+		select `+key+`, allergens, dietary_tags, spicy_level, calories, nutrition
+		from `+table+`
+		where `+key+` = any($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	return scanDietaryAttributes(rows)
+}
+
+// scanDietaryAttributes reads rows of (owner id, allergens, dietary_tags,
+// spicy_level, calories, nutrition) and closes them.
+func scanDietaryAttributes(rows pgx.Rows) (map[int64]dietaryAttributes, error) {
+	defer rows.Close()
+	out := make(map[int64]dietaryAttributes)
+	for rows.Next() {
+		var (
+			id         int64
+			attrs      dietaryAttributes
+			spicyLevel pgtype.Int2
+			calories   pgtype.Int4
+			nutrition  []byte
+		)
+		if err := rows.Scan(&id, &attrs.Allergens, &attrs.DietaryTags, &spicyLevel, &calories, &nutrition); err != nil {
+			return nil, err
+		}
+		if spicyLevel.Valid {
+			value := int32(spicyLevel.Int16)
+			attrs.SpicyLevel = &value
+		}
+		attrs.Calories = int4Ptr(calories)
+		if len(nutrition) > 0 {
+			var parsed dietaryNutrition
+			if err := json.Unmarshal(nutrition, &parsed); err == nil {
+				attrs.Nutrition = &parsed
+			}
+		}
+		out[id] = attrs
+	}
+	return out, rows.Err()
+}
+
+// fetchPublicDietary loads the attributes of public menus and their addon
+// items. Lookup failures only drop the attributes.
+func (h *Handler) fetchPublicDietary(ctx context.Context, menuIDs []int64, addonMap map[int64][]publicAddonCategory) (map[int64]dietaryAttributes, map[int64]dietaryAttributes) {
+	menus, err := h.fetchDietaryAttributes(ctx, dietaryTableMenus, menuIDs)
+	if err != nil {
+		h.Logger.Warn("public menu dietary lookup failed", zapError(err))
+		menus = map[int64]dietaryAttributes{}
+	}
+	addonItemIDs := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, categories := range addonMap {
+		for _, category := range categories {
+			for _, item := range category.AddonItems {
+				if !seen[item.ID] {
+					seen[item.ID] = true
+					addonItemIDs = append(addonItemIDs, item.ID)
+				}
+			}
+		}
+	}
+	addons, err := h.fetchDietaryAttributes(ctx, dietaryTableAddonItems, addonItemIDs)
+	if err != nil {
+		h.Logger.Warn("public addon dietary lookup failed", zapError(err))
+		addons = map[int64]dietaryAttributes{}
+	}
+	return menus, addons
+}
+
+func (h *Handler) writeDietaryAttributes(ctx context.Context, table string, merchantID, id int64, attrs dietaryAttributes, userID int64) error {
+	return upsertDietaryAttributes(ctx, h.DB, table, merchantID, id, attrs, &userID)
+}
+
+type dietaryExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// isEmpty reports whether nothing is recorded, so no row needs to be kept.
+func (a dietaryAttributes) isEmpty() bool {
+	return len(a.Allergens) == 0 && len(a.DietaryTags) == 0 && a.SpicyLevel == nil && a.Calories == nil && a.Nutrition == nil
+}
+
+// replaceDietaryAttributesTx stores attrs for id, deleting the row when they
+// are empty.
+func replaceDietaryAttributesTx(ctx context.Context, db dietaryExecer, table string, merchantID, id int64, attrs dietaryAttributes, userID *int64) error {
+	if attrs.isEmpty() {
+		_, err := db.Exec(ctx, `delete from `+table+` where `+dietaryKeyColumn(table)+` = $1`, id)
+		return err
+	}
+	return upsertDietaryAttributes(ctx, db, table, merchantID, id, attrs, userID)
+}
+
+func upsertDietaryAttributes(ctx context.Context, db dietaryExecer, table string, merchantID, id int64, attrs dietaryAttributes, userID *int64) error {
+	if attrs.Allergens == nil {
+		attrs.Allergens = []string{}
+	}
+	if attrs.DietaryTags == nil {
+		attrs.DietaryTags = []string{}
+	}
+	var nutrition []byte
+	if attrs.Nutrition != nil {
+		encoded, err := json.Marshal(attrs.Nutrition)
+		if err != nil {
+			return err
+		}
+		nutrition = encoded
+	}
+	_, err := db.Exec(ctx, `
+		insert into `+table+` (`+dietaryKeyColumn(table)+`, merchant_id, allergens, dietary_tags, spicy_level, calories, nutrition, updated_at, updated_by_user_id)
+		values ($1, $2, $3, $4, $5, $6, $7, now(), $8)
+		on conflict (`+dietaryKeyColumn(table)+`) do update set
+			allergens = excluded.allergens, dietary_tags = excluded.dietary_tags, spicy_level = excluded.spicy_level,
+			calories = excluded.calories, nutrition = excluded.nutrition,
+			updated_at = excluded.updated_at, updated_by_user_id = excluded.updated_by_user_id
+	`, id, merchantID, attrs.Allergens, attrs.DietaryTags, attrs.SpicyLevel, attrs.Calories, nutrition, userID)
+	return err
+}
+
+// fetchOrderItemAllergens collects the allergens of each order item from its
+// menu, addons and bundle components, for kitchen tickets. Attributes are
+// read live, so later menu edits show up on open tickets.
+func (h *Handler) fetchOrderItemAllergens(ctx context.Context, orderItemIDs []int64) (map[int64][]string, error) {
+	out := make(map[int64][]string)
+	if len(orderItemIDs) == 0 {
+		return out, nil
+	}
+	rows, err := h.DB.Query(ctx, `
+		select oi.id, array_agg(distinct a.code)
+		from order_items oi
+		join lateral (
+			select unnest(md.allergens) from menu_dietary_attributes md where md.menu_id = oi.menu_id
+			union
+			select unnest(ad.allergens)
+			from order_item_addons oia
+			join addon_item_dietary_attributes ad on ad.addon_item_id = oia.addon_item_id
+			where oia.order_item_id = oi.id
+			union
+			select unnest(md.allergens)
+			from order_item_components oic
+			join menu_dietary_attributes md on md.menu_id = oic.menu_id
+			where oic.order_item_id = oi.id
+		) a(code) on true
+		where oi.id = any($1)
+		group by oi.id
+	`, orderItemIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id    int64
+			codes []string
+		)
+		if err := rows.Scan(&id, &codes); err != nil {
+			return nil, err
+		}
+		if sorted, err := parseDietaryCodes(codes, dietaryAllergens, "allergen"); err == nil {
+			codes = sorted
+		}
+		out[id] = codes
+	}
+	return out, rows.Err()
+}
+
+// MerchantMenuDietary returns the allergen and nutrition attributes of a menu.
+func (h *Handler) MerchantMenuDietary(w http.ResponseWriter, r *http.Request) {
+	h.handleDietaryGet(w, r, dietaryTableMenus)
+}
+
+// MerchantMenuDietaryReplace replaces the allergen and nutrition attributes
+// of a menu.
+func (h *Handler) MerchantMenuDietaryReplace(w http.ResponseWriter, r *http.Request) {
+	h.handleDietaryReplace(w, r, dietaryTableMenus)
+}
+
+// MerchantAddonItemDietary returns the allergen and nutrition attributes of
+// an addon item.
+func (h *Handler) MerchantAddonItemDietary(w http.ResponseWriter, r *http.Request) {
+	h.handleDietaryGet(w, r, dietaryTableAddonItems)
+}
+
+// MerchantAddonItemDietaryReplace replaces the allergen and nutrition
+// attributes of an addon item.
+func (h *Handler) MerchantAddonItemDietaryReplace(w http.ResponseWriter, r *http.Request) {
+	h.handleDietaryReplace(w, r, dietaryTableAddonItems)
+}
+
+func (h *Handler) dietaryTargetExists(ctx context.Context, table string, merchantID, id int64) bool {
+	if table == dietaryTableAddonItems {
+		_, err := h.fetchAddonItemByID(ctx, merchantID, id)
+		return err == nil
+	}
+	var exists bool
+	if err := h.DB.QueryRow(ctx, `
+		select exists(select 1 from menus where id = $1 and merchant_id = $2 and deleted_at is null)
+	`, id, merchantID).Scan(&exists); err != nil {
+		return false
+	}
+	return exists
+}
+
+func dietaryNotFoundMessage(table string) string {
+	if table == dietaryTableAddonItems {
+		return "Addon item not found"
+	}
+	return "Menu not found"
+}
+
+func (h *Handler) handleDietaryGet(w http.ResponseWriter, r *http.Request, table string) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+	if !h.dietaryTargetExists(ctx, table, *authCtx.MerchantID, id) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", dietaryNotFoundMessage(table))
+		return
+	}
+
+	attributes, err := h.fetchDietaryAttributes(ctx, table, []int64{id})
+	if err != nil {
+		h.Logger.Error("dietary attributes query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve dietary attributes")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       dietaryFor(attributes, id),
+		"message":    "Dietary attributes retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) handleDietaryReplace(w http.ResponseWriter, r *http.Request, table string) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+
+	var body dietaryAttributes
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	attrs, err := normalizeDietaryAttributes(body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if !h.dietaryTargetExists(ctx, table, *authCtx.MerchantID, id) {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", dietaryNotFoundMessage(table))
+		return
+	}
+	if err := h.writeDietaryAttributes(ctx, table, *authCtx.MerchantID, id, attrs, authCtx.UserID); err != nil {
+		h.Logger.Error("dietary attributes update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update dietary attributes")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       dietaryFor(map[int64]dietaryAttributes{id: attrs}, id),
+		"message":    "Dietary attributes updated successfully",
+		"statusCode": 200,
+	})
+}
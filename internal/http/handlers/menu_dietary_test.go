@@ -0,0 +1,87 @@
+package handlers
+
+import "testing"
+
+func TestParseDietaryCodes(t *testing.T) {
+	cases := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", nil, []string{}, false},
+		{"taxonomy order and dedupe", []string{" milk", "GLUTEN", "Milk", ""}, []string{"GLUTEN", "MILK"}, false},
+		{"unknown", []string{"GLUTEN", "BACON"}, nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDietaryCodes(tc.values, dietaryAllergens, "allergen")
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+
+	if got, err := splitDietaryParam("vegan, halal", dietaryTags, "dietary tag"); err != nil || len(got) != 2 || got[0] != "VEGAN" {
+		t.Fatalf("unexpected split result %v, %v", got, err)
+	}
+	if got, err := splitDietaryParam(" ", dietaryTags, "dietary tag"); err != nil || got != nil {
+		t.Fatalf("expected no filter, got %v, %v", got, err)
+	}
+}
+
+func TestNormalizeDietaryAttributes(t *testing.T) {
+	level := func(v int32) *int32 { return &v }
+	grams := func(v float64) *float64 { return &v }
+
+	got, err := normalizeDietaryAttributes(dietaryAttributes{
+		Allergens:   []string{"eggs"},
+		DietaryTags: []string{"vegetarian"},
+		SpicyLevel:  level(2),
+		Calories:    level(450),
+		Nutrition:   &dietaryNutrition{ProteinG: grams(12.345)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Allergens[0] != "EGGS" || got.DietaryTags[0] != "VEGETARIAN" || *got.SpicyLevel != 2 || *got.Nutrition.ProteinG != 12.35 {
+		t.Fatalf("unexpected attributes: %+v", got)
+	}
+
+	empty, err := normalizeDietaryAttributes(dietaryAttributes{Nutrition: &dietaryNutrition{}})
+	if err != nil || empty.Nutrition != nil {
+		t.Fatalf("expected empty nutrition to be dropped, got %+v, %v", empty, err)
+	}
+
+	cases := []struct {
+		name  string
+		input dietaryAttributes
+	}{
+		{"unknown allergen", dietaryAttributes{Allergens: []string{"BACON"}}},
+		{"unknown tag", dietaryAttributes{DietaryTags: []string{"PALEO"}}},
+		{"spicy level too high", dietaryAttributes{SpicyLevel: level(dietaryMaxSpicyLevel + 1)}},
+		{"negative calories", dietaryAttributes{Calories: level(-1)}},
+		{"negative nutrition", dietaryAttributes{Nutrition: &dietaryNutrition{FatG: grams(-1)}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := normalizeDietaryAttributes(tc.input); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
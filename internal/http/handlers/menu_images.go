@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"genfity-order-services/internal/storage"
+	"genfity-order-services/internal/utils"
+)
+
+const (
+	menuImageQuality   = 80
+	menuImageRoleFull  = "full"
+	menuImageRoleThumb = "thumb"
+)
+
+// Widths of the responsive menu image renditions: the card and detail sizes
+// public menus request. Full images keep their aspect ratio and fall back to
+// the stored full JPEG; thumbs are square crops whose JPEGs are the legacy
+// 1x/2x thumbs.
+var menuImageVariantSpecs = []utils.ImageVariantSpec{
+	{Name: menuImageRoleFull, Width: 480},
+	{Name: menuImageRoleFull, Width: 960},
+	{Name: menuImageRoleThumb, Width: menuThumbSize, Square: true, JPEG: true},
+	{Name: menuImageRoleThumb, Width: menuThumb2xSize, Square: true, JPEG: true},
+}
+
+type menuImageRendition struct {
+	Role   string `json:"role"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+type storedMenuImage struct {
+	ThumbURL   string
+	Thumb2xURL string
+	Meta       menuThumbMeta
+}
+
+// storeMenuImageVariants encodes the responsive WebP and JPEG renditions of an
+// uploaded menu image and stores them under one folder per upload:
+// merchants/{code}/menus/menu-{imageKey}-{ts}-{rand}/{role}-{width}.{webp|jpg}.
+// The square JPEG thumbs double as the legacy thumb and 2x thumb URLs.
+func storeMenuImageVariants(ctx context.Context, store *storage.ObjectStore, merchantCode, imageKey string, data []byte) (storedMenuImage, error) {
+	variants, err := utils.EncodeImageVariants(data, menuImageVariantSpecs, menuImageQuality)
+	if err != nil {
+		return storedMenuImage{}, err
+	}
+
+	base := addRandomSuffix(fmt.Sprintf("merchants/%s/menus/menu-%s", merchantCode, imageKey))
+	placeholder := variants.Placeholder
+	out := storedMenuImage{
+		Meta: menuThumbMeta{
+			Format:      "jpeg",
+			Source:      variants.Source,
+			Renditions:  make([]menuImageRendition, 0, len(variants.Renditions)),
+			Placeholder: &placeholder,
+		},
+	}
+	for _, rendition := range variants.Renditions {
+		ext := "jpg"
+		if rendition.Format == "webp" {
+			ext = "webp"
+		}
+		key := fmt.Sprintf("%s/%s-%d.%s", base, rendition.Name, rendition.Width, ext)
+		url, err := store.PutObject(ctx, key, rendition.Data, rendition.ContentType, "public, max-age=31536000, immutable")
+		if err != nil {
+			return storedMenuImage{}, err
+		}
+		out.Meta.Renditions = append(out.Meta.Renditions, menuImageRendition{
+			Role:   rendition.Name,
+			Format: rendition.Format,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+			URL:    url,
+		})
+		if rendition.Name != menuImageRoleThumb || rendition.Format != "jpeg" {
+			continue
+		}
+		switch rendition.Width {
+		case menuThumbSize:
+			out.ThumbURL = url
+			out.Meta.Variants = append(out.Meta.Variants, menuThumbVariant{Dpr: 1, Width: rendition.Width, Height: rendition.Height, URL: url})
+		case menuThumb2xSize:
+			out.Thumb2xURL = url
+			out.Meta.Variants = append(out.Meta.Variants, menuThumbVariant{Dpr: 2, Width: rendition.Width, Height: rendition.Height, URL: url})
+		}
+	}
+	return out, nil
+}
+
+// menuImageMetaURLs lists every stored URL referenced by an image_thumb_meta
+// document, so replaced images can be removed from storage.
+func menuImageMetaURLs(raw []byte) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var meta struct {
+		Variants []struct {
+			URL *string `json:"url"`
+		} `json:"variants"`
+		Renditions []struct {
+			URL *string `json:"url"`
+		} `json:"renditions"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil
+	}
+	urls := make([]string, 0, len(meta.Variants)+len(meta.Renditions))
+	for _, v := range meta.Variants {
+		if v.URL != nil && strings.TrimSpace(*v.URL) != "" {
+			urls = append(urls, strings.TrimSpace(*v.URL))
+		}
+	}
+	for _, v := range meta.Renditions {
+		if v.URL != nil && strings.TrimSpace(*v.URL) != "" {
+			urls = append(urls, strings.TrimSpace(*v.URL))
+		}
+	}
+	return urls
+}
+
+// publicMenuImage builds the srcset-ready image object of public menu
+// responses. Images uploaded before renditions existed fall back to the
+// legacy JPEG thumbs.
+func publicMenuImage(imageURL, thumbURL *string, rawMeta []byte) any {
+	if imageURL == nil || strings.TrimSpace(*imageURL) == "" {
+		return nil
+	}
+
+	var meta menuThumbMeta
+	if len(rawMeta) > 0 {
+		_ = json.Unmarshal(rawMeta, &meta)
+	}
+
+	image := map[string]any{
+		"src":           *imageURL,
+		"width":         meta.Source.Width,
+		"height":        meta.Source.Height,
+		"blurHash":      nil,
+		"dominantColor": nil,
+		"sources":       menuImageSources(meta.Renditions, menuImageRoleFull),
+	}
+	if meta.Placeholder != nil {
+		if meta.Placeholder.BlurHash != "" {
+			image["blurHash"] = meta.Placeholder.BlurHash
+		}
+		if meta.Placeholder.DominantColor != "" {
+			image["dominantColor"] = meta.Placeholder.DominantColor
+		}
+	}
+
+	if thumbURL != nil && strings.TrimSpace(*thumbURL) != "" {
+		sources := menuImageSources(meta.Renditions, menuImageRoleThumb)
+		if len(sources) == 0 && len(meta.Variants) > 0 {
+			legacy := make([]menuImageRendition, 0, len(meta.Variants))
+			for _, v := range meta.Variants {
+				legacy = append(legacy, menuImageRendition{Role: menuImageRoleThumb, Format: "jpeg", Width: v.Width, Height: v.Height, URL: v.URL})
+			}
+			sources = menuImageSources(legacy, menuImageRoleThumb)
+		}
+		image["thumb"] = map[string]any{
+			"src":     *thumbURL,
+			"sources": sources,
+		}
+	} else {
+		image["thumb"] = nil
+	}
+	return image
+}
+
+// menuImageSources groups renditions of one role into <source> entries,
+// WebP first so browsers pick it over the JPEG fallback.
+func menuImageSources(renditions []menuImageRendition, role string) []map[string]any {
+	byFormat := make(map[string][]menuImageRendition)
+	for _, r := range renditions {
+		if r.Role != role || strings.TrimSpace(r.URL) == "" || r.Width <= 0 {
+			continue
+		}
+		byFormat[r.Format] = append(byFormat[r.Format], r)
+	}
+	sources := make([]map[string]any, 0, 2)
+	for _, format := range []string{"webp", "jpeg"} {
+		list := byFormat[format]
+		if len(list) == 0 {
+			continue
+		}
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Width < list[j].Width })
+		parts := make([]string, 0, len(list))
+		for _, r := range list {
+			parts = append(parts, fmt.Sprintf("%s %dw", r.URL, r.Width))
+		}
+		sources = append(sources, map[string]any{
+			"type":   "image/" + format,
+			"srcset": strings.Join(parts, ", "),
+		})
+	}
+	return sources
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import "testing"
+
+func TestPublicMenuImage(t *testing.T) {
+	full := "https://cdn/menu.jpg"
+	thumb := "https://cdn/m/thumb-300.jpg"
+
+	if publicMenuImage(nil, &thumb, nil) != nil {
+		t.Fatalf("expected nil image without an image URL")
+	}
+
+	meta := []byte(`{
+		"format": "jpeg",
+		"source": {"width": 2000, "height": 1500, "format": "jpeg"},
+		"variants": [{"dpr": 1, "width": 300, "height": 300, "url": "https://cdn/m/thumb-300.jpg"}],
+		"renditions": [
+			{"role": "full", "format": "jpeg", "width": 960, "height": 720, "url": "https://cdn/m/full-960.jpg"},
+			{"role": "full", "format": "webp", "width": 960, "height": 720, "url": "https://cdn/m/full-960.webp"},
+			{"role": "full", "format": "webp", "width": 480, "height": 360, "url": "https://cdn/m/full-480.webp"},
+			{"role": "thumb", "format": "webp", "width": 300, "height": 300, "url": "https://cdn/m/thumb-300.webp"}
+		],
+		"placeholder": {"blurHash": "LEHV6nWB2yk8pyo0adR*.7kCMdnj", "dominantColor": "#c4a877"}
+	}`)
+	image := publicMenuImage(&full, &thumb, meta).(map[string]any)
+	if image["src"] != full || image["blurHash"] != "LEHV6nWB2yk8pyo0adR*.7kCMdnj" || image["dominantColor"] != "#c4a877" {
+		t.Fatalf("unexpected image %+v", image)
+	}
+	sources := image["sources"].([]map[string]any)
+	if len(sources) != 2 || sources[0]["type"] != "image/webp" {
+		t.Fatalf("expected webp then jpeg sources, got %+v", sources)
+	}
+	if got := sources[0]["srcset"]; got != "https://cdn/m/full-480.webp 480w, https://cdn/m/full-960.webp 960w" {
+		t.Fatalf("unexpected webp srcset %q", got)
+	}
+	thumbSources := image["thumb"].(map[string]any)["sources"].([]map[string]any)
+	if len(thumbSources) != 1 || thumbSources[0]["srcset"] != "https://cdn/m/thumb-300.webp 300w" {
+		t.Fatalf("unexpected thumb sources %+v", thumbSources)
+	}
+
+	legacy := []byte(`{"format":"jpeg","source":{},"variants":[
+		{"dpr": 2, "width": 600, "height": 600, "url": "https://cdn/t2.jpg"},
+		{"dpr": 1, "width": 300, "height": 300, "url": "https://cdn/t1.jpg"}
+	]}`)
+	image = publicMenuImage(&full, &thumb, legacy).(map[string]any)
+	if len(image["sources"].([]map[string]any)) != 0 || image["blurHash"] != nil {
+		t.Fatalf("legacy image should have no full sources or placeholder: %+v", image)
+	}
+	thumbSources = image["thumb"].(map[string]any)["sources"].([]map[string]any)
+	if len(thumbSources) != 1 || thumbSources[0]["srcset"] != "https://cdn/t1.jpg 300w, https://cdn/t2.jpg 600w" {
+		t.Fatalf("unexpected legacy thumb sources %+v", thumbSources)
+	}
+}
+
+func TestMenuImageMetaURLs(t *testing.T) {
+	meta := []byte(`{"variants":[{"url":"a"},{"url":null}],"renditions":[{"url":"b"},{"url":" "}]}`)
+	urls := menuImageMetaURLs(meta)
+	if len(urls) != 2 || urls[0] != "a" || urls[1] != "b" {
+		t.Fatalf("unexpected urls %v", urls)
+	}
+	if menuImageMetaURLs([]byte(`not json`)) != nil {
+		t.Fatalf("expected nil for invalid meta")
+	}
+}
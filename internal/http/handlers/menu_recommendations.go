@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"genfity-order-services/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	recommendationRulePin     = "PIN"
+	recommendationRuleExclude = "EXCLUDE"
+
+	recommendationReasonPinned         = "PINNED"
+	recommendationReasonBoughtTogether = "BOUGHT_TOGETHER"
+	recommendationReasonPopularNow     = "POPULAR_NOW"
+	recommendationReasonPopular        = "POPULAR"
+
+	recommendationWindowDays      = 90
+	recommendationMinSupport      = 2
+	recommendationRefreshInterval = 6 * time.Hour
+	recommendationSweepInterval   = 10 * time.Minute
+	defaultRecommendationLimit    = 5
+	maxRecommendationLimit        = 20
+
+	// Share of the score taken by co-purchase confidence; the rest is
+	// popularity. Within popularity, the current hour slot outweighs
+	// all-time counts.
+	recommendationAssociationWeight = 0.7
+	recommendationSlotWeight        = 0.7
+)
+
+type recommendationCandidate struct {
+	MenuID int64
+	// Best confidence that the menu is ordered with any cart item.
+	Confidence  float64
+	Support     int64
+	SlotOrders  float64
+	TotalOrders float64
+	Pinned      bool
+	PinPosition int32
+}
+
+type rankedRecommendation struct {
+	MenuID  int64
+	Score   float64
+	Reason  string
+	Support int64
+}
+
+// rankRecommendations orders candidates for display: pinned menus first in
+// their pinned order, then by a blend of co-purchase confidence and
+// time-of-day popularity. Candidates with no signal at all are dropped.
+func rankRecommendations(candidates []recommendationCandidate, limit int) []rankedRecommendation {
+	maxSlot, maxTotal := 0.0, 0.0
+	for _, c := range candidates {
+		if c.SlotOrders > maxSlot {
+			maxSlot = c.SlotOrders
+		}
+		if c.TotalOrders > maxTotal {
+			maxTotal = c.TotalOrders
+		}
+	}
+
+	pinned := make([]recommendationCandidate, 0)
+	ranked := make([]rankedRecommendation, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Pinned {
+			pinned = append(pinned, c)
+			continue
+		}
+		popularNow, popular := 0.0, 0.0
+		if maxSlot > 0 {
+			popularNow = c.SlotOrders / maxSlot
+		}
+		if maxTotal > 0 {
+			popular = c.TotalOrders / maxTotal
+		}
+		popularity := recommendationSlotWeight*popularNow + (1-recommendationSlotWeight)*popular
+		score := recommendationAssociationWeight*c.Confidence + (1-recommendationAssociationWeight)*popularity
+		if score <= 0 {
+			continue
+		}
+		reason := recommendationReasonPopular
+		switch {
+		case c.Confidence > 0:
+			reason = recommendationReasonBoughtTogether
+		case popularNow > 0:
+			reason = recommendationReasonPopularNow
+		}
+		ranked = append(ranked, rankedRecommendation{MenuID: c.MenuID, Score: score, Reason: reason, Support: c.Support})
+	}
+
+	sort.Slice(pinned, func(i, j int) bool {
+		if pinned[i].PinPosition == pinned[j].PinPosition {
+			return pinned[i].MenuID < pinned[j].MenuID
+		}
+		return pinned[i].PinPosition < pinned[j].PinPosition
+	})
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score == ranked[j].Score {
+			return ranked[i].MenuID < ranked[j].MenuID
+		}
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	out := make([]rankedRecommendation, 0, limit)
+	for _, c := range pinned {
+		if len(out) == limit {
+			return out
+		}
+		out = append(out, rankedRecommendation{MenuID: c.MenuID, Score: 1, Reason: recommendationReasonPinned, Support: c.Support})
+	}
+	for _, r := range ranked {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// menuScheduledAt reports whether a menu's availability window includes the
+// merchant-local time now. Days use time.Weekday numbering. A window that
+// ends before it starts runs past midnight and belongs to the day it starts.
+func menuScheduledAt(enabled bool, start, end string, days []int32, now time.Time) bool {
+	if !enabled || !isValidHHMM(start) || !isValidHHMM(end) {
+		return true
+	}
+	current := now.Format("15:04")
+	day := now.Weekday()
+	switch {
+	case start <= end:
+		if current < start || current >= end {
+			return false
+		}
+	case current >= start:
+	case current < end:
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+type menuRecommendation struct {
+	rankedRecommendation
+	Name     string
+	Price    float64
+	ImageURL pgtype.Text
+}
+
+// recommendMenus ranks the merchant's orderable menus for a cart. An empty
+// cart gets what is popular at this merchant-local hour.
+func (h *Handler) recommendMenus(ctx context.Context, merchantID int64, loc *time.Location, cartIDs []int64, limit int) ([]menuRecommendation, error) {
+	now := time.Now().In(loc)
+	if cartIDs == nil {
+		cartIDs = []int64{}
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select m.id, m.name, m.price, m.image_url,
+		       m.schedule_enabled, m.schedule_start_time, m.schedule_end_time, m.schedule_days,
+		       r.rule, coalesce(r.position, 0)
+		from menus m
+		left join menu_recommendation_rules r on r.merchant_id = m.merchant_id and r.menu_id = m.id
+		where m.merchant_id = $1
+		  and m.is_active = true
+		  and m.deleted_at is null
+		  and (m.track_stock = false or m.stock_qty is null or m.stock_qty > 0)
+		  and not (m.id = any($2))
+	`, merchantID, cartIDs)
+	if err != nil {
+		return nil, err
+	}
+	menus := make(map[int64]menuRecommendation)
+	candidates := make(map[int64]*recommendationCandidate)
+	for rows.Next() {
+		var (
+			menu            menuRecommendation
+			price           pgtype.Numeric
+			scheduleEnabled bool
+			scheduleStart   pgtype.Text
+			scheduleEnd     pgtype.Text
+			scheduleDays    []int32
+			rule            pgtype.Text
+			position        int32
+		)
+		if err := rows.Scan(&menu.MenuID, &menu.Name, &price, &menu.ImageURL,
+			&scheduleEnabled, &scheduleStart, &scheduleEnd, &scheduleDays, &rule, &position); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if rule.String == recommendationRuleExclude ||
+			!menuScheduledAt(scheduleEnabled, scheduleStart.String, scheduleEnd.String, scheduleDays, now) {
+			continue
+		}
+		menu.Price = utils.NumericToFloat64(price)
+		menus[menu.MenuID] = menu
+		candidates[menu.MenuID] = &recommendationCandidate{
+			MenuID:      menu.MenuID,
+			Pinned:      rule.String == recommendationRulePin,
+			PinPosition: position,
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return []menuRecommendation{}, nil
+	}
+
+	if len(cartIDs) > 0 {
+		// Lift at or below 1 means the pair is no more common than chance.
+		assocRows, err := h.DB.Query(ctx, `
+			select related_menu_id, max(confidence), max(support_count)
+			from menu_association_scores
+			where merchant_id = $1 and menu_id = any($2) and lift > 1
+			group by related_menu_id
+		`, merchantID, cartIDs)
+		if err != nil {
+			return nil, err
+		}
+		for assocRows.Next() {
+			var (
+				menuID     int64
+				confidence float64
+				support    int64
+			)
+			if err := assocRows.Scan(&menuID, &confidence, &support); err != nil {
+				assocRows.Close()
+				return nil, err
+			}
+			if c := candidates[menuID]; c != nil {
+				c.Confidence = confidence
+				c.Support = support
+			}
+		}
+		assocRows.Close()
+		if err := assocRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	hour := now.Hour()
+	popRows, err := h.DB.Query(ctx, `
+		select menu_id,
+		       (coalesce(sum(order_count) filter (where day_of_week = $2 and hour = $3), 0)
+		         + 0.5 * coalesce(sum(order_count) filter (where day_of_week = $2 and hour in ($4, $5)), 0)
+		         + 0.25 * coalesce(sum(order_count) filter (where day_of_week <> $2 and hour = $3), 0))::float8,
+		       sum(order_count)::float8
+		from menu_popularity_slots
+		where merchant_id = $1
+		group by menu_id
+	`, merchantID, int(now.Weekday()), hour, (hour+23)%24, (hour+1)%24)
+	if err != nil {
+		return nil, err
+	}
+	for popRows.Next() {
+		var (
+			menuID      int64
+			slotOrders  float64
+			totalOrders float64
+		)
+		if err := popRows.Scan(&menuID, &slotOrders, &totalOrders); err != nil {
+			popRows.Close()
+			return nil, err
+		}
+		if c := candidates[menuID]; c != nil {
+			c.SlotOrders = slotOrders
+			c.TotalOrders = totalOrders
+		}
+	}
+	popRows.Close()
+	if err := popRows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]recommendationCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		list = append(list, *c)
+	}
+	ranked := rankRecommendations(list, limit)
+	out := make([]menuRecommendation, 0, len(ranked))
+	for _, r := range ranked {
+		menu := menus[r.MenuID]
+		menu.rankedRecommendation = r
+		out = append(out, menu)
+	}
+	return out, nil
+}
+
+// RunRecommendationSweeper rebuilds stale recommendation scores in the
+// background.
+func (h *Handler) RunRecommendationSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = recommendationSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepRecommendations(ctx)
+		}
+	}
+}
+
+func (h *Handler) sweepRecommendations(ctx context.Context) {
+	rows, err := h.DB.Query(ctx, `
+		select m.id
+		from merchants m
+		left join merchant_recommendation_runs rr on rr.merchant_id = m.id
+		where m.is_active = true
+		  and (rr.computed_at is null or rr.computed_at < now() - make_interval(secs => $1))
+	`, recommendationRefreshInterval.Seconds())
+	if err != nil {
+		h.Logger.Warn("recommendation sweep failed", zapError(err))
+		return
+	}
+	merchantIDs := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			h.Logger.Warn("recommendation sweep scan failed", zapError(err))
+			return
+		}
+		merchantIDs = append(merchantIDs, id)
+	}
+	rows.Close()
+
+	for _, merchantID := range merchantIDs {
+		if _, err := h.refreshMerchantRecommendations(ctx, merchantID, false); err != nil {
+			h.Logger.Warn("recommendation refresh failed", zapError(err))
+		}
+	}
+}
+
+// refreshMerchantRecommendations rebuilds the merchant's association scores
+// and popularity slots. Unless forced it first claims the refresh, so a
+// merchant refreshed by another replica in the meantime is skipped.
+func (h *Handler) refreshMerchantRecommendations(ctx context.Context, merchantID int64, force bool) (*time.Time, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		timezone   string
+		computedAt time.Time
+	)
+	if err := tx.QueryRow(ctx, `select timezone from merchants where id = $1`, merchantID).Scan(&timezone); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx, `
+		insert into merchant_recommendation_runs (merchant_id, computed_at)
+		values ($1, now())
+		on conflict (merchant_id) do update set computed_at = excluded.computed_at
+		where $2 or merchant_recommendation_runs.computed_at < now() - make_interval(secs => $3)
+		returning computed_at
+	`, merchantID, force, recommendationRefreshInterval.Seconds()).Scan(&computedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `delete from menu_association_scores where merchant_id = $1`, merchantID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+		with baskets as (
+			select distinct o.id as order_id, oi.menu_id
+			from orders o
+			join order_items oi on oi.order_id = o.id
+			where o.merchant_id = $1
+			  and o.status in ('ACCEPTED', 'COMPLETED', 'READY')
+			  and o.placed_at >= now() - make_interval(days => $2)
+			  and oi.menu_id is not null
+		),
+		totals as (
+			select count(distinct order_id)::float8 as orders from baskets
+		),
+		item_counts as (
+			select menu_id, count(*)::float8 as orders from baskets group by menu_id
+		),
+		pairs as (
+			select a.menu_id, b.menu_id as related_menu_id, count(*) as together
+			from baskets a
+			join baskets b on b.order_id = a.order_id and b.menu_id <> a.menu_id
+			group by a.menu_id, b.menu_id
+			having count(*) >= $3
+		)
+		insert into menu_association_scores (merchant_id, menu_id, related_menu_id, support_count, confidence, lift, computed_at)
+		select $1::bigint, p.menu_id, p.related_menu_id, p.together,
+		       p.together / ia.orders,
+		       (p.together / ia.orders) / (ib.orders / t.orders),
+		       now()
+		from pairs p
+		join item_counts ia on ia.menu_id = p.menu_id
+		join item_counts ib on ib.menu_id = p.related_menu_id
+		cross join totals t
+	`, merchantID, recommendationWindowDays, recommendationMinSupport); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `delete from menu_popularity_slots where merchant_id = $1`, merchantID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+		insert into menu_popularity_slots (merchant_id, menu_id, day_of_week, hour, order_count)
+		select $1::bigint, oi.menu_id,
+		       extract(dow from t.local_at)::smallint,
+		       extract(hour from t.local_at)::smallint,
+		       count(distinct o.id)
+		from orders o
+		join order_items oi on oi.order_id = o.id
+		cross join lateral (select (o.placed_at at time zone 'UTC') at time zone $3 as local_at) t
+		where o.merchant_id = $1
+		  and o.status in ('ACCEPTED', 'COMPLETED', 'READY')
+		  and o.placed_at >= now() - make_interval(days => $2)
+		  and oi.menu_id is not null
+		group by oi.menu_id, 3, 4
+	`, merchantID, recommendationWindowDays, timezoneOrDefault(timezone)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &computedAt, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankRecommendations(t *testing.T) {
+	candidates := []recommendationCandidate{
+		{MenuID: 1, Confidence: 0.6, Support: 12, SlotOrders: 1, TotalOrders: 40},
+		{MenuID: 2, SlotOrders: 10, TotalOrders: 100},
+		{MenuID: 3, TotalOrders: 20},
+		{MenuID: 4},
+		{MenuID: 5, Pinned: true, PinPosition: 1},
+		{MenuID: 6, Pinned: true, PinPosition: 0},
+	}
+	got := rankRecommendations(candidates, 10)
+	want := []struct {
+		id     int64
+		reason string
+	}{
+		{6, recommendationReasonPinned},
+		{5, recommendationReasonPinned},
+		{1, recommendationReasonBoughtTogether},
+		{2, recommendationReasonPopularNow},
+		{3, recommendationReasonPopular},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d recommendations, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].MenuID != w.id || got[i].Reason != w.reason {
+			t.Fatalf("position %d: got %d/%s, want %d/%s", i, got[i].MenuID, got[i].Reason, w.id, w.reason)
+		}
+	}
+
+	if limited := rankRecommendations(candidates, 3); len(limited) != 3 || limited[2].MenuID != 1 {
+		t.Fatalf("limited ranking %+v", limited)
+	}
+}
+
+func TestRankRecommendationsEmptyCart(t *testing.T) {
+	candidates := []recommendationCandidate{
+		{MenuID: 1, SlotOrders: 2, TotalOrders: 200},
+		{MenuID: 2, SlotOrders: 8, TotalOrders: 50},
+	}
+	got := rankRecommendations(candidates, 5)
+	if len(got) != 2 || got[0].MenuID != 2 {
+		t.Fatalf("popular now should lead: %+v", got)
+	}
+}
+
+func TestMenuScheduledAt(t *testing.T) {
+	// 2026-03-09 is a Monday.
+	at := func(day int, hhmm string) time.Time {
+		parsed, _ := time.Parse("15:04", hhmm)
+		return time.Date(2026, 3, 9+day-1, parsed.Hour(), parsed.Minute(), 0, 0, time.UTC)
+	}
+	weekdays := []int32{1, 2, 3, 4, 5}
+	cases := []struct {
+		name       string
+		enabled    bool
+		start, end string
+		days       []int32
+		now        time.Time
+		want       bool
+	}{
+		{"no schedule", false, "", "", nil, at(1, "03:00"), true},
+		{"inside window", true, "10:00", "14:00", nil, at(1, "11:30"), true},
+		{"at end", true, "10:00", "14:00", nil, at(1, "14:00"), false},
+		{"wrong day", true, "10:00", "14:00", weekdays, at(6, "11:00"), false},
+		{"overnight evening", true, "22:00", "02:00", weekdays, at(5, "23:00"), true},
+		{"overnight after midnight", true, "22:00", "02:00", weekdays, at(6, "01:00"), true},
+		{"overnight after midnight wrong day", true, "22:00", "02:00", weekdays, at(1, "01:00"), false},
+		{"overnight gap", true, "22:00", "02:00", nil, at(1, "12:00"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := menuScheduledAt(tc.enabled, tc.start, tc.end, tc.days, tc.now); got != tc.want {
+				t.Fatalf("scheduled %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateRecommendationRules(t *testing.T) {
+	if err := validateRecommendationRules(recommendationRulesPayload{Pinned: []int64{1, 2}, Excluded: []int64{3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateRecommendationRules(recommendationRulesPayload{Pinned: []int64{1}, Excluded: []int64{1}}); err == nil {
+		t.Fatal("expected error for a menu both pinned and excluded")
+	}
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	menuSearchMinScore        = 20
+	menuSearchSynonymWeight   = 0.9
+	menuSearchMaxVariants     = 12
+	menuSearchIndexMaxEntries = 500
+	menuSearchMaxMissLength   = 100
+)
+
+// Letters that do not decompose into a base letter plus marks.
+var searchTransliterations = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "đ", "d", "ł", "l", "ı", "i", "þ", "th",
+)
+
+// foldSearchText lowercases s, strips accents and diacritics and collapses
+// punctuation and whitespace to single spaces, so "Café  Latté" and
+// "cafe latte" compare equal.
+func foldSearchText(s string) string {
+	s = searchTransliterations.Replace(strings.ToLower(s))
+	var b strings.Builder
+	space := true
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type menuSearchText struct {
+	Name        string
+	Description string
+}
+
+type menuSearchDoc struct {
+	MenuID int64
+	// Folded source text first, then one entry per translated locale.
+	Texts    []menuSearchText
+	Keywords []string
+}
+
+type menuSearchIndex struct {
+	version  int64
+	docs     []menuSearchDoc
+	synonyms [][]string
+}
+
+type menuSearchVariant struct {
+	text   string
+	weight float64
+}
+
+// expandSearchQuery returns the folded query plus one variant per synonym
+// substitution. Terms match whole words, so "teh" does not expand inside
+// "tehnik". Synonym variants score slightly lower than the query as typed.
+func expandSearchQuery(query string, synonyms [][]string) []menuSearchVariant {
+	variants := []menuSearchVariant{{text: query, weight: 1}}
+	seen := map[string]bool{query: true}
+	padded := " " + query + " "
+	for _, group := range synonyms {
+		for _, term := range group {
+			if !strings.Contains(padded, " "+term+" ") {
+				continue
+			}
+			for _, other := range group {
+				if other == term {
+					continue
+				}
+				text := strings.TrimSpace(strings.Replace(padded, " "+term+" ", " "+other+" ", 1))
+				if seen[text] {
+					continue
+				}
+				seen[text] = true
+				variants = append(variants, menuSearchVariant{text: text, weight: menuSearchSynonymWeight})
+				if len(variants) >= menuSearchMaxVariants {
+					return variants
+				}
+			}
+		}
+	}
+	return variants
+}
+
+func keywordRelevance(query, keyword string) float64 {
+	switch {
+	case keyword == query:
+		return 95
+	case strings.HasPrefix(keyword, query):
+		return 75
+	case strings.Contains(keyword, query):
+		return 55
+	}
+	return 0
+}
+
+func scoreMenuSearchDoc(variants []menuSearchVariant, doc menuSearchDoc) float64 {
+	best := 0.0
+	for _, variant := range variants {
+		score := 0.0
+		for _, text := range doc.Texts {
+			var description *string
+			if text.Description != "" {
+				description = &text.Description
+			}
+			score = math.Max(score, calculateRelevance(variant.text, text.Name, description))
+		}
+		for _, keyword := range doc.Keywords {
+			score = math.Max(score, keywordRelevance(variant.text, keyword))
+		}
+		best = math.Max(best, score*variant.weight)
+	}
+	return best
+}
+
+// search scores every menu in the index and returns those above the minimum
+// relevance.
+func (idx *menuSearchIndex) search(query string) map[int64]float64 {
+	variants := expandSearchQuery(foldSearchText(query), idx.synonyms)
+	scores := make(map[int64]float64)
+	for _, doc := range idx.docs {
+		if score := scoreMenuSearchDoc(variants, doc); score > menuSearchMinScore {
+			scores[doc.MenuID] = score
+		}
+	}
+	return scores
+}
+
+// Search indexes are built per merchant and reused while the merchant's menu
+// version (see public_menu_cache.go) is unchanged. The version is read on
+// every search, so replicas never serve an index older than the database.
+var (
+	menuSearchIndexMu sync.Mutex
+	menuSearchIndexes = map[int64]*menuSearchIndex{}
+)
+
+func (h *Handler) menuSearchIndexFor(ctx context.Context, merchantID, version int64) (*menuSearchIndex, error) {
+	menuSearchIndexMu.Lock()
+	cached := menuSearchIndexes[merchantID]
+	menuSearchIndexMu.Unlock()
+	if cached != nil && cached.version == version {
+		return cached, nil
+	}
+
+	idx, err := h.buildMenuSearchIndex(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	idx.version = version
+
+	menuSearchIndexMu.Lock()
+	defer menuSearchIndexMu.Unlock()
+	if current := menuSearchIndexes[merchantID]; current != nil && current.version > version {
+		return idx, nil
+	}
+	menuSearchIndexes[merchantID] = idx
+	if len(menuSearchIndexes) > menuSearchIndexMaxEntries {
+		menuSearchIndexes = map[int64]*menuSearchIndex{merchantID: idx}
+	}
+	return idx, nil
+}
+
+func (h *Handler) buildMenuSearchIndex(ctx context.Context, merchantID int64) (*menuSearchIndex, error) {
+	rows, err := h.DB.Query(ctx, `
+		select m.id, m.name, m.description, coalesce(msk.keywords, '{}')
+		from menus m
+		left join menu_search_keywords msk on msk.menu_id = m.id
+		where m.merchant_id = $1 and m.is_active = true and m.deleted_at is null
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	idx := &menuSearchIndex{}
+	positions := make(map[int64]int)
+	for rows.Next() {
+		var (
+			id          int64
+			name        string
+			description pgtype.Text
+			keywords    []string
+		)
+		if err := rows.Scan(&id, &name, &description, &keywords); err != nil {
+			return nil, err
+		}
+		doc := menuSearchDoc{
+			MenuID: id,
+			Texts:  []menuSearchText{{Name: foldSearchText(name), Description: foldSearchText(description.String)}},
+		}
+		for _, keyword := range keywords {
+			if folded := foldSearchText(keyword); folded != "" {
+				doc.Keywords = append(doc.Keywords, folded)
+			}
+		}
+		positions[id] = len(idx.docs)
+		idx.docs = append(idx.docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	translationRows, err := h.DB.Query(ctx, `
+		select entity_id, field, locale, value
+		from content_translations
+		where merchant_id = $1 and entity_type = $2 and field = any($3)
+	`, merchantID, translationEntityMenu, []string{translationFieldName, translationFieldDescription})
+	if err != nil {
+		return nil, err
+	}
+	defer translationRows.Close()
+
+	type localeKey struct {
+		menuID int64
+		locale string
+	}
+	translated := make(map[localeKey]*menuSearchText)
+	order := make([]localeKey, 0)
+	for translationRows.Next() {
+		var (
+			menuID              int64
+			field, locale, text string
+		)
+		if err := translationRows.Scan(&menuID, &field, &locale, &text); err != nil {
+			return nil, err
+		}
+		pos, ok := positions[menuID]
+		if !ok {
+			continue
+		}
+		key := localeKey{menuID: menuID, locale: locale}
+		entry := translated[key]
+		if entry == nil {
+			source := idx.docs[pos].Texts[0]
+			entry = &menuSearchText{Name: source.Name, Description: source.Description}
+			translated[key] = entry
+			order = append(order, key)
+		}
+		if field == translationFieldName {
+			entry.Name = foldSearchText(text)
+		} else {
+			entry.Description = foldSearchText(text)
+		}
+	}
+	if err := translationRows.Err(); err != nil {
+		return nil, err
+	}
+	for _, key := range order {
+		pos := positions[key.menuID]
+		idx.docs[pos].Texts = append(idx.docs[pos].Texts, *translated[key])
+	}
+
+	synonyms, err := h.loadMenuSearchSynonyms(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range synonyms {
+		folded := make([]string, 0, len(group))
+		for _, term := range group {
+			if term = foldSearchText(term); term != "" {
+				folded = append(folded, term)
+			}
+		}
+		if len(folded) > 1 {
+			idx.synonyms = append(idx.synonyms, folded)
+		}
+	}
+	return idx, nil
+}
+
+func (h *Handler) loadMenuSearchSynonyms(ctx context.Context, merchantID int64) ([][]string, error) {
+	rows, err := h.DB.Query(ctx, `
+		select terms from menu_search_synonyms where merchant_id = $1 order by id
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([][]string, 0)
+	for rows.Next() {
+		var terms []string
+		if err := rows.Scan(&terms); err != nil {
+			return nil, err
+		}
+		groups = append(groups, terms)
+	}
+	return groups, rows.Err()
+}
+
+// recordMenuSearchMiss counts a query that matched no menu text, for the
+// search misses analytics. Failures are logged and otherwise ignored.
+func (h *Handler) recordMenuSearchMiss(ctx context.Context, merchantID int64, query string) {
+	folded := []rune(foldSearchText(query))
+	if len(folded) == 0 {
+		return
+	}
+	if len(folded) > menuSearchMaxMissLength {
+		folded = folded[:menuSearchMaxMissLength]
+	}
+	if _, err := h.DB.Exec(ctx, `
+		insert into menu_search_misses (merchant_id, query, day)
+		values ($1, $2, current_date)
+		on conflict (merchant_id, query, day)
+		do update set count = menu_search_misses.count + 1, last_searched_at = now()
+	`, merchantID, strings.TrimSpace(string(folded))); err != nil {
+		h.Logger.Warn("menu search miss insert failed", zapError(err))
+	}
+}
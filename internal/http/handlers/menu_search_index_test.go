@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFoldSearchText(t *testing.T) {
+	cases := map[string]string{
+		"Café  Latté":       "cafe latte",
+		"CRÈME brûlée!":     "creme brulee",
+		"Straße":            "strasse",
+		"Smørrebrød":        "smorrebrod",
+		"Phở bò":            "pho bo",
+		"Kid's Meal (L)":    "kids meal l",
+		"  es-teh   manis ": "es teh manis",
+	}
+	for input, want := range cases {
+		if got := foldSearchText(input); got != want {
+			t.Fatalf("fold %q = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestExpandSearchQuery(t *testing.T) {
+	synonyms := [][]string{{"es teh", "iced tea"}, {"kopi", "coffee"}}
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"es teh manis", []string{"es teh manis", "iced tea manis"}},
+		{"coffee", []string{"coffee", "kopi"}},
+		{"tehnik", []string{"tehnik"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			variants := expandSearchQuery(tc.query, synonyms)
+			got := make([]string, 0, len(variants))
+			for _, v := range variants {
+				got = append(got, v.text)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("variants %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMenuSearchIndexSearch(t *testing.T) {
+	idx := &menuSearchIndex{
+		docs: []menuSearchDoc{
+			{MenuID: 1, Texts: []menuSearchText{{Name: "iced tea"}}},
+			{MenuID: 2, Texts: []menuSearchText{{Name: "kopi susu"}}, Keywords: []string{"ks"}},
+			{MenuID: 3, Texts: []menuSearchText{{Name: "nasi goreng"}, {Name: "fried rice"}}},
+			{MenuID: 4, Texts: []menuSearchText{{Name: "creme brulee"}}},
+		},
+		synonyms: [][]string{{"es teh", "iced tea"}, {"kopi", "coffee"}},
+	}
+	cases := []struct {
+		query string
+		want  int64
+	}{
+		{"Es Teh", 1},
+		{"coffee", 2},
+		{"KS", 2},
+		{"fried rice", 3},
+		{"crème brûlée", 4},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			scores := idx.search(tc.query)
+			best, bestScore := int64(0), 0.0
+			for id, score := range scores {
+				if score > bestScore {
+					best, bestScore = id, score
+				}
+			}
+			if best != tc.want {
+				t.Fatalf("best match %d (%v), want %d", best, scores, tc.want)
+			}
+		})
+	}
+	if scores := idx.search("pizza"); len(scores) != 0 {
+		t.Fatalf("unexpected matches %v", scores)
+	}
+}
+
+func TestNormalizeSynonymGroups(t *testing.T) {
+	groups, err := normalizeSynonymGroups([][]string{{" Kopi ", "coffee", "KOPI"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(groups, [][]string{{"Kopi", "coffee"}}) {
+		t.Fatalf("groups %v", groups)
+	}
+	if _, err := normalizeSynonymGroups([][]string{{"kopi", "Kopí"}}); err == nil {
+		t.Fatal("expected error for a group with one distinct term")
+	}
+}
@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	menuVariantMaxPerMenu    = 20
+	menuVariantMaxNameLength = 60
+	menuVariantMaxSKULength  = 64
+)
+
+// menuVariant is a priced option of a menu item such as a size. When a menu
+// has active variants, order items must pick one and are charged its price
+// instead of the menu price.
+type menuVariant struct {
+	ID                 int64    `json:"id"`
+	MenuID             int64    `json:"menuId"`
+	Name               string   `json:"name"`
+	SKU                *string  `json:"sku"`
+	Price              float64  `json:"price"`
+	PromoPrice         *float64 `json:"promoPrice"`
+	DisplayOrder       int32    `json:"displayOrder"`
+	IsActive           bool     `json:"isActive"`
+	TrackStock         bool     `json:"trackStock"`
+	StockQty           *int32   `json:"stockQty"`
+	DailyStockTemplate *int32   `json:"dailyStockTemplate"`
+	AutoResetStock     bool     `json:"autoResetStock"`
+}
+
+func (v menuVariant) effectivePrice() float64 {
+	if v.PromoPrice != nil {
+		return *v.PromoPrice
+	}
+	return v.Price
+}
+
+type menuVariantInput struct {
+	ID                 any      `json:"id"`
+	Name               string   `json:"name"`
+	SKU                *string  `json:"sku"`
+	Price              *float64 `json:"price"`
+	DisplayOrder       *int32   `json:"displayOrder"`
+	IsActive           *bool    `json:"isActive"`
+	TrackStock         *bool    `json:"trackStock"`
+	StockQty           *int32   `json:"stockQty"`
+	DailyStockTemplate *int32   `json:"dailyStockTemplate"`
+	AutoResetStock     *bool    `json:"autoResetStock"`
+}
+
+// normalizeMenuVariantInputs validates a full variant list. Variants without
+// an id are new. A nil stockQty on a tracked variant keeps its current stock
+// (zero for new variants).
+func normalizeMenuVariantInputs(inputs []menuVariantInput) ([]menuVariant, error) {
+	if len(inputs) > menuVariantMaxPerMenu {
+		return nil, fmt.Errorf("A menu can have at most %d variants", menuVariantMaxPerMenu)
+	}
+	names := make(map[string]bool, len(inputs))
+	skus := make(map[string]bool, len(inputs))
+	ids := make(map[int64]bool, len(inputs))
+	out := make([]menuVariant, 0, len(inputs))
+
+	for i, input := range inputs {
+		var v menuVariant
+		if input.ID != nil {
+			id, ok := parseNumericID(input.ID)
+			if !ok {
+				return nil, errors.New("Invalid variant id")
+			}
+			if ids[id] {
+				return nil, fmt.Errorf("Variant %d is listed twice", id)
+			}
+			ids[id] = true
+			v.ID = id
+		}
+
+		v.Name = strings.TrimSpace(input.Name)
+		if v.Name == "" {
+			return nil, errors.New("Variant name is required")
+		}
+		if len(v.Name) > menuVariantMaxNameLength {
+			return nil, fmt.Errorf("Variant name must be at most %d characters", menuVariantMaxNameLength)
+		}
+		key := strings.ToLower(v.Name)
+		if names[key] {
+			return nil, fmt.Errorf("Variant name %q is used twice", v.Name)
+		}
+		names[key] = true
+
+		if input.SKU != nil {
+			sku := strings.TrimSpace(*input.SKU)
+			if len(sku) > menuVariantMaxSKULength {
+				return nil, fmt.Errorf("SKU must be at most %d characters", menuVariantMaxSKULength)
+			}
+			if sku != "" {
+				if skus[strings.ToLower(sku)] {
+					return nil, fmt.Errorf("SKU %q is used twice", sku)
+				}
+				skus[strings.ToLower(sku)] = true
+				v.SKU = &sku
+			}
+		}
+
+		if input.Price == nil || math.IsNaN(*input.Price) || math.IsInf(*input.Price, 0) || *input.Price < 0 {
+			return nil, fmt.Errorf("Variant %q needs a valid price", v.Name)
+		}
+		v.Price = round2(*input.Price)
+
+		v.DisplayOrder = int32(i)
+		if input.DisplayOrder != nil {
+			v.DisplayOrder = *input.DisplayOrder
+		}
+		v.IsActive = input.IsActive == nil || *input.IsActive
+		v.TrackStock = input.TrackStock != nil && *input.TrackStock
+		if v.TrackStock {
+			if input.StockQty != nil && *input.StockQty < 0 {
+				return nil, fmt.Errorf("Variant %q stock cannot be negative", v.Name)
+			}
+			if input.DailyStockTemplate != nil && *input.DailyStockTemplate < 0 {
+				return nil, fmt.Errorf("Variant %q daily stock cannot be negative", v.Name)
+			}
+			v.StockQty = input.StockQty
+			v.DailyStockTemplate = input.DailyStockTemplate
+			v.AutoResetStock = input.AutoResetStock != nil && *input.AutoResetStock && v.DailyStockTemplate != nil
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// resolveMenuVariant picks the ordered variant of a menu. Menus without
+// active variants take no variant; menus with them require one.
+func resolveMenuVariant(menuName string, variants []menuVariant, requested any, quantity int32) (*menuVariant, error) {
+	hasActive := false
+	for _, v := range variants {
+		if v.IsActive {
+			hasActive = true
+			break
+		}
+	}
+
+	if requested == nil {
+		if hasActive {
+			return nil, errInvalid(fmt.Sprintf("Please choose a variant for %s", menuName))
+		}
+		return nil, nil
+	}
+	if s, ok := requested.(string); ok && strings.TrimSpace(s) == "" {
+		if hasActive {
+			return nil, errInvalid(fmt.Sprintf("Please choose a variant for %s", menuName))
+		}
+		return nil, nil
+	}
+
+	variantID, ok := parseNumericID(requested)
+	if !ok {
+		return nil, errInvalid("Invalid variantId")
+	}
+	for i := range variants {
+		v := variants[i]
+		if v.ID != variantID {
+			continue
+		}
+		if !v.IsActive {
+			return nil, errInvalid(fmt.Sprintf("%s (%s) is not available", menuName, v.Name))
+		}
+		if v.TrackStock && (v.StockQty == nil || *v.StockQty < quantity) {
+			return nil, errInvalid("Insufficient stock")
+		}
+		return &v, nil
+	}
+	return nil, errInvalid("Variant not found")
+}
+
+// fetchMenuVariants loads the non-deleted variants of the given menus with
+// their current special price, if any.
+func (h *Handler) fetchMenuVariants(ctx context.Context, merchantID int64, menuIDs []int64, activeOnly bool) (map[int64][]menuVariant, error) {
+	out := make(map[int64][]menuVariant)
+	if len(menuIDs) == 0 {
+		return out, nil
+	}
+	rows, err := h.DB.Query(ctx, `
+		select v.id, v.menu_id, v.name, v.sku, v.price, v.display_order, v.is_active,
+			v.track_stock, v.stock_qty, v.daily_stock_template, v.auto_reset_stock,
+			(
+				select spv.promo_price
+				from special_price_variant_items spv
+				join special_prices sp on sp.id = spv.special_price_id
+				where spv.variant_id = v.id
+				  and sp.merchant_id = v.merchant_id
+				  and sp.is_active = true
+				  and sp.start_date <= current_date
+				  and sp.end_date >= current_date
+				order by spv.promo_price asc
+				limit 1
+			)
+		from menu_variants v
+		where v.merchant_id = $1 and v.menu_id = any($2) and v.deleted_at is null
+		  and (not $3 or v.is_active)
+		order by v.menu_id, v.display_order, v.id
+	`, merchantID, menuIDs, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			v          menuVariant
+			sku        pgtype.Text
+			price      pgtype.Numeric
+			stockQty   pgtype.Int4
+			template   pgtype.Int4
+			promoPrice pgtype.Numeric
+		)
+		if err := rows.Scan(&v.ID, &v.MenuID, &v.Name, &sku, &price, &v.DisplayOrder, &v.IsActive,
+			&v.TrackStock, &stockQty, &template, &v.AutoResetStock, &promoPrice); err != nil {
+			return nil, err
+		}
+		v.SKU = textPtr(sku)
+		v.Price = utils.NumericToFloat64(price)
+		v.StockQty = int4Ptr(stockQty)
+		v.DailyStockTemplate = int4Ptr(template)
+		if promoPrice.Valid {
+			promo := utils.NumericToFloat64(promoPrice)
+			v.PromoPrice = &promo
+		}
+		out[v.MenuID] = append(out[v.MenuID], v)
+	}
+	return out, rows.Err()
+}
+
+// decrementOrderItemStock is the best-effort stock decrement after an order
+// is placed: the menu's own stock and, when one was ordered, the variant's.
+func (h *Handler) decrementOrderItemStock(ctx context.Context, menuID int64, variantID *int64, quantity int32) {
+	h.decrementPOSStock(ctx, menuID, quantity)
+	if variantID == nil {
+		h.checkStockAlerts(ctx, stockAlertRef{ItemType: stockAlertMenu, ID: menuID})
+		return
+	}
+	_, _ = h.DB.Exec(ctx, `
+		update menu_variants
+		set stock_qty = stock_qty - $2, is_active = stock_qty - $2 > 0, updated_at = now()
+		where id = $1 and track_stock = true and stock_qty is not null
+	`, *variantID, quantity)
+	h.checkStockAlerts(ctx, stockAlertRef{ItemType: stockAlertMenu, ID: menuID}, stockAlertRef{ItemType: stockAlertVariant, ID: *variantID})
+}
+
+// insertOrderItemVariant records the variant an order item was ordered in.
+func insertOrderItemVariant(ctx context.Context, tx pgx.Tx, orderItemID int64, variantID *int64, variantName *string) error {
+	if variantID == nil {
+		return nil
+	}
+	name := ""
+	if variantName != nil {
+		name = *variantName
+	}
+	_, err := tx.Exec(ctx, `
+		insert into order_item_variants (order_item_id, variant_id, variant_name) values ($1, $2, $3)
+	`, orderItemID, *variantID, name)
+	return err
+}
+
+func variantPayloads(variants []menuVariant) []menuVariant {
+	if variants == nil {
+		return []menuVariant{}
+	}
+	return variants
+}
+
+// MerchantMenuVariants lists a menu's variants, including inactive ones.
+func (h *Handler) MerchantMenuVariants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+
+	var exists bool
+	if err := h.DB.QueryRow(ctx, `
+		select exists(select 1 from menus where id = $1 and merchant_id = $2 and deleted_at is null)
+	`, menuID, *authCtx.MerchantID).Scan(&exists); err != nil || !exists {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	variants, err := h.fetchMenuVariants(ctx, *authCtx.MerchantID, []int64{menuID}, false)
+	if err != nil {
+		h.Logger.Error("menu variants query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve variants")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       variantPayloads(variants[menuID]),
+		"message":    "Variants retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuVariantsReplace replaces a menu's variant list. Listed variants
+// with an id are updated, those without are created, and variants left out
+// are deleted. An empty list removes all variants.
+func (h *Handler) MerchantMenuVariantsReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	menuID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid menu id")
+		return
+	}
+
+	var body struct {
+		Variants []menuVariantInput `json:"variants"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	variants, err := normalizeMenuVariantInputs(body.Variants)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var menuExists bool
+	if err := tx.QueryRow(ctx, `
+		select exists(select 1 from menus where id = $1 and merchant_id = $2 and deleted_at is null)
+	`, menuID, *authCtx.MerchantID).Scan(&menuExists); err != nil || !menuExists {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	existing := make(map[int64]bool)
+	rows, err := tx.Query(ctx, `
+		select id from menu_variants where menu_id = $1 and deleted_at is null for update
+	`, menuID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+		return
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			existing[id] = true
+		}
+	}
+	rows.Close()
+
+	keep := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		if v.ID > 0 && !existing[v.ID] {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("Variant %d not found on this menu", v.ID))
+			return
+		}
+		keep = append(keep, v.ID)
+	}
+
+	// Removed variants are deleted first so their names and SKUs can be
+	// reused by new ones in the same request.
+	if _, err := tx.Exec(ctx, `
+		update menu_variants set deleted_at = now(), updated_at = now()
+		where menu_id = $1 and deleted_at is null and not (id = any($2))
+	`, menuID, keep); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+		return
+	}
+
+	for _, v := range variants {
+		if v.ID > 0 {
+			_, err = tx.Exec(ctx, `
+				update menu_variants set
+					name = $2, sku = $3, price = $4, display_order = $5, is_active = $6,
+					track_stock = $7,
+					stock_qty = case when $7 then coalesce($8, stock_qty, 0) else null end,
+					daily_stock_template = $9, auto_reset_stock = $10,
+					updated_at = now()
+				where id = $1
+			`, v.ID, v.Name, v.SKU, v.Price, v.DisplayOrder, v.IsActive,
+				v.TrackStock, v.StockQty, v.DailyStockTemplate, v.AutoResetStock)
+		} else {
+			_, err = tx.Exec(ctx, `
+				insert into menu_variants (
+					merchant_id, menu_id, name, sku, price, display_order, is_active,
+					track_stock, stock_qty, daily_stock_template, auto_reset_stock
+				) values (
+					$1, $2, $3, $4, $5, $6, $7,
+					$8, case when $8 then coalesce($9, 0) else null end, $10, $11
+				)
+			`, *authCtx.MerchantID, menuID, v.Name, v.SKU, v.Price, v.DisplayOrder, v.IsActive,
+				v.TrackStock, v.StockQty, v.DailyStockTemplate, v.AutoResetStock)
+		}
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				response.Error(w, http.StatusConflict, "SKU_EXISTS", "A variant with this SKU already exists")
+				return
+			}
+			h.Logger.Error("menu variant write failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		update menus set updated_at = now(), updated_by_user_id = $2 where id = $1
+	`, menuID, authCtx.UserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update variants")
+		return
+	}
+
+	updated, err := h.fetchMenuVariants(ctx, *authCtx.MerchantID, []int64{menuID}, false)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve variants")
+		return
+	}
+	alertRefs := make([]stockAlertRef, 0, len(updated[menuID]))
+	for _, v := range updated[menuID] {
+		alertRefs = append(alertRefs, stockAlertRef{ItemType: stockAlertVariant, ID: v.ID})
+	}
+	h.checkStockAlerts(ctx, alertRefs...)
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       variantPayloads(updated[menuID]),
+		"message":    "Variants updated successfully",
+		"statusCode": 200,
+	})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMenuVariantInputs(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	stock := func(v int32) *int32 { return &v }
+	sku := func(v string) *string { return &v }
+	yes := true
+
+	valid := []menuVariantInput{
+		{ID: "7", Name: " Large ", SKU: sku(" TEA-L "), Price: price(12.345), TrackStock: &yes, StockQty: stock(5), DailyStockTemplate: stock(10), AutoResetStock: &yes},
+		{Name: "Small", SKU: sku(""), Price: price(0)},
+	}
+	got, err := normalizeMenuVariantInputs(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].ID != 7 || got[0].Name != "Large" || *got[0].SKU != "TEA-L" || got[0].Price != 12.35 {
+		t.Fatalf("unexpected first variant: %+v", got[0])
+	}
+	if !got[0].AutoResetStock || *got[0].StockQty != 5 {
+		t.Fatalf("expected tracked stock on first variant: %+v", got[0])
+	}
+	if got[1].ID != 0 || got[1].SKU != nil || got[1].DisplayOrder != 1 || !got[1].IsActive || got[1].TrackStock {
+		t.Fatalf("unexpected second variant: %+v", got[1])
+	}
+
+	cases := []struct {
+		name   string
+		inputs []menuVariantInput
+	}{
+		{"missing price", []menuVariantInput{{Name: "Large"}}},
+		{"negative price", []menuVariantInput{{Name: "Large", Price: price(-1)}}},
+		{"blank name", []menuVariantInput{{Name: " ", Price: price(1)}}},
+		{"duplicate name", []menuVariantInput{{Name: "Large", Price: price(1)}, {Name: "large", Price: price(2)}}},
+		{"duplicate sku", []menuVariantInput{{Name: "A", SKU: sku("x"), Price: price(1)}, {Name: "B", SKU: sku("X"), Price: price(1)}}},
+		{"duplicate id", []menuVariantInput{{ID: 3, Name: "A", Price: price(1)}, {ID: "3", Name: "B", Price: price(1)}}},
+		{"invalid id", []menuVariantInput{{ID: "abc", Name: "A", Price: price(1)}}},
+		{"long name", []menuVariantInput{{Name: strings.Repeat("a", menuVariantMaxNameLength+1), Price: price(1)}}},
+		{"negative stock", []menuVariantInput{{Name: "A", Price: price(1), TrackStock: &yes, StockQty: stock(-1)}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := normalizeMenuVariantInputs(tc.inputs); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+
+	tooMany := make([]menuVariantInput, menuVariantMaxPerMenu+1)
+	for i := range tooMany {
+		tooMany[i] = menuVariantInput{Name: strings.Repeat("v", i+1), Price: price(1)}
+	}
+	if _, err := normalizeMenuVariantInputs(tooMany); err == nil {
+		t.Fatalf("expected error for too many variants")
+	}
+}
+
+func TestResolveMenuVariant(t *testing.T) {
+	stock := int32(2)
+	promo := 9.5
+	variants := []menuVariant{
+		{ID: 1, Name: "Regular", Price: 10, PromoPrice: &promo, IsActive: true},
+		{ID: 2, Name: "Large", Price: 14, IsActive: true, TrackStock: true, StockQty: &stock},
+		{ID: 3, Name: "Jumbo", Price: 18, IsActive: false},
+	}
+
+	cases := []struct {
+		name      string
+		variants  []menuVariant
+		requested any
+		quantity  int32
+		wantID    int64
+		wantErr   string
+	}{
+		{"no variants", nil, nil, 1, 0, ""},
+		{"only inactive variants", variants[2:], "", 1, 0, ""},
+		{"variant required", variants, nil, 1, 0, "choose a variant"},
+		{"blank variant", variants, " ", 1, 0, "choose a variant"},
+		{"string id", variants, "1", 1, 1, ""},
+		{"numeric id", variants, float64(2), 2, 2, ""},
+		{"insufficient stock", variants, 2, 3, 0, "Insufficient stock"},
+		{"inactive", variants, 3, 1, 0, "not available"},
+		{"unknown", variants, 9, 1, 0, "Variant not found"},
+		{"invalid", variants, "x", 1, 0, "Invalid variantId"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveMenuVariant("Tea", tc.variants, tc.requested, tc.quantity)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantID == 0 {
+				if got != nil {
+					t.Fatalf("expected no variant, got %+v", got)
+				}
+				return
+			}
+			if got == nil || got.ID != tc.wantID {
+				t.Fatalf("expected variant %d, got %+v", tc.wantID, got)
+			}
+		})
+	}
+
+	if got := variants[0].effectivePrice(); got != 9.5 {
+		t.Fatalf("expected promo price, got %v", got)
+	}
+	if got := variants[1].effectivePrice(); got != 14 {
+		t.Fatalf("expected base price, got %v", got)
+	}
+}
+
+func TestBuildMenuVariantPerformance(t *testing.T) {
+	large, small := int64(11), int64(12)
+	menus := map[int64]*menuPerformanceRow{1: {MenuID: 1, MenuName: "Tea"}}
+	items := []orderItemSnapshot{
+		{MenuID: 1, VariantID: &large, VariantName: "Large", Quantity: 3, Subtotal: 42},
+		{MenuID: 1, VariantID: &small, VariantName: "Small", Quantity: 1, Subtotal: 8},
+		{MenuID: 1, VariantID: &large, VariantName: "Large", Quantity: 1, Subtotal: 14},
+		{MenuID: 1, Quantity: 5, Subtotal: 50},
+		{MenuID: 2, VariantID: &small, Quantity: 9, Subtotal: 90},
+	}
+
+	got := buildMenuVariantPerformance(items, menus)
+	if len(got) != 2 {
+		t.Fatalf("expected two variants, got %+v", got)
+	}
+	if got[0]["variantId"] != "11" || got[0]["quantitySold"] != 4 || got[0]["revenue"] != 56.0 {
+		t.Fatalf("unexpected top variant: %+v", got[0])
+	}
+	if got[0]["menuSharePercentage"] != 40.0 || got[1]["menuSharePercentage"] != 10.0 {
+		t.Fatalf("unexpected menu shares: %+v", got)
+	}
+}
@@ -1449,6 +1449,7 @@ func (h *Handler) MerchantAddonItemsUpdate(w http.ResponseWriter, r *http.Reques
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update addon item")
 		return
 	}
+	h.checkStockAlerts(ctx, stockAlertRef{ItemType: stockAlertAddonItem, ID: itemID})
 
 	item, err := h.fetchAddonItemByID(ctx, *authCtx.MerchantID, itemID)
 	if err != nil {
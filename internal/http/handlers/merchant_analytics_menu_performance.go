@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"genfity-order-services/internal/middleware"
@@ -25,6 +26,7 @@ type menuPerformanceRow struct {
 	LastOrderDate    *time.Time
 	CreatedAt        time.Time
 	IsActive         bool
+	BundleAllocation string
 }
 
 func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *http.Request) {
@@ -39,12 +41,17 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 	if period == "" {
 		period = "month"
 	}
+	bundleAllocation := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("bundleAllocation")))
+	if bundleAllocation != "" && !isValidBundleAllocation(bundleAllocation) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "bundleAllocation must be LIST_PRICE, EQUAL or BUNDLE")
+		return
+	}
 
 	now := time.Now()
 	startDate := startDateForMenuPeriod(now, period)
 	prevStart, prevEnd := previousMenuPeriod(now, period, startDate)
 	cacheBucket := now.Truncate(5 * time.Minute)
-	cacheKey := analyticsCacheKey("menu_performance", *authCtx.MerchantID, period, startDate.Format("2006-01-02"), cacheBucket.Format(time.RFC3339))
+	cacheKey := analyticsCacheKey("menu_performance", *authCtx.MerchantID, period, startDate.Format("2006-01-02"), bundleAllocation, cacheBucket.Format(time.RFC3339))
 	if cached, ok := getAnalyticsCache(cacheKey); ok {
 		response.JSON(w, http.StatusOK, cached)
 		return
@@ -69,6 +76,15 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 		return
 	}
 
+	components, err := h.fetchOrderItemComponents(ctx, bundleOrderItemIDs(orderItems, menus))
+	if err != nil {
+		h.Logger.Error("menu performance components fetch failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch menu performance analytics")
+		return
+	}
+	soldItems := len(orderItems)
+	orderItems = attributeBundleRevenue(orderItems, components, menus, bundleAllocation)
+
 	previousQuantities, err := h.loadMenuPerformancePrevious(ctx, *authCtx.MerchantID, prevStart, prevEnd)
 	if err != nil {
 		h.Logger.Error("menu performance previous fetch failed", zapError(err))
@@ -83,6 +99,9 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 		}
 		row.QuantitySold += int(item.Quantity)
 		row.Revenue += item.Subtotal
+		if item.FromBundle {
+			continue
+		}
 		row.TotalOrders += 1
 		if count := orderItemAddonCounts[item.ID]; count > 0 {
 			row.OrdersWithAddons += 1
@@ -187,7 +206,8 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 	})
 
 	categoryPerformance := buildMenuCategoryPerformance(performance, totalRevenue)
-	addonPerformance := h.buildAddonPerformance(ctx, *authCtx.MerchantID, startDate, now, len(orderItems))
+	variantPerformance := buildMenuVariantPerformance(orderItems, menus)
+	addonPerformance := h.buildAddonPerformance(ctx, *authCtx.MerchantID, startDate, now, soldItems)
 	salesTrendByItem := buildMenuSalesTrend(performance, previousQuantities)
 
 	payload := map[string]any{
@@ -197,6 +217,7 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 			"topPerformers":       topPerformers,
 			"lowPerformers":       lowPerformers,
 			"categoryPerformance": categoryPerformance,
+			"variantPerformance":  variantPerformance,
 			"addonPerformance":    addonPerformance,
 			"salesTrendByItem":    salesTrendByItem,
 			"neverOrdered":        neverOrdered,
@@ -206,6 +227,12 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 			"startDate":    startDate.Format(time.RFC3339),
 			"endDate":      now.Format(time.RFC3339),
 			"daysInPeriod": daysInPeriod,
+			"bundleAllocation": func() any {
+				if bundleAllocation == "" {
+					return nil
+				}
+				return bundleAllocation
+			}(),
 		},
 	}
 	setAnalyticsCache(cacheKey, payload, 5*time.Minute)
@@ -213,17 +240,77 @@ func (h *Handler) MerchantMenuPerformanceAnalytics(w http.ResponseWriter, r *htt
 }
 
 type orderItemSnapshot struct {
-	ID       int64
-	MenuID   int64
-	Quantity int32
-	Subtotal float64
-	PlacedAt time.Time
+	ID          int64
+	MenuID      int64
+	VariantID   *int64
+	VariantName string
+	MenuPrice   float64
+	Quantity    int32
+	Subtotal    float64
+	PlacedAt    time.Time
+	FromBundle  bool
+}
+
+// bundleOrderItemIDs returns the order items sold as bundle menus.
+func bundleOrderItemIDs(items []orderItemSnapshot, menus map[int64]*menuPerformanceRow) []int64 {
+	ids := make([]int64, 0)
+	for _, item := range items {
+		if row := menus[item.MenuID]; row != nil && row.BundleAllocation != "" {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// attributeBundleRevenue moves the menu price part of each bundle item to its
+// components, which are appended as FromBundle snapshots. The bundle keeps
+// its quantity and whatever is not attributed (addons, BUNDLE allocation).
+// override replaces the per-bundle allocation method when set.
+func attributeBundleRevenue(items []orderItemSnapshot, components map[int64][]orderItemComponent, menus map[int64]*menuPerformanceRow, override string) []orderItemSnapshot {
+	out := make([]orderItemSnapshot, 0, len(items))
+	for _, item := range items {
+		row := menus[item.MenuID]
+		list := components[item.ID]
+		if row == nil || row.BundleAllocation == "" || len(list) == 0 {
+			out = append(out, item)
+			continue
+		}
+		method := row.BundleAllocation
+		if override != "" {
+			method = override
+		}
+		shares := allocateBundleRevenue(round2(item.MenuPrice*float64(item.Quantity)), item.Quantity, list, method)
+		bundle := item
+		for _, share := range shares {
+			bundle.Subtotal -= share
+		}
+		bundle.Subtotal = round2(bundle.Subtotal)
+		out = append(out, bundle)
+		for i, share := range shares {
+			entry := orderItemSnapshot{
+				MenuID:     list[i].MenuID,
+				VariantID:  list[i].VariantID,
+				MenuPrice:  list[i].ListPrice,
+				Quantity:   list[i].Quantity * item.Quantity,
+				Subtotal:   share,
+				PlacedAt:   item.PlacedAt,
+				FromBundle: true,
+			}
+			if list[i].VariantName != nil {
+				entry.VariantName = *list[i].VariantName
+			}
+			out = append(out, entry)
+		}
+	}
+	return out
 }
 
 func (h *Handler) loadMenuPerformanceBase(ctx context.Context, merchantID int64) (map[int64]*menuPerformanceRow, error) {
 	rows, err := h.DB.Query(ctx, `
-		select m.id, m.name, m.price, m.is_active, m.created_at, mc.name
+		select m.id, m.name, m.price, m.is_active, m.created_at, mc.name,
+			case when bs.is_bundle then bs.revenue_allocation else '' end
 		from menus m
+		left join menu_bundle_settings bs on bs.menu_id = m.id
 		left join menu_category_items mci on mci.menu_id = m.id
 		left join menu_categories mc on mc.id = mci.category_id
 		where m.merchant_id = $1 and m.deleted_at is null
@@ -243,19 +330,21 @@ func (h *Handler) loadMenuPerformanceBase(ctx context.Context, merchantID int64)
 			isActive  bool
 			createdAt time.Time
 			category  pgtype.Text
+			bundle    string
 		)
-		if err := rows.Scan(&id, &name, &price, &isActive, &createdAt, &category); err != nil {
+		if err := rows.Scan(&id, &name, &price, &isActive, &createdAt, &category, &bundle); err != nil {
 			continue
 		}
 		row := menus[id]
 		if row == nil {
 			row = &menuPerformanceRow{
-				MenuID:       id,
-				MenuName:     name,
-				CategoryName: textOrDefault(category, "Uncategorized"),
-				Price:        utils.NumericToFloat64(price),
-				CreatedAt:    createdAt,
-				IsActive:     isActive,
+				MenuID:           id,
+				MenuName:         name,
+				CategoryName:     textOrDefault(category, "Uncategorized"),
+				Price:            utils.NumericToFloat64(price),
+				CreatedAt:        createdAt,
+				IsActive:         isActive,
+				BundleAllocation: bundle,
 			}
 			menus[id] = row
 		}
@@ -268,9 +357,10 @@ func (h *Handler) loadMenuPerformanceBase(ctx context.Context, merchantID int64)
 
 func (h *Handler) loadMenuPerformanceOrders(ctx context.Context, merchantID int64, startDate, endDate time.Time) ([]orderItemSnapshot, map[int64]int, error) {
 	rows, err := h.DB.Query(ctx, `
-		select oi.id, oi.menu_id, oi.quantity, oi.subtotal, o.placed_at
+		select oi.id, oi.menu_id, oiv.variant_id, oiv.variant_name, oi.menu_price, oi.quantity, oi.subtotal, o.placed_at
 		from orders o
 		join order_items oi on oi.order_id = o.id
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
 		where o.merchant_id = $1 and o.status = 'COMPLETED' and o.placed_at >= $2 and o.placed_at <= $3
 	`, merchantID, startDate, endDate)
 	if err != nil {
@@ -282,21 +372,27 @@ func (h *Handler) loadMenuPerformanceOrders(ctx context.Context, merchantID int6
 	orderItemIDs := make([]int64, 0)
 	for rows.Next() {
 		var (
-			id       int64
-			menuID   int64
-			quantity int32
-			subtotal pgtype.Numeric
-			placedAt time.Time
+			id          int64
+			menuID      int64
+			variantID   pgtype.Int8
+			variantName pgtype.Text
+			menuPrice   pgtype.Numeric
+			quantity    int32
+			subtotal    pgtype.Numeric
+			placedAt    time.Time
 		)
-		if err := rows.Scan(&id, &menuID, &quantity, &subtotal, &placedAt); err != nil {
+		if err := rows.Scan(&id, &menuID, &variantID, &variantName, &menuPrice, &quantity, &subtotal, &placedAt); err != nil {
 			continue
 		}
 		items = append(items, orderItemSnapshot{
-			ID:       id,
-			MenuID:   menuID,
-			Quantity: quantity,
-			Subtotal: utils.NumericToFloat64(subtotal),
-			PlacedAt: placedAt,
+			ID:          id,
+			MenuID:      menuID,
+			VariantID:   int8Ptr(variantID),
+			VariantName: variantName.String,
+			MenuPrice:   utils.NumericToFloat64(menuPrice),
+			Quantity:    quantity,
+			Subtotal:    utils.NumericToFloat64(subtotal),
+			PlacedAt:    placedAt,
 		})
 		orderItemIDs = append(orderItemIDs, id)
 	}
@@ -459,6 +555,61 @@ func buildMenuCategoryPerformance(performance []*menuPerformanceRow, totalRevenu
 	return categories
 }
 
+// buildMenuVariantPerformance breaks sales down by the variant ordered. The
+// menu share is the variant's portion of its menu's quantity sold.
+func buildMenuVariantPerformance(items []orderItemSnapshot, menus map[int64]*menuPerformanceRow) []map[string]any {
+	type variantStats struct {
+		menuID      int64
+		variantID   int64
+		variantName string
+		quantity    int
+		revenue     float64
+	}
+	stats := make(map[int64]*variantStats)
+	menuQuantity := make(map[int64]int)
+	for _, item := range items {
+		if menus[item.MenuID] == nil {
+			continue
+		}
+		menuQuantity[item.MenuID] += int(item.Quantity)
+		if item.VariantID == nil {
+			continue
+		}
+		entry := stats[*item.VariantID]
+		if entry == nil {
+			entry = &variantStats{menuID: item.MenuID, variantID: *item.VariantID, variantName: item.VariantName}
+			stats[*item.VariantID] = entry
+		}
+		entry.quantity += int(item.Quantity)
+		entry.revenue += item.Subtotal
+	}
+
+	result := make([]map[string]any, 0, len(stats))
+	for _, entry := range stats {
+		menuShare := 0.0
+		if total := menuQuantity[entry.menuID]; total > 0 {
+			menuShare = (float64(entry.quantity) / float64(total)) * 100
+		}
+		result = append(result, map[string]any{
+			"menuId":              int64ToString(entry.menuID),
+			"menuName":            menus[entry.menuID].MenuName,
+			"variantId":           int64ToString(entry.variantID),
+			"variantName":         entry.variantName,
+			"quantitySold":        entry.quantity,
+			"revenue":             entry.revenue,
+			"menuSharePercentage": menuShare,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		qi, qj := result[i]["quantitySold"].(int), result[j]["quantitySold"].(int)
+		if qi != qj {
+			return qi > qj
+		}
+		return result[i]["variantId"].(string) < result[j]["variantId"].(string)
+	})
+	return result
+}
+
 func buildMenuSalesTrend(performance []*menuPerformanceRow, previous map[int64]int) []map[string]any {
 	trends := make([]map[string]any, 0)
 	for _, row := range performance {
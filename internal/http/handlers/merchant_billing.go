@@ -600,6 +600,15 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 		limit = 20
 	}
 
+	cursorMode, cursor, err := readKeysetCursor(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid cursor")
+		return
+	}
+	if cursorMode {
+		offset = 0
+	}
+
 	transactionType := strings.TrimSpace(r.URL.Query().Get("type"))
 	startDate := strings.TrimSpace(r.URL.Query().Get("startDate"))
 	endDate := strings.TrimSpace(r.URL.Query().Get("endDate"))
@@ -608,16 +617,24 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 
 	var balanceID int64
 	if err := h.DB.QueryRow(ctx, "select id from merchant_balances where merchant_id = $1", *authCtx.MerchantID).Scan(&balanceID); err != nil {
+		pagination := map[string]any{
+			"total":   0,
+			"limit":   limit,
+			"offset":  offset,
+			"hasMore": false,
+		}
+		if cursorMode {
+			pagination = map[string]any{
+				"limit":      limit,
+				"nextCursor": nil,
+				"hasMore":    false,
+			}
+		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"success": true,
 			"data": map[string]any{
 				"transactions": []any{},
-				"pagination": map[string]any{
-					"total":   0,
-					"limit":   limit,
-					"offset":  offset,
-					"hasMore": false,
-				},
+				"pagination":   pagination,
 				"pendingCount": 0,
 			},
 		})
@@ -653,22 +670,39 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 
 	where = append(where, "not (type = 'SUBSCRIPTION' and amount = 0 and description ilike '%days subscription%')")
 
-	countQuery := "select count(*) from balance_transactions where " + strings.Join(where, " and ")
 	var transactionTotal int
-	if err := h.DB.QueryRow(ctx, countQuery, args...).Scan(&transactionTotal); err != nil {
-		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get transactions")
-		return
-	}
-
+	var query string
 	argsWithPaging := append([]any{}, args...)
-	argsWithPaging = append(argsWithPaging, limit, offset)
-	query := fmt.Sprintf(`
+	if cursorMode {
+		if cursor != nil {
+			var condition string
+			condition, argsWithPaging = keysetCondition("created_at", "id", cursor, argsWithPaging)
+			where = append(where, condition)
+		}
+		argsWithPaging = append(argsWithPaging, limit+1)
+		query = fmt.Sprintf(`
         select id, type, amount, balance_before, balance_after, description, created_at, payment_request_id
         from balance_transactions
         where %s
-        order by created_at desc
+        order by created_at desc, id desc
+        limit $%d
+    `, strings.Join(where, " and "), len(argsWithPaging))
+	} else {
+		countQuery := "select count(*) from balance_transactions where " + strings.Join(where, " and ")
+		if err := h.DB.QueryRow(ctx, countQuery, args...).Scan(&transactionTotal); err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get transactions")
+			return
+		}
+
+		argsWithPaging = append(argsWithPaging, limit, offset)
+		query = fmt.Sprintf(`
+        select id, type, amount, balance_before, balance_after, description, created_at, payment_request_id
+        from balance_transactions
+        where %s
+        order by created_at desc, id desc
         limit $%d offset $%d
     `, strings.Join(where, " and "), len(argsWithPaging)-1, len(argsWithPaging))
+	}
 
 	rows, err := h.DB.Query(ctx, query, argsWithPaging...)
 	if err != nil {
@@ -678,6 +712,8 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 	defer rows.Close()
 
 	transactions := make([]map[string]any, 0)
+	var lastCreatedAt time.Time
+	var lastID int64
 	for rows.Next() {
 		var (
 			id               int64
@@ -690,6 +726,9 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 			paymentRequestID pgtype.Int8
 		)
 		if err := rows.Scan(&id, &rowType, &amount, &balanceBefore, &balanceAfter, &description, &createdAt, &paymentRequestID); err == nil {
+			if cursorMode && len(transactions) < limit {
+				lastCreatedAt, lastID = createdAt, id
+			}
 			transactions = append(transactions, map[string]any{
 				"id":               fmt.Sprint(id),
 				"type":             rowType,
@@ -711,7 +750,7 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 
 	pendingRequests := make([]map[string]any, 0)
 	pendingTotal := 0
-	if includePending && offset == 0 {
+	if includePending && offset == 0 && cursor == nil {
 		pendingWhere := "merchant_id = $1 and status in ('PENDING','CONFIRMED','REJECTED')"
 		pendingArgs := []any{*authCtx.MerchantID}
 
@@ -764,6 +803,36 @@ func (h *Handler) MerchantBalanceTransactions(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	if cursorMode {
+		// Pending payment requests ride along on the first page only and do
+		// not count against the page size, so the cursor always points at
+		// the last balance transaction returned.
+		hasMore := len(transactions) > limit
+		transactions = sliceTransactions(transactions, limit)
+		var nextCursor *string
+		if hasMore {
+			encoded := encodeKeysetCursor(lastCreatedAt, lastID)
+			nextCursor = &encoded
+		}
+
+		merged := append(pendingRequests, transactions...)
+		sortTransactionsByDate(merged)
+
+		response.JSON(w, http.StatusOK, map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"transactions": merged,
+				"pagination": map[string]any{
+					"limit":      limit,
+					"nextCursor": nextCursor,
+					"hasMore":    hasMore,
+				},
+				"pendingCount": pendingTotal,
+			},
+		})
+		return
+	}
+
 	merged := append(pendingRequests, transactions...)
 	sortTransactionsByDate(merged)
 
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (h *Handler) MerchantCourierSettingsGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var features []byte
+	if err := h.DB.QueryRow(ctx, `select features from merchants where id = $1`, *authCtx.MerchantID).Scan(&features); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       h.buildCourierSettingsResponse(parseCourierSettings(features)),
+		"message":    "Courier settings retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantCourierSettingsPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var body courierSettings
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if err := validateCourierSettings(&body, h.Couriers.Names()); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if len(body.Zones) > 0 {
+		zoneIDs := make([]int64, 0, len(body.Zones))
+		for _, zone := range body.Zones {
+			zoneIDs = append(zoneIDs, zone.ZoneID)
+		}
+		var found int
+		if err := h.DB.QueryRow(ctx, `
+			select count(*) from merchant_delivery_zones where merchant_id = $1 and id = any($2)
+		`, *authCtx.MerchantID, zoneIDs).Scan(&found); err != nil {
+			h.Logger.Error("courier settings zone lookup failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update courier settings")
+			return
+		}
+		if found != len(zoneIDs) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Zone entries must reference this merchant's delivery zones")
+			return
+		}
+	}
+
+	// Only the courierDispatch key is replaced, so concurrent writes to other
+	// feature settings are not lost.
+	settingsJSON, _ := json.Marshal(body)
+
+	var updatedFeatures []byte
+	if err := h.DB.QueryRow(ctx, `
+		update merchants
+		set features = jsonb_set(
+				case when jsonb_typeof(features) = 'object' then features else '{}'::jsonb end,
+				'{courierDispatch}', $1::jsonb),
+			updated_at = now()
+		where id = $2
+		returning features
+	`, settingsJSON, *authCtx.MerchantID).Scan(&updatedFeatures); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+			return
+		}
+		h.Logger.Error("courier settings update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update courier settings")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       h.buildCourierSettingsResponse(parseCourierSettings(updatedFeatures)),
+		"message":    "Courier settings updated successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) buildCourierSettingsResponse(settings courierSettings) map[string]any {
+	zones := settings.Zones
+	if zones == nil {
+		zones = []courierZoneFleet{}
+	}
+	return map[string]any{
+		"defaultMode":        settings.DefaultMode,
+		"provider":           settings.Provider,
+		"autoBook":           settings.AutoBook,
+		"zones":              zones,
+		"availableProviders": h.Couriers.Names(),
+	}
+}
+
+// MerchantOrderCourierQuote prices a delivery order with a courier. Without a
+// provider in the body, the one the order's zone routes to is used.
+func (h *Handler) MerchantOrderCourierQuote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	var body struct {
+		Provider string `json:"provider"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	o, err := h.loadCourierOrder(ctx, *authCtx.MerchantID, orderID)
+	if err != nil || o.OrderType != "DELIVERY" {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Delivery order not found")
+		return
+	}
+	if !o.hasCoordinates() {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order and merchant coordinates are required for a courier quote")
+		return
+	}
+
+	route, err := h.routeCourierOrder(ctx, o)
+	if err != nil {
+		h.Logger.Error("courier route lookup failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to quote courier delivery")
+		return
+	}
+	providerName := strings.TrimSpace(body.Provider)
+	if providerName == "" {
+		providerName = route.Provider
+	}
+	provider, ok := h.Couriers.Get(providerName)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Courier provider is not available")
+		return
+	}
+
+	quote, err := provider.Quote(ctx, o.quoteRequest())
+	if err != nil {
+		h.Logger.Warn("courier quote failed", zapError(err))
+		response.Error(w, http.StatusBadGateway, "COURIER_ERROR", "Courier provider could not quote this delivery")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"provider":       provider.Name(),
+			"fee":            quote.Fee,
+			"pickupMinutes":  quote.PickupMinutes,
+			"dropoffMinutes": quote.DropoffMinutes,
+			"distanceKm":     o.distanceKm(),
+			"routedMode":     route.Mode,
+			"routedZoneId":   route.ZoneID,
+		},
+		"message":    "Courier quote retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func writeCourierBookingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errCourierUnavailable):
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Courier provider is not available")
+	case errors.Is(err, errCourierOrderInvalid):
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "This order cannot be handed to a courier")
+	case errors.Is(err, errCourierNoLocation):
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order and merchant coordinates are required to book a courier")
+	case errors.Is(err, errCourierAlreadyBooked):
+		response.Error(w, http.StatusConflict, "INVALID_STATE", "Order already has an active courier booking")
+	default:
+		response.Error(w, http.StatusBadGateway, "COURIER_ERROR", "Courier provider could not book this delivery")
+	}
+}
@@ -22,12 +22,13 @@ type deliveryZonePoint struct {
 }
 
 type deliveryZonePayload struct {
-	ID       any    `json:"id"`
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	RadiusKm any    `json:"radiusKm"`
-	Polygon  any    `json:"polygon"`
-	IsActive *bool  `json:"isActive"`
+	ID       any            `json:"id"`
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	RadiusKm any            `json:"radiusKm"`
+	Polygon  any            `json:"polygon"`
+	Geometry map[string]any `json:"geometry"`
+	IsActive *bool          `json:"isActive"`
 }
 
 type geoJSONFeatureCollection struct {
@@ -136,7 +137,15 @@ func (h *Handler) MerchantDeliveryZonesUpsert(w http.ResponseWriter, r *http.Req
 	}
 
 	radiusKm, _ := parseOptionalFloat(body.RadiusKm)
-	polygonPoints, polygonErr := parsePolygon(body.Polygon)
+	var (
+		geometry    zoneGeometry
+		geometryErr error
+	)
+	if body.Geometry != nil {
+		geometry, geometryErr = parsePolygonFromGeoJSON(body.Geometry)
+	} else {
+		geometry, geometryErr = parseZoneGeometry(body.Polygon)
+	}
 
 	if zoneType == "RADIUS" {
 		if radiusKm == nil {
@@ -165,16 +174,12 @@ func (h *Handler) MerchantDeliveryZonesUpsert(w http.ResponseWriter, r *http.Req
 	}
 
 	if zoneType == "POLYGON" {
-		if polygonErr != nil || polygonPoints == nil {
+		if geometryErr != nil || geometry == nil {
 			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "polygon is required for POLYGON zones")
 			return
 		}
-		if len(polygonPoints) < 3 {
-			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "polygon must have at least 3 points")
-			return
-		}
-		if len(polygonPoints) > 250 {
-			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "polygon has too many points (max 250)")
+		if err := validateZoneGeometry(geometry); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
 			return
 		}
 	}
@@ -192,7 +197,7 @@ func (h *Handler) MerchantDeliveryZonesUpsert(w http.ResponseWriter, r *http.Req
 
 	var polygonJSON []byte
 	if zoneType == "POLYGON" {
-		data, err := json.Marshal(polygonPoints)
+		data, err := encodeZoneGeometry(geometry)
 		if err != nil {
 			h.Logger.Error("delivery zone polygon marshal failed", zapError(err))
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save delivery zone")
@@ -354,7 +359,8 @@ func (h *Handler) MerchantDeliveryZonesBulkImport(w http.ResponseWriter, r *http
 		if zoneTypeRaw == "" {
 			zoneTypeRaw = strings.ToUpper(strings.TrimSpace(toStringValue(props["type"])))
 		}
-		isPolygon := strings.EqualFold(toStringValue(feature.Geometry["type"]), "Polygon") || zoneTypeRaw == "POLYGON"
+		geometryType := toStringValue(feature.Geometry["type"])
+		isPolygon := strings.EqualFold(geometryType, "Polygon") || strings.EqualFold(geometryType, "MultiPolygon") || zoneTypeRaw == "POLYGON"
 
 		isActive := true
 		if val, ok := props["isActive"].(bool); ok {
@@ -363,8 +369,11 @@ func (h *Handler) MerchantDeliveryZonesBulkImport(w http.ResponseWriter, r *http
 
 		if isPolygon {
 			polygon, err := parsePolygonFromGeoJSON(feature.Geometry)
+			if err == nil {
+				err = validateZoneGeometry(polygon)
+			}
 			if err != nil {
-				response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid polygon for zone \""+name+"\" (must be a Polygon with 3-250 points)")
+				response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid polygon for zone \""+name+"\" (must be a Polygon or MultiPolygon with 3-250 points per ring)")
 				return
 			}
 			zones = append(zones, deliveryZonePayload{
@@ -422,7 +431,8 @@ func (h *Handler) MerchantDeliveryZonesBulkImport(w http.ResponseWriter, r *http
 	for _, zone := range zones {
 		var polygonJSON []byte
 		if zone.Type == "POLYGON" {
-			data, err := json.Marshal(zone.Polygon)
+			geometry, _ := zone.Polygon.(zoneGeometry)
+			data, err := encodeZoneGeometry(geometry)
 			if err != nil {
 				h.Logger.Error("delivery zone bulk polygon marshal failed", zapError(err))
 				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to bulk import delivery zones")
@@ -502,6 +512,100 @@ func (h *Handler) MerchantDeliveryZonesBulkImport(w http.ResponseWriter, r *http
 	})
 }
 
+// MerchantDeliveryZonesExport returns the merchant's zones as a GeoJSON
+// FeatureCollection that MerchantDeliveryZonesBulkImport accepts back.
+// Radius zones are exported as a Point at the merchant location.
+func (h *Handler) MerchantDeliveryZonesExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	if !authCtx.IsOwner {
+		response.Error(w, http.StatusForbidden, "FORBIDDEN", "Owner access required")
+		return
+	}
+
+	var (
+		merchantCode string
+		merchantLat  pgtype.Numeric
+		merchantLng  pgtype.Numeric
+	)
+	if err := h.DB.QueryRow(ctx, "select code, latitude, longitude from merchants where id = $1", *authCtx.MerchantID).Scan(&merchantCode, &merchantLat, &merchantLng); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+        select id, name, type, radius_km, polygon, is_active
+        from merchant_delivery_zones
+        where merchant_id = $1
+        order by created_at asc
+    `, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("delivery zones export query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export delivery zones")
+		return
+	}
+	defer rows.Close()
+
+	features := make([]geoJSONFeature, 0)
+	for rows.Next() {
+		var (
+			id       int64
+			name     string
+			zoneType string
+			radius   pgtype.Numeric
+			polygon  []byte
+			isActive bool
+		)
+		if err := rows.Scan(&id, &name, &zoneType, &radius, &polygon, &isActive); err != nil {
+			h.Logger.Error("delivery zones export scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export delivery zones")
+			return
+		}
+
+		properties := map[string]any{
+			"id":       id,
+			"name":     name,
+			"zoneType": zoneType,
+			"isActive": isActive,
+		}
+		var geometry map[string]any
+		switch zoneType {
+		case "POLYGON":
+			decoded, err := decodeZoneGeometry(polygon)
+			if err != nil {
+				continue
+			}
+			geometry = zoneGeometryToGeoJSON(decoded)
+		default:
+			properties["radiusKm"] = numericToNullableFloat(radius)
+			if merchantLat.Valid && merchantLng.Valid {
+				geometry = map[string]any{
+					"type":        "Point",
+					"coordinates": []float64{utils.NumericToFloat64(merchantLng), utils.NumericToFloat64(merchantLat)},
+				}
+			}
+		}
+		features = append(features, geoJSONFeature{Type: "Feature", Geometry: geometry, Properties: properties})
+	}
+
+	payload, err := json.Marshal(geoJSONFeatureCollection{Type: "FeatureCollection", Features: features})
+	if err != nil {
+		h.Logger.Error("delivery zones export marshal failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export delivery zones")
+		return
+	}
+
+	filename := fmt.Sprintf("delivery_zones_%s_%s.geojson", merchantCode, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload)
+}
+
 func parseOptionalFloat(value any) (*float64, error) {
 	if value == nil || value == "" {
 		return nil, nil
@@ -588,54 +692,6 @@ func parsePolygon(value any) ([]deliveryZonePoint, error) {
 	return points, nil
 }
 
-func parsePolygonFromGeoJSON(geometry map[string]any) ([]deliveryZonePoint, error) {
-	if geometry == nil || !strings.EqualFold(toStringValue(geometry["type"]), "Polygon") {
-		return nil, errors.New("invalid geometry")
-	}
-	coords, ok := geometry["coordinates"].([]any)
-	if !ok || len(coords) == 0 {
-		return nil, errors.New("invalid polygon")
-	}
-	ring, ok := coords[0].([]any)
-	if !ok || len(ring) < 4 {
-		return nil, errors.New("invalid polygon")
-	}
-
-	points := make([]deliveryZonePoint, 0, len(ring))
-	for _, raw := range ring {
-		pair, ok := raw.([]any)
-		if !ok || len(pair) < 2 {
-			return nil, errors.New("invalid polygon")
-		}
-		lng, err := parseOptionalFloat(pair[0])
-		if err != nil || lng == nil {
-			return nil, errors.New("invalid polygon")
-		}
-		lat, err := parseOptionalFloat(pair[1])
-		if err != nil || lat == nil {
-			return nil, errors.New("invalid polygon")
-		}
-		if *lat < -90 || *lat > 90 || *lng < -180 || *lng > 180 {
-			return nil, errors.New("invalid polygon")
-		}
-		points = append(points, deliveryZonePoint{Lat: *lat, Lng: *lng})
-	}
-
-	if len(points) >= 2 {
-		first := points[0]
-		last := points[len(points)-1]
-		if first.Lat == last.Lat && first.Lng == last.Lng {
-			points = points[:len(points)-1]
-		}
-	}
-
-	if len(points) < 3 || len(points) > 250 {
-		return nil, errors.New("invalid polygon")
-	}
-
-	return points, nil
-}
-
 func numericToNullableFloat(value pgtype.Numeric) any {
 	if !value.Valid {
 		return nil
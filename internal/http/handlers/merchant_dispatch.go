@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"genfity-order-services/internal/auth"
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type dispatchSettingsRequest struct {
+	Enabled             *bool `json:"enabled"`
+	OfferTimeoutSeconds *int  `json:"offerTimeoutSeconds"`
+}
+
+func (h *Handler) MerchantDispatchSettingsGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var features []byte
+	if err := h.DB.QueryRow(ctx, `select features from merchants where id = $1`, *authCtx.MerchantID).Scan(&features); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       buildDispatchSettingsResponse(*authCtx.MerchantID, parseDispatchSettings(features)),
+		"message":    "Dispatch settings retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantDispatchSettingsPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body dispatchSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if body.OfferTimeoutSeconds != nil && (*body.OfferTimeoutSeconds < minDispatchOfferTimeoutSeconds || *body.OfferTimeoutSeconds > maxDispatchOfferTimeoutSeconds) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("offerTimeoutSeconds must be between %d and %d", minDispatchOfferTimeoutSeconds, maxDispatchOfferTimeoutSeconds))
+		return
+	}
+
+	patch := map[string]any{}
+	if body.Enabled != nil {
+		patch["enabled"] = *body.Enabled
+	}
+	if body.OfferTimeoutSeconds != nil {
+		patch["offerTimeoutSeconds"] = *body.OfferTimeoutSeconds
+	}
+	patchJSON, _ := json.Marshal(patch)
+
+	// The patch is merged into autoDispatch in place, so concurrent writes to
+	// other feature settings are not lost.
+	var updatedFeatures []byte
+	if err := h.DB.QueryRow(ctx, `
+		update merchants
+		set features = jsonb_set(
+				case when jsonb_typeof(features) = 'object' then features else '{}'::jsonb end,
+				'{autoDispatch}',
+				case when jsonb_typeof(features->'autoDispatch') = 'object' then features->'autoDispatch' else '{}'::jsonb end || $1::jsonb),
+			updated_at = now()
+		where id = $2
+		returning features
+	`, patchJSON, *authCtx.MerchantID).Scan(&updatedFeatures); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+			return
+		}
+		h.Logger.Error("dispatch settings update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update dispatch settings")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       buildDispatchSettingsResponse(*authCtx.MerchantID, parseDispatchSettings(updatedFeatures)),
+		"message":    "Dispatch settings updated successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantDriversAvailability(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select u.id, u.name, coalesce(da.status, 'OFFLINE'), da.latitude, da.longitude, da.location_updated_at, da.last_offered_at,
+		       (
+				select count(*) from orders o
+				`+DeliveryProgressJoinSQL+`
+				where o.merchant_id = mu.merchant_id
+				  and o.delivery_driver_user_id = u.id
+				  and `+EffectiveDeliveryStatusSQL+` in ('ASSIGNED', 'PICKED_UP', 'ARRIVED')
+		       )
+		from merchant_users mu
+		join users u on u.id = mu.user_id
+		left join driver_availability da on da.merchant_id = mu.merchant_id and da.user_id = mu.user_id
+		where mu.merchant_id = $1
+		  and mu.is_active = true
+		  and u.is_active = true
+		  and (
+				mu.role in ('OWNER', 'DRIVER')
+				or (mu.role = 'STAFF' and mu.invitation_status = 'ACCEPTED' and mu.permissions @> ARRAY[$2]::text[])
+		  )
+		order by u.name asc
+	`, *authCtx.MerchantID, string(auth.PermDriverDashboard))
+	if err != nil {
+		h.Logger.Error("driver availability list query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve drivers")
+		return
+	}
+	defer rows.Close()
+
+	drivers := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			userID            int64
+			name              string
+			status            string
+			latitude          pgtype.Numeric
+			longitude         pgtype.Numeric
+			locationUpdatedAt pgtype.Timestamptz
+			lastOfferedAt     pgtype.Timestamptz
+			activeDeliveries  int64
+		)
+		if err := rows.Scan(&userID, &name, &status, &latitude, &longitude, &locationUpdatedAt, &lastOfferedAt, &activeDeliveries); err != nil {
+			h.Logger.Error("driver availability list scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve drivers")
+			return
+		}
+		drivers = append(drivers, map[string]any{
+			"id":                userID,
+			"name":              name,
+			"status":            status,
+			"latitude":          nullableNumeric(latitude),
+			"longitude":         nullableNumeric(longitude),
+			"locationUpdatedAt": nullableTime(locationUpdatedAt),
+			"lastOfferedAt":     nullableTime(lastOfferedAt),
+			"activeDeliveries":  activeDeliveries,
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       drivers,
+		"message":    "Driver availability retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) MerchantOrderDispatchAttempts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	orderID, err := readPathInt64(r, "orderId")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Order ID is required")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select a.id, a.order_id, a.strategy, a.distance_km, a.status, a.reason, a.offered_at, a.expires_at, a.responded_at,
+		       u.id, u.name
+		from order_dispatch_attempts a
+		join users u on u.id = a.driver_user_id
+		where a.order_id = $1 and a.merchant_id = $2
+		order by a.offered_at asc, a.id asc
+	`, orderID, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("dispatch attempts query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve dispatch attempts")
+		return
+	}
+	defer rows.Close()
+
+	attempts := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			attemptID   int64
+			attemptOrd  int64
+			strategy    string
+			distanceKm  pgtype.Numeric
+			status      string
+			reason      pgtype.Text
+			offeredAt   time.Time
+			expiresAt   time.Time
+			respondedAt pgtype.Timestamptz
+			driverID    int64
+			driverName  string
+		)
+		if err := rows.Scan(&attemptID, &attemptOrd, &strategy, &distanceKm, &status, &reason, &offeredAt, &expiresAt, &respondedAt, &driverID, &driverName); err != nil {
+			h.Logger.Error("dispatch attempts scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve dispatch attempts")
+			return
+		}
+		attempt := dispatchOfferPayload(attemptID, attemptOrd, strategy, distanceKm, status, reason, offeredAt, expiresAt, respondedAt)
+		attempt["driver"] = map[string]any{"id": driverID, "name": driverName}
+		attempts = append(attempts, attempt)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    attempts,
+		"count":   len(attempts),
+	})
+}
+
+func buildDispatchSettingsResponse(merchantID int64, settings dispatchSettings) map[string]any {
+	return map[string]any{
+		"merchantId":          fmt.Sprint(merchantID),
+		"enabled":             settings.Enabled,
+		"offerTimeoutSeconds": settings.OfferTimeoutSeconds,
+	}
+}
@@ -500,6 +500,7 @@ func (h *Handler) MerchantMenuUpdate(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update menu")
 		return
 	}
+	h.checkStockAlerts(ctx, stockAlertRef{ItemType: stockAlertMenu, ID: menuID})
 
 	menus, err := h.fetchMenus(ctx, *authCtx.MerchantID, nil, true)
 	if err != nil {
@@ -158,6 +158,7 @@ func (h *Handler) MerchantMenuBulk(w http.ResponseWriter, r *http.Request) {
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update stock")
 			return
 		}
+		h.checkStockAlerts(ctx, stockAlertRefs(stockAlertMenu, menuIDs...)...)
 		affected = count
 	case "TOGGLE_STATUS":
 		if payload.StatusChange == nil {
@@ -671,8 +672,9 @@ func (h *Handler) MerchantMenuResetStock(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	resetCount, err := h.resetMenuDailyStock(ctx, *authCtx.MerchantID, authCtx.UserID)
+	run, err := h.resetDailyStock(ctx, *authCtx.MerchantID, &authCtx.UserID)
 	if err != nil {
+		h.Logger.Error("stock reset failed", zapError(err))
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reset stock")
 		return
 	}
@@ -680,11 +682,13 @@ func (h *Handler) MerchantMenuResetStock(w http.ResponseWriter, r *http.Request)
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"data": map[string]any{
-			"resetCount": resetCount,
+			"resetCount": run.ItemCount,
+			"runId":      run.ID,
+			"items":      run.Items,
 			"merchantId": fmt.Sprintf("%d", *authCtx.MerchantID),
-			"resetAt":    time.Now().UTC().Format(time.RFC3339),
+			"resetAt":    run.CreatedAt.UTC().Format(time.RFC3339),
 		},
-		"message":    fmt.Sprintf("Successfully reset stock for %d menu items", resetCount),
+		"message":    fmt.Sprintf("Successfully reset stock for %d items", run.ItemCount),
 		"statusCode": http.StatusOK,
 	})
 }
@@ -698,12 +702,13 @@ func (h *Handler) MerchantMenuStockOverview(w http.ResponseWriter, r *http.Reque
 	}
 
 	var merchant struct {
-		ID       int64
-		Name     string
-		Currency string
+		ID                       int64
+		Name                     string
+		Currency                 string
+		DefaultLowStockThreshold int32
 	}
 
-	if err := h.DB.QueryRow(ctx, "select id, name, currency from merchants where id = $1", *authCtx.MerchantID).Scan(&merchant.ID, &merchant.Name, &merchant.Currency); err != nil {
+	if err := h.DB.QueryRow(ctx, "select id, name, currency, default_low_stock_threshold from merchants where id = $1", *authCtx.MerchantID).Scan(&merchant.ID, &merchant.Name, &merchant.Currency, &merchant.DefaultLowStockThreshold); err != nil {
 		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
 		return
 	}
@@ -725,9 +730,9 @@ func (h *Handler) MerchantMenuStockOverview(w http.ResponseWriter, r *http.Reque
 		"total":        len(allItems),
 		"menus":        len(menuItems),
 		"addons":       len(addonItems),
-		"lowStock":     countLowStock(allItems),
+		"lowStock":     countLowStock(allItems, merchant.DefaultLowStockThreshold),
 		"outOfStock":   countOutOfStock(allItems),
-		"healthy":      countHealthyStock(allItems),
+		"healthy":      countHealthyStock(allItems, merchant.DefaultLowStockThreshold),
 		"withTemplate": countWithTemplate(allItems),
 	}
 
@@ -804,13 +809,20 @@ func (h *Handler) MerchantMenuStockBulkUpdate(w http.ResponseWriter, r *http.Req
 
 	successCount := 0
 	failCount := 0
+	alertRefs := make([]stockAlertRef, 0, len(results))
 	for _, res := range results {
 		if res.Success {
 			successCount++
+			if res.Type == "addon" {
+				alertRefs = append(alertRefs, stockAlertRef{ItemType: stockAlertAddonItem, ID: res.ID})
+			} else {
+				alertRefs = append(alertRefs, stockAlertRef{ItemType: stockAlertMenu, ID: res.ID})
+			}
 		} else {
 			failCount++
 		}
 	}
+	h.checkStockAlerts(ctx, alertRefs...)
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
@@ -885,6 +897,7 @@ func (h *Handler) MerchantMenuAddStock(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to add stock")
 		return
 	}
+	h.checkStockAlerts(ctx, stockAlertRef{ItemType: stockAlertMenu, ID: menuID})
 
 	menus, err := h.fetchMenus(ctx, *authCtx.MerchantID, nil, true)
 	if err != nil {
@@ -1160,51 +1173,13 @@ func (h *Handler) fetchMerchantMenuNames(ctx context.Context, merchantID int64)
 	return nameMap, nil
 }
 
-func (h *Handler) resetMenuDailyStock(ctx context.Context, merchantID int64, userID int64) (int64, error) {
-	rows, err := h.DB.Query(ctx, `
-		select id, daily_stock_template
-		from menus
-		where merchant_id = $1 and deleted_at is null
-	`, merchantID)
-	if err != nil {
-		return 0, err
-	}
-	defer rows.Close()
-
-	resetCount := int64(0)
-	for rows.Next() {
-		var id int64
-		var template pgtype.Int4
-		if err := rows.Scan(&id, &template); err != nil {
-			continue
-		}
-		if !template.Valid {
-			continue
-		}
-		isActive := template.Int32 > 0
-		if result, err := h.DB.Exec(ctx, `
-			update menus
-			set stock_qty = $2,
-				is_active = $3,
-				last_stock_reset_at = now(),
-				updated_at = now(),
-				updated_by_user_id = $4
-			where id = $1 and auto_reset_stock = true
-		`, id, template.Int32, isActive, userID); err == nil {
-			if result.RowsAffected() > 0 {
-				resetCount++
-			}
-		}
-	}
-	return resetCount, nil
-}
-
 type stockOverviewItem struct {
 	ID                 int64   `json:"id"`
 	Type               string  `json:"type"`
 	Name               string  `json:"name"`
 	CategoryName       string  `json:"categoryName"`
 	StockQty           *int32  `json:"stockQty"`
+	LowStockThreshold  *int32  `json:"lowStockThreshold"`
 	DailyStockTemplate *int32  `json:"dailyStockTemplate"`
 	AutoResetStock     bool    `json:"autoResetStock"`
 	IsActive           bool    `json:"isActive"`
@@ -1213,7 +1188,7 @@ type stockOverviewItem struct {
 
 func (h *Handler) fetchMenuStockItems(ctx context.Context, merchantID int64) ([]stockOverviewItem, error) {
 	rows, err := h.DB.Query(ctx, `
-		select m.id, m.name, m.category_id, c.name, m.stock_qty, m.daily_stock_template,
+		select m.id, m.name, m.category_id, c.name, m.stock_qty, m.low_stock_threshold, m.daily_stock_template,
 			m.auto_reset_stock, m.is_active, m.image_url
 		from menus m
 		left join menu_categories c on c.id = m.category_id
@@ -1234,10 +1209,11 @@ func (h *Handler) fetchMenuStockItems(ctx context.Context, merchantID int64) ([]
 		var categoryID pgtype.Int8
 		var categoryName pgtype.Text
 		var stockQty pgtype.Int4
+		var lowStockThreshold pgtype.Int4
 		var dailyTemplate pgtype.Int4
 		var imageURL pgtype.Text
 
-		if err := rows.Scan(&item.ID, &item.Name, &categoryID, &categoryName, &stockQty, &dailyTemplate, &item.AutoResetStock, &item.IsActive, &imageURL); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &categoryID, &categoryName, &stockQty, &lowStockThreshold, &dailyTemplate, &item.AutoResetStock, &item.IsActive, &imageURL); err != nil {
 			continue
 		}
 		item.Type = "menu"
@@ -1248,6 +1224,7 @@ func (h *Handler) fetchMenuStockItems(ctx context.Context, merchantID int64) ([]
 		if stockQty.Valid {
 			item.StockQty = &stockQty.Int32
 		}
+		item.LowStockThreshold = int4Ptr(lowStockThreshold)
 		if dailyTemplate.Valid {
 			item.DailyStockTemplate = &dailyTemplate.Int32
 		}
@@ -1302,7 +1279,7 @@ func (h *Handler) fetchMenuCategoryNames(ctx context.Context, menuIDs []int64) (
 
 func (h *Handler) fetchAddonStockItems(ctx context.Context, merchantID int64) ([]stockOverviewItem, error) {
 	rows, err := h.DB.Query(ctx, `
-		select ai.id, ai.name, ac.name, ai.stock_qty, ai.daily_stock_template,
+		select ai.id, ai.name, ac.name, ai.stock_qty, ai.low_stock_threshold, ai.daily_stock_template,
 			ai.auto_reset_stock, ai.is_active
 		from addon_items ai
 		join addon_categories ac on ac.id = ai.addon_category_id
@@ -1319,9 +1296,10 @@ func (h *Handler) fetchAddonStockItems(ctx context.Context, merchantID int64) ([
 		var item stockOverviewItem
 		var categoryName string
 		var stockQty pgtype.Int4
+		var lowStockThreshold pgtype.Int4
 		var dailyTemplate pgtype.Int4
 
-		if err := rows.Scan(&item.ID, &item.Name, &categoryName, &stockQty, &dailyTemplate, &item.AutoResetStock, &item.IsActive); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &categoryName, &stockQty, &lowStockThreshold, &dailyTemplate, &item.AutoResetStock, &item.IsActive); err != nil {
 			continue
 		}
 		item.Type = "addon"
@@ -1329,6 +1307,7 @@ func (h *Handler) fetchAddonStockItems(ctx context.Context, merchantID int64) ([
 		if stockQty.Valid {
 			item.StockQty = &stockQty.Int32
 		}
+		item.LowStockThreshold = int4Ptr(lowStockThreshold)
 		if dailyTemplate.Valid {
 			item.DailyStockTemplate = &dailyTemplate.Int32
 		}
@@ -1489,10 +1468,10 @@ func buildBulkMenuToken(ids []int64) string {
 	return fmt.Sprintf("DELETE_%d_ITEMS_%d_%d", len(sorted), sorted[0], sorted[len(sorted)-1])
 }
 
-func countLowStock(items []stockOverviewItem) int {
+func countLowStock(items []stockOverviewItem, defaultThreshold int32) int {
 	count := 0
 	for _, item := range items {
-		if item.StockQty != nil && *item.StockQty > 0 && *item.StockQty <= 5 {
+		if stockLevel(item.StockQty, effectiveLowStockThreshold(item.LowStockThreshold, defaultThreshold)) == stockLevelLow {
 			count++
 		}
 	}
@@ -1509,10 +1488,10 @@ func countOutOfStock(items []stockOverviewItem) int {
 	return count
 }
 
-func countHealthyStock(items []stockOverviewItem) int {
+func countHealthyStock(items []stockOverviewItem, defaultThreshold int32) int {
 	count := 0
 	for _, item := range items {
-		if item.StockQty != nil && *item.StockQty > 5 {
+		if item.StockQty != nil && stockLevel(item.StockQty, effectiveLowStockThreshold(item.LowStockThreshold, defaultThreshold)) == stockLevelOK {
 			count++
 		}
 	}
@@ -0,0 +1,961 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	menuDraftStatusDraft     = "DRAFT"
+	menuDraftStatusScheduled = "SCHEDULED"
+	menuDraftStatusPublished = "PUBLISHED"
+	menuDraftStatusFailed    = "FAILED"
+	menuDraftStatusDiscarded = "DISCARDED"
+
+	menuVersionSourceLive     = "LIVE"
+	menuVersionSourcePublish  = "PUBLISH"
+	menuVersionSourceRollback = "ROLLBACK"
+
+	menuPublishSweepInterval = 30 * time.Second
+	menuPublishSweepBatch    = 20
+)
+
+var errMenuDraftNotPublishable = errors.New("menu draft is not publishable")
+
+type menuDraftPayload struct {
+	Name    *string      `json:"name"`
+	Catalog *menuCatalog `json:"catalog"`
+}
+
+type menuDraftRecord struct {
+	ID                 int64
+	Name               string
+	Snapshot           []byte
+	BaseVersionID      pgtype.Int8
+	Status             string
+	ScheduledPublishAt pgtype.Timestamptz
+	PublishedAt        pgtype.Timestamptz
+	PublishedVersionID pgtype.Int8
+	LastError          pgtype.Text
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+const menuDraftColumns = `
+	id, name, snapshot, base_version_id, status, scheduled_publish_at, published_at,
+	published_version_id, last_error, created_at, updated_at
+`
+
+func scanMenuDraft(row pgx.Row) (menuDraftRecord, error) {
+	var d menuDraftRecord
+	err := row.Scan(&d.ID, &d.Name, &d.Snapshot, &d.BaseVersionID, &d.Status, &d.ScheduledPublishAt,
+		&d.PublishedAt, &d.PublishedVersionID, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+func (d menuDraftRecord) payload(withCatalog bool) map[string]any {
+	out := map[string]any{
+		"id":                 d.ID,
+		"name":               d.Name,
+		"status":             d.Status,
+		"baseVersionId":      int8Ptr(d.BaseVersionID),
+		"scheduledPublishAt": timePtr(d.ScheduledPublishAt),
+		"publishedAt":        timePtr(d.PublishedAt),
+		"publishedVersionId": int8Ptr(d.PublishedVersionID),
+		"lastError":          ptrString(d.LastError),
+		"createdAt":          d.CreatedAt,
+		"updatedAt":          d.UpdatedAt,
+	}
+	if withCatalog {
+		out["catalog"] = json.RawMessage(d.Snapshot)
+	}
+	return out
+}
+
+func (d menuDraftRecord) editable() bool {
+	return d.Status == menuDraftStatusDraft || d.Status == menuDraftStatusScheduled || d.Status == menuDraftStatusFailed
+}
+
+// MerchantMenuDrafts lists the merchant's drafts, newest first. Discarded
+// drafts are left out unless ?includeDiscarded=true.
+func (h *Handler) MerchantMenuDrafts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	includeDiscarded := strings.EqualFold(r.URL.Query().Get("includeDiscarded"), "true")
+	rows, err := h.DB.Query(ctx, `
+		select `+menuDraftColumns+`
+		from menu_drafts
+		where merchant_id = $1 and ($2 or status <> 'DISCARDED')
+		order by updated_at desc, id desc
+	`, *authCtx.MerchantID, includeDiscarded)
+	if err != nil {
+		h.Logger.Error("menu drafts query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu drafts")
+		return
+	}
+	defer rows.Close()
+
+	drafts := make([]map[string]any, 0)
+	for rows.Next() {
+		d, err := scanMenuDraft(rows)
+		if err != nil {
+			h.Logger.Error("menu drafts scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu drafts")
+			return
+		}
+		drafts = append(drafts, d.payload(false))
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       drafts,
+		"message":    "Menu drafts retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftCreate starts a draft from the live catalog. A catalog
+// can be supplied in the body to start from it instead.
+func (h *Handler) MerchantMenuDraftCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	var payload menuDraftPayload
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+
+	name := "Menu draft " + time.Now().UTC().Format("2006-01-02 15:04")
+	if payload.Name != nil && strings.TrimSpace(*payload.Name) != "" {
+		name = strings.TrimSpace(*payload.Name)
+	}
+
+	var catalog menuCatalog
+	if payload.Catalog != nil {
+		catalog = *payload.Catalog
+		if err := validateMenuCatalog(&catalog); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+	} else {
+		live, err := loadMenuCatalog(ctx, h.DB, *authCtx.MerchantID)
+		if err != nil {
+			h.Logger.Error("menu draft live catalog failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read the current menu")
+			return
+		}
+		catalog = live
+	}
+	snapshot, err := json.Marshal(catalog)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create menu draft")
+		return
+	}
+
+	draft, err := scanMenuDraft(h.DB.QueryRow(ctx, `
+		insert into menu_drafts (merchant_id, name, snapshot, base_version_id, created_by_user_id, updated_by_user_id)
+		values ($1, $2, $3, (select id from menu_versions where merchant_id = $1 order by version_number desc limit 1), $4, $4)
+		returning `+menuDraftColumns,
+		*authCtx.MerchantID, name, snapshot, authCtx.UserID))
+	if err != nil {
+		h.Logger.Error("menu draft insert failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create menu draft")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"success":    true,
+		"data":       draft.payload(true),
+		"message":    "Menu draft created successfully",
+		"statusCode": 201,
+	})
+}
+
+func (h *Handler) loadMenuDraft(w http.ResponseWriter, r *http.Request) (int64, *menuDraftRecord, bool) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return 0, nil, false
+	}
+	draftID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid draft id")
+		return 0, nil, false
+	}
+	draft, err := scanMenuDraft(h.DB.QueryRow(ctx, `
+		select `+menuDraftColumns+` from menu_drafts where id = $1 and merchant_id = $2
+	`, draftID, *authCtx.MerchantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu draft not found")
+			return 0, nil, false
+		}
+		h.Logger.Error("menu draft query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu draft")
+		return 0, nil, false
+	}
+	return *authCtx.MerchantID, &draft, true
+}
+
+// MerchantMenuDraftDetail returns a draft with its full catalog.
+func (h *Handler) MerchantMenuDraftDetail(w http.ResponseWriter, r *http.Request) {
+	_, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       draft.payload(true),
+		"message":    "Menu draft retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftUpdate renames a draft and/or replaces its catalog. A
+// scheduled draft keeps its schedule.
+func (h *Handler) MerchantMenuDraftUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	authCtx, _ := middleware.GetAuthContext(ctx)
+	if !draft.editable() {
+		response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "Only unpublished drafts can be edited")
+		return
+	}
+
+	var payload menuDraftPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	name := draft.Name
+	if payload.Name != nil {
+		name = strings.TrimSpace(*payload.Name)
+		if name == "" {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Draft name is required")
+			return
+		}
+	}
+	snapshot := draft.Snapshot
+	if payload.Catalog != nil {
+		if err := validateMenuCatalog(payload.Catalog); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+		encoded, err := json.Marshal(payload.Catalog)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update menu draft")
+			return
+		}
+		snapshot = encoded
+	}
+
+	updated, err := scanMenuDraft(h.DB.QueryRow(ctx, `
+		update menu_drafts set
+			name = $3, snapshot = $4,
+			status = case when status = 'FAILED' then 'DRAFT' else status end,
+			last_error = null,
+			updated_at = now(), updated_by_user_id = $5
+		where id = $1 and merchant_id = $2 and status in ('DRAFT', 'SCHEDULED', 'FAILED')
+		returning `+menuDraftColumns,
+		draft.ID, merchantID, name, snapshot, authCtx.UserID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "Only unpublished drafts can be edited")
+			return
+		}
+		h.Logger.Error("menu draft update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update menu draft")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       updated.payload(true),
+		"message":    "Menu draft updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftDiscard discards an unpublished draft. The row is kept so
+// it still shows up in the history with ?includeDiscarded=true.
+func (h *Handler) MerchantMenuDraftDiscard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	authCtx, _ := middleware.GetAuthContext(ctx)
+
+	tag, err := h.DB.Exec(ctx, `
+		update menu_drafts set status = 'DISCARDED', scheduled_publish_at = null, updated_at = now(), updated_by_user_id = $3
+		where id = $1 and merchant_id = $2 and status in ('DRAFT', 'SCHEDULED', 'FAILED')
+	`, draft.ID, merchantID, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("menu draft discard failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to discard menu draft")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "Only unpublished drafts can be discarded")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"id": draft.ID, "status": menuDraftStatusDiscarded},
+		"message":    "Menu draft discarded",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftPreview renders the draft as the public menu would show
+// it once published.
+func (h *Handler) MerchantMenuDraftPreview(w http.ResponseWriter, r *http.Request) {
+	_, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	var catalog menuCatalog
+	if err := json.Unmarshal(draft.Snapshot, &catalog); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Menu draft is unreadable")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"draft":           draft.payload(false),
+			"menusByCategory": renderMenuCatalogPreview(catalog),
+		},
+		"message":    "Menu draft preview generated",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftDiff lists what publishing the draft would change on the
+// live menu.
+func (h *Handler) MerchantMenuDraftDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	var catalog menuCatalog
+	if err := json.Unmarshal(draft.Snapshot, &catalog); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Menu draft is unreadable")
+		return
+	}
+	live, err := loadMenuCatalog(ctx, h.DB, merchantID)
+	if err != nil {
+		h.Logger.Error("menu draft diff live catalog failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read the current menu")
+		return
+	}
+	diff := diffMenuCatalogs(live, catalog)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"draft":      draft.payload(false),
+			"hasChanges": !diff.isEmpty(),
+			"diff":       diff,
+		},
+		"message":    "Menu draft diff generated",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftPublish publishes a draft now, or schedules it when the
+// body carries a future scheduledAt.
+func (h *Handler) MerchantMenuDraftPublish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	authCtx, _ := middleware.GetAuthContext(ctx)
+	if !draft.editable() {
+		response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "This draft was already published or discarded")
+		return
+	}
+
+	var body struct {
+		ScheduledAt *string `json:"scheduledAt"`
+		Note        *string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+
+	if body.ScheduledAt != nil && strings.TrimSpace(*body.ScheduledAt) != "" {
+		scheduledAt, err := time.Parse(time.RFC3339, strings.TrimSpace(*body.ScheduledAt))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "scheduledAt must be an RFC 3339 timestamp")
+			return
+		}
+		if scheduledAt.After(time.Now()) {
+			updated, err := scanMenuDraft(h.DB.QueryRow(ctx, `
+				update menu_drafts set
+					status = 'SCHEDULED', scheduled_publish_at = $3, last_error = null,
+					updated_at = now(), updated_by_user_id = $4
+				where id = $1 and merchant_id = $2 and status in ('DRAFT', 'SCHEDULED', 'FAILED')
+				returning `+menuDraftColumns,
+				draft.ID, merchantID, scheduledAt.UTC(), authCtx.UserID))
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "This draft was already published or discarded")
+					return
+				}
+				h.Logger.Error("menu draft schedule failed", zapError(err))
+				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to schedule menu draft")
+				return
+			}
+			response.JSON(w, http.StatusOK, map[string]any{
+				"success":    true,
+				"data":       map[string]any{"draft": updated.payload(false)},
+				"message":    "Menu draft scheduled",
+				"statusCode": 200,
+			})
+			return
+		}
+	}
+
+	version, err := h.publishMenuDraft(ctx, merchantID, draft.ID, &authCtx.UserID, normalizeMenuVersionNote(body.Note), false)
+	if err != nil {
+		if errors.Is(err, errMenuDraftNotPublishable) {
+			response.Error(w, http.StatusConflict, "DRAFT_NOT_EDITABLE", "This draft was already published or discarded")
+			return
+		}
+		h.Logger.Error("menu draft publish failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "PUBLISH_FAILED", "Failed to publish menu draft")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"version": version},
+		"message":    "Menu draft published",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuDraftUnschedule moves a scheduled draft back to DRAFT.
+func (h *Handler) MerchantMenuDraftUnschedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, draft, ok := h.loadMenuDraft(w, r)
+	if !ok {
+		return
+	}
+	authCtx, _ := middleware.GetAuthContext(ctx)
+
+	updated, err := scanMenuDraft(h.DB.QueryRow(ctx, `
+		update menu_drafts set status = 'DRAFT', scheduled_publish_at = null, updated_at = now(), updated_by_user_id = $3
+		where id = $1 and merchant_id = $2 and status = 'SCHEDULED'
+		returning `+menuDraftColumns,
+		draft.ID, merchantID, authCtx.UserID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusConflict, "DRAFT_NOT_SCHEDULED", "This draft is not scheduled")
+			return
+		}
+		h.Logger.Error("menu draft unschedule failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel the schedule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       updated.payload(false),
+		"message":    "Scheduled publish cancelled",
+		"statusCode": 200,
+	})
+}
+
+type menuVersionSummary struct {
+	ID                      int64     `json:"id"`
+	VersionNumber           int32     `json:"versionNumber"`
+	Source                  string    `json:"source"`
+	Note                    *string   `json:"note"`
+	DraftID                 *int64    `json:"draftId"`
+	RolledBackFromVersionID *int64    `json:"rolledBackFromVersionId"`
+	CreatedByUserID         *int64    `json:"createdByUserId"`
+	CreatedAt               time.Time `json:"createdAt"`
+}
+
+const menuVersionColumns = `
+	id, version_number, source, note, draft_id, rolled_back_from_version_id, created_by_user_id, created_at
+`
+
+func scanMenuVersion(row pgx.Row, extra ...any) (menuVersionSummary, error) {
+	var (
+		v              menuVersionSummary
+		note           pgtype.Text
+		draftID        pgtype.Int8
+		rolledBackFrom pgtype.Int8
+		createdBy      pgtype.Int8
+	)
+	dest := append([]any{&v.ID, &v.VersionNumber, &v.Source, &note, &draftID, &rolledBackFrom, &createdBy, &v.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return v, err
+	}
+	v.Note = ptrString(note)
+	v.DraftID = int8Ptr(draftID)
+	v.RolledBackFromVersionID = int8Ptr(rolledBackFrom)
+	v.CreatedByUserID = int8Ptr(createdBy)
+	return v, nil
+}
+
+func normalizeMenuVersionNote(note *string) *string {
+	if note == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil
+	}
+	if len(trimmed) > 500 {
+		trimmed = trimmed[:500]
+	}
+	return &trimmed
+}
+
+// publishMenuDraft applies a draft's catalog to the live tables. When
+// onlyScheduled is set the draft must still be SCHEDULED, which keeps the
+// sweeper from publishing a draft that was unscheduled in the meantime.
+func (h *Handler) publishMenuDraft(ctx context.Context, merchantID, draftID int64, userID *int64, note *string, onlyScheduled bool) (menuVersionSummary, error) {
+	return h.publishMenuCatalog(ctx, merchantID, userID, func(tx pgx.Tx) (menuCatalog, menuVersionSummary, error) {
+		var (
+			status   string
+			snapshot []byte
+		)
+		if err := tx.QueryRow(ctx, `
+			select status, snapshot from menu_drafts where id = $1 and merchant_id = $2 for update
+		`, draftID, merchantID).Scan(&status, &snapshot); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return menuCatalog{}, menuVersionSummary{}, errMenuDraftNotPublishable
+			}
+			return menuCatalog{}, menuVersionSummary{}, err
+		}
+		if (onlyScheduled && status != menuDraftStatusScheduled) ||
+			!(menuDraftRecord{Status: status}).editable() {
+			return menuCatalog{}, menuVersionSummary{}, errMenuDraftNotPublishable
+		}
+		var catalog menuCatalog
+		if err := json.Unmarshal(snapshot, &catalog); err != nil {
+			return menuCatalog{}, menuVersionSummary{}, err
+		}
+		if err := validateMenuCatalog(&catalog); err != nil {
+			return menuCatalog{}, menuVersionSummary{}, err
+		}
+		return catalog, menuVersionSummary{Source: menuVersionSourcePublish, Note: note, DraftID: &draftID}, nil
+	})
+}
+
+// publishMenuCatalog is the single write path for drafts and rollbacks. In
+// one transaction, under a lock on the merchant row, it records any live
+// edits made since the last version as a LIVE version, applies the catalog
+// and records the result as a new version.
+func (h *Handler) publishMenuCatalog(
+	ctx context.Context,
+	merchantID int64,
+	userID *int64,
+	prepare func(tx pgx.Tx) (menuCatalog, menuVersionSummary, error),
+) (menuVersionSummary, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `select id from merchants where id = $1 for update`, merchantID); err != nil {
+		return menuVersionSummary{}, err
+	}
+
+	catalog, meta, err := prepare(tx)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+
+	live, err := loadMenuCatalog(ctx, tx, merchantID)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+	var latest []byte
+	err = tx.QueryRow(ctx, `
+		select snapshot from menu_versions where merchant_id = $1 order by version_number desc limit 1
+	`, merchantID).Scan(&latest)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return menuVersionSummary{}, err
+	}
+	var previous menuCatalog
+	if latest == nil || json.Unmarshal(latest, &previous) != nil || !diffMenuCatalogs(previous, live).isEmpty() {
+		if _, err := insertMenuVersionTx(ctx, tx, merchantID, live, menuVersionSummary{Source: menuVersionSourceLive}, userID); err != nil {
+			return menuVersionSummary{}, err
+		}
+	}
+
+	if err := applyMenuCatalogTx(ctx, tx, merchantID, userID, catalog); err != nil {
+		return menuVersionSummary{}, err
+	}
+	published, err := loadMenuCatalog(ctx, tx, merchantID)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+	version, err := insertMenuVersionTx(ctx, tx, merchantID, published, meta, userID)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+
+	if meta.DraftID != nil {
+		if _, err := tx.Exec(ctx, `
+			update menu_drafts set
+				status = 'PUBLISHED', published_at = now(), published_version_id = $2,
+				scheduled_publish_at = null, last_error = null, updated_at = now()
+			where id = $1
+		`, *meta.DraftID, version.ID); err != nil {
+			return menuVersionSummary{}, err
+		}
+	}
+	if err := bumpMenuCacheVersion(ctx, tx, merchantID); err != nil {
+		return menuVersionSummary{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return menuVersionSummary{}, err
+	}
+
+	if h.Queue != nil {
+		event := map[string]any{
+			"type":          "menu.published",
+			"merchantId":    merchantID,
+			"versionId":     version.ID,
+			"versionNumber": version.VersionNumber,
+			"source":        version.Source,
+			"draftId":       version.DraftID,
+			"publishedAt":   version.CreatedAt,
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", "menu.published", event)
+	}
+	return version, nil
+}
+
+func insertMenuVersionTx(ctx context.Context, tx pgx.Tx, merchantID int64, catalog menuCatalog, meta menuVersionSummary, userID *int64) (menuVersionSummary, error) {
+	snapshot, err := json.Marshal(catalog)
+	if err != nil {
+		return menuVersionSummary{}, err
+	}
+	return scanMenuVersion(tx.QueryRow(ctx, `
+		insert into menu_versions (
+			merchant_id, version_number, source, snapshot, note, draft_id, rolled_back_from_version_id, created_by_user_id
+		) values (
+			$1, (select coalesce(max(version_number), 0) + 1 from menu_versions where merchant_id = $1),
+			$2, $3, $4, $5, $6, $7
+		)
+		returning `+menuVersionColumns,
+		merchantID, meta.Source, snapshot, meta.Note, meta.DraftID, meta.RolledBackFromVersionID, userID))
+}
+
+// RunMenuPublishSweeper publishes scheduled drafts once they are due.
+func (h *Handler) RunMenuPublishSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = menuPublishSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepScheduledMenuPublishes(ctx)
+		}
+	}
+}
+
+func (h *Handler) sweepScheduledMenuPublishes(ctx context.Context) {
+	rows, err := h.DB.Query(ctx, `
+		select id, merchant_id, updated_by_user_id
+		from menu_drafts
+		where status = 'SCHEDULED' and scheduled_publish_at <= now()
+		order by scheduled_publish_at
+		limit $1
+	`, menuPublishSweepBatch)
+	if err != nil {
+		h.Logger.Warn("menu publish sweep failed", zapError(err))
+		return
+	}
+	type dueDraft struct {
+		id, merchantID int64
+		userID         pgtype.Int8
+	}
+	due := make([]dueDraft, 0)
+	for rows.Next() {
+		var d dueDraft
+		if err := rows.Scan(&d.id, &d.merchantID, &d.userID); err != nil {
+			rows.Close()
+			h.Logger.Warn("menu publish sweep scan failed", zapError(err))
+			return
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+
+	for _, d := range due {
+		_, err := h.publishMenuDraft(ctx, d.merchantID, d.id, int8Ptr(d.userID), nil, true)
+		if err == nil || errors.Is(err, errMenuDraftNotPublishable) {
+			continue
+		}
+		h.Logger.Warn("scheduled menu publish failed", zapError(err))
+		if _, uerr := h.DB.Exec(ctx, `
+			update menu_drafts set status = 'FAILED', last_error = $2, updated_at = now()
+			where id = $1 and status = 'SCHEDULED'
+		`, d.id, err.Error()); uerr != nil {
+			h.Logger.Warn("menu draft failure update failed", zapError(uerr))
+		}
+	}
+}
+
+// MerchantMenuVersions lists published versions, newest first.
+func (h *Handler) MerchantMenuVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select `+menuVersionColumns+`
+		from menu_versions
+		where merchant_id = $1
+		order by version_number desc
+		limit 200
+	`, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("menu versions query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu versions")
+		return
+	}
+	defer rows.Close()
+
+	versions := make([]menuVersionSummary, 0)
+	for rows.Next() {
+		v, err := scanMenuVersion(rows)
+		if err != nil {
+			h.Logger.Error("menu versions scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu versions")
+			return
+		}
+		versions = append(versions, v)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       versions,
+		"message":    "Menu versions retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+func (h *Handler) loadMenuVersion(ctx context.Context, merchantID, versionID int64) (menuVersionSummary, menuCatalog, error) {
+	var snapshot []byte
+	version, err := scanMenuVersion(h.DB.QueryRow(ctx, `
+		select `+menuVersionColumns+`, snapshot from menu_versions where id = $1 and merchant_id = $2
+	`, versionID, merchantID), &snapshot)
+	if err != nil {
+		return version, menuCatalog{}, err
+	}
+	var catalog menuCatalog
+	if err := json.Unmarshal(snapshot, &catalog); err != nil {
+		return version, catalog, err
+	}
+	return version, catalog, nil
+}
+
+func (h *Handler) readMenuVersion(w http.ResponseWriter, r *http.Request) (int64, menuVersionSummary, menuCatalog, bool) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return 0, menuVersionSummary{}, menuCatalog{}, false
+	}
+	versionID, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid version id")
+		return 0, menuVersionSummary{}, menuCatalog{}, false
+	}
+	version, catalog, err := h.loadMenuVersion(ctx, *authCtx.MerchantID, versionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu version not found")
+			return 0, version, catalog, false
+		}
+		h.Logger.Error("menu version query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve menu version")
+		return 0, version, catalog, false
+	}
+	return *authCtx.MerchantID, version, catalog, true
+}
+
+// MerchantMenuVersionDetail returns a version with its catalog snapshot.
+func (h *Handler) MerchantMenuVersionDetail(w http.ResponseWriter, r *http.Request) {
+	_, version, catalog, ok := h.readMenuVersion(w, r)
+	if !ok {
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"version": version, "catalog": catalog},
+		"message":    "Menu version retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuVersionDiff shows how a version differs from another. By
+// default it is compared with the version before it (what that publish
+// changed); ?against=live shows what rolling back to it would change, and
+// ?against=<versionId> compares two versions.
+func (h *Handler) MerchantMenuVersionDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, version, catalog, ok := h.readMenuVersion(w, r)
+	if !ok {
+		return
+	}
+
+	against := strings.TrimSpace(r.URL.Query().Get("against"))
+	var (
+		base      menuCatalog
+		baseLabel any
+		err       error
+	)
+	switch {
+	case strings.EqualFold(against, "live"):
+		base, err = loadMenuCatalog(ctx, h.DB, merchantID)
+		baseLabel = "live"
+	case against != "":
+		otherID, perr := parseInt64Value(against)
+		if perr != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "against must be 'live' or a version id")
+			return
+		}
+		var other menuVersionSummary
+		other, base, err = h.loadMenuVersion(ctx, merchantID, otherID)
+		baseLabel = other
+	default:
+		var previousID int64
+		err = h.DB.QueryRow(ctx, `
+			select id from menu_versions
+			where merchant_id = $1 and version_number < $2
+			order by version_number desc limit 1
+		`, merchantID, version.VersionNumber).Scan(&previousID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = nil
+			base = menuCatalog{}
+			baseLabel = nil
+			break
+		}
+		if err == nil {
+			var previous menuVersionSummary
+			previous, base, err = h.loadMenuVersion(ctx, merchantID, previousID)
+			baseLabel = previous
+		}
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu version to compare against not found")
+			return
+		}
+		h.Logger.Error("menu version diff failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to compare menu versions")
+		return
+	}
+
+	diff := diffMenuCatalogs(base, catalog)
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"version":    version,
+			"against":    baseLabel,
+			"hasChanges": !diff.isEmpty(),
+			"diff":       diff,
+		},
+		"message":    "Menu version diff generated",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuVersionRollback republishes a version's catalog. The rollback
+// is itself a new version, so it can be undone the same way.
+func (h *Handler) MerchantMenuVersionRollback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantID, version, catalog, ok := h.readMenuVersion(w, r)
+	if !ok {
+		return
+	}
+	authCtx, _ := middleware.GetAuthContext(ctx)
+
+	var body struct {
+		Note *string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+
+	fromID := version.ID
+	published, err := h.publishMenuCatalog(ctx, merchantID, &authCtx.UserID, func(tx pgx.Tx) (menuCatalog, menuVersionSummary, error) {
+		if err := validateMenuCatalog(&catalog); err != nil {
+			return menuCatalog{}, menuVersionSummary{}, err
+		}
+		return catalog, menuVersionSummary{
+			Source:                  menuVersionSourceRollback,
+			Note:                    normalizeMenuVersionNote(body.Note),
+			RolledBackFromVersionID: &fromID,
+		}, nil
+	})
+	if err != nil {
+		h.Logger.Error("menu rollback failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "ROLLBACK_FAILED", "Failed to roll back the menu")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"version": published},
+		"message":    "Menu rolled back",
+		"statusCode": 200,
+	})
+}
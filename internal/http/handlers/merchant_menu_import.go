@@ -0,0 +1,1214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/spreadsheet"
+	"genfity-order-services/internal/storage"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	menuImportMaxRows      = 2000
+	menuImportMaxFileBytes = 10 * 1024 * 1024
+	menuImportImageMaxSide = 1600
+	menuImportListSep      = "|"
+
+	// Image downloads run inside the import request, so both their number
+	// and their total time are capped below the server's write timeout. Rows
+	// past either cap keep their image and can be picked up by re-importing.
+	menuImportMaxImages      = 50
+	menuImportImageBudget    = 20 * time.Second
+	menuImportImageTimeout   = 10 * time.Second
+	menuImportImageRedirects = 3
+)
+
+// menuSheetColumns is the export header and the set of columns the import
+// understands. An exported file imports back without a column mapping.
+var menuSheetColumns = []string{
+	"id", "name", "description", "price", "categories", "addonGroups",
+	"isActive", "isSpicy", "isBestSeller", "isSignature", "isRecommended",
+	"trackStock", "stockQty", "dailyStockTemplate", "autoResetStock", "imageUrl",
+}
+
+var menuSheetColumnAliases = map[string]string{
+	"menuid":     "id",
+	"itemname":   "name",
+	"menuname":   "name",
+	"category":   "categories",
+	"addons":     "addonGroups",
+	"addongroup": "addonGroups",
+	"image":      "imageUrl",
+	"imagelink":  "imageUrl",
+	"stock":      "stockQty",
+	"active":     "isActive",
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeSheetHeader(value string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(value), "")
+}
+
+// menuImportImageClient fetches merchant-supplied image URLs. It only speaks
+// http(s), ignores proxy settings and refuses to connect to loopback, private,
+// link-local and other non-public addresses, checked on the resolved address
+// of every connection including redirects.
+var menuImportImageClient = &http.Client{
+	Timeout: menuImportImageTimeout,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: menuImportDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: menuImportImageTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= menuImportImageRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return errors.New("redirect to a non-http(s) URL")
+		}
+		return nil
+	},
+}
+
+var errMenuImportAddressBlocked = errors.New("image host resolves to a non-public address")
+
+func menuImportDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicImportAddress(ip) {
+		return errMenuImportAddressBlocked
+	}
+	return nil
+}
+
+// menuImportSharedAddressSpace is the carrier-grade NAT range (RFC 6598),
+// which net.IP does not classify as private.
+var menuImportSharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicImportAddress reports whether an image download may connect to ip.
+func isPublicImportAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return !menuImportSharedAddressSpace.Contains(ip4) && !ip4.Equal(net.IPv4bcast)
+	}
+	return true
+}
+
+type menuImportRow struct {
+	Row                int
+	ID                 *int64
+	Name               string
+	Description        *string
+	Price              *float64
+	Categories         []string
+	AddonGroups        []string
+	IsActive           *bool
+	IsSpicy            *bool
+	IsBestSeller       *bool
+	IsSignature        *bool
+	IsRecommended      *bool
+	TrackStock         *bool
+	StockQty           *int32
+	DailyStockTemplate *int32
+	AutoResetStock     *bool
+	ImageURL           *string
+}
+
+type menuImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// resolveMenuImportColumns maps column names to header positions. Explicit
+// mappings (column -> header text) win; other headers are matched by name
+// ignoring case, spaces and punctuation.
+func resolveMenuImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	known := make(map[string]string, len(menuSheetColumns)+len(menuSheetColumnAliases))
+	for _, col := range menuSheetColumns {
+		known[normalizeSheetHeader(col)] = col
+	}
+	for alias, col := range menuSheetColumnAliases {
+		known[alias] = col
+	}
+
+	positions := make(map[string]int, len(header))
+	for idx, h := range header {
+		key := normalizeSheetHeader(h)
+		if key == "" {
+			continue
+		}
+		if _, taken := positions[key]; !taken {
+			positions[key] = idx
+		}
+	}
+
+	columns := make(map[string]int)
+	for col, headerText := range mapping {
+		canonical, ok := known[normalizeSheetHeader(col)]
+		if !ok || canonical != col {
+			return nil, fmt.Errorf("Unknown column %q in mapping", col)
+		}
+		idx, ok := positions[normalizeSheetHeader(headerText)]
+		if !ok {
+			return nil, fmt.Errorf("Header %q mapped to %s was not found in the file", headerText, col)
+		}
+		columns[col] = idx
+	}
+
+	mappedIdx := make(map[int]bool, len(columns))
+	for _, idx := range columns {
+		mappedIdx[idx] = true
+	}
+	for idx, h := range header {
+		if mappedIdx[idx] {
+			continue
+		}
+		col, ok := known[normalizeSheetHeader(h)]
+		if !ok {
+			continue
+		}
+		if _, exists := columns[col]; !exists {
+			columns[col] = idx
+			mappedIdx[idx] = true
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("A name column is required")
+	}
+	return columns, nil
+}
+
+func parseSheetBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "1":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func splitSheetList(value string) []string {
+	out := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, menuImportListSep) {
+		name := strings.TrimSpace(part)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// parseMenuImportRows validates data rows (the header excluded). A column
+// left blank keeps the current value on update, except description,
+// categories and addon groups where a blank cell clears them.
+func parseMenuImportRows(rows [][]string, columns map[string]int) ([]menuImportRow, []menuImportRowError) {
+	parsed := make([]menuImportRow, 0, len(rows))
+	errs := make([]menuImportRowError, 0)
+	seenNames := make(map[string]int)
+	seenIDs := make(map[int64]int)
+
+	for i, cells := range rows {
+		rowNumber := i + 2
+		cell := func(col string) (string, bool) {
+			idx, ok := columns[col]
+			if !ok {
+				return "", false
+			}
+			if idx >= len(cells) {
+				return "", true
+			}
+			return strings.TrimSpace(cells[idx]), true
+		}
+
+		blank := true
+		for _, idx := range columns {
+			if idx < len(cells) && strings.TrimSpace(cells[idx]) != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			continue
+		}
+
+		row := menuImportRow{Row: rowNumber}
+		rowErrs := make([]menuImportRowError, 0)
+		fail := func(field, message string) {
+			rowErrs = append(rowErrs, menuImportRowError{Row: rowNumber, Field: field, Message: message})
+		}
+
+		if value, ok := cell("id"); ok && value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				fail("id", "Invalid menu id")
+			} else if prev, dup := seenIDs[id]; dup {
+				fail("id", fmt.Sprintf("Menu id %d is also used on row %d", id, prev))
+			} else {
+				seenIDs[id] = rowNumber
+				row.ID = &id
+			}
+		}
+
+		row.Name, _ = cell("name")
+		if row.Name == "" {
+			fail("name", "Name is required")
+		} else if prev, dup := seenNames[strings.ToLower(row.Name)]; dup {
+			fail("name", fmt.Sprintf("Duplicate name, also on row %d", prev))
+		} else {
+			seenNames[strings.ToLower(row.Name)] = rowNumber
+		}
+
+		if value, ok := cell("description"); ok {
+			row.Description = &value
+		}
+		if value, ok := cell("price"); ok && value != "" {
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil || price < 0 {
+				fail("price", "Price must be a non-negative number")
+			} else {
+				row.Price = &price
+			}
+		}
+		if value, ok := cell("categories"); ok {
+			row.Categories = splitSheetList(value)
+		}
+		if value, ok := cell("addonGroups"); ok {
+			row.AddonGroups = splitSheetList(value)
+		}
+
+		bools := []struct {
+			col string
+			dst **bool
+		}{
+			{"isActive", &row.IsActive},
+			{"isSpicy", &row.IsSpicy},
+			{"isBestSeller", &row.IsBestSeller},
+			{"isSignature", &row.IsSignature},
+			{"isRecommended", &row.IsRecommended},
+			{"trackStock", &row.TrackStock},
+			{"autoResetStock", &row.AutoResetStock},
+		}
+		for _, b := range bools {
+			value, ok := cell(b.col)
+			if !ok || value == "" {
+				continue
+			}
+			parsedBool, valid := parseSheetBool(value)
+			if !valid {
+				fail(b.col, "Must be true or false")
+				continue
+			}
+			*b.dst = &parsedBool
+		}
+
+		ints := []struct {
+			col string
+			dst **int32
+		}{
+			{"stockQty", &row.StockQty},
+			{"dailyStockTemplate", &row.DailyStockTemplate},
+		}
+		for _, n := range ints {
+			value, ok := cell(n.col)
+			if !ok || value == "" {
+				continue
+			}
+			parsedInt, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || parsedInt < 0 {
+				fail(n.col, "Must be a non-negative whole number")
+				continue
+			}
+			v := int32(parsedInt)
+			*n.dst = &v
+		}
+
+		if value, ok := cell("imageUrl"); ok && value != "" {
+			u, err := url.Parse(value)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				fail("imageUrl", "Image URL must be an http(s) link")
+			} else {
+				row.ImageURL = &value
+			}
+		}
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		parsed = append(parsed, row)
+	}
+
+	return parsed, errs
+}
+
+type menuImportExisting struct {
+	ID                 int64
+	Name               string
+	Description        *string
+	Price              float64
+	Categories         []string
+	AddonGroups        []string
+	IsActive           bool
+	IsSpicy            bool
+	IsBestSeller       bool
+	IsSignature        bool
+	IsRecommended      bool
+	TrackStock         bool
+	StockQty           *int32
+	DailyStockTemplate *int32
+	AutoResetStock     bool
+	ImageURL           *string
+}
+
+type menuImportAction struct {
+	Row     int      `json:"row"`
+	Action  string   `json:"action"`
+	MenuID  *int64   `json:"menuId"`
+	Name    string   `json:"name"`
+	Changes []string `json:"changes,omitempty"`
+
+	row      menuImportRow
+	existing *menuImportExisting
+}
+
+type menuImportPlan struct {
+	Actions        []menuImportAction
+	Errors         []menuImportRowError
+	NewCategories  []string
+	NewAddonGroups []string
+}
+
+func (p menuImportPlan) count(action string) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func (p menuImportPlan) imageCount() int {
+	n := 0
+	for _, a := range p.Actions {
+		if containsString(a.Changes, "imageUrl") {
+			n++
+		}
+	}
+	return n
+}
+
+// planMenuImport matches rows to existing menus (by id, then by name) and
+// decides whether each is created, updated or skipped as unchanged.
+func planMenuImport(rows []menuImportRow, existing []menuImportExisting, categories, addonGroups map[string]int64) menuImportPlan {
+	byID := make(map[int64]*menuImportExisting, len(existing))
+	byName := make(map[string]*menuImportExisting, len(existing))
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[strings.ToLower(strings.TrimSpace(existing[i].Name))] = &existing[i]
+	}
+
+	plan := menuImportPlan{
+		Actions:        make([]menuImportAction, 0, len(rows)),
+		Errors:         make([]menuImportRowError, 0),
+		NewCategories:  make([]string, 0),
+		NewAddonGroups: make([]string, 0),
+	}
+	newCategories := make(map[string]bool)
+	newAddonGroups := make(map[string]bool)
+
+	for _, row := range rows {
+		var match *menuImportExisting
+		if row.ID != nil {
+			match = byID[*row.ID]
+			if match == nil {
+				plan.Errors = append(plan.Errors, menuImportRowError{Row: row.Row, Field: "id", Message: fmt.Sprintf("Menu id %d not found", *row.ID)})
+				continue
+			}
+		} else {
+			match = byName[strings.ToLower(row.Name)]
+		}
+
+		action := menuImportAction{Row: row.Row, Name: row.Name, row: row, existing: match}
+		if match == nil {
+			if row.Price == nil {
+				plan.Errors = append(plan.Errors, menuImportRowError{Row: row.Row, Field: "price", Message: "Price is required for new menu items"})
+				continue
+			}
+			action.Action = "create"
+			if row.ImageURL != nil {
+				action.Changes = []string{"imageUrl"}
+			}
+		} else {
+			id := match.ID
+			action.MenuID = &id
+			action.Changes = diffMenuImportRow(row, *match)
+			action.Action = "update"
+			if len(action.Changes) == 0 {
+				action.Action = "skip"
+			}
+		}
+
+		for _, name := range row.Categories {
+			key := strings.ToLower(name)
+			if _, ok := categories[key]; !ok && !newCategories[key] {
+				newCategories[key] = true
+				plan.NewCategories = append(plan.NewCategories, name)
+			}
+		}
+		for _, name := range row.AddonGroups {
+			key := strings.ToLower(name)
+			if _, ok := addonGroups[key]; !ok && !newAddonGroups[key] {
+				newAddonGroups[key] = true
+				plan.NewAddonGroups = append(plan.NewAddonGroups, name)
+			}
+		}
+
+		plan.Actions = append(plan.Actions, action)
+	}
+	return plan
+}
+
+func diffMenuImportRow(row menuImportRow, existing menuImportExisting) []string {
+	changes := make([]string, 0)
+	if row.Name != existing.Name {
+		changes = append(changes, "name")
+	}
+	if row.Description != nil {
+		current := ""
+		if existing.Description != nil {
+			current = *existing.Description
+		}
+		if *row.Description != current {
+			changes = append(changes, "description")
+		}
+	}
+	if row.Price != nil && *row.Price != existing.Price {
+		changes = append(changes, "price")
+	}
+	if row.Categories != nil && !equalFoldLists(row.Categories, existing.Categories) {
+		changes = append(changes, "categories")
+	}
+	if row.AddonGroups != nil && !equalFoldLists(row.AddonGroups, existing.AddonGroups) {
+		changes = append(changes, "addonGroups")
+	}
+
+	bools := []struct {
+		col     string
+		value   *bool
+		current bool
+	}{
+		{"isActive", row.IsActive, existing.IsActive},
+		{"isSpicy", row.IsSpicy, existing.IsSpicy},
+		{"isBestSeller", row.IsBestSeller, existing.IsBestSeller},
+		{"isSignature", row.IsSignature, existing.IsSignature},
+		{"isRecommended", row.IsRecommended, existing.IsRecommended},
+		{"trackStock", row.TrackStock, existing.TrackStock},
+		{"autoResetStock", row.AutoResetStock, existing.AutoResetStock},
+	}
+	for _, b := range bools {
+		if b.value != nil && *b.value != b.current {
+			changes = append(changes, b.col)
+		}
+	}
+	if row.StockQty != nil && (existing.StockQty == nil || *row.StockQty != *existing.StockQty) {
+		changes = append(changes, "stockQty")
+	}
+	if row.DailyStockTemplate != nil && (existing.DailyStockTemplate == nil || *row.DailyStockTemplate != *existing.DailyStockTemplate) {
+		changes = append(changes, "dailyStockTemplate")
+	}
+	if row.ImageURL != nil && (existing.ImageURL == nil || *row.ImageURL != *existing.ImageURL) {
+		changes = append(changes, "imageUrl")
+	}
+	return changes
+}
+
+func equalFoldLists(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergedMenuImportValues overlays the row on the current menu (or the
+// defaults of a new one) and applies the stock rules of the bulk upload.
+type mergedMenuImportValues struct {
+	Name               string
+	Description        *string
+	Price              float64
+	IsActive           bool
+	IsSpicy            bool
+	IsBestSeller       bool
+	IsSignature        bool
+	IsRecommended      bool
+	TrackStock         bool
+	StockQty           *int32
+	DailyStockTemplate *int32
+	AutoResetStock     bool
+}
+
+func mergeMenuImportRow(row menuImportRow, existing *menuImportExisting) mergedMenuImportValues {
+	out := mergedMenuImportValues{Name: row.Name, IsActive: true}
+	if existing != nil {
+		out = mergedMenuImportValues{
+			Name:               row.Name,
+			Description:        existing.Description,
+			Price:              existing.Price,
+			IsActive:           existing.IsActive,
+			IsSpicy:            existing.IsSpicy,
+			IsBestSeller:       existing.IsBestSeller,
+			IsSignature:        existing.IsSignature,
+			IsRecommended:      existing.IsRecommended,
+			TrackStock:         existing.TrackStock,
+			StockQty:           existing.StockQty,
+			DailyStockTemplate: existing.DailyStockTemplate,
+			AutoResetStock:     existing.AutoResetStock,
+		}
+	}
+
+	if row.Description != nil {
+		if *row.Description == "" {
+			out.Description = nil
+		} else {
+			out.Description = row.Description
+		}
+	}
+	if row.Price != nil {
+		out.Price = *row.Price
+	}
+	assign := func(dst *bool, value *bool) {
+		if value != nil {
+			*dst = *value
+		}
+	}
+	assign(&out.IsActive, row.IsActive)
+	assign(&out.IsSpicy, row.IsSpicy)
+	assign(&out.IsBestSeller, row.IsBestSeller)
+	assign(&out.IsSignature, row.IsSignature)
+	assign(&out.IsRecommended, row.IsRecommended)
+	assign(&out.TrackStock, row.TrackStock)
+	assign(&out.AutoResetStock, row.AutoResetStock)
+	if row.StockQty != nil {
+		out.StockQty = row.StockQty
+	}
+	if row.DailyStockTemplate != nil {
+		out.DailyStockTemplate = row.DailyStockTemplate
+	}
+
+	if out.TrackStock {
+		if out.StockQty == nil {
+			zero := int32(0)
+			out.StockQty = &zero
+		}
+	} else {
+		out.StockQty = nil
+		out.DailyStockTemplate = nil
+		out.AutoResetStock = false
+	}
+	return out
+}
+
+// MerchantMenuImport imports menus from a CSV or XLSX upload. With
+// dryRun=true nothing is written and the response lists per-row errors and
+// the create/update/skip plan; otherwise the file is only applied when
+// every row is valid.
+func (h *Handler) MerchantMenuImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	data, _, filename, ferr := readFileBytes(r, "file", false, menuImportMaxFileBytes)
+	if ferr != nil {
+		switch ferr.Kind {
+		case fileReadErrMissing:
+			response.Error(w, http.StatusBadRequest, "FILE_REQUIRED", "File is required")
+		case fileReadErrTooLarge:
+			response.Error(w, http.StatusBadRequest, "INVALID_FILE", ferr.Message)
+		default:
+			response.Error(w, http.StatusInternalServerError, "IMPORT_FAILED", "Failed to read file")
+		}
+		return
+	}
+	name := ""
+	if filename != nil {
+		name = *filename
+	}
+
+	dryRun := strings.EqualFold(strings.TrimSpace(r.FormValue("dryRun")), "true")
+	mapping := make(map[string]string)
+	if raw := strings.TrimSpace(r.FormValue("mapping")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "mapping must be a JSON object of column to header")
+			return
+		}
+	}
+
+	rows, err := spreadsheet.Read(name, data)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "INVALID_FILE", "Could not read the spreadsheet. Upload a CSV or XLSX file.")
+		return
+	}
+	if len(rows) < 2 {
+		response.Error(w, http.StatusBadRequest, "INVALID_INPUT", "The file has no data rows")
+		return
+	}
+	if len(rows)-1 > menuImportMaxRows {
+		response.Error(w, http.StatusBadRequest, "TOO_MANY_ITEMS", fmt.Sprintf("Maximum %d rows allowed per import", menuImportMaxRows))
+		return
+	}
+
+	columns, err := resolveMenuImportColumns(rows[0], mapping)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	parsed, rowErrs := parseMenuImportRows(rows[1:], columns)
+
+	existing, err := h.fetchMenuSheetRows(ctx, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("menu import snapshot failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to validate menus")
+		return
+	}
+	categoryIDs, err := h.fetchNamedIDs(ctx, `select id, name from menu_categories where merchant_id = $1 and deleted_at is null`, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to validate categories")
+		return
+	}
+	addonGroupIDs, err := h.fetchNamedIDs(ctx, `select id, name from addon_categories where merchant_id = $1 and deleted_at is null`, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to validate addon groups")
+		return
+	}
+
+	plan := planMenuImport(parsed, existing, categoryIDs, addonGroupIDs)
+	plan.Errors = append(rowErrs, plan.Errors...)
+
+	mappedColumns := make(map[string]string, len(columns))
+	for col, idx := range columns {
+		mappedColumns[col] = rows[0][idx]
+	}
+	result := map[string]any{
+		"dryRun":  dryRun,
+		"columns": mappedColumns,
+		"summary": map[string]any{
+			"rows":   len(rows) - 1,
+			"create": plan.count("create"),
+			"update": plan.count("update"),
+			"skip":   plan.count("skip"),
+			"errors": len(plan.Errors),
+			"images": plan.imageCount(),
+		},
+		"actions":        plan.Actions,
+		"errors":         plan.Errors,
+		"newCategories":  plan.NewCategories,
+		"newAddonGroups": plan.NewAddonGroups,
+	}
+
+	if dryRun {
+		response.JSON(w, http.StatusOK, map[string]any{
+			"success":    true,
+			"data":       result,
+			"message":    "Import validated (dry run)",
+			"statusCode": 200,
+		})
+		return
+	}
+	if len(plan.Errors) > 0 {
+		response.JSON(w, http.StatusBadRequest, map[string]any{
+			"success":    false,
+			"error":      "VALIDATION_ERROR",
+			"data":       result,
+			"message":    fmt.Sprintf("%d row errors; nothing was imported", len(plan.Errors)),
+			"statusCode": http.StatusBadRequest,
+		})
+		return
+	}
+
+	images, warnings := h.prepareMenuImportImages(r, *authCtx.MerchantID, plan)
+
+	if err := h.applyMenuImport(ctx, *authCtx.MerchantID, authCtx.UserID, plan, categoryIDs, addonGroupIDs, images); err != nil {
+		h.Logger.Error("menu import failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to import menus")
+		return
+	}
+
+	result["warnings"] = warnings
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    result,
+		"message": fmt.Sprintf("Imported %d menu items (%d created, %d updated, %d unchanged)",
+			len(plan.Actions), plan.count("create"), plan.count("update"), plan.count("skip")),
+		"statusCode": 200,
+	})
+}
+
+type menuImportImage struct {
+	FullURL  string
+	ThumbURL string
+	Meta     []byte
+}
+
+// prepareMenuImportImages downloads and stores the images of rows whose URL
+// changed. Failures become warnings and leave the menu image untouched.
+func (h *Handler) prepareMenuImportImages(r *http.Request, merchantID int64, plan menuImportPlan) (map[int]menuImportImage, []menuImportRowError) {
+	ctx := r.Context()
+	images := make(map[int]menuImportImage)
+	warnings := make([]menuImportRowError, 0)
+	if plan.imageCount() == 0 {
+		return images, warnings
+	}
+
+	var merchantCode string
+	if err := h.DB.QueryRow(ctx, `select code from merchants where id = $1`, merchantID).Scan(&merchantCode); err != nil {
+		return images, append(warnings, menuImportRowError{Field: "imageUrl", Message: "Images were not imported: merchant not found"})
+	}
+	store, err := h.makeStore(r)
+	if err != nil {
+		h.Logger.Warn("menu import store unavailable", zapError(err))
+		return images, append(warnings, menuImportRowError{Field: "imageUrl", Message: "Images were not imported: storage is unavailable"})
+	}
+
+	budgetCtx, cancel := context.WithTimeout(ctx, menuImportImageBudget)
+	defer cancel()
+	downloads := 0
+	for _, action := range plan.Actions {
+		if !containsString(action.Changes, "imageUrl") {
+			continue
+		}
+		if downloads >= menuImportMaxImages {
+			warnings = append(warnings, menuImportRowError{Row: action.Row, Field: "imageUrl", Message: fmt.Sprintf("Image was not imported: at most %d images are downloaded per import", menuImportMaxImages)})
+			continue
+		}
+		if budgetCtx.Err() != nil {
+			warnings = append(warnings, menuImportRowError{Row: action.Row, Field: "imageUrl", Message: "Image was not imported: the import ran out of time for images"})
+			continue
+		}
+		downloads++
+		image, err := h.importMenuImage(budgetCtx, store, merchantCode, *action.row.ImageURL)
+		if err != nil {
+			warnings = append(warnings, menuImportRowError{Row: action.Row, Field: "imageUrl", Message: err.Error()})
+			continue
+		}
+		images[action.Row] = image
+	}
+	return images, warnings
+}
+
+func (h *Handler) importMenuImage(ctx context.Context, store *storage.ObjectStore, merchantCode, sourceURL string) (menuImportImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil || (req.URL.Scheme != "http" && req.URL.Scheme != "https") {
+		return menuImportImage{}, errors.New("Invalid image URL")
+	}
+	resp, err := menuImportImageClient.Do(req)
+	if err != nil {
+		if errors.Is(err, errMenuImportAddressBlocked) {
+			return menuImportImage{}, errors.New("Image URL must point to a public host")
+		}
+		return menuImportImage{}, errors.New("Image could not be downloaded")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return menuImportImage{}, fmt.Errorf("Image download failed with status %d", resp.StatusCode)
+	}
+
+	maxBytes := h.Config.MaxFileSizeBytes
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return menuImportImage{}, errors.New("Image could not be downloaded")
+	}
+	if int64(len(data)) > maxBytes {
+		return menuImportImage{}, fmt.Errorf("Image is larger than %dMB", maxBytes/(1024*1024))
+	}
+	if !utils.ValidateImageContentType(utils.DetectContentType(data)) {
+		return menuImportImage{}, errors.New("URL does not point to a supported image")
+	}
+
+	fullJpeg, _, err := utils.EncodeJpegFitInside(data, menuImportImageMaxSide, 90)
+	if err != nil {
+		return menuImportImage{}, errors.New("Image could not be processed")
+	}
+	imageKey := fmt.Sprintf("import-%d", time.Now().UnixMilli())
+	fullKey := addRandomSuffix(fmt.Sprintf("merchants/%s/menus/menu-%s.jpg", merchantCode, imageKey))
+
+	const cacheControl = "public, max-age=31536000, immutable"
+	fullURL, err := store.PutObject(ctx, fullKey, fullJpeg, "image/jpeg", cacheControl)
+	if err != nil {
+		return menuImportImage{}, errors.New("Image could not be stored")
+	}
+	stored, err := storeMenuImageVariants(ctx, store, merchantCode, imageKey, data)
+	if err != nil {
+		return menuImportImage{}, errors.New("Image could not be stored")
+	}
+
+	meta, _ := json.Marshal(stored.Meta)
+	return menuImportImage{FullURL: fullURL, ThumbURL: stored.ThumbURL, Meta: meta}, nil
+}
+
+func (h *Handler) applyMenuImport(
+	ctx context.Context,
+	merchantID int64,
+	userID int64,
+	plan menuImportPlan,
+	categoryIDs map[string]int64,
+	addonGroupIDs map[string]int64,
+	images map[int]menuImportImage,
+) error {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if len(plan.NewCategories) > 0 {
+		var maxSort pgtype.Int4
+		if err := tx.QueryRow(ctx, `select max(sort_order) from menu_categories where merchant_id = $1`, merchantID).Scan(&maxSort); err != nil {
+			return err
+		}
+		sortOrder := maxSort.Int32
+		for _, name := range plan.NewCategories {
+			sortOrder++
+			var id int64
+			if err := tx.QueryRow(ctx, `
+				insert into menu_categories (merchant_id, name, sort_order, is_active, created_at, updated_at, created_by_user_id)
+				values ($1, $2, $3, true, now(), now(), $4)
+				returning id
+			`, merchantID, name, sortOrder, userID).Scan(&id); err != nil {
+				return err
+			}
+			categoryIDs[strings.ToLower(name)] = id
+		}
+	}
+	for _, name := range plan.NewAddonGroups {
+		var id int64
+		if err := tx.QueryRow(ctx, `
+			insert into addon_categories (
+				merchant_id, name, min_selection, is_active,
+				created_at, updated_at, created_by_user_id, updated_by_user_id
+			) values ($1, $2, 0, true, now(), now(), $3, $3)
+			returning id
+		`, merchantID, name, userID).Scan(&id); err != nil {
+			return err
+		}
+		addonGroupIDs[strings.ToLower(name)] = id
+	}
+
+	for _, action := range plan.Actions {
+		if action.Action == "skip" {
+			continue
+		}
+		row := action.row
+		values := mergeMenuImportRow(row, action.existing)
+
+		var primaryCategoryID *int64
+		if len(row.Categories) > 0 {
+			id := categoryIDs[strings.ToLower(row.Categories[0])]
+			primaryCategoryID = &id
+		}
+
+		menuID := int64(0)
+		if action.existing == nil {
+			if err := tx.QueryRow(ctx, `
+				insert into menus (
+					merchant_id, name, description, price, category_id, is_active, is_spicy, is_best_seller,
+					is_signature, is_recommended, track_stock, stock_qty, daily_stock_template, auto_reset_stock,
+					created_at, updated_at, created_by_user_id, updated_by_user_id
+				) values (
+					$1, $2, $3, $4, $5, $6, $7, $8,
+					$9, $10, $11, $12, $13, $14,
+					now(), now(), $15, $15
+				) returning id
+			`, merchantID, values.Name, values.Description, values.Price, primaryCategoryID, values.IsActive, values.IsSpicy,
+				values.IsBestSeller, values.IsSignature, values.IsRecommended, values.TrackStock, values.StockQty,
+				values.DailyStockTemplate, values.AutoResetStock, userID).Scan(&menuID); err != nil {
+				return err
+			}
+		} else {
+			menuID = action.existing.ID
+			if _, err := tx.Exec(ctx, `
+				update menus set
+					name = $1,
+					description = $2,
+					price = $3,
+					category_id = case when $15 then $4 else category_id end,
+					is_active = $5,
+					is_spicy = $6,
+					is_best_seller = $7,
+					is_signature = $8,
+					is_recommended = $9,
+					track_stock = $10,
+					stock_qty = $11,
+					daily_stock_template = $12,
+					auto_reset_stock = $13,
+					updated_at = now(),
+					updated_by_user_id = $14
+				where id = $16 and merchant_id = $17
+			`, values.Name, values.Description, values.Price, primaryCategoryID, values.IsActive, values.IsSpicy,
+				values.IsBestSeller, values.IsSignature, values.IsRecommended, values.TrackStock, values.StockQty,
+				values.DailyStockTemplate, values.AutoResetStock, userID, row.Categories != nil, menuID, merchantID); err != nil {
+				return err
+			}
+		}
+
+		if row.Categories != nil {
+			if _, err := tx.Exec(ctx, `delete from menu_category_items where menu_id = $1`, menuID); err != nil {
+				return err
+			}
+			for _, name := range row.Categories {
+				if _, err := tx.Exec(ctx, `
+					insert into menu_category_items (menu_id, category_id) values ($1, $2)
+				`, menuID, categoryIDs[strings.ToLower(name)]); err != nil {
+					return err
+				}
+			}
+		}
+		if row.AddonGroups != nil {
+			if err := replaceMenuAddonCategories(ctx, tx, menuID, row.AddonGroups, addonGroupIDs); err != nil {
+				return err
+			}
+		}
+		if image, ok := images[row.Row]; ok {
+			if _, err := tx.Exec(ctx, `
+				update menus
+				set image_url = $1, image_thumb_url = $2, image_thumb_meta = $3, updated_by_user_id = $4
+				where id = $5
+			`, image.FullURL, image.ThumbURL, image.Meta, userID, menuID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func replaceMenuAddonCategories(ctx context.Context, tx pgx.Tx, menuID int64, names []string, addonGroupIDs map[string]int64) error {
+	if _, err := tx.Exec(ctx, `delete from menu_addon_categories where menu_id = $1`, menuID); err != nil {
+		return err
+	}
+	for idx, name := range names {
+		if _, err := tx.Exec(ctx, `
+			insert into menu_addon_categories (menu_id, addon_category_id, display_order, is_required, created_at, updated_at)
+			values ($1, $2, $3, false, now(), now())
+		`, menuID, addonGroupIDs[strings.ToLower(name)], idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) fetchNamedIDs(ctx context.Context, query string, merchantID int64) (map[string]int64, error) {
+	rows, err := h.DB.Query(ctx, query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int64)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, exists := out[key]; !exists {
+			out[key] = id
+		}
+	}
+	return out, rows.Err()
+}
+
+// fetchMenuSheetRows loads the merchant's menus in export shape. The primary
+// category is listed first so an export/import round trip keeps it.
+func (h *Handler) fetchMenuSheetRows(ctx context.Context, merchantID int64) ([]menuImportExisting, error) {
+	rows, err := h.DB.Query(ctx, `
+		select
+			m.id, m.name, m.description, m.price,
+			m.is_active, m.is_spicy, m.is_best_seller, m.is_signature, m.is_recommended,
+			m.track_stock, m.stock_qty, m.daily_stock_template, m.auto_reset_stock, m.image_url,
+			coalesce((
+				select array_agg(mc.name order by (mc.id = m.category_id) desc, mc.sort_order, mc.id)
+				from menu_category_items mci
+				join menu_categories mc on mc.id = mci.category_id and mc.deleted_at is null
+				where mci.menu_id = m.id
+			), '{}'),
+			coalesce((
+				select array_agg(ac.name order by mac.display_order, ac.id)
+				from menu_addon_categories mac
+				join addon_categories ac on ac.id = mac.addon_category_id and ac.deleted_at is null
+				where mac.menu_id = m.id
+			), '{}')
+		from menus m
+		where m.merchant_id = $1 and m.deleted_at is null
+		order by m.id
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]menuImportExisting, 0)
+	for rows.Next() {
+		var (
+			item          menuImportExisting
+			description   pgtype.Text
+			price         pgtype.Numeric
+			stockQty      pgtype.Int4
+			dailyTemplate pgtype.Int4
+			imageURL      pgtype.Text
+		)
+		if err := rows.Scan(
+			&item.ID, &item.Name, &description, &price,
+			&item.IsActive, &item.IsSpicy, &item.IsBestSeller, &item.IsSignature, &item.IsRecommended,
+			&item.TrackStock, &stockQty, &dailyTemplate, &item.AutoResetStock, &imageURL,
+			&item.Categories, &item.AddonGroups,
+		); err != nil {
+			return nil, err
+		}
+		item.Description = ptrString(description)
+		item.Price = utils.NumericToFloat64(price)
+		item.ImageURL = ptrString(imageURL)
+		if stockQty.Valid {
+			v := stockQty.Int32
+			item.StockQty = &v
+		}
+		if dailyTemplate.Valid {
+			v := dailyTemplate.Int32
+			item.DailyStockTemplate = &v
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
+func menuSheetRecord(item menuImportExisting) []string {
+	optionalInt := func(v *int32) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(int64(*v), 10)
+	}
+	optionalString := func(v *string) string {
+		if v == nil {
+			return ""
+		}
+		return *v
+	}
+	return []string{
+		strconv.FormatInt(item.ID, 10),
+		item.Name,
+		optionalString(item.Description),
+		strconv.FormatFloat(item.Price, 'f', -1, 64),
+		strings.Join(item.Categories, menuImportListSep),
+		strings.Join(item.AddonGroups, menuImportListSep),
+		strconv.FormatBool(item.IsActive),
+		strconv.FormatBool(item.IsSpicy),
+		strconv.FormatBool(item.IsBestSeller),
+		strconv.FormatBool(item.IsSignature),
+		strconv.FormatBool(item.IsRecommended),
+		strconv.FormatBool(item.TrackStock),
+		optionalInt(item.StockQty),
+		optionalInt(item.DailyStockTemplate),
+		strconv.FormatBool(item.AutoResetStock),
+		optionalString(item.ImageURL),
+	}
+}
+
+// MerchantMenuExport downloads the menu as CSV (default) or XLSX in the
+// column layout the import reads.
+func (h *Handler) MerchantMenuExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = spreadsheet.FormatCSV
+	}
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "format must be csv or xlsx")
+		return
+	}
+
+	var merchantCode string
+	if err := h.DB.QueryRow(ctx, `select code from merchants where id = $1`, *authCtx.MerchantID).Scan(&merchantCode); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found")
+		return
+	}
+
+	items, err := h.fetchMenuSheetRows(ctx, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("menu export failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export menus")
+		return
+	}
+
+	records := make([][]string, 0, len(items)+1)
+	records = append(records, menuSheetColumns)
+	for _, item := range items {
+		records = append(records, menuSheetRecord(item))
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == spreadsheet.FormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	filename := fmt.Sprintf("menu_%s_%s.%s", merchantCode, time.Now().Format("2006-01-02"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.WriteHeader(http.StatusOK)
+	if err := spreadsheet.Write(w, format, "Menu", records); err != nil {
+		h.Logger.Warn("menu export write failed", zapError(err))
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResolveMenuImportColumns(t *testing.T) {
+	header := []string{"Item Name", "Cost", "Category", "Image", "Notes"}
+
+	columns, err := resolveMenuImportColumns(header, map[string]string{"price": "cost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]int{"name": 0, "price": 1, "categories": 2, "imageUrl": 3}
+	if !reflect.DeepEqual(columns, want) {
+		t.Fatalf("expected %v, got %v", want, columns)
+	}
+
+	invalid := []struct {
+		name    string
+		header  []string
+		mapping map[string]string
+	}{
+		{name: "no name column", header: []string{"Cost"}},
+		{name: "unknown mapping column", header: header, mapping: map[string]string{"colour": "Notes"}},
+		{name: "mapped header missing", header: header, mapping: map[string]string{"description": "Details"}},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := resolveMenuImportColumns(tc.header, tc.mapping); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestParseMenuImportRows(t *testing.T) {
+	columns, err := resolveMenuImportColumns(menuSheetColumns, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	row := func(values map[string]string) []string {
+		cells := make([]string, len(menuSheetColumns))
+		for col, value := range values {
+			cells[columns[col]] = value
+		}
+		return cells
+	}
+
+	rows := [][]string{
+		row(map[string]string{"name": "Soup", "price": "12.5", "categories": "Mains | Soups|mains", "isSpicy": "yes"}),
+		row(nil),
+		row(map[string]string{"name": "soup", "price": "3"}),
+		row(map[string]string{"name": "Tea", "price": "-1", "trackStock": "maybe", "stockQty": "1.5", "imageUrl": "ftp://x/y.png"}),
+		row(map[string]string{"id": "abc", "name": "Cake"}),
+	}
+
+	parsed, errs := parseMenuImportRows(rows, columns)
+	if len(parsed) != 1 {
+		t.Fatalf("expected one valid row, got %d (%+v)", len(parsed), parsed)
+	}
+	soup := parsed[0]
+	if soup.Row != 2 || soup.Price == nil || *soup.Price != 12.5 || soup.IsSpicy == nil || !*soup.IsSpicy {
+		t.Fatalf("unexpected parsed row: %+v", soup)
+	}
+	if !reflect.DeepEqual(soup.Categories, []string{"Mains", "Soups"}) {
+		t.Fatalf("expected de-duplicated categories, got %v", soup.Categories)
+	}
+	if soup.AddonGroups == nil || len(soup.AddonGroups) != 0 {
+		t.Fatalf("expected blank addon groups to clear, got %#v", soup.AddonGroups)
+	}
+	if soup.IsActive != nil || soup.ImageURL != nil {
+		t.Fatalf("expected blank cells to keep current values, got %+v", soup)
+	}
+
+	fields := make(map[int][]string)
+	for _, e := range errs {
+		fields[e.Row] = append(fields[e.Row], e.Field)
+	}
+	wantFields := map[int][]string{
+		4: {"name"},
+		5: {"price", "trackStock", "stockQty", "imageUrl"},
+		6: {"id"},
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Fatalf("expected errors %v, got %v", wantFields, fields)
+	}
+}
+
+func TestPlanMenuImport(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	id := func(v int64) *int64 { return &v }
+	yes := true
+
+	existing := []menuImportExisting{
+		{ID: 1, Name: "Soup", Price: 10, Categories: []string{"Mains"}, IsActive: true},
+		{ID: 2, Name: "Tea", Price: 5, IsActive: true},
+	}
+	rows := []menuImportRow{
+		{Row: 2, Name: "soup", Price: price(10), Categories: []string{"mains"}},
+		{Row: 3, Name: "Tea", Price: price(6), IsSpicy: &yes},
+		{Row: 4, Name: "Cake", Price: price(20), Categories: []string{"Desserts"}, AddonGroups: []string{"Toppings"}},
+		{Row: 5, Name: "Bread"},
+		{Row: 6, ID: id(99), Name: "Ghost", Price: price(1)},
+		{Row: 7, ID: id(2), Name: "Iced Tea"},
+	}
+
+	plan := planMenuImport(rows, existing, map[string]int64{"mains": 7}, map[string]int64{})
+
+	got := make(map[int]string)
+	for _, a := range plan.Actions {
+		got[a.Row] = a.Action
+	}
+	want := map[int]string{2: "update", 3: "update", 4: "create", 7: "update"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected actions %v, got %v", want, got)
+	}
+	if changes := plan.Actions[0].Changes; !reflect.DeepEqual(changes, []string{"name"}) {
+		t.Fatalf("expected only the name casing to change, got %v", changes)
+	}
+	if changes := plan.Actions[1].Changes; !reflect.DeepEqual(changes, []string{"price", "isSpicy"}) {
+		t.Fatalf("unexpected changes for Tea: %v", changes)
+	}
+	if len(plan.Errors) != 2 || plan.Errors[0].Row != 5 || plan.Errors[1].Row != 6 {
+		t.Fatalf("expected errors for rows 5 and 6, got %+v", plan.Errors)
+	}
+	if !reflect.DeepEqual(plan.NewCategories, []string{"Desserts"}) || !reflect.DeepEqual(plan.NewAddonGroups, []string{"Toppings"}) {
+		t.Fatalf("unexpected new names: %v %v", plan.NewCategories, plan.NewAddonGroups)
+	}
+}
+
+func TestMenuSheetRoundTripIsUnchanged(t *testing.T) {
+	qty := int32(4)
+	description := "Hot, with \"chili\""
+	image := "https://cdn.example.com/soup.jpg"
+	existing := []menuImportExisting{
+		{
+			ID: 1, Name: "Soup", Description: &description, Price: 12.5,
+			Categories: []string{"Mains", "Soups"}, AddonGroups: []string{"Extras"},
+			IsActive: true, IsSpicy: true, TrackStock: true, StockQty: &qty, ImageURL: &image,
+		},
+		{ID: 2, Name: "Tea", Price: 5, Categories: []string{}, AddonGroups: []string{}},
+	}
+
+	records := [][]string{menuSheetColumns}
+	for _, item := range existing {
+		records = append(records, menuSheetRecord(item))
+	}
+	columns, err := resolveMenuImportColumns(records[0], nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, errs := parseMenuImportRows(records[1:], columns)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	plan := planMenuImport(rows, existing, map[string]int64{"mains": 1, "soups": 2}, map[string]int64{"extras": 3})
+	for _, a := range plan.Actions {
+		if a.Action != "skip" {
+			t.Fatalf("row %d: expected skip, got %s %v", a.Row, a.Action, a.Changes)
+		}
+	}
+}
+
+func TestMergeMenuImportRowStockRules(t *testing.T) {
+	no := false
+	yes := true
+	qty := int32(3)
+
+	created := mergeMenuImportRow(menuImportRow{Name: "New", TrackStock: &yes}, nil)
+	if !created.IsActive || created.StockQty == nil || *created.StockQty != 0 {
+		t.Fatalf("expected active new menu with zero stock, got %+v", created)
+	}
+
+	existing := &menuImportExisting{ID: 1, Name: "Soup", TrackStock: true, StockQty: &qty, DailyStockTemplate: &qty, AutoResetStock: true}
+	stopped := mergeMenuImportRow(menuImportRow{Name: "Soup", TrackStock: &no}, existing)
+	if stopped.StockQty != nil || stopped.DailyStockTemplate != nil || stopped.AutoResetStock {
+		t.Fatalf("expected stock fields cleared, got %+v", stopped)
+	}
+
+	kept := mergeMenuImportRow(menuImportRow{Name: "Soup"}, existing)
+	if kept.StockQty == nil || *kept.StockQty != 3 || !kept.AutoResetStock {
+		t.Fatalf("expected current stock kept, got %+v", kept)
+	}
+}
+
+func TestIsPublicImportAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.9":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00:ec2::254":    false,
+		"100.100.100.200":  false,
+		"0.0.0.0":          false,
+		"::":               false,
+		"224.0.0.1":        false,
+		"255.255.255.255":  false,
+		"::ffff:127.0.0.1": false,
+		"::ffff:10.0.0.1":  false,
+	}
+	for addr, want := range cases {
+		if got := isPublicImportAddress(net.ParseIP(addr)); got != want {
+			t.Errorf("%s: expected %v, got %v", addr, want, got)
+		}
+	}
+}
+
+func TestMenuImportDialControlRejectsPrivateAddresses(t *testing.T) {
+	if err := menuImportDialControl("tcp", "169.254.169.254:80", nil); !errors.Is(err, errMenuImportAddressBlocked) {
+		t.Fatalf("expected metadata address to be blocked, got %v", err)
+	}
+	if err := menuImportDialControl("tcp", "[::1]:443", nil); !errors.Is(err, errMenuImportAddressBlocked) {
+		t.Fatalf("expected loopback to be blocked, got %v", err)
+	}
+	if err := menuImportDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Fatalf("expected public address to be allowed, got %v", err)
+	}
+}
+
+func TestMenuImportImageClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("loopback server should not be reached")
+	}))
+	defer server.Close()
+
+	_, err := menuImportImageClient.Get(server.URL)
+	if !errors.Is(err, errMenuImportAddressBlocked) {
+		t.Fatalf("expected blocked address error, got %v", err)
+	}
+}
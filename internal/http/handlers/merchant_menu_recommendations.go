@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+)
+
+const maxRecommendationRules = 50
+
+type recommendationRulesPayload struct {
+	Pinned   []int64 `json:"pinned"`
+	Excluded []int64 `json:"excluded"`
+}
+
+// validateRecommendationRules checks that no menu is listed twice, in either
+// list or across both.
+func validateRecommendationRules(body recommendationRulesPayload) error {
+	if len(body.Pinned)+len(body.Excluded) > maxRecommendationRules {
+		return fmt.Errorf("at most %d pinned and excluded menus are allowed", maxRecommendationRules)
+	}
+	seen := make(map[int64]bool, len(body.Pinned)+len(body.Excluded))
+	for _, list := range [][]int64{body.Pinned, body.Excluded} {
+		for _, id := range list {
+			if id <= 0 {
+				return fmt.Errorf("invalid menu id %d", id)
+			}
+			if seen[id] {
+				return fmt.Errorf("menu %d is listed more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// MerchantMenuRecommendationRules lists pinned and excluded recommendation
+// menus and when scores were last computed.
+func (h *Handler) MerchantMenuRecommendationRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select r.menu_id, m.name, r.rule, r.position
+		from menu_recommendation_rules r
+		join menus m on m.id = r.menu_id
+		where r.merchant_id = $1 and m.deleted_at is null
+		order by r.rule, r.position, r.menu_id
+	`, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("recommendation rules query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve recommendation rules")
+		return
+	}
+	defer rows.Close()
+
+	pinned := make([]map[string]any, 0)
+	excluded := make([]map[string]any, 0)
+	for rows.Next() {
+		var (
+			menuID   int64
+			name     string
+			rule     string
+			position int32
+		)
+		if err := rows.Scan(&menuID, &name, &rule, &position); err != nil {
+			h.Logger.Error("recommendation rules scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve recommendation rules")
+			return
+		}
+		item := map[string]any{"menuId": menuID, "name": name}
+		if rule == recommendationRulePin {
+			item["position"] = position
+			pinned = append(pinned, item)
+		} else {
+			excluded = append(excluded, item)
+		}
+	}
+	rows.Close()
+
+	var computedAt *time.Time
+	_ = h.DB.QueryRow(ctx, `select computed_at from merchant_recommendation_runs where merchant_id = $1`, *authCtx.MerchantID).Scan(&computedAt)
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"pinned":     pinned,
+			"excluded":   excluded,
+			"computedAt": computedAt,
+		},
+		"message":    "Recommendation rules retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuRecommendationRulesReplace replaces the pinned (in display
+// order) and excluded recommendation menus.
+func (h *Handler) MerchantMenuRecommendationRulesReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body recommendationRulesPayload
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if err := validateRecommendationRules(body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	ids := append(append([]int64{}, body.Pinned...), body.Excluded...)
+	var found int
+	if err := h.DB.QueryRow(ctx, `
+		select count(*) from menus where merchant_id = $1 and id = any($2) and deleted_at is null
+	`, *authCtx.MerchantID, ids).Scan(&found); err != nil {
+		h.Logger.Error("recommendation rules menu lookup failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+		return
+	}
+	if found != len(ids) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "One or more menus were not found")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `delete from menu_recommendation_rules where merchant_id = $1`, *authCtx.MerchantID); err != nil {
+		h.Logger.Error("recommendation rules delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+		return
+	}
+	insert := func(menuID int64, rule string, position int) error {
+		_, err := tx.Exec(ctx, `
+			insert into menu_recommendation_rules (merchant_id, menu_id, rule, position, created_by_user_id)
+			values ($1, $2, $3, $4, $5)
+		`, *authCtx.MerchantID, menuID, rule, position, authCtx.UserID)
+		return err
+	}
+	for i, id := range body.Pinned {
+		if err := insert(id, recommendationRulePin, i); err != nil {
+			h.Logger.Error("recommendation rule insert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+			return
+		}
+	}
+	for _, id := range body.Excluded {
+		if err := insert(id, recommendationRuleExclude, 0); err != nil {
+			h.Logger.Error("recommendation rule insert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save recommendation rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"pinned":   body.Pinned,
+			"excluded": body.Excluded,
+		},
+		"message":    "Recommendation rules updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuRecommendationsRecompute rebuilds the merchant's recommendation
+// scores now instead of waiting for the next sweep.
+func (h *Handler) MerchantMenuRecommendationsRecompute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	computedAt, err := h.refreshMerchantRecommendations(ctx, *authCtx.MerchantID, true)
+	if err != nil || computedAt == nil {
+		if err != nil {
+			h.Logger.Error("recommendation recompute failed", zapError(err))
+		}
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to recompute recommendations")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"computedAt": computedAt},
+		"message":    "Recommendations recomputed successfully",
+		"statusCode": 200,
+	})
+}
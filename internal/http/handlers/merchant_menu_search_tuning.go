@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+)
+
+const (
+	maxMenuSearchSynonymGroups = 200
+	maxMenuSearchGroupTerms    = 10
+	maxMenuSearchKeywords      = 20
+	maxMenuSearchTermLength    = 60
+	maxSearchMissDays          = 90
+)
+
+// normalizeSearchTerms trims and de-duplicates terms by their folded form,
+// keeping the first spelling given.
+func normalizeSearchTerms(terms []string, max int, label string) ([]string, error) {
+	out := make([]string, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		term = strings.Join(strings.Fields(term), " ")
+		folded := foldSearchText(term)
+		if folded == "" {
+			continue
+		}
+		if len([]rune(term)) > maxMenuSearchTermLength {
+			return nil, fmt.Errorf("%s %q is longer than %d characters", label, term, maxMenuSearchTermLength)
+		}
+		if seen[folded] {
+			continue
+		}
+		seen[folded] = true
+		out = append(out, term)
+	}
+	if len(out) > max {
+		return nil, fmt.Errorf("at most %d %ss are allowed", max, label)
+	}
+	return out, nil
+}
+
+func normalizeSynonymGroups(groups [][]string) ([][]string, error) {
+	if len(groups) > maxMenuSearchSynonymGroups {
+		return nil, fmt.Errorf("at most %d synonym groups are allowed", maxMenuSearchSynonymGroups)
+	}
+	out := make([][]string, 0, len(groups))
+	for i, group := range groups {
+		terms, err := normalizeSearchTerms(group, maxMenuSearchGroupTerms, "synonym")
+		if err != nil {
+			return nil, err
+		}
+		if len(terms) < 2 {
+			return nil, fmt.Errorf("synonym group %d needs at least two different terms", i+1)
+		}
+		out = append(out, terms)
+	}
+	return out, nil
+}
+
+// MerchantMenuSearchSynonyms lists the merchant's search synonym groups.
+func (h *Handler) MerchantMenuSearchSynonyms(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	groups, err := h.loadMenuSearchSynonyms(ctx, *authCtx.MerchantID)
+	if err != nil {
+		h.Logger.Error("search synonyms query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve search synonyms")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"synonyms": groups},
+		"message":    "Search synonyms retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuSearchSynonymsReplace replaces all of the merchant's search
+// synonym groups.
+func (h *Handler) MerchantMenuSearchSynonymsReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body struct {
+		Synonyms [][]string `json:"synonyms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	groups, err := normalizeSynonymGroups(body.Synonyms)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save search synonyms")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `delete from menu_search_synonyms where merchant_id = $1`, *authCtx.MerchantID); err != nil {
+		h.Logger.Error("search synonyms delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save search synonyms")
+		return
+	}
+	for _, terms := range groups {
+		if _, err := tx.Exec(ctx, `
+			insert into menu_search_synonyms (merchant_id, terms) values ($1, $2)
+		`, *authCtx.MerchantID, terms); err != nil {
+			h.Logger.Error("search synonyms insert failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save search synonyms")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save search synonyms")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"synonyms": groups},
+		"message":    "Search synonyms updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuSearchKeywords returns the extra search keywords of a menu.
+func (h *Handler) MerchantMenuSearchKeywords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+
+	var keywords []string
+	if err := h.DB.QueryRow(ctx, `
+		select coalesce(msk.keywords, '{}')
+		from menus m
+		left join menu_search_keywords msk on msk.menu_id = m.id
+		where m.id = $1 and m.merchant_id = $2 and m.deleted_at is null
+	`, id, *authCtx.MerchantID).Scan(&keywords); err != nil {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"menuId": id, "keywords": keywords},
+		"message":    "Search keywords retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantMenuSearchKeywordsReplace replaces the extra search keywords of a
+// menu, such as nicknames staff use for it.
+func (h *Handler) MerchantMenuSearchKeywordsReplace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+
+	var body struct {
+		Keywords []string `json:"keywords"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	keywords, err := normalizeSearchTerms(body.Keywords, maxMenuSearchKeywords, "keyword")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	tag, err := h.DB.Exec(ctx, `
+		insert into menu_search_keywords (menu_id, merchant_id, keywords, updated_at, updated_by_user_id)
+		select id, merchant_id, $3, now(), $4
+		from menus
+		where id = $1 and merchant_id = $2 and deleted_at is null
+		on conflict (menu_id) do update set
+			keywords = excluded.keywords,
+			updated_at = excluded.updated_at,
+			updated_by_user_id = excluded.updated_by_user_id
+	`, id, *authCtx.MerchantID, keywords, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("search keywords update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update search keywords")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Menu not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       map[string]any{"menuId": id, "keywords": keywords},
+		"message":    "Search keywords updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantSearchMissesAnalytics lists the most frequent customer menu
+// searches that matched nothing over the last ?days= (default 30).
+func (h *Handler) MerchantSearchMissesAnalytics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	days := parseIntQuery(r, "days", 30)
+	if days < 1 || days > maxSearchMissDays {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("days must be between 1 and %d", maxSearchMissDays))
+		return
+	}
+	limit := parseIntQuery(r, "limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select query, sum(count)::bigint, count(*)::int, max(last_searched_at)
+		from menu_search_misses
+		where merchant_id = $1 and day > current_date - $2::int
+		group by query
+		order by sum(count) desc, max(last_searched_at) desc
+		limit $3
+	`, *authCtx.MerchantID, days, limit)
+	if err != nil {
+		h.Logger.Error("search misses query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch search misses")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]map[string]any, 0)
+	var total int64
+	for rows.Next() {
+		var (
+			query          string
+			count          int64
+			activeDays     int32
+			lastSearchedAt time.Time
+		)
+		if err := rows.Scan(&query, &count, &activeDays, &lastSearchedAt); err != nil {
+			h.Logger.Error("search misses scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch search misses")
+			return
+		}
+		total += count
+		items = append(items, map[string]any{
+			"query":          query,
+			"count":          count,
+			"days":           activeDays,
+			"lastSearchedAt": lastSearchedAt,
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"days":   days,
+			"total":  total,
+			"misses": items,
+		},
+		"message":    "Search misses retrieved successfully",
+		"statusCode": 200,
+	})
+}
@@ -45,6 +45,8 @@ type posMenuItem struct {
 	IsSignature     bool                   `json:"isSignature"`
 	IsRecommended   bool                   `json:"isRecommended"`
 	PromoPrice      *float64               `json:"promoPrice"`
+	Variants        []menuVariant          `json:"variants"`
+	Bundle          *menuBundle            `json:"bundle"`
 	HasAddons       bool                   `json:"hasAddons"`
 	AddonCategories []posMenuAddonCategory `json:"addonCategories"`
 }
@@ -130,6 +132,20 @@ func (h *Handler) MerchantPOSMenuGet(w http.ResponseWriter, r *http.Request) {
 
 	promoPrices := h.fetchPOSPromoPrices(ctx, merchantID, menuIDs)
 
+	variants, err := h.fetchMenuVariants(ctx, merchantID, menuIDs, false)
+	if err != nil {
+		h.Logger.Error("pos menu variants fetch failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch POS menu data")
+		return
+	}
+
+	bundles, err := h.fetchMenuBundles(ctx, merchantID, menuIDs)
+	if err != nil {
+		h.Logger.Error("pos menu bundles fetch failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch POS menu data")
+		return
+	}
+
 	menuAddons, addonCategoryIDs, err := h.fetchPOSMenuAddonCategories(ctx, menuIDs)
 	if err != nil {
 		h.Logger.Error("pos addon categories fetch failed", zapError(err))
@@ -144,6 +160,8 @@ func (h *Handler) MerchantPOSMenuGet(w http.ResponseWriter, r *http.Request) {
 		if promo, ok := promoPrices[item.ID]; ok {
 			item.PromoPrice = &promo
 		}
+		item.Variants = variantPayloads(variants[item.ID])
+		item.Bundle = bundles[item.ID]
 		addons := menuAddons[item.ID]
 		for addonIdx := range addons {
 			addons[addonIdx].AddonItems = addonItems[addons[addonIdx].ID]
@@ -171,6 +171,12 @@ func (h *Handler) MerchantReports(w http.ResponseWriter, r *http.Request) {
 	}
 	hourlyPerformance := buildHourlyPerformanceReport(orders, location)
 	scheduledSummary := buildScheduledSummary(orders)
+	curbsideSummary, err := h.buildCurbsideSummary(ctx, *authCtx.MerchantID, startDate, endDate)
+	if err != nil {
+		h.Logger.Error("merchant report curbside summary failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch reports data")
+		return
+	}
 
 	payload := map[string]any{
 		"success": true,
@@ -206,6 +212,7 @@ func (h *Handler) MerchantReports(w http.ResponseWriter, r *http.Request) {
 			"orderStatusBreakdown": orderStatusBreakdown,
 			"paymentBreakdown":     paymentBreakdown,
 			"scheduledSummary":     scheduledSummary,
+			"curbsideSummary":      curbsideSummary,
 			"dailyRevenue":         dailyRevenue,
 			"anomalies":            anomalies,
 			"anomalySettings": map[string]any{
@@ -708,9 +715,10 @@ func (h *Handler) buildTopMenuItemsReport(ctx context.Context, orders []reportOr
 		return []map[string]any{}, nil
 	}
 	rows, err := h.DB.Query(ctx, `
-        select menu_id, menu_name, quantity, subtotal
-        from order_items
-        where order_id = any($1)
+        select oi.menu_id, oi.menu_name, oiv.variant_id, oiv.variant_name, oi.quantity, oi.subtotal
+        from order_items oi
+        left join order_item_variants oiv on oiv.order_item_id = oi.id
+        where oi.order_id = any($1)
     `, orderIDs)
 	if err != nil {
 		return nil, err
@@ -718,14 +726,17 @@ func (h *Handler) buildTopMenuItemsReport(ctx context.Context, orders []reportOr
 	defer rows.Close()
 
 	agg := make(map[string]map[string]any)
+	variantAgg := make(map[string]map[string]map[string]any)
 	for rows.Next() {
 		var (
-			menuID   pgtype.Int8
-			menuName pgtype.Text
-			quantity int32
-			subtotal pgtype.Numeric
+			menuID      pgtype.Int8
+			menuName    pgtype.Text
+			variantID   pgtype.Int8
+			variantName pgtype.Text
+			quantity    int32
+			subtotal    pgtype.Numeric
 		)
-		if err := rows.Scan(&menuID, &menuName, &quantity, &subtotal); err != nil {
+		if err := rows.Scan(&menuID, &menuName, &variantID, &variantName, &quantity, &subtotal); err != nil {
 			return nil, err
 		}
 		name := "Menu"
@@ -748,10 +759,40 @@ func (h *Handler) buildTopMenuItemsReport(ctx context.Context, orders []reportOr
 		entry["quantity"] = entry["quantity"].(int64) + int64(quantity)
 		entry["revenue"] = entry["revenue"].(float64) + utils.NumericToFloat64(subtotal)
 		agg[key] = entry
+
+		if variantID.Valid || variantName.Valid {
+			variantKey := "NAME::" + variantName.String
+			if variantID.Valid {
+				variantKey = strconv.FormatInt(variantID.Int64, 10)
+			}
+			if variantAgg[key] == nil {
+				variantAgg[key] = make(map[string]map[string]any)
+			}
+			variant := variantAgg[key][variantKey]
+			if variant == nil {
+				variant = map[string]any{
+					"key":      variantKey,
+					"name":     variantName.String,
+					"quantity": int64(0),
+					"revenue":  float64(0),
+				}
+			}
+			variant["quantity"] = variant["quantity"].(int64) + int64(quantity)
+			variant["revenue"] = variant["revenue"].(float64) + utils.NumericToFloat64(subtotal)
+			variantAgg[key][variantKey] = variant
+		}
 	}
 
 	items := make([]map[string]any, 0, len(agg))
-	for _, entry := range agg {
+	for key, entry := range agg {
+		variants := make([]map[string]any, 0, len(variantAgg[key]))
+		for _, variant := range variantAgg[key] {
+			variants = append(variants, variant)
+		}
+		sort.Slice(variants, func(i, j int) bool {
+			return variants[i]["quantity"].(int64) > variants[j]["quantity"].(int64)
+		})
+		entry["variants"] = variants
 		items = append(items, entry)
 	}
 
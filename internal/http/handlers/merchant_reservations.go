@@ -797,11 +797,17 @@ func (h *Handler) MerchantReservationAccept(w http.ResponseWriter, r *http.Reque
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to accept reservation")
 		return
 	}
+	if err := h.syncOrderIngredientsTx(ctx, tx, orderID, &authCtx.UserID); err != nil {
+		h.Logger.Error("reservation ingredient depletion failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to accept reservation")
+		return
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to accept reservation")
 		return
 	}
+	h.checkOrderStockAlerts(ctx, orderID)
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
@@ -32,9 +32,27 @@ type specialPricePayload struct {
 
 type specialPriceItemPayload struct {
 	MenuID     int64
+	VariantID  *int64
 	PromoPrice float64
 }
 
+// insertSpecialPriceItem stores a promo price. Menu prices go to the core
+// special_price_items table, variant prices to special_price_variant_items.
+func insertSpecialPriceItem(ctx context.Context, tx pgx.Tx, specialPriceID int64, item specialPriceItemPayload) error {
+	if item.VariantID != nil {
+		_, err := tx.Exec(ctx, `
+			insert into special_price_variant_items (special_price_id, menu_id, variant_id, promo_price, created_at, updated_at)
+			values ($1,$2,$3,$4,now(),now())
+		`, specialPriceID, item.MenuID, *item.VariantID, item.PromoPrice)
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		insert into special_price_items (special_price_id, menu_id, promo_price, created_at, updated_at)
+		values ($1,$2,$3,now(),now())
+	`, specialPriceID, item.MenuID, item.PromoPrice)
+	return err
+}
+
 var timeHHMMPattern = regexp.MustCompile(`^(?:[01]\d|2[0-3]):[0-5]\d$`)
 
 func (h *Handler) MerchantSpecialPricesList(w http.ResponseWriter, r *http.Request) {
@@ -203,10 +221,7 @@ func (h *Handler) MerchantSpecialPricesCreate(w http.ResponseWriter, r *http.Req
 	}
 
 	for _, item := range payload.PriceItems {
-		if _, err = tx.Exec(ctx, `
-			insert into special_price_items (special_price_id, menu_id, promo_price, created_at, updated_at)
-			values ($1,$2,$3,now(),now())
-		`, newID, item.MenuID, item.PromoPrice); err != nil {
+		if err = insertSpecialPriceItem(ctx, tx, newID, item); err != nil {
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create special price")
 			return
 		}
@@ -485,11 +500,12 @@ func (h *Handler) MerchantSpecialPricesUpdate(w http.ResponseWriter, r *http.Req
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update special price")
 			return
 		}
+		if _, err = tx.Exec(ctx, `delete from special_price_variant_items where special_price_id = $1`, priceID); err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update special price")
+			return
+		}
 		for _, item := range priceItems {
-			if _, err = tx.Exec(ctx, `
-				insert into special_price_items (special_price_id, menu_id, promo_price, created_at, updated_at)
-				values ($1,$2,$3,now(),now())
-			`, priceID, item.MenuID, item.PromoPrice); err != nil {
+			if err = insertSpecialPriceItem(ctx, tx, priceID, item); err != nil {
 				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update special price")
 				return
 			}
@@ -579,10 +595,16 @@ func (h *Handler) fetchSpecialPriceDetail(ctx context.Context, specialPriceID in
 
 	priceItems := make([]map[string]any, 0)
 	itemRows, err := h.DB.Query(ctx, `
-		select spi.id, spi.menu_id, spi.promo_price, m.name, m.price
+		select spi.id, spi.menu_id, spi.promo_price, m.name, m.price, null::bigint, null::text, null::numeric
 		from special_price_items spi
 		join menus m on m.id = spi.menu_id
 		where spi.special_price_id = $1
+		union all
+		select spv.id, spv.menu_id, spv.promo_price, m.name, m.price, spv.variant_id, mv.name, mv.price
+		from special_price_variant_items spv
+		join menus m on m.id = spv.menu_id
+		left join menu_variants mv on mv.id = spv.variant_id
+		where spv.special_price_id = $1
 	`, specialPriceID)
 	if err != nil && err != pgx.ErrNoRows {
 		return nil, err
@@ -595,25 +617,39 @@ func (h *Handler) fetchSpecialPriceDetail(ctx context.Context, specialPriceID in
 
 	for itemRows.Next() {
 		var (
-			itemID     int64
-			menuID     int64
-			promoPrice pgtype.Numeric
-			menuName   string
-			menuPrice  pgtype.Numeric
+			itemID       int64
+			menuID       int64
+			promoPrice   pgtype.Numeric
+			menuName     string
+			menuPrice    pgtype.Numeric
+			variantID    pgtype.Int8
+			variantName  pgtype.Text
+			variantPrice pgtype.Numeric
 		)
-		if err := itemRows.Scan(&itemID, &menuID, &promoPrice, &menuName, &menuPrice); err != nil {
+		if err := itemRows.Scan(&itemID, &menuID, &promoPrice, &menuName, &menuPrice, &variantID, &variantName, &variantPrice); err != nil {
 			continue
 		}
-		priceItems = append(priceItems, map[string]any{
+		item := map[string]any{
 			"id":         int64ToString(itemID),
 			"menuId":     int64ToString(menuID),
+			"variantId":  nil,
 			"promoPrice": utils.NumericToFloat64(promoPrice),
 			"menu": map[string]any{
 				"id":    int64ToString(menuID),
 				"name":  menuName,
 				"price": utils.NumericToFloat64(menuPrice),
 			},
-		})
+			"variant": nil,
+		}
+		if variantID.Valid {
+			item["variantId"] = int64ToString(variantID.Int64)
+			item["variant"] = map[string]any{
+				"id":    int64ToString(variantID.Int64),
+				"name":  variantName.String,
+				"price": utils.NumericToFloat64(variantPrice),
+			}
+		}
+		priceItems = append(priceItems, item)
 	}
 
 	return map[string]any{
@@ -747,7 +783,11 @@ func parseSpecialPriceItems(value any) []specialPriceItemPayload {
 		if value, ok := row["promoPrice"].(float64); ok {
 			promo = value
 		}
-		out = append(out, specialPriceItemPayload{MenuID: menuID, PromoPrice: promo})
+		var variantID *int64
+		if parsed, ok := parseNumericID(row["variantId"]); ok {
+			variantID = &parsed
+		}
+		out = append(out, specialPriceItemPayload{MenuID: menuID, VariantID: variantID, PromoPrice: promo})
 	}
 	return out
 }
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxUpsellReportDays = 365
+
+type upsellRulePayload struct {
+	Name               string   `json:"name"`
+	Message            *string  `json:"message"`
+	IsActive           *bool    `json:"isActive"`
+	TriggerMenuIDs     []int64  `json:"triggerMenuIds"`
+	TriggerCategoryIDs []int64  `json:"triggerCategoryIds"`
+	MinSubtotal        *float64 `json:"minSubtotal"`
+	OfferMenuIDs       []int64  `json:"offerMenuIds"`
+	DiscountType       *string  `json:"discountType"`
+	DiscountValue      *float64 `json:"discountValue"`
+	MaxQuantity        *int32   `json:"maxQuantity"`
+	Priority           *int32   `json:"priority"`
+}
+
+func normalizeUpsellIDs(ids []int64, label string) ([]int64, error) {
+	out := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, fmt.Errorf("invalid %s id %d", label, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// normalizeUpsellRule validates a rule payload. A rule needs a trigger (menu,
+// category or subtotal threshold) and at least one offered menu that is not
+// also a trigger.
+func normalizeUpsellRule(body upsellRulePayload) (upsellRule, error) {
+	rule := upsellRule{
+		Name:        strings.TrimSpace(body.Name),
+		IsActive:    true,
+		MaxQuantity: 1,
+	}
+	if rule.Name == "" {
+		return upsellRule{}, errors.New("name is required")
+	}
+	if len([]rune(rule.Name)) > 100 {
+		return upsellRule{}, errors.New("name must be at most 100 characters")
+	}
+	if body.Message != nil {
+		if message := strings.TrimSpace(*body.Message); message != "" {
+			if len([]rune(message)) > 200 {
+				return upsellRule{}, errors.New("message must be at most 200 characters")
+			}
+			rule.Message = &message
+		}
+	}
+	if body.IsActive != nil {
+		rule.IsActive = *body.IsActive
+	}
+
+	var err error
+	if rule.TriggerMenuIDs, err = normalizeUpsellIDs(body.TriggerMenuIDs, "trigger menu"); err != nil {
+		return upsellRule{}, err
+	}
+	if rule.TriggerCategoryIDs, err = normalizeUpsellIDs(body.TriggerCategoryIDs, "trigger category"); err != nil {
+		return upsellRule{}, err
+	}
+	if len(rule.TriggerMenuIDs)+len(rule.TriggerCategoryIDs) > maxUpsellTriggers {
+		return upsellRule{}, fmt.Errorf("at most %d trigger menus and categories are allowed", maxUpsellTriggers)
+	}
+	if body.MinSubtotal != nil {
+		if *body.MinSubtotal < 0 {
+			return upsellRule{}, errors.New("minSubtotal cannot be negative")
+		}
+		if *body.MinSubtotal > 0 {
+			minSubtotal := round2(*body.MinSubtotal)
+			rule.MinSubtotal = &minSubtotal
+		}
+	}
+	if len(rule.TriggerMenuIDs) == 0 && len(rule.TriggerCategoryIDs) == 0 && rule.MinSubtotal == nil {
+		return upsellRule{}, errors.New("a trigger menu, trigger category or minSubtotal is required")
+	}
+
+	if rule.OfferMenuIDs, err = normalizeUpsellIDs(body.OfferMenuIDs, "offer menu"); err != nil {
+		return upsellRule{}, err
+	}
+	if len(rule.OfferMenuIDs) == 0 {
+		return upsellRule{}, errors.New("at least one offer menu is required")
+	}
+	if len(rule.OfferMenuIDs) > maxUpsellOfferMenus {
+		return upsellRule{}, fmt.Errorf("at most %d offer menus are allowed", maxUpsellOfferMenus)
+	}
+	for _, id := range rule.OfferMenuIDs {
+		for _, trigger := range rule.TriggerMenuIDs {
+			if id == trigger {
+				return upsellRule{}, fmt.Errorf("menu %d cannot be both a trigger and an offer", id)
+			}
+		}
+	}
+
+	if body.DiscountType != nil && strings.TrimSpace(*body.DiscountType) != "" {
+		discountType := strings.ToUpper(strings.TrimSpace(*body.DiscountType))
+		if discountType != upsellDiscountAmountOff && discountType != upsellDiscountPercentOff {
+			return upsellRule{}, errors.New("discountType must be AMOUNT_OFF or PERCENT_OFF")
+		}
+		if body.DiscountValue == nil || *body.DiscountValue <= 0 {
+			return upsellRule{}, errors.New("discountValue must be greater than 0")
+		}
+		if discountType == upsellDiscountPercentOff && *body.DiscountValue > 100 {
+			return upsellRule{}, errors.New("discountValue cannot exceed 100 percent")
+		}
+		discountValue := round2(*body.DiscountValue)
+		rule.DiscountType = &discountType
+		rule.DiscountValue = &discountValue
+	}
+
+	if body.MaxQuantity != nil {
+		if *body.MaxQuantity < 1 || *body.MaxQuantity > maxUpsellQuantity {
+			return upsellRule{}, fmt.Errorf("maxQuantity must be between 1 and %d", maxUpsellQuantity)
+		}
+		rule.MaxQuantity = *body.MaxQuantity
+	}
+	if body.Priority != nil {
+		rule.Priority = *body.Priority
+	}
+	return rule, nil
+}
+
+func upsellRuleMap(rule upsellRule) map[string]any {
+	return map[string]any{
+		"id":                 int64ToString(rule.ID),
+		"name":               rule.Name,
+		"message":            rule.Message,
+		"isActive":           rule.IsActive,
+		"triggerMenuIds":     rule.TriggerMenuIDs,
+		"triggerCategoryIds": rule.TriggerCategoryIDs,
+		"minSubtotal":        rule.MinSubtotal,
+		"offerMenuIds":       rule.OfferMenuIDs,
+		"discountType":       rule.DiscountType,
+		"discountValue":      rule.DiscountValue,
+		"maxQuantity":        rule.MaxQuantity,
+		"priority":           rule.Priority,
+		"createdAt":          rule.CreatedAt,
+		"updatedAt":          rule.UpdatedAt,
+	}
+}
+
+// decodeUpsellRule reads and validates the body, and checks that every menu
+// and category belongs to the merchant.
+func (h *Handler) decodeUpsellRule(ctx context.Context, r *http.Request, merchantID int64) (upsellRule, error) {
+	var body upsellRulePayload
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return upsellRule{}, errors.New("Invalid request body")
+	}
+	rule, err := normalizeUpsellRule(body)
+	if err != nil {
+		return upsellRule{}, err
+	}
+
+	menuIDs := append(append([]int64{}, rule.TriggerMenuIDs...), rule.OfferMenuIDs...)
+	var found int
+	if err := h.DB.QueryRow(ctx, `
+		select count(*) from menus where merchant_id = $1 and id = any($2) and deleted_at is null
+	`, merchantID, menuIDs).Scan(&found); err != nil || found != len(menuIDs) {
+		return upsellRule{}, errors.New("One or more menus were not found")
+	}
+	if len(rule.TriggerCategoryIDs) > 0 {
+		if err := h.DB.QueryRow(ctx, `
+			select count(*) from menu_categories where merchant_id = $1 and id = any($2) and deleted_at is null
+		`, merchantID, rule.TriggerCategoryIDs).Scan(&found); err != nil || found != len(rule.TriggerCategoryIDs) {
+			return upsellRule{}, errors.New("One or more categories were not found")
+		}
+	}
+	return rule, nil
+}
+
+// MerchantUpsellRulesList lists the merchant's upsell rules, highest priority
+// first.
+func (h *Handler) MerchantUpsellRulesList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	rules, err := h.loadUpsellRules(ctx, *authCtx.MerchantID, nil, false)
+	if err != nil {
+		h.Logger.Error("upsell rules list failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve upsell rules")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(rules))
+	for _, rule := range rules {
+		items = append(items, upsellRuleMap(rule))
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       items,
+		"message":    "Upsell rules retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantUpsellRulesCreate adds an upsell rule.
+func (h *Handler) MerchantUpsellRulesCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	rule, err := h.decodeUpsellRule(ctx, r, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	rule, err = scanUpsellRule(h.DB.QueryRow(ctx, `
+		insert into upsell_rules (
+			merchant_id, name, message, is_active, trigger_menu_ids, trigger_category_ids, min_subtotal,
+			offer_menu_ids, discount_type, discount_value, max_quantity, priority,
+			created_by_user_id, updated_by_user_id
+		)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+		returning `+upsellRuleColumns,
+		*authCtx.MerchantID, rule.Name, rule.Message, rule.IsActive, rule.TriggerMenuIDs, rule.TriggerCategoryIDs, rule.MinSubtotal,
+		rule.OfferMenuIDs, rule.DiscountType, rule.DiscountValue, rule.MaxQuantity, rule.Priority, authCtx.UserID,
+	))
+	if err != nil {
+		h.Logger.Error("upsell rule insert failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create upsell rule")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"success":    true,
+		"data":       upsellRuleMap(rule),
+		"message":    "Upsell rule created successfully",
+		"statusCode": 201,
+	})
+}
+
+// MerchantUpsellRulesUpdate replaces an upsell rule.
+func (h *Handler) MerchantUpsellRulesUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+
+	rule, err := h.decodeUpsellRule(ctx, r, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	rule, err = scanUpsellRule(h.DB.QueryRow(ctx, `
+		update upsell_rules
+		set name = $3, message = $4, is_active = $5, trigger_menu_ids = $6, trigger_category_ids = $7,
+		    min_subtotal = $8, offer_menu_ids = $9, discount_type = $10, discount_value = $11,
+		    max_quantity = $12, priority = $13, updated_by_user_id = $14, updated_at = now()
+		where id = $1 and merchant_id = $2 and deleted_at is null
+		returning `+upsellRuleColumns,
+		id, *authCtx.MerchantID, rule.Name, rule.Message, rule.IsActive, rule.TriggerMenuIDs, rule.TriggerCategoryIDs,
+		rule.MinSubtotal, rule.OfferMenuIDs, rule.DiscountType, rule.DiscountValue, rule.MaxQuantity, rule.Priority, authCtx.UserID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Upsell rule not found")
+			return
+		}
+		h.Logger.Error("upsell rule update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update upsell rule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       upsellRuleMap(rule),
+		"message":    "Upsell rule updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantUpsellRulesDelete soft-deletes an upsell rule. Orders already placed
+// from it keep their order_item_upsells links.
+func (h *Handler) MerchantUpsellRulesDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+	id, err := readPathInt64(r, "id")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid id")
+		return
+	}
+
+	tag, err := h.DB.Exec(ctx, `
+		update upsell_rules set deleted_at = now(), is_active = false, updated_by_user_id = $3, updated_at = now()
+		where id = $1 and merchant_id = $2 and deleted_at is null
+	`, id, *authCtx.MerchantID, authCtx.UserID)
+	if err != nil {
+		h.Logger.Error("upsell rule delete failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete upsell rule")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Upsell rule not found")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"message":    "Upsell rule deleted successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantUpsellRulesReport reports, per rule over the last ?days= (default
+// 30), how often its offers were shown, how many orders accepted them and the
+// revenue the accepted items added. Cancelled orders are excluded.
+func (h *Handler) MerchantUpsellRulesReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	days := parseIntQuery(r, "days", 30)
+	if days < 1 || days > maxUpsellReportDays {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("days must be between 1 and %d", maxUpsellReportDays))
+		return
+	}
+
+	var timezone string
+	if err := h.DB.QueryRow(ctx, `select timezone from merchants where id = $1`, *authCtx.MerchantID).Scan(&timezone); err != nil {
+		h.Logger.Error("upsell report merchant lookup failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch upsell report")
+		return
+	}
+	today := time.Now().In(loadTimezone(timezoneOrDefault(timezone))).Format("2006-01-02")
+
+	rows, err := h.DB.Query(ctx, `
+		select r.id, r.name, r.is_active,
+		       coalesce(i.impressions, 0)::bigint,
+		       coalesce(a.orders, 0)::bigint,
+		       coalesce(a.quantity, 0)::bigint,
+		       coalesce(a.revenue, 0)
+		from upsell_rules r
+		left join (
+			select rule_id, sum(count) as impressions
+			from upsell_rule_impressions
+			where merchant_id = $1 and day > $3::date - $2::int
+			group by rule_id
+		) i on i.rule_id = r.id
+		left join (
+			select ou.upsell_rule_id, count(distinct oi.order_id) as orders,
+			       sum(oi.quantity) as quantity, sum(oi.subtotal) as revenue
+			from order_item_upsells ou
+			join order_items oi on oi.id = ou.order_item_id
+			join orders o on o.id = oi.order_id
+			where o.merchant_id = $1
+			  and o.status <> 'CANCELLED'
+			  and o.placed_at >= now() - make_interval(days => $2::int)
+			group by ou.upsell_rule_id
+		) a on a.upsell_rule_id = r.id
+		where r.merchant_id = $1 and (r.deleted_at is null or a.orders is not null)
+		order by coalesce(a.revenue, 0) desc, r.priority desc, r.id
+	`, *authCtx.MerchantID, days, today)
+	if err != nil {
+		h.Logger.Error("upsell report query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch upsell report")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]map[string]any, 0)
+	var (
+		totalImpressions int64
+		totalAccepted    int64
+		totalRevenue     float64
+	)
+	for rows.Next() {
+		var (
+			id          int64
+			name        string
+			isActive    bool
+			impressions int64
+			accepted    int64
+			quantity    int64
+			revenue     pgtype.Numeric
+		)
+		if err := rows.Scan(&id, &name, &isActive, &impressions, &accepted, &quantity, &revenue); err != nil {
+			h.Logger.Error("upsell report scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch upsell report")
+			return
+		}
+		revenueValue := round2(utils.NumericToFloat64(revenue))
+		totalImpressions += impressions
+		totalAccepted += accepted
+		totalRevenue = round2(totalRevenue + revenueValue)
+		items = append(items, map[string]any{
+			"ruleId":         int64ToString(id),
+			"name":           name,
+			"isActive":       isActive,
+			"impressions":    impressions,
+			"acceptances":    accepted,
+			"quantity":       quantity,
+			"revenue":        revenueValue,
+			"acceptanceRate": upsellAcceptanceRate(accepted, impressions),
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"days":             days,
+			"totalImpressions": totalImpressions,
+			"totalAcceptances": totalAccepted,
+			"totalRevenue":     totalRevenue,
+			"rules":            items,
+		},
+		"message":    "Upsell report retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// upsellAcceptanceRate is accepted orders per impression, as a percentage.
+func upsellAcceptanceRate(accepted, impressions int64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return round2(float64(accepted) / float64(impressions) * 100)
+}
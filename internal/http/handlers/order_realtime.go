@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// notifyOrderRealtime signals both the merchant orders WebSocket (keyed by
+// merchant) and the public order WebSocket (keyed by order number) so that
+// subscribers receive a fresh snapshot of the order.
+func (h *Handler) notifyOrderRealtime(ctx context.Context, merchantID int64, orderNumber string) {
+	if _, err := h.DB.Exec(ctx, `select pg_notify('orders_updates', $1), pg_notify('public_order_updates', $2)`, fmt.Sprint(merchantID), orderNumber); err != nil {
+		h.Logger.Warn("order realtime notify failed", zapError(err))
+	}
+}
+
+// notifyMerchantOrderEvent pushes a typed event (e.g. customer.arrived) to the
+// merchant orders WebSocket, next to the usual order snapshots.
+func (h *Handler) notifyMerchantOrderEvent(ctx context.Context, merchantID int64, eventType string, data any) {
+	payload, err := json.Marshal(map[string]any{
+		"merchantId": fmt.Sprint(merchantID),
+		"type":       eventType,
+		"data":       data,
+	})
+	if err != nil {
+		h.Logger.Warn("order event encode failed", zapError(err))
+		return
+	}
+	if _, err := h.DB.Exec(ctx, `select pg_notify('merchant_order_events', $1)`, string(payload)); err != nil {
+		h.Logger.Warn("order event notify failed", zapError(err))
+	}
+}
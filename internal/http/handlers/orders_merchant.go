@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -35,7 +36,7 @@ func (h *Handler) MerchantActiveOrders(w http.ResponseWriter, r *http.Request) {
 		select
 		  o.id, o.merchant_id, o.customer_id, o.order_number, o.order_type, o.table_number,
 		  o.status, o.is_scheduled, o.scheduled_date, o.scheduled_time,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
+		  ` + EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
 		  o.delivery_distance_km, o.delivery_delivered_at,
 		  o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
 		  o.notes, o.admin_note, o.kitchen_notes, o.placed_at, o.updated_at, o.edited_at, o.edited_by_user_id,
@@ -45,6 +46,7 @@ func (h *Handler) MerchantActiveOrders(w http.ResponseWriter, r *http.Request) {
 		  d.id as driver_id, d.name as driver_name, d.email as driver_email, d.phone as driver_phone,
 		  count(oi.id) as order_items_count
 		from orders o
+		` + DeliveryProgressJoinSQL + `
 		left join payments p on p.order_id = o.id
 		left join reservations r on r.order_id = o.id
 		left join customers c on c.id = o.customer_id
@@ -52,7 +54,7 @@ func (h *Handler) MerchantActiveOrders(w http.ResponseWriter, r *http.Request) {
 		left join order_items oi on oi.order_id = o.id
 		where o.merchant_id = $1
 		  and o.status in ('PENDING', 'ACCEPTED', 'IN_PROGRESS', 'READY')
-		group by o.id, p.id, r.id, c.id, d.id
+		group by o.id, p.id, r.id, c.id, d.id, dp.order_id
 		order by o.placed_at asc
 	`
 
@@ -212,6 +214,11 @@ func (h *Handler) MerchantActiveOrders(w http.ResponseWriter, r *http.Request) {
 
 		orders = append(orders, order)
 	}
+	rows.Close()
+
+	if err := AttachCurbsidePickups(ctx, h.DB, orders); err != nil {
+		h.Logger.Warn("active orders curbside lookup failed", zapError(err))
+	}
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
@@ -321,37 +328,34 @@ func (h *Handler) MerchantUpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if shouldMarkPaid {
-		var paymentID pgtype.Int8
-		if err := tx.QueryRow(ctx, `select id from payments where order_id = $1`, orderID).Scan(&paymentID); err != nil && err != pgx.ErrNoRows {
+	if payload.Status == "ACCEPTED" {
+		if err := h.syncOrderIngredientsTx(ctx, tx, orderID, &authCtx.UserID); err != nil {
+			h.Logger.Error("order ingredient depletion failed", zapError(err))
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
 			return
 		}
-		if paymentID.Valid {
-			_, err = tx.Exec(ctx, `
-				update payments
-				set status = 'COMPLETED',
-					paid_at = coalesce(paid_at, $1),
-					paid_by_user_id = coalesce(paid_by_user_id, $2)
-				where order_id = $3
-			`, now, authCtx.UserID, orderID)
-			if err != nil {
-				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
-				return
-			}
-		} else {
-			defaultMethod := "CASH_ON_COUNTER"
-			if strings.EqualFold(orderType, "DELIVERY") {
-				defaultMethod = "CASH_ON_DELIVERY"
-			}
-			_, err = tx.Exec(ctx, `
-				insert into payments (order_id, amount, payment_method, status, paid_at, paid_by_user_id)
-				select id, total_amount, $2, 'COMPLETED', $3, $4 from orders where id = $1
-			`, orderID, defaultMethod, now, authCtx.UserID)
-			if err != nil {
-				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
-				return
-			}
+	}
+
+	if payload.Status == "CANCELLED" {
+		if err := restoreOrderIngredientsTx(ctx, tx, orderID, &authCtx.UserID); err != nil {
+			h.Logger.Error("order ingredient restore failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
+			return
+		}
+	}
+
+	if payload.Status == "COMPLETED" {
+		if err := recordCurbsideHandoverTx(ctx, tx, orderID, authCtx.UserID, now); err != nil {
+			h.Logger.Error("curbside handover record failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
+			return
+		}
+	}
+
+	if shouldMarkPaid {
+		if err := markOrderPaidTx(ctx, tx, orderID, orderType, authCtx.UserID, now); err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
+			return
 		}
 	}
 
@@ -359,6 +363,9 @@ func (h *Handler) MerchantUpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
 		return
 	}
+	if shouldDeductStock {
+		h.checkOrderStockAlerts(ctx, orderID)
+	}
 
 	if h.Queue != nil {
 		event := map[string]any{
@@ -373,6 +380,15 @@ func (h *Handler) MerchantUpdateOrderStatus(w http.ResponseWriter, r *http.Reque
 		_ = h.Queue.PublishJSON(ctx, "genfity.events", "order.status.updated", event)
 	}
 
+	if payload.Status == "READY" && strings.EqualFold(orderType, "DELIVERY") {
+		h.autoDispatchOrder(ctx, *authCtx.MerchantID, orderID)
+	}
+	if payload.Status == "CANCELLED" && strings.EqualFold(orderType, "DELIVERY") {
+		if err := h.cancelCourierDelivery(ctx, *authCtx.MerchantID, orderID); err != nil {
+			h.Logger.Warn("courier cancel failed", zapError(err))
+		}
+	}
+
 	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
@@ -416,6 +432,35 @@ func (h *Handler) MerchantResolveOrder(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// markOrderPaidTx completes the order's payment, creating a cash payment
+// when the order has none yet.
+func markOrderPaidTx(ctx context.Context, tx pgx.Tx, orderID int64, orderType string, userID int64, now time.Time) error {
+	var paymentID pgtype.Int8
+	if err := tx.QueryRow(ctx, `select id from payments where order_id = $1`, orderID).Scan(&paymentID); err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+	if paymentID.Valid {
+		_, err := tx.Exec(ctx, `
+			update payments
+			set status = 'COMPLETED',
+				paid_at = coalesce(paid_at, $1),
+				paid_by_user_id = coalesce(paid_by_user_id, $2)
+			where order_id = $3
+		`, now, userID, orderID)
+		return err
+	}
+
+	defaultMethod := "CASH_ON_COUNTER"
+	if strings.EqualFold(orderType, "DELIVERY") {
+		defaultMethod = "CASH_ON_DELIVERY"
+	}
+	_, err := tx.Exec(ctx, `
+		insert into payments (order_id, amount, payment_method, status, paid_at, paid_by_user_id)
+		select id, total_amount, $2, 'COMPLETED', $3, $4 from orders where id = $1
+	`, orderID, defaultMethod, now, userID)
+	return err
+}
+
 func isValidTransition(current, next string) bool {
 	if current == next {
 		return false
@@ -29,19 +29,25 @@ func (h *Handler) MerchantOrderDeliveryAssign(w http.ResponseWriter, r *http.Req
 	}
 
 	var body struct {
-		DriverUserID any `json:"driverUserId"`
+		DriverUserID    any    `json:"driverUserId"`
+		CourierProvider string `json:"courierProvider"`
 	}
 	_ = json.NewDecoder(r.Body).Decode(&body)
 
 	var (
 		orderType      string
 		deliveryStatus pgtype.Text
+		batchID        pgtype.Int8
 	)
 	if err := h.DB.QueryRow(ctx, `
-		select order_type, delivery_status
-		from orders
-		where id = $1 and merchant_id = $2
-	`, orderID, *authCtx.MerchantID).Scan(&orderType, &deliveryStatus); err != nil {
+		select o.order_type, o.delivery_status,
+		       (select b.id
+		        from delivery_batch_stops s
+		        join delivery_batches b on b.id = s.batch_id
+		        where s.order_id = o.id and b.status in ('PLANNED', 'ASSIGNED'))
+		from orders o
+		where o.id = $1 and o.merchant_id = $2
+	`, orderID, *authCtx.MerchantID).Scan(&orderType, &deliveryStatus, &batchID); err != nil {
 		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Order not found")
 		return
 	}
@@ -56,9 +62,53 @@ func (h *Handler) MerchantOrderDeliveryAssign(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// The batch owns the driver of its stops; assigning one order on its own
+	// would split it from the planned route.
+	if batchID.Valid {
+		response.Error(w, http.StatusConflict, "ORDER_IN_DELIVERY_BATCH", fmt.Sprintf("Order is part of delivery batch %d; reassign or cancel the batch instead", batchID.Int64))
+		return
+	}
+
 	driverUserIDRaw := strings.TrimSpace(toString(body.DriverUserID))
+	courierProvider := strings.TrimSpace(body.CourierProvider)
+	if courierProvider != "" {
+		if driverUserIDRaw != "" {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Provide either driverUserId or courierProvider, not both")
+			return
+		}
+		o, err := h.loadCourierOrder(ctx, *authCtx.MerchantID, orderID)
+		if err != nil {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Order not found")
+			return
+		}
+		if err := h.bookCourier(ctx, o, courierProvider, &authCtx.UserID); err != nil {
+			h.Logger.Warn("courier booking failed", zapError(err))
+			writeCourierBookingError(w, err)
+			return
+		}
+
+		data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
+		if err != nil {
+			response.Error(w, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+			return
+		}
+
+		response.JSON(w, http.StatusOK, map[string]any{
+			"success": true,
+			"data":    data,
+			"message": "Courier booked successfully",
+		})
+		return
+	}
+
 	if driverUserIDRaw == "" {
+		if err := h.cancelCourierDelivery(ctx, *authCtx.MerchantID, orderID); err != nil {
+			h.Logger.Warn("courier cancel failed", zapError(err))
+			response.Error(w, http.StatusBadGateway, "COURIER_ERROR", "Failed to cancel the courier booking")
+			return
+		}
 		_, err = h.DB.Exec(ctx, `
+			with reset as (delete from order_delivery_progress where order_id = $1)
 			update orders
 			set delivery_driver_user_id = null,
 				delivery_assigned_at = null,
@@ -69,6 +119,7 @@ func (h *Handler) MerchantOrderDeliveryAssign(w http.ResponseWriter, r *http.Req
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update driver assignment")
 			return
 		}
+		h.cancelOpenDispatchOffers(ctx, orderID, "Driver unassigned by merchant")
 
 		data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
 		if err != nil {
@@ -110,7 +161,15 @@ func (h *Handler) MerchantOrderDeliveryAssign(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Handing the order to a driver withdraws any courier booking first.
+	if err := h.cancelCourierDelivery(ctx, *authCtx.MerchantID, orderID); err != nil {
+		h.Logger.Warn("courier cancel failed", zapError(err))
+		response.Error(w, http.StatusBadGateway, "COURIER_ERROR", "Failed to cancel the courier booking")
+		return
+	}
+
 	_, err = h.DB.Exec(ctx, `
+		with reset as (delete from order_delivery_progress where order_id = $3)
 		update orders
 		set delivery_driver_user_id = $1,
 			delivery_assigned_at = $2,
@@ -121,6 +180,7 @@ func (h *Handler) MerchantOrderDeliveryAssign(w http.ResponseWriter, r *http.Req
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update driver assignment")
 		return
 	}
+	h.cancelOpenDispatchOffers(ctx, orderID, "Driver assigned by merchant")
 
 	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
 	if err != nil {
@@ -154,6 +154,10 @@ func (h *Handler) MerchantOrderDelete(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete order")
 		return
 	}
+	if _, err := tx.Exec(ctx, `delete from order_item_components where order_item_id in (select id from order_items where order_id = $1)`, orderID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete order")
+		return
+	}
 	if _, err := tx.Exec(ctx, `delete from order_items where order_id = $1`, orderID); err != nil {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete order")
 		return
@@ -321,7 +325,7 @@ func (h *Handler) fetchMerchantOrderDetail(ctx context.Context, merchantID, orde
 		select
 		  o.id, o.merchant_id, o.customer_id, o.order_number, o.order_type, o.table_number,
 		  o.status, o.is_scheduled, o.scheduled_date, o.scheduled_time, o.stock_deducted_at,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
+		  ` + EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
 		  o.delivery_distance_km, o.delivery_delivered_at,
 		  o.delivery_building_name, o.delivery_building_number, o.delivery_floor, o.delivery_instructions,
 		  o.delivery_street_line, o.delivery_suburb, o.delivery_city, o.delivery_state, o.delivery_postcode, o.delivery_country,
@@ -337,6 +341,7 @@ func (h *Handler) fetchMerchantOrderDetail(ctx context.Context, merchantID, orde
 		  c.id, c.name, c.email, c.phone,
 		  d.id, d.name, d.email, d.phone
 		from orders o
+		` + DeliveryProgressJoinSQL + `
 		left join payments p on p.order_id = o.id
 		left join users pu on pu.id = p.paid_by_user_id
 		left join reservations r on r.order_id = o.id
@@ -731,6 +736,18 @@ func (h *Handler) fetchMerchantOrderDetail(ctx context.Context, merchantID, orde
 		data["deliveryDriver"] = nil
 	}
 
+	courierDelivery, err := h.fetchCourierDelivery(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	data["courierDelivery"] = courierDelivery
+
+	curbside, err := FetchCurbsidePickup(ctx, h.DB, orderID)
+	if err != nil {
+		return nil, err
+	}
+	data["curbside"] = curbside
+
 	items, err := h.fetchOrderItemsWithAddons(ctx, orderID)
 	if err != nil {
 		return nil, err
@@ -747,9 +764,11 @@ func (h *Handler) fetchMerchantOrderDetail(ctx context.Context, merchantID, orde
 
 func (h *Handler) fetchOrderItemsWithAddons(ctx context.Context, orderID int64) ([]map[string]any, error) {
 	rows, err := h.DB.Query(ctx, `
-		select oi.id, oi.menu_id, oi.menu_name, oi.menu_price, oi.quantity, oi.subtotal, oi.notes, m.id, m.name, m.image_url
+		select oi.id, oi.menu_id, oi.menu_name, oi.menu_price, oi.quantity, oi.subtotal, oi.notes, m.id, m.name, m.image_url,
+		       oiv.variant_id, oiv.variant_name
 		from order_items oi
 		left join menus m on m.id = oi.menu_id
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
 		where oi.order_id = $1
 		order by oi.id asc
 	`, orderID)
@@ -772,8 +791,10 @@ func (h *Handler) fetchOrderItemsWithAddons(ctx context.Context, orderID int64)
 			menuIDRef   pgtype.Int8
 			menuNameRef pgtype.Text
 			menuImage   pgtype.Text
+			variantID   pgtype.Int8
+			variantName pgtype.Text
 		)
-		if err := rows.Scan(&itemID, &menuID, &menuName, &menuPrice, &quantity, &subtotal, &notes, &menuIDRef, &menuNameRef, &menuImage); err != nil {
+		if err := rows.Scan(&itemID, &menuID, &menuName, &menuPrice, &quantity, &subtotal, &notes, &menuIDRef, &menuNameRef, &menuImage, &variantID, &variantName); err != nil {
 			return nil, err
 		}
 		item := map[string]any{
@@ -784,10 +805,12 @@ func (h *Handler) fetchOrderItemsWithAddons(ctx context.Context, orderID int64)
 				}
 				return nil
 			}(),
-			"menuName":  menuName,
-			"menuPrice": utils.NumericToFloat64(menuPrice),
-			"quantity":  quantity,
-			"subtotal":  utils.NumericToFloat64(subtotal),
+			"menuName":    menuName,
+			"variantId":   int8Ptr(variantID),
+			"variantName": textPtr(variantName),
+			"menuPrice":   utils.NumericToFloat64(menuPrice),
+			"quantity":    quantity,
+			"subtotal":    utils.NumericToFloat64(subtotal),
 			"notes": func() any {
 				if notes.Valid {
 					return notes.String
@@ -861,9 +884,15 @@ func (h *Handler) fetchOrderItemsWithAddons(ctx context.Context, orderID int64)
 		}
 	}
 
+	componentMap, err := h.fetchOrderItemComponents(ctx, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, item := range items {
 		id := item["id"].(int64)
 		item["addons"] = addonsMap[id]
+		item["components"] = componentPayloads(componentMap[id])
 	}
 
 	return items, nil
@@ -946,6 +975,7 @@ func (h *Handler) recordOrderPayment(ctx context.Context, merchantID, orderID in
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
+	h.checkOrderStockAlerts(ctx, orderID)
 
 	data, err := h.fetchMerchantOrderDetail(ctx, merchantID, orderID)
 	if err != nil {
@@ -15,13 +15,16 @@ import (
 )
 
 type kitchenOrderItem struct {
-	ID        int64               `json:"id"`
-	MenuName  string              `json:"menuName"`
-	MenuPrice float64             `json:"menuPrice"`
-	Quantity  int32               `json:"quantity"`
-	Subtotal  float64             `json:"subtotal"`
-	Notes     *string             `json:"notes"`
-	Addons    []kitchenOrderAddon `json:"addons"`
+	ID               int64                `json:"id"`
+	MenuName         string               `json:"menuName"`
+	VariantName      *string              `json:"variantName"`
+	MenuPrice        float64              `json:"menuPrice"`
+	Quantity         int32                `json:"quantity"`
+	Subtotal         float64              `json:"subtotal"`
+	Notes            *string              `json:"notes"`
+	Addons           []kitchenOrderAddon  `json:"addons"`
+	Components       []orderItemComponent `json:"components"`
+	AllergenWarnings []string             `json:"allergenWarnings"`
 }
 
 type kitchenOrderAddon struct {
@@ -69,6 +72,12 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		limit = 200
 	}
 
+	cursorMode, cursor, err := readKeysetCursor(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid cursor")
+		return
+	}
+
 	whereClauses := []string{"o.merchant_id = $1"}
 	args := []any{*authCtx.MerchantID}
 
@@ -127,30 +136,40 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		args = append(args, sinceTime)
 	}
 
-	whereSQL := strings.Join(whereClauses, " and ")
-
-	countQuery := `
-		select count(distinct o.id)
-		from orders o
-		left join payments p on p.order_id = o.id
-		where ` + whereSQL
 	var total int64
-	if err := h.DB.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
-		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch orders")
-		return
-	}
-
 	limitOffset := ""
-	if limit > 0 {
+	if cursorMode {
+		// Cursor mode skips the count: it is the expensive part on large
+		// merchants and clients paging by cursor only need hasMore.
+		if cursor != nil {
+			var condition string
+			condition, args = keysetCondition("o.placed_at", "o.id", cursor, args)
+			whereClauses = append(whereClauses, condition)
+		}
+		limitOffset = " limit $" + strconv.Itoa(len(args)+1)
+		args = append(args, limit+1)
+	} else {
+		countQuery := `
+			select count(distinct o.id)
+			from orders o
+			left join payments p on p.order_id = o.id
+			where ` + strings.Join(whereClauses, " and ")
+		if err := h.DB.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch orders")
+			return
+		}
+
 		limitOffset = " limit $" + strconv.Itoa(len(args)+1) + " offset $" + strconv.Itoa(len(args)+2)
 		args = append(args, limit, (page-1)*limit)
 	}
 
+	whereSQL := strings.Join(whereClauses, " and ")
+
 	listQuery := `
 		select
 		  o.id, o.merchant_id, o.customer_id, o.order_number, o.order_type, o.table_number,
 		  o.status, o.is_scheduled, o.scheduled_date, o.scheduled_time,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
+		  ` + EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
 		  o.delivery_distance_km, o.delivery_delivered_at,
 		  o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
 		  o.notes, o.admin_note, o.kitchen_notes, o.placed_at, o.updated_at, o.edited_at, o.edited_by_user_id,
@@ -160,6 +179,7 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		  d.id, d.name, d.email, d.phone,
 		  coalesce(oi_count.order_items_count, 0)
 		from orders o
+		` + DeliveryProgressJoinSQL + `
 		left join payments p on p.order_id = o.id
 		left join reservations r on r.order_id = o.id
 		left join customers c on c.id = o.customer_id
@@ -170,7 +190,7 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 			group by order_id
 		) oi_count on oi_count.order_id = o.id
 		where ` + whereSQL + `
-		order by o.placed_at desc
+		order by o.placed_at desc, o.id desc
 	` + limitOffset
 
 	rows, err := h.DB.Query(ctx, listQuery, args...)
@@ -383,12 +403,20 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		orderIDs = append(orderIDs, order.ID)
 	}
 
+	hasMore := false
+	if cursorMode && len(items) > limit {
+		hasMore = true
+		items = items[:limit]
+		orderIDs = orderIDs[:limit]
+	}
+
 	if includeItems && len(orderIDs) > 0 {
 		itemRows, err := h.DB.Query(ctx, `
-			select id, order_id, menu_name, menu_price, quantity, subtotal, notes
-			from order_items
-			where order_id = any($1)
-			order by id asc
+			select oi.id, oi.order_id, oi.menu_name, oiv.variant_name, oi.menu_price, oi.quantity, oi.subtotal, oi.notes
+			from order_items oi
+			left join order_item_variants oiv on oiv.order_item_id = oi.id
+			where oi.order_id = any($1)
+			order by oi.id asc
 		`, orderIDs)
 		if err != nil {
 			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch orders")
@@ -400,24 +428,26 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		itemIDs := make([]int64, 0)
 		for itemRows.Next() {
 			var (
-				itemID    int64
-				orderID   int64
-				menuName  string
-				menuPrice pgtype.Numeric
-				quantity  int32
-				subtotal  pgtype.Numeric
-				notes     pgtype.Text
+				itemID      int64
+				orderID     int64
+				menuName    string
+				variantName pgtype.Text
+				menuPrice   pgtype.Numeric
+				quantity    int32
+				subtotal    pgtype.Numeric
+				notes       pgtype.Text
 			)
-			if err := itemRows.Scan(&itemID, &orderID, &menuName, &menuPrice, &quantity, &subtotal, &notes); err != nil {
+			if err := itemRows.Scan(&itemID, &orderID, &menuName, &variantName, &menuPrice, &quantity, &subtotal, &notes); err != nil {
 				response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch orders")
 				return
 			}
 			item := kitchenOrderItem{
-				ID:        itemID,
-				MenuName:  menuName,
-				MenuPrice: utils.NumericToFloat64(menuPrice),
-				Quantity:  quantity,
-				Subtotal:  utils.NumericToFloat64(subtotal),
+				ID:          itemID,
+				MenuName:    menuName,
+				VariantName: textPtr(variantName),
+				MenuPrice:   utils.NumericToFloat64(menuPrice),
+				Quantity:    quantity,
+				Subtotal:    utils.NumericToFloat64(subtotal),
 			}
 			if notes.Valid {
 				item.Notes = &notes.String
@@ -459,12 +489,28 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		componentMap, err := h.fetchOrderItemComponents(ctx, itemIDs)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch orders")
+			return
+		}
+		allergenMap, err := h.fetchOrderItemAllergens(ctx, itemIDs)
+		if err != nil {
+			h.Logger.Warn("kitchen allergen lookup failed", zapError(err))
+			allergenMap = map[int64][]string{}
+		}
+
 		for idx := range items {
 			orderID := items[idx].ID
 			orderItems := itemMap[orderID]
 			if len(orderItems) > 0 {
 				for i := range orderItems {
 					orderItems[i].Addons = addonMap[orderItems[i].ID]
+					orderItems[i].Components = componentPayloads(componentMap[orderItems[i].ID])
+					orderItems[i].AllergenWarnings = allergenMap[orderItems[i].ID]
+					if orderItems[i].AllergenWarnings == nil {
+						orderItems[i].AllergenWarnings = []string{}
+					}
 				}
 				items[idx].OrderItems = orderItems
 			}
@@ -476,6 +522,22 @@ func (h *Handler) MerchantOrdersList(w http.ResponseWriter, r *http.Request) {
 		payload = append(payload, item)
 	}
 
+	if cursorMode {
+		var nextCursor *string
+		if hasMore && len(items) > 0 {
+			last := items[len(items)-1]
+			encoded := encodeKeysetCursor(last.PlacedAt, last.ID)
+			nextCursor = &encoded
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"success":    true,
+			"data":       payload,
+			"nextCursor": nextCursor,
+			"hasMore":    hasMore,
+		})
+		return
+	}
+
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"data":    payload,
@@ -34,15 +34,37 @@ func (h *Handler) MerchantOrderCancel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := h.DB.Exec(ctx, `
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel order")
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
 		update orders
 		set status = 'CANCELLED', cancelled_at = $1, cancel_reason = $2
 		where id = $3 and merchant_id = $4
-	`, time.Now(), body.Reason, orderID, *authCtx.MerchantID); err != nil {
+	`, time.Now(), body.Reason, orderID, *authCtx.MerchantID)
+	if err != nil {
 		h.Logger.Error("order cancel failed", zapError(err))
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel order")
 		return
 	}
+	if tag.RowsAffected() > 0 {
+		if err := restoreOrderIngredientsTx(ctx, tx, orderID, &authCtx.UserID); err != nil {
+			h.Logger.Error("order ingredient restore failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel order")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel order")
+		return
+	}
+	if err := h.cancelCourierDelivery(ctx, *authCtx.MerchantID, orderID); err != nil {
+		h.Logger.Warn("courier cancel failed", zapError(err))
+	}
 
 	data, err := h.fetchMerchantOrderDetail(ctx, *authCtx.MerchantID, orderID)
 	if err != nil {
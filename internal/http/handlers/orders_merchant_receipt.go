@@ -25,12 +25,18 @@ type receiptAddon struct {
 }
 
 type receiptItem struct {
+	Name       string
+	Quantity   int32
+	Unit       string
+	Subtotal   string
+	Notes      string
+	Addons     []receiptAddon
+	Components []receiptComponent
+}
+
+type receiptComponent struct {
 	Name     string
 	Quantity int32
-	Unit     string
-	Subtotal string
-	Notes    string
-	Addons   []receiptAddon
 }
 
 type receiptTemplateData struct {
@@ -61,6 +67,7 @@ type receiptTemplateData struct {
 	PaymentMethod   string
 	PaymentStatus   string
 	CashierName     string
+	Footer          string
 }
 
 const receiptHTMLTemplate = `<!DOCTYPE html>
@@ -105,6 +112,7 @@ const receiptHTMLTemplate = `<!DOCTYPE html>
       </div>
       {{if .Unit}}<div class="addon">Unit: {{.Unit}}</div>{{end}}
       {{if .Notes}}<div class="notes">{{.Notes}}</div>{{end}}
+      {{range .Components}}<div class="addon">- {{.Quantity}} x {{.Name}}</div>{{end}}
       {{range .Addons}}
         <div class="row addon">
           <div>{{.Quantity}} x {{.Name}}</div>
@@ -127,6 +135,7 @@ const receiptHTMLTemplate = `<!DOCTYPE html>
     {{if .PaymentStatus}}<div class="row"><div>Status</div><div>{{.PaymentStatus}}</div></div>{{end}}
     {{if .CashierName}}<div class="row"><div>Cashier</div><div>{{.CashierName}}</div></div>{{end}}
   </div>
+  {{if .Footer}}<div class="section notes">{{.Footer}}</div>{{end}}
 </body>
 </html>`
 
@@ -155,6 +164,10 @@ func (h *Handler) MerchantOrderReceiptHTML(w http.ResponseWriter, r *http.Reques
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load merchant")
 		return
 	}
+	if merchantInfo.Footer != "" {
+		translator := h.loadContentTranslator(ctx, w, r, *authCtx.MerchantID)
+		merchantInfo.Footer = translator.text(translationEntityMerchant, *authCtx.MerchantID, translationFieldReceiptFooter, merchantInfo.Footer)
+	}
 
 	templateData := buildReceiptTemplateData(data, merchantInfo)
 	if templateData.OrderNumber == "" {
@@ -205,6 +218,10 @@ func (h *Handler) MerchantOrderReceiptPDF(w http.ResponseWriter, r *http.Request
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load merchant")
 		return
 	}
+	if merchantInfo.Footer != "" {
+		translator := h.loadContentTranslator(ctx, w, r, *authCtx.MerchantID)
+		merchantInfo.Footer = translator.text(translationEntityMerchant, *authCtx.MerchantID, translationFieldReceiptFooter, merchantInfo.Footer)
+	}
 
 	templateData := buildReceiptTemplateData(data, merchantInfo)
 	if templateData.OrderNumber == "" {
@@ -233,6 +250,9 @@ type merchantReceiptInfo struct {
 	Phone    string
 	Email    string
 	Currency string
+	// Footer is the custom footer text, set only when the receipt settings
+	// enable it.
+	Footer string
 }
 
 func (h *Handler) fetchMerchantReceiptInfo(ctx context.Context, merchantID int64) (merchantReceiptInfo, error) {
@@ -244,13 +264,18 @@ func (h *Handler) fetchMerchantReceiptInfo(ctx context.Context, merchantID int64
 		currency pgtype.Text
 	)
 	query := `
-		select code, name, address, phone, email, currency
+		select code, name, address, phone, email, currency, receipt_settings
 		from merchants
 		where id = $1
 	`
-	if err := h.DB.QueryRow(ctx, query, merchantID).Scan(&info.Code, &info.Name, &address, &phone, &email, &currency); err != nil {
+	var settings []byte
+	if err := h.DB.QueryRow(ctx, query, merchantID).Scan(&info.Code, &info.Name, &address, &phone, &email, &currency, &settings); err != nil {
 		return info, err
 	}
+	receiptSettings := parseJSONMap(settings)
+	if show, _ := receiptSettings["showCustomFooterText"].(bool); show {
+		info.Footer, _ = receiptSettings["customFooterText"].(string)
+	}
 	info.Address = defaultStringPtr(textPtr(address))
 	info.Phone = defaultStringPtr(textPtr(phone))
 	info.Email = defaultStringPtr(textPtr(email))
@@ -307,6 +332,7 @@ func buildReceiptTemplateData(orderData map[string]any, merchant merchantReceipt
 		PaymentMethod:   paymentMethod,
 		PaymentStatus:   paymentStatus,
 		CashierName:     cashier,
+		Footer:          merchant.Footer,
 	}
 }
 
@@ -336,15 +362,34 @@ func buildReceiptItem(item map[string]any, currency string) receiptItem {
 	qty := int32FromAny(item["quantity"])
 	addons := buildReceiptAddons(item["addons"], currency)
 	return receiptItem{
-		Name:     toString(item["menuName"]),
-		Quantity: qty,
-		Unit:     formatOptionalCurrency(item["menuPrice"], currency),
-		Subtotal: formatCurrency(amountFromAny(item["subtotal"]), currency),
-		Notes:    toString(item["notes"]),
-		Addons:   addons,
+		Name:       toString(item["menuName"]),
+		Quantity:   qty,
+		Unit:       formatOptionalCurrency(item["menuPrice"], currency),
+		Subtotal:   formatCurrency(amountFromAny(item["subtotal"]), currency),
+		Notes:      toString(item["notes"]),
+		Addons:     addons,
+		Components: buildReceiptComponents(item["components"], qty),
 	}
 }
 
+// buildReceiptComponents lists bundle components with quantities for the
+// whole line, since components are stored per bundle unit.
+func buildReceiptComponents(raw any, quantity int32) []receiptComponent {
+	list, ok := raw.([]orderItemComponent)
+	if !ok {
+		return nil
+	}
+	out := make([]receiptComponent, 0, len(list))
+	for _, component := range list {
+		name := component.MenuName
+		if component.VariantName != nil && *component.VariantName != "" {
+			name = fmt.Sprintf("%s (%s)", name, *component.VariantName)
+		}
+		out = append(out, receiptComponent{Name: name, Quantity: component.Quantity * quantity})
+	}
+	return out
+}
+
 func buildReceiptAddons(raw any, currency string) []receiptAddon {
 	addons := make([]receiptAddon, 0)
 	if raw == nil {
@@ -543,6 +588,9 @@ func renderReceiptPDF(data receiptTemplateData) (*bytes.Buffer, error) {
 		if item.Notes != "" {
 			pdf.MultiCell(0, 4, fmt.Sprintf("Notes: %s", item.Notes), "", "L", false)
 		}
+		for _, component := range item.Components {
+			pdf.CellFormat(0, 4, fmt.Sprintf("  - %dx %s", component.Quantity, component.Name), "", 1, "L", false, 0, "")
+		}
 		for _, addon := range item.Addons {
 			pdf.CellFormat(0, 4, fmt.Sprintf("  %dx %s (%s)", addon.Quantity, addon.Name, addon.Subtotal), "", 1, "L", false, 0, "")
 		}
@@ -587,6 +635,10 @@ func renderReceiptPDF(data receiptTemplateData) (*bytes.Buffer, error) {
 	if data.CashierName != "" {
 		pdf.CellFormat(0, 5, fmt.Sprintf("Cashier: %s", data.CashierName), "", 1, "L", false, 0, "")
 	}
+	if data.Footer != "" {
+		pdf.Ln(2)
+		pdf.MultiCell(0, 4, data.Footer, "", "C", false)
+	}
 
 	var out bytes.Buffer
 	if err := pdf.Output(&out); err != nil {
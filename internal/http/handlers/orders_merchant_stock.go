@@ -33,27 +33,62 @@ func (h *Handler) acceptOrderIfPendingAfterPayment(ctx context.Context, tx pgx.T
 		}
 	}
 
-	_, err := tx.Exec(ctx, `update orders set status = 'ACCEPTED' where id = $1`, orderID)
-	return err
+	if _, err := tx.Exec(ctx, `update orders set status = 'ACCEPTED' where id = $1`, orderID); err != nil {
+		return err
+	}
+	return h.syncOrderIngredientsTx(ctx, tx, orderID, nil)
 }
 
 func (h *Handler) deductStockForScheduledOrder(ctx context.Context, tx pgx.Tx, orderID int64, now time.Time) error {
-	itemRows, err := tx.Query(ctx, `select menu_id, quantity from order_items where order_id = $1`, orderID)
+	itemRows, err := tx.Query(ctx, `
+		select oi.menu_id, oiv.variant_id, oi.quantity
+		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
+		where oi.order_id = $1
+	`, orderID)
 	if err != nil {
 		return err
 	}
 	defer itemRows.Close()
 
 	menuRequired := make(map[int64]int)
+	variantRequired := make(map[int64]int)
 	for itemRows.Next() {
 		var menuID pgtype.Int8
+		var variantID pgtype.Int8
 		var quantity int32
-		if err := itemRows.Scan(&menuID, &quantity); err != nil {
+		if err := itemRows.Scan(&menuID, &variantID, &quantity); err != nil {
 			return err
 		}
 		if menuID.Valid {
 			menuRequired[menuID.Int64] += int(quantity)
 		}
+		if variantID.Valid {
+			variantRequired[variantID.Int64] += int(quantity)
+		}
+	}
+
+	componentRows, err := tx.Query(ctx, `
+		select oic.menu_id, oic.variant_id, oic.quantity * oi.quantity
+		from order_item_components oic
+		join order_items oi on oi.id = oic.order_item_id
+		where oi.order_id = $1
+	`, orderID)
+	if err != nil {
+		return err
+	}
+	defer componentRows.Close()
+	for componentRows.Next() {
+		var menuID int64
+		var variantID pgtype.Int8
+		var quantity int32
+		if err := componentRows.Scan(&menuID, &variantID, &quantity); err != nil {
+			return err
+		}
+		menuRequired[menuID] += int(quantity)
+		if variantID.Valid {
+			variantRequired[variantID.Int64] += int(quantity)
+		}
 	}
 
 	menuInfo := make(map[int64]struct {
@@ -110,6 +145,30 @@ func (h *Handler) deductStockForScheduledOrder(ctx context.Context, tx pgx.Tx, o
 		}
 	}
 
+	for id, qty := range variantRequired {
+		var name string
+		var trackStock bool
+		if err := tx.QueryRow(ctx, `select name, track_stock from menu_variants where id = $1`, id).Scan(&name, &trackStock); err != nil {
+			return fmt.Errorf("Menu variant not found")
+		}
+		if !trackStock {
+			continue
+		}
+		res, err := tx.Exec(ctx, `
+			update menu_variants
+			set stock_qty = stock_qty - $1,
+			    is_active = stock_qty - $1 > 0,
+			    updated_at = now()
+			where id = $2 and track_stock = true and stock_qty >= $1
+		`, qty, id)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() != 1 {
+			return fmt.Errorf("Insufficient stock for %s", name)
+		}
+	}
+
 	addonRows, err := tx.Query(ctx, `
 		select oia.addon_item_id, oia.quantity
 		from order_item_addons oia
@@ -28,13 +28,16 @@ type posOrderRequest struct {
 }
 
 type posOrderItem struct {
-	Type        string          `json:"type"`
-	MenuID      any             `json:"menuId"`
-	CustomName  string          `json:"customName"`
-	CustomPrice *float64        `json:"customPrice"`
-	Quantity    int32           `json:"quantity"`
-	Notes       *string         `json:"notes"`
-	Addons      []posOrderAddon `json:"addons"`
+	Type         string                 `json:"type"`
+	MenuID       any                    `json:"menuId"`
+	VariantID    any                    `json:"variantId"`
+	CustomName   string                 `json:"customName"`
+	CustomPrice  *float64               `json:"customPrice"`
+	Quantity     int32                  `json:"quantity"`
+	Notes        *string                `json:"notes"`
+	Addons       []posOrderAddon        `json:"addons"`
+	Components   []bundleComponentInput `json:"components"`
+	UpsellRuleID any                    `json:"upsellRuleId"`
 }
 
 type posOrderAddon struct {
@@ -55,14 +58,19 @@ type posCustomSettings struct {
 }
 
 type posOrderItemData struct {
-	MenuID    int64
-	MenuName  string
-	MenuPrice float64
-	Quantity  int32
-	Subtotal  float64
-	Notes     *string
-	Addons    []posAddonData
-	IsCustom  bool
+	MenuID      int64
+	VariantID   *int64
+	MenuName    string
+	VariantName *string
+	MenuPrice   float64
+	Quantity    int32
+	Subtotal    float64
+	Notes       *string
+	Addons      []posAddonData
+	Components  []orderItemComponent
+	IsCustom    bool
+	// UpsellRuleID is set when the item was added from an upsell offer.
+	UpsellRuleID *int64
 }
 
 type posAddonData struct {
@@ -131,7 +139,7 @@ func (h *Handler) MerchantPOSCreateOrder(w http.ResponseWriter, r *http.Request)
 
 	// Best-effort stock decrement
 	for _, item := range menuItemRefs {
-		h.decrementPOSStock(ctx, item.MenuID, item.Quantity)
+		h.decrementOrderItemStock(ctx, item.MenuID, item.VariantID, item.Quantity)
 	}
 
 	invalidateAnalyticsCacheForMerchant(merchant.ID)
@@ -255,8 +263,9 @@ func (h *Handler) resolvePOSCustomer(ctx context.Context, customer *posCustomer)
 }
 
 type menuItemRef struct {
-	MenuID   int64
-	Quantity int32
+	MenuID    int64
+	VariantID *int64
+	Quantity  int32
 }
 
 func (h *Handler) buildPOSOrderItems(ctx context.Context, merchant merchantPOSConfig, settings posCustomSettings, items []posOrderItem, userID int64) ([]posOrderItemData, float64, []menuItemRef, error) {
@@ -290,6 +299,14 @@ func (h *Handler) buildPOSOrderItems(ctx context.Context, merchant merchantPOSCo
 	if err != nil {
 		return nil, 0, nil, err
 	}
+	variantMap, err := h.fetchMenuVariants(ctx, merchant.ID, menuIDs, false)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	bundleMap, err := h.fetchMenuBundles(ctx, merchant.ID, menuIDs)
+	if err != nil {
+		return nil, 0, nil, err
+	}
 
 	var placeholderMenuID *int64
 	if len(customItems) > 0 {
@@ -358,12 +375,27 @@ func (h *Handler) buildPOSOrderItems(ctx context.Context, merchant merchantPOSCo
 		if menu.TrackStock && (!menu.StockQty.Valid || menu.StockQty.Int32 < item.Quantity) {
 			return nil, 0, nil, errInvalid("Insufficient stock")
 		}
+		variant, err := resolveMenuVariant(menu.Name, variantMap[menuID], item.VariantID, item.Quantity)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		components, upcharge, err := resolveBundleComponents(menu.Name, bundleMap[menuID], item.Components, item.Quantity)
+		if err != nil {
+			return nil, 0, nil, err
+		}
 
 		price := menu.Price
 		if promo, ok := promoMap[menuID]; ok {
 			price = promo
 		}
-		menuPrice := round2(price)
+		var variantID *int64
+		var variantName *string
+		if variant != nil {
+			price = variant.effectivePrice()
+			variantID = &variant.ID
+			variantName = &variant.Name
+		}
+		menuPrice := round2(price + upcharge)
 		itemTotal := round2(menuPrice * float64(item.Quantity))
 
 		addonData := make([]posAddonData, 0)
@@ -395,18 +427,33 @@ func (h *Handler) buildPOSOrderItems(ctx context.Context, merchant merchantPOSCo
 			})
 		}
 
+		upsellRuleID, err := parseUpsellRuleID(item.UpsellRuleID)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
 		subtotal = round2(subtotal + itemTotal)
 		orderItems = append(orderItems, posOrderItemData{
-			MenuID:    menu.ID,
-			MenuName:  menu.Name,
-			MenuPrice: menuPrice,
-			Quantity:  item.Quantity,
-			Subtotal:  itemTotal,
-			Notes:     item.Notes,
-			Addons:    addonData,
-			IsCustom:  false,
+			MenuID:       menu.ID,
+			VariantID:    variantID,
+			MenuName:     menu.Name,
+			VariantName:  variantName,
+			MenuPrice:    menuPrice,
+			Quantity:     item.Quantity,
+			Subtotal:     itemTotal,
+			Notes:        item.Notes,
+			Addons:       addonData,
+			Components:   components,
+			IsCustom:     false,
+			UpsellRuleID: upsellRuleID,
 		})
-		menuRefs = append(menuRefs, menuItemRef{MenuID: menu.ID, Quantity: item.Quantity})
+		menuRefs = append(menuRefs, menuItemRef{MenuID: menu.ID, VariantID: variantID, Quantity: item.Quantity})
+		menuRefs = append(menuRefs, bundleComponentRefs(components, item.Quantity)...)
+	}
+
+	subtotal, err = h.applyUpsellOffers(ctx, merchant.ID, orderItems, subtotal)
+	if err != nil {
+		return nil, 0, nil, err
 	}
 
 	return orderItems, subtotal, menuRefs, nil
@@ -579,6 +626,15 @@ func (h *Handler) createPOSOrder(ctx context.Context, merchantID int64, customer
 		`, orderID, item.MenuID, item.MenuName, item.MenuPrice, item.Quantity, item.Subtotal, nullIfEmptyPtr(item.Notes)).Scan(&orderItemID); err != nil {
 			return nil, err
 		}
+		if err := insertOrderItemVariant(ctx, tx, orderItemID, item.VariantID, item.VariantName); err != nil {
+			return nil, err
+		}
+		if err := insertOrderItemUpsell(ctx, tx, orderItemID, item.UpsellRuleID); err != nil {
+			return nil, err
+		}
+		if err := insertOrderItemComponents(ctx, tx, orderItemID, item.Components); err != nil {
+			return nil, err
+		}
 
 		if len(item.Addons) > 0 {
 			for _, addon := range item.Addons {
@@ -598,6 +654,9 @@ func (h *Handler) createPOSOrder(ctx context.Context, merchantID int64, customer
 	`, orderID, totalAmount); err != nil {
 		return nil, err
 	}
+	if err := h.syncOrderIngredientsTx(ctx, tx, orderID, nil); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
@@ -700,8 +759,10 @@ func (h *Handler) fetchPOSOrderDetails(ctx context.Context, orderID int64) (map[
 
 	items := make([]map[string]any, 0)
 	itemRows, err := h.DB.Query(ctx, `
-		select oi.id, oi.menu_name, oi.quantity, oi.menu_price, oi.subtotal, oi.notes
-		from order_items oi where oi.order_id = $1
+		select oi.id, oi.menu_name, oiv.variant_name, oi.quantity, oi.menu_price, oi.subtotal, oi.notes
+		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
+		where oi.order_id = $1
 	`, orderID)
 	if err == nil {
 		defer itemRows.Close()
@@ -709,12 +770,13 @@ func (h *Handler) fetchPOSOrderDetails(ctx context.Context, orderID int64) (map[
 			var (
 				itemID       int64
 				menuName     string
+				variantName  pgtype.Text
 				quantity     int32
 				menuPrice    pgtype.Numeric
 				subtotalItem pgtype.Numeric
 				note         pgtype.Text
 			)
-			if err := itemRows.Scan(&itemID, &menuName, &quantity, &menuPrice, &subtotalItem, &note); err != nil {
+			if err := itemRows.Scan(&itemID, &menuName, &variantName, &quantity, &menuPrice, &subtotalItem, &note); err != nil {
 				continue
 			}
 			addons := make([]map[string]any, 0)
@@ -742,12 +804,13 @@ func (h *Handler) fetchPOSOrderDetails(ctx context.Context, orderID int64) (map[
 			}
 
 			entry := map[string]any{
-				"id":        itemID,
-				"menuName":  menuName,
-				"quantity":  quantity,
-				"unitPrice": utils.NumericToFloat64(menuPrice),
-				"subtotal":  utils.NumericToFloat64(subtotalItem),
-				"addons":    addons,
+				"id":          itemID,
+				"menuName":    menuName,
+				"variantName": textPtr(variantName),
+				"quantity":    quantity,
+				"unitPrice":   utils.NumericToFloat64(menuPrice),
+				"subtotal":    utils.NumericToFloat64(subtotalItem),
+				"addons":      addons,
 			}
 			if note.Valid {
 				entry["notes"] = note.String
@@ -206,6 +206,9 @@ func (h *Handler) MerchantPOSOrderUpdate(w http.ResponseWriter, r *http.Request)
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order")
 		return
 	}
+	if stockAdjustments.ShouldAdjust {
+		h.checkStockAlerts(ctx, stockAdjustments.alertRefs()...)
+	}
 
 	updatedOrder, err := h.fetchPOSOrderDetails(ctx, orderID)
 	if err != nil {
@@ -235,11 +238,14 @@ type posEditOrder struct {
 }
 
 type posOrderItemSnapshot struct {
-	MenuID   int64
-	Quantity int32
-	Addons   []posAddonSnapshot
-	MenuName string
-	IsCustom bool
+	ID         int64
+	MenuID     int64
+	VariantID  *int64
+	Quantity   int32
+	Components []orderItemComponent
+	Addons     []posAddonSnapshot
+	MenuName   string
+	IsCustom   bool
 }
 
 type posAddonSnapshot struct {
@@ -304,9 +310,10 @@ func (h *Handler) fetchPosEditOrder(ctx context.Context, merchantID int64, order
 	items := make([]map[string]any, 0)
 	itemRows, err := h.DB.Query(ctx, `
 		select oi.id, oi.menu_id, oi.menu_name, oi.menu_price, oi.quantity, oi.notes,
-		       m.name, m.image_url
+		       m.name, m.image_url, oiv.variant_id, oiv.variant_name
 		from order_items oi
 		left join menus m on m.id = oi.menu_id
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
 		where oi.order_id = $1
 	`, orderID)
 	if err == nil {
@@ -321,20 +328,24 @@ func (h *Handler) fetchPosEditOrder(ctx context.Context, merchantID int64, order
 				note           pgtype.Text
 				menuRecordName pgtype.Text
 				imageURL       pgtype.Text
+				variantID      pgtype.Int8
+				variantName    pgtype.Text
 			)
-			if err := itemRows.Scan(&itemID, &menuID, &menuName, &menuPrice, &quantity, &note, &menuRecordName, &imageURL); err != nil {
+			if err := itemRows.Scan(&itemID, &menuID, &menuName, &menuPrice, &quantity, &note, &menuRecordName, &imageURL, &variantID, &variantName); err != nil {
 				continue
 			}
 
 			isCustom := menuRecordName.Valid && menuRecordName.String == posCustomPlaceholderMenuName
 			entry := map[string]any{
-				"type":      "MENU",
-				"menuId":    menuID,
-				"menuName":  menuName,
-				"menuPrice": utils.NumericToFloat64(menuPrice),
-				"quantity":  quantity,
-				"addons":    []map[string]any{},
-				"imageUrl":  valueOrNil(imageURL),
+				"type":        "MENU",
+				"menuId":      menuID,
+				"menuName":    menuName,
+				"variantId":   int8Ptr(variantID),
+				"variantName": textPtr(variantName),
+				"menuPrice":   utils.NumericToFloat64(menuPrice),
+				"quantity":    quantity,
+				"addons":      []map[string]any{},
+				"imageUrl":    valueOrNil(imageURL),
 			}
 			if note.Valid {
 				entry["notes"] = note.String
@@ -432,9 +443,10 @@ func (h *Handler) loadPosEditOrder(ctx context.Context, merchantID int64, orderI
 
 	// Load order items
 	itemRows, _ := h.DB.Query(ctx, `
-		select oi.id, oi.menu_id, oi.quantity, oi.menu_name, m.name
+		select oi.id, oi.menu_id, oi.quantity, oi.menu_name, m.name, oiv.variant_id
 		from order_items oi
 		left join menus m on m.id = oi.menu_id
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
 		where oi.order_id = $1
 	`, orderID)
 	if itemRows != nil {
@@ -446,12 +458,13 @@ func (h *Handler) loadPosEditOrder(ctx context.Context, merchantID int64, orderI
 				quantity       int32
 				menuName       string
 				menuRecordName pgtype.Text
+				variantID      pgtype.Int8
 			)
-			if err := itemRows.Scan(&itemID, &menuID, &quantity, &menuName, &menuRecordName); err != nil {
+			if err := itemRows.Scan(&itemID, &menuID, &quantity, &menuName, &menuRecordName, &variantID); err != nil {
 				continue
 			}
 			isCustom := menuRecordName.Valid && menuRecordName.String == posCustomPlaceholderMenuName
-			item := posOrderItemSnapshot{MenuID: menuID, Quantity: quantity, MenuName: menuName, IsCustom: isCustom}
+			item := posOrderItemSnapshot{ID: itemID, MenuID: menuID, VariantID: int8Ptr(variantID), Quantity: quantity, MenuName: menuName, IsCustom: isCustom}
 			addonRows, _ := h.DB.Query(ctx, `select addon_item_id, quantity from order_item_addons where order_item_id = $1`, itemID)
 			if addonRows != nil {
 				for addonRows.Next() {
@@ -468,6 +481,16 @@ func (h *Handler) loadPosEditOrder(ctx context.Context, merchantID int64, orderI
 		}
 	}
 
+	itemIDs := make([]int64, 0, len(order.Items))
+	for _, item := range order.Items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	if components, err := h.fetchOrderItemComponents(ctx, itemIDs); err == nil {
+		for idx := range order.Items {
+			order.Items[idx].Components = components[order.Items[idx].ID]
+		}
+	}
+
 	// Load discounts
 	rows, _ := h.DB.Query(ctx, `
 		select d.source, d.label, d.discount_type, d.discount_value, d.discount_amount,
@@ -559,13 +582,36 @@ type posStockAddon struct {
 }
 
 type posStockAdjustment struct {
-	ShouldAdjust bool
-	Menus        []posStockMenu
-	Addons       []posStockAddon
-	OldMenuQty   map[int64]int32
-	NewMenuQty   map[int64]int32
-	OldAddonQty  map[int64]int32
-	NewAddonQty  map[int64]int32
+	ShouldAdjust  bool
+	Menus         []posStockMenu
+	Addons        []posStockAddon
+	OldMenuQty    map[int64]int32
+	NewMenuQty    map[int64]int32
+	OldAddonQty   map[int64]int32
+	NewAddonQty   map[int64]int32
+	OldVariantQty map[int64]int32
+	NewVariantQty map[int64]int32
+}
+
+// alertRefs lists every item whose stock the edit may have moved, including
+// items removed from the order.
+func (a posStockAdjustment) alertRefs() []stockAlertRef {
+	refs := make([]stockAlertRef, 0)
+	add := func(itemType string, quantities ...map[int64]int32) {
+		seen := make(map[int64]bool)
+		for _, qty := range quantities {
+			for id := range qty {
+				if !seen[id] {
+					seen[id] = true
+					refs = append(refs, stockAlertRef{ItemType: itemType, ID: id})
+				}
+			}
+		}
+	}
+	add(stockAlertMenu, a.OldMenuQty, a.NewMenuQty)
+	add(stockAlertVariant, a.OldVariantQty, a.NewVariantQty)
+	add(stockAlertAddonItem, a.OldAddonQty, a.NewAddonQty)
+	return refs
 }
 
 func (h *Handler) buildPOSOrderItemsForEdit(ctx context.Context, merchant merchantPOSConfig, settings posCustomSettings, items []posOrderItem, userID int64) ([]posOrderItemData, float64, error) {
@@ -599,6 +645,14 @@ func (h *Handler) buildPOSOrderItemsForEdit(ctx context.Context, merchant mercha
 	if err != nil {
 		return nil, 0, err
 	}
+	variantMap, err := h.fetchMenuVariants(ctx, merchant.ID, menuIDs, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	bundleMap, err := h.fetchMenuBundles(ctx, merchant.ID, menuIDs)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	var placeholderMenuID *int64
 	if len(customItems) > 0 {
@@ -666,12 +720,28 @@ func (h *Handler) buildPOSOrderItemsForEdit(ctx context.Context, merchant mercha
 		if item.Quantity <= 0 {
 			return nil, 0, errInvalid("Invalid quantity.")
 		}
+		// Stock is checked against the edit delta in preparePOSStockAdjustments.
+		variant, err := resolveMenuVariant(menu.Name, variantMap[menuID], item.VariantID, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		components, upcharge, err := resolveBundleComponents(menu.Name, bundleMap[menuID], item.Components, 0)
+		if err != nil {
+			return nil, 0, err
+		}
 
 		price := menu.Price
 		if promo, ok := promoMap[menuID]; ok {
 			price = promo
 		}
-		menuPrice := round2(price)
+		var variantID *int64
+		var variantName *string
+		if variant != nil {
+			price = variant.effectivePrice()
+			variantID = &variant.ID
+			variantName = &variant.Name
+		}
+		menuPrice := round2(price + upcharge)
 		itemTotal := round2(menuPrice * float64(item.Quantity))
 
 		addonData := make([]posAddonData, 0)
@@ -703,19 +773,33 @@ func (h *Handler) buildPOSOrderItemsForEdit(ctx context.Context, merchant mercha
 			})
 		}
 
+		upsellRuleID, err := parseUpsellRuleID(item.UpsellRuleID)
+		if err != nil {
+			return nil, 0, err
+		}
+
 		subtotal = round2(subtotal + itemTotal)
 		orderItems = append(orderItems, posOrderItemData{
-			MenuID:    menu.ID,
-			MenuName:  menu.Name,
-			MenuPrice: menuPrice,
-			Quantity:  item.Quantity,
-			Subtotal:  itemTotal,
-			Notes:     item.Notes,
-			Addons:    addonData,
-			IsCustom:  false,
+			MenuID:       menu.ID,
+			VariantID:    variantID,
+			MenuName:     menu.Name,
+			VariantName:  variantName,
+			MenuPrice:    menuPrice,
+			Quantity:     item.Quantity,
+			Subtotal:     itemTotal,
+			Notes:        item.Notes,
+			Addons:       addonData,
+			Components:   components,
+			IsCustom:     false,
+			UpsellRuleID: upsellRuleID,
 		})
 	}
 
+	subtotal, err = h.applyUpsellOffers(ctx, merchant.ID, orderItems, subtotal)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	return orderItems, subtotal, nil
 }
 
@@ -833,11 +917,13 @@ func (h *Handler) recalculateOrderDiscounts(ctx context.Context, merchant mercha
 func (h *Handler) preparePOSStockAdjustments(ctx context.Context, existing posEditOrder, newItems []posOrderItemData) (posStockAdjustment, error) {
 	shouldAdjust := !existing.IsScheduled || existing.StockDeductedAt != nil
 	adjustments := posStockAdjustment{
-		ShouldAdjust: shouldAdjust,
-		OldMenuQty:   map[int64]int32{},
-		NewMenuQty:   map[int64]int32{},
-		OldAddonQty:  map[int64]int32{},
-		NewAddonQty:  map[int64]int32{},
+		ShouldAdjust:  shouldAdjust,
+		OldMenuQty:    map[int64]int32{},
+		NewMenuQty:    map[int64]int32{},
+		OldAddonQty:   map[int64]int32{},
+		NewAddonQty:   map[int64]int32{},
+		OldVariantQty: map[int64]int32{},
+		NewVariantQty: map[int64]int32{},
 	}
 
 	if !shouldAdjust {
@@ -849,6 +935,15 @@ func (h *Handler) preparePOSStockAdjustments(ctx context.Context, existing posEd
 			continue
 		}
 		adjustments.OldMenuQty[item.MenuID] += item.Quantity
+		if item.VariantID != nil {
+			adjustments.OldVariantQty[*item.VariantID] += item.Quantity
+		}
+		for _, ref := range bundleComponentRefs(item.Components, item.Quantity) {
+			adjustments.OldMenuQty[ref.MenuID] += ref.Quantity
+			if ref.VariantID != nil {
+				adjustments.OldVariantQty[*ref.VariantID] += ref.Quantity
+			}
+		}
 		for _, addon := range item.Addons {
 			adjustments.OldAddonQty[addon.AddonID] += addon.Quantity
 		}
@@ -859,6 +954,15 @@ func (h *Handler) preparePOSStockAdjustments(ctx context.Context, existing posEd
 			continue
 		}
 		adjustments.NewMenuQty[item.MenuID] += item.Quantity
+		if item.VariantID != nil {
+			adjustments.NewVariantQty[*item.VariantID] += item.Quantity
+		}
+		for _, ref := range bundleComponentRefs(item.Components, item.Quantity) {
+			adjustments.NewMenuQty[ref.MenuID] += ref.Quantity
+			if ref.VariantID != nil {
+				adjustments.NewVariantQty[*ref.VariantID] += ref.Quantity
+			}
+		}
 		for _, addon := range item.Addons {
 			adjustments.NewAddonQty[addon.AddonItemID] += addon.Quantity
 		}
@@ -946,6 +1050,24 @@ func (h *Handler) preparePOSStockAdjustments(ctx context.Context, existing posEd
 		}
 	}
 
+	for id, newQty := range adjustments.NewVariantQty {
+		delta := newQty - adjustments.OldVariantQty[id]
+		if delta <= 0 {
+			continue
+		}
+		var name string
+		var trackStock bool
+		var stockQty pgtype.Int4
+		if err := h.DB.QueryRow(ctx, `
+			select name, track_stock, stock_qty from menu_variants where id = $1
+		`, id).Scan(&name, &trackStock, &stockQty); err != nil {
+			return adjustments, err
+		}
+		if trackStock && (!stockQty.Valid || stockQty.Int32 < delta) {
+			return adjustments, errInvalid("Insufficient stock for \"" + name + "\".")
+		}
+	}
+
 	return adjustments, nil
 }
 
@@ -1038,12 +1160,46 @@ func (h *Handler) applyPosOrderUpdate(ctx context.Context, merchant merchantPOSC
 				}
 			}
 		}
+
+		variantIDs := make(map[int64]struct{})
+		for id := range stockAdjustments.OldVariantQty {
+			variantIDs[id] = struct{}{}
+		}
+		for id := range stockAdjustments.NewVariantQty {
+			variantIDs[id] = struct{}{}
+		}
+		for id := range variantIDs {
+			delta := stockAdjustments.NewVariantQty[id] - stockAdjustments.OldVariantQty[id]
+			if delta == 0 {
+				continue
+			}
+			cmd, err := tx.Exec(ctx, `
+				update menu_variants
+				set stock_qty = stock_qty - $1,
+				    is_active = stock_qty - $1 > 0,
+				    updated_at = now()
+				where id = $2 and track_stock = true and stock_qty is not null and stock_qty >= $1
+			`, delta, id)
+			if err != nil {
+				return err
+			}
+			if delta > 0 && cmd.RowsAffected() != 1 {
+				var trackStock bool
+				if err := tx.QueryRow(ctx, `select track_stock from menu_variants where id = $1`, id).Scan(&trackStock); err == nil && trackStock {
+					return errInvalid("Insufficient stock for the selected variant.")
+				}
+			}
+		}
 	}
 
 	_, err = tx.Exec(ctx, `delete from order_item_addons where order_item_id in (select id from order_items where order_id = $1)`, existing.ID)
 	if err != nil {
 		return err
 	}
+	_, err = tx.Exec(ctx, `delete from order_item_components where order_item_id in (select id from order_items where order_id = $1)`, existing.ID)
+	if err != nil {
+		return err
+	}
 	_, err = tx.Exec(ctx, `delete from order_items where order_id = $1`, existing.ID)
 	if err != nil {
 		return err
@@ -1058,6 +1214,15 @@ func (h *Handler) applyPosOrderUpdate(ctx context.Context, merchant merchantPOSC
 		`, existing.ID, item.MenuID, item.MenuName, item.MenuPrice, item.Quantity, item.Subtotal, nullIfEmptyPtr(item.Notes)).Scan(&orderItemID); err != nil {
 			return err
 		}
+		if err := insertOrderItemVariant(ctx, tx, orderItemID, item.VariantID, item.VariantName); err != nil {
+			return err
+		}
+		if err := insertOrderItemUpsell(ctx, tx, orderItemID, item.UpsellRuleID); err != nil {
+			return err
+		}
+		if err := insertOrderItemComponents(ctx, tx, orderItemID, item.Components); err != nil {
+			return err
+		}
 		if len(item.Addons) > 0 {
 			for _, addon := range item.Addons {
 				if _, err := tx.Exec(ctx, `
@@ -1109,6 +1274,9 @@ func (h *Handler) applyPosOrderUpdate(ctx context.Context, merchant merchantPOSC
 	if err != nil {
 		return err
 	}
+	if err := h.resyncOrderIngredientsTx(ctx, tx, existing.ID, editedByUserID); err != nil {
+		return err
+	}
 
 	return tx.Commit(ctx)
 }
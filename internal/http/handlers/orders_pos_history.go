@@ -22,13 +22,14 @@ type POSOrderHistoryAddon struct {
 }
 
 type POSOrderHistoryItem struct {
-	ID        string                 `json:"id"`
-	MenuName  string                 `json:"menuName"`
-	Quantity  int32                  `json:"quantity"`
-	UnitPrice float64                `json:"unitPrice"`
-	Subtotal  float64                `json:"subtotal"`
-	Notes     *string                `json:"notes,omitempty"`
-	Addons    []POSOrderHistoryAddon `json:"addons"`
+	ID          string                 `json:"id"`
+	MenuName    string                 `json:"menuName"`
+	VariantName *string                `json:"variantName,omitempty"`
+	Quantity    int32                  `json:"quantity"`
+	UnitPrice   float64                `json:"unitPrice"`
+	Subtotal    float64                `json:"subtotal"`
+	Notes       *string                `json:"notes,omitempty"`
+	Addons      []POSOrderHistoryAddon `json:"addons"`
 }
 
 type POSOrderHistoryEntry struct {
@@ -66,6 +67,12 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 	todayOnly := query.Get("today") == "true"
 	limit := parseIntWithBounds(query.Get("limit"), 200, 1, 500)
 
+	cursorMode, cursor, err := readKeysetCursor(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid cursor")
+		return
+	}
+
 	args := []any{*authCtx.MerchantID}
 	where := "where o.merchant_id = $1"
 	if todayOnly {
@@ -73,8 +80,17 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 		where += " and o.placed_at >= $2 and o.placed_at < $3"
 		args = append(args, start, end)
 	}
+	if cursor != nil {
+		var condition string
+		condition, args = keysetCondition("o.placed_at", "o.id", cursor, args)
+		where += " and " + condition
+	}
 
-	args = append(args, limit)
+	fetchLimit := limit
+	if cursorMode {
+		fetchLimit = limit + 1
+	}
+	args = append(args, fetchLimit)
 	limitPlaceholder := len(args)
 
 	querySQL := `
@@ -86,7 +102,7 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 		left join customers c on c.id = o.customer_id
 		left join payments p on p.order_id = o.id
 		` + where + `
-		order by o.placed_at desc
+		order by o.placed_at desc, o.id desc
 		limit $` + strconv.Itoa(limitPlaceholder)
 
 	rows, err := h.DB.Query(ctx, querySQL, args...)
@@ -100,6 +116,7 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 	entries := make([]POSOrderHistoryEntry, 0)
 	orderIDs := make([]int64, 0)
 	orderIndex := make(map[int64]int)
+	placedAts := make([]time.Time, 0)
 
 	for rows.Next() {
 		var (
@@ -217,15 +234,32 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 
 		orderIndex[orderID] = len(entries)
 		orderIDs = append(orderIDs, orderID)
+		placedAts = append(placedAts, placedAt)
 		entries = append(entries, entry)
 	}
 
+	payload := map[string]any{
+		"success":    true,
+		"data":       entries,
+		"statusCode": 200,
+	}
+	if cursorMode {
+		hasMore := len(entries) > limit
+		var nextCursor *string
+		if hasMore {
+			delete(orderIndex, orderIDs[limit])
+			entries = entries[:limit]
+			orderIDs = orderIDs[:limit]
+			encoded := encodeKeysetCursor(placedAts[limit-1], orderIDs[limit-1])
+			nextCursor = &encoded
+		}
+		payload["data"] = entries
+		payload["nextCursor"] = nextCursor
+		payload["hasMore"] = hasMore
+	}
+
 	if len(orderIDs) == 0 {
-		response.JSON(w, http.StatusOK, map[string]any{
-			"success":    true,
-			"data":       entries,
-			"statusCode": 200,
-		})
+		response.JSON(w, http.StatusOK, payload)
 		return
 	}
 
@@ -258,17 +292,14 @@ func (h *Handler) MerchantPOSOrderHistory(w http.ResponseWriter, r *http.Request
 		entries[idx].Items = orderItems
 	}
 
-	response.JSON(w, http.StatusOK, map[string]any{
-		"success":    true,
-		"data":       entries,
-		"statusCode": 200,
-	})
+	response.JSON(w, http.StatusOK, payload)
 }
 
 func (h *Handler) fetchPOSOrderItems(ctx context.Context, orderIDs []int64) (map[int64][]POSOrderHistoryItem, map[int64]int64, error) {
 	rows, err := h.DB.Query(ctx, `
-		select oi.id, oi.order_id, oi.menu_name, oi.quantity, oi.menu_price, oi.subtotal, oi.notes
+		select oi.id, oi.order_id, oi.menu_name, oiv.variant_name, oi.quantity, oi.menu_price, oi.subtotal, oi.notes
 		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
 		where oi.order_id = any($1)
 	`, orderIDs)
 	if err != nil {
@@ -281,25 +312,27 @@ func (h *Handler) fetchPOSOrderItems(ctx context.Context, orderIDs []int64) (map
 
 	for rows.Next() {
 		var (
-			itemID    int64
-			orderID   int64
-			menuName  string
-			quantity  int32
-			menuPrice pgtype.Numeric
-			subtotal  pgtype.Numeric
-			notes     pgtype.Text
+			itemID      int64
+			orderID     int64
+			menuName    string
+			variantName pgtype.Text
+			quantity    int32
+			menuPrice   pgtype.Numeric
+			subtotal    pgtype.Numeric
+			notes       pgtype.Text
 		)
-		if err := rows.Scan(&itemID, &orderID, &menuName, &quantity, &menuPrice, &subtotal, &notes); err != nil {
+		if err := rows.Scan(&itemID, &orderID, &menuName, &variantName, &quantity, &menuPrice, &subtotal, &notes); err != nil {
 			return nil, nil, err
 		}
 
 		item := POSOrderHistoryItem{
-			ID:        strconv.FormatInt(itemID, 10),
-			MenuName:  menuName,
-			Quantity:  quantity,
-			UnitPrice: utils.NumericToFloat64(menuPrice),
-			Subtotal:  utils.NumericToFloat64(subtotal),
-			Addons:    []POSOrderHistoryAddon{},
+			ID:          strconv.FormatInt(itemID, 10),
+			MenuName:    menuName,
+			VariantName: textPtr(variantName),
+			Quantity:    quantity,
+			UnitPrice:   utils.NumericToFloat64(menuPrice),
+			Subtotal:    utils.NumericToFloat64(subtotal),
+			Addons:      []POSOrderHistoryAddon{},
 		}
 		if notes.Valid {
 			item.Notes = &notes.String
@@ -165,6 +165,11 @@ func (h *Handler) MerchantPOSRefund(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	h.checkOrderStockAlerts(ctx, orderID)
+
+	if err := h.restoreOrderIngredients(ctx, orderID, &authCtx.UserID); err != nil {
+		h.Logger.Warn("order ingredient restore failed", zapError(err))
+	}
 
 	message := "Order refunded/voided successfully"
 	if alreadyCancelled && (alreadyRefunded || !paymentID.Valid) {
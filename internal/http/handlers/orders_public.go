@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"genfity-order-services/internal/utils"
@@ -27,13 +28,14 @@ func (h *Handler) PublicOrderDetail(w http.ResponseWriter, r *http.Request) {
 		  o.id, o.order_number, o.status, o.order_type, o.table_number,
 		  o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
 		  o.created_at, o.updated_at, o.placed_at, o.completed_at,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
+		  ` + EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
 		  o.edited_at,
 		  m.name, m.currency, m.code,
 		  c.name,
 		  p.status, p.payment_method, p.amount, p.paid_at, p.customer_paid_at, p.customer_proof_url, p.customer_proof_uploaded_at, p.customer_payment_note, p.customer_proof_meta,
 		  r.status, r.party_size, r.reservation_date, r.reservation_time, r.table_number
 		from orders o
+		` + DeliveryProgressJoinSQL + `
 		join merchants m on m.id = o.merchant_id
 		left join customers c on c.id = o.customer_id
 		left join payments p on p.order_id = o.id
@@ -141,6 +143,28 @@ func (h *Handler) PublicOrderDetail(w http.ResponseWriter, r *http.Request) {
 	detail.Merchant.Currency = merchantCurrency
 	detail.Merchant.Code = merchantCode
 
+	if strings.EqualFold(detail.OrderType, "DELIVERY") {
+		route, err := FetchDeliveryRouteInfo(ctx, h.DB, detail.ID)
+		if err != nil {
+			h.Logger.Warn("public order route lookup failed", zapError(err))
+		}
+		detail.DeliveryRoute = route
+
+		eta, err := FetchDeliveryEta(ctx, h.DB, detail.ID, route)
+		if err != nil {
+			h.Logger.Warn("public order eta lookup failed", zapError(err))
+		}
+		detail.DeliveryEta = eta
+	}
+
+	if strings.EqualFold(detail.OrderType, "TAKEAWAY") {
+		curbside, err := FetchCurbsidePickup(ctx, h.DB, detail.ID)
+		if err != nil {
+			h.Logger.Warn("public order curbside lookup failed", zapError(err))
+		}
+		detail.Curbside = curbside
+	}
+
 	if pStatus.Valid || pMethod.Valid || pAmount.Valid || pPaidAt.Valid {
 		status := pStatus.String
 		method := pMethod.String
@@ -237,10 +261,11 @@ func (h *Handler) PublicOrderDetail(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) fetchOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
 	query := `
-		select id, menu_name, menu_price, quantity, subtotal, notes
-		from order_items
-		where order_id = $1
-		order by id asc
+		select oi.id, oi.menu_name, oiv.variant_name, oi.menu_price, oi.quantity, oi.subtotal, oi.notes
+		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
+		where oi.order_id = $1
+		order by oi.id asc
 	`
 
 	rows, err := h.DB.Query(ctx, query, orderID)
@@ -257,7 +282,7 @@ func (h *Handler) fetchOrderItems(ctx context.Context, orderID int64) ([]OrderIt
 			menuPrice pgtype.Numeric
 			subtotal  pgtype.Numeric
 		)
-		if err := rows.Scan(&item.ID, &item.MenuName, &menuPrice, &item.Quantity, &subtotal, &item.Notes); err != nil {
+		if err := rows.Scan(&item.ID, &item.MenuName, &item.VariantName, &menuPrice, &item.Quantity, &subtotal, &item.Notes); err != nil {
 			return nil, err
 		}
 		item.MenuPrice = utils.NumericToFloat64(menuPrice)
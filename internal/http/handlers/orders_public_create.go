@@ -43,13 +43,19 @@ type publicOrderRequest struct {
 	PaymentMethod          *string           `json:"paymentMethod"`
 	PaymentAccountID       *string           `json:"paymentAccountId"`
 	VoucherCode            *string           `json:"voucherCode"`
+	PickupMode             *string           `json:"pickupMode"`
+	VehicleDescription     *string           `json:"vehicleDescription"`
+	ParkingSpot            *string           `json:"parkingSpot"`
 }
 
 type publicOrderItem struct {
-	MenuID   any                `json:"menuId"`
-	Quantity int32              `json:"quantity"`
-	Notes    *string            `json:"notes"`
-	Addons   []publicOrderAddon `json:"addons"`
+	MenuID       any                    `json:"menuId"`
+	VariantID    any                    `json:"variantId"`
+	Quantity     int32                  `json:"quantity"`
+	Notes        *string                `json:"notes"`
+	Addons       []publicOrderAddon     `json:"addons"`
+	Components   []bundleComponentInput `json:"components"`
+	UpsellRuleID any                    `json:"upsellRuleId"`
 }
 
 type publicOrderAddon struct {
@@ -122,6 +128,12 @@ func (h *Handler) PublicOrderCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	curbside, err := parseCurbsideRequest(orderType, body.PickupMode, body.VehicleDescription, body.ParkingSpot)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
 	merchant, availability, deliveryCfg, err := h.loadPublicOrderMerchant(ctx, merchantCode)
 	if err != nil {
 		response.Error(w, http.StatusBadRequest, "MERCHANT_INACTIVE", "Merchant is currently not accepting orders")
@@ -200,12 +212,16 @@ func (h *Handler) PublicOrderCreate(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		fee, distance, code, msg := h.calculateGroupOrderDeliveryFee(ctx, deliveryCfg, *body.DeliveryLatitude, *body.DeliveryLongitude)
+		pricedAt := time.Now()
+		if isScheduled {
+			pricedAt = scheduledTimeToday(availability.timezone, scheduledTime)
+		}
+		breakdown, distance, code, msg := h.evaluateMerchantDeliveryFee(ctx, deliveryCfg, *body.DeliveryLatitude, *body.DeliveryLongitude, &subtotal, pricedAt)
 		if code != "" {
 			response.Error(w, http.StatusBadRequest, code, msg)
 			return
 		}
-		deliveryFeeAmount = fee
+		deliveryFeeAmount = breakdown.Fee
 		deliveryDistance = &distance
 	}
 
@@ -261,7 +277,7 @@ func (h *Handler) PublicOrderCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID, err := h.createPublicOrder(ctx, merchant, orderType, orderNumber, customerID, body, orderItems, subtotal, taxAmount, serviceChargeAmount, packagingFeeAmount, deliveryFeeAmount, deliveryDistance, totalAfterDiscount, isScheduled, scheduledTime, paymentMethod, customerPaymentNote, customerProofMeta, voucherDiscount)
+	orderID, err := h.createPublicOrder(ctx, merchant, orderType, orderNumber, customerID, body, orderItems, subtotal, taxAmount, serviceChargeAmount, packagingFeeAmount, deliveryFeeAmount, deliveryDistance, totalAfterDiscount, isScheduled, scheduledTime, paymentMethod, customerPaymentNote, customerProofMeta, voucherDiscount, curbside)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create order")
 		return
@@ -269,7 +285,7 @@ func (h *Handler) PublicOrderCreate(w http.ResponseWriter, r *http.Request) {
 
 	if !isScheduled {
 		for _, item := range menuRefs {
-			h.decrementPOSStock(ctx, item.MenuID, item.Quantity)
+			h.decrementOrderItemStock(ctx, item.MenuID, item.VariantID, item.Quantity)
 		}
 	}
 
@@ -320,6 +336,14 @@ func (h *Handler) buildPublicOrderItems(ctx context.Context, merchantID int64, i
 		return nil, 0, nil, nil, err
 	}
 	promoMap := h.fetchGroupOrderPromoPrices(ctx, menuIDs, merchantID)
+	variantMap, err := h.fetchMenuVariants(ctx, merchantID, menuIDs, false)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	bundleMap, err := h.fetchMenuBundles(ctx, merchantID, menuIDs)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
 
 	orderItems := make([]posOrderItemData, 0)
 	voucherItems := make([]voucher.OrderItemInput, 0)
@@ -341,12 +365,27 @@ func (h *Handler) buildPublicOrderItems(ctx context.Context, merchantID int64, i
 		if menu.TrackStock && (!menu.StockQty.Valid || menu.StockQty.Int32 < item.Quantity) {
 			return nil, 0, nil, nil, errInvalid("Insufficient stock")
 		}
+		variant, err := resolveMenuVariant(menu.Name, variantMap[menuID], item.VariantID, item.Quantity)
+		if err != nil {
+			return nil, 0, nil, nil, err
+		}
+		components, upcharge, err := resolveBundleComponents(menu.Name, bundleMap[menuID], item.Components, item.Quantity)
+		if err != nil {
+			return nil, 0, nil, nil, err
+		}
 
 		price := menu.Price
 		if promo, ok := promoMap[menuID]; ok {
 			price = promo
 		}
-		menuPrice := round2(price)
+		var variantID *int64
+		var variantName *string
+		if variant != nil {
+			price = variant.effectivePrice()
+			variantID = &variant.ID
+			variantName = &variant.Name
+		}
+		menuPrice := round2(price + upcharge)
 		itemTotal := round2(menuPrice * float64(item.Quantity))
 
 		addonData := make([]posAddonData, 0)
@@ -375,21 +414,39 @@ func (h *Handler) buildPublicOrderItems(ctx context.Context, merchantID int64, i
 			})
 		}
 
+		upsellRuleID, err := parseUpsellRuleID(item.UpsellRuleID)
+		if err != nil {
+			return nil, 0, nil, nil, err
+		}
+
 		subtotal = round2(subtotal + itemTotal)
 		orderItems = append(orderItems, posOrderItemData{
-			MenuID:    menu.ID,
-			MenuName:  menu.Name,
-			MenuPrice: menuPrice,
-			Quantity:  item.Quantity,
-			Subtotal:  itemTotal,
-			Notes:     item.Notes,
-			Addons:    addonData,
-			IsCustom:  false,
+			MenuID:       menu.ID,
+			VariantID:    variantID,
+			MenuName:     menu.Name,
+			VariantName:  variantName,
+			MenuPrice:    menuPrice,
+			Quantity:     item.Quantity,
+			Subtotal:     itemTotal,
+			Notes:        item.Notes,
+			Addons:       addonData,
+			Components:   components,
+			IsCustom:     false,
+			UpsellRuleID: upsellRuleID,
 		})
-		menuRefs = append(menuRefs, menuItemRef{MenuID: menu.ID, Quantity: item.Quantity})
+		menuRefs = append(menuRefs, menuItemRef{MenuID: menu.ID, VariantID: variantID, Quantity: item.Quantity})
+		menuRefs = append(menuRefs, bundleComponentRefs(components, item.Quantity)...)
 		voucherItems = append(voucherItems, voucher.OrderItemInput{MenuID: menu.ID, Subtotal: itemTotal})
 	}
 
+	subtotal, err = h.applyUpsellOffers(ctx, merchantID, orderItems, subtotal)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	for i := range orderItems {
+		voucherItems[i].Subtotal = orderItems[i].Subtotal
+	}
+
 	return orderItems, subtotal, menuRefs, voucherItems, nil
 }
 
@@ -471,6 +528,8 @@ func (h *Handler) loadPublicOrderMerchant(ctx context.Context, code string) (pub
 
 	deliveryConfig.MerchantID = merchant.ID
 	merchant.Timezone = timezoneOrDefault(merchant.Timezone)
+	deliveryConfig.Timezone = merchant.Timezone
+	deliveryConfig.Features = featuresBytes
 	if strings.TrimSpace(merchant.Currency) == "" {
 		merchant.Currency = "AUD"
 	}
@@ -684,6 +743,7 @@ func (h *Handler) createPublicOrder(
 	customerPaymentNote *string,
 	customerProofMeta map[string]any,
 	voucherDiscount *voucher.DiscountResult,
+	curbside *curbsideRequest,
 ) (int64, error) {
 	tx, err := h.DB.Begin(ctx)
 	if err != nil {
@@ -798,6 +858,15 @@ func (h *Handler) createPublicOrder(
         `, orderID, item.MenuID, item.MenuName, item.MenuPrice, item.Quantity, item.Subtotal, nullIfEmptyPtr(item.Notes)).Scan(&orderItemID); err != nil {
 			return 0, err
 		}
+		if err := insertOrderItemVariant(ctx, tx, orderItemID, item.VariantID, item.VariantName); err != nil {
+			return 0, err
+		}
+		if err := insertOrderItemUpsell(ctx, tx, orderItemID, item.UpsellRuleID); err != nil {
+			return 0, err
+		}
+		if err := insertOrderItemComponents(ctx, tx, orderItemID, item.Components); err != nil {
+			return 0, err
+		}
 
 		if len(item.Addons) > 0 {
 			for _, addon := range item.Addons {
@@ -847,6 +916,12 @@ func (h *Handler) createPublicOrder(
 		}
 	}
 
+	if curbside != nil {
+		if err := insertCurbsidePickupTx(ctx, tx, merchant.ID, orderID, curbside); err != nil {
+			return 0, err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return 0, err
 	}
@@ -860,13 +935,14 @@ func (h *Handler) fetchPublicOrderDetailByID(ctx context.Context, orderID int64)
           o.id, o.order_number, o.status, o.order_type, o.table_number,
           o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
           o.created_at, o.updated_at, o.placed_at, o.completed_at,
-          o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
+          ` + EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
           o.edited_at,
           m.name, m.currency, m.code,
           c.name,
 					p.status, p.payment_method, p.amount, p.paid_at, p.customer_paid_at, p.customer_proof_url, p.customer_proof_uploaded_at, p.customer_payment_note, p.customer_proof_meta,
           r.status, r.party_size, r.reservation_date, r.reservation_time, r.table_number
         from orders o
+        ` + DeliveryProgressJoinSQL + `
         join merchants m on m.id = o.merchant_id
         left join customers c on c.id = o.customer_id
         left join payments p on p.order_id = o.id
@@ -1045,6 +1121,14 @@ func (h *Handler) fetchPublicOrderDetailByID(ctx context.Context, orderID int64)
 		}
 	}
 
+	if detail.OrderType == "TAKEAWAY" {
+		curbside, err := FetchCurbsidePickup(ctx, h.DB, detail.ID)
+		if err != nil {
+			return OrderDetail{}, err
+		}
+		detail.Curbside = curbside
+	}
+
 	items, err := h.fetchOrderItems(ctx, detail.ID)
 	if err != nil {
 		return OrderDetail{}, err
@@ -1054,6 +1138,17 @@ func (h *Handler) fetchPublicOrderDetailByID(ctx context.Context, orderID int64)
 	return detail, nil
 }
 
+// scheduledTimeToday returns today's HH:MM in the merchant timezone.
+func scheduledTimeToday(timezone, hhmm string) time.Time {
+	loc := loadTimezone(timezone)
+	now := time.Now().In(loc)
+	parsed, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return now
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, loc)
+}
+
 func isValidHHMM(value string) bool {
 	if len(value) != 5 || value[2] != ':' {
 		return false
@@ -154,9 +154,10 @@ func (h *Handler) PublicOrderFeedbackCreate(w http.ResponseWriter, r *http.Reque
 	)
 
 	if err := h.DB.QueryRow(ctx, `
-		select o.id, o.merchant_id, o.order_type, o.delivery_status, o.placed_at, o.completed_at, o.delivery_delivered_at,
+		select o.id, o.merchant_id, o.order_type, `+EffectiveDeliveryStatusSQL+`, o.placed_at, o.completed_at, o.delivery_delivered_at,
 		       m.code
 		from orders o
+		`+DeliveryProgressJoinSQL+`
 		join merchants m on m.id = o.merchant_id
 		where o.order_number = $1
 		limit 1
@@ -13,6 +13,7 @@ import (
 	"genfity-order-services/pkg/response"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type basePrepCacheEntry struct {
@@ -181,6 +182,10 @@ func (h *Handler) PublicOrderWaitTime(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) getBasePrepMinutesCached(ctx context.Context, merchantID int64, orderType string) int {
+	return basePrepMinutesCached(ctx, h.DB, merchantID, orderType)
+}
+
+func basePrepMinutesCached(ctx context.Context, db *pgxpool.Pool, merchantID int64, orderType string) int {
 	key := fmt.Sprintf("%d:%s", merchantID, orderType)
 	basePrepCacheMu.Lock()
 	entry, ok := basePrepCache[key]
@@ -190,7 +195,7 @@ func (h *Handler) getBasePrepMinutesCached(ctx context.Context, merchantID int64
 	}
 	basePrepCacheMu.Unlock()
 
-	value := h.computeBasePrepMinutes(ctx, merchantID, orderType)
+	value := queryBasePrepMinutes(ctx, db, merchantID, orderType)
 
 	basePrepCacheMu.Lock()
 	basePrepCache[key] = basePrepCacheEntry{value: value, expiresAt: time.Now().Add(2 * time.Minute)}
@@ -203,6 +208,10 @@ func (h *Handler) getBasePrepMinutesCached(ctx context.Context, merchantID int64
 }
 
 func (h *Handler) computeBasePrepMinutes(ctx context.Context, merchantID int64, orderType string) int {
+	return queryBasePrepMinutes(ctx, h.DB, merchantID, orderType)
+}
+
+func queryBasePrepMinutes(ctx context.Context, db *pgxpool.Pool, merchantID int64, orderType string) int {
 	query := `
 		select placed_at, actual_ready_at, completed_at
 		from orders
@@ -214,7 +223,7 @@ func (h *Handler) computeBasePrepMinutes(ctx context.Context, merchantID int64,
 		limit 60
 	`
 
-	rows, err := h.DB.Query(ctx, query, merchantID, orderType)
+	rows, err := db.Query(ctx, query, merchantID, orderType)
 	if err != nil {
 		return 20
 	}
@@ -258,6 +267,10 @@ func (h *Handler) computeBasePrepMinutes(ctx context.Context, merchantID int64,
 }
 
 func (h *Handler) computeQueueAhead(ctx context.Context, merchantID int64, orderType string, placedAt time.Time) int {
+	return queryQueueAhead(ctx, h.DB, merchantID, orderType, placedAt)
+}
+
+func queryQueueAhead(ctx context.Context, db *pgxpool.Pool, merchantID int64, orderType string, placedAt time.Time) int {
 	query := `
 		select count(*)
 		from orders
@@ -268,7 +281,7 @@ func (h *Handler) computeQueueAhead(ctx context.Context, merchantID int64, order
 	`
 
 	var count int
-	if err := h.DB.QueryRow(ctx, query, merchantID, orderType, placedAt).Scan(&count); err != nil {
+	if err := db.QueryRow(ctx, query, merchantID, orderType, placedAt).Scan(&count); err != nil {
 		return 0
 	}
 	return count
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"genfity-order-services/internal/courier"
+	"genfity-order-services/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxCourierWebhookBytes = 64 << 10
+
+// PublicCourierWebhook receives status callbacks from courier providers. The
+// provider verifies the request signature; unknown bookings return 404 so
+// the provider stops retrying.
+func (h *Handler) PublicCourierWebhook(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.Couriers.Get(chi.URLParam(r, "provider"))
+	if !ok {
+		response.Error(w, http.StatusNotFound, "NOT_FOUND", "Courier provider not found")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCourierWebhookBytes))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	update, err := provider.ParseWebhook(r.Header, body)
+	if err != nil {
+		if errors.Is(err, courier.ErrInvalidSignature) {
+			response.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid webhook signature")
+			return
+		}
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid webhook payload")
+		return
+	}
+
+	applied, err := h.applyCourierStatus(r.Context(), provider.Name(), update)
+	if err != nil {
+		if errors.Is(err, courier.ErrBookingNotFound) {
+			response.Error(w, http.StatusNotFound, "NOT_FOUND", "Courier booking not found")
+			return
+		}
+		h.Logger.Error("courier webhook apply failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to apply courier status")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    map[string]any{"applied": applied},
+		"message": "Courier status received",
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"genfity-order-services/internal/utils"
 	"genfity-order-services/pkg/response"
@@ -15,13 +16,9 @@ import (
 )
 
 type deliveryQuoteRequest struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-}
-
-type deliveryQuoteZonePoint struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Subtotal  *float64 `json:"subtotal"`
 }
 
 func (h *Handler) PublicDeliveryQuote(w http.ResponseWriter, r *http.Request) {
@@ -42,112 +39,52 @@ func (h *Handler) PublicDeliveryQuote(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusBadRequest, "INVALID_COORDS", "Valid latitude and longitude are required")
 		return
 	}
+	if body.Subtotal != nil && (!deliveryIsFinite(*body.Subtotal) || *body.Subtotal < 0) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "subtotal must be 0 or greater")
+		return
+	}
 
-	var (
-		merchantID           int64
-		isActive             bool
-		isDeliveryEnabled    bool
-		enforceDeliveryZones bool
-		latitude             pgtype.Numeric
-		longitude            pgtype.Numeric
-		deliveryMaxDistance  pgtype.Numeric
-		deliveryFeeBase      pgtype.Numeric
-		deliveryFeePerKm     pgtype.Numeric
-		deliveryFeeMin       pgtype.Numeric
-		deliveryFeeMax       pgtype.Numeric
-	)
-
+	var config groupOrderDeliveryConfig
 	err := h.DB.QueryRow(ctx, `
 		select id, is_active, is_delivery_enabled, enforce_delivery_zones,
 		       latitude, longitude, delivery_max_distance_km,
-		       delivery_fee_base, delivery_fee_per_km, delivery_fee_min, delivery_fee_max
+		       delivery_fee_base, delivery_fee_per_km, delivery_fee_min, delivery_fee_max,
+		       timezone, features
 		from merchants
 		where code = $1
 	`, merchantCode).Scan(
-		&merchantID,
-		&isActive,
-		&isDeliveryEnabled,
-		&enforceDeliveryZones,
-		&latitude,
-		&longitude,
-		&deliveryMaxDistance,
-		&deliveryFeeBase,
-		&deliveryFeePerKm,
-		&deliveryFeeMin,
-		&deliveryFeeMax,
+		&config.MerchantID,
+		&config.IsActive,
+		&config.IsDeliveryEnabled,
+		&config.EnforceDeliveryZones,
+		&config.Latitude,
+		&config.Longitude,
+		&config.DeliveryMaxDistance,
+		&config.DeliveryFeeBase,
+		&config.DeliveryFeePerKm,
+		&config.DeliveryFeeMin,
+		&config.DeliveryFeeMax,
+		&config.Timezone,
+		&config.Features,
 	)
-	if err != nil || !isActive {
+	if err != nil || !config.IsActive {
 		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found or inactive")
 		return
 	}
 
-	if !isDeliveryEnabled {
-		response.Error(w, http.StatusBadRequest, "DELIVERY_NOT_ENABLED", "Delivery is not available for this merchant")
-		return
-	}
-
-	if !latitude.Valid || !longitude.Valid {
-		response.Error(w, http.StatusBadRequest, "MERCHANT_LOCATION_NOT_SET", "Merchant location is not configured for delivery")
+	breakdown, distanceKm, code, msg := h.evaluateMerchantDeliveryFee(ctx, config, body.Latitude, body.Longitude, body.Subtotal, time.Now())
+	if code != "" {
+		response.Error(w, http.StatusBadRequest, code, msg)
 		return
 	}
 
-	merchantLat := utils.NumericToFloat64(latitude)
-	merchantLng := utils.NumericToFloat64(longitude)
-
-	distanceKm := deliveryRound3(haversineDistanceKm(merchantLat, merchantLng, body.Latitude, body.Longitude))
-
-	if deliveryMaxDistance.Valid {
-		maxDistance := utils.NumericToFloat64(deliveryMaxDistance)
-		if distanceKm > maxDistance {
-			response.Error(w, http.StatusBadRequest, "OUT_OF_RANGE", "Delivery is only available within "+formatFloat(maxDistance)+" km")
-			return
-		}
-	}
-
-	if enforceDeliveryZones {
-		ok, err := h.validateDeliveryZones(ctx, merchantID, merchantLat, merchantLng, body.Latitude, body.Longitude)
-		if errors.Is(err, errNoZonesConfigured) {
-			response.Error(w, http.StatusBadRequest, "NO_ZONES_CONFIGURED", "Delivery zones are not properly configured")
-			return
-		}
-		if err != nil {
-			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Delivery validation failed")
-			return
-		}
-		if !ok {
-			response.Error(w, http.StatusBadRequest, "OUT_OF_ZONE", "Delivery is not available for this location")
-			return
-		}
-	}
-
-	feeBase := 0.0
-	if deliveryFeeBase.Valid {
-		feeBase = utils.NumericToFloat64(deliveryFeeBase)
-	}
-	feePerKm := 0.0
-	if deliveryFeePerKm.Valid {
-		feePerKm = utils.NumericToFloat64(deliveryFeePerKm)
-	}
-
-	fee := deliveryRound2(feeBase + feePerKm*distanceKm)
-	if deliveryFeeMin.Valid {
-		minFee := utils.NumericToFloat64(deliveryFeeMin)
-		if fee < minFee {
-			fee = minFee
-		}
-	}
-	if deliveryFeeMax.Valid {
-		maxFee := utils.NumericToFloat64(deliveryFeeMax)
-		if fee > maxFee {
-			fee = maxFee
-		}
-	}
-
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"data": map[string]any{
 			"distanceKm": distanceKm,
-			"feeAmount":  deliveryRound2(fee),
+			"feeAmount":  breakdown.Fee,
+			"breakdown":  breakdown,
+			"eta":        quoteDeliveryEta(ctx, h.DB, config, distanceKm),
 		},
 		"message": "Delivery fee calculated successfully",
 	})
@@ -155,35 +92,39 @@ func (h *Handler) PublicDeliveryQuote(w http.ResponseWriter, r *http.Request) {
 
 var errNoZonesConfigured = errors.New("no zones configured")
 
-func (h *Handler) validateDeliveryZones(ctx context.Context, merchantID int64, merchantLat, merchantLng, deliveryLat, deliveryLng float64) (bool, error) {
+// matchDeliveryZoneIDs returns the active zones containing the delivery
+// point, or errNoZonesConfigured when the merchant has no active zones.
+func (h *Handler) matchDeliveryZoneIDs(ctx context.Context, merchantID int64, merchantLat, merchantLng, deliveryLat, deliveryLng float64) ([]int64, error) {
 	type zoneRow struct {
+		ID       int64
 		Type     string
 		RadiusKm pgtype.Numeric
 		Polygon  []byte
 	}
 
 	rows, err := h.DB.Query(ctx, `
-		select type, radius_km, polygon
+		select id, type, radius_km, polygon
 		from merchant_delivery_zones
 		where merchant_id = $1 and is_active = true
 	`, merchantID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer rows.Close()
 
 	zones := make([]zoneRow, 0)
 	for rows.Next() {
 		var z zoneRow
-		if err := rows.Scan(&z.Type, &z.RadiusKm, &z.Polygon); err == nil {
+		if err := rows.Scan(&z.ID, &z.Type, &z.RadiusKm, &z.Polygon); err == nil {
 			zones = append(zones, z)
 		}
 	}
 
 	if len(zones) == 0 {
-		return false, errNoZonesConfigured
+		return nil, errNoZonesConfigured
 	}
 
+	matched := make([]int64, 0)
 	for _, zone := range zones {
 		switch zone.Type {
 		case "RADIUS":
@@ -191,27 +132,21 @@ func (h *Handler) validateDeliveryZones(ctx context.Context, merchantID int64, m
 				radius := utils.NumericToFloat64(zone.RadiusKm)
 				d := haversineDistanceKm(merchantLat, merchantLng, deliveryLat, deliveryLng)
 				if d <= radius {
-					return true, nil
+					matched = append(matched, zone.ID)
 				}
 			}
 		case "POLYGON":
-			if len(zone.Polygon) == 0 {
-				continue
-			}
-			var polygon []deliveryQuoteZonePoint
-			if err := json.Unmarshal(zone.Polygon, &polygon); err != nil {
-				continue
-			}
-			if len(polygon) < 3 {
+			geometry, err := decodeZoneGeometry(zone.Polygon)
+			if err != nil {
 				continue
 			}
-			if pointInPolygon(deliveryLat, deliveryLng, polygon) {
-				return true, nil
+			if zoneGeometryContains(geometry, deliveryLat, deliveryLng) {
+				matched = append(matched, zone.ID)
 			}
 		}
 	}
 
-	return false, nil
+	return matched, nil
 }
 
 func haversineDistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
@@ -232,7 +167,7 @@ func haversineDistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
 	return earthRadius * c
 }
 
-func pointInPolygon(lat, lng float64, polygon []deliveryQuoteZonePoint) bool {
+func pointInPolygon(lat, lng float64, polygon []deliveryZonePoint) bool {
 	inside := false
 	j := len(polygon) - 1
 	for i := 0; i < len(polygon); i++ {
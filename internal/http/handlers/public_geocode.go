@@ -3,46 +3,33 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"math"
 	"net"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
+	"genfity-order-services/internal/geocode"
+	"genfity-order-services/internal/utils"
 	"genfity-order-services/pkg/response"
-)
-
-type geocodeCachedValue struct {
-	Data      any
-	ExpiresAt time.Time
-}
-
-type geocodeRateLimitBucket struct {
-	Count   int
-	ResetAt time.Time
-}
 
-var (
-	geocodeCacheMu   sync.Mutex
-	geocodeCache     = map[string]geocodeCachedValue{}
-	geocodeRateMu    sync.Mutex
-	geocodeRateLimit = map[string]geocodeRateLimitBucket{}
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const (
-	geocodeCacheTTL     = 24 * time.Hour
-	geocodeRateWindow   = 60 * time.Second
-	geocodeRateLimitMax = 30
+	geocodeRateWindow    = 60 * time.Second
+	geocodeRateLimitMax  = 30
+	geocodeSearchLimit   = 5
+	geocodeMinBiasKm     = 15.0
+	geocodeMaxBiasKm     = 100.0
+	geocodeCacheSweepMax = 100
 )
 
 func (h *Handler) PublicGeocodeReverse(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	if limited, retryAfter := geocodeIsRateLimited(clientIP(r)); limited {
+	if limited, retryAfter := h.geocodeIsRateLimited(ctx, clientIP(r)); limited {
 		if retryAfter != "" {
 			w.Header().Set("Retry-After", retryAfter)
 		}
@@ -62,8 +49,9 @@ func (h *Handler) PublicGeocodeReverse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cacheKey := roundCoord(lat) + "," + roundCoord(lng)
-	if data, ok := geocodeGetCache(cacheKey); ok {
+	geocoder := h.geocoder()
+	cacheKey := "reverse:" + geocoder.Name() + ":" + roundCoord(lat) + "," + roundCoord(lng)
+	if data, ok := h.geocodeCacheGet(ctx, cacheKey); ok {
 		response.JSON(w, http.StatusOK, map[string]any{
 			"success":    true,
 			"data":       data,
@@ -73,26 +61,23 @@ func (h *Handler) PublicGeocodeReverse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	endpoint := "https://nominatim.openstreetmap.org/reverse?format=jsonv2&lat=" + urlQueryFloat(lat) + "&lon=" + urlQueryFloat(lng) + "&zoom=18&addressdetails=1"
-	resBody, err := fetchJSON(ctx, endpoint)
+	place, err := geocoder.Reverse(ctx, lat, lng)
 	if err != nil {
 		response.Error(w, http.StatusBadGateway, "UPSTREAM_ERROR", "Failed to resolve address")
 		return
 	}
 
-	address := getMap(resBody, "address")
-	parts := buildAddressParts(address)
+	parts := buildAddressParts(place.Address)
 	formatted := buildFormattedAddress(parts)
-	displayName := strings.TrimSpace(getString(resBody, "display_name"))
 
 	data := map[string]any{
-		"displayName":      displayName,
-		"address":          address,
-		"formattedAddress": defaultString(formatted, displayName),
+		"displayName":      place.DisplayName,
+		"address":          place.Address,
+		"formattedAddress": defaultString(formatted, place.DisplayName),
 		"parts":            parts,
 	}
 
-	geocodeSetCache(cacheKey, data)
+	h.geocodeCacheSet(ctx, cacheKey, geocoder.Name(), data)
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success":    true,
@@ -102,10 +87,12 @@ func (h *Handler) PublicGeocodeReverse(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// PublicGeocodeForward searches addresses. With merchantCode, results are
+// biased toward the merchant's country and a view box around the outlet.
 func (h *Handler) PublicGeocodeForward(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	if limited, retryAfter := geocodeIsRateLimited(clientIP(r)); limited {
+	if limited, retryAfter := h.geocodeIsRateLimited(ctx, clientIP(r)); limited {
 		if retryAfter != "" {
 			w.Header().Set("Retry-After", retryAfter)
 		}
@@ -124,8 +111,11 @@ func (h *Handler) PublicGeocodeForward(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cacheKey := strings.ToLower(q)
-	if data, ok := geocodeGetCache(cacheKey); ok {
+	bias := h.merchantGeocodeBias(ctx, strings.TrimSpace(r.URL.Query().Get("merchantCode")))
+
+	geocoder := h.geocoder()
+	cacheKey := "search:" + geocoder.Name() + ":" + bias.Key() + ":" + geocode.NormalizeQuery(q)
+	if data, ok := h.geocodeCacheGet(ctx, cacheKey); ok {
 		response.JSON(w, http.StatusOK, map[string]any{
 			"success":    true,
 			"data":       data,
@@ -135,37 +125,27 @@ func (h *Handler) PublicGeocodeForward(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	endpoint := "https://nominatim.openstreetmap.org/search?format=jsonv2&addressdetails=1&limit=5&q=" + urlQueryEscape(q)
-	resBody, err := fetchJSONArray(ctx, endpoint)
+	places, err := geocoder.Search(ctx, q, bias, geocodeSearchLimit)
 	if err != nil {
 		response.Error(w, http.StatusBadGateway, "UPSTREAM_ERROR", "Failed to search address")
 		return
 	}
 
-	results := make([]map[string]any, 0)
-	for _, row := range resBody {
-		rowMap, ok := row.(map[string]any)
-		if !ok {
-			continue
-		}
-		lat, okLat := parseFloat(rowMap["lat"])
-		lng, okLng := parseFloat(rowMap["lon"])
-		if !okLat || !okLng {
+	results := make([]map[string]any, 0, len(places))
+	for _, place := range places {
+		if !isFinite(place.Lat) || !isFinite(place.Lng) {
 			continue
 		}
-
-		address := getMap(rowMap, "address")
-		parts := buildAddressParts(address)
+		parts := buildAddressParts(place.Address)
 		formatted := buildFormattedAddress(parts)
-		displayName := strings.TrimSpace(getString(rowMap, "display_name"))
 
 		results = append(results, map[string]any{
-			"lat":              lat,
-			"lng":              lng,
-			"displayName":      displayName,
-			"formattedAddress": defaultString(formatted, displayName),
+			"lat":              place.Lat,
+			"lng":              place.Lng,
+			"displayName":      place.DisplayName,
+			"formattedAddress": defaultString(formatted, place.DisplayName),
 			"parts":            parts,
-			"raw":              address,
+			"raw":              place.Address,
 		})
 	}
 
@@ -173,7 +153,7 @@ func (h *Handler) PublicGeocodeForward(w http.ResponseWriter, r *http.Request) {
 		"query":   q,
 		"results": results,
 	}
-	geocodeSetCache(cacheKey, data)
+	h.geocodeCacheSet(ctx, cacheKey, geocoder.Name(), data)
 
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success":    true,
@@ -183,45 +163,169 @@ func (h *Handler) PublicGeocodeForward(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func geocodeGetCache(key string) (any, bool) {
-	geocodeCacheMu.Lock()
-	defer geocodeCacheMu.Unlock()
-	if cached, ok := geocodeCache[key]; ok {
-		if cached.ExpiresAt.After(time.Now()) {
-			return cached.Data, true
+func (h *Handler) geocoder() geocode.Geocoder {
+	if h.Geocoder != nil {
+		return h.Geocoder
+	}
+	return geocode.NewNominatim(h.Config.GeocoderBaseURL, "")
+}
+
+func (h *Handler) geocodeCacheGet(ctx context.Context, key string) (any, bool) {
+	var payload []byte
+	if err := h.DB.QueryRow(ctx, `
+		select payload from geocode_cache where cache_key = $1 and expires_at > now()
+	`, key).Scan(&payload); err != nil {
+		return nil, false
+	}
+	var data any
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// geocodeCacheSet stores a result and trims a bounded batch of expired rows,
+// so the table does not grow with one-off lookups.
+func (h *Handler) geocodeCacheSet(ctx context.Context, key, provider string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	ttl := h.Config.GeocodeCacheTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if _, err := h.DB.Exec(ctx, `
+		insert into geocode_cache (cache_key, provider, payload, expires_at)
+		values ($1, $2, $3, now() + make_interval(secs => $4))
+		on conflict (cache_key) do update
+		set provider = excluded.provider, payload = excluded.payload, expires_at = excluded.expires_at, created_at = now()
+	`, key, provider, payload, ttl.Seconds()); err != nil {
+		h.Logger.Warn("geocode cache write failed", zapError(err))
+		return
+	}
+	_, _ = h.DB.Exec(ctx, `
+		delete from geocode_cache
+		where cache_key in (select cache_key from geocode_cache where expires_at <= now() limit $1)
+	`, geocodeCacheSweepMax)
+}
+
+// merchantGeocodeBias builds the search bias for a merchant: its country and
+// a view box around the outlet sized by the delivery radius.
+func (h *Handler) merchantGeocodeBias(ctx context.Context, merchantCode string) geocode.Bias {
+	if merchantCode == "" {
+		return geocode.Bias{}
+	}
+	var (
+		country     pgtype.Text
+		latitude    pgtype.Numeric
+		longitude   pgtype.Numeric
+		maxDistance pgtype.Numeric
+	)
+	if err := h.DB.QueryRow(ctx, `
+		select country, latitude, longitude, delivery_max_distance_km
+		from merchants where code = $1 and is_active = true
+	`, merchantCode).Scan(&country, &latitude, &longitude, &maxDistance); err != nil {
+		return geocode.Bias{}
+	}
+
+	bias := geocode.Bias{}
+	if code := countryISOCode(country.String); code != "" {
+		bias.CountryCodes = []string{code}
+	}
+	if latitude.Valid && longitude.Valid {
+		radiusKm := geocodeMinBiasKm
+		if maxDistance.Valid {
+			radiusKm = math.Max(radiusKm, utils.NumericToFloat64(maxDistance))
 		}
-		delete(geocodeCache, key)
+		radiusKm = math.Min(radiusKm, geocodeMaxBiasKm)
+		bias.ViewBox = geocodeViewBox(utils.NumericToFloat64(latitude), utils.NumericToFloat64(longitude), radiusKm)
 	}
-	return nil, false
+	return bias
 }
 
-func geocodeSetCache(key string, data any) {
-	geocodeCacheMu.Lock()
-	defer geocodeCacheMu.Unlock()
-	geocodeCache[key] = geocodeCachedValue{Data: data, ExpiresAt: time.Now().Add(geocodeCacheTTL)}
+func geocodeViewBox(lat, lng, radiusKm float64) *geocode.ViewBox {
+	dLat := radiusKm / 111.32
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if cosLat < 0.01 {
+		cosLat = 0.01
+	}
+	dLng := radiusKm / (111.32 * cosLat)
+	return &geocode.ViewBox{
+		MinLng: math.Max(-180, lng-dLng),
+		MinLat: math.Max(-90, lat-dLat),
+		MaxLng: math.Min(180, lng+dLng),
+		MaxLat: math.Min(90, lat+dLat),
+	}
 }
 
-func geocodeIsRateLimited(ip string) (bool, string) {
-	geocodeRateMu.Lock()
-	defer geocodeRateMu.Unlock()
+var countryISOCodes = map[string]string{
+	"australia":      "au",
+	"indonesia":      "id",
+	"singapore":      "sg",
+	"malaysia":       "my",
+	"new zealand":    "nz",
+	"thailand":       "th",
+	"philippines":    "ph",
+	"vietnam":        "vn",
+	"japan":          "jp",
+	"south korea":    "kr",
+	"china":          "cn",
+	"hong kong":      "hk",
+	"taiwan":         "tw",
+	"india":          "in",
+	"united states":  "us",
+	"united kingdom": "gb",
+	"canada":         "ca",
+}
+
+// countryISOCode maps the merchant's country (stored as a name) to an ISO
+// 3166-1 alpha-2 code. Two-letter values are taken as codes already.
+func countryISOCode(country string) string {
+	value := strings.ToLower(strings.TrimSpace(country))
+	if len(value) == 2 {
+		return value
+	}
+	return countryISOCodes[value]
+}
+
+// geocodeIsRateLimited counts a request against the client's fixed window in
+// geocode_rate_limits and reports whether it is over the limit, with the
+// Retry-After seconds. The limiter fails open when the counter cannot be read.
+func (h *Handler) geocodeIsRateLimited(ctx context.Context, ip string) (bool, string) {
 	if ip == "" {
 		ip = "unknown"
 	}
 
-	now := time.Now()
-	bucket, ok := geocodeRateLimit[ip]
-	if !ok || now.After(bucket.ResetAt) {
-		geocodeRateLimit[ip] = geocodeRateLimitBucket{Count: 1, ResetAt: now.Add(geocodeRateWindow)}
+	var count int
+	var resetIn float64
+	if err := h.DB.QueryRow(ctx, `
+		insert into geocode_rate_limits as l (client_key, window_started_at, request_count)
+		values ($1, now(), 1)
+		on conflict (client_key) do update
+		set window_started_at = case when l.window_started_at <= now() - make_interval(secs => $2) then now() else l.window_started_at end,
+		    request_count = case when l.window_started_at <= now() - make_interval(secs => $2) then 1 else l.request_count + 1 end
+		returning request_count, extract(epoch from (window_started_at + make_interval(secs => $2) - now()))::float8
+	`, ip, geocodeRateWindow.Seconds()).Scan(&count, &resetIn); err != nil {
+		h.Logger.Warn("geocode rate limit check failed", zapError(err))
 		return false, ""
 	}
 
-	if bucket.Count >= geocodeRateLimitMax {
-		retry := int(math.Max(1, math.Ceil(bucket.ResetAt.Sub(now).Seconds())))
-		return true, strconv.Itoa(retry)
+	if count == 1 {
+		_, _ = h.DB.Exec(ctx, `
+			delete from geocode_rate_limits
+			where client_key in (
+				select client_key from geocode_rate_limits
+				where window_started_at <= now() - make_interval(secs => $1)
+				limit $2
+			)
+		`, geocodeRateWindow.Seconds(), geocodeCacheSweepMax)
 	}
 
-	bucket.Count++
-	geocodeRateLimit[ip] = bucket
+	if count > geocodeRateLimitMax {
+		retry := int(math.Max(1, math.Ceil(resetIn)))
+		return true, strconv.Itoa(retry)
+	}
 	return false, ""
 }
 
@@ -277,64 +381,6 @@ func isFinite(value float64) bool {
 	return !math.IsNaN(value) && !math.IsInf(value, 0)
 }
 
-func urlQueryEscape(value string) string {
-	return url.QueryEscape(value)
-}
-
-func urlQueryFloat(value float64) string {
-	return strconv.FormatFloat(value, 'f', -1, 64)
-}
-
-func fetchJSON(ctx context.Context, url string) (map[string]any, error) {
-	client := &http.Client{Timeout: 8 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Genfity Online Ordering (https://order.genfity.com)")
-	req.Header.Set("Accept", "application/json")
-
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return nil, fmt.Errorf("upstream_error")
-	}
-
-	var payload map[string]any
-	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
-}
-
-func fetchJSONArray(ctx context.Context, url string) ([]any, error) {
-	client := &http.Client{Timeout: 8 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Genfity Online Ordering (https://order.genfity.com)")
-	req.Header.Set("Accept", "application/json")
-
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return nil, fmt.Errorf("upstream_error")
-	}
-
-	var payload []any
-	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
-		return nil, err
-	}
-	return payload, nil
-}
-
 func getString(m map[string]any, key string) string {
 	if v, ok := m[key]; ok {
 		if s, ok := v.(string); ok {
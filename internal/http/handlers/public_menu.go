@@ -25,6 +25,7 @@ type publicMenuItem struct {
 	ImageURL       *string
 	ImageThumbURL  *string
 	ImageThumbMeta any
+	Image          any
 	IsActive       bool
 	IsSpicy        bool
 	IsBestSeller   bool
@@ -57,6 +58,10 @@ type publicAddonCategory struct {
 }
 
 func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
+	h.servePublicMenuCached(w, r, "menu", readPathString(r, "merchantCode"), h.publicMenu)
+}
+
+func (h *Handler) publicMenu(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	merchantCode := readPathString(r, "merchantCode")
 	if merchantCode == "" {
@@ -175,6 +180,7 @@ func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
 				m.ImageThumbMeta = meta
 			}
 		}
+		m.Image = publicMenuImage(m.ImageURL, m.ImageThumbURL, imageThumbMeta)
 		if stockQty.Valid {
 			value := stockQty.Int32
 			m.StockQty = &value
@@ -361,6 +367,28 @@ func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	variantMap, err := h.fetchMenuVariants(ctx, merchantID, menuIDs, true)
+	if err != nil {
+		h.Logger.Warn("public menu variants lookup failed", zapError(err))
+		variantMap = map[int64][]menuVariant{}
+	}
+
+	bundleMap, err := h.fetchMenuBundles(ctx, merchantID, menuIDs)
+	if err != nil {
+		h.Logger.Warn("public menu bundles lookup failed", zapError(err))
+		bundleMap = map[int64]*menuBundle{}
+	}
+
+	menuDietary, addonDietary := h.fetchPublicDietary(ctx, menuIDs, addonMap)
+
+	translator := h.loadContentTranslator(ctx, w, r, merchantID)
+	translator.translateMenuItems(menus)
+	translator.translateAddonCategories(addonMap)
+	for i := range categories {
+		categories[i].Name = translator.text(translationEntityMenuCategory, categories[i].ID, translationFieldName, categories[i].Name)
+		categories[i].Description = translator.textPtr(translationEntityMenuCategory, categories[i].ID, translationFieldDescription, categories[i].Description)
+	}
+
 	menuMap := make(map[int64]publicMenuItem)
 	for _, m := range menus {
 		menuMap[m.ID] = m
@@ -391,6 +419,7 @@ func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
 				"imageUrl":       menu.ImageURL,
 				"imageThumbUrl":  menu.ImageThumbURL,
 				"imageThumbMeta": menu.ImageThumbMeta,
+				"image":          menu.Image,
 				"isActive":       menu.IsActive,
 				"isPromo":        promoOk,
 				"isSpicy":        menu.IsSpicy,
@@ -405,6 +434,9 @@ func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
 				}(),
 				"trackStock": menu.TrackStock,
 				"stockQty":   menu.StockQty,
+				"variants":   variantPayloads(variantMap[menuID]),
+				"bundle":     bundleMap[menuID],
+				"dietary":    dietaryFor(menuDietary, menuID),
 				"addonCategories": func() []map[string]any {
 					addons := addonMap[menuID]
 					result := make([]map[string]any, 0, len(addons))
@@ -420,6 +452,7 @@ func (h *Handler) PublicMenu(w http.ResponseWriter, r *http.Request) {
 								"displayOrder": item.DisplayOrder,
 								"trackStock":   item.TrackStock,
 								"stockQty":     item.StockQty,
+								"dietary":      dietaryFor(addonDietary, item.ID),
 							})
 						}
 						result = append(result, map[string]any{
@@ -177,6 +177,22 @@ func (h *Handler) PublicMerchantMenuAddons(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	addonItemIDs := make([]int64, 0)
+	for _, cat := range categories {
+		for _, addon := range cat.Addons {
+			addonItemIDs = append(addonItemIDs, addon["id"].(int64))
+		}
+	}
+	dietary, err := h.fetchDietaryAttributes(ctx, dietaryTableAddonItems, addonItemIDs)
+	if err != nil {
+		h.Logger.Warn("public addon dietary lookup failed", zapError(err))
+	}
+	for _, cat := range categories {
+		for _, addon := range cat.Addons {
+			addon["dietary"] = dietaryFor(dietary, addon["id"].(int64))
+		}
+	}
+
 	payload := make([]map[string]any, 0, len(categories))
 	for _, cat := range categories {
 		payload = append(payload, map[string]any{
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Public menu responses are cached per merchant and menu version. The version
+// lives in merchant_menu_versions and is bumped by this service's own write
+// paths: BumpMenuCacheOnWrite after merchant menu, category, addon,
+// special-price, settings and manual stock writes, and bumpMenuCacheVersion
+// inside catalog publishes, branch syncs and daily stock resets. Each bump is
+// sent on menuCacheChannel so every replica drops that merchant's entries.
+// Order stock decrements do not bump it, so cached stock can trail by up to
+// the TTL; checkout re-checks stock and the stock stream is live. The TTL also
+// bounds content that changes with the clock (special price windows,
+// subscription state, best sellers) and writes made outside this service.
+const (
+	menuCacheChannel          = "menu_cache_updates"
+	publicMenuCacheTTL        = time.Minute
+	publicMenuCacheMaxEntries = 2000
+	publicMenuCacheControl    = "public, max-age=0, must-revalidate"
+)
+
+type publicMenuCacheEntry struct {
+	merchantID int64
+	version    int64
+	etag       string
+	header     http.Header
+	body       []byte
+	expiresAt  time.Time
+}
+
+var (
+	publicMenuCacheMu sync.Mutex
+	publicMenuCache   = map[string]publicMenuCacheEntry{}
+	// Latest version seen on the notify channel, so a render that raced a
+	// write is not stored under an already superseded version.
+	publicMenuVersions = map[int64]int64{}
+	// Entries are only stored while the listener is connected; without it
+	// another replica's writes would go unnoticed until the TTL.
+	publicMenuCacheLive bool
+)
+
+func publicMenuCacheKey(route, code string, r *http.Request) string {
+	return strings.Join([]string{
+		route,
+		code,
+		r.URL.RawQuery,
+		r.Header.Get("Accept-Language"),
+	}, "|")
+}
+
+func getPublicMenuCache(key string) (publicMenuCacheEntry, bool) {
+	publicMenuCacheMu.Lock()
+	defer publicMenuCacheMu.Unlock()
+
+	entry, ok := publicMenuCache[key]
+	if !ok {
+		return publicMenuCacheEntry{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(publicMenuCache, key)
+		return publicMenuCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func setPublicMenuCache(key string, entry publicMenuCacheEntry) {
+	publicMenuCacheMu.Lock()
+	defer publicMenuCacheMu.Unlock()
+
+	if !publicMenuCacheLive || entry.version < publicMenuVersions[entry.merchantID] {
+		return
+	}
+	entry.expiresAt = time.Now().Add(publicMenuCacheTTL)
+	publicMenuCache[key] = entry
+	if len(publicMenuCache) > publicMenuCacheMaxEntries {
+		publicMenuCache = map[string]publicMenuCacheEntry{}
+	}
+}
+
+func invalidatePublicMenuCache(merchantID, version int64) {
+	publicMenuCacheMu.Lock()
+	defer publicMenuCacheMu.Unlock()
+
+	if version > publicMenuVersions[merchantID] {
+		publicMenuVersions[merchantID] = version
+	}
+	for key, entry := range publicMenuCache {
+		if entry.merchantID == merchantID && entry.version < version {
+			delete(publicMenuCache, key)
+		}
+	}
+}
+
+func setPublicMenuCacheLive(live bool) {
+	publicMenuCacheMu.Lock()
+	defer publicMenuCacheMu.Unlock()
+
+	publicMenuCacheLive = live
+	publicMenuCache = map[string]publicMenuCacheEntry{}
+}
+
+func publicMenuETag(merchantID, version int64, body []byte) string {
+	sum := sha1.Sum(body)
+	return fmt.Sprintf("\"m%d-v%d-%s\"", merchantID, version, hex.EncodeToString(sum[:8]))
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison RFC 9110 prescribes for GET.
+func etagMatches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+func parseMenuCacheNotification(payload string) (merchantID, version int64, ok bool) {
+	idText, versionText, found := strings.Cut(strings.TrimSpace(payload), ":")
+	if !found {
+		return 0, 0, false
+	}
+	merchantID, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil || merchantID <= 0 {
+		return 0, 0, false
+	}
+	version, err = strconv.ParseInt(versionText, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, 0, false
+	}
+	return merchantID, version, true
+}
+
+type publicMenuRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *publicMenuRecorder) Header() http.Header { return rec.header }
+
+func (rec *publicMenuRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *publicMenuRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(p)
+}
+
+func writePublicMenuResponse(w http.ResponseWriter, r *http.Request, status int, header http.Header, etag string, body []byte) {
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	if status == http.StatusOK && etag != "" {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", publicMenuCacheControl)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// servePublicMenuCached answers a public menu endpoint from the response cache
+// when possible, otherwise renders it with render and caches successful
+// responses under the merchant's current menu version.
+func (h *Handler) servePublicMenuCached(w http.ResponseWriter, r *http.Request, route, code string, render http.HandlerFunc) {
+	if strings.TrimSpace(code) == "" {
+		render(w, r)
+		return
+	}
+
+	key := publicMenuCacheKey(route, code, r)
+	if entry, ok := getPublicMenuCache(key); ok {
+		writePublicMenuResponse(w, r, http.StatusOK, entry.header, entry.etag, entry.body)
+		return
+	}
+
+	var merchantID, version int64
+	if err := h.DB.QueryRow(r.Context(), `
+		select m.id, coalesce(v.version, 0)
+		from merchants m
+		left join merchant_menu_versions v on v.merchant_id = m.id
+		where m.code = $1
+	`, code).Scan(&merchantID, &version); err != nil {
+		render(w, r)
+		return
+	}
+
+	rec := &publicMenuRecorder{header: http.Header{}}
+	render(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	body := rec.body.Bytes()
+	if rec.status != http.StatusOK {
+		writePublicMenuResponse(w, r, rec.status, rec.header, "", body)
+		return
+	}
+
+	etag := publicMenuETag(merchantID, version, body)
+	setPublicMenuCache(key, publicMenuCacheEntry{
+		merchantID: merchantID,
+		version:    version,
+		etag:       etag,
+		header:     rec.header.Clone(),
+		body:       append([]byte(nil), body...),
+	})
+	writePublicMenuResponse(w, r, http.StatusOK, rec.header, etag, body)
+}
+
+type menuCacheExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// bumpMenuCacheVersion moves the merchant to a new menu version and notifies
+// every replica. Inside a transaction the notification is only sent on commit.
+func bumpMenuCacheVersion(ctx context.Context, db menuCacheExecer, merchantID int64) error {
+	_, err := db.Exec(ctx, `
+		with bumped as (
+			insert into merchant_menu_versions (merchant_id, version, updated_at)
+			values ($1, 1, now())
+			on conflict (merchant_id) do update
+			set version = merchant_menu_versions.version + 1, updated_at = now()
+			returning merchant_id, version
+		)
+		select pg_notify($2, merchant_id::text || ':' || version::text) from bumped
+	`, merchantID, menuCacheChannel)
+	return err
+}
+
+// BumpMenuCacheOnWrite bumps the authenticated merchant's menu version when a
+// write request succeeds. The bump happens before the status is sent, after
+// the handler has committed, so a client that refetches the public menu on
+// success never gets the previous version back from the cache.
+func (h *Handler) BumpMenuCacheOnWrite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authCtx, ok := middleware.GetAuthContext(r.Context())
+		if !ok || authCtx.MerchantID == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&menuCacheBumpWriter{ResponseWriter: w, bump: func() {
+			if err := bumpMenuCacheVersion(r.Context(), h.DB, *authCtx.MerchantID); err != nil && h.Logger != nil {
+				h.Logger.Warn("menu cache version bump failed", zapError(err))
+			}
+		}}, r)
+	})
+}
+
+type menuCacheBumpWriter struct {
+	http.ResponseWriter
+	bump        func()
+	wroteHeader bool
+}
+
+func (w *menuCacheBumpWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status >= 200 && status < 300 {
+			w.bump()
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *menuCacheBumpWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so the
+// merchant proxy can still flush streamed responses.
+func (w *menuCacheBumpWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RunMenuCacheListener keeps the public menu cache coherent across replicas by
+// listening for menu version bumps. The cache is emptied and disabled while the
+// connection is down.
+func (h *Handler) RunMenuCacheListener(ctx context.Context) {
+	if h.DB == nil {
+		return
+	}
+
+	backoff := time.Second
+	for {
+		if ctx.Err() != nil {
+			setPublicMenuCacheLive(false)
+			return
+		}
+
+		conn, err := h.DB.Acquire(ctx)
+		if err == nil {
+			if _, err = conn.Exec(ctx, "listen "+menuCacheChannel); err != nil {
+				conn.Release()
+			}
+		}
+		if err != nil {
+			if h.Logger != nil {
+				h.Logger.Warn("menu cache LISTEN failed", zapError(err))
+			}
+			select {
+			case <-ctx.Done():
+				setPublicMenuCacheLive(false)
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+
+		backoff = time.Second
+		setPublicMenuCacheLive(true)
+		for {
+			n, err := conn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				break
+			}
+			if merchantID, version, ok := parseMenuCacheNotification(n.Payload); ok {
+				invalidatePublicMenuCache(merchantID, version)
+			}
+		}
+		setPublicMenuCacheLive(false)
+		conn.Release()
+	}
+}
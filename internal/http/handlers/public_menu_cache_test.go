@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"m7-v3-abc"`
+	cases := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"empty", "", false},
+		{"exact", `"m7-v3-abc"`, true},
+		{"weak", `W/"m7-v3-abc"`, true},
+		{"in list", `"m7-v2-xyz", "m7-v3-abc"`, true},
+		{"wildcard", "*", true},
+		{"older version", `"m7-v2-abc"`, false},
+		{"unquoted", "m7-v3-abc", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := etagMatches(tc.ifNoneMatch, etag); got != tc.want {
+				t.Fatalf("match %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMenuCacheNotification(t *testing.T) {
+	cases := []struct {
+		payload     string
+		wantID      int64
+		wantVersion int64
+		wantOK      bool
+	}{
+		{"12:4", 12, 4, true},
+		{" 12:4\n", 12, 4, true},
+		{"12", 0, 0, false},
+		{"x:4", 0, 0, false},
+		{"12:0", 0, 0, false},
+		{"0:3", 0, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.payload, func(t *testing.T) {
+			id, version, ok := parseMenuCacheNotification(tc.payload)
+			if id != tc.wantID || version != tc.wantVersion || ok != tc.wantOK {
+				t.Fatalf("got %d/%d/%v, want %d/%d/%v", id, version, ok, tc.wantID, tc.wantVersion, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestPublicMenuCacheVersions(t *testing.T) {
+	setPublicMenuCacheLive(true)
+	defer setPublicMenuCacheLive(false)
+
+	setPublicMenuCache("a", publicMenuCacheEntry{merchantID: 91, version: 2})
+	if _, ok := getPublicMenuCache("a"); !ok {
+		t.Fatal("entry not cached")
+	}
+	invalidatePublicMenuCache(91, 3)
+	if _, ok := getPublicMenuCache("a"); ok {
+		t.Fatal("entry survived a newer version")
+	}
+	setPublicMenuCache("b", publicMenuCacheEntry{merchantID: 91, version: 2})
+	if _, ok := getPublicMenuCache("b"); ok {
+		t.Fatal("stored a render older than the known version")
+	}
+}
+
+func TestMenuCacheBumpWriter(t *testing.T) {
+	cases := []struct {
+		name      string
+		write     func(w http.ResponseWriter)
+		wantBumps int
+	}{
+		{name: "explicit 200", write: func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) }, wantBumps: 1},
+		{name: "implicit 200", write: func(w http.ResponseWriter) { _, _ = w.Write([]byte("{}")) }, wantBumps: 1},
+		{name: "201 then body", write: func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("{}"))
+		}, wantBumps: 1},
+		{name: "validation error", write: func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadRequest) }, wantBumps: 0},
+		{name: "server error", write: func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) }, wantBumps: 0},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		bumps := 0
+		tc.write(&menuCacheBumpWriter{ResponseWriter: rec, bump: func() {
+			if rec.Code != http.StatusOK || rec.Body.Len() > 0 {
+				t.Errorf("%s: expected the bump before the response is written", tc.name)
+			}
+			bumps++
+		}})
+		if bumps != tc.wantBumps {
+			t.Errorf("%s: expected %d bumps, got %d", tc.name, tc.wantBumps, bumps)
+		}
+	}
+}
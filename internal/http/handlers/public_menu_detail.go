@@ -92,6 +92,21 @@ func (h *Handler) PublicMerchantMenuDetail(w http.ResponseWriter, r *http.Reques
 		promoPrice = &value
 	}
 
+	variantMap, err := h.fetchMenuVariants(ctx, merchantID, []int64{menuID}, true)
+	if err != nil {
+		h.Logger.Warn("public menu variants lookup failed", zapError(err))
+	}
+
+	bundleMap, err := h.fetchMenuBundles(ctx, merchantID, []int64{menuID})
+	if err != nil {
+		h.Logger.Warn("public menu bundles lookup failed", zapError(err))
+	}
+
+	dietary, err := h.fetchDietaryAttributes(ctx, dietaryTableMenus, []int64{menuID})
+	if err != nil {
+		h.Logger.Warn("public menu dietary lookup failed", zapError(err))
+	}
+
 	payload := map[string]any{
 		"id":             menuID,
 		"name":           menuName,
@@ -100,6 +115,7 @@ func (h *Handler) PublicMerchantMenuDetail(w http.ResponseWriter, r *http.Reques
 		"imageUrl":       nullIfEmptyText(imageURL),
 		"imageThumbUrl":  nullIfEmptyText(imageThumbURL),
 		"imageThumbMeta": decodeJSONMeta(imageThumbMeta),
+		"image":          publicMenuImage(textPtr(imageURL), textPtr(imageThumbURL), imageThumbMeta),
 		"stockQty": func() any {
 			if stockQty.Valid {
 				return stockQty.Int32
@@ -118,6 +134,9 @@ func (h *Handler) PublicMerchantMenuDetail(w http.ResponseWriter, r *http.Reques
 		"updatedAt":  updatedAt,
 		"isPromo":    promoPrice != nil,
 		"promoPrice": promoPrice,
+		"variants":   variantPayloads(variantMap[menuID]),
+		"bundle":     bundleMap[menuID],
+		"dietary":    dietaryFor(dietary, menuID),
 	}
 
 	response.JSON(w, http.StatusOK, map[string]any{
@@ -24,6 +24,10 @@ type merchantOpeningHour struct {
 }
 
 func (h *Handler) PublicMerchant(w http.ResponseWriter, r *http.Request) {
+	h.servePublicMenuCached(w, r, "merchant", readPathString(r, "code"), h.publicMerchant)
+}
+
+func (h *Handler) publicMerchant(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	merchantCode := readPathString(r, "code")
 	if merchantCode == "" {
@@ -16,6 +16,10 @@ type publicMerchantCategory struct {
 }
 
 func (h *Handler) PublicMerchantCategories(w http.ResponseWriter, r *http.Request) {
+	h.servePublicMenuCached(w, r, "categories", readPathString(r, "code"), h.publicMerchantCategories)
+}
+
+func (h *Handler) publicMerchantCategories(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	merchantCode := readPathString(r, "code")
 	if merchantCode == "" {
@@ -68,6 +72,12 @@ func (h *Handler) PublicMerchantCategories(w http.ResponseWriter, r *http.Reques
 		  and not exists (select 1 from menu_category_items mci where mci.menu_id = m.id)
 	`, merchantID).Scan(&uncategorizedCount)
 
+	translator := h.loadContentTranslator(ctx, w, r, merchantID)
+	for i := range categories {
+		categories[i].Name = translator.text(translationEntityMenuCategory, categories[i].ID, translationFieldName, categories[i].Name)
+		categories[i].Description = translator.textPtr(translationEntityMenuCategory, categories[i].ID, translationFieldDescription, categories[i].Description)
+	}
+
 	final := make([]map[string]any, 0, len(categories)+1)
 	maxSortOrder := int32(0)
 	for _, c := range categories {
@@ -143,6 +143,37 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 		maxPrice = &parsed
 	}
 
+	excludeAllergens, err := splitDietaryParam(r.URL.Query().Get("excludeAllergens"), dietaryAllergens, "allergen")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	requiredTags, err := splitDietaryParam(r.URL.Query().Get("dietary"), dietaryTags, "dietary tag")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	var maxSpicyLevel *int
+	if raw := strings.TrimSpace(r.URL.Query().Get("maxSpicyLevel")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > dietaryMaxSpicyLevel {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid maxSpicyLevel")
+			return
+		}
+		maxSpicyLevel = &parsed
+	}
+
+	var maxCalories *int
+	if raw := strings.TrimSpace(r.URL.Query().Get("maxCalories")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid maxCalories")
+			return
+		}
+		maxCalories = &parsed
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	if limit <= 0 {
 		limit = 20
@@ -151,9 +182,14 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 		limit = 100
 	}
 
-	var merchantID int64
+	var merchantID, menuVersion int64
 	var merchantActive bool
-	if err := h.DB.QueryRow(ctx, `select id, is_active from merchants where code = $1`, merchantCode).Scan(&merchantID, &merchantActive); err != nil {
+	if err := h.DB.QueryRow(ctx, `
+		select m.id, m.is_active, coalesce(v.version, 0)
+		from merchants m
+		left join merchant_menu_versions v on v.merchant_id = m.id
+		where m.code = $1
+	`, merchantCode).Scan(&merchantID, &merchantActive, &menuVersion); err != nil {
 		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found or inactive")
 		return
 	}
@@ -162,8 +198,23 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	where := `m.merchant_id = $1 and m.is_active = true and m.deleted_at is null`
-	args := []any{merchantID}
+	index, err := h.menuSearchIndexFor(ctx, merchantID, menuVersion)
+	if err != nil {
+		h.Logger.Error("menu search index build failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "SEARCH_ERROR", "Failed to search menus")
+		return
+	}
+	scores := index.search(query)
+	if len(scores) == 0 {
+		h.recordMenuSearchMiss(ctx, merchantID, query)
+	}
+	matchedIDs := make([]int64, 0, len(scores))
+	for id := range scores {
+		matchedIDs = append(matchedIDs, id)
+	}
+
+	where := `m.merchant_id = $1 and m.is_active = true and m.deleted_at is null and m.id = any($2)`
+	args := []any{merchantID, matchedIDs}
 	if categoryParam != "" && categoryParam != "all" {
 		parsed, err := strconv.ParseInt(categoryParam, 10, 64)
 		if err != nil || parsed <= 0 {
@@ -181,12 +232,30 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 		where += " and m.price <= $" + strconv.Itoa(len(args)+1)
 		args = append(args, *maxPrice)
 	}
+	if len(excludeAllergens) > 0 {
+		where += " and not (coalesce(md.allergens, '{}') && $" + strconv.Itoa(len(args)+1) + "::text[])"
+		args = append(args, excludeAllergens)
+	}
+	if len(requiredTags) > 0 {
+		where += " and coalesce(md.dietary_tags, '{}') @> $" + strconv.Itoa(len(args)+1) + "::text[]"
+		args = append(args, requiredTags)
+	}
+	if maxSpicyLevel != nil {
+		where += " and coalesce(md.spicy_level, 0) <= $" + strconv.Itoa(len(args)+1)
+		args = append(args, *maxSpicyLevel)
+	}
+	if maxCalories != nil {
+		// Menus without a calorie count cannot be shown to match.
+		where += " and md.calories <= $" + strconv.Itoa(len(args)+1)
+		args = append(args, *maxCalories)
+	}
 
 	rows, err := h.DB.Query(ctx, `
 		select m.id, m.name, m.description, m.price, m.image_url, m.image_thumb_url, m.image_thumb_meta,
 		       m.is_active, m.is_spicy, m.is_best_seller, m.is_signature, m.is_recommended,
 		       m.track_stock, m.stock_qty
 		from menus m
+		left join menu_dietary_attributes md on md.menu_id = m.id
 		where `+where, args...)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "SEARCH_ERROR", "Failed to search menus")
@@ -241,6 +310,7 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 				m.ImageThumbMeta = meta
 			}
 		}
+		m.Image = publicMenuImage(m.ImageURL, m.ImageThumbURL, imageThumbMeta)
 		if stockQty.Valid {
 			value := stockQty.Int32
 			m.StockQty = &value
@@ -307,6 +377,15 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	dietary, err := h.fetchDietaryAttributes(ctx, dietaryTableMenus, menuIDs)
+	if err != nil {
+		h.Logger.Warn("menu search dietary lookup failed", zapError(err))
+	}
+
+	translator := h.loadContentTranslator(ctx, w, r, merchantID)
+	translator.translateMenuItems(menus)
+	translator.translateCategoryRefs(menuCategories)
+
 	type scoredMenu struct {
 		payload      map[string]any
 		score        float64
@@ -318,7 +397,9 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 	}
 	results := make([]scoredMenu, 0, len(menus))
 	for _, menu := range menus {
-		score := calculateRelevance(query, menu.Name, menu.Description)
+		// Scores come from the index, which also matches source text,
+		// every translation, keywords and synonyms.
+		score := scores[menu.ID]
 		promo, promoOk := promoMap[menu.ID]
 		var promoPtr *float64
 		if promoOk {
@@ -334,6 +415,7 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 				"imageUrl":       menu.ImageURL,
 				"imageThumbUrl":  menu.ImageThumbURL,
 				"imageThumbMeta": menu.ImageThumbMeta,
+				"image":          menu.Image,
 				"isActive":       menu.IsActive,
 				"isPromo":        promoOk,
 				"promoPrice": func() any {
@@ -349,6 +431,7 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 				"trackStock":     menu.TrackStock,
 				"stockQty":       menu.StockQty,
 				"categories":     menuCategories[menu.ID],
+				"dietary":        dietaryFor(dietary, menu.ID),
 				"relevanceScore": score,
 			},
 			price:        menu.Price,
@@ -361,7 +444,7 @@ func (h *Handler) PublicMerchantMenuSearch(w http.ResponseWriter, r *http.Reques
 
 	filtered := make([]scoredMenu, 0, len(results))
 	for _, item := range results {
-		if item.score > 20 {
+		if item.score > menuSearchMinScore {
 			filtered = append(filtered, item)
 		}
 	}
@@ -14,6 +14,10 @@ import (
 )
 
 func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
+	h.servePublicMenuCached(w, r, "menus", readPathString(r, "code"), h.publicMerchantMenus)
+}
+
+func (h *Handler) publicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	merchantCode := readPathString(r, "code")
 	if merchantCode == "" {
@@ -120,6 +124,7 @@ func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 				m.ImageThumbMeta = meta
 			}
 		}
+		m.Image = publicMenuImage(m.ImageURL, m.ImageThumbURL, imageThumbMeta)
 		if stockQty.Valid {
 			value := stockQty.Int32
 			m.StockQty = &value
@@ -325,6 +330,13 @@ func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	menuDietary, addonDietary := h.fetchPublicDietary(ctx, menuIDs, addonMap)
+
+	translator := h.loadContentTranslator(ctx, w, r, merchantID)
+	translator.translateMenuItems(menus)
+	translator.translateAddonCategories(addonMap)
+	translator.translateCategoryRefs(menuCategories)
+
 	formatted := make([]map[string]any, 0, len(menus))
 	for _, menu := range menus {
 		promo, promoOk := promoMap[menu.ID]
@@ -343,6 +355,7 @@ func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 					"trackStock":   item.TrackStock,
 					"stockQty":     item.StockQty,
 					"isActive":     true,
+					"dietary":      dietaryFor(addonDietary, item.ID),
 				})
 			}
 			addonPayloads = append(addonPayloads, map[string]any{
@@ -364,6 +377,7 @@ func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 			"imageUrl":       menu.ImageURL,
 			"imageThumbUrl":  menu.ImageThumbURL,
 			"imageThumbMeta": menu.ImageThumbMeta,
+			"image":          menu.Image,
 			"isActive":       menu.IsActive,
 			"isPromo":        promoOk,
 			"isSpicy":        menu.IsSpicy,
@@ -381,6 +395,7 @@ func (h *Handler) PublicMerchantMenus(w http.ResponseWriter, r *http.Request) {
 			"stockQty":        menu.StockQty,
 			"categories":      menuCategories[menu.ID],
 			"addonCategories": addonPayloads,
+			"dietary":         dietaryFor(menuDietary, menu.ID),
 		})
 	}
 
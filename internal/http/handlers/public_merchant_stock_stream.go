@@ -77,6 +77,9 @@ func (h *Handler) PublicMerchantStockStream(w http.ResponseWriter, r *http.Reque
 		flusher.Flush()
 	}
 
+	var lastResetRunID int64
+	_ = h.DB.QueryRow(ctx, `select coalesce(max(id), 0) from stock_reset_runs where merchant_id = $1`, merchantID).Scan(&lastResetRunID)
+
 	keepAliveTicker := time.NewTicker(stockKeepAliveInterval)
 	stockTicker := time.NewTicker(stockCheckInterval)
 	defer keepAliveTicker.Stop()
@@ -90,6 +93,13 @@ func (h *Handler) PublicMerchantStockStream(w http.ResponseWriter, r *http.Reque
 			_, _ = fmt.Fprintf(w, ": keep-alive\n\n")
 			flusher.Flush()
 		case <-stockTicker.C:
+			for _, run := range h.fetchStockResetRunsSince(ctx, merchantID, lastResetRunID) {
+				lastResetRunID = run.ID
+				if payload, err := json.Marshal(run); err == nil {
+					_, _ = fmt.Fprintf(w, "event: stock-reset\ndata: %s\n\n", payload)
+					flusher.Flush()
+				}
+			}
 			changes, err := h.getStockChanges(ctx, merchantID, previous)
 			if err != nil {
 				continue
@@ -104,6 +114,31 @@ func (h *Handler) PublicMerchantStockStream(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// fetchStockResetRunsSince returns the merchant's stock resets logged after
+// afterID, without their item lists.
+func (h *Handler) fetchStockResetRunsSince(ctx context.Context, merchantID int64, afterID int64) []stockResetRun {
+	rows, err := h.DB.Query(ctx, `
+		select id, trigger, item_count, created_at
+		from stock_reset_runs
+		where merchant_id = $1 and id > $2
+		order by id asc
+	`, merchantID, afterID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	runs := make([]stockResetRun, 0)
+	for rows.Next() {
+		var run stockResetRun
+		if err := rows.Scan(&run.ID, &run.Trigger, &run.ItemCount, &run.CreatedAt); err != nil {
+			return runs
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
 func (h *Handler) fetchStockData(ctx context.Context, merchantID int64) ([]stockUpdate, error) {
 	rows, err := h.DB.Query(ctx, `
 		select id, stock_qty, track_stock
@@ -1,16 +1,17 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"genfity-order-services/internal/utils"
 	"genfity-order-services/pkg/response"
-
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// PublicMerchantRecommendations suggests menus for a cart given as
+// ?menuIds=. Without a cart it returns what is popular at the merchant's
+// current local hour.
 func (h *Handler) PublicMerchantRecommendations(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	merchantCode := readPathString(r, "code")
@@ -19,14 +20,8 @@ func (h *Handler) PublicMerchantRecommendations(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	menuIdsParam := strings.TrimSpace(r.URL.Query().Get("menuIds"))
-	if menuIdsParam == "" {
-		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "menuIds parameter is required")
-		return
-	}
-
 	menuIDs := make([]int64, 0)
-	for _, raw := range strings.Split(menuIdsParam, ",") {
+	for _, raw := range strings.Split(r.URL.Query().Get("menuIds"), ",") {
 		value := strings.TrimSpace(raw)
 		if value == "" {
 			continue
@@ -39,70 +34,49 @@ func (h *Handler) PublicMerchantRecommendations(w http.ResponseWriter, r *http.R
 		menuIDs = append(menuIDs, id)
 	}
 
-	if len(menuIDs) == 0 {
-		response.JSON(w, http.StatusOK, map[string]any{
-			"success": true,
-			"data":    []any{},
-		})
-		return
+	limit := parseIntQuery(r, "limit", defaultRecommendationLimit)
+	if limit < 1 || limit > maxRecommendationLimit {
+		limit = defaultRecommendationLimit
 	}
 
-	var merchantID int64
-	if err := h.DB.QueryRow(ctx, `select id from merchants where code = $1`, merchantCode).Scan(&merchantID); err != nil {
+	var (
+		merchantID int64
+		timezone   string
+	)
+	if err := h.DB.QueryRow(ctx, `select id, timezone from merchants where code = $1`, merchantCode).Scan(&merchantID, &timezone); err != nil {
 		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found")
 		return
 	}
 
-	rows, err := h.DB.Query(ctx, `
-		select oi2.menu_id, oi2.menu_name, m.image_url, m.price, count(distinct oi2.order_id) as frequency
-		from order_items oi1
-		join order_items oi2 on oi1.order_id = oi2.order_id
-		join orders o on oi1.order_id = o.id
-		join menus m on oi2.menu_id = m.id
-		where o.merchant_id = $1
-		  and o.status in ('ACCEPTED', 'COMPLETED', 'READY')
-		  and oi1.menu_id = any($2)
-		  and not (oi2.menu_id = any($2))
-		  and m.is_active = true
-		  and m.deleted_at is null
-		  and (m.track_stock = false or m.stock_qty is null or m.stock_qty > 0)
-		group by oi2.menu_id, oi2.menu_name, m.image_url, m.price
-		order by frequency desc
-		limit 5
-	`, merchantID, menuIDs)
+	recommendations, err := h.recommendMenus(ctx, merchantID, loadTimezone(timezoneOrDefault(timezone)), menuIDs, limit)
 	if err != nil {
+		h.Logger.Error("recommendations query failed", zapError(err))
 		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch recommendations")
 		return
 	}
-	defer rows.Close()
 
-	results := make([]map[string]any, 0)
-	for rows.Next() {
-		var (
-			menuID    int64
-			menuName  string
-			imageURL  pgtype.Text
-			menuPrice pgtype.Numeric
-			frequency int64
-		)
-		if err := rows.Scan(&menuID, &menuName, &imageURL, &menuPrice, &frequency); err != nil {
-			continue
-		}
-		item := map[string]any{
-			"id":        strconv.FormatInt(menuID, 10),
-			"name":      menuName,
-			"price":     utils.NumericToFloat64(menuPrice),
-			"imageUrl":  nullIfEmptyText(imageURL),
-			"frequency": frequency,
-		}
-		results = append(results, item)
+	results := make([]map[string]any, 0, len(recommendations))
+	for _, rec := range recommendations {
+		results = append(results, map[string]any{
+			"id":        strconv.FormatInt(rec.MenuID, 10),
+			"name":      rec.Name,
+			"price":     rec.Price,
+			"imageUrl":  nullIfEmptyText(rec.ImageURL),
+			"frequency": rec.Support,
+			"score":     math.Round(rec.Score*1000) / 1000,
+			"reason":    rec.Reason,
+		})
 	}
 
+	source := "co-purchase"
+	if len(menuIDs) == 0 {
+		source = "popular-now"
+	}
 	response.JSON(w, http.StatusOK, map[string]any{
 		"success": true,
 		"data":    results,
 		"meta": map[string]any{
-			"source":        "co-purchase",
+			"source":        source,
 			"cartItemCount": len(menuIDs),
 		},
 	})
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	stockAlertMenu      = "MENU"
+	stockAlertVariant   = "VARIANT"
+	stockAlertAddonItem = "ADDON_ITEM"
+
+	stockLevelOK       = "OK"
+	stockLevelLow      = "LOW"
+	stockLevelDepleted = "DEPLETED"
+
+	defaultLowStockThreshold  = 5
+	maxLowStockThreshold      = 100000
+	maxStockAlertCooldownMins = 7 * 24 * 60
+	stockAlertEventLow        = "menu.stock.low"
+	stockAlertEventDepleted   = "menu.stock.depleted"
+)
+
+// stockAlertRef points at one stock-tracked item whose quantity just changed.
+type stockAlertRef struct {
+	ItemType string
+	ID       int64
+}
+
+// effectiveLowStockThreshold prefers the item's own threshold over the
+// merchant default.
+func effectiveLowStockThreshold(itemThreshold *int32, merchantDefault int32) int32 {
+	if itemThreshold != nil && *itemThreshold >= 0 {
+		return *itemThreshold
+	}
+	if merchantDefault >= 0 {
+		return merchantDefault
+	}
+	return defaultLowStockThreshold
+}
+
+// stockLevel classifies a tracked quantity against its low-stock threshold.
+// Untracked (nil) quantities never alert.
+func stockLevel(stockQty *int32, threshold int32) string {
+	if stockQty == nil {
+		return stockLevelOK
+	}
+	if *stockQty <= 0 {
+		return stockLevelDepleted
+	}
+	if *stockQty <= threshold {
+		return stockLevelLow
+	}
+	return stockLevelOK
+}
+
+func stockLevelSeverity(level string) int {
+	switch level {
+	case stockLevelLow:
+		return 1
+	case stockLevelDepleted:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// stockAlertEventFor returns the event to emit when an item moves from prev to
+// next, or "" when the move is not a crossing into a worse level (restocks,
+// repeated decrements within the same level).
+func stockAlertEventFor(prev, next string) string {
+	if stockLevelSeverity(next) <= stockLevelSeverity(prev) {
+		return ""
+	}
+	if next == stockLevelDepleted {
+		return stockAlertEventDepleted
+	}
+	return stockAlertEventLow
+}
+
+func stockAlertRefs(itemType string, ids ...int64) []stockAlertRef {
+	refs := make([]stockAlertRef, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, stockAlertRef{ItemType: itemType, ID: id})
+	}
+	return refs
+}
+
+type stockAlertItem struct {
+	ItemType   string
+	ID         int64
+	MerchantID int64
+	Name       string
+	StockQty   *int32
+	Threshold  int32
+	PrevLevel  string
+}
+
+const stockAlertItemsQuery = `
+	select 'MENU', m.id, m.merchant_id, m.name, m.stock_qty, m.low_stock_threshold,
+	       mc.default_low_stock_threshold, coalesce(s.level, '')
+	from menus m
+	join merchants mc on mc.id = m.merchant_id
+	left join stock_alert_states s on s.item_type = 'MENU' and s.item_id = m.id
+	where (m.id = any($1) or m.merchant_id = $4)
+	  and m.track_stock = true and m.deleted_at is null and mc.stock_alert_enabled = true
+	union all
+	select 'VARIANT', v.id, v.merchant_id, mn.name || ' - ' || v.name, v.stock_qty, null::int,
+	       mc.default_low_stock_threshold, coalesce(s.level, '')
+	from menu_variants v
+	join menus mn on mn.id = v.menu_id
+	join merchants mc on mc.id = v.merchant_id
+	left join stock_alert_states s on s.item_type = 'VARIANT' and s.item_id = v.id
+	where (v.id = any($2) or v.merchant_id = $4)
+	  and v.track_stock = true and v.deleted_at is null and mc.stock_alert_enabled = true
+	union all
+	select 'ADDON_ITEM', ai.id, ac.merchant_id, ai.name, ai.stock_qty, ai.low_stock_threshold,
+	       mc.default_low_stock_threshold, coalesce(s.level, '')
+	from addon_items ai
+	join addon_categories ac on ac.id = ai.addon_category_id
+	join merchants mc on mc.id = ac.merchant_id
+	left join stock_alert_states s on s.item_type = 'ADDON_ITEM' and s.item_id = ai.id
+	where (ai.id = any($3) or ac.merchant_id = $4)
+	  and ai.track_stock = true and ai.deleted_at is null and mc.stock_alert_enabled = true
+`
+
+func (h *Handler) loadStockAlertItems(ctx context.Context, refs []stockAlertRef, merchantID int64) ([]stockAlertItem, error) {
+	menuIDs := make([]int64, 0)
+	variantIDs := make([]int64, 0)
+	addonIDs := make([]int64, 0)
+	for _, ref := range refs {
+		switch ref.ItemType {
+		case stockAlertMenu:
+			menuIDs = append(menuIDs, ref.ID)
+		case stockAlertVariant:
+			variantIDs = append(variantIDs, ref.ID)
+		case stockAlertAddonItem:
+			addonIDs = append(addonIDs, ref.ID)
+		}
+	}
+
+	rows, err := h.DB.Query(ctx, stockAlertItemsQuery, menuIDs, variantIDs, addonIDs, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]stockAlertItem, 0)
+	for rows.Next() {
+		var (
+			item            stockAlertItem
+			stockQty        pgtype.Int4
+			itemThreshold   pgtype.Int4
+			merchantDefault int32
+		)
+		if err := rows.Scan(&item.ItemType, &item.ID, &item.MerchantID, &item.Name, &stockQty, &itemThreshold, &merchantDefault, &item.PrevLevel); err != nil {
+			return nil, err
+		}
+		item.StockQty = int4Ptr(stockQty)
+		item.Threshold = effectiveLowStockThreshold(int4Ptr(itemThreshold), merchantDefault)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// checkStockAlerts re-evaluates the given items after a stock change, records
+// their level and emits menu.stock.low / menu.stock.depleted when an item
+// crosses into a worse level. Callers run it after the stock change has been
+// committed; it is best-effort and never fails the change itself.
+func (h *Handler) checkStockAlerts(ctx context.Context, refs ...stockAlertRef) {
+	if len(refs) == 0 {
+		return
+	}
+	h.evaluateStockAlerts(ctx, refs, 0)
+}
+
+// checkMerchantStockAlerts re-evaluates every tracked item of a merchant, for
+// changes that touch the whole catalog such as daily stock resets.
+func (h *Handler) checkMerchantStockAlerts(ctx context.Context, merchantID int64) {
+	h.evaluateStockAlerts(ctx, nil, merchantID)
+}
+
+func (h *Handler) evaluateStockAlerts(ctx context.Context, refs []stockAlertRef, merchantID int64) {
+	items, err := h.loadStockAlertItems(ctx, refs, merchantID)
+	if err != nil {
+		h.Logger.Warn("stock alert lookup failed", zapError(err))
+		return
+	}
+
+	for _, item := range items {
+		level := stockLevel(item.StockQty, item.Threshold)
+		if level == item.PrevLevel || (item.PrevLevel == "" && level == stockLevelOK) {
+			continue
+		}
+		if _, err := h.DB.Exec(ctx, `
+			insert into stock_alert_states (merchant_id, item_type, item_id, level, updated_at)
+			values ($1, $2, $3, $4, now())
+			on conflict (item_type, item_id) do update
+			set level = excluded.level, merchant_id = excluded.merchant_id, updated_at = now()
+		`, item.MerchantID, item.ItemType, item.ID, level); err != nil {
+			h.Logger.Warn("stock alert state update failed", zapError(err))
+			continue
+		}
+
+		eventType := stockAlertEventFor(item.PrevLevel, level)
+		if eventType == "" || h.Queue == nil {
+			continue
+		}
+		event := map[string]any{
+			"type":       eventType,
+			"merchantId": item.MerchantID,
+			"itemType":   item.ItemType,
+			"itemId":     item.ID,
+			"itemName":   item.Name,
+			"stockQty":   item.StockQty,
+			"threshold":  item.Threshold,
+			"level":      level,
+			"occurredAt": time.Now().UTC(),
+		}
+		_ = h.Queue.PublishJSON(ctx, "genfity.events", eventType, event)
+	}
+}
+
+// checkOrderStockAlerts evaluates the menus, variants and addon items of an
+// order, for paths that decrement stock inside the order's transaction.
+func (h *Handler) checkOrderStockAlerts(ctx context.Context, orderID int64) {
+	rows, err := h.DB.Query(ctx, `
+		select 'MENU', oi.menu_id from order_items oi where oi.order_id = $1 and oi.menu_id is not null
+		union
+		select 'VARIANT', oiv.variant_id
+		from order_item_variants oiv
+		join order_items oi on oi.id = oiv.order_item_id
+		where oi.order_id = $1
+		union
+		select 'ADDON_ITEM', oia.addon_item_id
+		from order_item_addons oia
+		join order_items oi on oi.id = oia.order_item_id
+		where oi.order_id = $1 and oia.addon_item_id is not null
+	`, orderID)
+	if err != nil {
+		h.Logger.Warn("stock alert order lookup failed", zapError(err))
+		return
+	}
+	refs := make([]stockAlertRef, 0)
+	for rows.Next() {
+		var ref stockAlertRef
+		if err := rows.Scan(&ref.ItemType, &ref.ID); err != nil {
+			rows.Close()
+			h.Logger.Warn("stock alert order lookup failed", zapError(err))
+			return
+		}
+		refs = append(refs, ref)
+	}
+	rows.Close()
+	h.checkStockAlerts(ctx, refs...)
+}
+
+type stockAlertSettingsRequest struct {
+	Enabled                  *bool  `json:"enabled"`
+	DefaultLowStockThreshold *int32 `json:"defaultLowStockThreshold"`
+	CooldownMinutes          *int32 `json:"cooldownMinutes"`
+}
+
+func (h *Handler) loadStockAlertSettings(ctx context.Context, merchantID int64) (map[string]any, error) {
+	var (
+		enabled   bool
+		threshold int32
+		cooldown  int32
+	)
+	if err := h.DB.QueryRow(ctx, `
+		select m.stock_alert_enabled, m.default_low_stock_threshold, coalesce(sas.cooldown_minutes, 60)
+		from merchants m
+		left join stock_alert_settings sas on sas.merchant_id = m.id
+		where m.id = $1
+	`, merchantID).Scan(&enabled, &threshold, &cooldown); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"merchantId":               int64ToString(merchantID),
+		"enabled":                  enabled,
+		"defaultLowStockThreshold": threshold,
+		"cooldownMinutes":          cooldown,
+	}, nil
+}
+
+// MerchantStockAlertSettingsGet returns the merchant's stock alert switch,
+// default low-stock threshold and notification cool-down.
+func (h *Handler) MerchantStockAlertSettingsGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	settings, err := h.loadStockAlertSettings(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       settings,
+		"message":    "Stock alert settings retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantStockAlertSettingsPut updates the stock alert settings. A changed
+// threshold is applied to every tracked item right away, so items already
+// below the new threshold alert once.
+func (h *Handler) MerchantStockAlertSettingsPut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body stockAlertSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if body.DefaultLowStockThreshold != nil && (*body.DefaultLowStockThreshold < 0 || *body.DefaultLowStockThreshold > maxLowStockThreshold) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("defaultLowStockThreshold must be between 0 and %d", maxLowStockThreshold))
+		return
+	}
+	if body.CooldownMinutes != nil && (*body.CooldownMinutes < 0 || *body.CooldownMinutes > maxStockAlertCooldownMins) {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("cooldownMinutes must be between 0 and %d", maxStockAlertCooldownMins))
+		return
+	}
+
+	if _, err := h.DB.Exec(ctx, `
+		with cooldown as (
+			insert into stock_alert_settings (merchant_id, cooldown_minutes, updated_at)
+			select $1, $4, now() where $4::int is not null
+			on conflict (merchant_id) do update set
+				cooldown_minutes = excluded.cooldown_minutes,
+				updated_at = excluded.updated_at
+		)
+		update merchants
+		set stock_alert_enabled = coalesce($2, stock_alert_enabled),
+		    default_low_stock_threshold = coalesce($3, default_low_stock_threshold),
+		    updated_at = now()
+		where id = $1
+	`, *authCtx.MerchantID, body.Enabled, body.DefaultLowStockThreshold, body.CooldownMinutes); err != nil {
+		h.Logger.Error("stock alert settings update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update stock alert settings")
+		return
+	}
+
+	if body.Enabled != nil || body.DefaultLowStockThreshold != nil {
+		h.checkMerchantStockAlerts(ctx, *authCtx.MerchantID)
+	}
+
+	settings, err := h.loadStockAlertSettings(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       settings,
+		"message":    "Stock alert settings updated successfully",
+		"statusCode": 200,
+	})
+}
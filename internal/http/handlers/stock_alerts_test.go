@@ -0,0 +1,71 @@
+package handlers
+
+import "testing"
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestStockLevel(t *testing.T) {
+	cases := []struct {
+		name          string
+		stockQty      *int32
+		itemThreshold *int32
+		merchantDef   int32
+		want          string
+	}{
+		{"untracked quantity", nil, nil, 5, stockLevelOK},
+		{"above merchant default", int32Ptr(6), nil, 5, stockLevelOK},
+		{"at merchant default", int32Ptr(5), nil, 5, stockLevelLow},
+		{"item threshold wins", int32Ptr(8), int32Ptr(10), 5, stockLevelLow},
+		{"item threshold zero", int32Ptr(1), int32Ptr(0), 5, stockLevelOK},
+		{"sold out", int32Ptr(0), nil, 5, stockLevelDepleted},
+		{"oversold", int32Ptr(-2), int32Ptr(3), 5, stockLevelDepleted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			threshold := effectiveLowStockThreshold(tc.itemThreshold, tc.merchantDef)
+			if got := stockLevel(tc.stockQty, threshold); got != tc.want {
+				t.Fatalf("level %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStockAlertEventFor(t *testing.T) {
+	cases := []struct {
+		prev, next string
+		want       string
+	}{
+		{"", stockLevelLow, stockAlertEventLow},
+		{stockLevelOK, stockLevelLow, stockAlertEventLow},
+		{stockLevelOK, stockLevelDepleted, stockAlertEventDepleted},
+		{stockLevelLow, stockLevelDepleted, stockAlertEventDepleted},
+		{stockLevelLow, stockLevelLow, ""},
+		{stockLevelDepleted, stockLevelLow, ""},
+		{stockLevelLow, stockLevelOK, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.prev+"->"+tc.next, func(t *testing.T) {
+			if got := stockAlertEventFor(tc.prev, tc.next); got != tc.want {
+				t.Fatalf("event %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStockOverviewCountsUseThresholds(t *testing.T) {
+	items := []stockOverviewItem{
+		{StockQty: int32Ptr(8), LowStockThreshold: int32Ptr(10)},
+		{StockQty: int32Ptr(8)},
+		{StockQty: int32Ptr(2)},
+		{StockQty: int32Ptr(0)},
+		{},
+	}
+	if got := countLowStock(items, 3); got != 2 {
+		t.Fatalf("low stock %d, want 2", got)
+	}
+	if got := countHealthyStock(items, 3); got != 1 {
+		t.Fatalf("healthy %d, want 1", got)
+	}
+}
@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/middleware"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	stockResetTriggerScheduled = "SCHEDULED"
+	stockResetTriggerManual    = "MANUAL"
+
+	stockResetSweepInterval = time.Minute
+)
+
+type stockResetItem struct {
+	ItemType string `json:"itemType"`
+	ItemID   int64  `json:"itemId"`
+	Name     string `json:"name"`
+	Before   *int32 `json:"before"`
+	After    int32  `json:"after"`
+}
+
+type stockResetRun struct {
+	ID        int64            `json:"id"`
+	Trigger   string           `json:"trigger"`
+	ItemCount int              `json:"itemCount"`
+	Items     []stockResetItem `json:"items,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// dailyStockResetDue reports whether the scheduled reset should run at now
+// and the merchant-local date it runs for. It runs once per local day, as
+// soon as the local clock has reached resetTime.
+func dailyStockResetDue(now time.Time, loc *time.Location, resetTime string, lastResetOn string) (string, bool) {
+	local := now.In(loc)
+	date := local.Format("2006-01-02")
+	if lastResetOn == date {
+		return date, false
+	}
+	return date, local.Format("15:04") >= resetTime
+}
+
+// nextDailyStockReset returns when the scheduled reset runs next. A reset that
+// is due but not yet picked up by the sweeper reports now.
+func nextDailyStockReset(now time.Time, loc *time.Location, resetTime string, lastResetOn string) time.Time {
+	clock, err := time.Parse("15:04", resetTime)
+	if err != nil {
+		return time.Time{}
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if lastResetOn == local.Format("2006-01-02") {
+		return time.Date(local.Year(), local.Month(), local.Day()+1, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+	if local.Before(today) {
+		return today
+	}
+	return local
+}
+
+// resetDailyStockTx restores daily_stock_template on every auto-reset menu,
+// variant and addon item of the merchant and returns the before/after
+// quantities.
+func resetDailyStockTx(ctx context.Context, tx pgx.Tx, merchantID int64, userID *int64) ([]stockResetItem, error) {
+	queries := []struct {
+		itemType string
+		sql      string
+		args     []any
+	}{
+		{stockAlertMenu, `
+			with before as (
+				select id, stock_qty from menus
+				where merchant_id = $1 and deleted_at is null
+				  and auto_reset_stock = true and daily_stock_template is not null
+				for update
+			)
+			update menus m
+			set stock_qty = m.daily_stock_template,
+				is_active = m.daily_stock_template > 0,
+				last_stock_reset_at = now(),
+				updated_at = now(),
+				updated_by_user_id = coalesce($2, m.updated_by_user_id)
+			from before b
+			where m.id = b.id
+			returning m.id, m.name, b.stock_qty, m.stock_qty
+		`, []any{merchantID, userID}},
+		{stockAlertVariant, `
+			with before as (
+				select id, stock_qty from menu_variants
+				where merchant_id = $1 and deleted_at is null and track_stock = true
+				  and auto_reset_stock = true and daily_stock_template is not null
+				for update
+			)
+			update menu_variants v
+			set stock_qty = v.daily_stock_template,
+				is_active = v.daily_stock_template > 0,
+				last_stock_reset_at = now(),
+				updated_at = now()
+			from before b, menus mn
+			where v.id = b.id and mn.id = v.menu_id
+			returning v.id, mn.name || ' - ' || v.name, b.stock_qty, v.stock_qty
+		`, []any{merchantID}},
+		{stockAlertAddonItem, `
+			with before as (
+				select ai.id, ai.stock_qty from addon_items ai
+				join addon_categories ac on ac.id = ai.addon_category_id
+				where ac.merchant_id = $1 and ai.deleted_at is null
+				  and ai.auto_reset_stock = true and ai.daily_stock_template is not null
+				for update of ai
+			)
+			update addon_items ai
+			set stock_qty = ai.daily_stock_template,
+				is_active = ai.daily_stock_template > 0,
+				last_stock_reset_at = now(),
+				updated_at = now(),
+				updated_by_user_id = coalesce($2, ai.updated_by_user_id)
+			from before b
+			where ai.id = b.id
+			returning ai.id, ai.name, b.stock_qty, ai.stock_qty
+		`, []any{merchantID, userID}},
+	}
+
+	items := make([]stockResetItem, 0)
+	for _, q := range queries {
+		rows, err := tx.Query(ctx, q.sql, q.args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := stockResetItem{ItemType: q.itemType}
+			var before pgtype.Int4
+			if err := rows.Scan(&item.ItemID, &item.Name, &before, &item.After); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			item.Before = int4Ptr(before)
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func recordStockResetTx(ctx context.Context, tx pgx.Tx, merchantID int64, trigger string, items []stockResetItem, userID *int64) (stockResetRun, error) {
+	payload, err := json.Marshal(items)
+	if err != nil {
+		return stockResetRun{}, err
+	}
+	run := stockResetRun{Trigger: trigger, ItemCount: len(items), Items: items}
+	if err := tx.QueryRow(ctx, `
+		insert into stock_reset_runs (merchant_id, trigger, item_count, items, created_by_user_id)
+		values ($1, $2, $3, $4, $5)
+		returning id, created_at
+	`, merchantID, trigger, len(items), payload, userID).Scan(&run.ID, &run.CreatedAt); err != nil {
+		return stockResetRun{}, err
+	}
+	return run, nil
+}
+
+// resetDailyStock runs and logs a manual reset.
+func (h *Handler) resetDailyStock(ctx context.Context, merchantID int64, userID *int64) (stockResetRun, error) {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return stockResetRun{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	items, err := resetDailyStockTx(ctx, tx, merchantID, userID)
+	if err != nil {
+		return stockResetRun{}, err
+	}
+	run, err := recordStockResetTx(ctx, tx, merchantID, stockResetTriggerManual, items, userID)
+	if err != nil {
+		return stockResetRun{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return stockResetRun{}, err
+	}
+	h.checkMerchantStockAlerts(ctx, merchantID)
+	return run, nil
+}
+
+// RunStockResetSweeper runs each merchant's scheduled daily stock reset once
+// its local reset time has passed. It blocks until ctx is done.
+func (h *Handler) RunStockResetSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = stockResetSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepDailyStockResets(ctx)
+		}
+	}
+}
+
+func (h *Handler) sweepDailyStockResets(ctx context.Context) {
+	rows, err := h.DB.Query(ctx, `
+		select m.id, m.timezone, srs.reset_time, srs.last_reset_on
+		from stock_reset_schedules srs
+		join merchants m on m.id = srs.merchant_id
+		where srs.reset_time is not null and m.is_active = true
+	`)
+	if err != nil {
+		h.Logger.Warn("stock reset sweep failed", zapError(err))
+		return
+	}
+	type dueReset struct {
+		merchantID int64
+		date       string
+	}
+	due := make([]dueReset, 0)
+	now := time.Now()
+	for rows.Next() {
+		var (
+			merchantID  int64
+			timezone    string
+			resetTime   string
+			lastResetOn pgtype.Date
+		)
+		if err := rows.Scan(&merchantID, &timezone, &resetTime, &lastResetOn); err != nil {
+			rows.Close()
+			h.Logger.Warn("stock reset sweep scan failed", zapError(err))
+			return
+		}
+		last := ""
+		if lastResetOn.Valid {
+			last = lastResetOn.Time.Format("2006-01-02")
+		}
+		if date, ok := dailyStockResetDue(now, loadTimezone(timezoneOrDefault(timezone)), resetTime, last); ok {
+			due = append(due, dueReset{merchantID: merchantID, date: date})
+		}
+	}
+	rows.Close()
+
+	for _, d := range due {
+		if err := h.runScheduledStockReset(ctx, d.merchantID, d.date); err != nil {
+			h.Logger.Warn("scheduled stock reset failed", zapError(err))
+		}
+	}
+}
+
+// runScheduledStockReset claims the merchant's local day before resetting, so
+// when several replicas see the same due reset only the first one runs it.
+func (h *Handler) runScheduledStockReset(ctx context.Context, merchantID int64, date string) error {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	claim, err := tx.Exec(ctx, `
+		update stock_reset_schedules set last_reset_on = $2::date, updated_at = now()
+		where merchant_id = $1 and reset_time is not null
+		  and (last_reset_on is null or last_reset_on <> $2::date)
+	`, merchantID, date)
+	if err != nil {
+		return err
+	}
+	if claim.RowsAffected() == 0 {
+		return nil
+	}
+
+	items, err := resetDailyStockTx(ctx, tx, merchantID, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := recordStockResetTx(ctx, tx, merchantID, stockResetTriggerScheduled, items, nil); err != nil {
+		return err
+	}
+	if err := bumpMenuCacheVersion(ctx, tx, merchantID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	h.checkMerchantStockAlerts(ctx, merchantID)
+	return nil
+}
+
+type stockResetScheduleRequest struct {
+	Enabled   *bool   `json:"enabled"`
+	ResetTime *string `json:"resetTime"`
+}
+
+func (h *Handler) loadStockResetSchedule(ctx context.Context, merchantID int64) (map[string]any, error) {
+	var (
+		timezone    string
+		resetTime   pgtype.Text
+		lastResetOn pgtype.Date
+	)
+	if err := h.DB.QueryRow(ctx, `
+		select m.timezone, srs.reset_time, srs.last_reset_on
+		from merchants m
+		left join stock_reset_schedules srs on srs.merchant_id = m.id
+		where m.id = $1
+	`, merchantID).Scan(&timezone, &resetTime, &lastResetOn); err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{
+		"merchantId":  int64ToString(merchantID),
+		"enabled":     resetTime.Valid,
+		"resetTime":   textPtr(resetTime),
+		"timezone":    timezone,
+		"lastResetOn": nil,
+		"nextResetAt": nil,
+	}
+	last := ""
+	if lastResetOn.Valid {
+		last = lastResetOn.Time.Format("2006-01-02")
+		data["lastResetOn"] = last
+	}
+	if resetTime.Valid {
+		data["nextResetAt"] = nextDailyStockReset(time.Now(), loadTimezone(timezoneOrDefault(timezone)), resetTime.String, last)
+	}
+	return data, nil
+}
+
+// MerchantStockResetScheduleGet returns the scheduled daily stock reset.
+func (h *Handler) MerchantStockResetScheduleGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	data, err := h.loadStockResetSchedule(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Stock reset schedule retrieved successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantStockResetSchedulePut enables, moves or disables the scheduled
+// reset. A time that has already passed today first runs tomorrow.
+func (h *Handler) MerchantStockResetSchedulePut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	var body stockResetScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	var (
+		timezone  string
+		resetTime pgtype.Text
+	)
+	if err := h.DB.QueryRow(ctx, `
+		select m.timezone, srs.reset_time
+		from merchants m
+		left join stock_reset_schedules srs on srs.merchant_id = m.id
+		where m.id = $1
+	`, *authCtx.MerchantID).Scan(&timezone, &resetTime); err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	var newTime *string
+	if resetTime.Valid {
+		newTime = &resetTime.String
+	}
+	if body.ResetTime != nil {
+		value := strings.TrimSpace(*body.ResetTime)
+		if !isValidHHMM(value) {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "resetTime must be HH:MM")
+			return
+		}
+		newTime = &value
+	}
+	if body.Enabled != nil && !*body.Enabled {
+		newTime = nil
+	} else if body.Enabled != nil && newTime == nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "resetTime is required to enable the daily reset")
+		return
+	}
+
+	// Mark today as done when the new time has already passed, so enabling or
+	// moving the schedule never resets stock in the middle of service.
+	var claimDate *string
+	if newTime != nil {
+		if date, due := dailyStockResetDue(time.Now(), loadTimezone(timezoneOrDefault(timezone)), *newTime, ""); due {
+			claimDate = &date
+		}
+	}
+
+	if _, err := h.DB.Exec(ctx, `
+		insert into stock_reset_schedules (merchant_id, reset_time, last_reset_on, updated_at)
+		values ($1, $2, $3::date, now())
+		on conflict (merchant_id) do update set
+			reset_time = excluded.reset_time,
+			last_reset_on = coalesce(excluded.last_reset_on, stock_reset_schedules.last_reset_on),
+			updated_at = excluded.updated_at
+	`, *authCtx.MerchantID, newTime, claimDate); err != nil {
+		h.Logger.Error("stock reset schedule update failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update stock reset schedule")
+		return
+	}
+
+	data, err := h.loadStockResetSchedule(ctx, *authCtx.MerchantID)
+	if err != nil {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found for this user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       data,
+		"message":    "Stock reset schedule updated successfully",
+		"statusCode": 200,
+	})
+}
+
+// MerchantStockResetRuns lists recent stock resets, newest first. Pass
+// includeItems=true for the before/after quantities of every item.
+func (h *Handler) MerchantStockResetRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	authCtx, ok := middleware.GetAuthContext(ctx)
+	if !ok || authCtx.MerchantID == nil {
+		response.Error(w, http.StatusBadRequest, "MERCHANT_ID_REQUIRED", "Merchant ID is required")
+		return
+	}
+
+	limit := parseIntQuery(r, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	includeItems := r.URL.Query().Get("includeItems") == "true"
+
+	rows, err := h.DB.Query(ctx, `
+		select id, trigger, item_count, case when $3 then items else null end, created_at
+		from stock_reset_runs
+		where merchant_id = $1
+		order by id desc
+		limit $2
+	`, *authCtx.MerchantID, limit, includeItems)
+	if err != nil {
+		h.Logger.Error("stock reset runs query failed", zapError(err))
+		response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load stock resets")
+		return
+	}
+	defer rows.Close()
+
+	runs := make([]stockResetRun, 0)
+	for rows.Next() {
+		var (
+			run   stockResetRun
+			items []byte
+		)
+		if err := rows.Scan(&run.ID, &run.Trigger, &run.ItemCount, &items, &run.CreatedAt); err != nil {
+			h.Logger.Error("stock reset runs scan failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load stock resets")
+			return
+		}
+		if len(items) > 0 {
+			_ = json.Unmarshal(items, &run.Items)
+		}
+		runs = append(runs, run)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"data":       runs,
+		"message":    "Stock resets retrieved successfully",
+		"statusCode": 200,
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyStockResetDue(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	cases := []struct {
+		name     string
+		now      time.Time
+		last     string
+		wantDate string
+		wantDue  bool
+	}{
+		{"before reset time", time.Date(2026, 3, 9, 20, 30, 0, 0, time.UTC), "2026-03-09", "2026-03-10", false},
+		{"reached in merchant time", time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC), "2026-03-09", "2026-03-10", true},
+		{"already ran today", time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC), "2026-03-10", "2026-03-10", false},
+		{"never ran", time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), "", "2026-03-10", true},
+		{"catches up after downtime", time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), "2026-03-08", "2026-03-10", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			date, due := dailyStockResetDue(tc.now, jakarta, "04:00", tc.last)
+			if date != tc.wantDate || due != tc.wantDue {
+				t.Fatalf("got %s/%v, want %s/%v", date, due, tc.wantDate, tc.wantDue)
+			}
+		})
+	}
+}
+
+func TestNextDailyStockReset(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	cases := []struct {
+		name string
+		now  time.Time
+		last string
+		want time.Time
+	}{
+		{"later today", time.Date(2026, 3, 9, 20, 0, 0, 0, time.UTC), "2026-03-09", time.Date(2026, 3, 10, 4, 0, 0, 0, jakarta)},
+		{"done today", time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC), "2026-03-10", time.Date(2026, 3, 11, 4, 0, 0, 0, jakarta)},
+		{"due now", time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC), "2026-03-09", time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nextDailyStockReset(tc.now, jakarta, "04:00", tc.last); !got.Equal(tc.want) {
+				t.Fatalf("next %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
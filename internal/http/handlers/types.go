@@ -34,6 +34,40 @@ type DeliveryDriverSummary struct {
 	Phone *string `json:"phone"`
 }
 
+// DeliveryRouteInfo describes where an order sits in a multi-drop route.
+type DeliveryRouteInfo struct {
+	Position           int        `json:"position"`
+	TotalStops         int        `json:"totalStops"`
+	StopsAhead         int        `json:"stopsAhead"`
+	EtaMinutes         *float64   `json:"etaMinutes"`
+	EstimatedArrivalAt *time.Time `json:"estimatedArrivalAt"`
+}
+
+// DeliveryEta is the remaining time until a delivery reaches the customer,
+// split into kitchen prep, driver pickup and travel.
+type DeliveryEta struct {
+	PrepMinutes        float64    `json:"prepMinutes"`
+	PickupMinutes      float64    `json:"pickupMinutes"`
+	TravelMinutes      float64    `json:"travelMinutes"`
+	TotalMinutes       float64    `json:"totalMinutes"`
+	MinMinutes         int        `json:"minMinutes"`
+	MaxMinutes         int        `json:"maxMinutes"`
+	TrafficFactor      float64    `json:"trafficFactor"`
+	EstimatedArrivalAt *time.Time `json:"estimatedArrivalAt"`
+	Source             string     `json:"source"`
+}
+
+// CurbsidePickup is the vehicle and check-in state of a curbside TAKEAWAY
+// order. WaitSeconds is arrival to handover, set once handed over.
+type CurbsidePickup struct {
+	VehicleDescription string     `json:"vehicleDescription"`
+	ParkingSpot        *string    `json:"parkingSpot"`
+	ArrivalNote        *string    `json:"arrivalNote"`
+	ArrivedAt          *time.Time `json:"arrivedAt"`
+	HandedOverAt       *time.Time `json:"handedOverAt"`
+	WaitSeconds        *int32     `json:"waitSeconds"`
+}
+
 type OrderCount struct {
 	OrderItems int64 `json:"orderItems"`
 }
@@ -73,6 +107,7 @@ type OrderListItem struct {
 	Reservation         *ReservationSummary    `json:"reservation,omitempty"`
 	Customer            *CustomerSummary       `json:"customer,omitempty"`
 	DeliveryDriver      *DeliveryDriverSummary `json:"deliveryDriver,omitempty"`
+	Curbside            *CurbsidePickup        `json:"curbside,omitempty"`
 	Count               *OrderCount            `json:"_count,omitempty"`
 }
 
@@ -84,41 +119,45 @@ type OrderItemAddon struct {
 }
 
 type OrderItem struct {
-	ID        int64            `json:"id"`
-	MenuName  string           `json:"menuName"`
-	Quantity  int32            `json:"quantity"`
-	MenuPrice float64          `json:"menuPrice"`
-	Subtotal  float64          `json:"subtotal"`
-	Notes     *string          `json:"notes"`
-	Addons    []OrderItemAddon `json:"addons"`
+	ID          int64            `json:"id"`
+	MenuName    string           `json:"menuName"`
+	VariantName *string          `json:"variantName"`
+	Quantity    int32            `json:"quantity"`
+	MenuPrice   float64          `json:"menuPrice"`
+	Subtotal    float64          `json:"subtotal"`
+	Notes       *string          `json:"notes"`
+	Addons      []OrderItemAddon `json:"addons"`
 }
 
 type OrderDetail struct {
-	ID                  int64       `json:"id"`
-	OrderNumber         string      `json:"orderNumber"`
-	Status              string      `json:"status"`
-	OrderType           string      `json:"orderType"`
-	TableNumber         *string     `json:"tableNumber"`
-	CustomerName        string      `json:"customerName"`
-	Subtotal            float64     `json:"subtotal"`
-	TaxAmount           float64     `json:"taxAmount"`
-	ServiceChargeAmount float64     `json:"serviceChargeAmount"`
-	PackagingFeeAmount  float64     `json:"packagingFeeAmount"`
-	DiscountAmount      float64     `json:"discountAmount"`
-	TotalAmount         float64     `json:"totalAmount"`
-	CreatedAt           time.Time   `json:"createdAt"`
-	UpdatedAt           time.Time   `json:"updatedAt"`
-	PlacedAt            *time.Time  `json:"placedAt"`
-	CompletedAt         *time.Time  `json:"completedAt"`
-	DeliveryStatus      *string     `json:"deliveryStatus"`
-	DeliveryUnit        *string     `json:"deliveryUnit"`
-	DeliveryAddress     *string     `json:"deliveryAddress"`
-	DeliveryFeeAmount   float64     `json:"deliveryFeeAmount"`
-	DeliveryDistanceKm  *float64    `json:"deliveryDistanceKm"`
-	DeliveryDeliveredAt *time.Time  `json:"deliveryDeliveredAt"`
-	EditedAt            *time.Time  `json:"editedAt"`
-	ChangedByAdmin      bool        `json:"changedByAdmin"`
-	OrderItems          []OrderItem `json:"orderItems"`
+	ID                  int64              `json:"id"`
+	OrderNumber         string             `json:"orderNumber"`
+	Status              string             `json:"status"`
+	OrderType           string             `json:"orderType"`
+	TableNumber         *string            `json:"tableNumber"`
+	CustomerName        string             `json:"customerName"`
+	Subtotal            float64            `json:"subtotal"`
+	TaxAmount           float64            `json:"taxAmount"`
+	ServiceChargeAmount float64            `json:"serviceChargeAmount"`
+	PackagingFeeAmount  float64            `json:"packagingFeeAmount"`
+	DiscountAmount      float64            `json:"discountAmount"`
+	TotalAmount         float64            `json:"totalAmount"`
+	CreatedAt           time.Time          `json:"createdAt"`
+	UpdatedAt           time.Time          `json:"updatedAt"`
+	PlacedAt            *time.Time         `json:"placedAt"`
+	CompletedAt         *time.Time         `json:"completedAt"`
+	DeliveryStatus      *string            `json:"deliveryStatus"`
+	DeliveryUnit        *string            `json:"deliveryUnit"`
+	DeliveryAddress     *string            `json:"deliveryAddress"`
+	DeliveryFeeAmount   float64            `json:"deliveryFeeAmount"`
+	DeliveryDistanceKm  *float64           `json:"deliveryDistanceKm"`
+	DeliveryDeliveredAt *time.Time         `json:"deliveryDeliveredAt"`
+	DeliveryRoute       *DeliveryRouteInfo `json:"deliveryRoute"`
+	DeliveryEta         *DeliveryEta       `json:"deliveryEta"`
+	Curbside            *CurbsidePickup    `json:"curbside"`
+	EditedAt            *time.Time         `json:"editedAt"`
+	ChangedByAdmin      bool               `json:"changedByAdmin"`
+	OrderItems          []OrderItem        `json:"orderItems"`
 	Merchant            struct {
 		Name     string `json:"name"`
 		Currency string `json:"currency"`
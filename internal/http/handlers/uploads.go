@@ -39,9 +39,11 @@ type menuThumbVariant struct {
 }
 
 type menuThumbMeta struct {
-	Format   string                `json:"format"`
-	Source   utils.ImageSourceMeta `json:"source"`
-	Variants []menuThumbVariant    `json:"variants"`
+	Format      string                  `json:"format"`
+	Source      utils.ImageSourceMeta   `json:"source"`
+	Variants    []menuThumbVariant      `json:"variants"`
+	Renditions  []menuImageRendition    `json:"renditions,omitempty"`
+	Placeholder *utils.ImagePlaceholder `json:"placeholder,omitempty"`
 }
 
 func (h *Handler) makeStore(r *http.Request) (*storage.ObjectStore, error) {
@@ -662,18 +664,6 @@ func (h *Handler) MerchantUploadMenuImage(w http.ResponseWriter, r *http.Request
 		))
 	}
 
-	thumbJpeg, _, err := utils.EncodeJpegCoverSquare(data, menuThumbSize, 80)
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
-		return
-	}
-
-	thumb2xJpeg, _, err := utils.EncodeJpegCoverSquare(data, menuThumb2xSize, 80)
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
-		return
-	}
-
 	imageKey := menuIDRaw
 	if strings.TrimSpace(imageKey) == "" {
 		imageKey = fmt.Sprintf("%d", time.Now().UnixMilli())
@@ -686,34 +676,23 @@ func (h *Handler) MerchantUploadMenuImage(w http.ResponseWriter, r *http.Request
 	}
 
 	fullKey := addRandomSuffix(fmt.Sprintf("merchants/%s/menus/menu-%s.jpg", merchantCode, imageKey))
-	thumbKey := addRandomSuffix(fmt.Sprintf("merchants/%s/menus/menu-%s-thumb.jpg", merchantCode, imageKey))
-	thumb2xKey := addRandomSuffix(fmt.Sprintf("merchants/%s/menus/menu-%s-thumb-2x.jpg", merchantCode, imageKey))
-
 	fullURL, err := store.PutObject(ctx, fullKey, fullJpeg, "image/jpeg", "public, max-age=31536000, immutable")
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
 		return
 	}
-	thumbURL, err := store.PutObject(ctx, thumbKey, thumbJpeg, "image/jpeg", "public, max-age=31536000, immutable")
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
-		return
-	}
-	thumb2xURL, err := store.PutObject(ctx, thumb2xKey, thumb2xJpeg, "image/jpeg", "public, max-age=31536000, immutable")
+
+	stored, err := storeMenuImageVariants(ctx, store, merchantCode, imageKey, data)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
 		return
 	}
-
-	meta := menuThumbMeta{
-		Format: "jpeg",
-		Source: sourceMeta,
-		Variants: []menuThumbVariant{
-			{Dpr: 1, Width: menuThumbSize, Height: menuThumbSize, URL: thumbURL},
-			{Dpr: 2, Width: menuThumb2xSize, Height: menuThumb2xSize, URL: thumb2xURL},
-		},
+	thumbURL, thumb2xURL := stored.ThumbURL, stored.Thumb2xURL
+	metaJSON, _ := json.Marshal(stored.Meta)
+	keep := map[string]bool{fullURL: true}
+	for _, u := range menuImageMetaURLs(metaJSON) {
+		keep[u] = true
 	}
-	metaJSON, _ := json.Marshal(meta)
 
 	if menuIDValue != nil {
 		if _, err := h.DB.Exec(ctx, `
@@ -737,19 +716,7 @@ func (h *Handler) MerchantUploadMenuImage(w http.ResponseWriter, r *http.Request
 		}
 
 		if hasPreviousThumbMeta {
-			var prev struct {
-				Variants []struct {
-					URL *string `json:"url"`
-				} `json:"variants"`
-			}
-			if err := json.Unmarshal(previousThumbMeta, &prev); err == nil {
-				for _, v := range prev.Variants {
-					if v.URL == nil {
-						continue
-					}
-					urlsToDelete = append(urlsToDelete, *v.URL)
-				}
-			}
+			urlsToDelete = append(urlsToDelete, menuImageMetaURLs(previousThumbMeta)...)
 		}
 
 		if len(urlsToDelete) > 0 {
@@ -757,7 +724,7 @@ func (h *Handler) MerchantUploadMenuImage(w http.ResponseWriter, r *http.Request
 				if strings.TrimSpace(u) == "" {
 					continue
 				}
-				if u == thumbURL || u == thumb2xURL || u == fullURL {
+				if keep[u] {
 					continue
 				}
 				if err := store.DeleteURL(ctx, u); err != nil {
@@ -780,6 +747,7 @@ func (h *Handler) MerchantUploadMenuImage(w http.ResponseWriter, r *http.Request
 			"thumbUrl":   thumbURL,
 			"thumb2xUrl": thumb2xURL,
 			"thumbMeta":  json.RawMessage(metaJSON),
+			"image":      publicMenuImage(&fullURL, &thumbURL, metaJSON),
 			"warnings":   warnings,
 		},
 		"message":    message,
@@ -1199,25 +1167,19 @@ func (h *Handler) MerchantMenuImageConfirm(w http.ResponseWriter, r *http.Reques
 			urlsToDelete[*existing.ImageThumbURL] = true
 		}
 		if existing.HasMeta {
-			var prev struct {
-				Variants []struct {
-					URL *string `json:"url"`
-				} `json:"variants"`
-			}
-			if err := json.Unmarshal(existing.ThumbMeta, &prev); err == nil {
-				for _, v := range prev.Variants {
-					if v.URL == nil || strings.TrimSpace(*v.URL) == "" {
-						continue
-					}
-					candidate := strings.TrimSpace(*v.URL)
-					if candidate != body.ImageThumbURL {
-						if body.ImageThumb2xURL == nil || candidate != strings.TrimSpace(*body.ImageThumb2xURL) {
-							urlsToDelete[candidate] = true
-						}
+			for _, candidate := range menuImageMetaURLs(existing.ThumbMeta) {
+				if candidate != body.ImageThumbURL {
+					if body.ImageThumb2xURL == nil || candidate != strings.TrimSpace(*body.ImageThumb2xURL) {
+						urlsToDelete[candidate] = true
 					}
 				}
 			}
 		}
+		if body.ImageThumbMeta != nil {
+			for _, u := range menuImageMetaURLs(*body.ImageThumbMeta) {
+				delete(urlsToDelete, u)
+			}
+		}
 
 		for u := range urlsToDelete {
 			_ = store.DeleteURL(ctx, u)
@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"genfity-order-services/internal/utils"
+	"genfity-order-services/pkg/response"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	upsellDiscountAmountOff  = "AMOUNT_OFF"
+	upsellDiscountPercentOff = "PERCENT_OFF"
+
+	maxUpsellTriggers      = 50
+	maxUpsellOfferMenus    = 10
+	maxUpsellQuantity      = 20
+	maxUpsellOffersPerCart = 5
+
+	maxUpsellCartTokenLength   = 64
+	upsellImpressionSweepLimit = 500
+)
+
+type upsellRule struct {
+	ID                 int64
+	Name               string
+	Message            *string
+	IsActive           bool
+	TriggerMenuIDs     []int64
+	TriggerCategoryIDs []int64
+	MinSubtotal        *float64
+	OfferMenuIDs       []int64
+	DiscountType       *string
+	DiscountValue      *float64
+	MaxQuantity        int32
+	Priority           int32
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// upsellCart is what rules are evaluated against: the cart without the items
+// that were themselves taken from an offer.
+type upsellCart struct {
+	MenuIDs       map[int64]bool
+	CategoryIDs   map[int64]bool
+	Subtotal      float64
+	AcceptedRules map[int64]bool
+}
+
+// matches reports whether the cart triggers the rule. A rule with only a
+// subtotal threshold applies to every cart that reaches it.
+func (rule upsellRule) matches(cart upsellCart) bool {
+	if rule.MinSubtotal != nil && cart.Subtotal < *rule.MinSubtotal {
+		return false
+	}
+	if len(rule.TriggerMenuIDs) == 0 && len(rule.TriggerCategoryIDs) == 0 {
+		return true
+	}
+	for _, id := range rule.TriggerMenuIDs {
+		if cart.MenuIDs[id] {
+			return true
+		}
+	}
+	for _, id := range rule.TriggerCategoryIDs {
+		if cart.CategoryIDs[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule upsellRule) offersMenu(menuID int64) bool {
+	for _, id := range rule.OfferMenuIDs {
+		if id == menuID {
+			return true
+		}
+	}
+	return false
+}
+
+// offerPrice applies the rule's discount to a unit price. Rules without a
+// discount only suggest the item.
+func (rule upsellRule) offerPrice(price float64) float64 {
+	if rule.DiscountType == nil || rule.DiscountValue == nil {
+		return round2(price)
+	}
+	switch *rule.DiscountType {
+	case upsellDiscountAmountOff:
+		return round2(math.Max(0, price-*rule.DiscountValue))
+	case upsellDiscountPercentOff:
+		return round2(price * (1 - *rule.DiscountValue/100))
+	}
+	return round2(price)
+}
+
+func parseUpsellRuleID(value any) (*int64, error) {
+	if value == nil {
+		return nil, nil
+	}
+	id, ok := parseNumericID(value)
+	if !ok || id <= 0 {
+		return nil, errInvalid("Invalid upsellRuleId")
+	}
+	return &id, nil
+}
+
+// insertOrderItemUpsell links an order item bought from an upsell offer to
+// its rule.
+func insertOrderItemUpsell(ctx context.Context, tx pgx.Tx, orderItemID int64, ruleID *int64) error {
+	if ruleID == nil {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		insert into order_item_upsells (order_item_id, upsell_rule_id) values ($1, $2)
+	`, orderItemID, *ruleID)
+	return err
+}
+
+const upsellRuleColumns = `
+	id, name, message, is_active, trigger_menu_ids, trigger_category_ids, min_subtotal,
+	offer_menu_ids, discount_type, discount_value, max_quantity, priority, created_at, updated_at`
+
+func scanUpsellRule(row pgx.Row) (upsellRule, error) {
+	var (
+		rule          upsellRule
+		minSubtotal   pgtype.Numeric
+		discountType  pgtype.Text
+		discountValue pgtype.Numeric
+	)
+	if err := row.Scan(&rule.ID, &rule.Name, &rule.Message, &rule.IsActive, &rule.TriggerMenuIDs, &rule.TriggerCategoryIDs,
+		&minSubtotal, &rule.OfferMenuIDs, &discountType, &discountValue, &rule.MaxQuantity, &rule.Priority,
+		&rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return upsellRule{}, err
+	}
+	if minSubtotal.Valid {
+		value := utils.NumericToFloat64(minSubtotal)
+		rule.MinSubtotal = &value
+	}
+	rule.DiscountType = textPtr(discountType)
+	if discountValue.Valid {
+		value := utils.NumericToFloat64(discountValue)
+		rule.DiscountValue = &value
+	}
+	return rule, nil
+}
+
+// loadUpsellRules returns the merchant's rules, highest priority first. With
+// ids it returns only those rules; activeOnly skips disabled ones.
+func (h *Handler) loadUpsellRules(ctx context.Context, merchantID int64, ids []int64, activeOnly bool) ([]upsellRule, error) {
+	where := "merchant_id = $1 and deleted_at is null"
+	args := []any{merchantID}
+	if ids != nil {
+		where += " and id = any($2)"
+		args = append(args, ids)
+	}
+	if activeOnly {
+		where += " and is_active = true"
+	}
+	rows, err := h.DB.Query(ctx, `select `+upsellRuleColumns+` from upsell_rules where `+where+` order by priority desc, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]upsellRule, 0)
+	for rows.Next() {
+		rule, err := scanUpsellRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (h *Handler) buildUpsellCart(ctx context.Context, items []posOrderItemData) (upsellCart, error) {
+	cart := upsellCart{
+		MenuIDs:       make(map[int64]bool),
+		CategoryIDs:   make(map[int64]bool),
+		AcceptedRules: make(map[int64]bool),
+	}
+	menuIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.UpsellRuleID != nil {
+			cart.AcceptedRules[*item.UpsellRuleID] = true
+			continue
+		}
+		cart.Subtotal = round2(cart.Subtotal + item.Subtotal)
+		if !item.IsCustom {
+			cart.MenuIDs[item.MenuID] = true
+			menuIDs = append(menuIDs, item.MenuID)
+		}
+	}
+	if len(menuIDs) == 0 {
+		return cart, nil
+	}
+
+	rows, err := h.DB.Query(ctx, `select distinct category_id from menu_category_items where menu_id = any($1)`, menuIDs)
+	if err != nil {
+		return upsellCart{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryID int64
+		if err := rows.Scan(&categoryID); err != nil {
+			return upsellCart{}, err
+		}
+		cart.CategoryIDs[categoryID] = true
+	}
+	return cart, rows.Err()
+}
+
+// applyUpsellOffers reprices order items taken from an upsell offer and
+// returns the new subtotal. The rule must still be active, offer the menu,
+// be triggered by the rest of the cart and not exceed its quantity.
+func (h *Handler) applyUpsellOffers(ctx context.Context, merchantID int64, items []posOrderItemData, subtotal float64) (float64, error) {
+	ruleIDs := make([]int64, 0)
+	for _, item := range items {
+		if item.UpsellRuleID != nil {
+			ruleIDs = append(ruleIDs, *item.UpsellRuleID)
+		}
+	}
+	if len(ruleIDs) == 0 {
+		return subtotal, nil
+	}
+
+	loaded, err := h.loadUpsellRules(ctx, merchantID, ruleIDs, true)
+	if err != nil {
+		return 0, err
+	}
+	rules := make(map[int64]upsellRule, len(loaded))
+	for _, rule := range loaded {
+		rules[rule.ID] = rule
+	}
+	cart, err := h.buildUpsellCart(ctx, items)
+	if err != nil {
+		return 0, err
+	}
+
+	quantities := make(map[int64]int32)
+	for i := range items {
+		item := &items[i]
+		if item.UpsellRuleID == nil {
+			continue
+		}
+		rule, ok := rules[*item.UpsellRuleID]
+		if !ok {
+			return 0, errInvalid("Upsell offer is no longer available")
+		}
+		if !rule.offersMenu(item.MenuID) || !rule.matches(cart) {
+			return 0, errInvalid(fmt.Sprintf("Upsell offer does not apply to %s", item.MenuName))
+		}
+		quantities[rule.ID] += item.Quantity
+		if quantities[rule.ID] > rule.MaxQuantity {
+			return 0, errInvalid(fmt.Sprintf("Upsell offer for %s is limited to %d", item.MenuName, rule.MaxQuantity))
+		}
+		price := rule.offerPrice(item.MenuPrice)
+		discount := round2((item.MenuPrice - price) * float64(item.Quantity))
+		item.MenuPrice = price
+		item.Subtotal = round2(item.Subtotal - discount)
+		subtotal = round2(subtotal - discount)
+	}
+	return subtotal, nil
+}
+
+type upsellOffer struct {
+	Rule        upsellRule
+	MenuID      int64
+	Name        string
+	Description *string
+	ImageURL    *string
+	Price       float64
+	OfferPrice  float64
+}
+
+// upsellOffersForCart lists the offers the cart currently triggers. Menus
+// already in the cart, unavailable or out of stock are not offered, and a
+// rule already accepted in the cart is not offered again.
+func (h *Handler) upsellOffersForCart(ctx context.Context, merchantID int64, loc *time.Location, items []posOrderItemData) ([]upsellOffer, error) {
+	cart, err := h.buildUpsellCart(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := h.loadUpsellRules(ctx, merchantID, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]upsellRule, 0)
+	menuIDs := make([]int64, 0)
+	for _, rule := range rules {
+		if cart.AcceptedRules[rule.ID] || !rule.matches(cart) {
+			continue
+		}
+		matching = append(matching, rule)
+		menuIDs = append(menuIDs, rule.OfferMenuIDs...)
+	}
+	if len(matching) == 0 {
+		return []upsellOffer{}, nil
+	}
+
+	rows, err := h.DB.Query(ctx, `
+		select id, name, description, price, image_url,
+		       schedule_enabled, schedule_start_time, schedule_end_time, schedule_days
+		from menus
+		where merchant_id = $1 and id = any($2)
+		  and is_active = true and deleted_at is null
+		  and (track_stock = false or stock_qty is null or stock_qty > 0)
+	`, merchantID, menuIDs)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	menus := make(map[int64]upsellOffer)
+	for rows.Next() {
+		var (
+			offer           upsellOffer
+			description     pgtype.Text
+			price           pgtype.Numeric
+			imageURL        pgtype.Text
+			scheduleEnabled bool
+			scheduleStart   pgtype.Text
+			scheduleEnd     pgtype.Text
+			scheduleDays    []int32
+		)
+		if err := rows.Scan(&offer.MenuID, &offer.Name, &description, &price, &imageURL,
+			&scheduleEnabled, &scheduleStart, &scheduleEnd, &scheduleDays); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !menuScheduledAt(scheduleEnabled, scheduleStart.String, scheduleEnd.String, scheduleDays, now) {
+			continue
+		}
+		offer.Description = textPtr(description)
+		offer.ImageURL = textPtr(imageURL)
+		offer.Price = utils.NumericToFloat64(price)
+		menus[offer.MenuID] = offer
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	promoMap := h.fetchGroupOrderPromoPrices(ctx, menuIDs, merchantID)
+
+	offers := make([]upsellOffer, 0)
+	offered := make(map[int64]bool)
+	for _, rule := range matching {
+		for _, menuID := range rule.OfferMenuIDs {
+			menu, ok := menus[menuID]
+			if !ok || cart.MenuIDs[menuID] || offered[menuID] {
+				continue
+			}
+			if promo, ok := promoMap[menuID]; ok {
+				menu.Price = promo
+			}
+			menu.Rule = rule
+			menu.OfferPrice = rule.offerPrice(menu.Price)
+			offers = append(offers, menu)
+			offered[menuID] = true
+			if len(offers) == maxUpsellOffersPerCart {
+				return offers, nil
+			}
+		}
+	}
+	return offers, nil
+}
+
+// recordUpsellImpressions counts each rule shown once per cart and
+// merchant-local day. Calls without a cart token are not counted.
+func (h *Handler) recordUpsellImpressions(ctx context.Context, merchantID int64, day string, cartToken string, offers []upsellOffer) {
+	if cartToken == "" {
+		return
+	}
+	ruleIDs := make([]int64, 0, len(offers))
+	seen := make(map[int64]bool)
+	for _, offer := range offers {
+		if !seen[offer.Rule.ID] {
+			seen[offer.Rule.ID] = true
+			ruleIDs = append(ruleIDs, offer.Rule.ID)
+		}
+	}
+	if len(ruleIDs) == 0 {
+		return
+	}
+	sort.Slice(ruleIDs, func(i, j int) bool { return ruleIDs[i] < ruleIDs[j] })
+	tag, err := h.DB.Exec(ctx, `
+		with fresh as (
+			insert into upsell_rule_impression_carts (rule_id, day, cart_token)
+			select id, $2::date, $3 from unnest($4::bigint[]) as id
+			on conflict do nothing
+			returning rule_id
+		)
+		insert into upsell_rule_impressions (rule_id, merchant_id, day, count)
+		select rule_id, $1, $2::date, 1 from fresh
+		on conflict (rule_id, day) do update set count = upsell_rule_impressions.count + 1
+	`, merchantID, day, cartToken, ruleIDs)
+	if err != nil {
+		h.Logger.Warn("upsell impression insert failed", zapError(err))
+		return
+	}
+
+	if tag.RowsAffected() > 0 {
+		_, _ = h.DB.Exec(ctx, `
+			delete from upsell_rule_impression_carts
+			where (rule_id, day, cart_token) in (
+				select rule_id, day, cart_token from upsell_rule_impression_carts
+				where day < $1::date - 1
+				limit $2
+			)
+		`, day, upsellImpressionSweepLimit)
+	}
+}
+
+// PublicUpsellOffers returns the upsell offers for a checkout cart. The body
+// takes the same items as order creation, so thresholds see the exact
+// subtotal the order will have. Each rule shown counts as one impression per
+// cartToken and merchant-local day.
+func (h *Handler) PublicUpsellOffers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	merchantCode := readPathString(r, "code")
+	if merchantCode == "" {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Merchant code is required")
+		return
+	}
+
+	var body struct {
+		Items     []publicOrderItem `json:"items"`
+		CartToken string            `json:"cartToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	cartToken := strings.TrimSpace(body.CartToken)
+	if len(cartToken) > maxUpsellCartTokenLength {
+		response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("cartToken must be at most %d characters", maxUpsellCartTokenLength))
+		return
+	}
+
+	var (
+		merchantID     int64
+		timezone       string
+		merchantActive bool
+	)
+	if err := h.DB.QueryRow(ctx, `select id, timezone, is_active from merchants where code = $1`, merchantCode).
+		Scan(&merchantID, &timezone, &merchantActive); err != nil || !merchantActive {
+		response.Error(w, http.StatusNotFound, "MERCHANT_NOT_FOUND", "Merchant not found or inactive")
+		return
+	}
+
+	offers := []upsellOffer{}
+	if len(body.Items) > 0 {
+		orderItems, _, _, _, err := h.buildPublicOrderItems(ctx, merchantID, body.Items)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+		loc := loadTimezone(timezoneOrDefault(timezone))
+		offers, err = h.upsellOffersForCart(ctx, merchantID, loc, orderItems)
+		if err != nil {
+			h.Logger.Error("upsell offers query failed", zapError(err))
+			response.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch upsell offers")
+			return
+		}
+		h.recordUpsellImpressions(ctx, merchantID, time.Now().In(loc).Format("2006-01-02"), cartToken, offers)
+	}
+
+	data := make([]map[string]any, 0, len(offers))
+	for _, offer := range offers {
+		data = append(data, map[string]any{
+			"upsellRuleId": offer.Rule.ID,
+			"message":      offer.Rule.Message,
+			"maxQuantity":  offer.Rule.MaxQuantity,
+			"menu": map[string]any{
+				"id":          offer.MenuID,
+				"name":        offer.Name,
+				"description": offer.Description,
+				"imageUrl":    offer.ImageURL,
+				"price":       offer.Price,
+				"offerPrice":  offer.OfferPrice,
+			},
+		})
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data":    data,
+	})
+}
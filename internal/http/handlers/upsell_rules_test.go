@@ -0,0 +1,96 @@
+package handlers
+
+import "testing"
+
+func TestUpsellRuleMatches(t *testing.T) {
+	threshold := 100000.0
+	cart := upsellCart{
+		MenuIDs:     map[int64]bool{1: true, 2: true},
+		CategoryIDs: map[int64]bool{10: true},
+		Subtotal:    80000,
+	}
+	cases := []struct {
+		name string
+		rule upsellRule
+		want bool
+	}{
+		{"trigger menu in cart", upsellRule{TriggerMenuIDs: []int64{5, 2}}, true},
+		{"trigger menu missing", upsellRule{TriggerMenuIDs: []int64{5}}, false},
+		{"trigger category in cart", upsellRule{TriggerCategoryIDs: []int64{10}}, true},
+		{"threshold only, not reached", upsellRule{MinSubtotal: &threshold}, false},
+		{"trigger with threshold not reached", upsellRule{TriggerMenuIDs: []int64{1}, MinSubtotal: &threshold}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.rule.matches(cart); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	cart.Subtotal = 120000
+	if !(upsellRule{MinSubtotal: &threshold}).matches(cart) {
+		t.Fatalf("threshold-only rule should match a cart above the threshold")
+	}
+}
+
+func TestUpsellRuleOfferPrice(t *testing.T) {
+	amount := upsellDiscountAmountOff
+	percent := upsellDiscountPercentOff
+	five, ten, hundred := 5000.0, 10.0, 100.0
+	cases := []struct {
+		name  string
+		rule  upsellRule
+		price float64
+		want  float64
+	}{
+		{"no discount", upsellRule{}, 15000, 15000},
+		{"amount off", upsellRule{DiscountType: &amount, DiscountValue: &five}, 15000, 10000},
+		{"amount off floors at zero", upsellRule{DiscountType: &amount, DiscountValue: &five}, 3000, 0},
+		{"percent off", upsellRule{DiscountType: &percent, DiscountValue: &ten}, 15500, 13950},
+		{"free", upsellRule{DiscountType: &percent, DiscountValue: &hundred}, 15000, 0},
+	}
+	for _, tc := range cases {
+		if got := tc.rule.offerPrice(tc.price); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeUpsellRule(t *testing.T) {
+	percent := "percent_off"
+	amount := upsellDiscountAmountOff
+	threshold := 100000.0
+	over, value := 150.0, 5000.0
+	zero := int32(0)
+
+	rule, err := normalizeUpsellRule(upsellRulePayload{
+		Name:           " Fries deal ",
+		TriggerMenuIDs: []int64{1, 1},
+		OfferMenuIDs:   []int64{2},
+		DiscountType:   &amount,
+		DiscountValue:  &value,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Name != "Fries deal" || len(rule.TriggerMenuIDs) != 1 || rule.MaxQuantity != 1 || !rule.IsActive {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	invalid := []struct {
+		name string
+		body upsellRulePayload
+	}{
+		{"missing name", upsellRulePayload{TriggerMenuIDs: []int64{1}, OfferMenuIDs: []int64{2}}},
+		{"no trigger", upsellRulePayload{Name: "x", OfferMenuIDs: []int64{2}}},
+		{"no offer", upsellRulePayload{Name: "x", MinSubtotal: &threshold}},
+		{"offer is trigger", upsellRulePayload{Name: "x", TriggerMenuIDs: []int64{2}, OfferMenuIDs: []int64{2}}},
+		{"percent over 100", upsellRulePayload{Name: "x", MinSubtotal: &threshold, OfferMenuIDs: []int64{2}, DiscountType: &percent, DiscountValue: &over}},
+		{"discount without value", upsellRulePayload{Name: "x", MinSubtotal: &threshold, OfferMenuIDs: []int64{2}, DiscountType: &amount}},
+		{"zero max quantity", upsellRulePayload{Name: "x", MinSubtotal: &threshold, OfferMenuIDs: []int64{2}, MaxQuantity: &zero}},
+	}
+	for _, tc := range invalid {
+		if _, err := normalizeUpsellRule(tc.body); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"genfity-order-services/internal/config"
+	"genfity-order-services/internal/courier"
+	"genfity-order-services/internal/geocode"
 	"genfity-order-services/internal/http/handlers"
 	"genfity-order-services/internal/middleware"
 	"genfity-order-services/internal/queue"
@@ -19,7 +21,35 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueClient *queue.Client, wsServer *ws.Server) http.Handler {
+// NewHandler builds the handler set shared by the router and the background
+// jobs started from main.
+func NewHandler(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueClient *queue.Client) *handlers.Handler {
+	geocoder, err := geocode.New(geocode.Config{
+		Provider:    cfg.GeocoderProvider,
+		BaseURL:     cfg.GeocoderBaseURL,
+		FixturePath: cfg.GeocoderFixturePath,
+	})
+	if err != nil {
+		logger.Warn("geocoder unavailable, falling back to nominatim", zap.Error(err))
+		geocoder = geocode.NewNominatim(cfg.GeocoderBaseURL, "")
+	}
+
+	couriers, err := courier.NewRegistry(courier.Config{
+		Providers:     cfg.CourierProviders,
+		WebhookSecret: cfg.CourierWebhookSecret,
+		MockStep:      cfg.CourierMockStep,
+	})
+	if err != nil {
+		logger.Warn("courier providers unavailable", zap.Error(err))
+		couriers, _ = courier.NewRegistry(courier.Config{})
+	}
+
+	return &handlers.Handler{DB: db, Logger: logger, Config: cfg, Queue: queueClient, Geocoder: geocoder, Couriers: couriers}
+}
+
+func NewRouter(h *handlers.Handler, wsServer *ws.Server) http.Handler {
+	db, logger, cfg := h.DB, h.Logger, h.Config
+
 	r := chi.NewRouter()
 	r.Use(requestLogger(logger))
 	r.Use(middleware.RequestID())
@@ -55,8 +85,6 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Use(cors.Handler(options))
 	}
 
-	h := &handlers.Handler{DB: db, Logger: logger, Config: cfg, Queue: queueClient}
-
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
@@ -69,6 +97,7 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Post("/orders/{orderNumber}/upload-proof", h.PublicOrderUploadProof)
 		r.Post("/orders/{orderNumber}/confirm-payment", h.PublicOrderConfirmPayment)
 		r.Get("/orders/{orderNumber}/wait-time", h.PublicOrderWaitTime)
+		r.Post("/orders/{orderNumber}/arrived", h.PublicOrderCurbsideArrived)
 		r.Get("/orders/{orderNumber}/group-details", h.PublicOrderGroupDetails)
 		r.Get("/orders/{orderNumber}/feedback", h.PublicOrderFeedbackGet)
 		r.Post("/orders/{orderNumber}/feedback", h.PublicOrderFeedbackCreate)
@@ -92,11 +121,13 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Get("/merchants/{code}/stock-stream", h.PublicMerchantStockStream)
 		r.Get("/merchants/{code}/available-times", h.PublicMerchantAvailableTimes)
 		r.Post("/merchants/{code}/delivery/quote", h.PublicDeliveryQuote)
+		r.Post("/couriers/{provider}/webhook", h.PublicCourierWebhook)
 		r.Get("/merchants/{code}/menus", h.PublicMerchantMenus)
 		r.Get("/merchants/{code}/menus/{id}", h.PublicMerchantMenuDetail)
 		r.Get("/merchants/{code}/menus/{id}/addons", h.PublicMerchantMenuAddons)
 		r.Get("/merchants/{code}/menus/search", h.PublicMerchantMenuSearch)
 		r.Get("/merchants/{code}/recommendations", h.PublicMerchantRecommendations)
+		r.Post("/merchants/{code}/upsell-offers", h.PublicUpsellOffers)
 		// Push notifications
 		r.Get("/push/subscribe", h.PublicPushGetVAPIDKey)
 		r.Post("/push/subscribe", h.PublicPushSubscribe)
@@ -108,6 +139,10 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Use(setResponseHeader("X-Order-Service-Origin", "native"))
 		r.Use(middleware.MerchantAuth(db, cfg.JWTSecret))
 
+		// Writes that change what the public menu endpoints return bump the
+		// merchant's menu cache version once they succeed.
+		menu := r.With(h.BumpMenuCacheOnWrite)
+
 		r.Get("/orders", h.MerchantOrdersList)
 		r.Get("/orders/active", h.MerchantActiveOrders)
 		r.Get("/orders/analytics", h.MerchantOrderAnalytics)
@@ -118,7 +153,10 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Put("/orders/{orderId}/admin-note", h.MerchantOrderAdminNote)
 		r.Post("/orders/{orderId}/cancel", h.MerchantOrderCancel)
 		r.Put("/orders/{orderId}/delivery/assign", h.MerchantOrderDeliveryAssign)
+		r.Post("/orders/{orderId}/courier/quote", h.MerchantOrderCourierQuote)
+		r.Post("/orders/{orderId}/handover", h.MerchantOrderHandover)
 		r.Post("/orders/{orderId}/cod/confirm", h.MerchantOrderCashOnDeliveryConfirm)
+		r.Get("/orders/{orderId}/dispatch-attempts", h.MerchantOrderDispatchAttempts)
 		r.Post("/orders/{orderId}/payment", h.MerchantOrderPayment)
 		r.Get("/orders/{orderId}/receipt-html", h.MerchantOrderReceiptHTML)
 		r.Get("/orders/{orderId}/receipt", h.MerchantOrderReceiptPDF)
@@ -134,78 +172,127 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Put("/orders/pos/{orderId}", h.MerchantPOSOrderUpdate)
 		r.Get("/pos/menu", h.MerchantPOSMenuGet)
 		r.Get("/pos-settings", h.MerchantPOSSettingsGet)
-		r.Put("/pos-settings", h.MerchantPOSSettingsPut)
+		menu.Put("/pos-settings", h.MerchantPOSSettingsPut)
 		r.Put("/orders/{orderId}/status", h.MerchantUpdateOrderStatus)
 		r.Get("/orders/resolve", h.MerchantResolveOrder)
 		r.Get("/menu", h.MerchantMenuList)
-		r.Post("/menu", h.MerchantMenuCreate)
+		menu.Post("/menu", h.MerchantMenuCreate)
 		r.Get("/menu/{id}", h.MerchantMenuDetail)
-		r.Put("/menu/{id}", h.MerchantMenuUpdate)
-		r.Delete("/menu/{id}", h.MerchantMenuDelete)
-		r.Post("/menu/builder", h.MerchantMenuBuilderCreate)
-		r.Put("/menu/builder", h.MerchantMenuBuilderUpdate)
-		r.Post("/menu/{id}/addon-categories", h.MerchantMenuAddAddonCategory)
-		r.Delete("/menu/{id}/addon-categories/{categoryId}", h.MerchantMenuRemoveAddonCategory)
-		r.Put("/menu/{id}/categories", h.MerchantMenuUpdateCategories)
-		r.Post("/menu/{id}/duplicate", h.MerchantMenuDuplicate)
-		r.Post("/menu/{id}/add-stock", h.MerchantMenuAddStock)
-		r.Patch("/menu/{id}/toggle-active", h.MerchantMenuToggleActive)
-		r.Post("/menu/{id}/restore", h.MerchantMenuRestore)
-		r.Delete("/menu/{id}/permanent-delete", h.MerchantMenuPermanentDelete)
-		r.Post("/menu/bulk", h.MerchantMenuBulk)
-		r.Post("/menu/bulk-delete", h.MerchantMenuBulkDelete)
-		r.Post("/menu/bulk-restore", h.MerchantMenuBulkRestore)
+		menu.Put("/menu/{id}", h.MerchantMenuUpdate)
+		menu.Delete("/menu/{id}", h.MerchantMenuDelete)
+		menu.Post("/menu/builder", h.MerchantMenuBuilderCreate)
+		menu.Put("/menu/builder", h.MerchantMenuBuilderUpdate)
+		menu.Post("/menu/{id}/addon-categories", h.MerchantMenuAddAddonCategory)
+		menu.Delete("/menu/{id}/addon-categories/{categoryId}", h.MerchantMenuRemoveAddonCategory)
+		menu.Put("/menu/{id}/categories", h.MerchantMenuUpdateCategories)
+		r.Get("/menu/{id}/variants", h.MerchantMenuVariants)
+		menu.Put("/menu/{id}/variants", h.MerchantMenuVariantsReplace)
+		r.Get("/menu/{id}/bundle", h.MerchantMenuBundle)
+		menu.Put("/menu/{id}/bundle", h.MerchantMenuBundleReplace)
+		r.Get("/menu/{id}/dietary", h.MerchantMenuDietary)
+		menu.Put("/menu/{id}/dietary", h.MerchantMenuDietaryReplace)
+		r.Get("/menu/{id}/search-keywords", h.MerchantMenuSearchKeywords)
+		menu.Put("/menu/{id}/search-keywords", h.MerchantMenuSearchKeywordsReplace)
+		r.Get("/menu/{id}/recipe", h.MerchantMenuRecipe)
+		menu.Put("/menu/{id}/recipe", h.MerchantMenuRecipeReplace)
+		menu.Post("/menu/{id}/duplicate", h.MerchantMenuDuplicate)
+		menu.Post("/menu/{id}/add-stock", h.MerchantMenuAddStock)
+		menu.Patch("/menu/{id}/toggle-active", h.MerchantMenuToggleActive)
+		menu.Post("/menu/{id}/restore", h.MerchantMenuRestore)
+		menu.Delete("/menu/{id}/permanent-delete", h.MerchantMenuPermanentDelete)
+		menu.Post("/menu/bulk", h.MerchantMenuBulk)
+		menu.Post("/menu/bulk-delete", h.MerchantMenuBulkDelete)
+		menu.Post("/menu/bulk-restore", h.MerchantMenuBulkRestore)
 		r.Get("/menu/bulk-soft-delete", h.MerchantMenuBulkSoftDeleteToken)
-		r.Post("/menu/bulk-soft-delete", h.MerchantMenuBulkSoftDelete)
-		r.Post("/menu/bulk-update-status", h.MerchantMenuBulkUpdateStatus)
-		r.Post("/menu/bulk-upload", h.MerchantMenuBulkUpload)
-		r.Post("/menu/rebuild-thumbnails", h.MerchantMenuRebuildThumbnails)
-		r.Post("/menu/reset-stock", h.MerchantMenuResetStock)
+		menu.Post("/menu/bulk-soft-delete", h.MerchantMenuBulkSoftDelete)
+		menu.Post("/menu/bulk-update-status", h.MerchantMenuBulkUpdateStatus)
+		menu.Post("/menu/bulk-upload", h.MerchantMenuBulkUpload)
+		menu.Post("/menu/import", h.MerchantMenuImport)
+		r.Get("/menu/export", h.MerchantMenuExport)
+		r.Get("/menu/recommendations/rules", h.MerchantMenuRecommendationRules)
+		r.Put("/menu/recommendations/rules", h.MerchantMenuRecommendationRulesReplace)
+		r.Post("/menu/recommendations/recompute", h.MerchantMenuRecommendationsRecompute)
+		r.Get("/menu/search-synonyms", h.MerchantMenuSearchSynonyms)
+		menu.Put("/menu/search-synonyms", h.MerchantMenuSearchSynonymsReplace)
+		r.Get("/menu-drafts", h.MerchantMenuDrafts)
+		r.Post("/menu-drafts", h.MerchantMenuDraftCreate)
+		r.Get("/menu-drafts/{id}", h.MerchantMenuDraftDetail)
+		r.Put("/menu-drafts/{id}", h.MerchantMenuDraftUpdate)
+		r.Delete("/menu-drafts/{id}", h.MerchantMenuDraftDiscard)
+		r.Get("/menu-drafts/{id}/preview", h.MerchantMenuDraftPreview)
+		r.Get("/menu-drafts/{id}/diff", h.MerchantMenuDraftDiff)
+		r.Post("/menu-drafts/{id}/publish", h.MerchantMenuDraftPublish)
+		r.Delete("/menu-drafts/{id}/schedule", h.MerchantMenuDraftUnschedule)
+		r.Get("/menu-versions", h.MerchantMenuVersions)
+		r.Get("/menu-versions/{id}", h.MerchantMenuVersionDetail)
+		r.Get("/menu-versions/{id}/diff", h.MerchantMenuVersionDiff)
+		r.Post("/menu-versions/{id}/rollback", h.MerchantMenuVersionRollback)
+		r.Get("/translations", h.MerchantTranslationsList)
+		menu.Put("/translations", h.MerchantTranslationsUpsert)
+		r.Get("/translations/coverage", h.MerchantTranslationsCoverage)
+		r.Get("/ingredients", h.MerchantIngredientsList)
+		menu.Post("/ingredients", h.MerchantIngredientCreate)
+		r.Get("/ingredients/movements", h.MerchantIngredientMovements)
+		menu.Post("/ingredients/stocktake", h.MerchantIngredientStocktake)
+		menu.Put("/ingredients/{id}", h.MerchantIngredientUpdate)
+		menu.Delete("/ingredients/{id}", h.MerchantIngredientDelete)
+		menu.Post("/ingredients/{id}/movements", h.MerchantIngredientMovementCreate)
+		menu.Post("/menu/rebuild-thumbnails", h.MerchantMenuRebuildThumbnails)
+		menu.Post("/menu/reset-stock", h.MerchantMenuResetStock)
 		r.Get("/menu/stock/overview", h.MerchantMenuStockOverview)
-		r.Post("/menu/stock/bulk-update", h.MerchantMenuStockBulkUpdate)
-		r.Post("/bulk/menu", h.MerchantBulkMenuLegacy)
+		menu.Post("/menu/stock/bulk-update", h.MerchantMenuStockBulkUpdate)
+		r.Get("/menu/stock/alert-settings", h.MerchantStockAlertSettingsGet)
+		r.Put("/menu/stock/alert-settings", h.MerchantStockAlertSettingsPut)
+		r.Get("/menu/stock/reset-schedule", h.MerchantStockResetScheduleGet)
+		r.Put("/menu/stock/reset-schedule", h.MerchantStockResetSchedulePut)
+		r.Get("/menu/stock/reset-runs", h.MerchantStockResetRuns)
+		menu.Post("/bulk/menu", h.MerchantBulkMenuLegacy)
 		r.Get("/addon-categories", h.MerchantAddonCategoriesList)
-		r.Post("/addon-categories", h.MerchantAddonCategoriesCreate)
+		menu.Post("/addon-categories", h.MerchantAddonCategoriesCreate)
 		r.Get("/addon-categories/{id}", h.MerchantAddonCategoriesDetail)
-		r.Put("/addon-categories/{id}", h.MerchantAddonCategoriesUpdate)
-		r.Delete("/addon-categories/{id}", h.MerchantAddonCategoriesDelete)
-		r.Patch("/addon-categories/{id}/toggle-active", h.MerchantAddonCategoriesToggleActive)
-		r.Post("/addon-categories/{id}/restore", h.MerchantAddonCategoriesRestore)
-		r.Delete("/addon-categories/{id}/permanent-delete", h.MerchantAddonCategoriesPermanentDelete)
+		menu.Put("/addon-categories/{id}", h.MerchantAddonCategoriesUpdate)
+		menu.Delete("/addon-categories/{id}", h.MerchantAddonCategoriesDelete)
+		menu.Patch("/addon-categories/{id}/toggle-active", h.MerchantAddonCategoriesToggleActive)
+		menu.Post("/addon-categories/{id}/restore", h.MerchantAddonCategoriesRestore)
+		menu.Delete("/addon-categories/{id}/permanent-delete", h.MerchantAddonCategoriesPermanentDelete)
 		r.Get("/addon-categories/{id}/delete-preview", h.MerchantAddonCategoriesDeletePreview)
 		r.Get("/addon-categories/{id}/items", h.MerchantAddonCategoriesItemsList)
-		r.Post("/addon-categories/{id}/reorder-items", h.MerchantAddonCategoriesReorderItems)
+		menu.Post("/addon-categories/{id}/reorder-items", h.MerchantAddonCategoriesReorderItems)
 		r.Get("/addon-categories/{id}/relationships", h.MerchantAddonCategoriesRelationships)
-		r.Post("/addon-categories/bulk-delete", h.MerchantAddonCategoriesBulkDelete)
+		menu.Post("/addon-categories/bulk-delete", h.MerchantAddonCategoriesBulkDelete)
 		r.Get("/addon-categories/bulk-soft-delete", h.MerchantAddonCategoriesBulkSoftDeleteToken)
-		r.Post("/addon-categories/bulk-soft-delete", h.MerchantAddonCategoriesBulkSoftDelete)
+		menu.Post("/addon-categories/bulk-soft-delete", h.MerchantAddonCategoriesBulkSoftDelete)
 		r.Get("/addon-items", h.MerchantAddonItemsList)
-		r.Post("/addon-items", h.MerchantAddonItemsCreate)
+		menu.Post("/addon-items", h.MerchantAddonItemsCreate)
 		r.Get("/addon-items/{id}", h.MerchantAddonItemsDetail)
-		r.Put("/addon-items/{id}", h.MerchantAddonItemsUpdate)
-		r.Delete("/addon-items/{id}", h.MerchantAddonItemsDelete)
-		r.Patch("/addon-items/{id}/toggle-active", h.MerchantAddonItemsToggleActive)
-		r.Post("/addon-items/{id}/restore", h.MerchantAddonItemsRestore)
-		r.Delete("/addon-items/{id}/permanent-delete", h.MerchantAddonItemsPermanentDelete)
+		menu.Put("/addon-items/{id}", h.MerchantAddonItemsUpdate)
+		r.Get("/addon-items/{id}/dietary", h.MerchantAddonItemDietary)
+		menu.Put("/addon-items/{id}/dietary", h.MerchantAddonItemDietaryReplace)
+		r.Get("/addon-items/{id}/recipe", h.MerchantAddonItemRecipe)
+		menu.Put("/addon-items/{id}/recipe", h.MerchantAddonItemRecipeReplace)
+		menu.Delete("/addon-items/{id}", h.MerchantAddonItemsDelete)
+		menu.Patch("/addon-items/{id}/toggle-active", h.MerchantAddonItemsToggleActive)
+		menu.Post("/addon-items/{id}/restore", h.MerchantAddonItemsRestore)
+		menu.Delete("/addon-items/{id}/permanent-delete", h.MerchantAddonItemsPermanentDelete)
 		r.Get("/addon-items/bulk-soft-delete", h.MerchantAddonItemsBulkSoftDeleteToken)
-		r.Post("/addon-items/bulk-soft-delete", h.MerchantAddonItemsBulkSoftDelete)
-		r.Post("/addon-items/bulk-upload", h.MerchantAddonItemsBulkUpload)
-		r.Post("/bulk/addon-items", h.MerchantBulkAddonItemsLegacy)
+		menu.Post("/addon-items/bulk-soft-delete", h.MerchantAddonItemsBulkSoftDelete)
+		menu.Post("/addon-items/bulk-upload", h.MerchantAddonItemsBulkUpload)
+		menu.Post("/bulk/addon-items", h.MerchantBulkAddonItemsLegacy)
 		r.Get("/categories", h.MerchantCategoriesList)
-		r.Post("/categories", h.MerchantCategoriesCreate)
-		r.Put("/categories/{id}", h.MerchantCategoriesUpdate)
-		r.Delete("/categories/{id}", h.MerchantCategoriesDelete)
+		menu.Post("/categories", h.MerchantCategoriesCreate)
+		menu.Put("/categories/{id}", h.MerchantCategoriesUpdate)
+		menu.Delete("/categories/{id}", h.MerchantCategoriesDelete)
 		r.Get("/categories/{id}/delete-preview", h.MerchantCategoriesDeletePreview)
 		r.Get("/categories/{id}/menus", h.MerchantCategoryMenusList)
-		r.Post("/categories/{id}/menus", h.MerchantCategoryMenusAdd)
-		r.Delete("/categories/{id}/menus/{menuId}", h.MerchantCategoryMenusRemove)
-		r.Delete("/categories/{id}/permanent-delete", h.MerchantCategoriesPermanentDelete)
-		r.Post("/categories/{id}/restore", h.MerchantCategoriesRestore)
-		r.Patch("/categories/{id}/toggle-active", h.MerchantCategoriesToggleActive)
-		r.Post("/categories/bulk-delete", h.MerchantCategoriesBulkDelete)
+		menu.Post("/categories/{id}/menus", h.MerchantCategoryMenusAdd)
+		menu.Delete("/categories/{id}/menus/{menuId}", h.MerchantCategoryMenusRemove)
+		menu.Delete("/categories/{id}/permanent-delete", h.MerchantCategoriesPermanentDelete)
+		menu.Post("/categories/{id}/restore", h.MerchantCategoriesRestore)
+		menu.Patch("/categories/{id}/toggle-active", h.MerchantCategoriesToggleActive)
+		menu.Post("/categories/bulk-delete", h.MerchantCategoriesBulkDelete)
 		r.Get("/categories/bulk-soft-delete", h.MerchantCategoriesBulkSoftDeleteToken)
-		r.Post("/categories/bulk-soft-delete", h.MerchantCategoriesBulkSoftDelete)
-		r.Post("/categories/reorder", h.MerchantCategoriesReorder)
+		menu.Post("/categories/bulk-soft-delete", h.MerchantCategoriesBulkSoftDelete)
+		menu.Post("/categories/reorder", h.MerchantCategoriesReorder)
 		r.Get("/reservations", h.MerchantReservationsList)
 		r.Get("/reservations/active", h.MerchantReservationsActive)
 		r.Get("/reservations/count", h.MerchantReservationCount)
@@ -224,25 +311,51 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Put("/staff/{id}/permissions", h.MerchantStaffPermissionsUpdate)
 		r.Patch("/staff/{id}/permissions", h.MerchantStaffPermissionsToggle)
 		r.Get("/drivers", h.MerchantDriversList)
+		r.Get("/drivers/availability", h.MerchantDriversAvailability)
 		r.Post("/drivers", h.MerchantDriversCreate)
 		r.Patch("/drivers/{userId}", h.MerchantDriversUpdate)
 		r.Delete("/drivers/{userId}", h.MerchantDriversDelete)
+		r.Get("/driver/orders", h.DriverOrdersList)
+		r.Get("/driver/orders/{orderId}", h.DriverOrderDetail)
+		r.Put("/driver/orders/{orderId}/delivery-status", h.DriverOrderDeliveryStatus)
+		r.Post("/driver/orders/{orderId}/proof", h.DriverOrderProofUpload)
+		r.Post("/driver/orders/{orderId}/cod/confirm", h.DriverOrderCashOnDeliveryConfirm)
+		r.Get("/driver/availability", h.DriverAvailabilityGet)
+		r.Put("/driver/availability", h.DriverAvailabilityPut)
+		r.Get("/driver/offers", h.DriverOffersList)
+		r.Post("/driver/offers/{attemptId}/accept", h.DriverOfferAccept)
+		r.Post("/driver/offers/{attemptId}/decline", h.DriverOfferDecline)
+		r.Get("/delivery-batches", h.MerchantDeliveryBatchList)
+		r.Post("/delivery-batches", h.MerchantDeliveryBatchCreate)
+		r.Get("/delivery-batches/suggestions", h.MerchantDeliveryBatchSuggestions)
+		r.Get("/delivery-batches/{batchId}", h.MerchantDeliveryBatchDetail)
+		r.Put("/delivery-batches/{batchId}/assign", h.MerchantDeliveryBatchAssign)
+		r.Delete("/delivery-batches/{batchId}", h.MerchantDeliveryBatchCancel)
+		r.Get("/dispatch-settings", h.MerchantDispatchSettingsGet)
+		menu.Put("/dispatch-settings", h.MerchantDispatchSettingsPut)
+		r.Get("/courier-settings", h.MerchantCourierSettingsGet)
+		menu.Put("/courier-settings", h.MerchantCourierSettingsPut)
 		r.Get("/delivery/zones", h.MerchantDeliveryZonesList)
-		r.Post("/delivery/zones", h.MerchantDeliveryZonesUpsert)
-		r.Delete("/delivery/zones", h.MerchantDeliveryZonesDelete)
-		r.Post("/delivery/zones/bulk-import", h.MerchantDeliveryZonesBulkImport)
+		menu.Post("/delivery/zones", h.MerchantDeliveryZonesUpsert)
+		menu.Delete("/delivery/zones", h.MerchantDeliveryZonesDelete)
+		menu.Post("/delivery/zones/bulk-import", h.MerchantDeliveryZonesBulkImport)
+		r.Get("/delivery/zones/export", h.MerchantDeliveryZonesExport)
+		r.Get("/delivery/pricing", h.MerchantDeliveryPricingGet)
+		menu.Put("/delivery/pricing", h.MerchantDeliveryPricingPut)
+		r.Get("/delivery/eta-settings", h.MerchantDeliveryEtaSettingsGet)
+		menu.Put("/delivery/eta-settings", h.MerchantDeliveryEtaSettingsPut)
 		r.Get("/profile", h.MerchantProfileGet)
-		r.Put("/profile", h.MerchantProfilePut)
-		r.Put("/opening-hours", h.MerchantOpeningHoursPut)
+		menu.Put("/profile", h.MerchantProfilePut)
+		menu.Put("/opening-hours", h.MerchantOpeningHoursPut)
 		r.Get("/special-hours", h.MerchantSpecialHoursGet)
-		r.Post("/special-hours", h.MerchantSpecialHoursPost)
+		menu.Post("/special-hours", h.MerchantSpecialHoursPost)
 		r.Get("/special-hours/{id}", h.MerchantSpecialHoursDetailGet)
-		r.Put("/special-hours/{id}", h.MerchantSpecialHoursDetailPut)
-		r.Delete("/special-hours/{id}", h.MerchantSpecialHoursDetailDelete)
+		menu.Put("/special-hours/{id}", h.MerchantSpecialHoursDetailPut)
+		menu.Delete("/special-hours/{id}", h.MerchantSpecialHoursDetailDelete)
 		r.Get("/mode-schedules", h.MerchantModeSchedulesGet)
-		r.Post("/mode-schedules", h.MerchantModeSchedulesPost)
-		r.Delete("/mode-schedules", h.MerchantModeSchedulesDelete)
-		r.Put("/toggle-open", h.MerchantToggleOpen)
+		menu.Post("/mode-schedules", h.MerchantModeSchedulesPost)
+		menu.Delete("/mode-schedules", h.MerchantModeSchedulesDelete)
+		menu.Put("/toggle-open", h.MerchantToggleOpen)
 		r.Get("/subscription", h.MerchantSubscriptionGet)
 		r.Get("/subscription/can-switch", h.MerchantSubscriptionCanSwitch)
 		r.Get("/subscription/history", h.MerchantSubscriptionHistory)
@@ -254,11 +367,14 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Post("/balance/transfer", h.MerchantBalanceTransfer)
 		r.Get("/balance/group", h.MerchantBalanceGroup)
 		r.Get("/branches", h.MerchantBranchesList)
-		r.Post("/branches", h.MerchantBranchesCreate)
-		r.Post("/branches/move", h.MerchantBranchesMove)
-		r.Post("/branches/set-main", h.MerchantBranchesSetMain)
+		menu.Post("/branches", h.MerchantBranchesCreate)
+		menu.Post("/branches/move", h.MerchantBranchesMove)
+		menu.Post("/branches/set-main", h.MerchantBranchesSetMain)
+		r.Post("/branches/catalog-sync/preview", h.MerchantCatalogSyncPreview)
+		r.Post("/branches/catalog-sync", h.MerchantCatalogSync)
+		r.Get("/branches/catalog-sync/history", h.MerchantCatalogSyncHistory)
 		r.Get("/payment-settings", h.MerchantPaymentSettingsGet)
-		r.Put("/payment-settings", h.MerchantPaymentSettingsPut)
+		menu.Put("/payment-settings", h.MerchantPaymentSettingsPut)
 		r.Get("/payment-request", h.MerchantPaymentRequestList)
 		r.Post("/payment-request", h.MerchantPaymentRequestCreate)
 		r.Get("/payment-request/active", h.MerchantPaymentRequestActive)
@@ -269,6 +385,7 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Get("/analytics/customers", h.MerchantCustomerAnalytics)
 		r.Get("/analytics/menu-performance", h.MerchantMenuPerformanceAnalytics)
 		r.Get("/analytics/sales", h.MerchantSalesAnalytics)
+		r.Get("/analytics/search-misses", h.MerchantSearchMissesAnalytics)
 		r.Get("/revenue", h.MerchantRevenue)
 		r.Get("/reports", h.MerchantReports)
 		r.Get("/reports/sales-dashboard", h.MerchantReportsSalesDashboard)
@@ -276,7 +393,7 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Get("/feedback/analytics", h.MerchantFeedbackAnalytics)
 		r.Get("/order-vouchers/analytics", h.MerchantOrderVoucherAnalytics)
 		r.Get("/order-vouchers/settings", h.MerchantOrderVoucherSettingsGet)
-		r.Put("/order-vouchers/settings", h.MerchantOrderVoucherSettingsUpdate)
+		menu.Put("/order-vouchers/settings", h.MerchantOrderVoucherSettingsUpdate)
 		r.Get("/order-vouchers/templates", h.MerchantOrderVoucherTemplatesList)
 		r.Post("/order-vouchers/templates", h.MerchantOrderVoucherTemplatesCreate)
 		r.Get("/order-vouchers/templates/{id}", h.MerchantOrderVoucherTemplateGet)
@@ -289,7 +406,7 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Get("/users", h.MerchantUsersList)
 		r.Get("/deleted-items", h.MerchantDeletedItemsList)
 		r.Get("/stock-photos", h.MerchantStockPhotosList)
-		r.Post("/stock-photos/{id}/use", h.MerchantStockPhotoUse)
+		menu.Post("/stock-photos/{id}/use", h.MerchantStockPhotoUse)
 		r.Post("/vouchers/redeem", h.MerchantVouchersRedeem)
 		r.Get("/menu-books", h.MerchantMenuBooksList)
 		r.Post("/menu-books", h.MerchantMenuBooksCreate)
@@ -297,10 +414,15 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Put("/menu-books/{id}", h.MerchantMenuBooksUpdate)
 		r.Delete("/menu-books/{id}", h.MerchantMenuBooksDelete)
 		r.Get("/special-prices", h.MerchantSpecialPricesList)
-		r.Post("/special-prices", h.MerchantSpecialPricesCreate)
+		menu.Post("/special-prices", h.MerchantSpecialPricesCreate)
 		r.Get("/special-prices/{id}", h.MerchantSpecialPricesDetail)
-		r.Put("/special-prices/{id}", h.MerchantSpecialPricesUpdate)
-		r.Delete("/special-prices/{id}", h.MerchantSpecialPricesDelete)
+		menu.Put("/special-prices/{id}", h.MerchantSpecialPricesUpdate)
+		menu.Delete("/special-prices/{id}", h.MerchantSpecialPricesDelete)
+		r.Get("/upsell-rules", h.MerchantUpsellRulesList)
+		r.Post("/upsell-rules", h.MerchantUpsellRulesCreate)
+		r.Get("/upsell-rules/report", h.MerchantUpsellRulesReport)
+		r.Put("/upsell-rules/{id}", h.MerchantUpsellRulesUpdate)
+		r.Delete("/upsell-rules/{id}", h.MerchantUpsellRulesDelete)
 		r.Get("/payment/verify", h.MerchantPaymentVerify)
 		r.Post("/receipt/preview", h.MerchantReceiptPreviewPDF)
 		r.Post("/receipt/preview-html", h.MerchantReceiptPreviewHTML)
@@ -312,18 +434,19 @@ func NewRouter(db *pgxpool.Pool, logger *zap.Logger, cfg config.Config, queueCli
 		r.Get("/customer-display/state", h.MerchantCustomerDisplayStateGet)
 		r.Put("/customer-display/state", h.MerchantCustomerDisplayStatePut)
 		r.Get("/customer-display/sessions", h.MerchantCustomerDisplaySessions)
-		r.Post("/upload-logo", h.MerchantUploadLogo)
-		r.Post("/upload/qris", h.MerchantUploadQris)
-		r.Post("/upload/merchant-image", h.MerchantUploadMerchantImage)
-		r.Post("/upload/promo-banner", h.MerchantUploadPromoBanner)
-		r.Post("/upload/menu-image", h.MerchantUploadMenuImage)
-		r.Post("/upload/menu-image/confirm", h.MerchantMenuImageConfirm)
-		r.Post("/upload/delete-image", h.MerchantDeleteImage)
+		menu.Post("/upload-logo", h.MerchantUploadLogo)
+		menu.Post("/upload/qris", h.MerchantUploadQris)
+		menu.Post("/upload/merchant-image", h.MerchantUploadMerchantImage)
+		menu.Post("/upload/promo-banner", h.MerchantUploadPromoBanner)
+		menu.Post("/upload/menu-image", h.MerchantUploadMenuImage)
+		menu.Post("/upload/menu-image/confirm", h.MerchantMenuImageConfirm)
+		menu.Post("/upload/delete-image", h.MerchantDeleteImage)
 		r.Post("/upload/presign", h.MerchantUploadPresign)
-		r.Post("/upload/confirm", h.MerchantUploadConfirm)
+		menu.Post("/upload/confirm", h.MerchantUploadConfirm)
 
-		r.NotFound(h.MerchantProxy)
-		r.MethodNotAllowed(h.MerchantProxy)
+		// Proxied writes may change menu content in the Next.js app too.
+		r.NotFound(h.BumpMenuCacheOnWrite(http.HandlerFunc(h.MerchantProxy)).ServeHTTP)
+		r.MethodNotAllowed(h.BumpMenuCacheOnWrite(http.HandlerFunc(h.MerchantProxy)).ServeHTTP)
 	})
 
 	if wsServer != nil {
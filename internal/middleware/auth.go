@@ -61,6 +61,10 @@ func writeAuthErrorDebug(w http.ResponseWriter, status int, message string, debu
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+func isDriverAppPath(path string) bool {
+	return path == "/api/merchant/driver" || strings.HasPrefix(path, "/api/merchant/driver/")
+}
+
 func isMerchantLockExempt(path string, method string) bool {
 	if strings.HasPrefix(path, "/api/merchant/subscription") {
 		return true
@@ -90,7 +94,9 @@ func MerchantAuth(db *pgxpool.Pool, jwtSecret string) func(http.Handler) http.Ha
 				return
 			}
 
-			if claims.Role != auth.RoleMerchantOwner && claims.Role != auth.RoleMerchantStaff {
+			// Delivery drivers only get the driver app endpoints.
+			isDriverRole := claims.Role == auth.RoleDelivery && isDriverAppPath(r.URL.Path)
+			if claims.Role != auth.RoleMerchantOwner && claims.Role != auth.RoleMerchantStaff && !isDriverRole {
 				writeAuthError(w, http.StatusForbidden, "Merchant access required")
 				return
 			}
@@ -100,6 +100,9 @@ func ProcessEventToJobs(ctx context.Context, db *pgxpool.Pool, qc *Client, body
 		// unknown envelope
 		return nil
 	}
+	if evt.Type == StockLowEvent || evt.Type == StockDepletedEvent {
+		return processStockAlertEvent(ctx, db, qc, body)
+	}
 	if evt.Type != "order.status.updated" {
 		// ignore
 		return nil
@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	StockLowEvent      = "menu.stock.low"
+	StockDepletedEvent = "menu.stock.depleted"
+)
+
+type stockAlertEvent struct {
+	Type       string `json:"type"`
+	MerchantID int64  `json:"merchantId"`
+	ItemType   string `json:"itemType"`
+	ItemID     int64  `json:"itemId"`
+	ItemName   string `json:"itemName"`
+	StockQty   *int32 `json:"stockQty"`
+	Threshold  int32  `json:"threshold"`
+	Level      string `json:"level"`
+}
+
+// processStockAlertEvent turns a menu.stock.low / menu.stock.depleted event
+// into merchant push and email jobs. The alert state row doubles as the
+// de-duplication record: a job is only enqueued while the item is still at
+// the event's level, and not again for the same level within the merchant's
+// cool-down window.
+func processStockAlertEvent(ctx context.Context, db *pgxpool.Pool, qc *Client, body []byte) error {
+	var evt stockAlertEvent
+	if err := json.Unmarshal(body, &evt); err != nil {
+		return err
+	}
+	if evt.MerchantID == 0 || evt.ItemID == 0 || strings.TrimSpace(evt.Level) == "" {
+		return nil
+	}
+
+	var (
+		merchantCode  string
+		merchantName  string
+		merchantEmail *string
+	)
+	err := db.QueryRow(ctx, `
+		update stock_alert_states s
+		set notified_level = s.level, notified_at = now()
+		from merchants m
+		left join stock_alert_settings sas on sas.merchant_id = m.id
+		where m.id = s.merchant_id
+		  and s.item_type = $1 and s.item_id = $2 and s.merchant_id = $3 and s.level = $4
+		  and m.stock_alert_enabled = true
+		  and (
+				s.notified_at is null
+				or s.notified_level is distinct from s.level
+				or s.notified_at < now() - make_interval(mins => coalesce(sas.cooldown_minutes, 60))
+		  )
+		returning m.code, m.name, m.email
+	`, evt.ItemType, evt.ItemID, evt.MerchantID, evt.Level).Scan(&merchantCode, &merchantName, &merchantEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already notified within the cool-down, or the item has moved on.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"merchantId":   fmt.Sprintf("%d", evt.MerchantID),
+		"merchantCode": merchantCode,
+		"merchantName": merchantName,
+		"itemType":     evt.ItemType,
+		"itemId":       fmt.Sprintf("%d", evt.ItemID),
+		"itemName":     evt.ItemName,
+		"stockQty":     evt.StockQty,
+		"threshold":    evt.Threshold,
+		"level":        evt.Level,
+	}
+	if err := publishNotificationJob(ctx, qc, "push.merchant_stock_alert", payload); err != nil {
+		return err
+	}
+
+	recipients, err := stockAlertRecipients(ctx, db, evt.MerchantID, merchantEmail)
+	if err != nil {
+		return err
+	}
+	for _, email := range recipients {
+		emailPayload := make(map[string]any, len(payload)+1)
+		for k, v := range payload {
+			emailPayload[k] = v
+		}
+		emailPayload["toEmail"] = email
+		if err := publishNotificationJob(ctx, qc, "email.merchant_stock_alert", emailPayload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockAlertRecipients returns the active owners' emails, falling back to the
+// merchant's contact email when no owner has one.
+func stockAlertRecipients(ctx context.Context, db *pgxpool.Pool, merchantID int64, merchantEmail *string) ([]string, error) {
+	rows, err := db.Query(ctx, `
+		select distinct lower(u.email)
+		from merchant_users mu
+		join users u on u.id = mu.user_id
+		where mu.merchant_id = $1 and mu.role = 'OWNER' and mu.is_active = true
+		  and u.is_active = true and coalesce(u.email, '') <> ''
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := make([]string, 0)
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 && merchantEmail != nil && strings.TrimSpace(*merchantEmail) != "" {
+		emails = append(emails, strings.TrimSpace(*merchantEmail))
+	}
+	return emails, nil
+}
+
+func publishNotificationJob(ctx context.Context, qc *Client, kind string, payload map[string]any) error {
+	payload["kind"] = kind
+	job := map[string]any{
+		"kind":      kind,
+		"payload":   payload,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"attempt":   1,
+	}
+	return qc.PublishJSON(ctx, NotificationJobsExchange, NotificationJobsRK, job)
+}
@@ -0,0 +1,88 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("spreadsheet: unsupported format")
+	ErrNoWorksheet       = errors.New("spreadsheet: workbook has no worksheet")
+)
+
+var zipMagic = []byte("PK\x03\x04")
+
+// DetectFormat picks the format from the file content, falling back to the
+// file extension. Anything that is not a zip archive is treated as CSV.
+func DetectFormat(filename string, data []byte) string {
+	if bytes.HasPrefix(data, zipMagic) {
+		return FormatXLSX
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		return FormatXLSX
+	}
+	return FormatCSV
+}
+
+// Read returns the rows of a CSV file or of the first worksheet of an XLSX
+// workbook. Rows may have different lengths.
+func Read(filename string, data []byte) ([][]string, error) {
+	switch DetectFormat(filename, data) {
+	case FormatXLSX:
+		return ReadXLSX(data)
+	default:
+		return ReadCSV(data)
+	}
+}
+
+// Write encodes rows in the given format.
+func Write(w io.Writer, format, sheetName string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, rows)
+	case FormatXLSX:
+		return WriteXLSX(w, sheetName, rows)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// ReadCSV parses comma or semicolon separated values. The delimiter is taken
+// from the header line, since spreadsheet apps in many locales export ';'.
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.Comma = detectDelimiter(data)
+	return reader.ReadAll()
+}
+
+func detectDelimiter(data []byte) rune {
+	firstLine := data
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+		firstLine = data[:idx]
+	}
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func WriteCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,86 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"name", "price", "description", "categories"},
+		{"Nasi <Goreng> & Co", "25000", "", "Rice|Mains"},
+		{"Es Teh", "8000.5", "Sweet \"iced\" tea", ""},
+		{"007 Special", "-3", "  spaced  ", "Promo"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, "Menu", rows); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if DetectFormat("menu.csv", buf.Bytes()) != FormatXLSX {
+		t.Fatalf("expected zip content to be detected as xlsx")
+	}
+
+	got, err := Read("menu.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := [][]string{
+		{"name", "price", "description", "categories"},
+		{"Nasi <Goreng> & Co", "25000", "", "Rice|Mains"},
+		{"Es Teh", "8000.5", "Sweet \"iced\" tea"},
+		{"007 Special", "-3", "  spaced  ", "Promo"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestReadCSVDelimiters(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{
+			name: "comma with bom",
+			data: "\xef\xbb\xbfname,price\n\"Soup, hot\",12\n",
+			want: [][]string{{"name", "price"}, {"Soup, hot", "12"}},
+		},
+		{
+			name: "semicolon",
+			data: "name;price;notes\nSoup;12,5;a,b\n",
+			want: [][]string{{"name", "price", "notes"}, {"Soup", "12,5", "a,b"}},
+		},
+		{
+			name: "ragged rows",
+			data: "name,price\nSoup\n",
+			want: [][]string{{"name", "price"}, {"Soup"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Read("menu.csv", []byte(tc.data))
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for idx, ref := range map[int]string{0: "A1", 25: "Z9", 26: "AA2", 27: "AB100", 701: "ZZ1"} {
+		got, ok := columnIndex(ref)
+		if !ok || got != idx {
+			t.Fatalf("%s: expected %d, got %d", ref, idx, got)
+		}
+		if name := columnName(idx); name+ref[len(name):] != ref {
+			t.Fatalf("%d: expected column %s, got %s", idx, ref, name)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+	placeholderSampleW  = 32
+	blurHashAlphabet    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// ImagePlaceholder is what clients paint while the real image loads.
+type ImagePlaceholder struct {
+	BlurHash      string `json:"blurHash"`
+	DominantColor string `json:"dominantColor"`
+}
+
+// ComputePlaceholder returns a BlurHash and the dominant colour of img, both
+// computed on a small downscaled copy.
+func ComputePlaceholder(img image.Image) ImagePlaceholder {
+	if img.Bounds().Dx() <= 0 || img.Bounds().Dy() <= 0 {
+		return ImagePlaceholder{}
+	}
+	small := imaging.Resize(img, placeholderSampleW, 0, imaging.Box)
+	return ImagePlaceholder{
+		BlurHash:      encodeBlurHash(small, blurHashComponentsX, blurHashComponentsY),
+		DominantColor: dominantColor(small),
+	}
+}
+
+// encodeBlurHash implements the reference BlurHash encoder
+// (https://github.com/woltapp/blurhash).
+func encodeBlurHash(img *image.NRGBA, cx, cy int) string {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	factors := make([][3]float64, 0, cx*cy)
+	for j := 0; j < cy; j++ {
+		for i := 0; i < cx; i++ {
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1
+			}
+			var r, g, b float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := norm * math.Cos(math.Pi*float64(i*x)/float64(w)) * math.Cos(math.Pi*float64(j*y)/float64(h))
+					p := img.Pix[y*img.Stride+x*4:]
+					r += basis * srgbToLinear(p[0])
+					g += basis * srgbToLinear(p[1])
+					b += basis * srgbToLinear(p[2])
+				}
+			}
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base83((cx-1)+(cy-1)*9, 1))
+
+	maxValue := 1.0
+	if len(factors) > 1 {
+		actualMax := 0.0
+		for _, f := range factors[1:] {
+			for _, v := range f {
+				actualMax = math.Max(actualMax, math.Abs(v))
+			}
+		}
+		quantised := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantised+1) / 166
+		sb.WriteString(base83(quantised, 1))
+	} else {
+		sb.WriteString(base83(0, 1))
+	}
+
+	dc := factors[0]
+	sb.WriteString(base83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, f := range factors[1:] {
+		q := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		sb.WriteString(base83(q(f[0])*19*19+q(f[1])*19+q(f[2]), 2))
+	}
+	return sb.String()
+}
+
+func base83(value, length int) string {
+	out := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		out[i-1] = blurHashAlphabet[digit]
+	}
+	return string(out)
+}
+
+func srgbToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+// dominantColor buckets opaque pixels at 4 bits per channel and returns the
+// average colour of the fullest bucket as #rrggbb.
+func dominantColor(img *image.NRGBA) string {
+	type bucket struct{ n, r, g, b int }
+	buckets := make(map[int]*bucket)
+	var best *bucket
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			if p[3] < 128 {
+				continue
+			}
+			key := int(p[0]>>4)<<8 | int(p[1]>>4)<<4 | int(p[2]>>4)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.n++
+			bk.r += int(p[0])
+			bk.g += int(p[1])
+			bk.b += int(p[2])
+			if best == nil || bk.n > best.n {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func fillNRGBA(w, h int, at func(x, y int) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, at(x, y))
+		}
+	}
+	return img
+}
+
+func TestBase83(t *testing.T) {
+	cases := []struct {
+		value, length int
+		want          string
+	}{
+		{value: 0, length: 1, want: "0"},
+		{value: 21, length: 1, want: "L"},
+		{value: 82, length: 1, want: "~"},
+		{value: 83, length: 2, want: "10"},
+		{value: 3429, length: 2, want: "fQ"},
+		{value: 0, length: 4, want: "0000"},
+		{value: 0xffffff, length: 4, want: "TSUA"},
+	}
+	for _, tc := range cases {
+		if got := base83(tc.value, tc.length); got != tc.want {
+			t.Errorf("base83(%d, %d): expected %q, got %q", tc.value, tc.length, tc.want, got)
+		}
+	}
+}
+
+// decodeBlurHash reverses encodeBlurHash into the DC colour and the linear AC
+// factors, following the reference decoder.
+func decodeBlurHash(t *testing.T, hash string) (cx, cy, dc int, ac [][3]float64) {
+	t.Helper()
+	decode := func(s string) int {
+		v := 0
+		for _, c := range s {
+			i := strings.IndexRune(blurHashAlphabet, c)
+			if i < 0 {
+				t.Fatalf("invalid base83 character %q in %q", c, hash)
+			}
+			v = v*83 + i
+		}
+		return v
+	}
+	size := decode(hash[:1])
+	cx, cy = size%9+1, size/9+1
+	if len(hash) != 4+2*cx*cy {
+		t.Fatalf("expected %d characters for %dx%d components, got %d (%q)", 4+2*cx*cy, cx, cy, len(hash), hash)
+	}
+	maxValue := float64(decode(hash[1:2])+1) / 166
+	dc = decode(hash[2:6])
+	for i := 6; i < len(hash); i += 2 {
+		v := decode(hash[i : i+2])
+		q := func(n int) float64 { return signPow(float64(n-9)/9, 2) * maxValue }
+		ac = append(ac, [3]float64{q(v / (19 * 19)), q(v / 19 % 19), q(v % 19)})
+	}
+	return cx, cy, dc, ac
+}
+
+func TestEncodeBlurHash(t *testing.T) {
+	// The reference encoder samples cos(pi*i*x/w) for x in [0, w), so on a
+	// flat image the sum over a row is 1 for odd i and 0 for even i. Every
+	// AC factor of a flat white 8x6 image is therefore norm 2 times 1/8 for
+	// odd i (1 for i = 0) times 1/6 for odd j (1 for j = 0).
+	white := fillNRGBA(8, 6, func(int, int) color.NRGBA { return color.NRGBA{R: 255, G: 255, B: 255, A: 255} })
+	hash := encodeBlurHash(white, 4, 3)
+	cx, cy, dc, ac := decodeBlurHash(t, hash)
+	if cx != 4 || cy != 3 || dc != 0xffffff {
+		t.Fatalf("expected 4x3 components and white DC, got %dx%d #%06x in %q", cx, cy, dc, hash)
+	}
+	sum := func(i, n int) float64 {
+		switch {
+		case i == 0:
+			return 1
+		case i%2 == 1:
+			return 1 / float64(n)
+		default:
+			return 0
+		}
+	}
+	for k, f := range ac {
+		i, j := (k+1)%cx, (k+1)/cx
+		want := 2 * sum(i, 8) * sum(j, 6)
+		for c, v := range f {
+			if d := v - want; d < -0.02 || d > 0.02 {
+				t.Errorf("factor (%d,%d) channel %d: expected %.4f, got %.4f in %q", i, j, c, want, v, hash)
+			}
+		}
+	}
+
+	if got := encodeBlurHash(white, 1, 1); got != "00TSUA" {
+		t.Errorf("flat white with one component: expected %q, got %q", "00TSUA", got)
+	}
+
+	// A left-to-right ramp correlates negatively with the first horizontal
+	// cosine, which starts at 1 and ends at -1.
+	gradient := fillNRGBA(32, 24, func(x, _ int) color.NRGBA {
+		v := uint8(x * 255 / 31)
+		return color.NRGBA{R: v, G: v, B: v, A: 255}
+	})
+	_, _, dc, ac = decodeBlurHash(t, encodeBlurHash(gradient, 4, 3))
+	if r, g, b := dc>>16, dc>>8&0xff, dc&0xff; r != g || g != b || r < 96 || r > 224 {
+		t.Errorf("expected a mid grey DC, got #%06x", dc)
+	}
+	for c, v := range ac[0] {
+		if v > -0.1 {
+			t.Errorf("channel %d: expected a strongly negative horizontal factor, got %.4f", c, v)
+		}
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	// Three quarters red (two shades in the same bucket), one quarter blue.
+	img := fillNRGBA(4, 4, func(x, y int) color.NRGBA {
+		switch {
+		case y == 3:
+			return color.NRGBA{B: 255, A: 255}
+		case x%2 == 0:
+			return color.NRGBA{R: 240, G: 16, B: 16, A: 255}
+		default:
+			return color.NRGBA{R: 250, G: 20, B: 20, A: 255}
+		}
+	})
+	if got := dominantColor(img); got != "#f51212" {
+		t.Errorf("expected #f51212, got %q", got)
+	}
+
+	// Transparent pixels are ignored even when they are the majority.
+	mostlyClear := fillNRGBA(4, 4, func(x, y int) color.NRGBA {
+		if x == 0 && y == 0 {
+			return color.NRGBA{G: 128, A: 255}
+		}
+		return color.NRGBA{R: 255, A: 10}
+	})
+	if got := dominantColor(mostlyClear); got != "#008000" {
+		t.Errorf("expected #008000, got %q", got)
+	}
+
+	transparent := fillNRGBA(2, 2, func(int, int) color.NRGBA { return color.NRGBA{R: 255} })
+	if got := dominantColor(transparent); got != "" {
+		t.Errorf("expected no colour for a transparent image, got %q", got)
+	}
+}
+
+func TestComputePlaceholder(t *testing.T) {
+	if got := ComputePlaceholder(image.NewNRGBA(image.Rect(0, 0, 0, 0))); got != (ImagePlaceholder{}) {
+		t.Errorf("expected an empty placeholder for an empty image, got %+v", got)
+	}
+
+	// Large, non-NRGBA and offset bounds all go through the downscale.
+	src := image.NewRGBA(image.Rect(10, 20, 410, 320))
+	for y := 20; y < 320; y++ {
+		for x := 10; x < 410; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: 30, G: 120, B: 200, A: 255})
+		}
+	}
+	got := ComputePlaceholder(src)
+	if got.DominantColor != "#1e78c8" {
+		t.Errorf("expected #1e78c8, got %q", got.DominantColor)
+	}
+	if cx, cy, dc, _ := decodeBlurHash(t, got.BlurHash); cx != 4 || cy != 3 || dc != 30<<16|120<<8|200 {
+		t.Errorf("expected 4x3 components and DC #1e78c8, got %dx%d #%06x", cx, cy, dc)
+	}
+
+	tiny := ComputePlaceholder(fillNRGBA(1, 1, func(int, int) color.NRGBA { return color.NRGBA{R: 255, G: 255, B: 255, A: 255} }))
+	if tiny.DominantColor != "#ffffff" || len(tiny.BlurHash) != 28 {
+		t.Errorf("expected a full placeholder for a 1x1 image, got %+v", tiny)
+	}
+}
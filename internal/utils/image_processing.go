@@ -3,7 +3,10 @@ package utils
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
 	"net/http"
 	"strings"
@@ -119,10 +122,11 @@ func EncodeJpegFitInside(data []byte, maxSide int, quality int) ([]byte, ImageSo
 	if maxSide <= 0 {
 		return nil, ImageSourceMeta{}, errors.New("maxSide must be > 0")
 	}
-	img, format, err := decodeAndAutoRotate(data)
+	decoded, format, err := decodeAndAutoRotate(data)
 	if err != nil {
 		return nil, ImageSourceMeta{}, err
 	}
+	img := flattenOnWhite(decoded)
 
 	b := img.Bounds()
 	w := b.Dx()
@@ -146,10 +150,11 @@ func EncodeJpegCoverSquare(data []byte, size int, quality int) ([]byte, ImageSou
 	if size <= 0 {
 		return nil, ImageSourceMeta{}, errors.New("size must be > 0")
 	}
-	img, format, err := decodeAndAutoRotate(data)
+	decoded, format, err := decodeAndAutoRotate(data)
 	if err != nil {
 		return nil, ImageSourceMeta{}, err
 	}
+	img := flattenOnWhite(decoded)
 
 	b := img.Bounds()
 	w := b.Dx()
@@ -170,10 +175,11 @@ func EncodeJpegCoverSquare(data []byte, size int, quality int) ([]byte, ImageSou
 }
 
 func EncodeJpegOriginal(data []byte, quality int) ([]byte, ImageSourceMeta, error) {
-	img, format, err := decodeAndAutoRotate(data)
+	decoded, format, err := decodeAndAutoRotate(data)
 	if err != nil {
 		return nil, ImageSourceMeta{}, err
 	}
+	img := flattenOnWhite(decoded)
 
 	b := img.Bounds()
 	w := b.Dx()
@@ -191,6 +197,115 @@ func EncodeJpegOriginal(data []byte, quality int) ([]byte, ImageSourceMeta, erro
 	return buf.Bytes(), meta, nil
 }
 
+// ImageVariantSpec requests one rendition size. Square variants are cropped
+// to Width x Width around the centre; the others keep the aspect ratio and
+// are never enlarged past the source width. Every size is encoded as WebP;
+// JPEG also stores a JPEG copy for clients that need one.
+type ImageVariantSpec struct {
+	Name   string
+	Width  int
+	Square bool
+	JPEG   bool
+}
+
+type ImageRendition struct {
+	Name        string
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+type ImageVariants struct {
+	Source      ImageSourceMeta
+	Placeholder ImagePlaceholder
+	Renditions  []ImageRendition
+}
+
+// EncodeImageVariants decodes data once and encodes every spec, in spec order,
+// together with a blur placeholder. Transparent pixels are composited onto
+// white. Builds without WebP support only produce the JPEG renditions.
+func EncodeImageVariants(data []byte, specs []ImageVariantSpec, quality int) (ImageVariants, error) {
+	decoded, format, err := decodeAndAutoRotate(data)
+	if err != nil {
+		return ImageVariants{}, err
+	}
+	img := flattenOnWhite(decoded)
+
+	b := img.Bounds()
+	w := b.Dx()
+	h := b.Dy()
+	out := ImageVariants{
+		Source: ImageSourceMeta{
+			Width:  &w,
+			Height: &h,
+			Format: ptrString(format),
+		},
+		Placeholder: ComputePlaceholder(img),
+	}
+
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if spec.Width <= 0 {
+			return ImageVariants{}, errors.New("variant width must be > 0")
+		}
+		var resized image.Image
+		if spec.Square {
+			resized = imaging.Fill(img, spec.Width, spec.Width, imaging.Center, imaging.Lanczos)
+		} else if spec.Width >= w {
+			resized = img
+		} else {
+			resized = imaging.Resize(img, spec.Width, 0, imaging.Lanczos)
+		}
+		rw, rh := resized.Bounds().Dx(), resized.Bounds().Dy()
+		key := fmt.Sprintf("%s-%d", spec.Name, rw)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if webpSupported {
+			webpData, err := EncodeWebP(resized, quality)
+			if err != nil {
+				return ImageVariants{}, err
+			}
+			out.Renditions = append(out.Renditions,
+				ImageRendition{Name: spec.Name, Format: "webp", ContentType: "image/webp", Width: rw, Height: rh, Data: webpData})
+		}
+		if spec.JPEG {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: quality}); err != nil {
+				return ImageVariants{}, err
+			}
+			out.Renditions = append(out.Renditions,
+				ImageRendition{Name: spec.Name, Format: "jpeg", ContentType: "image/jpeg", Width: rw, Height: rh, Data: buf.Bytes()})
+		}
+	}
+	return out, nil
+}
+
+// flattenOnWhite composites img onto an opaque white background. Opaque
+// images, such as decoded JPEGs, are returned as is.
+func flattenOnWhite(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	return compositeOnWhite(img)
+}
+
+// compositeOnWhite returns img as an opaque RGBA image drawn over white.
+func compositeOnWhite(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Opaque() {
+		return rgba
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Over)
+	return out
+}
+
 func ptrString(v string) *string {
 	vv := strings.TrimSpace(v)
 	if vv == "" {
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestEncodeImageVariants(t *testing.T) {
+	// A 400x200 PNG whose left half is fully transparent.
+	var src bytes.Buffer
+	if err := png.Encode(&src, fillNRGBA(400, 200, func(x, _ int) color.NRGBA {
+		if x < 200 {
+			return color.NRGBA{}
+		}
+		return color.NRGBA{R: 20, G: 120, B: 60, A: 255}
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	variants, err := EncodeImageVariants(src.Bytes(), []ImageVariantSpec{
+		{Name: "full", Width: 200},
+		{Name: "full", Width: 960},
+		{Name: "thumb", Width: 100, Square: true, JPEG: true},
+	}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type rendition struct {
+		name, format  string
+		width, height int
+	}
+	var want []rendition
+	if webpSupported {
+		want = []rendition{
+			{"full", "webp", 200, 100},
+			{"full", "webp", 400, 200},
+			{"thumb", "webp", 100, 100},
+			{"thumb", "jpeg", 100, 100},
+		}
+	} else {
+		want = []rendition{{"thumb", "jpeg", 100, 100}}
+	}
+	if len(variants.Renditions) != len(want) {
+		t.Fatalf("expected %d renditions, got %d", len(want), len(variants.Renditions))
+	}
+	for i, r := range variants.Renditions {
+		if got := (rendition{r.Name, r.Format, r.Width, r.Height}); got != want[i] {
+			t.Errorf("rendition %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+
+	thumb := variants.Renditions[len(variants.Renditions)-1]
+	img, err := jpeg.Decode(bytes.NewReader(thumb.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(5, 50).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("expected transparent pixels on white, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+}
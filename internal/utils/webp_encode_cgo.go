@@ -0,0 +1,31 @@
+//go:build cgo
+
+package utils
+
+import (
+	"errors"
+	"image"
+
+	"github.com/chai2010/webp"
+)
+
+// webpSupported reports whether this build can encode WebP renditions.
+const webpSupported = true
+
+// webpMaxDimension is libwebp's WEBP_MAX_DIMENSION.
+const webpMaxDimension = 16383
+
+// EncodeWebP encodes img as a lossy WebP with libwebp. quality runs from 1
+// (smallest) to 100 (best). Transparent pixels are composited onto white, as
+// in the JPEG renditions.
+func EncodeWebP(img image.Image, quality int) ([]byte, error) {
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return nil, errors.New("image is empty")
+	}
+	if b.Dx() > webpMaxDimension || b.Dy() > webpMaxDimension {
+		return nil, errors.New("image is too large for WebP")
+	}
+	quality = min(max(quality, 1), 100)
+	return webp.EncodeRGBA(compositeOnWhite(img), float32(quality))
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package utils
+
+import (
+	"errors"
+	"image"
+)
+
+const webpSupported = false
+
+func EncodeWebP(_ image.Image, _ int) ([]byte, error) {
+	return nil, errors.New("webp encoding not supported in this build")
+}
@@ -0,0 +1,93 @@
+//go:build cgo
+
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// testPhoto draws smooth gradients around a hard-edged disc: easier to
+// compress than noise, but with enough detail to show quantization error.
+func testPhoto(w, h int) *image.NRGBA {
+	return fillNRGBA(w, h, func(x, y int) color.NRGBA {
+		r := uint8(x * 255 / max(w-1, 1))
+		g := uint8(y * 255 / max(h-1, 1))
+		b := uint8(128 + 100*math.Sin(float64(x+y)/9))
+		if dx, dy := x-w/2, y-h/2; dx*dx+dy*dy < w*h/16 {
+			r, g, b = 240, 200, 40
+		}
+		return color.NRGBA{R: r, G: g, B: b, A: 255}
+	})
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {7, 5}, {33, 17}, {257, 129}} {
+		data, err := EncodeWebP(testPhoto(size.X, size.Y), 80)
+		if err != nil {
+			t.Fatalf("%v: encode: %v", size, err)
+		}
+		got, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%v: decode: %v", size, err)
+		}
+		if b := got.Bounds(); b.Dx() != size.X || b.Dy() != size.Y {
+			t.Fatalf("%v: decoded as %dx%d", size, b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestEncodeWebPQualityShrinksOutput(t *testing.T) {
+	src := testPhoto(160, 120)
+	low, err := EncodeWebP(src, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	high, err := EncodeWebP(src, 95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low) >= len(high) {
+		t.Fatalf("expected quality 20 (%d bytes) to be smaller than quality 95 (%d bytes)", len(low), len(high))
+	}
+}
+
+func TestEncodeWebPFlattensTransparencyOnWhite(t *testing.T) {
+	src := fillNRGBA(16, 16, func(x, _ int) color.NRGBA {
+		if x < 8 {
+			return color.NRGBA{}
+		}
+		return color.NRGBA{A: 255}
+	})
+	data, err := EncodeWebP(src, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.(*image.NYCbCrA); ok {
+		t.Error("expected an opaque WebP without an alpha channel")
+	}
+	if r, _, _, _ := got.At(2, 8).RGBA(); r>>8 < 230 {
+		t.Errorf("expected transparent pixels to decode near white, got %d", r>>8)
+	}
+	if r, _, _, _ := got.At(13, 8).RGBA(); r>>8 > 24 {
+		t.Errorf("expected opaque black to decode near black, got %d", r>>8)
+	}
+}
+
+func TestEncodeWebPRejectsInvalidSizes(t *testing.T) {
+	if _, err := EncodeWebP(image.NewNRGBA(image.Rect(0, 0, 0, 10)), 80); err == nil {
+		t.Error("expected an empty image to be rejected")
+	}
+	if _, err := EncodeWebP(image.NewNRGBA(image.Rect(0, 0, webpMaxDimension+1, 1)), 80); err == nil {
+		t.Error("expected an oversized image to be rejected")
+	}
+}
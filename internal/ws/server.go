@@ -153,7 +153,7 @@ func (mr *merchantOrdersRealtime) fetchMerchantActiveOrders(ctx context.Context,
 		select
 		  o.id, o.merchant_id, o.customer_id, o.order_number, o.order_type, o.table_number,
 		  o.status, o.is_scheduled, o.scheduled_date, o.scheduled_time,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
+		  ` + handlers.EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount,
 		  o.delivery_distance_km, o.delivery_delivered_at,
 		  o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
 		  o.notes, o.admin_note, o.kitchen_notes, o.placed_at, o.updated_at, o.edited_at, o.edited_by_user_id,
@@ -161,6 +161,7 @@ func (mr *merchantOrdersRealtime) fetchMerchantActiveOrders(ctx context.Context,
 		  r.id, r.party_size, r.reservation_date, r.reservation_time, r.table_number, r.status,
 		  c.id, c.name, c.phone, c.email
 		from orders o
+		` + handlers.DeliveryProgressJoinSQL + `
 		left join payments p on p.order_id = o.id
 		left join reservations r on r.order_id = o.id
 		left join customers c on c.id = o.customer_id
@@ -368,6 +369,11 @@ func (mr *merchantOrdersRealtime) fetchMerchantActiveOrders(ctx context.Context,
 
 		orders = append(orders, order)
 	}
+	rows.Close()
+
+	if err := handlers.AttachCurbsidePickups(ctx, mr.db, orders); err != nil && mr.logger != nil {
+		mr.logger.Warn("merchant-orders curbside lookup failed", zap.Error(err))
+	}
 
 	return orders, true, nil
 }
@@ -385,7 +391,7 @@ func (mr *merchantOrdersRealtime) listenLoop(ctx context.Context) {
 			continue
 		}
 
-		_, err = conn.Exec(ctx, `listen orders_updates`)
+		_, err = conn.Exec(ctx, `listen orders_updates; listen merchant_order_events`)
 		if err != nil {
 			conn.Release()
 			if mr.logger != nil {
@@ -402,6 +408,10 @@ func (mr *merchantOrdersRealtime) listenLoop(ctx context.Context) {
 			if err != nil {
 				break
 			}
+			if n.Channel == "merchant_order_events" {
+				mr.broadcastEvent(n.Payload)
+				continue
+			}
 			merchantIDText := strings.TrimSpace(n.Payload)
 			if merchantIDText == "" {
 				continue
@@ -436,6 +446,20 @@ func (mr *merchantOrdersRealtime) listenLoop(ctx context.Context) {
 	}
 }
 
+// broadcastEvent forwards a typed event such as customer.arrived as
+// {"type": ..., "data": ...} to the merchant's subscribers.
+func (mr *merchantOrdersRealtime) broadcastEvent(payload string) {
+	var event struct {
+		MerchantID string          `json:"merchantId"`
+		Type       string          `json:"type"`
+		Data       json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(payload), &event); err != nil || event.MerchantID == "" || event.Type == "" {
+		return
+	}
+	mr.broadcast(event.MerchantID, map[string]any{"type": event.Type, "data": event.Data})
+}
+
 type customerDisplayRealtime struct {
 	db     *pgxpool.Pool
 	logger *zap.Logger
@@ -696,13 +720,14 @@ func (pr *publicOrderRealtime) fetchOrderDetail(ctx context.Context, orderNumber
 		  o.id, o.order_number, o.status, o.order_type, o.table_number,
 		  o.subtotal, o.tax_amount, o.service_charge_amount, o.packaging_fee, o.discount_amount, o.total_amount,
 		  o.created_at, o.updated_at, o.placed_at, o.completed_at,
-		  o.delivery_status, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
+		  ` + handlers.EffectiveDeliveryStatusSQL + `, o.delivery_unit, o.delivery_address, o.delivery_fee_amount, o.delivery_distance_km, o.delivery_delivered_at,
 		  o.edited_at,
 		  m.name, m.currency, m.code,
 		  c.name,
 		  p.status, p.payment_method, p.amount, p.paid_at, p.customer_paid_at, p.customer_proof_url, p.customer_proof_uploaded_at, p.customer_payment_note, p.customer_proof_meta,
 		  r.status, r.party_size, r.reservation_date, r.reservation_time, r.table_number
 		from orders o
+		` + handlers.DeliveryProgressJoinSQL + `
 		join merchants m on m.id = o.merchant_id
 		left join customers c on c.id = o.customer_id
 		left join payments p on p.order_id = o.id
@@ -804,6 +829,28 @@ func (pr *publicOrderRealtime) fetchOrderDetail(ctx context.Context, orderNumber
 	detail.Merchant.Currency = merchantCurrency
 	detail.Merchant.Code = merchantCode
 
+	if strings.EqualFold(detail.OrderType, "DELIVERY") {
+		route, err := handlers.FetchDeliveryRouteInfo(ctx, pr.db, detail.ID)
+		if err != nil && pr.logger != nil {
+			pr.logger.Warn("public-order route lookup failed", zap.Error(err))
+		}
+		detail.DeliveryRoute = route
+
+		eta, err := handlers.FetchDeliveryEta(ctx, pr.db, detail.ID, route)
+		if err != nil && pr.logger != nil {
+			pr.logger.Warn("public-order eta lookup failed", zap.Error(err))
+		}
+		detail.DeliveryEta = eta
+	}
+
+	if strings.EqualFold(detail.OrderType, "TAKEAWAY") {
+		curbside, err := handlers.FetchCurbsidePickup(ctx, pr.db, detail.ID)
+		if err != nil && pr.logger != nil {
+			pr.logger.Warn("public-order curbside lookup failed", zap.Error(err))
+		}
+		detail.Curbside = curbside
+	}
+
 	if pStatus.Valid || pMethod.Valid || pAmount.Valid || pPaidAt.Valid {
 		status := pStatus.String
 		method := pMethod.String
@@ -892,10 +939,11 @@ func (pr *publicOrderRealtime) fetchOrderDetail(ctx context.Context, orderNumber
 
 func (pr *publicOrderRealtime) fetchOrderItems(ctx context.Context, orderID int64) ([]handlers.OrderItem, error) {
 	query := `
-		select id, menu_name, menu_price, quantity, subtotal, notes
-		from order_items
-		where order_id = $1
-		order by id asc
+		select oi.id, oi.menu_name, oiv.variant_name, oi.menu_price, oi.quantity, oi.subtotal, oi.notes
+		from order_items oi
+		left join order_item_variants oiv on oiv.order_item_id = oi.id
+		where oi.order_id = $1
+		order by oi.id asc
 	`
 
 	rows, err := pr.db.Query(ctx, query, orderID)
@@ -912,7 +960,7 @@ func (pr *publicOrderRealtime) fetchOrderItems(ctx context.Context, orderID int6
 			menuPrice pgtype.Numeric
 			subtotal  pgtype.Numeric
 		)
-		if err := rows.Scan(&item.ID, &item.MenuName, &menuPrice, &item.Quantity, &subtotal, &item.Notes); err != nil {
+		if err := rows.Scan(&item.ID, &item.MenuName, &item.VariantName, &menuPrice, &item.Quantity, &subtotal, &item.Notes); err != nil {
 			return nil, err
 		}
 		item.MenuPrice = utils.NumericToFloat64(menuPrice)
@@ -36,6 +36,10 @@ func main() {
 	}
 	defer pool.Close()
 
+	if err := db.Migrate(ctx, pool); err != nil {
+		log.Fatal("database migration failed", zap.Error(err))
+	}
+
 	var queueClient *queue.Client
 	if cfg.RabbitMQURL != "" {
 		log.Info("rabbitmq enabled", zap.String("eventsQueue", "genfity.notifications"))
@@ -79,6 +83,16 @@ func main() {
 				qc = nil
 			}
 		}
+		if qc != nil {
+			if err := qc.BindQueue("genfity.notifications", "genfity.events", "menu.stock.#"); err != nil {
+				if cfg.Env == "production" {
+					log.Fatal("rabbitmq bind failed", zap.Error(err))
+				}
+				log.Warn("rabbitmq bind failed; continuing without worker", zap.Error(err))
+				_ = qc.Close()
+				qc = nil
+			}
+		}
 
 		if qc != nil {
 			if err := queue.EnsureNotificationJobsTopology(ctx, qc); err != nil {
@@ -127,9 +141,10 @@ func main() {
 	}
 
 	wsServer := ws.New(pool, log, cfg)
+	handler := httpapi.NewHandler(pool, log, cfg, queueClient)
 	apiServer := &http.Server{
 		Addr:         cfg.HTTPAddr,
-		Handler:      httpapi.NewRouter(pool, log, cfg, queueClient, wsServer),
+		Handler:      httpapi.NewRouter(handler, wsServer),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -144,13 +159,28 @@ func main() {
 		}
 	}()
 
+	// Sweepers and the menu cache listener stop with the process; only the
+	// replica holding the leader lock runs the sweepers.
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		handler.RunBackgroundJobs(jobsCtx)
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	stopJobs()
 	if err := apiServer.Shutdown(ctxShutdown); err != nil {
 		log.Error("http server shutdown failed", zap.Error(err))
 	}
+	select {
+	case <-jobsDone:
+	case <-ctxShutdown.Done():
+		log.Warn("background jobs did not stop before shutdown timeout")
+	}
 }